@@ -48,6 +48,10 @@ var (
 		"web.debug-server",
 		"Enable debug server (default: disabled).",
 	).Default("false").Bool()
+	requestsLimit = app.Flag(
+		"web.requests-limit",
+		"Maximum number of requests per minute from a given IP address. Zero disables rate limiting.",
+	).Default("0").Int()
 )
 
 type Target struct {
@@ -99,6 +103,7 @@ type WebConfig struct {
 	WebSystemdSocket  bool
 	WebConfigFile     string
 	EnableDebugServer bool
+	RequestsLimit     int
 }
 
 // Config makes a server config.
@@ -184,6 +189,7 @@ func main() {
 			WebSystemdSocket:  *systemdSocket,
 			WebConfigFile:     webConfigFilePath,
 			EnableDebugServer: *enableDebugServer,
+			RequestsLimit:     *requestsLimit,
 		},
 		Redfish: redfish,
 	}
@@ -193,7 +199,12 @@ func main() {
 	defer stop()
 
 	// Create a new proxy instance
-	server := NewRedfishProxyServer(config)
+	server, err := NewRedfishProxyServer(config)
+	if err != nil {
+		logger.Error("Failed to create Redfish proxy server", "err", err)
+
+		os.Exit(1)
+	}
 
 	// Initializing the server in a goroutine so that
 	// it won't block the graceful shutdown handling below.
@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/exporter-toolkit/web"
 )
 
@@ -22,7 +25,7 @@ type RedfishProxyServer struct {
 }
 
 // NewRedfishProxyServer creates new RedfishProxyServer struct instance.
-func NewRedfishProxyServer(c *Config) *RedfishProxyServer {
+func NewRedfishProxyServer(c *Config) (*RedfishProxyServer, error) {
 	router := mux.NewRouter()
 	server := &RedfishProxyServer{
 		logger:  c.Logger,
@@ -47,10 +50,24 @@ func NewRedfishProxyServer(c *Config) *RedfishProxyServer {
 		router.PathPrefix("/debug/").Handler(http.DefaultServeMux).Methods(http.MethodGet).Host("localhost")
 	}
 
-	// Handle metrics path
+	// Metrics of proxy itself
+	router.Handle("/metrics", promhttp.Handler())
+
+	// Proxy rest of the requests to Redfish targets
 	router.PathPrefix("/").Handler(server.newProxyHandler())
 
-	return server
+	metrics, err := middleware.NewMetrics("redfish_proxy", prometheus.DefaultRegisterer, middleware.RouteTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add common middlewares
+	router.Use(
+		mux.MiddlewareFunc(middleware.Logging(c.Logger)), metrics.Middleware,
+		mux.MiddlewareFunc(middleware.SecurityHeaders()), mux.MiddlewareFunc(middleware.RateLimit(c.Web.RequestsLimit)),
+	)
+
+	return server, nil
 }
 
 // Start launches CEEMS exporter HTTP server.
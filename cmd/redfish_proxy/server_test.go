@@ -76,7 +76,8 @@ func TestNewRedfishProxyServerWithTargets(t *testing.T) {
 	config.Web.Addresses = []string{":" + strconv.FormatInt(int64(p), 10)}
 
 	// New instance
-	server := NewRedfishProxyServer(config)
+	server, err := NewRedfishProxyServer(config)
+	require.NoError(t, err)
 
 	// Start server
 	go func() {
@@ -104,6 +105,35 @@ func TestNewRedfishProxyServerWithTargets(t *testing.T) {
 		// Check the body if it has same IP set
 		assert.EqualValues(t, strings.Join([]string{ip}, ","), string(bodyBytes))
 	}
+
+	// Metrics of proxied requests must be served
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/metrics", p)) //nolint:noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(bodyBytes), `redfish_proxy_http_requests_total{code="200",handler="/",method="GET"}`)
+}
+
+func TestRedfishProxyServerRateLimit(t *testing.T) {
+	config := &Config{
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Redfish: &Redfish{},
+		Web: WebConfig{
+			Addresses:     []string{":0"},
+			RequestsLimit: 1,
+		},
+	}
+
+	server, err := NewRedfishProxyServer(config)
+	require.NoError(t, err)
+
+	for _, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, code, w.Code)
+	}
 }
 
 func TestNewRedfishProxyServerWithWebConfig(t *testing.T) {
@@ -150,7 +180,8 @@ func TestNewRedfishProxyServerWithWebConfig(t *testing.T) {
 	config.Web.Addresses = []string{":" + strconv.FormatInt(int64(p), 10)}
 
 	// New instance
-	server := NewRedfishProxyServer(config)
+	server, err := NewRedfishProxyServer(config)
+	require.NoError(t, err)
 
 	// Start server
 	go func() {
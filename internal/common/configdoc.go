@@ -0,0 +1,286 @@
+package common
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlMarshalerType = reflect.TypeOf((*yaml.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	yamlNodeType      = reflect.TypeOf(yaml.Node{})
+)
+
+// FlagsSkeleton returns a YAML mapping of all the visible flags of app set
+// to their default values. Help text of each flag is added as a comment.
+func FlagsSkeleton(app *kingpin.Application) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, f := range app.Model().Flags {
+		if f.Hidden || f.Name == "help" || f.Name == "version" {
+			continue
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode, Value: strings.Join(f.Default, ",")}
+		if f.IsBoolFlag() {
+			value.Tag = "!!bool"
+			value.Value = "false"
+
+			if len(f.Default) > 0 {
+				value.Value = f.Default[0]
+			}
+		}
+
+		node.Content = append(
+			node.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: f.Name, HeadComment: f.Help},
+			value,
+		)
+	}
+
+	return &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "flags"}, node},
+	}
+}
+
+// ConfigSkeleton returns a YAML mapping of all the options of configs generated
+// from the yaml struct tags of their types. Each option is set to its default
+// value and annotated with its type. Defaults are the ones set by UnmarshalYAML
+// methods of config types. Top level options of all configs are merged into a
+// single mapping and comment is added at the head of the mapping.
+func ConfigSkeleton(comment string, configs ...any) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode, HeadComment: comment}
+
+	for _, c := range configs {
+		t := reflect.TypeOf(c)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("config must be a struct, got %s", t)
+		}
+
+		if err := structSkeleton(node, defaultValue(t), map[reflect.Type]bool{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return node, nil
+}
+
+// WriteSkeletons writes the skeletons to w as separate YAML documents.
+func WriteSkeletons(w io.Writer, nodes ...*yaml.Node) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	for _, node := range nodes {
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+	}
+
+	return encoder.Close()
+}
+
+// defaultValue returns the default value of type t by unmarshalling an empty
+// mapping into it so that the defaults set by UnmarshalYAML are applied.
+func defaultValue(t reflect.Type) reflect.Value {
+	v := reflect.New(t)
+
+	// Unmarshal errors are ignored as validation of empty configs can fail
+	if err := yaml.Unmarshal([]byte("{}"), v.Interface()); err != nil {
+		return reflect.New(t).Elem()
+	}
+
+	return v.Elem()
+}
+
+// structSkeleton appends the options of struct value v to node. Types in
+// visited are skipped to avoid infinite recursion on recursive types.
+func structSkeleton(node *yaml.Node, v reflect.Value, visited map[reflect.Type]bool) error {
+	t := v.Type()
+	if visited[t] {
+		return nil
+	}
+
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Type.Kind() == reflect.Func || field.Type.Kind() == reflect.Chan {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Options of inlined structs are at the same level as parent
+		if strings.Contains(opts, "inline") {
+			inlined := v.Field(i)
+			if inlined.Kind() == reflect.Pointer {
+				inlined = defaultValue(inlined.Type().Elem())
+			}
+
+			if inlined.Kind() == reflect.Struct {
+				if err := structSkeleton(node, inlined, visited); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		value, err := valueSkeleton(v.Field(i), visited)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		node.Content = append(node.Content, keyNode(name, value), value)
+	}
+
+	return nil
+}
+
+// keyNode returns the key node of value. Type comments of block collections
+// are moved to key as they are emitted after the collection otherwise.
+func keyNode(name string, value *yaml.Node) *yaml.Node {
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+
+	if value.Kind != yaml.ScalarNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 {
+		key.LineComment, value.LineComment = value.LineComment, ""
+	}
+
+	return key
+}
+
+// valueSkeleton returns the skeleton of value v.
+func valueSkeleton(v reflect.Value, visited map[reflect.Type]bool) (*yaml.Node, error) {
+	t := v.Type()
+
+	// Use defaults of element type for nil pointers
+	if t.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return valueSkeleton(defaultValue(t.Elem()), visited)
+		}
+
+		return valueSkeleton(v.Elem(), visited)
+	}
+
+	switch {
+	case t == yamlNodeType:
+		return &yaml.Node{Kind: yaml.MappingNode, Style: yaml.FlowStyle, LineComment: "any"}, nil
+	case isLeaf(t):
+		// Some types cannot marshal their zero values and they are shown empty
+		node := &yaml.Node{}
+		if err := node.Encode(v.Interface()); err != nil {
+			node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		}
+
+		node.LineComment = typeName(t)
+
+		return node, nil
+	case t.Kind() == reflect.Struct:
+		// Use defaults of struct type when parent does not set any
+		if v.IsZero() {
+			v = defaultValue(t)
+		}
+
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		if err := structSkeleton(node, v, visited); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case t.Kind() == reflect.Slice:
+		// Lists of structs are shown with a single element
+		node := &yaml.Node{Kind: yaml.SequenceNode, LineComment: typeName(t)}
+
+		elem, err := valueSkeleton(reflect.New(t.Elem()).Elem(), visited)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, elem)
+
+		return node, nil
+	default:
+		// Maps of structs are shown with a single placeholder key
+		node := &yaml.Node{Kind: yaml.MappingNode, LineComment: typeName(t)}
+
+		elem, err := valueSkeleton(reflect.New(t.Elem()).Elem(), visited)
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, keyNode("<"+typeName(t.Key())+">", elem), elem)
+
+		return node, nil
+	}
+}
+
+// isLeaf returns true if values of type t are rendered as they are.
+func isLeaf(t reflect.Type) bool {
+	if t.Implements(yamlMarshalerType) || reflect.PointerTo(t).Implements(yamlMarshalerType) ||
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return true
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Struct:
+		return false
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return isLeaf(t.Elem())
+	default:
+		return true
+	}
+}
+
+// typeName returns the human readable name of type t.
+func typeName(t reflect.Type) string {
+	switch t {
+	case reflect.TypeOf(model.Duration(0)), reflect.TypeOf(time.Duration(0)):
+		return "duration"
+	case reflect.TypeOf(config.Secret("")):
+		return "secret"
+	}
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem())
+	case reflect.Map:
+		return fmt.Sprintf("map of %s to %s", typeName(t.Key()), typeName(t.Elem()))
+	case reflect.Interface:
+		return "any"
+	default:
+		return strings.ToLower(t.Name())
+	}
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNestedConfig struct {
+	Interval model.Duration `yaml:"interval"`
+	Enabled  bool           `yaml:"enabled"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *mockNestedConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = mockNestedConfig{Interval: model.Duration(time.Minute)}
+
+	type plain mockNestedConfig
+
+	return unmarshal((*plain)(c))
+}
+
+type mockItemConfig struct {
+	Name string `yaml:"name"`
+}
+
+type mockSkeletonConfig struct {
+	Server struct {
+		Path     string                    `yaml:"path"`
+		Nested   mockNestedConfig          `yaml:"nested"`
+		Items    []mockItemConfig          `yaml:"items"`
+		Labels   map[string]string         `yaml:"labels"`
+		Named    map[string]mockItemConfig `yaml:"named"`
+		Internal string                    `yaml:"-"`
+		Inline   mockItemConfig            `yaml:",inline"`
+	} `yaml:"server"`
+}
+
+func TestConfigSkeleton(t *testing.T) {
+	app := kingpin.New("test", "test app")
+	app.Flag("web.listen-address", "Listen address.").Default(":9010").String()
+	app.Flag("web.debug", "Enable debug.").Default("false").Bool()
+
+	config, err := ConfigSkeleton("Config file of test app", mockSkeletonConfig{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSkeletons(&buf, FlagsSkeleton(app), config))
+
+	expected := `flags:
+  # Listen address.
+  web.listen-address: :9010
+  # Enable debug.
+  web.debug: false
+---
+# Config file of test app
+server:
+  path: "" # string
+  nested:
+    interval: 1m # duration
+    enabled: false # boolean
+  items: # list of mockitemconfig
+    - name: "" # string
+  labels: {} # map of string to string
+  named: # map of string to mockitemconfig
+    <string>:
+      name: "" # string
+  name: "" # string
+`
+	assert.Equal(t, expected, buf.String())
+
+	// Only structs are allowed
+	_, err = ConfigSkeleton("", "foo")
+	require.Error(t, err)
+}
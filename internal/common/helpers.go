@@ -8,17 +8,33 @@ import (
 	"log/slog"
 	"math"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
 	"github.com/zeebo/xxh3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"gopkg.in/yaml.v3"
 )
 
+// These functions are nicked from https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go
+var (
+	// MinTime is the default timestamp used for the begin of optional time ranges.
+	MinTime = time.Unix(math.MinInt64/1000+62135596801, 0).UTC()
+
+	// MaxTime is the default timestamp used for the end of optional time ranges.
+	MaxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999).UTC()
+
+	minTimeFormatted = MinTime.Format(time.RFC3339Nano)
+	maxTimeFormatted = MaxTime.Format(time.RFC3339Nano)
+)
+
 // GenerateKey generates a reproducible key from a given URL string.
 func GenerateKey(url string) uint64 {
 	hash := fnv.New64a()
@@ -216,3 +232,87 @@ func ComputeExternalURL(u, listenAddr string) (*url.URL, error) {
 
 	return eu, nil
 }
+
+// ParseTime converts time parameter string into time.Time. It supports unix
+// timestamps in seconds, either integer or float, and RFC3339 formatted times.
+func ParseTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		s, ns := math.Modf(t)
+		ns = math.Round(ns*1000) / 1000
+
+		return time.Unix(int64(s), int64(ns*float64(time.Second))).UTC(), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+
+	// Stdlib's time parser can only handle 4 digit years. As a workaround until
+	// that is fixed we want to at least support our own boundary times.
+	// Context: https://github.com/prometheus/client_golang/issues/614
+	// Upstream issue: https://github.com/golang/go/issues/20555
+	switch s {
+	case minTimeFormatted:
+		return MinTime, nil
+	case maxTimeFormatted:
+		return MaxTime, nil
+	}
+
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// ConfigureHTTPServer applies connections config to the server. HTTP/2 is
+// always offered on TLS connections and, when enabled, on cleartext connections
+// as well (h2c). Requests from addresses that are not allowed are forbidden.
+// It must be called after setting the handler of the server.
+func ConfigureHTTPServer(server *http.Server, c ConnectionsConfig) error {
+	server.SetKeepAlivesEnabled(!c.DisableKeepAlives)
+
+	var err error
+	if server.Handler, err = AllowListHandler(server.Handler, c.AllowedCIDRs); err != nil {
+		return fmt.Errorf("failed to configure allowed CIDRs: %w", err)
+	}
+
+	if c.IdleTimeout > 0 {
+		server.IdleTimeout = time.Duration(c.IdleTimeout)
+	}
+
+	h2Server := &http2.Server{
+		MaxConcurrentStreams: c.MaxConcurrentStreams,
+		IdleTimeout:          time.Duration(c.IdleTimeout),
+	}
+
+	if err := http2.ConfigureServer(server, h2Server); err != nil {
+		return fmt.Errorf("failed to configure HTTP/2: %w", err)
+	}
+
+	if c.H2C {
+		server.Handler = h2c.NewHandler(server.Handler, h2Server)
+	}
+
+	return nil
+}
+
+// NewHTTPTransport returns a new transport based on default transport with
+// connections config applied. HTTP/2 is attempted on TLS connections.
+func NewHTTPTransport(c ConnectionsConfig) *http.Transport {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{}
+	}
+
+	transport = transport.Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.DisableKeepAlives = c.DisableKeepAlives
+
+	if c.IdleTimeout > 0 {
+		transport.IdleConnTimeout = time.Duration(c.IdleTimeout)
+	}
+
+	if c.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+		transport.MaxIdleConns = max(transport.MaxIdleConns, c.MaxIdleConnsPerHost)
+	}
+
+	return transport
+}
@@ -2,21 +2,26 @@ package common
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"io"
 	"log/slog"
 	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
 	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
 )
 
 type mockConfig struct {
@@ -295,3 +300,129 @@ func TestComputeExternalURL(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTime(t *testing.T) {
+	ts, err := time.Parse(time.RFC3339Nano, "2015-06-03T13:21:58.555Z")
+	require.NoError(t, err)
+
+	tests := []struct {
+		input  string
+		fail   bool
+		result time.Time
+	}{
+		{
+			input: "",
+			fail:  true,
+		},
+		{
+			input: "abc",
+			fail:  true,
+		},
+		{
+			input: "30s",
+			fail:  true,
+		},
+		{
+			input:  "123",
+			result: time.Unix(123, 0),
+		},
+		{
+			input:  "123.123",
+			result: time.Unix(123, 123000000),
+		},
+		{
+			input:  "2015-06-03T13:21:58.555Z",
+			result: ts,
+		},
+		{
+			input:  "2015-06-03T14:21:58.555+01:00",
+			result: ts,
+		},
+		{
+			// Test float rounding.
+			input:  "1543578564.705",
+			result: time.Unix(1543578564, 705*1e6),
+		},
+		{
+			input:  MinTime.Format(time.RFC3339Nano),
+			result: MinTime,
+		},
+		{
+			input:  MaxTime.Format(time.RFC3339Nano),
+			result: MaxTime,
+		},
+	}
+
+	for _, test := range tests {
+		ts, err := ParseTime(test.input)
+		if !test.fail {
+			require.NoError(t, err)
+			// assert.Equal(t, test.result, ts)
+			if !ts.Equal(test.result) {
+				t.Errorf("%s: expected %s, got %s", test.input, test.result, ts)
+			}
+
+			continue
+		}
+
+		assert.Error(t, err)
+	}
+}
+
+func TestConfigureHTTPServer(t *testing.T) {
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.Proto))
+		}),
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+
+	err := ConfigureHTTPServer(server, ConnectionsConfig{
+		IdleTimeout:          model.Duration(time.Minute),
+		MaxConcurrentStreams: 10,
+		H2C:                  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, server.IdleTimeout)
+
+	// Serve using cleartext and make request with HTTP/2 prior knowledge
+	ts := httptest.NewServer(server.Handler)
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		},
+	}
+
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", string(body))
+}
+
+func TestNewHTTPTransport(t *testing.T) {
+	transport := NewHTTPTransport(ConnectionsConfig{
+		DisableKeepAlives:   true,
+		IdleTimeout:         model.Duration(time.Minute),
+		MaxIdleConnsPerHost: 200,
+	})
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, 200, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 200, transport.MaxIdleConns)
+
+	// Default transport must not be modified
+	assert.False(t, http.DefaultTransport.(*http.Transport).DisableKeepAlives)
+
+	// Negative values must be rejected
+	c := ConnectionsConfig{MaxIdleConnsPerHost: -1}
+	require.Error(t, c.Validate())
+}
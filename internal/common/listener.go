@@ -0,0 +1,136 @@
+package common
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/activation"
+	"github.com/pires/go-proxyproto"
+	"github.com/prometheus/exporter-toolkit/web"
+)
+
+// proxyHeaderTimeout is the maximum time to wait for PROXY header on new connections.
+const proxyHeaderTimeout = 2 * time.Second
+
+// ListenAndServe starts the server on the listeners configured in flags. When
+// PROXY protocol is enabled in connections config, listeners read the PROXY
+// header sent by upstream proxies so that the remote address of requests is
+// the address of the real client.
+func ListenAndServe(server *http.Server, flags *web.FlagConfig, c ConnectionsConfig, logger *slog.Logger) error {
+	if !c.ProxyProtocol.Enabled {
+		return web.ListenAndServe(server, flags, logger)
+	}
+
+	// Only trusted proxies are allowed to send PROXY header when they are configured
+	var policy proxyproto.PolicyFunc
+
+	if len(c.ProxyProtocol.TrustedCIDRs) > 0 {
+		var err error
+		if policy, err = proxyproto.LaxWhiteListPolicy(c.ProxyProtocol.TrustedCIDRs); err != nil {
+			return err
+		}
+	}
+
+	listeners, err := listen(flags, logger)
+	if err != nil {
+		return err
+	}
+
+	for i, listener := range listeners {
+		defer listener.Close()
+
+		listeners[i] = &proxyproto.Listener{
+			Listener:          listener,
+			Policy:            policy,
+			ReadHeaderTimeout: proxyHeaderTimeout,
+		}
+	}
+
+	logger.Info("PROXY protocol is enabled on listeners")
+
+	return web.ServeMultiple(listeners, server, flags, logger)
+}
+
+// listen returns the listeners configured in flags.
+func listen(flags *web.FlagConfig, logger *slog.Logger) ([]net.Listener, error) {
+	if flags.WebSystemdSocket != nil && *flags.WebSystemdSocket {
+		logger.Info("Listening on systemd activated listeners instead of port listeners.")
+
+		listeners, err := activation.Listeners()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(listeners) < 1 {
+			return nil, errors.New("no socket activation file descriptors found")
+		}
+
+		return listeners, nil
+	}
+
+	if flags.WebListenAddresses == nil || len(*flags.WebListenAddresses) == 0 {
+		return nil, web.ErrNoListeners
+	}
+
+	listeners := make([]net.Listener, 0, len(*flags.WebListenAddresses))
+
+	for _, address := range *flags.WebListenAddresses {
+		if strings.HasPrefix(address, "vsock://") {
+			return nil, errors.New("PROXY protocol is not supported on vsock listeners")
+		}
+
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+
+			return nil, err
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// AllowListHandler returns a handler that forbids requests from remote addresses
+// that are not in any of the allowed CIDRs. When no CIDRs are provided, handler
+// is returned as it is.
+func AllowListHandler(handler http.Handler, cidrs []string) (http.Handler, error) {
+	if len(cidrs) == 0 {
+		return handler, nil
+	}
+
+	nets := make([]*net.IPNet, len(cidrs))
+
+	for i, cidr := range cidrs {
+		var err error
+		if _, nets[i], err = net.ParseCIDR(cidr); err != nil {
+			return nil, err
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if ip := net.ParseIP(host); ip != nil {
+			for _, n := range nets {
+				if n.Contains(ip) {
+					handler.ServeHTTP(w, r)
+
+					return
+				}
+			}
+		}
+
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+	}), nil
+}
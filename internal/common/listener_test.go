@@ -0,0 +1,98 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/exporter-toolkit/web"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowListHandler(t *testing.T) {
+	handler, err := AllowListHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), []string{"10.0.0.0/8", "::1/128"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		remoteAddr string
+		code       int
+	}{
+		{remoteAddr: "10.1.2.3:4567", code: http.StatusOK},
+		{remoteAddr: "[::1]:4567", code: http.StatusOK},
+		{remoteAddr: "192.168.1.1:4567", code: http.StatusForbidden},
+		{remoteAddr: "malformed", code: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = test.remoteAddr
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, test.code, w.Code, test.remoteAddr)
+	}
+
+	// Invalid CIDR
+	_, err = AllowListHandler(handler, []string{"10.0.0.0"})
+	require.Error(t, err)
+}
+
+func TestListenAndServeProxyProtocol(t *testing.T) {
+	port, l, err := GetFreePort()
+	require.NoError(t, err)
+	l.Close()
+
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	systemdSocket := false
+	configFile := ""
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.RemoteAddr))
+		}),
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+	defer server.Shutdown(context.Background())
+
+	go func() {
+		ListenAndServe(server, &web.FlagConfig{
+			WebListenAddresses: &[]string{address},
+			WebSystemdSocket:   &systemdSocket,
+			WebConfigFile:      &configFile,
+		}, ConnectionsConfig{
+			ProxyProtocol: ProxyProtocolConfig{Enabled: true, TrustedCIDRs: []string{"127.0.0.0/8"}},
+		}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+
+	// Wait for server to start
+	var conn net.Conn
+
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("tcp", address)
+
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+	defer conn.Close()
+
+	// Send PROXY header followed by request
+	fmt.Fprintf(conn, "PROXY TCP4 192.168.1.10 127.0.0.1 56324 %d\r\n", port)
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", address)
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.10:56324", string(body))
+}
@@ -1,12 +1,53 @@
 package common
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
 	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
 )
 
+var errNegativeConnections = errors.New("connection limits must not be negative")
+
 // GrafanaWebConfig makes HTTP Grafana config.
 type GrafanaWebConfig struct {
 	URL              string                       `yaml:"url"`
 	TeamsIDs         []string                     `yaml:"teams_ids"`
 	HTTPClientConfig config_util.HTTPClientConfig `yaml:",inline"`
 }
+
+// ConnectionsConfig contains the HTTP/2 and keep-alive tuning parameters of
+// HTTP servers and the transports of reverse proxies.
+type ConnectionsConfig struct {
+	DisableKeepAlives    bool                `yaml:"disable_keep_alives"`
+	IdleTimeout          model.Duration      `yaml:"idle_timeout"`
+	MaxIdleConnsPerHost  int                 `yaml:"max_idle_connections_per_host"`
+	MaxConcurrentStreams uint32              `yaml:"max_concurrent_streams"`
+	H2C                  bool                `yaml:"h2c"`
+	AllowedCIDRs         []string            `yaml:"allowed_cidrs"`
+	ProxyProtocol        ProxyProtocolConfig `yaml:"proxy_protocol"`
+}
+
+// ProxyProtocolConfig contains the PROXY protocol configuration of listeners.
+type ProxyProtocolConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	TrustedCIDRs []string `yaml:"trusted_cidrs"`
+}
+
+// Validate validates the config.
+func (c *ConnectionsConfig) Validate() error {
+	if c.IdleTimeout < 0 || c.MaxIdleConnsPerHost < 0 {
+		return errNegativeConnections
+	}
+
+	for _, cidr := range slices.Concat(c.AllowedCIDRs, c.ProxyProtocol.TrustedCIDRs) {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("invalid CIDR %s: %w", cidr, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Supported content encodings in the order of preference.
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+var (
+	gzipPool = sync.Pool{
+		New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+
+			return w
+		},
+	}
+	flatePool = sync.Pool{
+		New: func() any {
+			w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+
+			return w
+		},
+	}
+)
+
+// compressor is the interface implemented by both gzip and flate writers.
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressWriter compresses the response body using the negotiated encoding.
+// Compression is skipped when handler sets the Content-Encoding header itself
+// or when the response has no body.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	compressor  compressor
+	wroteHeader bool
+}
+
+// WriteHeader sets the compression headers and writes status code to underlying
+// response writer.
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	if h.Get("Content-Encoding") == "" && bodyAllowed(code) {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+
+		if cw.encoding == encodingGzip {
+			cw.compressor = gzipPool.Get().(*gzip.Writer) //nolint:forcetypeassert
+		} else {
+			cw.compressor = flatePool.Get().(*flate.Writer) //nolint:forcetypeassert
+		}
+
+		cw.compressor.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+// Write compresses b and writes it to underlying response writer.
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+
+	if cw.compressor == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+
+	return cw.compressor.Write(b)
+}
+
+// Flush flushes the compressed data to the client. It is used by
+// http.ResponseController to stream responses.
+func (cw *compressWriter) Flush() {
+	if cw.compressor != nil {
+		cw.compressor.Flush() //nolint:errcheck
+	}
+
+	http.NewResponseController(cw.ResponseWriter).Flush() //nolint:errcheck
+}
+
+// Unwrap returns underlying response writer. It is used by http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close writes the remaining compressed data and returns compressor to its pool.
+func (cw *compressWriter) close() {
+	if cw.compressor == nil {
+		return
+	}
+
+	cw.compressor.Close()
+
+	switch c := cw.compressor.(type) {
+	case *gzip.Writer:
+		gzipPool.Put(c)
+	case *flate.Writer:
+		flatePool.Put(c)
+	}
+
+	cw.compressor = nil
+}
+
+// Compress returns a middleware that compresses responses with gzip or deflate
+// based on the Accept-Encoding header of the request.
+func Compress() Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality
+// value in Accept-Encoding header. An empty string is returned when none of
+// supported encodings are acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		qualities[name] = q
+	}
+
+	// Wildcard matches only the encodings that are not listed explicitly
+	if q, ok := qualities["*"]; ok {
+		for _, name := range []string{encodingGzip, encodingDeflate} {
+			if _, listed := qualities[name]; !listed {
+				qualities[name] = q
+			}
+		}
+	}
+
+	// Prefer gzip over deflate when both have same quality
+	switch {
+	case qualities[encodingGzip] > 0 && qualities[encodingGzip] >= qualities[encodingDeflate]:
+		return encodingGzip
+	case qualities[encodingDeflate] > 0:
+		return encodingDeflate
+	default:
+		return ""
+	}
+}
+
+// bodyAllowed returns true if response with status code can have a body.
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Custom errors.
+var (
+	ErrMissingBearerToken = errors.New("bearer token not found")
+	ErrMissingUserClaim   = errors.New("user claim not found in token")
+	errJWTKeys            = errors.New("exactly one of secret_file and public_key_file must be set")
+)
+
+// JWTConfig contains the configuration to validate JWT bearer tokens.
+type JWTConfig struct {
+	Issuer        string `yaml:"issuer"`
+	Audience      string `yaml:"audience"`
+	UserClaim     string `yaml:"user_claim"`
+	SecretFile    string `yaml:"secret_file"`
+	PublicKeyFile string `yaml:"public_key_file"`
+}
+
+// Enabled returns true when JWT authentication is configured.
+func (c JWTConfig) Enabled() bool {
+	return c.SecretFile != "" || c.PublicKeyFile != ""
+}
+
+// JWTAuthenticator validates JWT bearer tokens and identifies users from
+// a claim of the token.
+type JWTAuthenticator struct {
+	userClaim string
+	parser    *jwt.Parser
+	key       interface{}
+}
+
+// NewJWTAuthenticator returns a new JWTAuthenticator. Tokens signed with HMAC
+// are validated using the secret in secret_file and tokens signed with RSA,
+// ECDSA or EdDSA keys are validated using the PEM encoded public key in
+// public_key_file.
+func NewJWTAuthenticator(c JWTConfig) (*JWTAuthenticator, error) {
+	if (c.SecretFile == "") == (c.PublicKeyFile == "") {
+		return nil, errJWTKeys
+	}
+
+	var key interface{}
+
+	var methods []string
+
+	if c.SecretFile != "" {
+		secret, err := os.ReadFile(c.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT secret file: %w", err)
+		}
+
+		key = []byte(strings.TrimSpace(string(secret)))
+		methods = []string{"HS256", "HS384", "HS512"}
+	} else {
+		pem, err := os.ReadFile(c.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT public key file: %w", err)
+		}
+
+		if key, methods, err = parsePublicKey(pem); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only accept the algorithms that correspond to the configured key to
+	// avoid algorithm confusion attacks
+	opts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+
+	if c.Audience != "" {
+		opts = append(opts, jwt.WithAudience(c.Audience))
+	}
+
+	userClaim := c.UserClaim
+	if userClaim == "" {
+		userClaim = "sub"
+	}
+
+	return &JWTAuthenticator{
+		userClaim: userClaim,
+		parser:    jwt.NewParser(opts...),
+		key:       key,
+	}, nil
+}
+
+// LoggedUser validates the bearer token in request, removes any headers that
+// can only be set by CEEMS components from the request, sets the logged user header
+// from the user claim of token and returns the logged user.
+func (a *JWTAuthenticator) LoggedUser(r *http.Request) (string, error) {
+	// Remove any X-Admin-User header or X-Logged-User if passed
+	r.Header.Del(AdminUserHeader)
+	r.Header.Del(LoggedUserHeader)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", ErrMissingBearerToken
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return a.key, nil
+	}); err != nil {
+		return "", err
+	}
+
+	loggedUser, ok := claims[a.userClaim].(string)
+	if !ok || loggedUser == "" {
+		return "", ErrMissingUserClaim
+	}
+
+	// Set Grafana user header as well so that the user is identified
+	// consistently by all the handlers
+	r.Header.Set(GrafanaUserHeader, loggedUser)
+	r.Header.Set(LoggedUserHeader, loggedUser)
+
+	return loggedUser, nil
+}
+
+// parsePublicKey parses a PEM encoded RSA, ECDSA or EdDSA public key and returns
+// the key and the signing methods that can be validated with it.
+func parsePublicKey(pem []byte) (interface{}, []string, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}, nil
+	}
+
+	if key, err := jwt.ParseECPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"ES256", "ES384", "ES512"}, nil
+	}
+
+	if key, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+		return key, []string{"EdDSA"}, nil
+	}
+
+	return nil, nil, errors.New("failed to parse JWT public key. Only RSA, ECDSA and EdDSA keys are supported")
+}
@@ -0,0 +1,255 @@
+// Package middleware implements the HTTP middlewares that are shared between
+// CEEMS API server, CEEMS LB and Redfish proxy.
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/httprate"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Headers used by CEEMS components to identify users.
+const (
+	GrafanaUserHeader   = "X-Grafana-User"
+	DashboardUserHeader = "X-Dashboard-User"
+	LoggedUserHeader    = "X-Logged-User"
+	AdminUserHeader     = "X-Admin-User"
+	CEEMSUserHeader     = "X-Ceems-User"  // Special header that will be included in requests from CEEMS LB
+	CEEMSTokenHeader    = "X-Ceems-Token" // Shared internal secret sent along with CEEMS user header
+)
+
+// Func is the function signature of a HTTP middleware.
+type Func func(http.Handler) http.Handler
+
+// Chain wraps the handler h with middlewares. The first middleware will be the
+// outermost one, i.e., it will be the first to process the request.
+func Chain(h http.Handler, mws ...Func) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
+}
+
+// LoggedUser removes any headers that can only be set by CEEMS components from the
+// request, sets the logged user header from Grafana user header and returns the
+// logged user. An empty string is returned when Grafana user header is not found.
+func LoggedUser(r *http.Request) string {
+	// Remove any X-Admin-User header or X-Logged-User if passed
+	r.Header.Del(AdminUserHeader)
+	r.Header.Del(LoggedUserHeader)
+
+	// Check if username header is available
+	loggedUser := r.Header.Get(GrafanaUserHeader)
+	if loggedUser == "" {
+		return ""
+	}
+
+	// Set logged user header
+	r.Header.Set(LoggedUserHeader, loggedUser)
+
+	return loggedUser
+}
+
+// IsCEEMSRequest returns true if the request is made by other CEEMS components
+// which is identified by the presence of CEEMS user header. When secret is not
+// empty, the request must carry the same secret in CEEMS token header as well.
+func IsCEEMSRequest(r *http.Request, secret string) bool {
+	if _, ok := r.Header[CEEMSUserHeader]; !ok {
+		return false
+	}
+
+	if secret == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(CEEMSTokenHeader)), []byte(secret)) == 1
+}
+
+// SetCEEMSUser sets the CEEMS user header on the request made to other CEEMS
+// components. Value of header is not important, only its presence. When secret
+// is not empty, it is set in CEEMS token header to authenticate the request.
+func SetCEEMSUser(r *http.Request, secret string) {
+	r.Header.Set(CEEMSUserHeader, "admin")
+
+	if secret != "" {
+		r.Header.Set(CEEMSTokenHeader, secret)
+	}
+}
+
+// DelCEEMSUser removes the CEEMS user and token headers from the request.
+func DelCEEMSUser(r *http.Request) {
+	r.Header.Del(CEEMSUserHeader)
+	r.Header.Del(CEEMSTokenHeader)
+}
+
+// RouteTemplate returns the path template of the route matched by request. It is
+// meant to be used as handler label of HTTP metrics to keep their cardinality
+// bounded.
+func RouteTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+
+	return ""
+}
+
+// statusRecorder records the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records status code and writes it to underlying response writer.
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns underlying response writer. It is used by http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ByteRecorder records the number of bytes of response body written by the handler.
+type ByteRecorder struct {
+	http.ResponseWriter
+	Bytes int64
+}
+
+// Write writes b to underlying response writer and records the number of written bytes.
+func (r *ByteRecorder) Write(b []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(b)
+	r.Bytes += int64(n)
+
+	return n, err
+}
+
+// Unwrap returns underlying response writer. It is used by http.ResponseController.
+func (r *ByteRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Logging returns a middleware that logs every request at debug level.
+func Logging(logger *slog.Logger) Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			logger.Debug(
+				"Request served", "method", r.Method, "url", r.URL.Path, "remote_addr", r.RemoteAddr,
+				"status", rec.status, "duration", time.Since(start),
+			)
+		})
+	}
+}
+
+// SecurityHeaders returns a middleware that sets common security headers on
+// responses.
+func SecurityHeaders() Func {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimit returns a middleware that limits number of requests per minute
+// from a given real IP address. Requests are not limited when requestsPerMinute
+// is not positive.
+func RateLimit(requestsPerMinute int) Func {
+	if requestsPerMinute <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return httprate.LimitByRealIP(requestsPerMinute, time.Minute)
+}
+
+// Metrics contains HTTP request metrics of a component.
+type Metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	handler  func(r *http.Request) string
+}
+
+// NewMetrics returns a new instance of Metrics registered in reg with
+// namespace. If metrics are already registered, existing ones will be
+// reused. Function handler returns the name of handler that serves the request,
+// like route template, and it is used as label of metrics. When it is nil,
+// handler label will be empty.
+func NewMetrics(namespace string, reg prometheus.Registerer, handler func(r *http.Request) string) (*Metrics, error) {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total number of HTTP requests by handler, method and status code.",
+	}, []string{"handler", "method", "code"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests in seconds by handler and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"handler", "method"})
+
+	var err error
+
+	if requests, err = register(reg, requests); err != nil {
+		return nil, err
+	}
+
+	if duration, err = register(reg, duration); err != nil {
+		return nil, err
+	}
+
+	if handler == nil {
+		handler = func(_ *http.Request) string { return "" }
+	}
+
+	return &Metrics{requests: requests, duration: duration, handler: handler}, nil
+}
+
+// Middleware returns a middleware that updates HTTP request metrics.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		handler := m.handler(r)
+		m.requests.WithLabelValues(handler, r.Method, strconv.Itoa(rec.status)).Inc()
+		m.duration.WithLabelValues(handler, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// register registers collector c in reg and returns the existing collector
+// if it is already registered.
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	if err := reg.Register(c); err != nil {
+		are := prometheus.AlreadyRegisteredError{}
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+
+		return c, err
+	}
+
+	return c, nil
+}
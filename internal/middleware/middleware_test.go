@@ -0,0 +1,416 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggedUser(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(AdminUserHeader, "admin")
+	req.Header.Set(LoggedUserHeader, "admin")
+
+	// No Grafana user header
+	assert.Empty(t, LoggedUser(req))
+	assert.Empty(t, req.Header.Get(AdminUserHeader))
+	assert.Empty(t, req.Header.Get(LoggedUserHeader))
+
+	// With Grafana user header
+	req.Header.Set(GrafanaUserHeader, "foo")
+	assert.Equal(t, "foo", LoggedUser(req))
+	assert.Equal(t, "foo", req.Header.Get(LoggedUserHeader))
+}
+
+func TestChain(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	metrics, err := NewMetrics("test", reg, func(r *http.Request) string { return "root" })
+	require.NoError(t, err)
+
+	// Registering again must return existing metrics
+	_, err = NewMetrics("test", reg, nil)
+	require.NoError(t, err)
+
+	var order []string
+
+	mw := func(name string) Func {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := Chain(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+		mw("first"),
+		Logging(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		metrics.Middleware,
+		SecurityHeaders(),
+		mw("second"),
+	)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.InDelta(t, 1, testutil.ToFloat64(metrics.requests.WithLabelValues("root", http.MethodGet, "418")), 0)
+}
+
+func TestRateLimit(t *testing.T) {
+	handler := RateLimit(1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, code, w.Code)
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"identity":                  "",
+		"gzip":                      "gzip",
+		"deflate":                   "deflate",
+		"deflate, gzip":             "gzip",
+		"gzip;q=0.5, deflate":       "deflate",
+		"gzip;q=0, *":               "deflate",
+		"*":                         "gzip",
+		"br, gzip;q=0, deflate;q=0": "",
+	}
+
+	for header, expected := range tests {
+		assert.Equal(t, expected, negotiateEncoding(header), header)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"nodelist":"compute-[0-100]"}`, 100)
+
+	handler := Compress()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/empty" {
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body[:10]))
+
+		// Flushing must be possible while streaming response
+		require.NoError(t, http.NewResponseController(w).Flush())
+
+		w.Write([]byte(body[10:]))
+	}))
+
+	for _, encoding := range []string{"gzip", "deflate", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+
+		var reader io.Reader
+
+		switch encoding {
+		case "gzip":
+			gr, err := gzip.NewReader(w.Body)
+			require.NoError(t, err)
+
+			reader = gr
+		case "deflate":
+			reader = flate.NewReader(w.Body)
+		default:
+			reader = w.Body
+		}
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, body, string(got), encoding)
+
+		if encoding != "" {
+			assert.Empty(t, w.Header().Get("Content-Length"))
+			assert.Less(t, w.Body.Len(), len(body))
+		}
+	}
+
+	// Responses without body must not be compressed
+	req := httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("supersecret\n"), 0o600))
+
+	authenticator, err := NewJWTAuthenticator(JWTConfig{
+		Issuer:     "https://idp.example.com",
+		Audience:   "ceems",
+		UserClaim:  "preferred_username",
+		SecretFile: secretFile,
+	})
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		require.NoError(t, err)
+
+		return token
+	}
+
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                "https://idp.example.com",
+			"aud":                "ceems",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"preferred_username": "foo",
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		user  string
+		err   bool
+	}{
+		{
+			name:  "valid token",
+			token: sign(jwt.SigningMethodHS256, []byte("supersecret"), validClaims()),
+			user:  "foo",
+		},
+		{
+			name:  "wrong secret",
+			token: sign(jwt.SigningMethodHS256, []byte("wrongsecret"), validClaims()),
+			err:   true,
+		},
+		{
+			name: "wrong issuer",
+			token: sign(jwt.SigningMethodHS256, []byte("supersecret"), func() jwt.MapClaims {
+				c := validClaims()
+				c["iss"] = "https://evil.example.com"
+
+				return c
+			}()),
+			err: true,
+		},
+		{
+			name: "wrong audience",
+			token: sign(jwt.SigningMethodHS256, []byte("supersecret"), func() jwt.MapClaims {
+				c := validClaims()
+				c["aud"] = "grafana"
+
+				return c
+			}()),
+			err: true,
+		},
+		{
+			name: "expired token",
+			token: sign(jwt.SigningMethodHS256, []byte("supersecret"), func() jwt.MapClaims {
+				c := validClaims()
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+
+				return c
+			}()),
+			err: true,
+		},
+		{
+			name: "missing user claim",
+			token: sign(jwt.SigningMethodHS256, []byte("supersecret"), func() jwt.MapClaims {
+				c := validClaims()
+				delete(c, "preferred_username")
+
+				return c
+			}()),
+			err: true,
+		},
+		{
+			name:  "unsigned token",
+			token: sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, validClaims()),
+			err:   true,
+		},
+		{
+			name: "missing token",
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(GrafanaUserHeader, "bar")
+		req.Header.Set(LoggedUserHeader, "bar")
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		user, err := authenticator.LoggedUser(req)
+		if test.err {
+			require.Error(t, err, test.name)
+			assert.Empty(t, req.Header.Get(LoggedUserHeader), test.name)
+
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.user, user, test.name)
+		assert.Equal(t, test.user, req.Header.Get(LoggedUserHeader), test.name)
+		assert.Equal(t, test.user, req.Header.Get(GrafanaUserHeader), test.name)
+	}
+}
+
+func TestJWTAuthenticatorPublicKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	pubKeyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(
+		t,
+		os.WriteFile(pubKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes}), 0o600),
+	)
+
+	authenticator, err := NewJWTAuthenticator(JWTConfig{PublicKeyFile: pubKeyFile})
+	require.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(
+		jwt.SigningMethodRS256, jwt.MapClaims{"sub": "foo", "exp": time.Now().Add(time.Hour).Unix()},
+	).SignedString(key)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	user, err := authenticator.LoggedUser(req)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", user)
+
+	// Both secret and public key cannot be set
+	_, err = NewJWTAuthenticator(JWTConfig{PublicKeyFile: pubKeyFile, SecretFile: pubKeyFile})
+	require.Error(t, err)
+}
+
+// mockOIDCProvider returns a test server that implements discovery and userinfo
+// endpoints of an OIDC provider.
+func mockOIDCProvider(t *testing.T, userinfoRequests *int) *httptest.Server {
+	t.Helper()
+
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/realms/hpc/.well-known/openid-configuration":
+			w.Write([]byte(`{"issuer": "` + server.URL + `/realms/hpc", "userinfo_endpoint": "` + server.URL + `/realms/hpc/userinfo"}`))
+		case "/realms/hpc/userinfo":
+			*userinfoRequests++
+
+			switch r.Header.Get("Authorization") {
+			case "Bearer admintoken":
+				w.Write([]byte(`{"sub": "1", "preferred_username": "foo", "groups": ["users", "hpc-admins"]}`))
+			case "Bearer usertoken":
+				w.Write([]byte(`{"sub": "2", "preferred_username": "bar", "groups": "users"}`))
+			case "Bearer nousertoken":
+				w.Write([]byte(`{"sub": "3"}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	var userinfoRequests int
+
+	server := mockOIDCProvider(t, &userinfoRequests)
+
+	authenticator, err := NewOIDCAuthenticator(OIDCConfig{
+		IssuerURL:   server.URL + "/realms/hpc/",
+		AdminGroups: []string{"hpc-admins"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		user  string
+		admin bool
+		err   bool
+	}{
+		{name: "admin group", token: "admintoken", user: "foo", admin: true},
+		{name: "single group", token: "usertoken", user: "bar"},
+		{name: "cached token", token: "admintoken", user: "foo", admin: true},
+		{name: "missing user claim", token: "nousertoken", err: true},
+		{name: "invalid token", token: "invalid", err: true},
+		{name: "missing token", err: true},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+		req.Header.Set(GrafanaUserHeader, "baz")
+		req.Header.Set(AdminUserHeader, "baz")
+
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		user, admin, err := authenticator.LoggedUser(req)
+		if test.err {
+			require.Error(t, err, test.name)
+			assert.Empty(t, req.Header.Get(LoggedUserHeader), test.name)
+
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.user, user, test.name)
+		assert.Equal(t, test.admin, admin, test.name)
+		assert.Equal(t, test.user, req.Header.Get(GrafanaUserHeader), test.name)
+		assert.Equal(t, test.user, req.Header.Get(LoggedUserHeader), test.name)
+		assert.Empty(t, req.Header.Get(AdminUserHeader), test.name)
+	}
+
+	// Cached token must not be exchanged again
+	assert.Equal(t, 4, userinfoRequests)
+}
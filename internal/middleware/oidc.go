@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Default OIDC settings.
+const (
+	defaultOIDCUserClaim   = "preferred_username"
+	defaultOIDCGroupsClaim = "groups"
+	defaultOIDCCacheTTL    = time.Minute
+)
+
+// Custom errors.
+var (
+	ErrInvalidAccessToken = errors.New("invalid access token")
+	errOIDCIssuer         = errors.New("issuer_url must be set")
+)
+
+// OIDCConfig contains the configuration to identify users from OIDC access tokens.
+type OIDCConfig struct {
+	IssuerURL        string                  `yaml:"issuer_url"`
+	UserClaim        string                  `yaml:"user_claim"`
+	GroupsClaim      string                  `yaml:"groups_claim"`
+	AdminGroups      []string                `yaml:"admin_groups"`
+	CacheTTL         model.Duration          `yaml:"cache_ttl"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:",inline"`
+}
+
+// Enabled returns true when OIDC authentication is configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.IssuerURL != ""
+}
+
+// oidcIdentity is the identity of user returned by the userinfo endpoint.
+type oidcIdentity struct {
+	user    string
+	admin   bool
+	expires time.Time
+}
+
+// OIDCAuthenticator identifies users by exchanging access tokens for their claims
+// at the userinfo endpoint of OIDC provider. As access tokens can be opaque, they
+// are never validated locally.
+type OIDCAuthenticator struct {
+	config           OIDCConfig
+	client           *http.Client
+	cacheTTL         time.Duration
+	userinfoEndpoint string
+	mu               sync.RWMutex
+	cache            map[string]oidcIdentity // Identities keyed by token hash
+}
+
+// NewOIDCAuthenticator returns a new OIDCAuthenticator. The userinfo endpoint is
+// discovered lazily from the provider metadata so that the server can start even
+// when the provider is unavailable.
+func NewOIDCAuthenticator(c OIDCConfig) (*OIDCAuthenticator, error) {
+	if c.IssuerURL == "" {
+		return nil, errOIDCIssuer
+	}
+
+	client, err := config.NewClientFromConfig(c.HTTPClientConfig, "oidc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OIDC HTTP client: %w", err)
+	}
+
+	if c.UserClaim == "" {
+		c.UserClaim = defaultOIDCUserClaim
+	}
+
+	if c.GroupsClaim == "" {
+		c.GroupsClaim = defaultOIDCGroupsClaim
+	}
+
+	cacheTTL := time.Duration(c.CacheTTL)
+	if cacheTTL <= 0 {
+		cacheTTL = defaultOIDCCacheTTL
+	}
+
+	return &OIDCAuthenticator{
+		config:   c,
+		client:   client,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]oidcIdentity),
+	}, nil
+}
+
+// LoggedUser exchanges the bearer token in request for user claims, removes any
+// headers that can only be set by CEEMS components from the request, sets the logged
+// user header and returns the logged user. The returned boolean is true when user
+// belongs to one of the admin groups.
+func (a *OIDCAuthenticator) LoggedUser(r *http.Request) (string, bool, error) {
+	// Remove any X-Admin-User header or X-Logged-User if passed
+	r.Header.Del(AdminUserHeader)
+	r.Header.Del(LoggedUserHeader)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false, ErrMissingBearerToken
+	}
+
+	identity, err := a.identity(r.Context(), token)
+	if err != nil {
+		return "", false, err
+	}
+
+	// Set Grafana user header as well so that the user is identified
+	// consistently by all the handlers
+	r.Header.Set(GrafanaUserHeader, identity.user)
+	r.Header.Set(LoggedUserHeader, identity.user)
+
+	return identity.user, identity.admin, nil
+}
+
+// identity returns the identity of token either from cache or userinfo endpoint.
+func (a *OIDCAuthenticator) identity(ctx context.Context, token string) (oidcIdentity, error) {
+	h := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(h[:])
+
+	a.mu.RLock()
+	identity, ok := a.cache[key]
+	a.mu.RUnlock()
+
+	if ok && time.Now().Before(identity.expires) {
+		return identity, nil
+	}
+
+	claims, err := a.userinfo(ctx, token)
+	if err != nil {
+		return oidcIdentity{}, err
+	}
+
+	user, ok := claims[a.config.UserClaim].(string)
+	if !ok || user == "" {
+		return oidcIdentity{}, ErrMissingUserClaim
+	}
+
+	identity = oidcIdentity{user: user, expires: time.Now().Add(a.cacheTTL)}
+
+	for _, group := range claimStrings(claims[a.config.GroupsClaim]) {
+		if slices.Contains(a.config.AdminGroups, group) {
+			identity.admin = true
+
+			break
+		}
+	}
+
+	a.mu.Lock()
+	// Purge expired identities to keep the cache bounded
+	for k, v := range a.cache {
+		if time.Now().After(v.expires) {
+			delete(a.cache, k)
+		}
+	}
+
+	a.cache[key] = identity
+	a.mu.Unlock()
+
+	return identity, nil
+}
+
+// userinfo returns the claims of token from the userinfo endpoint.
+func (a *OIDCAuthenticator) userinfo(ctx context.Context, token string) (map[string]interface{}, error) {
+	endpoint, err := a.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrInvalidAccessToken
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode userinfo: %w", err)
+	}
+
+	return claims, nil
+}
+
+// discover returns the userinfo endpoint from provider metadata.
+func (a *OIDCAuthenticator) discover(ctx context.Context) (string, error) {
+	a.mu.RLock()
+	endpoint := a.userinfoEndpoint
+	a.mu.RUnlock()
+
+	if endpoint != "" {
+		return endpoint, nil
+	}
+
+	url := strings.TrimSuffix(a.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch OIDC provider metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch OIDC provider metadata: status %d", resp.StatusCode)
+	}
+
+	var metadata struct {
+		UserinfoEndpoint string `json:"userinfo_endpoint"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return "", fmt.Errorf("failed to decode OIDC provider metadata: %w", err)
+	}
+
+	if metadata.UserinfoEndpoint == "" {
+		return "", errors.New("userinfo_endpoint not found in OIDC provider metadata")
+	}
+
+	a.mu.Lock()
+	a.userinfoEndpoint = metadata.UserinfoEndpoint
+	a.mu.Unlock()
+
+	return metadata.UserinfoEndpoint, nil
+}
+
+// claimStrings returns the claim as a slice of strings. Claims can be either a
+// single string or a list of strings.
+func claimStrings(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	}
+
+	return nil
+}
@@ -25,31 +25,57 @@ var CEEMSServerApp = *kingpin.New(
 
 // DB table names.
 var (
-	UnitsDBTableName      = models.Unit{}.TableName()
-	UsageDBTableName      = models.Usage{}.TableName()
-	DailyUsageDBTableName = models.DailyUsage{}.TableName()
-	ProjectsDBTableName   = models.Project{}.TableName()
-	UsersDBTableName      = models.User{}.TableName()
-	AdminUsersDBTableName = models.AdminUsers{}.TableName()
+	UnitsDBTableName        = models.Unit{}.TableName()
+	UsageDBTableName        = models.Usage{}.TableName()
+	DailyUsageDBTableName   = models.DailyUsage{}.TableName()
+	MonthlyUsageDBTableName = models.MonthlyUsage{}.TableName()
+	ProjectsDBTableName     = models.Project{}.TableName()
+	UsersDBTableName        = models.User{}.TableName()
+	RolesDBTableName        = models.Role{}.TableName()
+	NodesDBTableName        = models.Node{}.TableName()
+	AnnotationsDBTableName  = models.Annotation{}.TableName()
+	ReservationsDBTableName = models.Reservation{}.TableName()
+	PreemptionsDBTableName  = models.Preemption{}.TableName()
+	APIKeysDBTableName      = models.APIKey{}.TableName()
+	QuotasDBTableName       = models.Quota{}.TableName()
+	ProvisionedDBTableName  = models.ProvisionedConfig{}.TableName()
+	UnitNodesDBTableName    = models.UnitNode{}.TableName()
+	UnitStepsDBTableName    = models.UnitStep{}.TableName()
+	UnitScriptsDBTableName  = models.UnitScript{}.TableName()
 )
 
 // Slice of field names of all tables
 // This slice will not contain the DB columns that are ignored in the query.
 var (
-	UnitsDBTableColNames      = models.Unit{}.TagNames("json")
-	UsageDBTableColNames      = models.Usage{}.TagNames("json")
-	ProjectsDBTableColNames   = models.Project{}.TagNames("json")
-	UsersDBTableColNames      = models.User{}.TagNames("json")
-	AdminUsersDBTableColNames = models.AdminUsers{}.TagNames("json")
+	UnitsDBTableColNames        = models.Unit{}.TagNames("json")
+	UsageDBTableColNames        = models.Usage{}.TagNames("json")
+	ProjectsDBTableColNames     = models.Project{}.TagNames("json")
+	UsersDBTableColNames        = models.User{}.TagNames("json")
+	RolesDBTableColNames        = models.Role{}.TagNames("sql")
+	NodesDBTableColNames        = models.Node{}.TagNames("json")
+	AnnotationsDBTableColNames  = models.Annotation{}.TagNames("json")
+	ReservationsDBTableColNames = models.Reservation{}.TagNames("json")
+	PreemptionsDBTableColNames  = models.Preemption{}.TagNames("json")
+	APIKeysDBTableColNames      = models.APIKey{}.TagNames("sql")
+	QuotasDBTableColNames       = models.Quota{}.TagNames("sql")
+	ProvisionedDBTableColNames  = models.ProvisionedConfig{}.TagNames("sql")
+	UnitStepsDBTableColNames    = models.UnitStep{}.TagNames("json")
+	UnitScriptsDBTableColNames  = models.UnitScript{}.TagNames("json")
 )
 
 // Map of struct field name to DB column name.
 var (
-	UnitsDBTableStructFieldColNameMap      = models.Unit{}.TagMap("", "sql")
-	UsageDBTableStructFieldColNameMap      = models.Usage{}.TagMap("", "sql")
-	ProjectsDBTableStructFieldColNameMap   = models.Project{}.TagMap("", "sql")
-	UsersDBTableStructFieldColNameMap      = models.User{}.TagMap("", "sql")
-	AdminUsersDBTableStructFieldColNameMap = models.AdminUsers{}.TagMap("", "sql")
+	UnitsDBTableStructFieldColNameMap        = models.Unit{}.TagMap("", "sql")
+	UsageDBTableStructFieldColNameMap        = models.Usage{}.TagMap("", "sql")
+	ProjectsDBTableStructFieldColNameMap     = models.Project{}.TagMap("", "sql")
+	UsersDBTableStructFieldColNameMap        = models.User{}.TagMap("", "sql")
+	RolesDBTableStructFieldColNameMap        = models.Role{}.TagMap("", "sql")
+	NodesDBTableStructFieldColNameMap        = models.Node{}.TagMap("", "sql")
+	AnnotationsDBTableStructFieldColNameMap  = models.Annotation{}.TagMap("", "sql")
+	ReservationsDBTableStructFieldColNameMap = models.Reservation{}.TagMap("", "sql")
+	PreemptionsDBTableStructFieldColNameMap  = models.Preemption{}.TagMap("", "sql")
+	UnitStepsDBTableStructFieldColNameMap    = models.UnitStep{}.TagMap("", "sql")
+	UnitScriptsDBTableStructFieldColNameMap  = models.UnitScript{}.TagMap("", "sql")
 )
 
 // DatetimeLayout to be used in the package.
@@ -70,3 +96,14 @@ const APIVersion = "v1"
 var (
 	InvalidIDRegex = regexp.MustCompile("[^a-zA-Z0-9-_]")
 )
+
+// UnitsPartitionLayout is the time layout of suffix of monthly partitions of
+// units table. Terminated units are partitioned by the month of their end
+// time in UTC.
+const UnitsPartitionLayout = "2006_01"
+
+// UnitsPartitionName returns the name of monthly partition of units table
+// that contains units terminated at time t.
+func UnitsPartitionName(t time.Time) string {
+	return UnitsDBTableName + "_" + t.UTC().Format(UnitsPartitionLayout)
+}
@@ -7,14 +7,13 @@ package cli
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"os/user"
 	"path/filepath"
 	"runtime"
-	"sync"
 	"syscall"
-	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/mahendrapaipuri/ceems/internal/common"
@@ -23,11 +22,13 @@ import (
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	ceems_db "github.com/mahendrapaipuri/ceems/pkg/api/db"
 	ceems_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/promslog/flag"
 	"github.com/prometheus/common/version"
+	"gopkg.in/yaml.v3"
 	"kernel.org/pub/linux/libs/security/libcap/cap"
 )
 
@@ -53,14 +54,60 @@ func (c *CEEMSAPIAppConfig) Validate() error {
 		return err
 	}
 
+	// Validate Grafana team sync config
+	if err := c.Server.GrafanaTeamSync.Validate(); err != nil {
+		return err
+	}
+
+	// Validate DB connection pool config
+	if err := c.Server.Web.DBPool.Validate(); err != nil {
+		return err
+	}
+
+	// Validate connections config
+	if err := c.Server.Web.Connections.Validate(); err != nil {
+		return err
+	}
+
+	// Validate billing config
+	if err := c.Server.Web.Billing.Validate(); err != nil {
+		return err
+	}
+
+	// Validate fairshare config
+	if err := c.Server.Web.Fairshare.Validate(); err != nil {
+		return err
+	}
+
+	// Validate privacy config
+	if err := c.Server.Web.Privacy.Validate(); err != nil {
+		return err
+	}
+
+	// Validate legacy API config
+	if err := c.Server.Web.LegacyAPI.Validate(); err != nil {
+		return err
+	}
+
+	// Validate CORS config
+	if err := c.Server.Web.CORS.Validate(); err != nil {
+		return err
+	}
+
+	// Validate analytics config
+	if err := c.Server.Web.Analytics.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // CEEMSAPIServerConfig contains the configuration of CEEMS API server.
 type CEEMSAPIServerConfig struct {
-	Data  ceems_db.DataConfig  `yaml:"data"`
-	Admin ceems_db.AdminConfig `yaml:"admin"`
-	Web   ceems_http.WebConfig `yaml:"web"`
+	Data            ceems_db.DataConfig            `yaml:"data"`
+	Admin           ceems_db.AdminConfig           `yaml:"admin"`
+	GrafanaTeamSync ceems_db.GrafanaTeamSyncConfig `yaml:"grafana_team_sync"`
+	Web             ceems_http.WebConfig           `yaml:"web"`
 }
 
 // CEEMSServer represents the `ceems_server` cli.
@@ -84,6 +131,10 @@ func (b *CEEMSServer) Main() error {
 			"web.listen-address",
 			"Addresses on which to expose metrics and web interface.",
 		).Default(":9020").Strings()
+		webGRPCListenAddress = b.App.Flag(
+			"web.grpc.listen-address",
+			"Address on which to expose gRPC API. gRPC API is disabled when empty.",
+		).Default("").String()
 		webConfigFile = b.App.Flag(
 			"web.config.file",
 			"Path to configuration file that can enable TLS or authentication. See: https://github.com/prometheus/exporter-toolkit/blob/master/docs/web-configuration.md",
@@ -93,6 +144,24 @@ func (b *CEEMSServer) Main() error {
 			"Path to CEEMS API server configuration file.",
 		).Envar("CEEMS_API_SERVER_CONFIG_FILE").Default("").String()
 
+		enableSynthetic = b.App.Flag(
+			"web.enable-synthetic-units",
+			"Enable admin endpoint to inject synthetic compute units into DB. Use it only to demo and validate dashboards.",
+		).Default("false").Bool()
+
+		printConfig = b.App.Flag(
+			"print-config",
+			"Print effective configuration in YAML and exit.",
+		).Default("false").Bool()
+		helpConfig = b.App.Flag(
+			"help-config",
+			"Print all available flags and configuration file options with their types and defaults in YAML and exit.",
+		).Default("false").Bool()
+		migrateTo = b.App.Flag(
+			"storage.data.migrate-to",
+			"Migrate DB schema to given version and exit. Use it to downgrade DB before rolling back to an older release.",
+		).Default("-1").Int()
+
 		// Testing related hidden CLI args
 		skipDeleteOldUnits = b.App.Flag(
 			"storage.data.skip.delete.old.units",
@@ -131,6 +200,12 @@ func (b *CEEMSServer) Main() error {
 		return fmt.Errorf("failed to parse CLI flags: %w", err)
 	}
 
+	// Print skeleton of all available options and exit. Config file is not
+	// needed for this
+	if *helpConfig {
+		return printConfigSkeleton(&b.App)
+	}
+
 	// Get absolute path for web config file if provided
 	var webConfigFilePath string
 	if *webConfigFile != "" {
@@ -154,6 +229,11 @@ func (b *CEEMSServer) Main() error {
 	}
 	// Set directory for reading files
 	config.SetDirectory(filepath.Dir(base.ConfigFilePath))
+
+	// Print effective config and exit
+	if *printConfig {
+		return printEffectiveConfig(os.Stdout, &b.App, config)
+	}
 	// This is used only in tests
 	config.Server.Data.SkipDeleteOldUnits = *skipDeleteOldUnits
 
@@ -179,6 +259,11 @@ func (b *CEEMSServer) Main() error {
 	runtime.GOMAXPROCS(*maxProcs)
 	logger.Debug("Go MAXPROCS", "procs", runtime.GOMAXPROCS(0))
 
+	// Migrate DB schema and exit
+	if *migrateTo >= 0 {
+		return ceems_db.MigrateTo(config.Server.Data, uint(*migrateTo), logger)
+	}
+
 	if user, err := user.Current(); err == nil && user.Uid == "0" {
 		logger.Info("CEEMS API server is running as root user. Privileges will be dropped and process will be run as unprivileged user")
 	}
@@ -231,153 +316,80 @@ func (b *CEEMSServer) Main() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	// Make DB config.
-	dbConfig := &ceems_db.Config{
-		Logger:          logger,
-		Data:            config.Server.Data,
-		Admin:           config.Server.Admin,
-		ResourceManager: resource.New,
-		Updater:         updater.New,
-	}
-
-	// Make server config.
-	serverConfig := &ceems_http.Config{
-		Logger: logger,
-		Web: ceems_http.WebConfig{
-			Addresses:        *webListenAddresses,
-			WebSystemdSocket: *systemdSocket,
-			WebConfigFile:    webConfigFilePath,
-			RoutePrefix:      config.Server.Web.RoutePrefix,
-			RequestsLimit:    config.Server.Web.RequestsLimit,
-			MaxQueryPeriod:   config.Server.Web.MaxQueryPeriod,
-		},
-		DB: *dbConfig,
-	}
+	// Restore default behavior on the interrupt signal and notify user of shutdown.
+	context.AfterFunc(ctx, func() {
+		stop()
+		logger.Info("Shutting down gracefully, press Ctrl+C again to force")
+	})
+
+	// Set web config from CLI args.
+	config.Server.Web.Addresses = *webListenAddresses
+	config.Server.Web.GRPCAddress = *webGRPCListenAddress
+	config.Server.Web.WebSystemdSocket = *systemdSocket
+	config.Server.Web.WebConfigFile = webConfigFilePath
+	config.Server.Web.EnableSynthetic = *enableSynthetic
 
 	// Create server instance.
-	apiServer, cleanup, err := ceems_http.New(serverConfig)
-	defer cleanup()
-
+	apiServer, err := NewServer(config.Server, WithLogger(logger))
 	if err != nil {
 		logger.Error("Failed to create ceems_server server", "err", err)
 
 		return err
 	}
 
-	// Create DB instance.
-	collector, err := ceems_db.New(dbConfig)
-	if err != nil {
-		logger.Error("Failed to create ceems_server DB", "err", err)
-
+	// Run server until the interrupt signal is received.
+	if err := apiServer.Run(ctx); err != nil {
 		return err
 	}
 
-	// Declare wait group and tickers.
-	var wg sync.WaitGroup
-
-	var dbUpdateTicker, dbBackupTicker *time.Ticker
-
-	// Initialize tickers. We will stop the ticker immediately after signal has received.
-	dbUpdateTicker = time.NewTicker(time.Duration(config.Server.Data.UpdateInterval))
-
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-
-		for {
-			// This will ensure that we will run the method as soon as go routine
-			// starts instead of waiting for ticker to tick.
-			logger.Info("Updating CEEMS DB", "interval", config.Server.Data.UpdateInterval)
-
-			if err := collector.Collect(ctx); err != nil {
-				logger.Error("Failed to fetch data", "err", err)
-			}
+	logger.Info("Server exiting")
+	logger.Info("See you next time!!")
 
-			select {
-			case <-dbUpdateTicker.C:
-				continue
-			case <-ctx.Done():
-				logger.Info("Received Interrupt. Stopping DB update")
+	return nil
+}
 
-				return
-			}
-		}
-	}()
+// printEffectiveConfig writes the resolved CLI flags and config file in YAML to w.
+// Secrets in config are redacted.
+func printEffectiveConfig(w io.Writer, app *kingpin.Application, config *CEEMSAPIAppConfig) error {
+	flags := make(map[string]string)
 
-	// Start backup go routine only backup path is provided in CLI.
-	if config.Server.Data.BackupPath != "" {
-		// Initialise ticker and increase waitgroup counter.
-		dbBackupTicker = time.NewTicker(time.Duration(config.Server.Data.BackupInterval))
-
-		wg.Add(1)
-
-		go func() {
-			defer wg.Done()
-
-			for {
-				select {
-				case <-dbBackupTicker.C:
-					// Dont run backup as soon as go routine is spawned. In prod, it
-					// can take very long depending on the size of DB and so wait until
-					// first tick to run it.
-					logger.Info("Backing up CEEMS DB", "interval", config.Server.Data.BackupInterval)
-
-					if err := collector.Backup(ctx); err != nil {
-						logger.Error("Failed to backup DB", "err", err)
-					}
-				case <-ctx.Done():
-					logger.Info("Received Interrupt. Stopping DB backup")
-
-					return
-				}
-			}
-		}()
-	}
-
-	// Initializing the server in a goroutine so that
-	// it won't block the graceful shutdown handling below.
-	go func() {
-		if err := apiServer.Start(); err != nil {
-			logger.Error("Failed to start server", "err", err)
+	for _, f := range app.Model().Flags {
+		if f.Hidden || f.Name == "help" || f.Name == "version" {
+			continue
 		}
-	}()
-
-	// Listen for the interrupt signal.
-	<-ctx.Done()
 
-	// Stop tickers.
-	dbUpdateTicker.Stop()
-
-	if config.Server.Data.BackupPath != "" {
-		dbBackupTicker.Stop()
+		flags[f.Name] = f.Value.String()
 	}
 
-	// Wait for all DB go routines to finish.
-	wg.Wait()
-
-	// Close DB only after all DB go routines are done.
-	if err := collector.Stop(); err != nil {
-		logger.Error("Failed to close DB connection", "err", err)
+	out, err := yaml.Marshal(struct {
+		Flags  map[string]string    `yaml:"flags"`
+		Server CEEMSAPIServerConfig `yaml:"ceems_api_server"`
+	}{
+		Flags:  flags,
+		Server: config.Server,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	// Restore default behavior on the interrupt signal and notify user of shutdown.
-	stop()
-	logger.Info("Shutting down gracefully, press Ctrl+C again to force")
+	_, err = w.Write(out)
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return err
+}
 
-	if err := apiServer.Shutdown(ctx); err != nil {
-		logger.Error("Failed to gracefully shutdown server", "err", err)
+// printConfigSkeleton prints YAML skeletons of flags and config file.
+func printConfigSkeleton(app *kingpin.Application) error {
+	config, err := common.ConfigSkeleton(
+		"Configuration file of CEEMS API server",
+		CEEMSAPIAppConfig{},
+		resource.Config[models.Cluster]{},
+		updater.Config[updater.Instance]{},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to generate config skeleton: %w", err)
 	}
 
-	logger.Info("Server exiting")
-	logger.Info("See you next time!!")
-
-	return nil
+	return common.WriteSkeletons(os.Stdout, common.FlagsSkeleton(app), config)
 }
 
 // createDirs makes data directories and set paths to absolute in config.
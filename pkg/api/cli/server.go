@@ -0,0 +1,272 @@
+//go:build cgo
+// +build cgo
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	ceems_db "github.com/mahendrapaipuri/ceems/pkg/api/db"
+	ceems_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+)
+
+// Default address of CEEMS API server.
+const defaultListenAddress = ":9020"
+
+// Time allowed for the server to finish the in-flight requests on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+var errNoFetchers = errors.New("resource manager and updater must be set")
+
+// Server is the CEEMS API server that can be embedded in other Go programs. It
+// runs the HTTP API along with the periodic DB updates that fetch compute units
+// from resource managers and update their aggregate metrics using updaters. DB
+// backups and integrity checks are run as well when they are configured.
+type Server struct {
+	logger          *slog.Logger
+	config          CEEMSAPIServerConfig
+	resourceManager func(*slog.Logger) (*resource.Manager, error)
+	updater         func(*slog.Logger) (*updater.UnitUpdater, error)
+}
+
+// Option configures Server.
+type Option func(*Server)
+
+// WithLogger sets the logger of Server. By default, logs are discarded.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithConfigFile sets the configuration file from which clusters of resource
+// managers and instances of updaters are read. By default, the configuration
+// file of CEEMS API server app is used.
+func WithConfigFile(path string) Option {
+	return func(s *Server) {
+		s.resourceManager = func(logger *slog.Logger) (*resource.Manager, error) {
+			return resource.NewFromFile(path, logger)
+		}
+		s.updater = func(logger *slog.Logger) (*updater.UnitUpdater, error) {
+			return updater.NewFromFile(path, logger)
+		}
+	}
+}
+
+// WithResourceManager sets the constructor of resource manager that fetches
+// compute units, users and projects.
+func WithResourceManager(fn func(*slog.Logger) (*resource.Manager, error)) Option {
+	return func(s *Server) {
+		s.resourceManager = fn
+	}
+}
+
+// WithUpdater sets the constructor of updater that updates compute units
+// with aggregate metrics.
+func WithUpdater(fn func(*slog.Logger) (*updater.UnitUpdater, error)) Option {
+	return func(s *Server) {
+		s.updater = fn
+	}
+}
+
+// NewServer returns a new instance of Server with config. Config is expected
+// to be validated and data directories must exist. Listen addresses of web
+// config default to :9020 when they are not set.
+func NewServer(config CEEMSAPIServerConfig, opts ...Option) (*Server, error) {
+	s := &Server{
+		logger:          promslog.NewNopLogger(),
+		config:          config,
+		resourceManager: resource.New,
+		updater:         updater.New,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.resourceManager == nil || s.updater == nil {
+		return nil, errNoFetchers
+	}
+
+	if len(s.config.Web.Addresses) == 0 {
+		s.config.Web.Addresses = []string{defaultListenAddress}
+	}
+
+	return s, nil
+}
+
+// Run starts the server and blocks until ctx is canceled or the HTTP server
+// fails. The server is shutdown gracefully before returning.
+func (s *Server) Run(ctx context.Context) error {
+	// Make DB config.
+	dbConfig := &ceems_db.Config{
+		Logger:          s.logger,
+		Data:            s.config.Data,
+		Admin:           s.config.Admin,
+		GrafanaTeamSync: s.config.GrafanaTeamSync,
+		ResourceManager: s.resourceManager,
+		Updater:         s.updater,
+	}
+
+	// Create DB instance.
+	collector, err := ceems_db.New(dbConfig)
+	if err != nil {
+		s.logger.Error("Failed to create ceems_server DB", "err", err)
+
+		return err
+	}
+
+	// Create server instance.
+	apiServer, cleanup, err := ceems_http.New(&ceems_http.Config{
+		Logger:     s.logger,
+		Web:        s.config.Web,
+		DB:         *dbConfig,
+		Maintainer: collector,
+		Injector:   collector,
+		Backups:    collector,
+	})
+	defer cleanup()
+
+	if err != nil {
+		s.logger.Error("Failed to create ceems_server server", "err", err)
+
+		if err := collector.Stop(); err != nil {
+			s.logger.Error("Failed to close DB connection", "err", err)
+		}
+
+		return err
+	}
+
+	// DB go routines are stopped when either ctx is canceled or server fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Declare wait group.
+	var wg sync.WaitGroup
+
+	s.runPeriodically(ctx, &wg, periodicTask{
+		msg: "Updating CEEMS DB", errMsg: "Failed to fetch data", stopMsg: "Stopping DB update",
+		interval: s.config.Data.UpdateInterval, immediate: true, fn: collector.Collect,
+	})
+
+	// Start backup go routine only backup path is provided in config.
+	if s.config.Data.BackupPath != "" {
+		// Dont run backup as soon as go routine is spawned. In prod, it
+		// can take very long depending on the size of DB and so wait until
+		// first tick to run it.
+		s.runPeriodically(ctx, &wg, periodicTask{
+			msg: "Backing up CEEMS DB", errMsg: "Failed to backup DB", stopMsg: "Stopping DB backup",
+			interval: s.config.Data.BackupInterval, fn: collector.Backup,
+		})
+	}
+
+	// Start scheduled backups to backup targets only when targets are configured.
+	if len(s.config.Data.Backups.Targets) > 0 {
+		s.runPeriodically(ctx, &wg, periodicTask{
+			msg: "Backing up CEEMS DB to backup targets", errMsg: "Failed to backup DB to backup targets", stopMsg: "Stopping scheduled DB backups",
+			interval: s.config.Data.Backups.Interval, fn: collector.ScheduledBackup,
+		})
+	}
+
+	// Start integrity check go routine only when interval is non zero.
+	if s.config.Data.IntegrityCheckInt > 0 {
+		s.runPeriodically(ctx, &wg, periodicTask{
+			msg: "Checking CEEMS DB integrity", errMsg: "DB integrity check failed", stopMsg: "Stopping DB integrity check",
+			interval: s.config.Data.IntegrityCheckInt, fn: collector.CheckIntegrity,
+		})
+	}
+
+	// Initializing the server in a goroutine so that
+	// it won't block the graceful shutdown handling below.
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- apiServer.Start()
+	}()
+
+	// Wait until either ctx is canceled or server fails.
+	var serverErr error
+
+	select {
+	case <-ctx.Done():
+	case serverErr = <-errCh:
+		if serverErr != nil {
+			s.logger.Error("Failed to start server", "err", serverErr)
+		}
+	}
+
+	// Wait for all DB go routines to finish.
+	cancel()
+	wg.Wait()
+
+	// The context is used to inform the server it has 5 seconds to finish
+	// the request it is currently handling.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer shutdownCancel()
+
+	// Shutdown server first as it cancels and waits for the running DB
+	// maintenance tasks.
+	if err := apiServer.Shutdown(shutdownCtx); err != nil { //nolint:contextcheck
+		s.logger.Error("Failed to gracefully shutdown server", "err", err)
+	}
+
+	// Close DB only after all DB go routines are done.
+	if err := collector.Stop(); err != nil {
+		s.logger.Error("Failed to close DB connection", "err", err)
+	}
+
+	return serverErr
+}
+
+// periodicTask is a DB task that is run periodically.
+type periodicTask struct {
+	msg       string
+	errMsg    string
+	stopMsg   string
+	interval  model.Duration
+	immediate bool // Run task as soon as go routine starts instead of waiting for first tick
+	fn        func(context.Context) error
+}
+
+// runPeriodically runs task at every interval in a go routine until ctx is canceled.
+func (s *Server) runPeriodically(ctx context.Context, wg *sync.WaitGroup, task periodicTask) {
+	ticker := time.NewTicker(time.Duration(task.interval))
+
+	run := func() {
+		s.logger.Info(task.msg, "interval", task.interval)
+
+		if err := task.fn(ctx); err != nil {
+			s.logger.Error(task.errMsg, "err", err)
+		}
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		defer ticker.Stop()
+
+		if task.immediate {
+			run()
+		}
+
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-ctx.Done():
+				s.logger.Info("Received Interrupt. " + task.stopMsg)
+
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,91 @@
+//go:build cgo
+// +build cgo
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	dataDir := filepath.Join(tmpDir, "data")
+
+	configFilePath := makeConfigFile(fmt.Sprintf(`
+---
+ceems_api_server:
+  data:
+    path: %s`, dataDir), tmpDir)
+
+	config, err := common.MakeConfig[CEEMSAPIAppConfig](configFilePath)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(dataDir, 0o750))
+
+	config.Server.Web.Addresses = []string{"localhost:9021"}
+
+	server, err := NewServer(config.Server, WithConfigFile(configFilePath))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- server.Run(ctx)
+	}()
+
+	// Query server
+	require.Eventually(t, func() bool {
+		return queryServer("localhost:9021") == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Canceling context must stop server gracefully
+	cancel()
+
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("server did not stop after context is canceled")
+	}
+}
+
+func TestNewServerNoFetchers(t *testing.T) {
+	_, err := NewServer(CEEMSAPIServerConfig{}, WithResourceManager(nil))
+	require.ErrorIs(t, err, errNoFetchers)
+}
+
+func ExampleNewServer() {
+	// Read config of CEEMS API server from a file. Resource managers and
+	// updaters are read from the same file.
+	config, err := common.MakeConfig[CEEMSAPIAppConfig]("/etc/ceems_api_server/config.yml")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+
+	config.Server.Web.Addresses = []string{":9020"}
+
+	server, err := NewServer(config.Server, WithConfigFile("/etc/ceems_api_server/config.yml"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Server runs until context is canceled
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	if err := server.Run(ctx); err != nil {
+		panic(err)
+	}
+}
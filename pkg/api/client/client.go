@@ -0,0 +1,420 @@
+// Package client implements a Go client of CEEMS API server.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Headers used in requests.
+const (
+	grafanaUserHeader = middleware.GrafanaUserHeader
+	apiKeyHeader      = "X-Api-Key" //nolint:gosec
+)
+
+// Defaults of client config.
+const (
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+	defaultPageWindow   = 24 * time.Hour
+)
+
+// Custom errors.
+var (
+	ErrNoURL    = errors.New("CEEMS API server URL not set")
+	ErrNoUser   = errors.New("user not set")
+	ErrNoWindow = errors.New("from and to must be set to paginate units")
+)
+
+// Usage modes.
+const (
+	CurrentUsage = "current"
+	GlobalUsage  = "global"
+)
+
+// Error codes of CEEMS API server. They are stable across releases and can be
+// compared with Code of APIError to handle failures.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeBadTimeRange    = "bad_time_range"
+	CodeWindowExceeded  = "window_exceeded"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeTooManyRequests = "too_many_requests"
+	CodeDBError         = "db_error"
+	CodeInternal        = "internal"
+	CodeUnavailable     = "unavailable"
+)
+
+// Config contains the configuration of client.
+type Config struct {
+	URL              string                       `yaml:"url"`
+	User             string                       `yaml:"user"`
+	APIKey           config_util.Secret           `yaml:"api_key"`
+	MaxRetries       *int                         `yaml:"max_retries"`
+	RetryBackoff     model.Duration               `yaml:"retry_backoff"`
+	PageWindow       model.Duration               `yaml:"page_window"`
+	HTTPClientConfig config_util.HTTPClientConfig `yaml:",inline"`
+}
+
+// APIError is the error returned by CEEMS API server.
+type APIError struct {
+	StatusCode int    `json:"status"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Code       string `json:"code"`
+}
+
+// Error implements error interface.
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("ceems api server returned %d: %s: %s", e.StatusCode, e.Title, e.Detail)
+	}
+
+	return fmt.Sprintf("ceems api server returned %d: %s", e.StatusCode, e.Title)
+}
+
+// response is the response of CEEMS API server.
+type response[T any] struct {
+	Status   string   `json:"status"`
+	Data     []T      `json:"data"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// UnitOwnership is the ownership status of a compute unit.
+type UnitOwnership struct {
+	UUID  string `json:"uuid"`
+	Owned bool   `json:"owned"`
+}
+
+// verifyRequest is the request body of units verification.
+type verifyRequest struct {
+	UUIDs      []string `json:"uuids"`
+	ClusterIDs []string `json:"cluster_ids"`
+}
+
+// UnitsQuery contains the parameters of units query. When Admin is true, units
+// of any user are fetched from admin end point.
+type UnitsQuery struct {
+	Admin      bool
+	ClusterIDs []string
+	UUIDs      []string
+	Projects   []string
+	Users      []string
+	States     []string
+	Fields     []string
+	Running    bool
+	From       time.Time
+	To         time.Time
+}
+
+// values returns the URL query values of units query.
+func (q UnitsQuery) values() url.Values {
+	v := url.Values{}
+	v["cluster_id"] = q.ClusterIDs
+	v["uuid"] = q.UUIDs
+	v["project"] = q.Projects
+	v["state"] = q.States
+	v["field"] = q.Fields
+
+	if q.Admin {
+		v["user"] = q.Users
+	}
+
+	if q.Running {
+		v.Set("running", "true")
+	}
+
+	return v
+}
+
+// UsageQuery contains the parameters of usage query. When Admin is true, usage
+// of any user is fetched from admin end point.
+type UsageQuery struct {
+	Admin      bool
+	Mode       string
+	ClusterIDs []string
+	Projects   []string
+	Users      []string
+	Fields     []string
+	From       time.Time
+	To         time.Time
+}
+
+// Client is a client of CEEMS API server.
+type Client struct {
+	logger       *slog.Logger
+	url          *url.URL
+	client       *http.Client
+	user         string
+	apiKey       string
+	maxRetries   int
+	retryBackoff time.Duration
+	pageWindow   time.Duration
+}
+
+// New returns a new instance of Client.
+func New(c Config, logger *slog.Logger) (*Client, error) {
+	if c.URL == "" {
+		return nil, ErrNoURL
+	}
+
+	// Requests are always made on behalf of a user
+	if c.User == "" {
+		return nil, ErrNoUser
+	}
+
+	apiURL, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := config_util.NewClientFromConfig(c.HTTPClientConfig, "ceems_api_client")
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries := defaultMaxRetries
+	if c.MaxRetries != nil {
+		maxRetries = *c.MaxRetries
+	}
+
+	retryBackoff := defaultRetryBackoff
+	if c.RetryBackoff > 0 {
+		retryBackoff = time.Duration(c.RetryBackoff)
+	}
+
+	pageWindow := defaultPageWindow
+	if c.PageWindow > 0 {
+		pageWindow = time.Duration(c.PageWindow)
+	}
+
+	return &Client{
+		logger:       logger,
+		url:          apiURL,
+		client:       client,
+		user:         c.User,
+		apiKey:       string(c.APIKey),
+		maxRetries:   maxRetries,
+		retryBackoff: retryBackoff,
+		pageWindow:   pageWindow,
+	}, nil
+}
+
+// endpoint returns the URL of resource with query values.
+func (c *Client) endpoint(resource string, admin bool, values url.Values) string {
+	u := c.url.JoinPath("/api", base.APIVersion, resource)
+	if admin {
+		u = u.JoinPath("admin")
+	}
+
+	u.RawQuery = values.Encode()
+
+	return u.String()
+}
+
+// ListUnits returns compute units matching the query. The query window between From
+// and To is split into pages that do not exceed page window of client so that long
+// windows are not rejected by maximum query period of server. Running units are
+// returned only once even if they are present in several pages.
+func (c *Client) ListUnits(ctx context.Context, q UnitsQuery) ([]models.Unit, error) {
+	values := q.values()
+
+	// Specific units are fetched without query window
+	if len(q.UUIDs) > 0 {
+		return get[models.Unit](ctx, c, c.endpoint("units", q.Admin, values))
+	}
+
+	if q.From.IsZero() || q.To.IsZero() {
+		return nil, ErrNoWindow
+	}
+
+	var units []models.Unit
+
+	seen := make(map[string]bool)
+
+	for from := q.From; from.Before(q.To); from = from.Add(c.pageWindow) {
+		to := from.Add(c.pageWindow)
+		if to.After(q.To) {
+			to = q.To
+		}
+
+		values.Set("from", strconv.FormatInt(from.Unix(), 10))
+		values.Set("to", strconv.FormatInt(to.Unix(), 10))
+
+		page, err := get[models.Unit](ctx, c, c.endpoint("units", q.Admin, values))
+		if err != nil {
+			return nil, err
+		}
+
+		// Units ending on the boundary of pages and running units are returned
+		// in several pages
+		for _, unit := range page {
+			key := fmt.Sprintf("%s/%s/%d", unit.ClusterID, unit.UUID, unit.StartedAtTS)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+
+			units = append(units, unit)
+		}
+	}
+
+	return units, nil
+}
+
+// ListUsage returns usage statistics of projects in current or global mode.
+func (c *Client) ListUsage(ctx context.Context, q UsageQuery) ([]models.Usage, error) {
+	mode := q.Mode
+	if mode == "" {
+		mode = CurrentUsage
+	}
+
+	values := url.Values{}
+	values["cluster_id"] = q.ClusterIDs
+	values["project"] = q.Projects
+	values["field"] = q.Fields
+
+	if q.Admin {
+		values["user"] = q.Users
+	}
+
+	if !q.From.IsZero() {
+		values.Set("from", strconv.FormatInt(q.From.Unix(), 10))
+	}
+
+	if !q.To.IsZero() {
+		values.Set("to", strconv.FormatInt(q.To.Unix(), 10))
+	}
+
+	return get[models.Usage](ctx, c, c.endpoint("usage/"+mode, q.Admin, values))
+}
+
+// Projects returns projects of the user. When admin is true, all projects are returned.
+func (c *Client) Projects(ctx context.Context, admin bool) ([]models.Project, error) {
+	return get[models.Project](ctx, c, c.endpoint("projects", admin, nil))
+}
+
+// Verify returns ownership status of each unit for the user of client.
+func (c *Client) Verify(ctx context.Context, clusterIDs []string, uuids []string) ([]UnitOwnership, error) {
+	body, err := json.Marshal(verifyRequest{UUIDs: uuids, ClusterIDs: clusterIDs})
+	if err != nil {
+		return nil, err
+	}
+
+	return do[UnitOwnership](ctx, c, http.MethodPost, c.endpoint("units/verify", false, nil), body)
+}
+
+// get makes a GET request to endpoint and returns data in response.
+func get[T any](ctx context.Context, c *Client, endpoint string) ([]T, error) {
+	return do[T](ctx, c, http.MethodGet, endpoint, nil)
+}
+
+// do makes a request to endpoint and returns data in response. Requests that failed due to
+// network errors, rate limits or unavailable server are retried with exponential
+// backoff. All requests made by client are read only and hence, safe to retry.
+func do[T any](ctx context.Context, c *Client, method string, endpoint string, body []byte) ([]T, error) {
+	backoff := c.retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		data, retryAfter, err := request[T](ctx, c, method, endpoint, body)
+		if err == nil || retryAfter < 0 || attempt >= c.maxRetries {
+			return data, err
+		}
+
+		wait := max(backoff, retryAfter)
+
+		c.logger.Debug("Retrying request to CEEMS API server", "url", endpoint, "attempt", attempt+1, "wait", wait, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+	}
+}
+
+// request makes a single request to endpoint and returns data in response. A negative
+// retryAfter is returned when request must not be retried.
+func request[T any](ctx context.Context, c *Client, method string, endpoint string, body []byte) ([]T, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, -1, err
+	}
+
+	// Add necessary headers
+	req.Header.Set(grafanaUserHeader, c.user)
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Requests cancelled by caller must not be retried
+		if ctx.Err() != nil {
+			return nil, -1, err
+		}
+
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+
+		// Server returns problem details on errors
+		if b, err := io.ReadAll(resp.Body); err == nil {
+			json.Unmarshal(b, apiErr) //nolint:errcheck
+			apiErr.StatusCode = resp.StatusCode
+		}
+
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			var retryAfter time.Duration
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				retryAfter = time.Duration(seconds) * time.Second
+			}
+
+			return nil, retryAfter, apiErr
+		default:
+			return nil, -1, apiErr
+		}
+	}
+
+	var data response[T]
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, -1, fmt.Errorf("failed to decode response of ceems api server: %w", err)
+	}
+
+	for _, warning := range data.Warnings {
+		c.logger.Warn("CEEMS API server returned warning", "url", endpoint, "warning", warning)
+	}
+
+	return data.Data, 0, nil
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var noOpLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestClient(t *testing.T, h http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{
+		URL:          server.URL,
+		User:         "usr1",
+		APIKey:       "secret",
+		RetryBackoff: model.Duration(time.Millisecond),
+		PageWindow:   model.Duration(24 * time.Hour),
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	return c
+}
+
+func writeData(w http.ResponseWriter, data any) {
+	json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{User: "usr1"}, noOpLogger)
+	require.ErrorIs(t, err, ErrNoURL)
+
+	_, err = New(Config{URL: "http://localhost:9020"}, noOpLogger)
+	require.ErrorIs(t, err, ErrNoUser)
+}
+
+func TestListUnits(t *testing.T) {
+	var windows [][2]string
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/units/admin", r.URL.Path)
+		assert.Equal(t, "usr1", r.Header.Get(grafanaUserHeader))
+		assert.Equal(t, "secret", r.Header.Get(apiKeyHeader))
+		assert.Equal(t, []string{"usr2"}, r.URL.Query()["user"])
+		assert.Equal(t, "true", r.URL.Query().Get("running"))
+
+		windows = append(windows, [2]string{r.URL.Query().Get("from"), r.URL.Query().Get("to")})
+
+		// Running unit is returned in every page
+		writeData(w, []models.Unit{
+			{ClusterID: "rm-0", UUID: "running", StartedAtTS: 1},
+			{ClusterID: "rm-0", UUID: r.URL.Query().Get("from"), StartedAtTS: 1},
+		})
+	})
+
+	from := time.Unix(1700000000, 0)
+
+	units, err := c.ListUnits(context.Background(), UnitsQuery{
+		Admin:   true,
+		Users:   []string{"usr2"},
+		Running: true,
+		From:    from,
+		To:      from.Add(60 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	// 60 hours must be fetched in three pages
+	assert.Equal(t, [][2]string{
+		{"1700000000", "1700086400"},
+		{"1700086400", "1700172800"},
+		{"1700172800", "1700216000"},
+	}, windows)
+
+	require.Len(t, units, 4)
+	assert.Equal(t, "running", units[0].UUID)
+	assert.Equal(t, "1700172800", units[3].UUID)
+
+	// Query window is mandatory
+	_, err = c.ListUnits(context.Background(), UnitsQuery{})
+	require.ErrorIs(t, err, ErrNoWindow)
+}
+
+func TestRetries(t *testing.T) {
+	var attempts int
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		assert.Equal(t, "/api/v1/usage/global", r.URL.Path)
+		writeData(w, []models.Usage{{Project: "prj1"}})
+	})
+
+	usage, err := c.ListUsage(context.Background(), UsageQuery{Mode: GlobalUsage})
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, "prj1", usage[0].Project)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestAPIError(t *testing.T) {
+	var attempts int
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"type":"about:blank","title":"Forbidden","status":403,"detail":"user do not have permissions","code":"forbidden"}`))
+	})
+
+	_, err := c.Projects(context.Background(), true)
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "user do not have permissions", apiErr.Detail)
+	assert.Equal(t, CodeForbidden, apiErr.Code)
+
+	// Client errors must not be retried
+	assert.Equal(t, 1, attempts)
+}
+
+func TestVerify(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/units/verify", r.URL.Path)
+
+		var req verifyRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, verifyRequest{UUIDs: []string{"1", "2"}, ClusterIDs: []string{"rm-0", "rm-0"}}, req)
+
+		writeData(w, []UnitOwnership{{UUID: "1", Owned: true}, {UUID: "2", Owned: false}})
+	})
+
+	ownership, err := c.Verify(context.Background(), []string{"rm-0", "rm-0"}, []string{"1", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []UnitOwnership{{UUID: "1", Owned: true}, {UUID: "2", Owned: false}}, ownership)
+}
@@ -0,0 +1,348 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// Number of rows buffered in memory before writing them to Parquet file.
+const archiveBatchSize = 10000
+
+// ArchiveConfig is the container for the config of archival of expired units.
+// Archival is disabled when type is empty.
+type ArchiveConfig struct {
+	Type string   `yaml:"type"`
+	Path string   `yaml:"path"`
+	S3   S3Config `yaml:"s3"`
+}
+
+// Validate validates the config.
+func (c *ArchiveConfig) Validate() error {
+	if c.Type == "" {
+		return nil
+	}
+
+	target := c.targetConfig()
+
+	return target.Validate()
+}
+
+// targetConfig returns the config of target where archives are stored.
+func (c *ArchiveConfig) targetConfig() BackupTargetConfig {
+	return BackupTargetConfig{Name: "archive", Type: c.Type, Path: c.Path, S3: c.S3}
+}
+
+// parquetWriter writes rows of a table into a Parquet file.
+type parquetWriter struct {
+	writer  *pqarrow.FileWriter
+	builder *array.RecordBuilder
+	rows    int
+}
+
+// newParquetWriter returns a new instance of parquetWriter that writes to file.
+func newParquetWriter(file string, schema *arrow.Schema) (*parquetWriter, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := pqarrow.NewFileWriter(
+		schema,
+		f,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy)),
+		pqarrow.DefaultWriterProps(),
+	)
+	if err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return &parquetWriter{
+		writer:  writer,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+	}, nil
+}
+
+// append appends a row to the writer. Values must be in the same order as
+// the fields of schema.
+func (w *parquetWriter) append(values []any) error {
+	for i, value := range values {
+		switch v := value.(type) {
+		case *sql.NullInt64:
+			if b := w.builder.Field(i).(*array.Int64Builder); v.Valid {
+				b.Append(v.Int64)
+			} else {
+				b.AppendNull()
+			}
+		case *sql.NullFloat64:
+			if b := w.builder.Field(i).(*array.Float64Builder); v.Valid {
+				b.Append(v.Float64)
+			} else {
+				b.AppendNull()
+			}
+		case *sql.NullString:
+			if b := w.builder.Field(i).(*array.StringBuilder); v.Valid {
+				b.Append(v.String)
+			} else {
+				b.AppendNull()
+			}
+		}
+	}
+
+	w.rows++
+
+	if w.rows%archiveBatchSize == 0 {
+		return w.flush()
+	}
+
+	return nil
+}
+
+// flush writes buffered rows to the file.
+func (w *parquetWriter) flush() error {
+	rec := w.builder.NewRecord()
+	defer rec.Release()
+
+	if rec.NumRows() == 0 {
+		return nil
+	}
+
+	return w.writer.Write(rec)
+}
+
+// close flushes buffered rows and closes the file.
+func (w *parquetWriter) close() error {
+	defer w.builder.Release()
+
+	if err := w.flush(); err != nil {
+		w.writer.Close()
+
+		return err
+	}
+
+	// Closing writer closes the underlying file as well
+	return w.writer.Close()
+}
+
+// archiveColumn returns the Arrow field of column and a new scan destination
+// for its values based on the declared type of column.
+func archiveColumn(col *sql.ColumnType) (arrow.Field, func() any) {
+	dbType := strings.ToUpper(col.DatabaseTypeName())
+
+	switch {
+	case strings.Contains(dbType, "INT"):
+		return arrow.Field{Name: col.Name(), Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+			func() any { return &sql.NullInt64{} }
+	case strings.Contains(dbType, "REAL"), strings.Contains(dbType, "FLOA"), strings.Contains(dbType, "DOUB"):
+		return arrow.Field{Name: col.Name(), Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+			func() any { return &sql.NullFloat64{} }
+	default:
+		return arrow.Field{Name: col.Name(), Type: arrow.BinaryTypes.String, Nullable: true},
+			func() any { return &sql.NullString{} }
+	}
+}
+
+// archiveTable writes expired units of table into Parquet files in dir and
+// uploads them to archive target. Files are partitioned by cluster and month
+// in Hive layout, units/cluster_id=<cluster_id>/month=<YYYY-MM>/<table>-<suffix>.parquet,
+// which can be read by DuckDB and Spark. As cluster_id is encoded in the path,
+// it is not included in files.
+func (s *stats) archiveTable(ctx context.Context, tx *sql.Tx, table string, dir string, suffix string) (int, error) {
+	query := fmt.Sprintf(
+		"SELECT cluster_id AS archive_cluster_id, substr(started_at, 1, 7) AS archive_month, * FROM %s "+
+			"WHERE started_at <= date('now', '-%d day') ORDER BY archive_cluster_id, archive_month",
+		table,
+		int(s.storage.retentionPeriod.Hours()/24),
+	) // #nosec
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+
+	// Build schema and scan destinations of columns
+	var (
+		fields   []arrow.Field
+		newDests []func() any
+	)
+
+	for _, col := range colTypes[2:] {
+		if col.Name() == "cluster_id" {
+			newDests = append(newDests, func() any { return &sql.NullString{} })
+
+			continue
+		}
+
+		field, newDest := archiveColumn(col)
+		fields = append(fields, field)
+		newDests = append(newDests, newDest)
+	}
+
+	schema := arrow.NewSchema(fields, nil)
+
+	var (
+		writer      *parquetWriter
+		key, name   string
+		numArchived int
+	)
+
+	// Close writer of a partially written file on errors
+	defer func() {
+		if writer != nil {
+			writer.close()
+		}
+	}()
+
+	// upload closes current writer and uploads the file to target
+	upload := func() error {
+		if writer == nil {
+			return nil
+		}
+
+		if err := writer.close(); err != nil {
+			return err
+		}
+
+		writer = nil
+
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := s.archive.upload(ctx, file, name); err != nil {
+			return fmt.Errorf("failed to upload archive %s: %w", name, err)
+		}
+
+		return os.Remove(file)
+	}
+
+	for rows.Next() {
+		var cluster, month sql.NullString
+
+		dests := []any{&cluster, &month}
+		for _, newDest := range newDests {
+			dests = append(dests, newDest())
+		}
+
+		if err := rows.Scan(dests...); err != nil {
+			return numArchived, err
+		}
+
+		// Start a new file for every cluster and month
+		if k := cluster.String + "/" + month.String; writer == nil || k != key {
+			if err := upload(); err != nil {
+				return numArchived, err
+			}
+
+			key = k
+			name = path.Join(
+				base.UnitsDBTableName,
+				"cluster_id="+cluster.String,
+				"month="+month.String,
+				fmt.Sprintf("%s-%s.parquet", table, suffix),
+			)
+
+			file := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+				return numArchived, err
+			}
+
+			if writer, err = newParquetWriter(file, schema); err != nil {
+				return numArchived, err
+			}
+		}
+
+		// Drop cluster_id from values
+		var values []any
+
+		for i, dest := range dests[2:] {
+			if colTypes[i+2].Name() != "cluster_id" {
+				values = append(values, dest)
+			}
+		}
+
+		if err := writer.append(values); err != nil {
+			return numArchived, err
+		}
+
+		numArchived++
+	}
+
+	if err := rows.Err(); err != nil {
+		return numArchived, err
+	}
+
+	return numArchived, upload()
+}
+
+// archiveExpiredUnits exports the units that will be purged by retention from
+// units table and its monthly partitions to Parquet files in archive target.
+// Units are never purged when archival fails so that no data is lost.
+func (s *stats) archiveExpiredUnits(ctx context.Context, tx *sql.Tx) error {
+	if s.archive == nil {
+		return nil
+	}
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB archival", s.logger)
+
+	tables := []string{base.UnitsDBTableName}
+
+	partitions, err := unitsPartitions(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		tables = append(tables, p.name)
+	}
+
+	// Parquet files are written in a temporary directory next to DB file
+	// and removed once they are uploaded
+	dir, err := os.MkdirTemp(filepath.Dir(s.storage.dbPath), ".archive-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	suffix := time.Now().In(s.storage.timeLocation).Format("20060102150405")
+
+	var numArchived int
+
+	for _, table := range tables {
+		n, err := s.archiveTable(ctx, tx, table, dir, suffix)
+		if err != nil {
+			return fmt.Errorf("failed to archive expired units of %s: %w", table, err)
+		}
+
+		numArchived += n
+	}
+
+	if numArchived > 0 {
+		s.logger.Info("Archived expired units", "num_units", numArchived)
+	}
+
+	return nil
+}
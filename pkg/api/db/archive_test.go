@@ -0,0 +1,124 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveConfig(t *testing.T) {
+	// Archival is disabled by default
+	require.NoError(t, (&ArchiveConfig{}).Validate())
+
+	require.NoError(t, (&ArchiveConfig{Type: backupTargetFilesystem, Path: "/mnt/archive"}).Validate())
+	require.ErrorIs(t, (&ArchiveConfig{Type: backupTargetFilesystem}).Validate(), errNoBackupTargetPath)
+	require.ErrorIs(t, (&ArchiveConfig{Type: backupTargetS3}).Validate(), errNoS3Bucket)
+	require.ErrorIs(t, (&ArchiveConfig{Type: "gcs"}).Validate(), errInvalidBackupTarget)
+}
+
+func TestArchiveExpiredUnits(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	archiveDir := filepath.Join(tmpDir, "archive")
+	c.Data.Archive = ArchiveConfig{Type: backupTargetFilesystem, Path: archiveDir}
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	// Add expired units in two clusters and an active unit
+	expired := time.Now().Add(-s.storage.retentionPeriod * 2)
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units: []models.Unit{
+				{UUID: "1", StartedAt: expired.Format(base.DatetimeLayout)},
+				{UUID: "2", StartedAt: expired.Format(base.DatetimeLayout)},
+				{UUID: "3", StartedAt: time.Now().Format(base.DatetimeLayout)},
+			},
+		},
+		{
+			Cluster: models.Cluster{ID: "os-0"},
+			Units: []models.Unit{
+				{UUID: "4", StartedAt: expired.Format(base.DatetimeLayout)},
+			},
+		},
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil, nil, nil))
+	require.NoError(t, s.purgeExpiredUnits(ctx, tx))
+	require.NoError(t, tx.Commit())
+
+	// Expired units must be archived in Hive layout
+	files, err := filepath.Glob(filepath.Join(archiveDir, "units", "cluster_id=*", "month=*", "*.parquet"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	month := "month=" + expired.Format("2006-01")
+	numRows := map[string]int64{}
+
+	for _, f := range files {
+		assert.Equal(t, month, filepath.Base(filepath.Dir(f)))
+
+		reader, err := file.OpenParquetFile(f, false)
+		require.NoError(t, err)
+
+		arrowReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+		require.NoError(t, err)
+
+		schema, err := arrowReader.Schema()
+		require.NoError(t, err)
+
+		// cluster_id must be only in the path
+		assert.Empty(t, schema.FieldIndices("cluster_id"))
+		assert.NotEmpty(t, schema.FieldIndices("uuid"))
+
+		numRows[filepath.Base(filepath.Dir(filepath.Dir(f)))] = reader.NumRows()
+		reader.Close()
+	}
+
+	assert.Equal(t, map[string]int64{"cluster_id=slurm-0": 2, "cluster_id=os-0": 1}, numRows)
+
+	// Temporary files must be removed
+	matches, err := filepath.Glob(filepath.Join(c.Data.Path, ".archive-*"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Only active unit must be left in DB
+	var numUnits int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM "+base.UnitsDBTableName).Scan(&numUnits))
+	assert.Equal(t, 1, numUnits)
+
+	// Units must not be purged when archival fails
+	require.NoError(t, os.RemoveAll(archiveDir))
+	require.NoError(t, os.WriteFile(archiveDir, nil, 0o600))
+
+	tx, err = s.db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil, nil, nil))
+	require.Error(t, s.purgeExpiredUnits(ctx, tx))
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM "+base.UnitsDBTableName).Scan(&numUnits))
+	assert.Equal(t, 4, numUnits)
+}
@@ -0,0 +1,517 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Supported types of backup targets.
+const (
+	backupTargetFilesystem = "filesystem"
+	backupTargetS3         = "s3"
+)
+
+// Custom errors.
+var (
+	errNoBackupTargetName    = errors.New("name of backup target must not be empty")
+	errDuplicateBackupTarget = errors.New("names of backup targets must be unique")
+	errInvalidBackupTarget   = errors.New("type of backup target must be one of filesystem or s3")
+	errNoBackupTargetPath    = errors.New("path of filesystem backup target must not be empty")
+	errNoS3Bucket            = errors.New("endpoint and bucket of s3 backup target must not be empty")
+	errNoBackupsInterval     = errors.New("interval of backups must be more than 0s")
+)
+
+// S3Config is the container for the config of S3 compatible object storage.
+type S3Config struct {
+	Endpoint        string        `yaml:"endpoint"`
+	Bucket          string        `yaml:"bucket"`
+	Region          string        `yaml:"region"`
+	AccessKeyID     string        `yaml:"access_key_id"`
+	SecretAccessKey config.Secret `yaml:"secret_access_key"`
+	Insecure        bool          `yaml:"insecure"`
+}
+
+// BackupTargetConfig is the container for the config of a backup target.
+type BackupTargetConfig struct {
+	Name            string         `yaml:"name"`
+	Type            string         `yaml:"type"`
+	Path            string         `yaml:"path"`
+	RetentionPeriod model.Duration `yaml:"retention_period"`
+	S3              S3Config       `yaml:"s3"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *BackupTargetConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = BackupTargetConfig{
+		RetentionPeriod: model.Duration(7 * 24 * time.Hour),
+	}
+
+	type plain BackupTargetConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *BackupTargetConfig) Validate() error {
+	if c.Name == "" {
+		return errNoBackupTargetName
+	}
+
+	switch c.Type {
+	case backupTargetFilesystem:
+		if c.Path == "" {
+			return errNoBackupTargetPath
+		}
+	case backupTargetS3:
+		if c.S3.Endpoint == "" || c.S3.Bucket == "" {
+			return errNoS3Bucket
+		}
+	default:
+		return errInvalidBackupTarget
+	}
+
+	return nil
+}
+
+// BackupsConfig is the container for the config of scheduled backups of DB
+// to backup targets.
+type BackupsConfig struct {
+	Interval model.Duration       `yaml:"interval"`
+	Targets  []BackupTargetConfig `yaml:"targets"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *BackupsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = BackupsConfig{
+		Interval: model.Duration(24 * time.Hour),
+	}
+
+	type plain BackupsConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *BackupsConfig) Validate() error {
+	if len(c.Targets) == 0 {
+		return nil
+	}
+
+	if c.Interval <= 0 {
+		return fmt.Errorf("invalid backups config: %w", errNoBackupsInterval)
+	}
+
+	names := make(map[string]bool, len(c.Targets))
+
+	for _, target := range c.Targets {
+		if err := target.Validate(); err != nil {
+			return fmt.Errorf("invalid backups config: %w", err)
+		}
+
+		if names[target.Name] {
+			return fmt.Errorf("invalid backups config: %w", errDuplicateBackupTarget)
+		}
+
+		names[target.Name] = true
+	}
+
+	return nil
+}
+
+// BackupTargetStatus is the status of backups to a backup target.
+type BackupTargetStatus struct {
+	Name          string `json:"name"`                      // Name of the target
+	LastSuccessAt string `json:"last_success_at,omitempty"` // Time of last successful upload
+	Error         string `json:"error,omitempty"`           // Error of last upload when it failed
+}
+
+// BackupStatus is the status of scheduled backups of DB.
+type BackupStatus struct {
+	LastAttemptAt string               `json:"last_attempt_at,omitempty"` // Time of last scheduled backup
+	LastSuccessAt string               `json:"last_success_at,omitempty"` // Time of last backup uploaded to all targets
+	LastBackup    string               `json:"last_backup,omitempty"`     // File name of last successful backup
+	LastError     string               `json:"last_error,omitempty"`      // Error of last scheduled backup when it failed
+	Targets       []BackupTargetStatus `json:"targets"`                   // Status of each target
+}
+
+// Healthy returns true when the last scheduled backup has been uploaded to all targets.
+func (s BackupStatus) Healthy() bool {
+	return s.LastError == ""
+}
+
+// backupObject is a backup stored in a backup target.
+type backupObject struct {
+	name    string
+	modTime time.Time
+}
+
+// backupTarget stores DB backups.
+type backupTarget interface {
+	upload(ctx context.Context, file string, name string) error
+	list(ctx context.Context) ([]backupObject, error)
+	remove(ctx context.Context, name string) error
+}
+
+// fsTarget stores backups in a directory on a filesystem, for instance, a
+// network filesystem.
+type fsTarget struct {
+	dir string
+}
+
+// upload copies file into the directory. Backups are first written to a
+// temporary file and renamed so that partial backups are never found.
+func (t *fsTarget) upload(_ context.Context, file string, name string) error {
+	dir := filepath.Dir(filepath.Join(t.dir, name))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(dir, "."+filepath.Base(name))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(dst.Name(), filepath.Join(t.dir, name))
+}
+
+// list returns the backups in the directory.
+func (t *fsTarget) list(_ context.Context) ([]backupObject, error) {
+	entries, err := os.ReadDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []backupObject
+
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() {
+			objects = append(objects, backupObject{name: entry.Name(), modTime: info.ModTime()})
+		}
+	}
+
+	return objects, nil
+}
+
+// remove removes the backup from the directory.
+func (t *fsTarget) remove(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(t.dir, name))
+}
+
+// s3Target stores backups in a bucket of S3 compatible object storage like
+// AWS S3 or MinIO.
+type s3Target struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// newS3Target returns a new instance of s3Target. When access keys are not
+// configured, credentials are read from environment variables and IAM role.
+func newS3Target(c BackupTargetConfig) (*s3Target, error) {
+	var creds *credentials.Credentials
+	if c.S3.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(c.S3.AccessKeyID, string(c.S3.SecretAccessKey), "")
+	} else {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.IAM{},
+		})
+	}
+
+	client, err := minio.New(c.S3.Endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: !c.S3.Insecure,
+		Region: c.S3.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Target{client: client, bucket: c.S3.Bucket, prefix: strings.Trim(c.Path, "/")}, nil
+}
+
+// key returns the object key of backup.
+func (t *s3Target) key(name string) string {
+	return path.Join(t.prefix, name)
+}
+
+// upload uploads file to the bucket.
+func (t *s3Target) upload(ctx context.Context, file string, name string) error {
+	contentType := "application/vnd.sqlite3"
+	if path.Ext(name) == ".parquet" {
+		contentType = "application/vnd.apache.parquet"
+	}
+
+	_, err := t.client.FPutObject(ctx, t.bucket, t.key(name), file, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+// list returns the backups in the bucket under prefix.
+func (t *s3Target) list(ctx context.Context) ([]backupObject, error) {
+	prefix := t.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var objects []backupObject
+
+	for object := range t.client.ListObjects(ctx, t.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+
+		objects = append(objects, backupObject{name: path.Base(object.Key), modTime: object.LastModified})
+	}
+
+	return objects, nil
+}
+
+// remove removes the backup from the bucket.
+func (t *s3Target) remove(ctx context.Context, name string) error {
+	return t.client.RemoveObject(ctx, t.bucket, t.key(name), minio.RemoveObjectOptions{})
+}
+
+// newBackupTarget returns a backupTarget based on the type of target.
+func newBackupTarget(c BackupTargetConfig) (backupTarget, error) {
+	switch c.Type {
+	case backupTargetFilesystem:
+		return &fsTarget{dir: c.Path}, nil
+	case backupTargetS3:
+		return newS3Target(c)
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidBackupTarget, c.Type)
+	}
+}
+
+// backupScheduler takes online backups of DB and uploads them to backup
+// targets. Backups older than retention period of a target are removed
+// from it after each upload.
+type backupScheduler struct {
+	logger    *slog.Logger
+	configs   []BackupTargetConfig
+	targets   []backupTarget
+	location  *time.Location
+	mu        sync.RWMutex
+	status    BackupStatus
+	uploading sync.Mutex
+}
+
+// newBackupScheduler returns a new instance of backupScheduler. A nil scheduler
+// is returned when no backup targets are configured.
+func newBackupScheduler(c BackupsConfig, location *time.Location, logger *slog.Logger) (*backupScheduler, error) {
+	if len(c.Targets) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	scheduler := &backupScheduler{
+		logger:   logger,
+		configs:  c.Targets,
+		location: location,
+	}
+
+	for _, target := range c.Targets {
+		t, err := newBackupTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup backup target %s: %w", target.Name, err)
+		}
+
+		scheduler.targets = append(scheduler.targets, t)
+		scheduler.status.Targets = append(scheduler.status.Targets, BackupTargetStatus{Name: target.Name})
+	}
+
+	logger.Info("DB will be backed up to targets", "interval", c.Interval, "num_targets", len(c.Targets))
+
+	return scheduler, nil
+}
+
+// run uploads backup file to all targets and removes expired backups from them.
+// An error is returned when upload to any of targets fails.
+func (b *backupScheduler) run(ctx context.Context, file string, name string) error {
+	b.uploading.Lock()
+	defer b.uploading.Unlock()
+
+	now := time.Now().In(b.location)
+
+	var errs error
+
+	for i, target := range b.targets {
+		cfg := b.configs[i]
+
+		err := target.upload(ctx, file, name)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to upload backup to %s: %w", cfg.Name, err))
+		} else {
+			b.logger.Info("DB backup uploaded", "target", cfg.Name, "file", name)
+
+			if err := b.purge(ctx, target, name, now.Add(-time.Duration(cfg.RetentionPeriod))); err != nil {
+				b.logger.Error("Failed to remove expired backups", "target", cfg.Name, "err", err)
+			}
+		}
+
+		b.mu.Lock()
+		if err != nil {
+			b.status.Targets[i].Error = err.Error()
+		} else {
+			b.status.Targets[i].Error = ""
+			b.status.Targets[i].LastSuccessAt = now.Format(base.DatetimezoneLayout)
+		}
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	b.status.LastAttemptAt = now.Format(base.DatetimezoneLayout)
+
+	if errs == nil {
+		b.status.LastSuccessAt = b.status.LastAttemptAt
+		b.status.LastBackup = name
+		b.status.LastError = ""
+	} else {
+		b.status.LastError = errs.Error()
+	}
+	b.mu.Unlock()
+
+	return errs
+}
+
+// failed records a scheduled backup that failed before it could be uploaded.
+func (b *backupScheduler) failed(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.status.LastAttemptAt = time.Now().In(b.location).Format(base.DatetimezoneLayout)
+	b.status.LastError = err.Error()
+}
+
+// purge removes backups of DB that were modified before cutoff from target.
+// Latest backup is never removed.
+func (b *backupScheduler) purge(ctx context.Context, target backupTarget, latest string, cutoff time.Time) error {
+	objects, err := target.list(ctx)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.Split(base.CEEMSDBName, ".")[0] + "-"
+
+	for _, object := range objects {
+		if object.name == latest || !strings.HasPrefix(object.name, prefix) || !strings.HasSuffix(object.name, ".db") {
+			continue
+		}
+
+		if object.modTime.Before(cutoff) {
+			if err := target.remove(ctx, object.name); err != nil {
+				return err
+			}
+
+			b.logger.Debug("Expired DB backup removed", "file", object.name)
+		}
+	}
+
+	return nil
+}
+
+// currentStatus returns the status of scheduled backups.
+func (b *backupScheduler) currentStatus() BackupStatus {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	status := b.status
+	status.Targets = append([]BackupTargetStatus(nil), b.status.Targets...)
+
+	return status
+}
+
+// ScheduledBackup takes a consistent online backup of DB and uploads it to
+// all the configured backup targets.
+func (s *stats) ScheduledBackup(ctx context.Context) error {
+	if s.backups == nil {
+		return nil
+	}
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Scheduled DB backup", s.logger)
+
+	name := fmt.Sprintf(
+		"%s-%s.db",
+		strings.Split(base.CEEMSDBName, ".")[0],
+		time.Now().In(s.storage.timeLocation).Format("200601021504"),
+	)
+
+	// Backup is taken next to DB file and removed once it is uploaded
+	file := filepath.Join(filepath.Dir(s.storage.dbPath), ".scheduled-"+name)
+	defer os.Remove(file)
+
+	s.mu.Lock()
+	err := s.backup(ctx, file)
+	s.mu.Unlock()
+
+	if err != nil {
+		err = fmt.Errorf("failed to backup DB: %w", err)
+		s.backups.failed(err)
+
+		return err
+	}
+
+	return s.backups.run(ctx, file, name)
+}
+
+// BackupStatus returns the status of scheduled backups of DB. Status is nil
+// when no backup targets are configured.
+func (s *stats) BackupStatus() *BackupStatus {
+	if s.backups == nil {
+		return nil
+	}
+
+	status := s.backups.currentStatus()
+
+	return &status
+}
@@ -0,0 +1,119 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBackupsConfig(t *testing.T) {
+	var c BackupsConfig
+
+	// Defaults must be set
+	err := yaml.Unmarshal([]byte(`
+targets:
+  - name: nfs
+    type: filesystem
+    path: /mnt/backups
+  - name: minio
+    type: s3
+    s3:
+      endpoint: localhost:9000
+      bucket: ceems`), &c)
+	require.NoError(t, err)
+	assert.Equal(t, model.Duration(24*time.Hour), c.Interval)
+	assert.Equal(t, model.Duration(7*24*time.Hour), c.Targets[0].RetentionPeriod)
+	require.NoError(t, c.Validate())
+
+	// Names must be unique
+	c.Targets[1].Name = "nfs"
+	require.ErrorIs(t, c.Validate(), errDuplicateBackupTarget)
+
+	c.Targets[1].Name = "minio"
+	c.Targets[1].S3.Bucket = ""
+	require.ErrorIs(t, c.Validate(), errNoS3Bucket)
+
+	c.Targets[1].Type = "gcs"
+	require.ErrorIs(t, c.Validate(), errInvalidBackupTarget)
+
+	c.Targets[0].Path = ""
+	require.ErrorIs(t, c.Validate(), errNoBackupTargetPath)
+
+	// Config without targets is always valid
+	require.NoError(t, (&BackupsConfig{}).Validate())
+
+	// S3 objects are keyed under path
+	target, err := newS3Target(BackupTargetConfig{Path: "/ceems/", S3: S3Config{Endpoint: "localhost:9000", Bucket: "ceems"}})
+	require.NoError(t, err)
+	assert.Equal(t, "ceems/ceems-202401010000.db", target.key("ceems-202401010000.db"))
+}
+
+func TestScheduledBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	targetDir := filepath.Join(tmpDir, "target")
+	c.Data.Backups = BackupsConfig{
+		Interval: model.Duration(time.Hour),
+		Targets: []BackupTargetConfig{
+			{Name: "local", Type: backupTargetFilesystem, Path: targetDir, RetentionPeriod: model.Duration(24 * time.Hour)},
+		},
+	}
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	// Status must be healthy before first backup
+	status := s.BackupStatus()
+	require.NotNil(t, status)
+	assert.True(t, status.Healthy())
+
+	// Add an expired backup and an unrelated file in target
+	require.NoError(t, os.MkdirAll(targetDir, 0o700))
+
+	expired := filepath.Join(targetDir, "ceems-202001010000.db")
+	require.NoError(t, os.WriteFile(expired, []byte("old"), 0o600))
+	require.NoError(t, os.Chtimes(expired, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)))
+
+	other := filepath.Join(targetDir, "notes.txt")
+	require.NoError(t, os.WriteFile(other, []byte("keep"), 0o600))
+	require.NoError(t, os.Chtimes(other, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)))
+
+	require.NoError(t, s.ScheduledBackup(context.Background()))
+
+	// Backup must be uploaded and expired one must be removed
+	status = s.BackupStatus()
+	require.NotEmpty(t, status.LastBackup)
+	assert.True(t, status.Healthy())
+	assert.Equal(t, status.LastAttemptAt, status.Targets[0].LastSuccessAt)
+	assert.FileExists(t, filepath.Join(targetDir, status.LastBackup))
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, other)
+
+	// Temporary backup must be removed from data directory
+	matches, err := filepath.Glob(filepath.Join(c.Data.Path, ".scheduled-*"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Failed uploads must be reported in status
+	require.NoError(t, os.RemoveAll(targetDir))
+	require.NoError(t, os.WriteFile(targetDir, nil, 0o600))
+	require.Error(t, s.ScheduledBackup(context.Background()))
+
+	status = s.BackupStatus()
+	assert.False(t, status.Healthy())
+	assert.NotEmpty(t, status.Targets[0].Error)
+}
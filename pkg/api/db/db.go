@@ -14,7 +14,9 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/internal/common"
@@ -24,8 +26,11 @@ import (
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
+	"github.com/mahendrapaipuri/ceems/pkg/ldap"
 	ceems_sqlite3 "github.com/mahendrapaipuri/ceems/pkg/sqlite3"
 	"github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 )
@@ -44,8 +49,13 @@ var StatementsFS embed.FS
 
 // Custom errors.
 var (
-	ErrBackupInt = errors.New("backup_interval of less than 1 day is not supported")
-	ErrUpdateInt = errors.New("update_interval and/or max_update_interval must be more than 0s")
+	ErrBackupInt    = errors.New("backup_interval of less than 1 day is not supported")
+	ErrUpdateInt    = errors.New("update_interval and/or max_update_interval must be more than 0s")
+	ErrCorruptDB    = errors.New("DB integrity check failed")
+	ErrNoBackup     = errors.New("no valid DB backup found")
+	ErrFolderPerm   = errors.New("folder_permission must be one of View, Edit or Admin")
+	ErrBatchSize    = errors.New("insert_batch_size must not be negative")
+	ErrIndexColumns = errors.New("units_indexes must be non empty lists of columns of units table")
 )
 
 type Timezone struct {
@@ -72,10 +82,24 @@ func (t *Timezone) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t Timezone) MarshalYAML() (interface{}, error) {
+	if t.Location == nil {
+		return "", nil
+	}
+
+	return t.String(), nil
+}
+
 type DateTime struct {
 	time.Time
 }
 
+// MarshalYAML implements the yaml.Marshaler interface.
+func (t DateTime) MarshalYAML() (interface{}, error) {
+	return t.Format("2006-01-02"), nil
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (t *DateTime) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var tmp string
@@ -108,6 +132,7 @@ func (t *DateTime) UnmarshalYAML(unmarshal func(interface{}) error) error {
 type AdminConfig struct {
 	Users   []string                `yaml:"users"`
 	Grafana common.GrafanaWebConfig `yaml:"grafana"`
+	LDAP    ldap.Config             `yaml:"ldap"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -133,25 +158,90 @@ func (c *AdminConfig) Validate() error {
 	// The UnmarshalYAML method of HTTPClientConfig is not being called because it's not a pointer.
 	// We cannot make it a pointer as the parser panics for inlined pointer structs.
 	// Thus we just do its validation here.
-	return c.Grafana.HTTPClientConfig.Validate()
+	if err := c.Grafana.HTTPClientConfig.Validate(); err != nil {
+		return err
+	}
+
+	return c.LDAP.Validate()
 }
 
 // SetDirectory joins any relative file paths with dir.
 func (c *AdminConfig) SetDirectory(dir string) {
 	c.Grafana.HTTPClientConfig.SetDirectory(dir)
+	c.LDAP.SetDirectory(dir)
+}
+
+// GrafanaTeamSyncConfig is the container for the config of syncing projects
+// to Grafana teams.
+type GrafanaTeamSyncConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	TeamNamePrefix   string `yaml:"team_name_prefix"`
+	RemoveStale      bool   `yaml:"remove_stale_members"`
+	Folders          bool   `yaml:"create_folders"`
+	FolderPermission string `yaml:"folder_permission"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *GrafanaTeamSyncConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = GrafanaTeamSyncConfig{
+		TeamNamePrefix:   "ceems-",
+		RemoveStale:      true,
+		FolderPermission: grafana.FolderPermissionView,
+	}
+
+	type plain GrafanaTeamSyncConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *GrafanaTeamSyncConfig) Validate() error {
+	if !c.Enabled || !c.Folders {
+		return nil
+	}
+
+	switch c.FolderPermission {
+	case grafana.FolderPermissionView, grafana.FolderPermissionEdit, grafana.FolderPermissionAdmin:
+		return nil
+	default:
+		return ErrFolderPerm
+	}
+}
+
+// teamName returns the Grafana team name of the project.
+func (c *GrafanaTeamSyncConfig) teamName(clusterID, project string) string {
+	return fmt.Sprintf("%s%s-%s", c.TeamNamePrefix, clusterID, project)
 }
 
 // DataConfig is the container for the data related config.
 type DataConfig struct {
-	Path               string         `yaml:"path"`
-	BackupPath         string         `yaml:"backup_path"`
-	RetentionPeriod    model.Duration `yaml:"retention_period"`
-	UpdateInterval     model.Duration `yaml:"update_interval"`
-	MaxUpdateInterval  model.Duration `yaml:"max_update_interval"`
-	BackupInterval     model.Duration `yaml:"backup_interval"`
-	LastUpdate         DateTime       `yaml:"update_from"`
-	Timezone           Timezone       `yaml:"time_zone"`
-	SkipDeleteOldUnits bool
+	Path                 string          `yaml:"path"`
+	BackupPath           string          `yaml:"backup_path"`
+	RetentionPeriod      model.Duration  `yaml:"retention_period"`
+	UpdateInterval       model.Duration  `yaml:"update_interval"`
+	MaxUpdateInterval    model.Duration  `yaml:"max_update_interval"`
+	BackupInterval       model.Duration  `yaml:"backup_interval"`
+	RecoveryPeriod       model.Duration  `yaml:"recovery_period"`
+	IntegrityCheckInt    model.Duration  `yaml:"integrity_check_interval"`
+	QuickIntegrityCheck  bool            `yaml:"quick_integrity_check"`
+	RestoreFromBackup    bool            `yaml:"restore_from_backup"`
+	BillIdleReservations bool            `yaml:"bill_idle_reservations"`
+	PartitionUnits       bool            `yaml:"partition_units"`
+	InsertBatchSize      int             `yaml:"insert_batch_size"`
+	UnitsIndexes         [][]string      `yaml:"units_indexes"`
+	RetentionDryRun      bool            `yaml:"retention_dry_run"`
+	LastUpdate           DateTime        `yaml:"update_from"`
+	Timezone             Timezone        `yaml:"time_zone"`
+	SQLite               SQLiteConfig    `yaml:"sqlite"`
+	Backups              BackupsConfig   `yaml:"backups"`
+	Archive              ArchiveConfig   `yaml:"archive"`
+	Timescale            TimescaleConfig `yaml:"timescaledb"`
+	SkipDeleteOldUnits   bool            `yaml:"-"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -164,8 +254,18 @@ func (c *DataConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		UpdateInterval:    model.Duration(15 * time.Minute),
 		MaxUpdateInterval: model.Duration(time.Hour),
 		BackupInterval:    model.Duration(24 * time.Hour),
+		InsertBatchSize:   defaultInsertBatchSize,
+		UnitsIndexes:      defaultUnitsIndexes,
 		Timezone:          Timezone{Location: time.Local},
 		LastUpdate:        DateTime{todayMidnight},
+		SQLite: SQLiteConfig{
+			JournalMode: defaultJournalMode,
+			Synchronous: defaultSynchronous,
+			BusyTimeout: model.Duration(defaultBusyTimeout),
+		},
+		Backups: BackupsConfig{
+			Interval: model.Duration(24 * time.Hour),
+		},
 	}
 
 	type plain DataConfig
@@ -192,7 +292,28 @@ func (c *DataConfig) Validate() error {
 		return ErrBackupInt
 	}
 
-	return nil
+	// Batch size of zero inserts all units in a single batch
+	if c.InsertBatchSize < 0 {
+		return ErrBatchSize
+	}
+
+	if err := validateUnitsIndexes(c.UnitsIndexes); err != nil {
+		return err
+	}
+
+	if err := c.SQLite.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Backups.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Archive.Validate(); err != nil {
+		return fmt.Errorf("invalid archive config: %w", err)
+	}
+
+	return c.Timescale.Validate()
 }
 
 // Config makes a DB config from config file.
@@ -200,6 +321,7 @@ type Config struct {
 	Logger          *slog.Logger
 	Data            DataConfig
 	Admin           AdminConfig
+	GrafanaTeamSync GrafanaTeamSyncConfig
 	ResourceManager func(*slog.Logger) (*resource.Manager, error)
 	Updater         func(*slog.Logger) (*updater.UnitUpdater, error)
 }
@@ -213,6 +335,13 @@ type storageConfig struct {
 	lastUpdateTime     time.Time
 	timeLocation       *time.Location
 	skipDeleteOldUnits bool
+	restoreFromBackup  bool
+	quickIntegrity     bool
+	billIdleResv       bool
+	partitionUnits     bool
+	retentionDryRun    bool
+	insertBatchSize    int
+	unitsIndexes       [][]string
 }
 
 // String implements Stringer interface for storageConfig.
@@ -226,26 +355,43 @@ func (s *storageConfig) String() string {
 type adminConfig struct {
 	users                map[string]models.List // Map of admin users from different sources
 	grafana              *grafana.Grafana
+	ldapMembers          func(context.Context) ([]string, error) // Nil when LDAP members are not synchronized
 	grafanaAdminTeamsIDs []string
+	grafanaTeamSync      GrafanaTeamSyncConfig
 }
 
 // stats struct implements fetching compute units, users and project data.
 type stats struct {
-	logger  *slog.Logger
-	db      *sql.DB
-	dbConn  *ceems_sqlite3.Conn
-	emptyDB bool
-	manager *resource.Manager
-	updater *updater.UnitUpdater
-	storage *storageConfig
-	admin   *adminConfig
+	logger    *slog.Logger
+	db        *sql.DB
+	dbConn    *ceems_sqlite3.Conn
+	emptyDB   bool
+	manager   *resource.Manager
+	updater   *updater.UnitUpdater
+	storage   *storageConfig
+	admin     *adminConfig
+	timescale *timescaleStore      // Store of usage snapshots. Nil when TimescaleDB is not configured
+	backups   *backupScheduler     // Uploads backups to backup targets. Nil when no targets are configured
+	archive   backupTarget         // Stores expired units in Parquet files. Nil when archival is not configured
+	stmts     map[string]*sql.Stmt // Prepared statements of tables reused by all transactions
+
+	// mu serializes DB updates with backups, integrity checks, restores and
+	// maintenance operations as restoring DB overwrites its content and last
+	// update time
+	mu sync.Mutex
 }
 
+// preemptedState is the state of compute units that have been preempted.
+const preemptedState = "PREEMPTED"
+
 // SQLite DB related constant vars.
 const (
 	sqlite3Main  = "main"
 	pagesPerStep = 25
 	stepSleep    = 50 * time.Millisecond
+
+	// Default number of units inserted in each batch
+	defaultInsertBatchSize = 10000
 )
 
 var (
@@ -263,13 +409,40 @@ var (
 		"avg_gpu_mem_usage": "alloc_gpumemtime",
 	}
 
-	// Admin users sources.
-	AdminUsersSources = []string{"ceems", "grafana"}
+	// Sources of admin roles that are synchronized by updater. Roles assigned
+	// using API server have api source and they are never synchronized.
+	AdminRoleSources = []string{"ceems", "grafana", "ldap"}
+
+	// DB integrity check metrics.
+	integrityCheckErrors = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "integrity_check_errors",
+		Help:      "Number of errors found by the last DB integrity check",
+	})
+	integrityCheckTimestamp = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "integrity_check_last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the last DB integrity check",
+	})
+	restoresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "restores_total",
+		Help:      "Number of times DB has been restored from a backup",
+	})
+	reindexesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "reindexes_total",
+		Help:      "Number of times DB has been repaired by rebuilding its indexes",
+	})
 )
 
 // Init func to set prepareStatements.
 func init() {
-	for _, tableName := range []string{base.UnitsDBTableName, base.UsageDBTableName, base.DailyUsageDBTableName, base.AdminUsersDBTableName, base.UsersDBTableName, base.ProjectsDBTableName} {
+	for _, tableName := range []string{base.UnitsDBTableName, base.UsageDBTableName, base.DailyUsageDBTableName, base.MonthlyUsageDBTableName, base.RolesDBTableName, base.UsersDBTableName, base.ProjectsDBTableName, base.NodesDBTableName, base.ReservationsDBTableName, base.PreemptionsDBTableName, base.UnitNodesDBTableName, base.UnitStepsDBTableName, base.UnitScriptsDBTableName} {
 		statements, err := StatementsFS.ReadFile(fmt.Sprintf("statements/%s.sql", tableName))
 		if err != nil {
 			panic(fmt.Sprintf("failed to read SQL statements file for table %s: %s", tableName, err))
@@ -286,8 +459,15 @@ func New(c *Config) (*stats, error) {
 	// Get file paths
 	dbPath := filepath.Join(c.Data.Path, base.CEEMSDBName)
 
+	// When recovery is enabled, check if DB file is missing or corrupt before setting
+	// it up. If it is corrupt, it will be moved aside and a new DB will be created.
+	var dbLost bool
+	if c.Data.RecoveryPeriod > 0 {
+		dbLost = recoverDB(dbPath, c.Data.SQLite.Options(false), c.Logger)
+	}
+
 	// Setup DB
-	db, dbConn, err := setupDB(dbPath, c.Logger)
+	db, dbConn, err := setupDB(dbPath, c.Data.SQLite.Options(false), c.Logger)
 	if err != nil {
 		c.Logger.Error("DB setup failed", "err", err)
 
@@ -305,6 +485,17 @@ func New(c *Config) (*stats, error) {
 		return nil, err
 	}
 
+	// Migrations only alter units table and columns added to it must be added
+	// to its monthly partitions as well
+	if err = syncUnitsPartitions(context.Background(), db); err != nil {
+		return nil, fmt.Errorf("failed to sync partitions of units table: %w", err)
+	}
+
+	// Secondary indexes are not part of migrations as they can be configured
+	if err = syncAllUnitsIndexes(context.Background(), db, c.Data.UnitsIndexes); err != nil {
+		return nil, fmt.Errorf("failed to sync indexes of units table: %w", err)
+	}
+
 	// Get last_updated_at time from DB and overwrite the one provided from config.
 	// DB should be the single source of truth.
 	var lastUpdatedAt string
@@ -322,6 +513,20 @@ func New(c *Config) (*stats, error) {
 		emptyDB = true
 	}
 
+	// If DB has been lost and recovery is enabled, rebuild units from resource manager
+	// for the recovery period. Updaters will re-run aggregation on these units.
+	if dbLost && emptyDB && c.Data.RecoveryPeriod > 0 {
+		recoverFrom := time.Now().In(c.Data.Timezone.Location).Add(-time.Duration(c.Data.RecoveryPeriod))
+		if recoverFrom.Before(c.Data.LastUpdate.Time) {
+			c.Data.LastUpdate.Time = recoverFrom
+		}
+
+		c.Logger.Warn(
+			"DB is lost. Rebuilding units from resource manager(s)",
+			"recovery_period", c.Data.RecoveryPeriod, "from", c.Data.LastUpdate.Time,
+		)
+	}
+
 	// Now make an instance of time.Date with proper format and zone
 	c.Data.LastUpdate.Time = time.Date(
 		c.Data.LastUpdate.Time.Year(),
@@ -342,7 +547,7 @@ func New(c *Config) (*stats, error) {
 	}
 
 	// Make admin users map
-	adminUsers := make(map[string]models.List, len(AdminUsersSources))
+	adminUsers := make(map[string]models.List, len(AdminRoleSources))
 	for _, user := range c.Admin.Users {
 		adminUsers["ceems"] = append(adminUsers["ceems"], user)
 	}
@@ -352,6 +557,17 @@ func New(c *Config) (*stats, error) {
 		users:                adminUsers,
 		grafana:              grafanaClient,
 		grafanaAdminTeamsIDs: c.Admin.Grafana.TeamsIDs,
+		grafanaTeamSync:      c.GrafanaTeamSync,
+	}
+
+	// Members of LDAP admin group are synchronized periodically when configured
+	if c.Admin.LDAP.Enabled() && c.Admin.LDAP.SyncMembers {
+		ldapClient, err := ldap.New(c.Admin.LDAP, c.Logger.With("client", "ldap"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create LDAP client: %w", err)
+		}
+
+		adminConfig.ldapMembers = ldapClient.Members
 	}
 
 	// Storage config
@@ -363,6 +579,13 @@ func New(c *Config) (*stats, error) {
 		lastUpdateTime:     c.Data.LastUpdate.Time,
 		timeLocation:       c.Data.Timezone.Location,
 		skipDeleteOldUnits: c.Data.SkipDeleteOldUnits,
+		restoreFromBackup:  c.Data.RestoreFromBackup,
+		quickIntegrity:     c.Data.QuickIntegrityCheck,
+		billIdleResv:       c.Data.BillIdleReservations,
+		partitionUnits:     c.Data.PartitionUnits,
+		unitsIndexes:       c.Data.UnitsIndexes,
+		retentionDryRun:    c.Data.RetentionDryRun,
+		insertBatchSize:    c.Data.InsertBatchSize,
 	}
 
 	// Setup manager struct that retrieves unit data
@@ -381,23 +604,92 @@ func New(c *Config) (*stats, error) {
 		return nil, err
 	}
 
+	// Add clusters and updaters provisioned using API server. Invalid ones are
+	// skipped so that they cannot prevent server from starting
+	if err := addProvisioned(context.Background(), db, manager, updater); err != nil {
+		c.Logger.Error("Failed to add provisioned clusters and updaters", "err", err)
+	}
+
+	// Setup TimescaleDB store of usage snapshots when configured
+	timescale, err := newTimescaleStore(context.Background(), c.Data.Timescale, c.Logger)
+	if err != nil {
+		c.Logger.Error("TimescaleDB setup failed", "err", err)
+
+		return nil, err
+	}
+
+	// Setup scheduler of backups to backup targets when configured
+	backups, err := newBackupScheduler(c.Data.Backups, c.Data.Timezone.Location, c.Logger)
+	if err != nil {
+		c.Logger.Error("Backup targets setup failed", "err", err)
+
+		return nil, err
+	}
+
+	// Setup target of archives of expired units when configured
+	var archive backupTarget
+	if c.Data.Archive.Type != "" {
+		if archive, err = newBackupTarget(c.Data.Archive.targetConfig()); err != nil {
+			c.Logger.Error("Archive target setup failed", "err", err)
+
+			return nil, err
+		}
+	}
+
+	// Prepare statements once so that they are reused by all transactions
+	stmts, err := prepareStmts(context.Background(), db)
+	if err != nil {
+		c.Logger.Error("Failed to prepare SQL statements", "err", err)
+
+		return nil, err
+	}
+
 	// Emit debug logs
 	c.Logger.Debug("Storage config", "cfg", storageConfig)
 
 	return &stats{
-		logger:  c.Logger,
-		db:      db,
-		dbConn:  dbConn,
-		emptyDB: emptyDB,
-		manager: manager,
-		updater: updater,
-		storage: storageConfig,
-		admin:   adminConfig,
+		logger:    c.Logger,
+		db:        db,
+		dbConn:    dbConn,
+		emptyDB:   emptyDB,
+		manager:   manager,
+		updater:   updater,
+		storage:   storageConfig,
+		admin:     adminConfig,
+		timescale: timescale,
+		backups:   backups,
+		archive:   archive,
+		stmts:     stmts,
 	}, nil
 }
 
+// MigrateTo migrates the schema of DB in data path up or down to the given
+// version. It is meant to downgrade DB before rolling back to an older release.
+func MigrateTo(c DataConfig, version uint, logger *slog.Logger) error {
+	dbPath := filepath.Join(c.Path, base.CEEMSDBName)
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("failed to find DB file: %w", err)
+	}
+
+	db, _, err := openDBConnection(dbPath, c.SQLite.Options(false))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := db_migrator.New(MigrationsFS, migrationsDir, logger)
+	if err != nil {
+		return err
+	}
+
+	return migrator.MigrateTo(db, version)
+}
+
 // Collect stats.
 func (s *stats) Collect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Measure elapsed time
 	defer common.TimeTrack(time.Now(), "Data collection", s.logger)
 
@@ -437,35 +729,189 @@ func (s *stats) Collect(ctx context.Context) error {
 
 // Backup DB.
 func (s *stats) Backup(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return s.createBackup(ctx)
 }
 
+// CheckIntegrity runs integrity check on DB and restores it from latest backup
+// when corruption is detected and restoring is enabled.
+func (s *stats) CheckIntegrity(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkIntegrity(ctx)
+}
+
+// Repair rebuilds the indexes of DB and restores DB from the latest backup
+// when corruption is still detected after rebuilding indexes.
+func (s *stats) Repair(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.repair(ctx)
+}
+
+// Vacuum DB to reclaim free pages.
+func (s *stats) Vacuum(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB vacuum", s.logger)
+
+	return s.vacuum(ctx)
+}
+
+// Purge deletes the entries that are older than retention period from DB.
+func (s *stats) Purge(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+
+	if err := s.purgeExpiredUnits(ctx, tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("errors: %w, %w", err, rbErr)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Inject inserts units, users and projects into DB as if they were fetched from
+// resource managers between start and end times. It is meant to populate DB with
+// synthetic data.
+func (s *stats) Inject(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+	units []models.ClusterUnits,
+	users []models.ClusterUsers,
+	projects []models.ClusterProjects,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ingest(ctx, start, end, units, users, projects, nil, nil, false)
+}
+
 // Close DB connection.
 func (s *stats) Stop() error {
+	if s.timescale != nil {
+		if err := s.timescale.close(); err != nil {
+			s.logger.Error("Failed to close TimescaleDB connection", "err", err)
+		}
+	}
+
+	for _, stmt := range s.stmts {
+		stmt.Close()
+	}
+
 	return s.db.Close()
 }
 
 // updateAdminUsers updates the static list of admin users with the ones fetched
-// from Grafana teams.
+// from Grafana teams and LDAP group. Admin users of a source are retained when
+// they cannot be fetched.
 func (s *stats) updateAdminUsers(ctx context.Context) error {
-	// If no teams IDs are configured or Grafana is not online, return
-	if s.admin.grafanaAdminTeamsIDs == nil || !s.admin.grafana.Available() {
-		return nil
+	var errs error
+
+	// If teams IDs are configured and Grafana is online, fetch team members
+	if s.admin.grafanaAdminTeamsIDs != nil && s.admin.grafana.Available() {
+		if users, err := s.admin.grafana.TeamMembers(ctx, s.admin.grafanaAdminTeamsIDs); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("grafana: %w", err))
+		} else {
+			// Reset existing grafana admin users
+			s.admin.users["grafana"] = models.List{}
+
+			for _, u := range users {
+				s.admin.users["grafana"] = append(s.admin.users["grafana"], u)
+			}
+		}
 	}
 
-	users, err := s.admin.grafana.TeamMembers(ctx, s.admin.grafanaAdminTeamsIDs)
-	if err != nil {
-		return err
+	if s.admin.ldapMembers != nil {
+		if users, err := s.admin.ldapMembers(ctx); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("ldap: %w", err))
+		} else {
+			s.admin.users["ldap"] = models.List{}
+
+			for _, u := range users {
+				s.admin.users["ldap"] = append(s.admin.users["ldap"], u)
+			}
+		}
 	}
 
-	// Reset existing grafana admin users
-	s.admin.users["grafana"] = models.List{}
+	return errs
+}
 
-	for _, u := range users {
-		s.admin.users["grafana"] = append(s.admin.users["grafana"], u)
+// syncGrafanaTeams creates a Grafana team for each project and synchronizes its
+// members with the users of the project. When folders are enabled, a folder
+// with the same name as team is created and the team is granted permission on it.
+func (s *stats) syncGrafanaTeams(ctx context.Context, projects []models.ClusterProjects) error {
+	// If sync is disabled or Grafana is not online, return
+	if !s.admin.grafanaTeamSync.Enabled || !s.admin.grafana.Available() {
+		return nil
 	}
 
-	return nil
+	cfg := s.admin.grafanaTeamSync
+
+	var errs error
+
+	for _, clusterProjects := range projects {
+		for _, project := range clusterProjects.Projects {
+			name := cfg.teamName(clusterProjects.Cluster.ID, project.Name)
+
+			logins := make([]string, 0, len(project.Users))
+			for _, user := range project.Users {
+				if login, ok := user.(string); ok && login != "" {
+					logins = append(logins, login)
+				}
+			}
+
+			result, err := s.admin.grafana.SyncTeam(ctx, name, logins, cfg.RemoveStale)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("team %s: %w", name, err))
+
+				continue
+			}
+
+			if len(result.Added) > 0 || len(result.Removed) > 0 {
+				s.logger.Debug(
+					"Grafana team synchronized", "team", name,
+					"added", strings.Join(result.Added, ","), "removed", strings.Join(result.Removed, ","),
+				)
+			}
+
+			if len(result.Missing) > 0 {
+				s.logger.Debug("Users not found in Grafana", "team", name, "users", strings.Join(result.Missing, ","))
+			}
+
+			if !cfg.Folders {
+				continue
+			}
+
+			folder, err := s.admin.grafana.EnsureFolder(ctx, name)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("folder %s: %w", name, err))
+
+				continue
+			}
+
+			if err := s.admin.grafana.SetFolderTeamPermission(ctx, folder.UID, result.TeamID, cfg.FolderPermission); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("folder %s: %w", name, err))
+			}
+		}
+	}
+
+	return errs
 }
 
 // collect fetches unit, user and project stats and insert them into DB.
@@ -495,47 +941,45 @@ func (s *stats) collect(ctx context.Context, startTime, endTime time.Time) error
 	// Update units struct with unit level metrics from TSDB
 	units = s.updater.Update(ctx, startTime, endTime, units)
 
-	// Update admin users list from Grafana
-	if err := s.updateAdminUsers(ctx); err != nil {
-		s.logger.Error("Failed to update admin users from Grafana", "err", err)
+	// Fetch node level metrics of clusters from updaters
+	clusters := make([]models.Cluster, len(units))
+	for i := range units {
+		clusters[i] = units[i].Cluster
 	}
 
-	// Begin transcation
-	tx, err := s.db.BeginTx(ctx, nil)
+	nodes := s.updater.UpdateNodes(ctx, startTime, endTime, clusters)
+
+	// Fetch reservations from resource managers that support them
+	reservations, err := s.manager.FetchReservations(ctx, startTime, endTime)
 	if err != nil {
-		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+		s.logger.Error("Fetching reservations from atleast one resource manager failed", "err", err)
 	}
 
-	// Delete older entries and free up DB pages
-	// In testing we want to skip this
-	if !s.storage.skipDeleteOldUnits {
-		s.logger.Debug("Cleaning up old entries in DB")
-
-		if err = s.purgeExpiredUnits(ctx, tx); err != nil {
-			s.logger.Error("Failed to clean up old entries", "err", err)
-		} else {
-			s.logger.Debug("Cleaned up old entries in DB")
-		}
+	// Update admin users list from Grafana and LDAP
+	if err := s.updateAdminUsers(ctx); err != nil {
+		s.logger.Error("Failed to update admin users", "err", err)
 	}
 
-	// Insert data into DB
-	s.logger.Debug("Executing SQL statements")
-
-	if err := s.execStatements(ctx, tx, startTime, endTime, units, users, projects); err != nil {
-		s.logger.Debug("Failed to execute SQL statements", "err", err)
-
-		return fmt.Errorf("failed to execute SQL statements: %w", err)
-	} else {
-		s.logger.Debug("Finished executing SQL statements")
+	// Synchronize project memberships to Grafana teams
+	if err := s.syncGrafanaTeams(ctx, projects); err != nil {
+		s.logger.Error("Failed to synchronize projects with Grafana teams", "err", err)
 	}
 
-	// Commit changes
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit SQL transcation: %w", err)
+	// Insert data into DB along with maintenance of expired and terminated units
+	if err := s.ingest(ctx, startTime, endTime, units, users, projects, nodes, reservations, true); err != nil {
+		return err
 	}
 
 	s.logger.Info("DB updated for period", "from", startTime, "to", endTime)
 
+	// Store usage snapshots of units in TimescaleDB. Failures must not prevent
+	// updating the DB and hence, they are only logged
+	if s.timescale != nil {
+		if err := s.timescale.insert(ctx, endTime, units); err != nil {
+			s.logger.Error("Failed to store usage snapshots in TimescaleDB", "err", err)
+		}
+	}
+
 	// Keep track of last updated time upon successful DB ops
 	s.storage.lastUpdateTime = endTime
 
@@ -547,38 +991,289 @@ func (s *stats) purgeExpiredUnits(ctx context.Context, tx *sql.Tx) error {
 	// Measure elapsed time
 	defer common.TimeTrack(time.Now(), "DB cleanup", s.logger)
 
-	// Purge expired units
-	deleteUnitsQuery := fmt.Sprintf(
-		"DELETE FROM %s WHERE started_at <= date('now', '-%d day')",
-		base.UnitsDBTableName,
-		int(s.storage.retentionPeriod.Hours()/24),
-	) // #nosec
-	if _, err := tx.ExecContext(ctx, deleteUnitsQuery); err != nil {
+	// In dry run mode, only report the entries that would be deleted
+	if s.storage.retentionDryRun {
+		reports, err := s.purgeReport(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range reports {
+			s.logger.Info(
+				"Retention dry run: expired entries are not purged",
+				"table", r.Table, "month", r.Month, "rows", r.Rows, "bytes", r.Bytes,
+			)
+		}
+
+		return nil
+	}
+
+	// Archive expired units before purging them
+	if err := s.archiveExpiredUnits(ctx, tx); err != nil {
 		return err
 	}
 
-	// Get changes
-	var unitsDeleted int
-	if err := tx.QueryRowContext(ctx, "SELECT changes()").Scan(&unitsDeleted); err == nil {
-		s.logger.Debug("DB update", "units_deleted", unitsDeleted)
+	retentionDays := int(s.storage.retentionPeriod.Hours() / 24)
+
+	// Purge expired entries of all tables. Nodes, steps and scripts of expired
+	// units are deleted by triggers on units table
+	for _, rt := range retentionTables {
+		deleteQuery := fmt.Sprintf(
+			"DELETE FROM %s WHERE %s <= date('now', '-%d day')",
+			rt.table,
+			rt.column,
+			retentionDays,
+		) // #nosec
+		if _, err := tx.ExecContext(ctx, deleteQuery); err != nil {
+			return err
+		}
+
+		// Get changes
+		var deleted int
+		if err := tx.QueryRowContext(ctx, "SELECT changes()").Scan(&deleted); err == nil {
+			s.logger.Debug("DB update", "table", rt.table, "deleted", deleted)
+		}
+
+		// Purge expired units from monthly partitions of units
+		if rt.table == base.UnitsDBTableName {
+			if err := s.purgeExpiredPartitions(ctx, tx); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Purge stale usage data
-	deleteUsageQuery := fmt.Sprintf(
-		"DELETE FROM %s WHERE last_updated_at <= date('now', '-%d day')",
-		base.UsageDBTableName,
-		int(s.storage.retentionPeriod.Hours()/24),
-	) // #nosec
-	if _, err := tx.ExecContext(ctx, deleteUsageQuery); err != nil {
+	// Purge annotations of units that have been purged
+	deleted, err := purgeOrphanAnnotations(ctx, tx)
+	if err != nil {
 		return err
 	}
 
-	// Get changes
-	var usageDeleted int
-	if err := tx.QueryRowContext(ctx, "SELECT changes()").Scan(&usageDeleted); err == nil {
-		s.logger.Debug("DB update", "usage_deleted", usageDeleted)
+	s.logger.Debug("DB update", "table", base.AnnotationsDBTableName, "deleted", deleted)
+
+	return nil
+}
+
+// billIdleReservation adds idle time of reservation to the usage of the project
+// that reserved the resources. Idle times are added to `total_time_seconds` of
+// usage without any user using `reservation_idle_` prefixed TRES names as keys.
+func (s *stats) billIdleReservation(
+	ctx context.Context,
+	stmts map[string]*sql.Stmt,
+	cluster models.Cluster,
+	project string,
+	resv models.Reservation,
+	currentTime time.Time,
+) {
+	// Reservations that can be used by multiple projects cannot be billed
+	if project == "" || strings.Contains(project, ",") {
+		s.logger.Debug("Skipping billing of reservation without a unique project", "cluster_id", cluster.ID, "reservation", resv.Name, "project", project)
+
+		return
+	}
+
+	// Weights of average metrics must exist in total time
+	totalTime := models.MetricMap{}
+	for _, weight := range Weights {
+		totalTime[weight] = 0
+	}
+
+	for tres, idle := range resv.IdleTime {
+		totalTime["reservation_idle_"+tres] = idle
+	}
+
+	tables := usageRollups(currentTime)
+	tables[base.UsageDBTableName] = currentTime.Format(base.DatetimeLayout)
+
+	for table, lastUpdatedAt := range tables {
+		if _, err := stmts[table].ExecContext(
+			ctx,
+			sql.Named(base.UsageDBTableStructFieldColNameMap["ResourceManager"], cluster.Manager),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["ClusterID"], cluster.ID),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["NumUnits"], 0),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["Project"], project),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["User"], ""),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["Group"], ""),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["LastUpdatedAt"], lastUpdatedAt),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalTime"], totalTime),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUMemUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEnergyUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEmissions"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUMemUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEnergyUsage"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEmissions"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOWriteStats"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOReadStats"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIngressStats"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["TotalOutgressStats"], models.MetricMap{}),
+			sql.Named(base.UsageDBTableStructFieldColNameMap["NumUpdates"], 1),
+		); err != nil {
+			s.logger.Error("Failed to bill reservation in DB", "table", table, "cluster_id", cluster.ID, "reservation", resv.Name, "err", err)
+		}
+	}
+}
+
+// usageRollups returns the last_updated_at timestamps of daily and monthly
+// usage rollup tables at currentTime. Usage of all units updated on the same
+// day/month is aggregated into a single row of rollup tables.
+func usageRollups(currentTime time.Time) map[string]string {
+	return map[string]string{
+		base.DailyUsageDBTableName:   currentTime.Truncate(24 * time.Hour).Format(base.DatetimeLayout),
+		base.MonthlyUsageDBTableName: time.Date(currentTime.Year(), currentTime.Month(), 1, 0, 0, 0, 0, currentTime.Location()).Format(base.DatetimeLayout),
+	}
+}
+
+// prepareStmts prepares statements of all tables on DB.
+func prepareStmts(ctx context.Context, db *sql.DB) (map[string]*sql.Stmt, error) {
+	stmts := make(map[string]*sql.Stmt, len(prepareStatements))
+
+	for table, stmt := range prepareStatements {
+		prepared, err := db.PrepareContext(ctx, stmt)
+		if err != nil {
+			for _, s := range stmts {
+				s.Close()
+			}
+
+			return nil, fmt.Errorf("failed to prepare statement for table %s: %w", table, err)
+		}
+
+		stmts[table] = prepared
+	}
+
+	return stmts, nil
+}
+
+// batchUnits splits units of clusters into batches of at most size units. When
+// size is zero, all units are returned in a single batch. There is always at
+// least one batch even when there are no units.
+func batchUnits(clusterUnits []models.ClusterUnits, size int) [][]models.ClusterUnits {
+	if size <= 0 {
+		return [][]models.ClusterUnits{clusterUnits}
+	}
+
+	var batches [][]models.ClusterUnits
+
+	var batch []models.ClusterUnits
+
+	var n int
+
+	for _, cluster := range clusterUnits {
+		units := cluster.Units
+
+		for len(units) > 0 {
+			chunk := units[:min(size-n, len(units))]
+			units = units[len(chunk):]
+
+			batch = append(batch, models.ClusterUnits{Cluster: cluster.Cluster, Units: chunk})
+
+			if n += len(chunk); n == size {
+				batches = append(batches, batch)
+				batch, n = nil, 0
+			}
+		}
+	}
+
+	if len(batch) > 0 || len(batches) == 0 {
+		batches = append(batches, batch)
 	}
 
+	return batches
+}
+
+// ingest inserts units in batches of configured size within a single
+// transaction so that statements prepared on the transaction are reused by all
+// batches. Users, projects, nodes and reservations are inserted in the last
+// batch. When maintain is true, expired entries are purged in the first batch
+// and terminated units are partitioned in the last batch.
+//
+// If a batch fails, the entire transaction is rolled back and DB is left as it
+// was before the update. As last update time is not advanced, same period is
+// ingested again in next update without counting units of committed batches
+// twice.
+func (s *stats) ingest(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	clusterUnits []models.ClusterUnits,
+	clusterUsers []models.ClusterUsers,
+	clusterProjects []models.ClusterProjects,
+	clusterNodes []models.ClusterNodes,
+	clusterReservations []models.ClusterReservations,
+	maintain bool,
+) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB ingestion", s.logger)
+
+	batches := batchUnits(clusterUnits, s.storage.insertBatchSize)
+
+	// All batches belong to the same update and hence, they must be inserted
+	// with the same state of DB
+	emptyDB := s.emptyDB
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+
+	// Delete older entries and free up DB pages
+	// In testing we want to skip this
+	if maintain && !s.storage.skipDeleteOldUnits {
+		s.logger.Debug("Cleaning up old entries in DB")
+
+		if err = s.purgeExpiredUnits(ctx, tx); err != nil {
+			s.logger.Error("Failed to clean up old entries", "err", err)
+		} else {
+			s.logger.Debug("Cleaned up old entries in DB")
+		}
+	}
+
+	for i, batch := range batches {
+		s.emptyDB = emptyDB
+
+		// Insert data into DB
+		s.logger.Debug("Executing SQL statements", "batch", i+1, "num_batches", len(batches))
+
+		if i == len(batches)-1 {
+			err = s.execStatements(ctx, tx, startTime, endTime, batch, clusterUsers, clusterProjects, clusterNodes, clusterReservations)
+		} else {
+			err = s.execStatements(ctx, tx, startTime, endTime, batch, nil, nil, nil, nil)
+		}
+
+		// Errors of individual statements are only logged. Stop when context
+		// has been cancelled in the middle of update as remaining statements
+		// of transaction would fail
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			s.emptyDB = emptyDB
+
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				return fmt.Errorf("failed to execute SQL statements in batch %d: %w, %w", i+1, err, rbErr)
+			}
+
+			return fmt.Errorf("failed to execute SQL statements in batch %d: %w", i+1, err)
+		}
+	}
+
+	// Move terminated units of past months into their monthly partitions
+	if maintain && s.storage.partitionUnits {
+		if err := s.partitionUnits(ctx, tx, endTime); err != nil {
+			s.logger.Error("Failed to partition units", "err", err)
+		}
+	}
+
+	// Commit changes
+	if err = tx.Commit(); err != nil {
+		s.emptyDB = emptyDB
+
+		return fmt.Errorf("failed to commit SQL transcation: %w", err)
+	}
+
+	s.logger.Debug("Finished executing SQL statements", "num_batches", len(batches))
+
 	return nil
 }
 
@@ -591,29 +1286,39 @@ func (s *stats) execStatements(
 	clusterUnits []models.ClusterUnits,
 	clusterUsers []models.ClusterUsers,
 	clusterProjects []models.ClusterProjects,
+	clusterNodes []models.ClusterNodes,
+	clusterReservations []models.ClusterReservations,
 ) error {
 	// Measure elapsed time
 	defer common.TimeTrack(time.Now(), "DB insertion", s.logger)
 
-	// Prepare statements
+	// Bind prepared statements to transaction. Statements prepared on the
+	// connection of transaction are reused and they are prepared only when
+	// they are not prepared yet
 	stmts := make(map[string]*sql.Stmt, len(prepareStatements))
 
 	var err error
 
 	for table, stmt := range prepareStatements {
-		stmts[table], err = tx.PrepareContext(ctx, stmt) //nolint:sqlclosecheck
-		if err != nil {
-			return fmt.Errorf("failed to prepare statement for table %s: %w", table, err)
+		if cached, ok := s.stmts[table]; ok {
+			stmts[table] = tx.StmtContext(ctx, cached)
+		} else {
+			stmts[table], err = tx.PrepareContext(ctx, stmt) //nolint:sqlclosecheck
+			if err != nil {
+				return fmt.Errorf("failed to prepare statement for table %s: %w", table, err)
+			}
 		}
 
 		defer stmts[table].Close()
 	}
 
-	// Get current day midnight
-	todayMidnight := currentTime.Truncate(24 * time.Hour).Format(base.DatetimeLayout)
+	// Get timestamps of current day and month of rollup tables
+	rollups := usageRollups(currentTime)
 
 	var unitIncr int
 
+	var res sql.Result
+
 	for _, cluster := range clusterUnits {
 		for _, unit := range cluster.Units {
 			// Empty unit
@@ -623,7 +1328,7 @@ func (s *stats) execStatements(
 
 			// s.logger.Debug("Inserting unit", "id", unit.Jobid)
 			// Use named parameters to not to repeat the values
-			if _, err = stmts[base.UnitsDBTableName].ExecContext(
+			if res, err = stmts[base.UnitsDBTableName].ExecContext(
 				ctx,
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["ResourceManager"], unit.ResourceManager),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
@@ -654,12 +1359,99 @@ func (s *stats) execStatements(
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
+				sql.Named(base.UnitsDBTableStructFieldColNameMap["Completeness"], unit.Completeness),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["Tags"], unit.Tags),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["Ignore"], unit.Ignore),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["NumUpdates"], 1),
 				sql.Named(base.UnitsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
 			); err != nil {
 				s.logger.Error("Failed to insert unit in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
+			} else if n, err := res.RowsAffected(); err == nil && n == 0 {
+				// Unit has already been updated for this period. This happens
+				// when same fetch window is processed more than once, for instance,
+				// after restoring DB from backup. Skip aggregating its metrics
+				// into usage tables to avoid counting them twice
+				s.logger.Debug("Unit already updated for period", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID)
+
+				continue
+			}
+
+			// Record preemption events of units
+			if unit.State == preemptedState && unit.EndedAtTS > 0 {
+				if _, err = stmts[base.PreemptionsDBTableName].ExecContext(
+					ctx,
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["ResourceManager"], unit.ResourceManager),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["UUID"], unit.UUID),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Name"], unit.Name),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Project"], unit.Project),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Group"], unit.Group),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["User"], unit.User),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["StartedAt"], unit.StartedAt),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["PreemptedAt"], unit.EndedAt),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["PreemptedAtTS"], unit.EndedAtTS),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Elapsed"], unit.Elapsed),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Allocation"], unit.Allocation),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["Tags"], unit.Tags),
+					sql.Named(base.PreemptionsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+				); err != nil {
+					s.logger.Error("Failed to insert preemption in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
+				}
+			}
+
+			// Record nodes of units so that they can be looked up by node
+			for _, node := range unitNodes(unit) {
+				if _, err = stmts[base.UnitNodesDBTableName].ExecContext(
+					ctx,
+					sql.Named("node", node),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["UUID"], unit.UUID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["StartedAtTS"], unit.StartedAtTS),
+				); err != nil {
+					s.logger.Error("Failed to insert unit node in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "node", node, "err", err)
+				}
+			}
+
+			// Record steps of units. Steps are replaced by their latest state
+			for _, step := range unit.Steps {
+				if _, err = stmts[base.UnitStepsDBTableName].ExecContext(
+					ctx,
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["StepID"], step.StepID),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["Name"], step.Name),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["State"], step.State),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["StartedAt"], step.StartedAt),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["EndedAt"], step.EndedAt),
+					sql.Named("step_started_at_ts", step.StartedAtTS),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["EndedAtTS"], step.EndedAtTS),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["Elapsed"], step.Elapsed),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["ExitCode"], step.ExitCode),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["Nodelist"], step.Nodelist),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["Allocation"], step.Allocation),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["TotalTime"], step.TotalTime),
+					sql.Named(base.UnitStepsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["UUID"], unit.UUID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["StartedAtTS"], unit.StartedAtTS),
+				); err != nil {
+					s.logger.Error("Failed to insert unit step in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "step_id", step.StepID, "err", err)
+				}
+			}
+
+			// Record script of units. Scripts do not change and hence, they are
+			// recorded only once
+			if unit.Script != nil {
+				if _, err = stmts[base.UnitScriptsDBTableName].ExecContext(
+					ctx,
+					sql.Named(base.UnitScriptsDBTableStructFieldColNameMap["Script"], unit.Script.Script),
+					sql.Named(base.UnitScriptsDBTableStructFieldColNameMap["Environment"], unit.Script.Environment),
+					sql.Named(base.UnitScriptsDBTableStructFieldColNameMap["Truncated"], unit.Script.Truncated),
+					sql.Named(base.UnitScriptsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["UUID"], unit.UUID),
+					sql.Named(base.UnitsDBTableStructFieldColNameMap["StartedAtTS"], unit.StartedAtTS),
+				); err != nil {
+					s.logger.Error("Failed to insert unit script in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
+				}
 			}
 
 			// If the unit has started in this update period, increment num units
@@ -698,33 +1490,35 @@ func (s *stats) execStatements(
 				s.logger.Error("Failed to update usage table in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
 			}
 
-			// Update DailyUsage table
-			// Use named parameters to not to repeat the values
-			if _, err = stmts[base.DailyUsageDBTableName].ExecContext(
-				ctx,
-				sql.Named(base.UsageDBTableStructFieldColNameMap["ResourceManager"], unit.ResourceManager),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["NumUnits"], unitIncr),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["Project"], unit.Project),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["User"], unit.User),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["Group"], unit.Group),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["LastUpdatedAt"], todayMidnight), // This ensures that we aggregate data for each day
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalTime"], unit.TotalTime),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUUsage"], unit.AveCPUUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUMemUsage"], unit.AveCPUMemUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEnergyUsage"], unit.TotalCPUEnergyUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEmissions"], unit.TotalCPUEmissions),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUUsage"], unit.AveGPUUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUMemUsage"], unit.AveGPUMemUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEnergyUsage"], unit.TotalGPUEnergyUsage),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEmissions"], unit.TotalGPUEmissions),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOWriteStats"], unit.TotalIOWriteStats),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
-				sql.Named(base.UsageDBTableStructFieldColNameMap["NumUpdates"], 1),
-			); err != nil {
-				s.logger.Error("Failed to update daily_usage table in DB", "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
+			// Update daily and monthly usage rollup tables so that usage over long
+			// periods can be estimated without aggregating all the units
+			for table, lastUpdatedAt := range rollups {
+				if _, err = stmts[table].ExecContext(
+					ctx,
+					sql.Named(base.UsageDBTableStructFieldColNameMap["ResourceManager"], unit.ResourceManager),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["NumUnits"], unitIncr),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["Project"], unit.Project),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["User"], unit.User),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["Group"], unit.Group),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["LastUpdatedAt"], lastUpdatedAt), // This ensures that we aggregate data for each day/month
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalTime"], unit.TotalTime),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUUsage"], unit.AveCPUUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["AveCPUMemUsage"], unit.AveCPUMemUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEnergyUsage"], unit.TotalCPUEnergyUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalCPUEmissions"], unit.TotalCPUEmissions),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUUsage"], unit.AveGPUUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["AveGPUMemUsage"], unit.AveGPUMemUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEnergyUsage"], unit.TotalGPUEnergyUsage),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalGPUEmissions"], unit.TotalGPUEmissions),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOWriteStats"], unit.TotalIOWriteStats),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIOReadStats"], unit.TotalIOReadStats),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalIngressStats"], unit.TotalIngressStats),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["TotalOutgressStats"], unit.TotalOutgressStats),
+					sql.Named(base.UsageDBTableStructFieldColNameMap["NumUpdates"], 1),
+				); err != nil {
+					s.logger.Error("Failed to update usage rollup table in DB", "table", table, "cluster_id", cluster.Cluster.ID, "uuid", unit.UUID, "err", err)
+				}
 			}
 		}
 	}
@@ -765,15 +1559,89 @@ func (s *stats) execStatements(
 		}
 	}
 
-	// Update admin users table
-	for _, source := range AdminUsersSources {
-		if _, err = stmts[base.AdminUsersDBTableName].ExecContext(
-			ctx,
-			sql.Named(base.AdminUsersDBTableStructFieldColNameMap["Source"], source),
-			sql.Named(base.AdminUsersDBTableStructFieldColNameMap["Users"], s.admin.users[source]),
-			sql.Named(base.AdminUsersDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+	// Update nodes. Node stats are aggregated into hourly periods based on
+	// start time of the update interval
+	periodStart := startTime.Truncate(time.Hour)
+
+	for _, cluster := range clusterNodes {
+		for _, node := range cluster.Nodes {
+			if _, err = stmts[base.NodesDBTableName].ExecContext(
+				ctx,
+				sql.Named(base.NodesDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["ResourceManager"], cluster.Cluster.Manager),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["Hostname"], node.Hostname),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["PeriodStart"], periodStart.Format(base.DatetimeLayout)),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["PeriodStartTS"], periodStart.UnixMilli()),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["TotalTime"], node.TotalTime),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["TotalEnergyUsage"], node.TotalEnergyUsage),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["TotalEmissions"], node.TotalEmissions),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["NumUpdates"], 1),
+				sql.Named(base.NodesDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+			); err != nil {
+				s.logger.Error("Failed to update nodes table in DB", "cluster_id", cluster.Cluster.ID, "hostname", node.Hostname, "err", err)
+			}
+		}
+	}
+
+	// Update reservations. Usage of reservations is accumulated over update intervals
+	for _, cluster := range clusterReservations {
+		for _, resv := range cluster.Reservations {
+			// Statement returns the project of reservation which can be known from
+			// previous updates when resource manager does not report it anymore
+			var project string
+			if err = stmts[base.ReservationsDBTableName].QueryRowContext(
+				ctx,
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["ClusterID"], cluster.Cluster.ID),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["ResourceManager"], cluster.Cluster.Manager),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["Name"], resv.Name),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["Project"], resv.Project),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["StartedAt"], resv.StartedAt),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["EndedAt"], resv.EndedAt),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["StartedAtTS"], resv.StartedAtTS),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["EndedAtTS"], resv.EndedAtTS),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["Nodes"], resv.Nodes),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["Allocation"], resv.Allocation),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["ReservedTime"], resv.ReservedTime),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["AllocatedTime"], resv.AllocatedTime),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["IdleTime"], resv.IdleTime),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["NumUpdates"], 1),
+				sql.Named(base.ReservationsDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+			).Scan(&project); err != nil {
+				s.logger.Error("Failed to update reservations table in DB", "cluster_id", cluster.Cluster.ID, "reservation", resv.Name, "err", err)
+
+				continue
+			}
+
+			// Bill idle time of reservation to the project that reserved it
+			if s.storage.billIdleResv {
+				s.billIdleReservation(ctx, stmts, cluster.Cluster, project, resv, currentTime)
+			}
+		}
+	}
+
+	// Replace roles of admin users of each source so that users who are not
+	// admins anymore in that source are removed
+	for _, source := range AdminRoleSources {
+		//nolint:gosec
+		if _, err = tx.ExecContext(
+			ctx, fmt.Sprintf("DELETE FROM %s WHERE source = ?", base.RolesDBTableName), source,
 		); err != nil {
-			s.logger.Error("Failed to update admin_users table in DB", "source", source, "err", err)
+			s.logger.Error("Failed to remove roles from DB", "source", source, "err", err)
+
+			continue
+		}
+
+		for _, user := range s.admin.users[source] {
+			if _, err = stmts[base.RolesDBTableName].ExecContext(
+				ctx,
+				sql.Named(base.RolesDBTableStructFieldColNameMap["User"], user),
+				sql.Named(base.RolesDBTableStructFieldColNameMap["Role"], "admin"),
+				sql.Named(base.RolesDBTableStructFieldColNameMap["Source"], source),
+				sql.Named(base.RolesDBTableStructFieldColNameMap["UpdatedBy"], ""),
+				sql.Named(base.RolesDBTableStructFieldColNameMap["LastUpdatedAt"], currentTime.Format(base.DatetimeLayout)),
+			); err != nil {
+				s.logger.Error("Failed to update roles table in DB", "source", source, "user", user, "err", err)
+			}
 		}
 	}
 
@@ -800,7 +1668,7 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 	backupDBFile.Close()
 
 	// Open a second sqlite3 database at the backup location
-	destDB, destConn, err := openDBConnection(backupDBPath)
+	destDB, destConn, err := openDBConnection(backupDBPath, defaultOpts)
 	if err != nil {
 		return err
 	}
@@ -809,11 +1677,19 @@ func (s *stats) backup(ctx context.Context, backupDBPath string) error {
 	// also close the underlying sqlite3 connection.
 	defer destDB.Close()
 
+	return s.copyDB(ctx, destConn, s.dbConn)
+}
+
+// copyDB copies the content of srcConn DB into destConn DB using SQLite online
+// backup API.
+func (s *stats) copyDB(ctx context.Context, destConn, srcConn *ceems_sqlite3.Conn) error {
+	var err error
+
 	// Create the backup manager into the destination db from the src connection.
 	// NOTE: backup.Finish() MUST be called to prevent panics.
 	var backup *sqlite3.SQLiteBackup
 
-	if backup, err = destConn.Backup(sqlite3Main, s.dbConn, sqlite3Main); err != nil {
+	if backup, err = destConn.Backup(sqlite3Main, srcConn, sqlite3Main); err != nil {
 		return err
 	}
 
@@ -895,3 +1771,140 @@ func (s *stats) createBackup(ctx context.Context) error {
 
 	return nil
 }
+
+// checkIntegrity runs integrity check on DB and restores DB from the latest
+// backup if corruption is found and restore from backup is enabled.
+func (s *stats) checkIntegrity(ctx context.Context) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB integrity check", s.logger)
+
+	problems, err := s.integrityProblems(ctx, s.storage.quickIntegrity)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		s.logger.Debug("DB integrity check passed")
+
+		return nil
+	}
+
+	s.logger.Error("DB integrity check failed", "num_errors", len(problems), "errors", strings.Join(problems, ";"))
+
+	// Restoring DB is only done when operator explicitly enabled it
+	if !s.storage.restoreFromBackup || s.storage.dbBackupPath == "" {
+		return ErrCorruptDB
+	}
+
+	if err := s.restore(ctx); err != nil {
+		return fmt.Errorf("failed to restore DB from backup: %w", err)
+	}
+
+	return nil
+}
+
+// integrityProblems runs integrity check on DB, updates integrity check metrics
+// and returns the problems found.
+func (s *stats) integrityProblems(ctx context.Context, quick bool) ([]string, error) {
+	problems, err := integrityCheck(ctx, s.db, quick)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run DB integrity check: %w", err)
+	}
+
+	integrityCheckTimestamp.SetToCurrentTime()
+	integrityCheckErrors.Set(float64(len(problems)))
+
+	return problems, nil
+}
+
+// repair rebuilds all the indexes of DB as most of the corruptions after an
+// unclean shutdown are found in indexes. If DB is still corrupt, it is restored
+// from the latest valid backup when backup path is configured.
+func (s *stats) repair(ctx context.Context) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB repair", s.logger)
+
+	if _, err := s.db.ExecContext(ctx, "REINDEX"); err != nil {
+		s.logger.Error("Failed to rebuild DB indexes", "err", err)
+	}
+
+	// Always run a full check after rebuilding indexes
+	problems, err := s.integrityProblems(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		reindexesTotal.Inc()
+
+		s.logger.Info("DB indexes rebuilt and integrity check passed")
+
+		return nil
+	}
+
+	s.logger.Error("DB integrity check failed after rebuilding indexes", "num_errors", len(problems), "errors", strings.Join(problems, ";"))
+
+	if s.storage.dbBackupPath == "" {
+		return ErrCorruptDB
+	}
+
+	if err := s.restore(ctx); err != nil {
+		return fmt.Errorf("failed to restore DB from backup: %w", err)
+	}
+
+	return nil
+}
+
+// restore restores DB from the latest valid backup in backup path.
+func (s *stats) restore(ctx context.Context) error {
+	// Backup files are suffixed with timestamp and so sorting them by name
+	// gives us the latest backup at the end
+	backupFiles, err := filepath.Glob(
+		filepath.Join(s.storage.dbBackupPath, strings.Split(base.CEEMSDBName, ".")[0]+"-*.db"),
+	)
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(backupFiles)))
+
+	for _, backupFile := range backupFiles {
+		if err := s.restoreFrom(ctx, backupFile); err != nil {
+			s.logger.Error("Failed to restore DB from backup", "file", backupFile, "err", err)
+
+			continue
+		}
+
+		restoresTotal.Inc()
+
+		// Units since the backup must be fetched again from resource manager(s)
+		var lastUpdatedAt string
+		if err := s.db.QueryRowContext(ctx, "SELECT MAX(last_updated_at) FROM "+base.UsageDBTableName).Scan(&lastUpdatedAt); err == nil {
+			if t, err := time.ParseInLocation(base.DatetimeLayout, lastUpdatedAt, s.storage.timeLocation); err == nil {
+				s.storage.lastUpdateTime = t
+			}
+		}
+
+		s.logger.Warn("DB restored from backup", "file", backupFile, "last_update", s.storage.lastUpdateTime)
+
+		return nil
+	}
+
+	return ErrNoBackup
+}
+
+// restoreFrom copies the content of backup DB file into current DB after
+// checking the integrity of backup DB.
+func (s *stats) restoreFrom(ctx context.Context, backupFile string) error {
+	srcDB, srcConn, err := openDBConnection(backupFile, defaultOpts)
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	if problems, err := integrityCheck(ctx, srcDB, true); err != nil || len(problems) > 0 {
+		return errors.Join(ErrCorruptDB, err)
+	}
+
+	return s.copyDB(ctx, s.dbConn, srcConn)
+}
@@ -5,23 +5,31 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/grafana"
+	ceems_sqlite3 "github.com/mahendrapaipuri/ceems/pkg/sqlite3"
 	config_util "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
@@ -589,8 +597,8 @@ func populateDBWithMockData(s *stats) error {
 		return err
 	}
 
-	s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), mockUnitsOne, mockUsersOne, mockProjectsOne)
-	s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), mockUnitsTwo, nil, nil)
+	s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), mockUnitsOne, mockUsersOne, mockProjectsOne, nil, nil)
+	s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), mockUnitsTwo, nil, nil, nil, nil)
 	tx.Commit()
 
 	return nil
@@ -635,6 +643,32 @@ func TestNewUnitStatsDB(t *testing.T) {
 	s.Stop()
 }
 
+func TestNewUnitStatsDBRecovery(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Write a corrupt DB file
+	dbPath := filepath.Join(c.Data.Path, base.CEEMSDBName)
+	err = os.WriteFile(dbPath, []byte("this is not a sqlite db file but a corrupt one"), 0o600)
+	require.NoError(t, err)
+
+	// Make new stats DB with recovery enabled
+	c.Data.RecoveryPeriod = model.Duration(48 * time.Hour)
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	// Corrupt file must be moved aside
+	matches, err := filepath.Glob(dbPath + ".corrupt-*")
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	// Last update time must be moved back by recovery period
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), s.storage.lastUpdateTime, 2*time.Minute)
+}
+
 func TestUnitStatsDBEntries(t *testing.T) {
 	tmpDir := t.TempDir()
 	c, err := prepareMockConfig(tmpDir)
@@ -749,6 +783,78 @@ func TestUnitStatsDBEntries(t *testing.T) {
 	s.Stop()
 }
 
+func TestUsageRollups(t *testing.T) {
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	require.NoError(t, s.Collect(context.Background()), "failed to collect units data")
+
+	// Rollup tables must have the same usage as usage table
+	for _, table := range []string{base.DailyUsageDBTableName, base.MonthlyUsageDBTableName} {
+		var numRows, numUnits int64
+
+		require.NoError(t, s.db.QueryRow("SELECT COUNT(*), SUM(num_units) FROM "+table).Scan(&numRows, &numUnits))
+
+		var expectedRows, expectedUnits int64
+
+		require.NoError(t, s.db.QueryRow("SELECT COUNT(*), SUM(num_units) FROM usage").Scan(&expectedRows, &expectedUnits))
+		assert.Equal(t, expectedRows, numRows, table)
+		assert.Equal(t, expectedUnits, numUnits, table)
+	}
+
+	assert.Equal(t, map[string]string{
+		base.DailyUsageDBTableName:   "2024-02-15T00:00:00",
+		base.MonthlyUsageDBTableName: "2024-02-01T00:00:00",
+	}, usageRollups(time.Date(2024, 2, 15, 10, 30, 0, 0, time.UTC)))
+}
+
+func TestMonthlyUsageBackfill(t *testing.T) {
+	db, err := sql.Open(ceems_sqlite3.DriverName, filepath.Join(t.TempDir(), base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	migrator, err := db_migrator.New(MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.MigrateTo(db, 17))
+
+	_, err = db.Exec(`INSERT INTO daily_usage (cluster_id,project,username,num_units,total_time_seconds,avg_cpu_usage,total_cpu_energy_usage_kwh,num_updates,last_updated_at) VALUES
+	('slurm-0','prj1','usr1',1,'{"alloc_cputime":10}','{"usage":10}','{"total":1}',1,'2024-01-01T00:00:00'),
+	('slurm-0','prj1','usr1',2,'{"alloc_cputime":30}','{"usage":20}','{"total":2}',2,'2024-01-15T00:00:00'),
+	('slurm-0','prj1','usr1',1,'{"alloc_cputime":10}','{"usage":50}','{"total":4}',1,'2024-02-01T00:00:00')`)
+	require.NoError(t, err)
+
+	// Monthly usage must be backfilled from daily usage
+	require.NoError(t, migrator.ApplyMigrations(db))
+
+	rows, err := db.Query("SELECT num_units,avg_cpu_usage,total_cpu_energy_usage_kwh,num_updates,last_updated_at FROM monthly_usage ORDER BY last_updated_at")
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	var usage []models.Usage
+
+	for rows.Next() {
+		var u models.Usage
+		require.NoError(t, rows.Scan(&u.NumUnits, &u.AveCPUUsage, &u.TotalCPUEnergyUsage, &u.NumUpdates, &u.LastUpdatedAt))
+
+		usage = append(usage, u)
+	}
+
+	require.NoError(t, rows.Err())
+	require.Len(t, usage, 2)
+	assert.Equal(t, int64(3), usage[0].NumUnits)
+	assert.InEpsilon(t, 17.5, float64(usage[0].AveCPUUsage["usage"]), 0)
+	assert.InEpsilon(t, 3, float64(usage[0].TotalCPUEnergyUsage["total"]), 0)
+	assert.Equal(t, "2024-01-01T00:00:00", usage[0].LastUpdatedAt)
+	assert.Equal(t, "2024-02-01T00:00:00", usage[1].LastUpdatedAt)
+}
+
 func TestCollectContextCancellation(t *testing.T) {
 	tmpDir := t.TempDir()
 	c, err := prepareMockConfig(tmpDir)
@@ -914,7 +1020,7 @@ func TestUnitStatsDBBackup(t *testing.T) {
 	// Check contents of backed up DB
 	var numRows int
 
-	db, _, err := openDBConnection(expectedBackupFile)
+	db, _, err := openDBConnection(expectedBackupFile, defaultOpts)
 	if err != nil {
 		t.Errorf("Failed to create DB connection to backup DB: %s", err)
 	}
@@ -932,6 +1038,157 @@ func TestUnitStatsDBBackup(t *testing.T) {
 	assert.Equal(t, 7, numRows, "Backup DB check failed. Expected rows 7")
 }
 
+func TestUnitStatsDBIntegrityAndRestore(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.RestoreFromBackup = true
+
+	// Make new stats DB
+	s, err := New(c)
+	require.NoError(t, err, "Failed to create new stats")
+
+	defer s.Stop()
+
+	// Populate DB with data
+	err = populateDBWithMockData(s)
+	require.NoError(t, err, "failed to insert data in test DB")
+
+	// Integrity check must pass on a healthy DB
+	err = s.CheckIntegrity(context.Background())
+	require.NoError(t, err)
+
+	// Restore without backups must fail
+	err = s.restore(context.Background())
+	require.ErrorIs(t, err, ErrNoBackup)
+
+	// Create a backup and remove units from current DB
+	err = s.createBackup(context.Background())
+	require.NoError(t, err, "failed to backup DB")
+
+	_, err = s.db.Exec("DELETE FROM " + base.UnitsDBTableName)
+	require.NoError(t, err)
+
+	// Restore DB from backup
+	err = s.restore(context.Background())
+	require.NoError(t, err, "failed to restore DB")
+
+	var numRows int
+
+	err = s.db.QueryRow("SELECT COUNT(*) FROM " + base.UnitsDBTableName).Scan(&numRows) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, 7, numRows, "Restored DB check failed. Expected rows 7")
+
+	// Integrity checks must wait for ongoing DB updates
+	s.mu.Lock()
+
+	done := make(chan error)
+	go func() {
+		done <- s.CheckIntegrity(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("integrity check did not wait for DB update")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.mu.Unlock()
+	require.NoError(t, <-done)
+}
+
+func TestUnitStatsDBMaintenanceWaitsForUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	for name, op := range map[string]func(context.Context) error{
+		"vacuum": s.Vacuum,
+		"purge":  s.Purge,
+		"repair": s.Repair,
+	} {
+		// Maintenance operations must wait for ongoing DB updates
+		s.mu.Lock()
+
+		done := make(chan error)
+		go func() {
+			done <- op(context.Background())
+		}()
+
+		select {
+		case <-done:
+			t.Fatalf("%s did not wait for DB update", name)
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		s.mu.Unlock()
+		require.NoError(t, <-done, name)
+	}
+}
+
+func TestUnitStatsDBRepair(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.QuickIntegrityCheck = true
+	c.Data.UnitsIndexes = [][]string{{"username"}}
+
+	// Make new stats DB
+	s, err := New(c)
+	require.NoError(t, err, "Failed to create new stats")
+
+	defer s.Stop()
+
+	// Populate DB with data
+	err = populateDBWithMockData(s)
+	require.NoError(t, err, "failed to insert data in test DB")
+
+	// Quick integrity check must pass on a healthy DB
+	err = s.CheckIntegrity(context.Background())
+	require.NoError(t, err)
+
+	// Corrupt an index by changing its definition so that its entries do not
+	// match the table anymore. Bumping schema version reloads the schema
+	var version int
+
+	err = s.db.QueryRow("PRAGMA schema_version").Scan(&version)
+	require.NoError(t, err)
+
+	for _, stmt := range []string{
+		"PRAGMA writable_schema = ON",
+		"UPDATE sqlite_master SET sql = 'CREATE INDEX auto_idx_units_username ON units (project)' WHERE name = 'auto_idx_units_username'",
+		fmt.Sprintf("PRAGMA schema_version = %d", version+1),
+		"PRAGMA writable_schema = OFF",
+	} {
+		_, err = s.db.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	problems, err := integrityCheck(context.Background(), s.db, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, problems)
+
+	// Quick check does not verify the content of indexes
+	err = s.CheckIntegrity(context.Background())
+	require.NoError(t, err)
+
+	// Repair must rebuild indexes and pass integrity check
+	err = s.Repair(context.Background())
+	require.NoError(t, err)
+
+	problems, err = integrityCheck(context.Background(), s.db, false)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
 func TestAdminUsersDBUpdate(t *testing.T) {
 	// Start test server
 	expected := []grafana.GrafanaTeamsReponse{
@@ -978,6 +1235,169 @@ func TestAdminUsersDBUpdate(t *testing.T) {
 	assert.ElementsMatch(t, s.admin.users["grafana"], models.List{"foo", "bar"})
 }
 
+func TestAdminRolesDBUpdate(t *testing.T) {
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Admin.Users = []string{"adm1"}
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	// Mock LDAP group members
+	members := []string{"ldap1", "ldap2"}
+
+	var ldapErr error
+
+	s.admin.ldapMembers = func(_ context.Context) ([]string, error) {
+		return members, ldapErr
+	}
+
+	// roles returns admin roles of each source in DB
+	roles := func() map[string][]string {
+		rows, err := s.db.Query("SELECT username, source FROM roles WHERE role = 'admin' ORDER BY username")
+		require.NoError(t, err)
+
+		defer rows.Close()
+
+		got := make(map[string][]string)
+
+		for rows.Next() {
+			var user, source string
+			require.NoError(t, rows.Scan(&user, &source))
+
+			got[source] = append(got[source], user)
+		}
+
+		return got
+	}
+
+	require.NoError(t, s.Collect(context.Background()))
+	assert.Equal(t, map[string][]string{"ceems": {"adm1"}, "ldap": {"ldap1", "ldap2"}}, roles())
+
+	// Users removed from LDAP group must lose their roles
+	members = []string{"ldap2"}
+
+	require.NoError(t, s.Collect(context.Background()))
+	assert.Equal(t, map[string][]string{"ceems": {"adm1"}, "ldap": {"ldap2"}}, roles())
+
+	// Roles must be retained when LDAP server is unavailable
+	ldapErr = errors.New("ldap server unavailable")
+
+	require.NoError(t, s.Collect(context.Background()))
+	assert.Equal(t, map[string][]string{"ceems": {"adm1"}, "ldap": {"ldap2"}}, roles())
+
+	// Roles assigned using API must not be touched
+	_, err = s.db.Exec("INSERT INTO roles (username,role,source,updated_by,last_updated_at) VALUES ('usr1','admin','api','adm1','')")
+	require.NoError(t, err)
+
+	require.NoError(t, s.Collect(context.Background()))
+	assert.Equal(t, []string{"usr1"}, roles()["api"])
+}
+
+func TestAdminUsersRolesMigration(t *testing.T) {
+	db, err := sql.Open(ceems_sqlite3.DriverName, filepath.Join(t.TempDir(), base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	migrator, err := db_migrator.New(MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.MigrateTo(db, 18))
+
+	_, err = db.Exec(`INSERT INTO admin_users (source,users,last_updated_at) VALUES ('ceems','["adm1","adm2"]',''),('grafana','["adm2"]','')`)
+	require.NoError(t, err)
+
+	// Admin users must be migrated to roles table
+	require.NoError(t, migrator.ApplyMigrations(db))
+
+	var numRoles int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM roles WHERE role = 'admin'").Scan(&numRoles))
+	assert.Equal(t, 3, numRoles)
+
+	// Admin users must be restored when migration is reverted
+	require.NoError(t, migrator.MigrateTo(db, 18))
+
+	var users models.List
+	require.NoError(t, db.QueryRow("SELECT users FROM admin_users WHERE source = 'ceems'").Scan(&users))
+	assert.ElementsMatch(t, models.List{"adm1", "adm2"}, users)
+}
+
+func TestGrafanaTeamsSync(t *testing.T) {
+	var mu sync.Mutex
+
+	teams := make(map[string][]string)
+	teamNames := make(map[string]string)
+	permissions := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var req map[string]any
+
+		json.NewDecoder(r.Body).Decode(&req)
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+		switch {
+		case r.URL.Path == "/api/teams/search":
+			w.Write([]byte(`{"teams":[]}`))
+		case r.URL.Path == "/api/search":
+			w.Write([]byte("[]"))
+		case r.URL.Path == "/api/teams":
+			id := len(teamNames) + 1
+			teamNames[strconv.Itoa(id)] = req["name"].(string)
+
+			json.NewEncoder(w).Encode(map[string]int{"teamId": id})
+		case r.URL.Path == "/api/users/lookup":
+			json.NewEncoder(w).Encode(map[string]any{"id": len(r.URL.Query().Get("loginOrEmail"))})
+		case r.URL.Path == "/api/folders":
+			json.NewEncoder(w).Encode(map[string]any{"uid": req["title"]})
+		case parts[1] == "teams" && r.Method == http.MethodGet:
+			w.Write([]byte("[]"))
+		case parts[1] == "teams" && r.Method == http.MethodPost:
+			teams[teamNames[parts[2]]] = append(teams[teamNames[parts[2]]], fmt.Sprint(req["userId"]))
+		case parts[1] == "access-control":
+			permissions[parts[3]] = req["permission"].(string)
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	s.admin.grafana, err = grafana.New(server.URL, config_util.HTTPClientConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// When sync is disabled, Grafana must not be contacted
+	err = s.syncGrafanaTeams(context.Background(), mockProjectsOne)
+	require.NoError(t, err)
+	assert.Empty(t, teamNames)
+
+	s.admin.grafanaTeamSync = GrafanaTeamSyncConfig{
+		Enabled:          true,
+		TeamNamePrefix:   "ceems-",
+		Folders:          true,
+		FolderPermission: grafana.FolderPermissionView,
+	}
+
+	err = s.syncGrafanaTeams(context.Background(), mockProjectsOne)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"ceems-slurm-0-fooprj", "ceems-slurm-0-barprj", "ceems-slurm-1-fooprj", "ceems-slurm-1-barprj"}, slices.Collect(maps.Values(teamNames)))
+	assert.Len(t, teams["ceems-slurm-0-fooprj"], 2)
+	assert.Equal(t, "View", permissions["ceems-slurm-1-barprj"])
+}
+
 func TestStatsDBBackup(t *testing.T) {
 	tmpDir := t.TempDir()
 	c, err := prepareMockConfig(tmpDir)
@@ -1021,6 +1441,9 @@ func TestUnitStatsDeleteOldUnits(t *testing.T) {
 				{
 					UUID:      unitID,
 					StartedAt: time.Now().Add(-s.storage.retentionPeriod * 2).Format(base.DatetimeLayout),
+					Tags:      models.Tag{"nodelistexp": "compute-0|compute-1"},
+					Steps:     []models.UnitStep{{StepID: "batch", State: "COMPLETED"}},
+					Script:    &models.UnitScript{Script: "#!/bin/bash"},
 				},
 			},
 		},
@@ -1030,8 +1453,26 @@ func TestUnitStatsDeleteOldUnits(t *testing.T) {
 	require.NoError(t, err)
 	// stmtMap, err := s.prepareStatements(ctx, tx)
 	// require.NoError(t, err)
-	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// Nodes of unit must be recorded
+	var numNodes int
+	err = tx.QueryRow("SELECT COUNT(node) FROM " + base.UnitNodesDBTableName).Scan(&numNodes)
+	require.NoError(t, err)
+	assert.Equal(t, 2, numNodes)
+
+	// Steps of unit must be recorded
+	var numSteps int
+	err = tx.QueryRow("SELECT COUNT(step_id) FROM " + base.UnitStepsDBTableName).Scan(&numSteps)
 	require.NoError(t, err)
+	assert.Equal(t, 1, numSteps)
+
+	// Script of unit must be recorded
+	var numScripts int
+	err = tx.QueryRow("SELECT COUNT(unit_id) FROM " + base.UnitScriptsDBTableName).Scan(&numScripts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numScripts)
 
 	// Now clean up DB for old units
 	err = s.purgeExpiredUnits(ctx, tx)
@@ -1049,4 +1490,523 @@ func TestUnitStatsDeleteOldUnits(t *testing.T) {
 	err = result.QueryRow(unitID).Scan(&numRows)
 	require.NoError(t, err, "failed to query DB")
 	assert.Equal(t, 0, numRows, "expected 0 rows after deletion")
+
+	// Nodes, steps and script of deleted unit must be deleted as well
+	err = s.db.QueryRow("SELECT COUNT(node) FROM " + base.UnitNodesDBTableName).Scan(&numNodes)
+	require.NoError(t, err)
+	assert.Equal(t, 0, numNodes)
+
+	err = s.db.QueryRow("SELECT COUNT(step_id) FROM " + base.UnitStepsDBTableName).Scan(&numSteps)
+	require.NoError(t, err)
+	assert.Equal(t, 0, numSteps)
+
+	err = s.db.QueryRow("SELECT COUNT(unit_id) FROM " + base.UnitScriptsDBTableName).Scan(&numScripts)
+	require.NoError(t, err)
+	assert.Equal(t, 0, numScripts)
+}
+
+func TestUnitStatsDBPurgeAndVacuum(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "default"},
+			Units: []models.Unit{
+				{UUID: "1111", StartedAt: time.Now().Add(-s.storage.retentionPeriod * 2).Format(base.DatetimeLayout)},
+				{UUID: "2222", StartedAt: time.Now().Format(base.DatetimeLayout), StartedAtTS: time.Now().UnixMilli()},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	err = s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// Purge expired entries and vacuum DB
+	require.NoError(t, s.Purge(ctx))
+	require.NoError(t, s.Vacuum(ctx))
+
+	var uuids []string
+
+	rows, err := s.db.Query("SELECT uuid FROM units")
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var uuid string
+		require.NoError(t, rows.Scan(&uuid))
+
+		uuids = append(uuids, uuid)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"2222"}, uuids)
+}
+
+func TestUnitStatsDBIdempotentUpserts(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	started := time.Now().Add(-time.Hour)
+	unit := models.Unit{
+		UUID:        "1111",
+		Project:     "prj",
+		User:        "usr",
+		StartedAt:   started.Format(base.DatetimezoneLayout),
+		StartedAtTS: started.UnixMilli(),
+		TotalTime:   models.MetricMap{"walltime": 60, "alloc_cputime": 60, "alloc_cpumemtime": 60, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+	}
+
+	ctx := context.Background()
+	start := time.Now().Add(-15 * time.Minute).Truncate(time.Second)
+	end := start.Add(15 * time.Minute)
+
+	// Process same window twice and then the same unit with a start time
+	// formatted in a different time zone in next window
+	for _, w := range []struct {
+		started string
+		end     time.Time
+	}{
+		{unit.StartedAt, end},
+		{unit.StartedAt, end},
+		{started.UTC().Format(base.DatetimezoneLayout), end.Add(15 * time.Minute)},
+	} {
+		u := unit
+		u.StartedAt = w.started
+
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+
+		err = s.execStatements(ctx, tx, start, w.end, []models.ClusterUnits{{Cluster: models.Cluster{ID: "default"}, Units: []models.Unit{u}}}, nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	}
+
+	var numRows, numUpdates int
+
+	var totalTime models.MetricMap
+
+	err = s.db.QueryRow("SELECT COUNT(id), SUM(num_updates) FROM units WHERE uuid = '1111'").Scan(&numRows, &numUpdates)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numRows)
+	assert.Equal(t, 2, numUpdates)
+
+	err = s.db.QueryRow("SELECT total_time_seconds, num_updates FROM usage WHERE username = 'usr'").Scan(&totalTime, &numUpdates)
+	require.NoError(t, err)
+	assert.Equal(t, models.JSONFloat(120), totalTime["walltime"])
+	assert.Equal(t, 2, numUpdates)
+}
+
+func TestUnitStatsDBInject(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	// Make new stats DB
+	s, err := New(c)
+	defer s.Stop()
+	require.NoError(t, err, "failed to create new stats")
+
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-2 * time.Hour)
+	started := start.Add(time.Minute)
+	cluster := models.Cluster{ID: "synthetic-0", Manager: "slurm"}
+
+	units := []models.ClusterUnits{
+		{
+			Cluster: cluster,
+			Units: []models.Unit{
+				{
+					UUID:        "1",
+					Project:     "prj",
+					User:        "usr",
+					StartedAt:   started.Format(base.DatetimezoneLayout),
+					StartedAtTS: started.UnixMilli(),
+					EndedAtTS:   end.UnixMilli(),
+					TotalTime:   models.MetricMap{"walltime": 60, "alloc_cputime": 60, "alloc_cpumemtime": 60, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+				},
+			},
+		},
+	}
+	users := []models.ClusterUsers{
+		{Cluster: cluster, Users: []models.User{{Name: "usr", Projects: models.List{"prj"}}}},
+	}
+	projects := []models.ClusterProjects{
+		{Cluster: cluster, Projects: []models.Project{{Name: "prj", Users: models.List{"usr"}}}},
+	}
+
+	require.NoError(t, s.Inject(context.Background(), start, end, units, users, projects))
+
+	var numUnits, numUsage, numUsers int
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM units WHERE cluster_id = 'synthetic-0'").Scan(&numUnits))
+	require.NoError(t, s.db.QueryRow("SELECT num_units FROM usage WHERE username = 'usr'").Scan(&numUsage))
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM users WHERE name = 'usr'").Scan(&numUsers))
+	assert.Equal(t, 1, numUnits)
+	assert.Equal(t, 1, numUsage)
+	assert.Equal(t, 1, numUsers)
+
+	// Canceled context must not leave any partial data
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	units[0].Units[0].UUID = "2"
+	require.Error(t, s.Inject(ctx, start, end, units, users, projects))
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM units").Scan(&numUnits))
+	assert.Equal(t, 1, numUnits)
+}
+
+func TestBatchUnits(t *testing.T) {
+	units := func(uuids ...string) []models.Unit {
+		var us []models.Unit
+		for _, uuid := range uuids {
+			us = append(us, models.Unit{UUID: uuid})
+		}
+
+		return us
+	}
+
+	clusterUnits := []models.ClusterUnits{
+		{Cluster: models.Cluster{ID: "rm-0"}, Units: units("1", "2", "3")},
+		{Cluster: models.Cluster{ID: "rm-1"}, Units: units("4", "5")},
+	}
+
+	// Without batch size all units must be in a single batch
+	assert.Equal(t, [][]models.ClusterUnits{clusterUnits}, batchUnits(clusterUnits, 0))
+
+	// Batches must split units of clusters
+	assert.Equal(t, [][]models.ClusterUnits{
+		{{Cluster: models.Cluster{ID: "rm-0"}, Units: units("1", "2")}},
+		{{Cluster: models.Cluster{ID: "rm-0"}, Units: units("3")}, {Cluster: models.Cluster{ID: "rm-1"}, Units: units("4")}},
+		{{Cluster: models.Cluster{ID: "rm-1"}, Units: units("5")}},
+	}, batchUnits(clusterUnits, 2))
+
+	// There must be always a batch even without units
+	assert.Len(t, batchUnits(nil, 2), 1)
+}
+
+func TestUnitStatsDBInjectBatches(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.InsertBatchSize = 2
+
+	// Make new stats DB
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-time.Hour)
+	cluster := models.Cluster{ID: "synthetic-0", Manager: "slurm"}
+
+	var units []models.Unit
+	for i := range 5 {
+		units = append(units, models.Unit{
+			UUID:        strconv.Itoa(i),
+			Project:     "prj",
+			User:        "usr",
+			StartedAtTS: start.Add(time.Minute).UnixMilli(),
+			TotalTime:   models.MetricMap{"walltime": 60, "alloc_cputime": 60, "alloc_cpumemtime": 60, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+		})
+	}
+
+	users := []models.ClusterUsers{
+		{Cluster: cluster, Users: []models.User{{Name: "usr", Projects: models.List{"prj"}}}},
+	}
+
+	// All units must be inserted and counted in usage even when they are in
+	// different batches
+	require.NoError(t, s.Inject(context.Background(), start, end, []models.ClusterUnits{{Cluster: cluster, Units: units}}, users, nil))
+
+	var numUnits, numUsage, numUsers int
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM units").Scan(&numUnits))
+	require.NoError(t, s.db.QueryRow("SELECT num_units FROM usage WHERE username = 'usr'").Scan(&numUsage))
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM users").Scan(&numUsers))
+	assert.Equal(t, 5, numUnits)
+	assert.Equal(t, 5, numUsage)
+	assert.Equal(t, 1, numUsers)
+}
+
+// cancelBatchHandler cancels context when batch of given number is executed.
+type cancelBatchHandler struct {
+	slog.Handler
+	batch  int64
+	cancel context.CancelFunc
+}
+
+func (h *cancelBatchHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *cancelBatchHandler) Handle(_ context.Context, r slog.Record) error {
+	r.Attrs(func(a slog.Attr) bool {
+		if r.Message == "Executing SQL statements" && a.Key == "batch" && a.Value.Int64() == h.batch {
+			h.cancel()
+
+			return false
+		}
+
+		return true
+	})
+
+	return nil
+}
+
+func TestUnitStatsDBInjectBatchesFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.InsertBatchSize = 2
+
+	// Make new stats DB
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-time.Hour)
+	cluster := models.Cluster{ID: "synthetic-0", Manager: "slurm"}
+
+	var units []models.Unit
+	for i := range 5 {
+		units = append(units, models.Unit{
+			UUID:        strconv.Itoa(i),
+			Project:     "prj",
+			User:        "usr",
+			StartedAtTS: start.Add(time.Minute).UnixMilli(),
+			TotalTime:   models.MetricMap{"walltime": 60, "alloc_cputime": 60, "alloc_cpumemtime": 60, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+		})
+	}
+
+	clusterUnits := []models.ClusterUnits{{Cluster: cluster, Units: units}}
+	users := []models.ClusterUsers{
+		{Cluster: cluster, Users: []models.User{{Name: "usr", Projects: models.List{"prj"}}}},
+	}
+
+	// Fail update in the middle batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := s.logger
+	s.logger = slog.New(&cancelBatchHandler{Handler: logger.Handler(), batch: 2, cancel: cancel})
+
+	err = s.Inject(ctx, start, end, clusterUnits, users, nil)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// Units of first batch must not be committed
+	var numUnits, numUsage, numUsers int
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM units").Scan(&numUnits))
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM usage").Scan(&numUsage))
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM users").Scan(&numUsers))
+	assert.Equal(t, 0, numUnits)
+	assert.Equal(t, 0, numUsage)
+	assert.Equal(t, 0, numUsers)
+
+	// Ingesting the same period again must count each unit only once
+	s.logger = logger
+
+	require.NoError(t, s.Inject(context.Background(), start, end, clusterUnits, users, nil))
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM units").Scan(&numUnits))
+	require.NoError(t, s.db.QueryRow("SELECT num_units FROM usage WHERE username = 'usr'").Scan(&numUsage))
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(id) FROM users").Scan(&numUsers))
+	assert.Equal(t, 5, numUnits)
+	assert.Equal(t, 5, numUsage)
+	assert.Equal(t, 1, numUsers)
+}
+
+func TestUnitStatsDBNodes(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	nodes := []models.ClusterNodes{
+		{
+			Cluster: models.Cluster{ID: "slurm-0", Manager: "slurm"},
+			Nodes: []models.Node{
+				{
+					Hostname:         "compute-0",
+					TotalTime:        models.MetricMap{"walltime": 900},
+					TotalEnergyUsage: models.MetricMap{"ipmi": 0.5},
+					TotalEmissions:   models.MetricMap{"rte": 10},
+				},
+			},
+		},
+	}
+
+	// Insert node stats twice in same hourly period
+	startTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+
+	for i := range 2 {
+		start := startTime.Add(time.Duration(i) * 15 * time.Minute)
+		err = s.execStatements(ctx, tx, start, start.Add(15*time.Minute), nil, nil, nil, nodes, nil)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tx.Commit())
+
+	var (
+		periodStart           string
+		totalTime, energy, em string
+		numUpdates            int
+	)
+
+	err = s.db.QueryRow(
+		"SELECT period_start, total_time_seconds, total_energy_usage_kwh, total_emissions_gms, num_updates FROM nodes WHERE hostname = ?",
+		"compute-0",
+	).Scan(&periodStart, &totalTime, &energy, &em, &numUpdates)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2024-01-01T10:00:00", periodStart)
+	assert.JSONEq(t, `{"walltime":1800}`, totalTime)
+	assert.JSONEq(t, `{"ipmi":1}`, energy)
+	assert.JSONEq(t, `{"rte":20}`, em)
+	assert.Equal(t, 2, numUpdates)
+}
+
+func TestUnitStatsDBReservationsPreemptions(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	s.storage.billIdleResv = true
+
+	cluster := models.Cluster{ID: "slurm-0", Manager: "slurm"}
+	resv := models.Reservation{
+		Name:          "resv1",
+		Project:       "prj1",
+		StartedAt:     "2024-01-01T10:00:00+0000",
+		EndedAt:       "2024-01-02T10:00:00+0000",
+		Nodes:         "compute-[0-1]",
+		Allocation:    models.Allocation{"cpu": 64},
+		ReservedTime:  models.MetricMap{"cpu": 57600},
+		AllocatedTime: models.MetricMap{"cpu": 7600},
+		IdleTime:      models.MetricMap{"cpu": 50000},
+	}
+	units := []models.ClusterUnits{
+		{
+			Cluster: cluster,
+			Units: []models.Unit{
+				{
+					UUID:      "1000",
+					Project:   "prj1",
+					User:      "usr1",
+					StartedAt: "2024-01-01T10:00:00+0000",
+					EndedAt:   "2024-01-01T10:10:00+0000",
+					EndedAtTS: 1704103800000,
+					State:     "PREEMPTED",
+					TotalTime: models.MetricMap{"alloc_cputime": 0, "alloc_cpumemtime": 0, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+				},
+				{
+					UUID:      "1001",
+					Project:   "prj1",
+					User:      "usr1",
+					StartedAt: "2024-01-01T10:00:00+0000",
+					State:     "RUNNING",
+					TotalTime: models.MetricMap{"alloc_cputime": 0, "alloc_cpumemtime": 0, "alloc_gputime": 0, "alloc_gpumemtime": 0},
+				},
+			},
+		},
+	}
+
+	startTime := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	ctx := context.Background()
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+
+	// Insert reservation twice. In the second update, resource manager does not
+	// report project anymore and it must be retained from first update
+	for i := range 2 {
+		start := startTime.Add(time.Duration(i) * 15 * time.Minute)
+		err = s.execStatements(ctx, tx, start, start.Add(15*time.Minute), units, nil, nil, nil, []models.ClusterReservations{{Cluster: cluster, Reservations: []models.Reservation{resv}}})
+		require.NoError(t, err)
+
+		resv.Project = ""
+	}
+
+	require.NoError(t, tx.Commit())
+
+	var project, idleTime, reservedTime string
+
+	var numUpdates int
+
+	err = s.db.QueryRow(
+		"SELECT project, reserved_time_seconds, idle_time_seconds, num_updates FROM reservations WHERE cluster_id = ? AND name = ?",
+		"slurm-0", "resv1",
+	).Scan(&project, &reservedTime, &idleTime, &numUpdates)
+	require.NoError(t, err)
+
+	assert.Equal(t, "prj1", project)
+	assert.JSONEq(t, `{"cpu":115200}`, reservedTime)
+	assert.JSONEq(t, `{"cpu":100000}`, idleTime)
+	assert.Equal(t, 2, numUpdates)
+
+	// Only preempted unit must be recorded once
+	var uuids []string
+
+	rows, err := s.db.Query("SELECT uuid FROM preemptions")
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var uuid string
+
+		require.NoError(t, rows.Scan(&uuid))
+
+		uuids = append(uuids, uuid)
+	}
+
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"1000"}, uuids)
+
+	// Idle time must be billed to project
+	var totalTime string
+
+	err = s.db.QueryRow(
+		"SELECT total_time_seconds FROM usage WHERE cluster_id = ? AND project = ? AND username = ''",
+		"slurm-0", "prj1",
+	).Scan(&totalTime)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"alloc_cputime":0,"alloc_cpumemtime":0,"alloc_gputime":0,"alloc_gpumemtime":0,"reservation_idle_cpu":100000}`, totalTime)
 }
@@ -4,30 +4,29 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	ceems_sqlite3 "github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
 // Ref: https://stackoverflow.com/questions/1711631/improve-insert-per-second-performance-of-sqlite
 // Ref: https://gitlab.com/gnufred/logslate/-/blob/8eda5cedc9a28da3793dcf73480d618c95cc322c/playground/sqlite3.go
 // Ref: https://github.com/mattn/go-sqlite3/issues/1145#issuecomment-1519012055
-// Use WAL as journal mode by default as using litestream alongside ceems API server can result
-// in DB locked problem when restarting CEEMS API server. This is due to the starting DB connection
-// attempts to open DB in DELETE journal mode which cannot be possible when WAL is activated by
-// litestream.
-var defaultOpts = map[string]string{
-	"_busy_timeout": "5000",
-	"_journal_mode": "WAL",
-	"_synchronous":  "0",
-}
+// Default options are used for DB files other than CEEMS DB like backups.
+var defaultOpts = (&SQLiteConfig{}).Options(false)
 
-// Make DSN from DB file path and opts map.
-func makeDSN(filePath string, opts map[string]string) string {
+// MakeDSN makes DSN from DB file path and opts map.
+func MakeDSN(filePath string, opts map[string]string) string {
 	dsn := "file:" + filePath
 
 	optsSlice := []string{}
@@ -35,30 +34,44 @@ func makeDSN(filePath string, opts map[string]string) string {
 		optsSlice = append(optsSlice, fmt.Sprintf("%s=%s", opt, val))
 	}
 
+	// Sort options to have a stable DSN
+	slices.Sort(optsSlice)
+
 	optString := strings.Join(optsSlice, "&")
 
 	return fmt.Sprintf("%s?%s", dsn, optString)
 }
 
 // Open DB connection and return connection poiner.
-func openDBConnection(dbFilePath string) (*sql.DB, *ceems_sqlite3.Conn, error) {
-	var db *sql.DB
-
-	var dbConn *ceems_sqlite3.Conn
-
-	var err error
-
-	var ok bool
-
-	if db, err = sql.Open(ceems_sqlite3.DriverName, makeDSN(dbFilePath, defaultOpts)); err != nil {
+func openDBConnection(dbFilePath string, opts map[string]string) (*sql.DB, *ceems_sqlite3.Conn, error) {
+	db, err := sql.Open(ceems_sqlite3.DriverName, MakeDSN(dbFilePath, opts))
+	if err != nil {
 		return nil, nil, err
 	}
 
-	if err = db.Ping(); err != nil {
+	// Fetch the underlying connection of this pool rather than the last connection
+	// created by the driver which can belong to a different pool when several DB
+	// instances are opened in the same process
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+
 		return nil, nil, err
 	}
+	defer conn.Close()
+
+	var dbConn *ceems_sqlite3.Conn
+
+	if err = conn.Raw(func(driverConn any) error {
+		var ok bool
+		if dbConn, ok = driverConn.(*ceems_sqlite3.Conn); !ok {
+			return fmt.Errorf("unknown connection type %T", driverConn)
+		}
+
+		return nil
+	}); err != nil {
+		db.Close()
 
-	if dbConn, ok = ceems_sqlite3.GetLastConn(); !ok {
 		return nil, nil, err
 	}
 
@@ -66,10 +79,10 @@ func openDBConnection(dbFilePath string) (*sql.DB, *ceems_sqlite3.Conn, error) {
 }
 
 // Setup DB and create table.
-func setupDB(dbFilePath string, logger *slog.Logger) (*sql.DB, *ceems_sqlite3.Conn, error) {
+func setupDB(dbFilePath string, opts map[string]string, logger *slog.Logger) (*sql.DB, *ceems_sqlite3.Conn, error) {
 	if _, err := os.Stat(dbFilePath); err == nil {
 		// Open the created SQLite File
-		db, dbConn, err := openDBConnection(dbFilePath)
+		db, dbConn, err := openDBConnection(dbFilePath, opts)
 		if err != nil {
 			logger.Error("Failed to open DB file", "err", err)
 
@@ -97,7 +110,7 @@ func setupDB(dbFilePath string, logger *slog.Logger) (*sql.DB, *ceems_sqlite3.Co
 	}
 
 	// Open the created SQLite File
-	db, dbConn, err := openDBConnection(dbFilePath)
+	db, dbConn, err := openDBConnection(dbFilePath, opts)
 	if err != nil {
 		logger.Error("Failed to open DB file", "err", err)
 
@@ -106,3 +119,123 @@ func setupDB(dbFilePath string, logger *slog.Logger) (*sql.DB, *ceems_sqlite3.Co
 
 	return db, dbConn, nil
 }
+
+// integrityCheck runs SQLite integrity check on DB and returns the list of problems found.
+// When quick is true, a less thorough but faster quick_check is performed.
+func integrityCheck(ctx context.Context, db *sql.DB, quick bool) ([]string, error) {
+	pragma := "integrity_check"
+	if quick {
+		pragma = "quick_check"
+	}
+
+	rows, err := db.QueryContext(ctx, "PRAGMA "+pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, err
+		}
+
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+
+	return problems, rows.Err()
+}
+
+// recoverDB returns true if the DB file at dbFilePath is missing or corrupt. A corrupt
+// DB file will be moved aside along with its WAL files so that a new DB can be created.
+// DB is considered corrupt only when SQLite reports it as corrupt or not a database or
+// when quick check finds problems. Any other error, like a busy DB or insufficient
+// permissions, leaves the DB file untouched.
+func recoverDB(dbFilePath string, opts map[string]string, logger *slog.Logger) bool {
+	if _, err := os.Stat(dbFilePath); errors.Is(err, os.ErrNotExist) {
+		return true
+	}
+
+	// Open DB and run a quick check
+	problems, err := func() ([]string, error) {
+		db, _, err := openDBConnection(dbFilePath, opts)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+
+		return integrityCheck(context.Background(), db, true)
+	}()
+	if err != nil && !isCorruptErr(err) {
+		logger.Error("Failed to check DB file integrity", "path", dbFilePath, "err", err)
+
+		return false
+	}
+
+	if err == nil && len(problems) == 0 {
+		return false
+	}
+
+	logger.Error("DB file is corrupt", "path", dbFilePath, "problems", strings.Join(problems, ";"), "err", err)
+
+	// Move the corrupt DB file and its WAL and SHM files aside for post-mortem
+	suffix := corruptFileSuffix(dbFilePath)
+	for _, ext := range []string{"", "-wal", "-shm"} {
+		if _, err := os.Stat(dbFilePath + ext); err != nil {
+			continue
+		}
+
+		if err := os.Rename(dbFilePath+ext, dbFilePath+ext+suffix); err != nil {
+			logger.Error("Failed to move corrupt DB file", "path", dbFilePath+ext, "err", err)
+		}
+	}
+
+	return true
+}
+
+// isCorruptErr returns true if err is a SQLite error indicating that the DB
+// file is corrupt or not a database.
+func isCorruptErr(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code == sqlite3.ErrCorrupt || sqliteErr.Code == sqlite3.ErrNotADB
+}
+
+// corruptFileSuffix returns a suffix for moving aside the corrupt DB file at
+// dbFilePath that does not collide with existing files.
+func corruptFileSuffix(dbFilePath string) string {
+	prefix := ".corrupt-" + time.Now().Format("20060102150405")
+
+	for i := 0; ; i++ {
+		suffix := prefix
+		if i > 0 {
+			suffix = fmt.Sprintf("%s-%d", prefix, i)
+		}
+
+		if _, err := os.Stat(dbFilePath + suffix); errors.Is(err, os.ErrNotExist) {
+			return suffix
+		}
+	}
+}
+
+// unitNodes returns the names of nodes on which unit has run. Expanded
+// nodelist of batch jobs is stored as node names delimited by "|" and
+// VMs have a single hypervisor.
+func unitNodes(unit models.Unit) []string {
+	if nodelist, ok := unit.Tags["nodelistexp"].(string); ok && nodelist != "" {
+		return slices.DeleteFunc(strings.Split(nodelist, "|"), func(n string) bool { return n == "" })
+	}
+
+	if hypervisor, ok := unit.Tags["hypervisor"].(string); ok && hypervisor != "" {
+		return []string{hypervisor}
+	}
+
+	return nil
+}
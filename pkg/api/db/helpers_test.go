@@ -4,11 +4,14 @@
 package db
 
 import (
+	"database/sql/driver"
 	"io"
 	"log/slog"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -22,14 +25,84 @@ func TestJobStatsDBPreparation(t *testing.T) {
 	}
 
 	// Test setupDB function
-	_, _, err := setupDB(statDBPath, j.logger)
+	_, _, err := setupDB(statDBPath, defaultOpts, j.logger)
 	require.NoError(t, err)
 	require.FileExists(t, statDBPath, "DB file not found")
 
 	// Call setupDB again. This should return with db conn
-	_, _, err = setupDB(statDBPath, j.logger)
+	_, _, err = setupDB(statDBPath, defaultOpts, j.logger)
 	require.NoError(t, err, "failed to setup DB on already setup DB")
 
 	// Check DB file exists
 	assert.FileExists(t, statDBPath)
 }
+
+func TestOpenDBConnectionMultipleInstances(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Open two DBs in the same process
+	db1, conn1, err := openDBConnection(filepath.Join(tmpDir, "db1.db"), defaultOpts)
+	require.NoError(t, err)
+
+	defer db1.Close()
+
+	db2, conn2, err := openDBConnection(filepath.Join(tmpDir, "db2.db"), defaultOpts)
+	require.NoError(t, err)
+
+	defer db2.Close()
+
+	// Each instance must get a connection from its own pool
+	assert.NotSame(t, conn1, conn2)
+
+	_, err = db1.Exec("CREATE TABLE foo (id INTEGER)")
+	require.NoError(t, err)
+
+	// Connection of first instance must see the table created in its DB
+	rows, err := conn1.Query("SELECT name FROM sqlite_master WHERE name = 'foo'", nil)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	dest := make([]driver.Value, 1)
+	require.NoError(t, rows.Next(dest))
+	assert.Equal(t, "foo", dest[0])
+}
+
+func TestUnitNodes(t *testing.T) {
+	assert.Equal(t, []string{"compute-0", "compute-1"}, unitNodes(models.Unit{Tags: models.Tag{"nodelistexp": "compute-0|compute-1|"}}))
+	assert.Equal(t, []string{"hv-0"}, unitNodes(models.Unit{Tags: models.Tag{"hypervisor": "hv-0"}}))
+	assert.Empty(t, unitNodes(models.Unit{Tags: models.Tag{"nodelistexp": ""}}))
+}
+
+func TestRecoverDB(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Missing DB must be reported as lost
+	dbPath := filepath.Join(tmpDir, "stats.db")
+	assert.True(t, recoverDB(dbPath, defaultOpts, logger))
+
+	// Healthy DB must not be touched
+	db, _, err := setupDB(dbPath, defaultOpts, logger)
+	require.NoError(t, err)
+	db.Close()
+	assert.False(t, recoverDB(dbPath, defaultOpts, logger))
+	assert.FileExists(t, dbPath)
+
+	// Errors other than corruption must not move DB aside
+	dirPath := filepath.Join(tmpDir, "dir.db")
+	require.NoError(t, os.Mkdir(dirPath, 0o700))
+	assert.False(t, recoverDB(dirPath, defaultOpts, logger))
+	assert.DirExists(t, dirPath)
+
+	// Corrupt DBs must be moved aside without overwriting earlier ones
+	for range 2 {
+		require.NoError(t, os.WriteFile(dbPath, []byte("this is not a sqlite db file"), 0o600))
+		assert.True(t, recoverDB(dbPath, defaultOpts, logger))
+		assert.NoFileExists(t, dbPath)
+	}
+
+	matches, err := filepath.Glob(dbPath + ".corrupt-*")
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+}
@@ -0,0 +1,138 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// Prefix of names of configured secondary indexes. Indexes with this prefix
+// that are not in the config are dropped so that they must not be created
+// by any other means.
+const unitsIndexPrefix = "auto_idx_"
+
+// Default secondary indexes of units table. Most of the queries made by API
+// server filter units on one of these columns.
+var defaultUnitsIndexes = [][]string{
+	{"username"},
+	{"project"},
+	{"started_at_ts"},
+	{"state"},
+	{"uuid"},
+}
+
+// validateUnitsIndexes returns an error when an index is empty or has columns
+// that are not in units table.
+func validateUnitsIndexes(indexes [][]string) error {
+	for _, index := range indexes {
+		if len(index) == 0 {
+			return ErrIndexColumns
+		}
+
+		for _, column := range index {
+			if !isUnitsColumn(column) {
+				return fmt.Errorf("%w: %s", ErrIndexColumns, column)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isUnitsColumn returns true when column is a column of units table.
+func isUnitsColumn(column string) bool {
+	for _, c := range base.UnitsDBTableStructFieldColNameMap {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unitsIndexName returns the name of index on columns of table.
+func unitsIndexName(table string, columns []string) string {
+	return fmt.Sprintf("%s%s_%s", unitsIndexPrefix, table, strings.Join(columns, "_"))
+}
+
+// syncUnitsIndexes creates configured indexes on table, which is either units
+// table or one of its partitions, and drops the previously configured indexes
+// that are not in config anymore.
+func syncUnitsIndexes(ctx context.Context, db dbQueryer, table string, indexes [][]string) error {
+	names := make([]string, 0, len(indexes))
+
+	for _, columns := range indexes {
+		name := unitsIndexName(table, columns)
+		names = append(names, name)
+
+		if _, err := db.ExecContext(
+			ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, table, strings.Join(columns, ",")),
+		); err != nil {
+			return fmt.Errorf("failed to create index %s: %w", name, err)
+		}
+	}
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name LIKE ? ESCAPE '\'`,
+		table, strings.ReplaceAll(unitsIndexPrefix, "_", `\_`)+"%",
+	)
+	if err != nil {
+		return err
+	}
+
+	var stale []string
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+
+			return err
+		}
+
+		if !slices.Contains(names, name) {
+			stale = append(stale, name)
+		}
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, name := range stale {
+		if _, err := db.ExecContext(ctx, "DROP INDEX IF EXISTS "+name); err != nil {
+			return fmt.Errorf("failed to drop index %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// syncAllUnitsIndexes syncs configured indexes of units table and all its partitions.
+func syncAllUnitsIndexes(ctx context.Context, db dbQueryer, indexes [][]string) error {
+	partitions, err := unitsPartitions(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if err := syncUnitsIndexes(ctx, db, base.UnitsDBTableName, indexes); err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		if err := syncUnitsIndexes(ctx, db, p.name, indexes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
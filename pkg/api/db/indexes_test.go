@@ -0,0 +1,85 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// tableIndexes returns names of configured indexes of table.
+func tableIndexes(t *testing.T, s *stats, table string) []string {
+	t.Helper()
+
+	rows, err := s.db.Query(
+		`SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name LIKE 'auto\_idx\_%' ESCAPE '\' ORDER BY name`,
+		table,
+	)
+	require.NoError(t, err)
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestUnitsIndexesConfig(t *testing.T) {
+	var c DataConfig
+
+	// Default indexes must be set
+	require.NoError(t, yaml.Unmarshal([]byte("path: data"), &c))
+	assert.Equal(t, defaultUnitsIndexes, c.UnitsIndexes)
+	require.NoError(t, validateUnitsIndexes(c.UnitsIndexes))
+
+	// Indexes can be disabled
+	require.NoError(t, yaml.Unmarshal([]byte("units_indexes: []"), &c))
+	assert.Empty(t, c.UnitsIndexes)
+
+	require.ErrorIs(t, validateUnitsIndexes([][]string{{}}), ErrIndexColumns)
+	require.ErrorIs(t, validateUnitsIndexes([][]string{{"username", "unknown"}}), ErrIndexColumns)
+}
+
+func TestSyncUnitsIndexes(t *testing.T) {
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.UnitsIndexes = [][]string{{"username"}, {"project", "started_at_ts"}}
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	assert.Equal(
+		t,
+		[]string{"auto_idx_units_project_started_at_ts", "auto_idx_units_username"},
+		tableIndexes(t, s, base.UnitsDBTableName),
+	)
+
+	ctx := context.Background()
+
+	// Partitions must get the same indexes
+	name, err := createUnitsPartition(ctx, s.db, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), c.Data.UnitsIndexes)
+	require.NoError(t, err)
+	assert.Len(t, tableIndexes(t, s, name), 2)
+
+	// Indexes removed from config must be dropped
+	require.NoError(t, syncAllUnitsIndexes(ctx, s.db, [][]string{{"state"}}))
+	assert.Equal(t, []string{"auto_idx_units_state"}, tableIndexes(t, s, base.UnitsDBTableName))
+	assert.Equal(t, []string{"auto_idx_" + name + "_state"}, tableIndexes(t, s, name))
+}
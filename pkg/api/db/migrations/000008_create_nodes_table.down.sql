@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_hostname_period_start;
+DROP TABLE IF EXISTS nodes;
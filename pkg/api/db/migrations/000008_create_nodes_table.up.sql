@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS nodes (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "resource_manager" text default "",
+ "hostname" text,
+ "period_start" text,
+ "period_start_ts" integer,
+ "total_time_seconds" text default '{}',
+ "total_energy_usage_kwh" text default '{}',
+ "total_emissions_gms" text default '{}',
+ "num_updates" integer default 0,
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX uq_cluster_id_hostname_period_start ON nodes (cluster_id,hostname,period_start);
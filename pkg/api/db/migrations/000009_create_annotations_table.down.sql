@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_cluster_id_uuid_annotations;
+DROP TABLE IF EXISTS annotations;
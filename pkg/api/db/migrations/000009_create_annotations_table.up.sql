@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS annotations (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "uuid" text,
+ "author" text,
+ "note" text,
+ "created_at" text
+);
+CREATE INDEX IF NOT EXISTS idx_cluster_id_uuid_annotations ON annotations (cluster_id,uuid);
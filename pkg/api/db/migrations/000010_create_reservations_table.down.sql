@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cluster_id_project_reservations;
+DROP INDEX IF EXISTS uq_cluster_id_name_started_at;
+DROP TABLE IF EXISTS reservations;
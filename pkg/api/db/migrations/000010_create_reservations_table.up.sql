@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS reservations (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "resource_manager" text default "",
+ "name" text,
+ "project" text default "",
+ "started_at" text,
+ "ended_at" text,
+ "started_at_ts" integer,
+ "ended_at_ts" integer,
+ "nodes" text default "",
+ "allocation" text default '{}',
+ "reserved_time_seconds" text default '{}',
+ "allocated_time_seconds" text default '{}',
+ "idle_time_seconds" text default '{}',
+ "num_updates" integer default 0,
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX uq_cluster_id_name_started_at ON reservations (cluster_id,name,started_at);
+CREATE INDEX IF NOT EXISTS idx_cluster_id_project_reservations ON reservations (cluster_id,project);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_cluster_id_username_preemptions;
+DROP INDEX IF EXISTS uq_cluster_id_uuid_preempted_at;
+DROP TABLE IF EXISTS preemptions;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS preemptions (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "resource_manager" text default "",
+ "uuid" text,
+ "name" text,
+ "project" text,
+ "groupname" text,
+ "username" text,
+ "started_at" text,
+ "preempted_at" text,
+ "preempted_at_ts" integer,
+ "elapsed" text,
+ "allocation" text default '{}',
+ "tags" text default '{}',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX uq_cluster_id_uuid_preempted_at ON preemptions (cluster_id,uuid,preempted_at);
+CREATE INDEX IF NOT EXISTS idx_cluster_id_username_preemptions ON preemptions (cluster_id,username);
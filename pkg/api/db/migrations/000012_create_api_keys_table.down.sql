@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_key_hash_api_keys;
+DROP TABLE IF EXISTS api_keys;
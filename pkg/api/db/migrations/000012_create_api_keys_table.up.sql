@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+ "id" integer not null primary key,
+ "name" text,
+ "username" text,
+ "role" text,
+ "key_hash" text,
+ "created_by" text,
+ "created_at" text,
+ "expires_at_ts" integer default 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_key_hash_api_keys ON api_keys (key_hash);
@@ -0,0 +1 @@
+ALTER TABLE units ADD COLUMN "completeness" text default '{}';
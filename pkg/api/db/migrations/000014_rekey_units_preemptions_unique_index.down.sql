@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS uq_cluster_id_uuid_preempted_at_ts;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_uuid_preempted_at ON preemptions (cluster_id,uuid,preempted_at);
+DROP INDEX IF EXISTS uq_cluster_id_uuid_started_at_ts;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_uuid_start ON units (cluster_id,uuid,started_at);
@@ -0,0 +1,14 @@
+DELETE FROM units WHERE id IN (
+ SELECT id FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY cluster_id,uuid,started_at_ts ORDER BY num_updates DESC, last_updated_at DESC, id DESC) AS rn FROM units
+ ) WHERE rn > 1
+);
+DROP INDEX IF EXISTS uq_cluster_id_uuid_start;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_uuid_started_at_ts ON units (cluster_id,uuid,started_at_ts);
+DELETE FROM preemptions WHERE id IN (
+ SELECT id FROM (
+  SELECT id, ROW_NUMBER() OVER (PARTITION BY cluster_id,uuid,preempted_at_ts ORDER BY last_updated_at DESC, id DESC) AS rn FROM preemptions
+ ) WHERE rn > 1
+);
+DROP INDEX IF EXISTS uq_cluster_id_uuid_preempted_at;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_uuid_preempted_at_ts ON preemptions (cluster_id,uuid,preempted_at_ts);
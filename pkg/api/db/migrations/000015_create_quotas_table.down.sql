@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_cluster_id_project_quotas;
+DROP TABLE IF EXISTS quotas;
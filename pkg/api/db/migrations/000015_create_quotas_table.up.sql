@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS quotas (
+ "id" integer not null primary key,
+ "cluster_id" text,
+ "project" text,
+ "cpu_hours" real default 0,
+ "gpu_hours" real default 0,
+ "energy_kwh" real default 0,
+ "start_ts" integer default 0,
+ "end_ts" integer default 0,
+ "updated_by" text,
+ "updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cluster_id_project_quotas ON quotas (cluster_id,project);
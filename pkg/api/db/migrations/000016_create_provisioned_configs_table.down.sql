@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_kind_config_id;
+DROP TABLE IF EXISTS provisioned_configs;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS provisioned_configs (
+ "id" integer not null primary key,
+ "kind" text,
+ "config_id" text,
+ "type" text,
+ "config" text,
+ "updated_by" text,
+ "updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_kind_config_id ON provisioned_configs (kind,config_id);
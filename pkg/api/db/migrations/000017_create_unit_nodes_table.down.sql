@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS delete_unit_nodes;
+DROP INDEX IF EXISTS idx_unit_nodes_node;
+DROP TABLE IF EXISTS unit_nodes;
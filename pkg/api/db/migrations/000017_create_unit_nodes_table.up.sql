@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS unit_nodes (
+ "unit_id" integer not null,
+ "node" text not null,
+ PRIMARY KEY (unit_id,node)
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS idx_unit_nodes_node ON unit_nodes (node);
+CREATE TRIGGER IF NOT EXISTS delete_unit_nodes AFTER DELETE ON units
+BEGIN
+ DELETE FROM unit_nodes WHERE unit_id = OLD.id;
+END;
+WITH RECURSIVE split(unit_id, node, rest) AS (
+ SELECT id, '', json_extract(tags, '$.nodelistexp') || '|' FROM units WHERE json_extract(tags, '$.nodelistexp') != ''
+ UNION ALL
+ SELECT unit_id, substr(rest, 1, instr(rest, '|') - 1), substr(rest, instr(rest, '|') + 1) FROM split WHERE rest != ''
+)
+INSERT OR IGNORE INTO unit_nodes (unit_id,node) SELECT unit_id, node FROM split WHERE node != '';
+INSERT OR IGNORE INTO unit_nodes (unit_id,node) SELECT id, json_extract(tags, '$.hypervisor') FROM units WHERE json_extract(tags, '$.hypervisor') != '';
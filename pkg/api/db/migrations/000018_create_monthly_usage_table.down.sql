@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_monthly_cluster_id_project_usr_lastupdated;
+DROP TABLE IF EXISTS monthly_usage;
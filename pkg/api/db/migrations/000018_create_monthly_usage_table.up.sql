@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS monthly_usage (
+ "id" integer not null primary key,
+ "resource_manager" text default "",
+ "cluster_id" text, 
+ "num_units" integer,
+ "project" text,
+ "groupname" text,
+ "username" text,
+ "total_time_seconds" text default '{}', 
+ "avg_cpu_usage" text default '{}', 
+ "avg_cpu_mem_usage" text default '{}',
+ "total_cpu_energy_usage_kwh" text default '{}', 
+ "total_cpu_emissions_gms" text default '{}',
+ "avg_gpu_usage" text default '{}', 
+ "avg_gpu_mem_usage" text default '{}',
+ "total_gpu_energy_usage_kwh" text default '{}', 
+ "total_gpu_emissions_gms" text default '{}',
+ "total_io_write_stats" text default '{}', 
+ "total_io_read_stats" text default '{}',
+ "total_ingress_stats" text default '{}', 
+ "total_outgress_stats" text default '{}',
+ "num_updates" integer default 0,  
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX uq_monthly_cluster_id_project_usr_lastupdated ON monthly_usage (cluster_id,username,project,last_updated_at);
+-- Backfill monthly usage from existing daily usage
+INSERT INTO monthly_usage (cluster_id,resource_manager,num_units,project,groupname,username,last_updated_at,total_time_seconds,avg_cpu_usage,avg_cpu_mem_usage,total_cpu_energy_usage_kwh,total_cpu_emissions_gms,avg_gpu_usage,avg_gpu_mem_usage,total_gpu_energy_usage_kwh,total_gpu_emissions_gms,total_io_write_stats,total_io_read_stats,total_ingress_stats,total_outgress_stats,num_updates)
+SELECT
+  cluster_id,
+  MAX(resource_manager),
+  SUM(num_units),
+  project,
+  MAX(groupname),
+  username,
+  substr(last_updated_at, 1, 7) || '-01T00:00:00',
+  sum_metric_map_agg(total_time_seconds),
+  avg_metric_map_agg(avg_cpu_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_cputime') AS REAL), 0.0)),
+  avg_metric_map_agg(avg_cpu_mem_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_cpumemtime') AS REAL), 0.0)),
+  sum_metric_map_agg(total_cpu_energy_usage_kwh),
+  sum_metric_map_agg(total_cpu_emissions_gms),
+  avg_metric_map_agg(avg_gpu_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_gputime') AS REAL), 0.0)),
+  avg_metric_map_agg(avg_gpu_mem_usage, COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_gpumemtime') AS REAL), 0.0)),
+  sum_metric_map_agg(total_gpu_energy_usage_kwh),
+  sum_metric_map_agg(total_gpu_emissions_gms),
+  sum_metric_map_agg(total_io_write_stats),
+  sum_metric_map_agg(total_io_read_stats),
+  sum_metric_map_agg(total_ingress_stats),
+  sum_metric_map_agg(total_outgress_stats),
+  SUM(num_updates)
+FROM daily_usage
+GROUP BY cluster_id, username, project, substr(last_updated_at, 1, 7);
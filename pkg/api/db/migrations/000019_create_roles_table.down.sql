@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS admin_users (
+ "id" integer not null primary key,
+ "source" text,
+ "users" text default '[]',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_source ON admin_users (source);
+INSERT INTO admin_users (source,users,last_updated_at)
+SELECT source, json_group_array(username), MAX(last_updated_at) FROM roles WHERE role = 'admin' AND source != 'api' GROUP BY source;
+DROP INDEX IF EXISTS uq_username_source_roles;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS roles (
+ "id" integer not null primary key,
+ "username" text,
+ "role" text,
+ "source" text,
+ "updated_by" text default '',
+ "last_updated_at" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_username_source_roles ON roles (username,source);
+-- Admin users of all sources are migrated to roles table
+INSERT OR IGNORE INTO roles (username,role,source,updated_by,last_updated_at)
+SELECT u.value, 'admin', a.source, '', a.last_updated_at FROM admin_users AS a, json_each(a.users) AS u WHERE u.value != '';
+DROP INDEX IF EXISTS uq_source;
+DROP TABLE IF EXISTS admin_users;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS delete_unit_steps;
+DROP TABLE IF EXISTS unit_steps;
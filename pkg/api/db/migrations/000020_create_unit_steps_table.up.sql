@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS unit_steps (
+ "unit_id" integer not null,
+ "step_id" text not null,
+ "name" text,
+ "state" text,
+ "started_at" text,
+ "ended_at" text,
+ "started_at_ts" integer,
+ "ended_at_ts" integer,
+ "elapsed" text,
+ "exit_code" text,
+ "nodelist" text,
+ "allocation" text,
+ "total_time_seconds" text,
+ "last_updated_at" text,
+ PRIMARY KEY (unit_id,step_id)
+) WITHOUT ROWID;
+CREATE TRIGGER IF NOT EXISTS delete_unit_steps AFTER DELETE ON units
+BEGIN
+ DELETE FROM unit_steps WHERE unit_id = OLD.id;
+END;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS delete_unit_scripts;
+DROP TABLE IF EXISTS unit_scripts;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS unit_scripts (
+ "unit_id" integer not null primary key,
+ "script" text,
+ "environment" text,
+ "truncated" integer,
+ "last_updated_at" text
+);
+CREATE TRIGGER IF NOT EXISTS delete_unit_scripts AFTER DELETE ON units
+BEGIN
+ DELETE FROM unit_scripts WHERE unit_id = OLD.id;
+END;
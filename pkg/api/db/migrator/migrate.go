@@ -3,10 +3,12 @@ package migrator
 
 import (
 	"database/sql"
-	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
@@ -14,14 +16,37 @@ import (
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
+// Custom errors.
+var (
+	ErrDirtyDB     = errors.New("DB schema is dirty due to a failed migration and must be fixed manually")
+	ErrNewerSchema = errors.New("DB schema is newer than the latest known migration")
+)
+
+// migrateLogger adapts slog.Logger to migrate.Logger interface.
+type migrateLogger struct {
+	logger *slog.Logger
+}
+
+// Printf logs the progress of migrations.
+func (l *migrateLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info("DB migration", "step", strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+// Verbose returns false to skip verbose logs of migrations.
+func (l *migrateLogger) Verbose() bool {
+	return false
+}
+
 // Migrator implements DB migrations.
 type Migrator struct {
 	logger    *slog.Logger
 	srcDriver source.Driver
 }
 
-// New returns new instance of Migrator.
-func New(sqlFiles embed.FS, dirName string, logger *slog.Logger) (*Migrator, error) {
+// New returns new instance of Migrator. Migrations are read from
+// dirName of sqlFiles which are named as `<version>_<title>.up.sql`
+// and `<version>_<title>.down.sql`.
+func New(sqlFiles fs.FS, dirName string, logger *slog.Logger) (*Migrator, error) {
 	d, err := iofs.New(sqlFiles, dirName)
 	if err != nil {
 		return nil, err
@@ -33,16 +58,17 @@ func New(sqlFiles embed.FS, dirName string, logger *slog.Logger) (*Migrator, err
 	}, nil
 }
 
-// ApplyMigrations applies DB migrations.
+// ApplyMigrations applies all DB migrations that are not applied yet.
 func (m *Migrator) ApplyMigrations(db *sql.DB) error {
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	migrator, err := m.migrator(db)
 	if err != nil {
-		return fmt.Errorf("unable to create db instance: %w", err)
+		return err
 	}
 
-	migrator, err := migrate.NewWithInstance("iofs", m.srcDriver, "sqlite3", driver)
-	if err != nil {
-		return fmt.Errorf("unable to create migration: %w", err)
+	// Refuse to migrate dirty DBs or DBs that are migrated by a newer version
+	// as migrating them would leave DB in an unknown state
+	if err := m.check(migrator); err != nil {
+		return err
 	}
 
 	m.logger.Info("Applying DB migrations")
@@ -59,3 +85,95 @@ func (m *Migrator) ApplyMigrations(db *sql.DB) error {
 
 	return nil
 }
+
+// MigrateTo migrates DB up or down to the given version. A version of 0
+// reverts all the migrations. It is meant to downgrade DB before rolling
+// back to an older release.
+func (m *Migrator) MigrateTo(db *sql.DB, version uint) error {
+	migrator, err := m.migrator(db)
+	if err != nil {
+		return err
+	}
+
+	if err := m.check(migrator); err != nil {
+		return err
+	}
+
+	m.logger.Info("Migrating DB", "version", version)
+
+	if version == 0 {
+		err = migrator.Down()
+	} else {
+		err = migrator.Migrate(version)
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("unable to migrate DB to version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+// Version returns the current version of DB schema. A version of 0 means
+// no migrations have been applied.
+func (m *Migrator) Version(db *sql.DB) (uint, bool, error) {
+	migrator, err := m.migrator(db)
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty, err := migrator.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// migrator returns a new migrate instance on db.
+func (m *Migrator) migrator(db *sql.DB) (*migrate.Migrate, error) {
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create db instance: %w", err)
+	}
+
+	migrator, err := migrate.NewWithInstance("iofs", m.srcDriver, "sqlite3", driver)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create migration: %w", err)
+	}
+
+	migrator.Log = &migrateLogger{logger: m.logger}
+
+	return migrator, nil
+}
+
+// check returns an error when DB is dirty or when DB schema version is
+// unknown to source.
+func (m *Migrator) check(migrator *migrate.Migrate) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+
+		return fmt.Errorf("unable to get DB migration version: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("%w: version %d", ErrDirtyDB, version)
+	}
+
+	// Current version must exist in source
+	r, _, err := m.srcDriver.ReadUp(version)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%w: version %d", ErrNewerSchema, version)
+		}
+
+		return err
+	}
+
+	r.Close()
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,3 +31,66 @@ func TestMigratorError(t *testing.T) {
 	err = migrator.ApplyMigrations(db)
 	assert.Error(t, err, "expected DB migrations error")
 }
+
+func TestMigratorUpDown(t *testing.T) {
+	migrations := fstest.MapFS{
+		"migrations/000001_create_units.up.sql":   {Data: []byte("CREATE TABLE units (id INTEGER);")},
+		"migrations/000001_create_units.down.sql": {Data: []byte("DROP TABLE units;")},
+		"migrations/000002_add_name.up.sql":       {Data: []byte("ALTER TABLE units ADD COLUMN name TEXT;")},
+		"migrations/000002_add_name.down.sql":     {Data: []byte("ALTER TABLE units DROP COLUMN name;")},
+	}
+
+	migrator, err := New(migrations, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Fresh DB has no version
+	version, _, err := migrator.Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+
+	// Apply all migrations
+	require.NoError(t, migrator.ApplyMigrations(db))
+
+	version, dirty, err := migrator.Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), version)
+	assert.False(t, dirty)
+
+	_, err = db.Exec("INSERT INTO units (id, name) VALUES (1, 'a')")
+	require.NoError(t, err)
+
+	// Applying again must be no-op
+	require.NoError(t, migrator.ApplyMigrations(db))
+
+	// Downgrade to version 1
+	require.NoError(t, migrator.MigrateTo(db, 1))
+
+	version, _, err = migrator.Version(db)
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), version)
+
+	_, err = db.Exec("INSERT INTO units (id, name) VALUES (2, 'b')")
+	require.Error(t, err)
+
+	// Revert all migrations
+	require.NoError(t, migrator.MigrateTo(db, 0))
+
+	_, err = db.Exec("SELECT * FROM units")
+	require.Error(t, err)
+
+	// DB migrated by a newer release must be refused
+	require.NoError(t, migrator.ApplyMigrations(db))
+
+	_, err = db.Exec("UPDATE schema_migrations SET version = 3")
+	require.NoError(t, err)
+	require.ErrorIs(t, migrator.ApplyMigrations(db), ErrNewerSchema)
+
+	// Dirty DB must be refused
+	_, err = db.Exec("UPDATE schema_migrations SET version = 2, dirty = 1")
+	require.NoError(t, err)
+	require.ErrorIs(t, migrator.ApplyMigrations(db), ErrDirtyDB)
+}
@@ -0,0 +1,319 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// unitsPartition is a monthly partition of units table.
+type unitsPartition struct {
+	name  string
+	start time.Time // Start of month in UTC
+}
+
+// end returns the end of month of partition.
+func (p unitsPartition) end() time.Time {
+	return p.start.AddDate(0, 1, 0)
+}
+
+// unitChildTables are the tables whose rows are linked to units by unit_id.
+var unitChildTables = []string{base.UnitNodesDBTableName, base.UnitStepsDBTableName, base.UnitScriptsDBTableName}
+
+// dbQueryer makes queries. It is implemented by both *sql.DB and *sql.Tx.
+type dbQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// unitsPartitions returns the existing monthly partitions of units table.
+func unitsPartitions(ctx context.Context, db dbQueryer) ([]unitsPartition, error) {
+	rows, err := db.QueryContext(
+		ctx,
+		"SELECT name FROM sqlite_master WHERE type = 'table' AND name GLOB ? ORDER BY name",
+		base.UnitsDBTableName+"_[0-9][0-9][0-9][0-9]_[0-9][0-9]",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []unitsPartition
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+
+		start, err := time.Parse(base.UnitsPartitionLayout, strings.TrimPrefix(name, base.UnitsDBTableName+"_"))
+		if err != nil {
+			continue
+		}
+
+		partitions = append(partitions, unitsPartition{name: name, start: start})
+	}
+
+	return partitions, rows.Err()
+}
+
+// createUnitsPartition creates the partition of units table for the month
+// starting at start if it does not exist. Partitions have the same columns
+// as units table, a unique index on the same columns as upserts and the
+// configured secondary indexes.
+func createUnitsPartition(ctx context.Context, db dbQueryer, start time.Time, indexes [][]string) (string, error) {
+	name := base.UnitsPartitionName(start)
+
+	for _, stmt := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS SELECT * FROM %s WHERE 0", name, base.UnitsDBTableName),
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS uq_%[1]s_cluster_id_uuid_started_at_ts ON %[1]s (cluster_id,uuid,started_at_ts)", name),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%[1]s_cluster_id_project_user_ended ON %[1]s (cluster_id,project,username,ended_at)", name),
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return "", err
+		}
+	}
+
+	if err := syncUnitsIndexes(ctx, db, name, indexes); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// syncUnitsPartitions adds the columns of units table that are missing in
+// the partitions. This is needed as migrations only alter units table and
+// partitions must have the same columns in same order to be unioned.
+func syncUnitsPartitions(ctx context.Context, db dbQueryer) error {
+	partitions, err := unitsPartitions(ctx, db)
+	if err != nil || len(partitions) == 0 {
+		return err
+	}
+
+	columns, err := tableColumns(ctx, db, base.UnitsDBTableName)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range partitions {
+		existing, err := tableColumns(ctx, db, p.name)
+		if err != nil {
+			return err
+		}
+
+		// Partitions with more columns than units table can only happen when
+		// DB has been downgraded and they are left as they are
+		if len(existing) >= len(columns) {
+			continue
+		}
+
+		for _, c := range columns[len(existing):] {
+			if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", p.name, c[0], c[1])); err != nil {
+				return fmt.Errorf("failed to add column %s to %s: %w", c[0], p.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// tableColumns returns the names and types of columns of table in order.
+func tableColumns(ctx context.Context, db dbQueryer, table string) ([][2]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?) ORDER BY cid", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns [][2]string
+
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+
+		columns = append(columns, [2]string{name, typ})
+	}
+
+	return columns, rows.Err()
+}
+
+// partitionUnits moves the units that terminated before the current month
+// from units table into their monthly partitions. Rows keep their IDs so
+// that their nodes in unit nodes table remain valid. The unit with largest
+// ID is never moved so that SQLite does not reuse the IDs of moved units.
+//
+// Units are moved inside a savepoint so that a failure does not leave units
+// half moved in the transaction tx.
+func (s *stats) partitionUnits(ctx context.Context, tx *sql.Tx, now time.Time) (err error) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "Units partitioning", s.logger)
+
+	if _, err = tx.ExecContext(ctx, "SAVEPOINT partition_units"); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO partition_units") //nolint:errcheck
+		}
+
+		tx.ExecContext(ctx, "RELEASE partition_units") //nolint:errcheck
+	}()
+
+	now = now.UTC()
+	boundary := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// Get months of units that must be moved
+	rows, err := tx.QueryContext(
+		ctx,
+		fmt.Sprintf(
+			"SELECT DISTINCT strftime('%%Y-%%m', ended_at_ts / 1000, 'unixepoch') FROM %[1]s "+
+				"WHERE ended_at_ts > 0 AND ended_at_ts < ? AND id < (SELECT MAX(id) FROM %[1]s)",
+			base.UnitsDBTableName,
+		),
+		boundary.UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+
+	var months []time.Time
+
+	for rows.Next() {
+		var month string
+		if err := rows.Scan(&month); err != nil {
+			rows.Close()
+
+			return err
+		}
+
+		if start, err := time.Parse("2006-01", month); err == nil {
+			months = append(months, start)
+		}
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Nodes, steps and scripts of moved units are saved in temporary tables as
+	// deleting units triggers deleting them
+	for _, table := range unitChildTables {
+		if _, err := tx.ExecContext(
+			ctx, fmt.Sprintf("CREATE TEMP TABLE IF NOT EXISTS partitioned_%[1]s AS SELECT * FROM %[1]s WHERE 0", table),
+		); err != nil {
+			return err
+		}
+	}
+
+	for _, start := range months {
+		name, err := createUnitsPartition(ctx, tx, start, s.storage.unitsIndexes)
+		if err != nil {
+			return fmt.Errorf("failed to create partition %s: %w", base.UnitsPartitionName(start), err)
+		}
+
+		// Units of the month that must be moved
+		filter := fmt.Sprintf(
+			"ended_at_ts >= %d AND ended_at_ts < %d AND id < (SELECT MAX(id) FROM %s)",
+			start.UnixMilli(), start.AddDate(0, 1, 0).UnixMilli(), base.UnitsDBTableName,
+		)
+
+		var stmts []string
+
+		for _, table := range unitChildTables {
+			stmts = append(
+				stmts,
+				// Units that have been updated again after they were moved, for instance, when
+				// backfilling DB, replace the partitioned ones. Remove child rows of replaced units
+				fmt.Sprintf(
+					"DELETE FROM %[1]s WHERE unit_id IN (SELECT id FROM %[2]s WHERE (cluster_id,uuid,started_at_ts) IN "+
+						"(SELECT cluster_id,uuid,started_at_ts FROM %[3]s WHERE %[4]s))",
+					table, name, base.UnitsDBTableName, filter,
+				),
+				fmt.Sprintf(
+					"INSERT INTO temp.partitioned_%[1]s SELECT * FROM %[1]s WHERE unit_id IN (SELECT id FROM %[2]s WHERE %[3]s)",
+					table, base.UnitsDBTableName, filter,
+				),
+			)
+		}
+
+		stmts = append(
+			stmts,
+			fmt.Sprintf("INSERT OR REPLACE INTO %s SELECT * FROM %s WHERE %s", name, base.UnitsDBTableName, filter),
+			fmt.Sprintf("DELETE FROM %s WHERE %s", base.UnitsDBTableName, filter),
+		)
+
+		for _, table := range unitChildTables {
+			stmts = append(
+				stmts,
+				fmt.Sprintf("INSERT OR IGNORE INTO %[1]s SELECT * FROM temp.partitioned_%[1]s", table),
+				"DELETE FROM temp.partitioned_"+table,
+			)
+		}
+
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil { //nolint:gosec
+				return fmt.Errorf("failed to move units to partition %s: %w", name, err)
+			}
+		}
+
+		s.logger.Debug("Units moved to partition", "partition", name)
+	}
+
+	return nil
+}
+
+// purgeExpiredPartitions drops the partitions of units table that are older
+// than retention period and deletes expired units from the rest. Nodes, steps
+// and scripts of deleted units are deleted explicitly as partitions do not have triggers.
+func (s *stats) purgeExpiredPartitions(ctx context.Context, tx *sql.Tx) error {
+	partitions, err := unitsPartitions(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	retentionDays := int(s.storage.retentionPeriod.Hours() / 24)
+	cutoff := time.Now().UTC().AddDate(0, 0, -retentionDays)
+
+	for _, p := range partitions {
+		// Units of partitions that ended before cutoff have all started before cutoff
+		var stmts []string
+		if !p.end().After(cutoff) {
+			for _, table := range unitChildTables {
+				stmts = append(stmts, fmt.Sprintf("DELETE FROM %s WHERE unit_id IN (SELECT id FROM %s)", table, p.name))
+			}
+
+			stmts = append(stmts, "DROP TABLE "+p.name)
+		} else {
+			expired := fmt.Sprintf("started_at <= date('now', '-%d day')", retentionDays)
+			for _, table := range unitChildTables {
+				stmts = append(stmts, fmt.Sprintf(
+					"DELETE FROM %s WHERE unit_id IN (SELECT id FROM %s WHERE %s)",
+					table, p.name, expired,
+				))
+			}
+
+			stmts = append(stmts, fmt.Sprintf("DELETE FROM %s WHERE %s", p.name, expired))
+		}
+
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil { //nolint:gosec
+				return fmt.Errorf("failed to purge partition %s: %w", p.name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// terminatedUnit returns a unit that terminated at end.
+func terminatedUnit(uuid string, end time.Time) models.Unit {
+	start := end.Add(-2 * time.Hour)
+
+	return models.Unit{
+		UUID:        uuid,
+		StartedAt:   start.Format(base.DatetimeLayout),
+		StartedAtTS: start.UnixMilli(),
+		EndedAt:     end.Format(base.DatetimeLayout),
+		EndedAtTS:   end.UnixMilli(),
+		Tags:        models.Tag{"nodelistexp": "compute-" + uuid},
+		Steps:       []models.UnitStep{{StepID: "batch"}, {StepID: "0"}},
+		Script:      &models.UnitScript{Script: "#!/bin/bash"},
+	}
+}
+
+func TestPartitionUnits(t *testing.T) {
+	c, err := prepareMockConfig(t.TempDir())
+	require.NoError(t, err, "failed to create mock config")
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	old := terminatedUnit("1111", currentMonth.AddDate(0, -2, 0).Add(time.Hour))
+	recent := terminatedUnit("2222", currentMonth.Add(-time.Hour))
+	running := models.Unit{UUID: "3333", StartedAt: now.Format(base.DatetimeLayout), StartedAtTS: now.UnixMilli()}
+
+	insert := func(units ...models.Unit) {
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+
+		err = s.execStatements(
+			ctx, tx, now.Add(-time.Minute), now,
+			[]models.ClusterUnits{{Cluster: models.Cluster{ID: "default"}, Units: units}}, nil, nil, nil, nil,
+		)
+		require.NoError(t, err)
+
+		require.NoError(t, s.partitionUnits(ctx, tx, now))
+		require.NoError(t, tx.Commit())
+	}
+
+	count := func(query string) int {
+		var n int
+		require.NoError(t, s.db.QueryRow(query).Scan(&n)) //nolint:gosec
+
+		return n
+	}
+
+	insert(old, recent, running)
+
+	// Only running unit must remain in units table
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM "+base.UnitsDBTableName))
+
+	partitions, err := unitsPartitions(ctx, s.db)
+	require.NoError(t, err)
+	require.Len(t, partitions, 2)
+	assert.Equal(t, base.UnitsPartitionName(time.UnixMilli(old.EndedAtTS)), partitions[0].name)
+	assert.Equal(t, base.UnitsPartitionName(time.UnixMilli(recent.EndedAtTS)), partitions[1].name)
+
+	// Nodes, steps and scripts of moved units must be preserved
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM "+base.UnitNodesDBTableName))
+	assert.Equal(t, 4, count("SELECT COUNT(*) FROM "+base.UnitStepsDBTableName))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM "+base.UnitScriptsDBTableName))
+	assert.Equal(t, 1, count(fmt.Sprintf(
+		"SELECT COUNT(*) FROM %s WHERE id IN (SELECT unit_id FROM %s WHERE node = 'compute-2222')",
+		partitions[1].name, base.UnitNodesDBTableName,
+	)))
+
+	// Units that are updated again after being moved must replace partitioned ones
+	insert(recent, models.Unit{UUID: "4444", StartedAt: now.Format(base.DatetimeLayout), StartedAtTS: now.UnixMilli()})
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM "+partitions[1].name))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM "+base.UnitNodesDBTableName))
+	assert.Equal(t, 4, count("SELECT COUNT(*) FROM "+base.UnitStepsDBTableName))
+
+	// Columns added to units table must be added to partitions
+	_, err = s.db.Exec("ALTER TABLE " + base.UnitsDBTableName + " ADD COLUMN extra text")
+	require.NoError(t, err)
+	require.NoError(t, syncUnitsPartitions(ctx, s.db))
+
+	for _, p := range partitions {
+		columns, err := tableColumns(ctx, s.db, p.name)
+		require.NoError(t, err)
+		assert.Equal(t, "extra", columns[len(columns)-1][0])
+	}
+
+	// Annotations of units in units table and partitions. Annotations are old
+	// but they must be kept as long as their units exist
+	for _, uuid := range []string{old.UUID, recent.UUID, running.UUID} {
+		_, err = s.db.Exec(
+			"INSERT INTO "+base.AnnotationsDBTableName+" (cluster_id, uuid, author, note, created_at) VALUES ('default', ?, 'usr1', 'note', ?)",
+			uuid, now.AddDate(-2, 0, 0).Format(base.DatetimeLayout),
+		)
+		require.NoError(t, err)
+	}
+
+	// Partition that is older than retention period must be dropped along with
+	// its child rows while the recent one is kept
+	s.storage.retentionPeriod = 40 * 24 * time.Hour
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, s.purgeExpiredPartitions(ctx, tx))
+
+	deleted, err := purgeOrphanAnnotations(ctx, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	// Only annotation of unit in dropped partition must be deleted
+	assert.Equal(t, int64(1), deleted)
+	assert.Equal(t, 0, count("SELECT COUNT(*) FROM "+base.AnnotationsDBTableName+" WHERE uuid = '"+old.UUID+"'"))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM "+base.AnnotationsDBTableName))
+
+	partitions, err = unitsPartitions(ctx, s.db)
+	require.NoError(t, err)
+	require.Len(t, partitions, 1)
+	assert.Equal(t, base.UnitsPartitionName(time.UnixMilli(recent.EndedAtTS)), partitions[0].name)
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM "+base.UnitNodesDBTableName))
+	assert.Equal(t, 2, count("SELECT COUNT(*) FROM "+base.UnitStepsDBTableName))
+	assert.Equal(t, 1, count("SELECT COUNT(*) FROM "+base.UnitScriptsDBTableName))
+}
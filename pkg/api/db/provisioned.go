@@ -0,0 +1,80 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
+	"gopkg.in/yaml.v3"
+)
+
+// addProvisioned adds the clusters and updaters provisioned using API server to
+// manager and updater. Configs in config file take precedence over provisioned
+// ones with same IDs. Invalid configs are skipped and errors are returned.
+func addProvisioned(ctx context.Context, db *sql.DB, manager *resource.Manager, unitUpdater *updater.UnitUpdater) error {
+	//nolint:gosec
+	rows, err := db.QueryContext(
+		ctx, fmt.Sprintf("SELECT kind,config_id,config FROM %s ORDER BY id", base.ProvisionedDBTableName),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var clusters []models.Cluster
+
+	var instances []updater.Instance
+
+	var errs error
+
+	for rows.Next() {
+		var kind, id, config string
+		if err := rows.Scan(&kind, &id, &config); err != nil {
+			return err
+		}
+
+		switch kind {
+		case models.ProvisionedCluster:
+			var cluster models.Cluster
+			if err := yaml.Unmarshal([]byte(config), &cluster); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("invalid config of cluster %s: %w", id, err))
+
+				continue
+			}
+
+			clusters = append(clusters, cluster)
+		case models.ProvisionedUpdater:
+			var instance updater.Instance
+			if err := yaml.Unmarshal([]byte(config), &instance); err != nil {
+				errs = errors.Join(errs, fmt.Errorf("invalid config of updater %s: %w", id, err))
+
+				continue
+			}
+
+			instances = append(instances, instance)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Updaters are added first as they are referenced by clusters
+	if len(instances) > 0 && unitUpdater != nil {
+		errs = errors.Join(errs, unitUpdater.Add(instances))
+	}
+
+	if len(clusters) > 0 && manager != nil {
+		errs = errors.Join(errs, manager.Add(clusters))
+	}
+
+	return errs
+}
@@ -0,0 +1,274 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// retentionTable is a table whose entries are purged after retention period
+// based on the time in column.
+type retentionTable struct {
+	table  string
+	column string
+}
+
+// retentionTables are the tables purged by retention in the order they are purged.
+var retentionTables = []retentionTable{
+	{base.UnitsDBTableName, "started_at"},
+	{base.UsageDBTableName, "last_updated_at"},
+	{base.NodesDBTableName, "last_updated_at"},
+	{base.ReservationsDBTableName, "ended_at"},
+	{base.PreemptionsDBTableName, "preempted_at"},
+}
+
+// unitsTables returns units table and its monthly partitions.
+func unitsTables(ctx context.Context, db dbQueryer) ([]string, error) {
+	partitions, err := unitsPartitions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := []string{base.UnitsDBTableName}
+	for _, p := range partitions {
+		tables = append(tables, p.name)
+	}
+
+	return tables, nil
+}
+
+// orphanAnnotationsCond returns the condition matching annotations whose units
+// do not exist in any of the tables. Units are further filtered by unitsCond
+// when it is not empty.
+func orphanAnnotationsCond(tables []string, unitsCond string) string {
+	conds := make([]string, len(tables))
+	for i, table := range tables {
+		conds[i] = fmt.Sprintf(
+			"NOT EXISTS (SELECT 1 FROM %[1]s AS u WHERE u.cluster_id = %[2]s.cluster_id AND u.uuid = %[2]s.uuid%[3]s)",
+			table, base.AnnotationsDBTableName, unitsCond,
+		)
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+// purgeOrphanAnnotations deletes annotations of units that do not exist anymore
+// in units table or its partitions. Annotations are not purged based on their
+// own creation time as units can be annotated long after they started.
+func purgeOrphanAnnotations(ctx context.Context, tx *sql.Tx) (int64, error) {
+	tables, err := unitsTables(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE %s", base.AnnotationsDBTableName, orphanAnnotationsCond(tables, "")), // #nosec
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge annotations: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// PurgeReport is the number of entries of a table in a month that are deleted
+// when expired entries are purged. Bytes is the approximate size of data in
+// the entries.
+type PurgeReport struct {
+	Table string `json:"table"`
+	Month string `json:"month"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+}
+
+// bytesExpr returns the expression of approximate size of a row of table
+// whose columns are prefixed with alias.
+func bytesExpr(ctx context.Context, db dbQueryer, table string, alias string) (string, error) {
+	columns, err := tableColumns(ctx, db, table)
+	if err != nil {
+		return "", err
+	}
+
+	if len(columns) == 0 {
+		return "0", nil
+	}
+
+	exprs := make([]string, len(columns))
+	for i, col := range columns {
+		exprs[i] = fmt.Sprintf("COALESCE(length(%s\"%s\"), 0)", alias, col[0])
+	}
+
+	return strings.Join(exprs, " + "), nil
+}
+
+// scanPurgeReports scans rows of month, number of rows and bytes into reports of table.
+func scanPurgeReports(rows *sql.Rows, table string) ([]PurgeReport, error) {
+	defer rows.Close()
+
+	var reports []PurgeReport
+
+	for rows.Next() {
+		r := PurgeReport{Table: table}
+		if err := rows.Scan(&r.Month, &r.Rows, &r.Bytes); err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports, rows.Err()
+}
+
+// purgeReport returns the number of rows and bytes per table and per month
+// that will be deleted when expired entries are purged. Units in monthly
+// partitions are reported under their partitions and child rows of expired
+// units, like nodes and steps, are reported under their tables in the month in
+// which units started. Annotations of expired units are reported in the month
+// in which they are created.
+func (s *stats) purgeReport(ctx context.Context, db dbQueryer) ([]PurgeReport, error) {
+	cutoff := fmt.Sprintf("date('now', '-%d day')", int(s.storage.retentionPeriod.Hours()/24))
+	expired := "<= " + cutoff
+
+	tables := slices.Clone(retentionTables)
+
+	partitions, err := unitsPartitions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	unitsTbls := []string{base.UnitsDBTableName}
+
+	for _, p := range partitions {
+		tables = append(tables, retentionTable{p.name, "started_at"})
+		unitsTbls = append(unitsTbls, p.name)
+	}
+
+	var reports []PurgeReport
+
+	// Reports of child rows of expired units keyed by table and month
+	children := make(map[[2]string]*PurgeReport)
+
+	childBytes := make(map[string]string, len(unitChildTables))
+
+	for _, table := range unitChildTables {
+		if childBytes[table], err = bytesExpr(ctx, db, table, "n."); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range tables {
+		rowBytes, err := bytesExpr(ctx, db, t.table, "")
+		if err != nil {
+			return nil, err
+		}
+
+		query := fmt.Sprintf(
+			"SELECT substr(%[2]s, 1, 7) AS month, COUNT(*), COALESCE(SUM(%[3]s), 0) FROM %[1]s "+
+				"WHERE %[2]s %[4]s GROUP BY month ORDER BY month",
+			t.table, t.column, rowBytes, expired,
+		) // #nosec
+
+		rows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to report expired entries of %s: %w", t.table, err)
+		}
+
+		tableReports, err := scanPurgeReports(rows, t.table)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, tableReports...)
+
+		if t.column != "started_at" {
+			continue
+		}
+
+		// Child rows of expired units
+		for _, table := range unitChildTables {
+			query = fmt.Sprintf(
+				"SELECT substr(u.started_at, 1, 7) AS month, COUNT(*), COALESCE(SUM(%[3]s), 0) FROM %[1]s AS n "+
+					"JOIN %[2]s AS u ON n.unit_id = u.id WHERE u.started_at %[4]s GROUP BY month",
+				table, t.table, childBytes[table], expired,
+			) // #nosec
+
+			rows, err = db.QueryContext(ctx, query)
+			if err != nil {
+				return nil, fmt.Errorf("failed to report expired entries of %s: %w", table, err)
+			}
+
+			childReports, err := scanPurgeReports(rows, table)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, r := range childReports {
+				if existing, ok := children[[2]string{table, r.Month}]; ok {
+					existing.Rows += r.Rows
+					existing.Bytes += r.Bytes
+				} else {
+					children[[2]string{table, r.Month}] = &r
+				}
+			}
+		}
+	}
+
+	for _, r := range children {
+		reports = append(reports, *r)
+	}
+
+	// Annotations whose units are all expired
+	annotBytes, err := bytesExpr(ctx, db, base.AnnotationsDBTableName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"SELECT substr(created_at, 1, 7) AS month, COUNT(*), COALESCE(SUM(%[2]s), 0) FROM %[1]s "+
+			"WHERE %[3]s GROUP BY month ORDER BY month",
+		base.AnnotationsDBTableName, annotBytes, orphanAnnotationsCond(unitsTbls, " AND u.started_at > "+cutoff),
+	) // #nosec
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report expired entries of %s: %w", base.AnnotationsDBTableName, err)
+	}
+
+	annotReports, err := scanPurgeReports(rows, base.AnnotationsDBTableName)
+	if err != nil {
+		return nil, err
+	}
+
+	reports = append(reports, annotReports...)
+
+	// Sort reports by table and month
+	slices.SortStableFunc(reports, func(a, b PurgeReport) int {
+		if c := strings.Compare(a.Table, b.Table); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Month, b.Month)
+	})
+
+	return reports, nil
+}
+
+// PurgeReport returns the number of rows and bytes per table and per month that
+// will be deleted when expired entries are purged without deleting them.
+func (s *stats) PurgeReport(ctx context.Context) ([]PurgeReport, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	return s.purgeReport(ctx, tx)
+}
@@ -0,0 +1,111 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeReportAndDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	c, err := prepareMockConfig(tmpDir)
+	require.NoError(t, err, "failed to create mock config")
+
+	c.Data.RetentionDryRun = true
+
+	s, err := New(c)
+	require.NoError(t, err, "failed to create new stats")
+
+	defer s.Stop()
+
+	// Add an expired unit with two nodes and an active unit
+	expired := time.Now().Add(-s.storage.retentionPeriod * 2)
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "default"},
+			Units: []models.Unit{
+				{
+					UUID:      "1",
+					StartedAt: expired.Format(base.DatetimeLayout),
+					Tags:      models.Tag{"nodelistexp": "compute-0|compute-1"},
+				},
+				{UUID: "2", StartedAt: time.Now().Format(base.DatetimeLayout)},
+			},
+		},
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, s.execStatements(ctx, tx, time.Now().Add(-time.Minute), time.Now(), units, nil, nil, nil, nil))
+	require.NoError(t, tx.Commit())
+
+	// Annotate expired unit recently and active unit long ago. Only annotation
+	// of expired unit must be purged
+	for _, a := range [][2]string{{"1", time.Now().Format(base.DatetimeLayout)}, {"2", expired.Format(base.DatetimeLayout)}} {
+		_, err = s.db.Exec(
+			"INSERT INTO "+base.AnnotationsDBTableName+" (cluster_id, uuid, author, note, created_at) VALUES ('default', ?, 'usr1', 'note', ?)",
+			a[0], a[1],
+		)
+		require.NoError(t, err)
+	}
+
+	// Report must contain expired unit, its nodes and annotations
+	reports, err := s.PurgeReport(ctx)
+	require.NoError(t, err)
+
+	month := expired.Format("2006-01")
+	got := map[string]PurgeReport{}
+
+	for _, r := range reports {
+		got[r.Table] = r
+	}
+
+	require.Contains(t, got, base.UnitsDBTableName)
+	assert.Equal(t, month, got[base.UnitsDBTableName].Month)
+	assert.Equal(t, int64(1), got[base.UnitsDBTableName].Rows)
+	assert.Positive(t, got[base.UnitsDBTableName].Bytes)
+	require.Contains(t, got, base.UnitNodesDBTableName)
+	assert.Equal(t, int64(2), got[base.UnitNodesDBTableName].Rows)
+	require.Contains(t, got, base.AnnotationsDBTableName)
+	assert.Equal(t, time.Now().Format("2006-01"), got[base.AnnotationsDBTableName].Month)
+	assert.Equal(t, int64(1), got[base.AnnotationsDBTableName].Rows)
+
+	purge := func() {
+		tx, err := s.db.Begin()
+		require.NoError(t, err)
+		require.NoError(t, s.purgeExpiredUnits(ctx, tx))
+		require.NoError(t, tx.Commit())
+	}
+
+	// In dry run, nothing must be deleted
+	purge()
+
+	var numUnits int
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM "+base.UnitsDBTableName).Scan(&numUnits))
+	assert.Equal(t, 2, numUnits)
+
+	// Without dry run, expired unit must be deleted and report must be empty
+	s.storage.retentionDryRun = false
+	purge()
+
+	require.NoError(t, s.db.QueryRow("SELECT COUNT(*) FROM "+base.UnitsDBTableName).Scan(&numUnits))
+	assert.Equal(t, 1, numUnits)
+
+	var uuid string
+	require.NoError(t, s.db.QueryRow("SELECT uuid FROM "+base.AnnotationsDBTableName).Scan(&uuid))
+	assert.Equal(t, "2", uuid)
+
+	reports, err = s.PurgeReport(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
@@ -0,0 +1,113 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Default pragmas of SQLite connections.
+// Use WAL as journal mode by default as using litestream alongside ceems API server can result
+// in DB locked problem when restarting CEEMS API server. This is due to the starting DB connection
+// attempts to open DB in DELETE journal mode which cannot be possible when WAL is activated by
+// litestream.
+const (
+	defaultJournalMode = "WAL"
+	defaultSynchronous = "OFF"
+	defaultBusyTimeout = 5 * time.Second
+)
+
+var (
+	journalModes      = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+	synchronousLevels = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// Custom errors.
+var (
+	errInvalidJournalMode = fmt.Errorf("journal_mode must be one of %s", strings.Join(journalModes, ", "))
+	errInvalidSynchronous = fmt.Errorf("synchronous must be one of %s", strings.Join(synchronousLevels, ", "))
+	errNegativePragma     = errors.New("busy_timeout and mmap_size must not be negative")
+)
+
+// SQLiteConfig is the container for the pragmas of SQLite connections.
+type SQLiteConfig struct {
+	JournalMode string         `yaml:"journal_mode"`
+	Synchronous string         `yaml:"synchronous"`
+	BusyTimeout model.Duration `yaml:"busy_timeout"`
+	CacheSize   int64          `yaml:"cache_size"`
+	MmapSize    int64          `yaml:"mmap_size"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *SQLiteConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = SQLiteConfig{
+		JournalMode: defaultJournalMode,
+		Synchronous: defaultSynchronous,
+		BusyTimeout: model.Duration(defaultBusyTimeout),
+	}
+
+	type plain SQLiteConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	c.JournalMode = strings.ToUpper(c.JournalMode)
+	c.Synchronous = strings.ToUpper(c.Synchronous)
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *SQLiteConfig) Validate() error {
+	if c.JournalMode != "" && !slices.Contains(journalModes, c.JournalMode) {
+		return fmt.Errorf("invalid sqlite config: %w", errInvalidJournalMode)
+	}
+
+	if c.Synchronous != "" && !slices.Contains(synchronousLevels, c.Synchronous) {
+		return fmt.Errorf("invalid sqlite config: %w", errInvalidSynchronous)
+	}
+
+	if c.BusyTimeout < 0 || c.MmapSize < 0 {
+		return fmt.Errorf("invalid sqlite config: %w", errNegativePragma)
+	}
+
+	return nil
+}
+
+// Options returns the DSN options of SQLite connections. Zero values fallback
+// to defaults. Journal mode and synchronous only matter for writes and hence,
+// they are set only on read-write connections.
+func (c *SQLiteConfig) Options(readOnly bool) map[string]string {
+	busyTimeout := cmp.Or(time.Duration(c.BusyTimeout), defaultBusyTimeout)
+
+	opts := map[string]string{
+		"_busy_timeout": strconv.FormatInt(busyTimeout.Milliseconds(), 10),
+	}
+
+	if !readOnly {
+		opts["_journal_mode"] = cmp.Or(c.JournalMode, defaultJournalMode)
+		opts["_synchronous"] = cmp.Or(c.Synchronous, defaultSynchronous)
+	}
+
+	// Negative cache size is the size in KiB instead of number of pages
+	if c.CacheSize != 0 {
+		opts["_cache_size"] = strconv.FormatInt(c.CacheSize, 10)
+	}
+
+	if c.MmapSize > 0 {
+		opts["_mmap_size"] = strconv.FormatInt(c.MmapSize, 10)
+	}
+
+	return opts
+}
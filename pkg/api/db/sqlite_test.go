@@ -0,0 +1,50 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSQLiteConfig(t *testing.T) {
+	var c SQLiteConfig
+
+	// Defaults must be set and modes are case insensitive
+	err := yaml.Unmarshal([]byte("synchronous: normal\nmmap_size: 268435456"), &c)
+	require.NoError(t, err)
+	require.NoError(t, c.Validate())
+	assert.Equal(t, model.Duration(5*time.Second), c.BusyTimeout)
+	assert.Equal(t, map[string]string{
+		"_busy_timeout": "5000",
+		"_journal_mode": "WAL",
+		"_synchronous":  "NORMAL",
+		"_mmap_size":    "268435456",
+	}, c.Options(false))
+
+	// Journal mode and synchronous are not set on read-only connections
+	assert.Equal(t, map[string]string{
+		"_busy_timeout": "5000",
+		"_mmap_size":    "268435456",
+	}, c.Options(true))
+
+	// Zero config falls back to defaults
+	assert.Equal(t, defaultOpts, (&SQLiteConfig{}).Options(false))
+
+	c.JournalMode = "FOO"
+	require.ErrorIs(t, c.Validate(), errInvalidJournalMode)
+
+	c.JournalMode = "DELETE"
+	c.Synchronous = "BAR"
+	require.ErrorIs(t, c.Validate(), errInvalidSynchronous)
+
+	c.Synchronous = "FULL"
+	c.MmapSize = -1
+	require.ErrorIs(t, c.Validate(), errNegativePragma)
+}
@@ -0,0 +1,6 @@
+INSERT INTO nodes (cluster_id,resource_manager,hostname,period_start,period_start_ts,total_time_seconds,total_energy_usage_kwh,total_emissions_gms,num_updates,last_updated_at) VALUES (:cluster_id,:resource_manager,:hostname,:period_start,:period_start_ts,:total_time_seconds,:total_energy_usage_kwh,:total_emissions_gms,:num_updates,:last_updated_at) ON CONFLICT(cluster_id,hostname,period_start) DO UPDATE SET
+  total_time_seconds = add_metric_map(total_time_seconds, :total_time_seconds),
+  total_energy_usage_kwh = add_metric_map(total_energy_usage_kwh, :total_energy_usage_kwh),
+  total_emissions_gms = add_metric_map(total_emissions_gms, :total_emissions_gms),
+  num_updates = num_updates + :num_updates,
+  last_updated_at = :last_updated_at
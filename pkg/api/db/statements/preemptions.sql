@@ -0,0 +1,5 @@
+INSERT INTO preemptions (cluster_id,resource_manager,uuid,name,project,groupname,username,started_at,preempted_at,preempted_at_ts,elapsed,allocation,tags,last_updated_at) VALUES (:cluster_id,:resource_manager,:uuid,:name,:project,:groupname,:username,:started_at,:preempted_at,:preempted_at_ts,:elapsed,:allocation,:tags,:last_updated_at) ON CONFLICT(cluster_id,uuid,preempted_at_ts) DO UPDATE SET
+  elapsed = :elapsed,
+  allocation = :allocation,
+  tags = :tags,
+  last_updated_at = :last_updated_at
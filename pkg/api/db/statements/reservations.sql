@@ -0,0 +1,12 @@
+INSERT INTO reservations (cluster_id,resource_manager,name,project,started_at,ended_at,started_at_ts,ended_at_ts,nodes,allocation,reserved_time_seconds,allocated_time_seconds,idle_time_seconds,num_updates,last_updated_at) VALUES (:cluster_id,:resource_manager,:name,:project,:started_at,:ended_at,:started_at_ts,:ended_at_ts,:nodes,:allocation,:reserved_time_seconds,:allocated_time_seconds,:idle_time_seconds,:num_updates,:last_updated_at) ON CONFLICT(cluster_id,name,started_at) DO UPDATE SET
+  project = CASE WHEN :project = '' THEN project ELSE :project END,
+  ended_at = :ended_at,
+  ended_at_ts = :ended_at_ts,
+  nodes = :nodes,
+  allocation = :allocation,
+  reserved_time_seconds = add_metric_map(reserved_time_seconds, :reserved_time_seconds),
+  allocated_time_seconds = add_metric_map(allocated_time_seconds, :allocated_time_seconds),
+  idle_time_seconds = add_metric_map(idle_time_seconds, :idle_time_seconds),
+  num_updates = num_updates + :num_updates,
+  last_updated_at = :last_updated_at
+RETURNING project
@@ -0,0 +1,4 @@
+INSERT INTO roles (username,role,source,updated_by,last_updated_at) VALUES (:username,:role,:source,:updated_by,:last_updated_at) ON CONFLICT(username,source) DO UPDATE SET
+  role = :role,
+  updated_by = :updated_by,
+  last_updated_at = :last_updated_at
@@ -0,0 +1 @@
+INSERT OR IGNORE INTO unit_nodes (unit_id,node) SELECT id,:node FROM units WHERE cluster_id = :cluster_id AND uuid = :uuid AND started_at_ts = :started_at_ts
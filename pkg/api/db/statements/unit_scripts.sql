@@ -0,0 +1 @@
+INSERT INTO unit_scripts (unit_id,script,environment,truncated,last_updated_at) SELECT id,:script,:environment,:truncated,:last_updated_at FROM units WHERE cluster_id = :cluster_id AND uuid = :uuid AND started_at_ts = :started_at_ts ON CONFLICT(unit_id) DO NOTHING
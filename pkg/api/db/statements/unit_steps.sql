@@ -0,0 +1,13 @@
+INSERT INTO unit_steps (unit_id,step_id,name,state,started_at,ended_at,started_at_ts,ended_at_ts,elapsed,exit_code,nodelist,allocation,total_time_seconds,last_updated_at) SELECT id,:step_id,:name,:state,:started_at,:ended_at,:step_started_at_ts,:ended_at_ts,:elapsed,:exit_code,:nodelist,:allocation,:total_time_seconds,:last_updated_at FROM units WHERE cluster_id = :cluster_id AND uuid = :uuid AND started_at_ts = :started_at_ts ON CONFLICT(unit_id,step_id) DO UPDATE SET
+  name = :name,
+  state = :state,
+  started_at = :started_at,
+  ended_at = :ended_at,
+  started_at_ts = :step_started_at_ts,
+  ended_at_ts = :ended_at_ts,
+  elapsed = :elapsed,
+  exit_code = :exit_code,
+  nodelist = :nodelist,
+  allocation = :allocation,
+  total_time_seconds = :total_time_seconds,
+  last_updated_at = :last_updated_at
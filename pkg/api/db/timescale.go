@@ -0,0 +1,276 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // Registers pgx driver
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Default name of the hypertable of usage snapshots.
+const defaultSnapshotsTable = "unit_usage_snapshots"
+
+// Custom errors.
+var (
+	errInvalidSnapshotsTable = errors.New("table must be a valid SQL identifier")
+	errNoChunkInterval       = errors.New("chunk_interval must be more than 0s")
+)
+
+var sqlIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// snapshotColumns are the columns of usage snapshots hypertable in the
+// order of insertion along with their PostgreSQL types.
+var snapshotColumns = []struct {
+	name string
+	typ  string
+}{
+	{"time", "TIMESTAMPTZ NOT NULL"},
+	{"cluster_id", "TEXT NOT NULL"},
+	{"resource_manager", "TEXT"},
+	{"uuid", "TEXT NOT NULL"},
+	{"project", "TEXT"},
+	{"groupname", "TEXT"},
+	{"username", "TEXT"},
+	{"state", "TEXT"},
+	{"total_time_seconds", "JSONB"},
+	{"avg_cpu_usage", "JSONB"},
+	{"avg_cpu_mem_usage", "JSONB"},
+	{"total_cpu_energy_usage_kwh", "JSONB"},
+	{"total_cpu_emissions_gms", "JSONB"},
+	{"avg_gpu_usage", "JSONB"},
+	{"avg_gpu_mem_usage", "JSONB"},
+	{"total_gpu_energy_usage_kwh", "JSONB"},
+	{"total_gpu_emissions_gms", "JSONB"},
+	{"total_io_write_stats", "JSONB"},
+	{"total_io_read_stats", "JSONB"},
+	{"total_ingress_stats", "JSONB"},
+	{"total_outgress_stats", "JSONB"},
+}
+
+// TimescaleConfig is the container for the config of storing periodic
+// usage snapshots of units in a TimescaleDB hypertable.
+type TimescaleConfig struct {
+	DSN           config.Secret  `yaml:"dsn"`
+	Table         string         `yaml:"table"`
+	ChunkInterval model.Duration `yaml:"chunk_interval"`
+	CompressAfter model.Duration `yaml:"compress_after"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *TimescaleConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = TimescaleConfig{
+		Table:         defaultSnapshotsTable,
+		ChunkInterval: model.Duration(7 * 24 * time.Hour),
+		CompressAfter: model.Duration(7 * 24 * time.Hour),
+	}
+
+	type plain TimescaleConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *TimescaleConfig) Validate() error {
+	if c.DSN == "" {
+		return nil
+	}
+
+	if !sqlIdentifierRegex.MatchString(c.Table) {
+		return fmt.Errorf("invalid timescaledb config: %w", errInvalidSnapshotsTable)
+	}
+
+	if c.ChunkInterval <= 0 {
+		return fmt.Errorf("invalid timescaledb config: %w", errNoChunkInterval)
+	}
+
+	return nil
+}
+
+// timescaleStore stores usage snapshots of units in a TimescaleDB hypertable.
+type timescaleStore struct {
+	logger *slog.Logger
+	db     *sql.DB
+	table  string
+}
+
+// newTimescaleStore returns a new instance of timescaleStore after setting up
+// the hypertable. A nil store is returned when DSN is not configured.
+func newTimescaleStore(ctx context.Context, c TimescaleConfig, logger *slog.Logger) (*timescaleStore, error) {
+	if c.DSN == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	db, err := sql.Open("pgx", string(c.DSN))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stmt := range timescaleSetupStatements(c) {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+
+			return nil, fmt.Errorf("failed to setup hypertable %s: %w", c.Table, err)
+		}
+	}
+
+	logger.Info("Usage snapshots will be stored in TimescaleDB", "table", c.Table)
+
+	return &timescaleStore{
+		logger: logger,
+		db:     db,
+		table:  c.Table,
+	}, nil
+}
+
+// interval returns d as a PostgreSQL interval literal.
+func interval(d model.Duration) string {
+	return fmt.Sprintf("INTERVAL '%d seconds'", int64(time.Duration(d).Seconds()))
+}
+
+// timescaleSetupStatements returns the statements that create the hypertable
+// and its compression policy. All statements are idempotent.
+func timescaleSetupStatements(c TimescaleConfig) []string {
+	cols := make([]string, len(snapshotColumns))
+	for i, col := range snapshotColumns {
+		cols[i] = fmt.Sprintf("%s %s", col.name, col.typ)
+	}
+
+	stmts := []string{
+		"CREATE EXTENSION IF NOT EXISTS timescaledb",
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", c.Table, strings.Join(cols, ", ")),
+		fmt.Sprintf(
+			"SELECT create_hypertable('%s', 'time', chunk_time_interval => %s, if_not_exists => TRUE)",
+			c.Table, interval(c.ChunkInterval),
+		),
+		fmt.Sprintf(
+			"CREATE INDEX IF NOT EXISTS idx_%[1]s_cluster_uuid ON %[1]s (cluster_id, uuid, time DESC)",
+			c.Table,
+		),
+	}
+
+	// Compress chunks older than compress_after segmented by cluster and unit
+	// so that time bucketed queries on a given unit stay efficient
+	if c.CompressAfter > 0 {
+		stmts = append(
+			stmts,
+			fmt.Sprintf(
+				"ALTER TABLE %s SET (timescaledb.compress, timescaledb.compress_segmentby = 'cluster_id, uuid', timescaledb.compress_orderby = 'time DESC')",
+				c.Table,
+			),
+			fmt.Sprintf(
+				"SELECT add_compression_policy('%s', %s, if_not_exists => TRUE)",
+				c.Table, interval(c.CompressAfter),
+			),
+		)
+	}
+
+	return stmts
+}
+
+// insertStatement returns the statement to insert a snapshot into hypertable.
+func (t *timescaleStore) insertStatement() string {
+	cols := make([]string, len(snapshotColumns))
+	params := make([]string, len(snapshotColumns))
+
+	for i, col := range snapshotColumns {
+		cols[i] = col.name
+		params[i] = fmt.Sprintf("$%d", i+1)
+
+		if strings.HasPrefix(col.typ, "JSONB") {
+			params[i] += "::jsonb"
+		}
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", t.table, strings.Join(cols, ", "), strings.Join(params, ", "))
+}
+
+// insert stores the snapshots of units at snapshotTime.
+func (t *timescaleStore) insert(ctx context.Context, snapshotTime time.Time, clusterUnits []models.ClusterUnits) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin SQL transcation: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, t.insertStatement())
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("errors: %w, %w", err, rbErr)
+		}
+
+		return err
+	}
+	defer stmt.Close()
+
+	var numSnapshots int
+
+	for _, cluster := range clusterUnits {
+		for _, unit := range cluster.Units {
+			if unit.UUID == "" {
+				continue
+			}
+
+			if _, err := stmt.ExecContext(
+				ctx,
+				snapshotTime,
+				cluster.Cluster.ID,
+				cluster.Cluster.Manager,
+				unit.UUID,
+				unit.Project,
+				unit.Group,
+				unit.User,
+				unit.State,
+				unit.TotalTime,
+				unit.AveCPUUsage,
+				unit.AveCPUMemUsage,
+				unit.TotalCPUEnergyUsage,
+				unit.TotalCPUEmissions,
+				unit.AveGPUUsage,
+				unit.AveGPUMemUsage,
+				unit.TotalGPUEnergyUsage,
+				unit.TotalGPUEmissions,
+				unit.TotalIOWriteStats,
+				unit.TotalIOReadStats,
+				unit.TotalIngressStats,
+				unit.TotalOutgressStats,
+			); err != nil {
+				if rbErr := tx.Rollback(); rbErr != nil {
+					return fmt.Errorf("errors: %w, %w", err, rbErr)
+				}
+
+				return fmt.Errorf("failed to insert snapshot of unit %s: %w", unit.UUID, err)
+			}
+
+			numSnapshots++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit SQL transcation: %w", err)
+	}
+
+	t.logger.Debug("Usage snapshots stored in TimescaleDB", "num_snapshots", numSnapshots)
+
+	return nil
+}
+
+// close closes DB connection.
+func (t *timescaleStore) close() error {
+	return t.db.Close()
+}
@@ -0,0 +1,67 @@
+//go:build cgo
+// +build cgo
+
+package db
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTimescaleConfig(t *testing.T) {
+	var c TimescaleConfig
+
+	// Defaults must be set
+	err := yaml.Unmarshal([]byte("dsn: postgres://localhost/ceems"), &c)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSnapshotsTable, c.Table)
+	assert.Equal(t, model.Duration(7*24*time.Hour), c.ChunkInterval)
+	require.NoError(t, c.Validate())
+
+	// Table names must be valid identifiers
+	c.Table = "snapshots; DROP TABLE units"
+	require.ErrorIs(t, c.Validate(), errInvalidSnapshotsTable)
+
+	c.Table = defaultSnapshotsTable
+	c.ChunkInterval = 0
+	require.ErrorIs(t, c.Validate(), errNoChunkInterval)
+
+	// Config without DSN is always valid
+	require.NoError(t, (&TimescaleConfig{}).Validate())
+}
+
+func TestTimescaleStatements(t *testing.T) {
+	c := TimescaleConfig{
+		Table:         "snapshots",
+		ChunkInterval: model.Duration(24 * time.Hour),
+		CompressAfter: model.Duration(7 * 24 * time.Hour),
+	}
+
+	stmts := timescaleSetupStatements(c)
+	require.Len(t, stmts, 6)
+	assert.Equal(t, "SELECT create_hypertable('snapshots', 'time', chunk_time_interval => INTERVAL '86400 seconds', if_not_exists => TRUE)", stmts[2])
+	assert.Equal(t, "SELECT add_compression_policy('snapshots', INTERVAL '604800 seconds', if_not_exists => TRUE)", stmts[5])
+
+	// Compression is disabled
+	c.CompressAfter = 0
+	assert.Len(t, timescaleSetupStatements(c), 4)
+
+	store := timescaleStore{table: "snapshots", logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	stmt := store.insertStatement()
+	assert.Contains(t, stmt, "INSERT INTO snapshots (time, cluster_id, resource_manager, uuid,")
+	assert.Contains(t, stmt, "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb,")
+	assert.Contains(t, stmt, "$21::jsonb)")
+
+	// Store is not created without DSN
+	s, err := newTimescaleStore(context.Background(), TimescaleConfig{}, store.logger)
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
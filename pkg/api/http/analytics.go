@@ -0,0 +1,447 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	_ "github.com/marcboeker/go-duckdb" // Registers duckdb driver
+	"github.com/prometheus/common/model"
+)
+
+// Engines and sources of analytical queries.
+const (
+	sqliteAnalyticsEngine  = "sqlite"
+	duckdbAnalyticsEngine  = "duckdb"
+	sqliteAnalyticsSource  = "sqlite"
+	parquetAnalyticsSource = "parquet"
+	mirrorAnalyticsSource  = "mirror"
+)
+
+// Limits of top consumers report.
+const (
+	defaultTopLimit = 10
+	maxTopLimit     = 1000
+)
+
+// Fields of top consumers report in the same order as CSV columns.
+var topFields = []string{"cluster_id", "name", "num_units", "value"}
+
+// Expressions of metrics that can be ranked in top consumers report. They
+// must be valid in both SQLite and DuckDB.
+var topMetrics = map[string]string{
+	"cpu_hours": "COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_cputime') AS DOUBLE), 0) / 3600",
+	"gpu_hours": "COALESCE(CAST(json_extract(total_time_seconds, '$.alloc_gputime') AS DOUBLE), 0) / 3600",
+	"energy_kwh": fmt.Sprintf(
+		"COALESCE(CAST(json_extract(total_cpu_energy_usage_kwh, '$.%[1]s') AS DOUBLE), 0) + "+
+			"COALESCE(CAST(json_extract(total_gpu_energy_usage_kwh, '$.%[1]s') AS DOUBLE), 0)",
+		defaultBillingEnergyMetric,
+	),
+	"ingress_gb": fmt.Sprintf(
+		"COALESCE(CAST(json_extract(total_ingress_stats, '$.%s') AS DOUBLE), 0) / 1e9",
+		defaultBillingIngressMetric,
+	),
+}
+
+// Columns of units table by which consumers can be ranked.
+var topDimensions = map[string]string{
+	"user":    "username",
+	"project": "project",
+	"group":   "groupname",
+}
+
+// AnalyticsConfig contains the configuration of the engine used for heavy
+// aggregation queries like top consumers report.
+type AnalyticsConfig struct {
+	Engine          string         `yaml:"engine"`
+	Source          string         `yaml:"source"`
+	ParquetPath     string         `yaml:"parquet_path"`
+	MirrorPath      string         `yaml:"mirror_path"`
+	RefreshInterval model.Duration `yaml:"refresh_interval"`
+	Threads         int            `yaml:"threads"`
+	MemoryLimit     string         `yaml:"memory_limit"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *AnalyticsConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = AnalyticsConfig{
+		RefreshInterval: model.Duration(defaultMirrorRefreshInterval),
+	}
+
+	type plain AnalyticsConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *AnalyticsConfig) Validate() error {
+	if !slices.Contains([]string{"", sqliteAnalyticsEngine, duckdbAnalyticsEngine}, c.Engine) {
+		return fmt.Errorf("invalid analytics config: %w", errInvalidAnalyticsEngine)
+	}
+
+	if !slices.Contains([]string{"", sqliteAnalyticsSource, parquetAnalyticsSource, mirrorAnalyticsSource}, c.Source) {
+		return fmt.Errorf("invalid analytics config: %w", errInvalidAnalyticsSource)
+	}
+
+	if c.Source == parquetAnalyticsSource && c.ParquetPath == "" {
+		return fmt.Errorf("invalid analytics config: %w", errNoParquetPath)
+	}
+
+	if c.Source == mirrorAnalyticsSource && c.RefreshInterval <= 0 {
+		return fmt.Errorf("invalid analytics config: %w", errInvalidRefreshInterval)
+	}
+
+	return nil
+}
+
+// sqlString returns s as a quoted SQL string literal.
+func sqlString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// openAnalyticsDB opens an in-memory DuckDB database with a units view over either
+// the CEEMS DB in data path or the Parquet files in parquet path. When source is
+// mirror, DuckDB database file in mirror path that mirrors units table is opened
+// instead. A nil DB is returned when DuckDB engine is not configured and analytical
+// queries are made on SQLite.
+//
+// DuckDB reads the SQLite file using its sqlite extension which is installed on the
+// first start when it is not available already.
+func openAnalyticsDB(dataPath string, c AnalyticsConfig) (*sql.DB, error) {
+	if c.Engine != duckdbAnalyticsEngine {
+		return nil, nil //nolint:nilnil
+	}
+
+	values := url.Values{}
+	if c.Threads > 0 {
+		values.Set("threads", strconv.Itoa(c.Threads))
+	}
+
+	if c.MemoryLimit != "" {
+		values.Set("memory_limit", c.MemoryLimit)
+	}
+
+	// Mirror is refreshed by analyticsMirror and there are no views to create
+	if c.Source == mirrorAnalyticsSource {
+		mirrorPath := c.MirrorPath
+		if mirrorPath == "" {
+			mirrorPath = filepath.Join(dataPath, analyticsMirrorName)
+		}
+
+		return sql.Open("duckdb", mirrorPath+"?"+values.Encode())
+	}
+
+	db, err := sql.Open("duckdb", "?"+values.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts []string
+
+	// Units exported as Parquet files can be partitioned in Hive layout like
+	// units/cluster_id=slurm-0/month=2024-01/data.parquet
+	if c.Source == parquetAnalyticsSource {
+		unitsGlob := filepath.Join(c.ParquetPath, base.UnitsDBTableName, "**", "*.parquet")
+		stmts = []string{
+			fmt.Sprintf(
+				"CREATE VIEW %s AS SELECT * FROM read_parquet(%s, hive_partitioning = true, union_by_name = true)",
+				base.UnitsDBTableName, sqlString(unitsGlob),
+			),
+		}
+	} else {
+		stmts = []string{
+			"INSTALL sqlite",
+			"LOAD sqlite",
+			fmt.Sprintf("ATTACH %s AS ceems (TYPE sqlite, READ_ONLY)", sqlString(filepath.Join(dataPath, base.CEEMSDBName))),
+			fmt.Sprintf("CREATE VIEW %[1]s AS SELECT * FROM ceems.%[1]s", base.UnitsDBTableName),
+		}
+	}
+
+	// Views are created in the in-memory catalog which is shared by all connections
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+
+			return nil, fmt.Errorf("failed to setup duckdb: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// TopConsumer is the aggregate usage of a user, project or group in a cluster.
+type TopConsumer struct {
+	ClusterID string  `json:"cluster_id" sql:"cluster_id"`
+	Name      string  `json:"name"       sql:"name"`
+	NumUnits  int64   `json:"num_units"  sql:"num_units"`
+	Value     float64 `json:"value"      sql:"value"`
+}
+
+// topQuery returns the query of top consumers from url values. Query is limited
+// to projects when it is not nil. Projects and groups with fewer distinct users
+// than floor are omitted when floor is more than 1.
+func (s *CEEMSServer) topQuery(r *http.Request, projects []string, floor int) (Query, error) {
+	urlValues := r.URL.Query()
+
+	metric, ok := topMetrics[urlValues.Get("metric")]
+	if !ok {
+		return Query{}, errInvalidTopMetric
+	}
+
+	dimension, ok := topDimensions[urlValues.Get("by")]
+	if urlValues.Get("by") == "" {
+		dimension, ok = topDimensions["project"], true
+	}
+
+	if !ok {
+		return Query{}, errInvalidTopDimension
+	}
+
+	limit := defaultTopLimit
+
+	if l := strings.TrimSpace(urlValues.Get("limit")); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+			return Query{}, errInvalidLimit
+		}
+
+		limit = min(limit, maxTopLimit)
+	}
+
+	timeQuery, err := s.getQueryWindow(r, "last_updated_at", false, false)
+	if err != nil {
+		return Query{}, err
+	}
+
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT cluster_id,%s AS name,COUNT(*) AS num_units,COALESCE(SUM(%s), 0) AS value FROM %s",
+			dimension, metric, base.UnitsDBTableName,
+		),
+	)
+	q.query(" WHERE ignore = 0 AND ")
+	q.subQuery(timeQuery)
+
+	if projects != nil {
+		q.query(" AND project IN ")
+		q.param(projects)
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, urlValues)
+	q = s.getGroupQueryParams(&q, urlValues)
+
+	q.query(fmt.Sprintf(" GROUP BY cluster_id,%s", dimension))
+
+	// Each user is a consumer on their own and hence, floor is applied only on
+	// projects and groups
+	if floor > 1 && dimension != topDimensions["user"] {
+		q.query(fmt.Sprintf(" HAVING COUNT(DISTINCT username) >= %d", floor))
+	}
+
+	q.query(fmt.Sprintf(" ORDER BY value DESC, cluster_id ASC, %[1]s ASC LIMIT %[2]d", dimension, limit))
+
+	return q, nil
+}
+
+// topQuerier ranks users, projects or groups by their usage of a metric and writes
+// them in response. If users is empty, consumers of all projects are ranked. Caller
+// is the non-admin user making the request and it is empty for admin requests on
+// which aggregation floor is not applied.
+func (s *CEEMSServer) topQuerier(users []string, caller string, w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "top endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Projects are resolved on SQLite as analytics DB has only units
+	var projects []string
+
+	if len(users) > 0 {
+		projs, err := s.queriers.project(r.Context(), s.db, projectsSubQuery(users), s.logger)
+		if projs == nil && err != nil {
+			s.logger.Error("Failed to fetch projects for top report", "users", strings.Join(users, ","), "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+
+		projects = make([]string, 0, len(projs))
+		for _, p := range projs {
+			projects = append(projects, p.Name)
+		}
+
+		// Only projects that meet aggregation floor are ranked for non-admin users
+		if projects, err = s.largeProjects(r.Context(), caller, projects); err != nil {
+			s.logger.Error("Failed to fetch sizes of projects for top report", "user", caller, "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+	}
+
+	floor := 0
+	if caller != "" {
+		floor = s.privacy.MinGroupSize
+	}
+
+	q, err := s.topQuery(r, projects, floor)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	// Set write deadline
+	s.setWriteDeadline(5*time.Minute, w)
+
+	// Heavy aggregations are offloaded to DuckDB when configured
+	dbConn := s.db
+	if s.analyticsDB != nil {
+		dbConn = s.analyticsDB
+	}
+
+	var consumers []TopConsumer
+
+	// Users without projects do not have any consumers
+	if projects == nil || len(projects) > 0 {
+		consumers, err = s.queriers.top(r.Context(), dbConn, q, s.logger)
+		if consumers == nil && err != nil {
+			s.logger.Error("Failed to fetch top consumers", "users", strings.Join(users, ","), "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+	}
+
+	// Write CSV response when requested
+	if csvRequested(r) {
+		if err := writeCSV(w, consumers, topFields, "top.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	topResponse := Response[TopConsumer]{
+		Status: "success",
+		Data:   consumers,
+	}
+
+	if err != nil {
+		topResponse.Warnings = append(topResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&topResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// topReport         godoc
+//
+//	@Summary		Top consumers in projects of current user
+//	@Description	This endpoint ranks users, projects or groups of the projects that the current
+//	@Description	user is part of by their usage of a metric. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The metric to rank is set by `metric` query parameter which can be one of
+//	@Description	`cpu_hours`, `gpu_hours`, `energy_kwh` and `ingress_gb`. Consumers are ranked by
+//	@Description	`project` by default and it can be changed to `user` or `group` using `by`
+//	@Description	query parameter. At most `limit` consumers are returned in each report which
+//	@Description	defaults to 10. The period of the report is set by `from` and `to` query
+//	@Description	parameters similar to `/usage` endpoint.
+//	@Description
+//	@Description	When DuckDB analytics engine is configured, reports are computed by DuckDB.
+//	@Description	The report can be exported as CSV using `format=csv` query parameter.
+//	@Description
+//	@Description	When an aggregation floor is configured, only projects that have at least as
+//	@Description	many distinct users as the floor are ranked and projects and groups with fewer
+//	@Description	distinct users in the period are omitted.
+//	@Security		BasicAuth
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			metric			query		string		true	"Metric to rank"	Enums(cpu_hours, gpu_hours, energy_kwh, ingress_gb)
+//	@Param			by				query		string		false	"Rank by"			Enums(user, project, group)
+//	@Param			limit			query		integer		false	"Number of consumers"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			group			query		[]string	false	"Group"			collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
+//	@Success		200				{object}	Response[TopConsumer]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/reports/top [get]
+//
+// GET /reports/top
+// Get top consumers in projects of current user.
+func (s *CEEMSServer) topReport(w http.ResponseWriter, r *http.Request) {
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	s.topQuerier([]string{dashboardUser}, dashboardUser, w, r)
+}
+
+// topReportAdmin         godoc
+//
+//	@Summary		Admin endpoint for top consumers report
+//	@Description	This admin endpoint ranks users, projects or groups of all projects by their
+//	@Description	usage of a metric. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Query parameters are same as `/reports/top`
+//	@Description	endpoint.
+//	@Security		BasicAuth
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			metric			query		string		true	"Metric to rank"	Enums(cpu_hours, gpu_hours, energy_kwh, ingress_gb)
+//	@Param			by				query		string		false	"Rank by"			Enums(user, project, group)
+//	@Param			limit			query		integer		false	"Number of consumers"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			group			query		[]string	false	"Group"			collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
+//	@Success		200				{object}	Response[TopConsumer]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/reports/top/admin [get]
+//
+// GET /reports/top/admin
+// Get top consumers of all projects.
+func (s *CEEMSServer) topReportAdmin(w http.ResponseWriter, r *http.Request) {
+	s.topQuerier(nil, "", w, r)
+}
@@ -0,0 +1,193 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Units used to compare top consumers reports of SQLite and DuckDB. Same
+// statements are valid in both engines.
+const analyticsUnitsStmts = `
+CREATE TABLE units (
+	cluster_id text,
+	username text,
+	project text,
+	groupname text,
+	ignore integer,
+	last_updated_at text,
+	total_time_seconds text,
+	total_cpu_energy_usage_kwh text,
+	total_gpu_energy_usage_kwh text,
+	total_ingress_stats text
+);
+INSERT INTO units VALUES ('rm-0', 'usr1', 'prj1', 'grp1', 0, '2024-01-02T00:00:00+0000', '{"alloc_cputime":7200}', '{"total":1.5}', '{"total":0.5}', '{"bytes":2e9}');
+INSERT INTO units VALUES ('rm-0', 'usr2', 'prj1', 'grp1', 0, '2024-01-03T00:00:00+0000', '{"alloc_cputime":3600}', '{"total":1}', '{}', '{}');
+INSERT INTO units VALUES ('rm-0', 'usr3', 'prj2', 'grp2', 0, '2024-01-03T00:00:00+0000', '{"alloc_cputime":36000}', '{"total":10}', '{}', '{}');
+INSERT INTO units VALUES ('rm-0', 'usr3', 'prj2', 'grp2', 1, '2024-01-03T00:00:00+0000', '{"alloc_cputime":1000000}', '{"total":100}', '{}', '{}');
+INSERT INTO units VALUES ('rm-1', 'usr1', 'prj1', 'grp1', 0, '2023-01-03T00:00:00+0000', '{"alloc_cputime":1000000}', '{"total":100}', '{}', '{}');
+`
+
+func TestAnalyticsConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AnalyticsConfig
+		err    error
+	}{
+		{
+			name: "default config",
+		},
+		{
+			name:   "duckdb on parquet",
+			config: AnalyticsConfig{Engine: "duckdb", Source: "parquet", ParquetPath: "/var/lib/ceems/parquet"},
+		},
+		{
+			name:   "invalid engine",
+			config: AnalyticsConfig{Engine: "clickhouse"},
+			err:    errInvalidAnalyticsEngine,
+		},
+		{
+			name:   "invalid source",
+			config: AnalyticsConfig{Engine: "duckdb", Source: "csv"},
+			err:    errInvalidAnalyticsSource,
+		},
+		{
+			name:   "parquet without path",
+			config: AnalyticsConfig{Engine: "duckdb", Source: "parquet"},
+			err:    errNoParquetPath,
+		},
+		{
+			name:   "mirror without refresh interval",
+			config: AnalyticsConfig{Engine: "duckdb", Source: "mirror"},
+			err:    errInvalidRefreshInterval,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if test.err == nil {
+			require.NoError(t, err, test.name)
+		} else {
+			require.ErrorIs(t, err, test.err, test.name)
+		}
+	}
+}
+
+func TestTopReport(t *testing.T) {
+	dir := t.TempDir()
+
+	// Populate SQLite DB
+	sqliteDB, err := sql.Open("sqlite3", filepath.Join(dir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer sqliteDB.Close()
+
+	_, err = sqliteDB.Exec(analyticsUnitsStmts)
+	require.NoError(t, err)
+
+	// Export same units to Parquet files partitioned by cluster
+	duckDB, err := sql.Open("duckdb", "")
+	require.NoError(t, err)
+
+	defer duckDB.Close()
+
+	_, err = duckDB.Exec(analyticsUnitsStmts)
+	require.NoError(t, err)
+
+	parquetPath := filepath.Join(dir, "parquet")
+	require.NoError(t, os.MkdirAll(parquetPath, 0o700))
+
+	_, err = duckDB.Exec(
+		"COPY units TO " + sqlString(filepath.Join(parquetPath, "units")) + " (FORMAT PARQUET, PARTITION_BY (cluster_id))",
+	)
+	require.NoError(t, err)
+
+	server := setupServer(dir)
+	server.queriers.top = Querier[TopConsumer]
+
+	analyticsDB, err := openAnalyticsDB(dir, AnalyticsConfig{Engine: "duckdb", Source: "parquet", ParquetPath: parquetPath})
+	require.NoError(t, err)
+
+	defer analyticsDB.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		admin    bool
+		expected []TopConsumer
+	}{
+		{
+			name:  "cpu hours by project",
+			query: "metric=cpu_hours",
+			admin: true,
+			expected: []TopConsumer{
+				{ClusterID: "rm-0", Name: "prj2", NumUnits: 1, Value: 10},
+				{ClusterID: "rm-0", Name: "prj1", NumUnits: 2, Value: 3},
+			},
+		},
+		{
+			name:  "energy by user with limit",
+			query: "metric=energy_kwh&by=user&limit=2",
+			admin: true,
+			expected: []TopConsumer{
+				{ClusterID: "rm-0", Name: "usr3", NumUnits: 1, Value: 10},
+				{ClusterID: "rm-0", Name: "usr1", NumUnits: 1, Value: 2},
+			},
+		},
+		{
+			name:  "ingress by group in projects of user",
+			query: "metric=ingress_gb&by=group",
+			expected: []TopConsumer{
+				{ClusterID: "rm-0", Name: "grp1", NumUnits: 2, Value: 2},
+			},
+		},
+	}
+
+	// Projects of non admin user
+	server.queriers.project = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Project, error) {
+		return []models.Project{{Name: "prj1"}}, nil
+	}
+
+	for _, test := range tests {
+		// Reports from SQLite and DuckDB must be identical
+		for _, engine := range []*sql.DB{nil, analyticsDB} {
+			server.analyticsDB = engine
+
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/reports/top?from=1704067200&to=1704412800&"+test.query, nil)
+			request.Header.Set("X-Grafana-User", "usr1")
+
+			w := httptest.NewRecorder()
+			if test.admin {
+				server.topReportAdmin(w, request)
+			} else {
+				server.topReport(w, request)
+			}
+
+			require.Equal(t, http.StatusOK, w.Code, test.name)
+
+			var response Response[TopConsumer]
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+			assert.Equal(t, test.expected, response.Data, test.name)
+		}
+	}
+
+	// Invalid metric must be rejected
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/reports/top/admin?metric=walltime", nil)
+	w := httptest.NewRecorder()
+	server.topReportAdmin(w, request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,333 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
+)
+
+// API key roles.
+const (
+	apiKeyUserRole  = "user"
+	apiKeyAdminRole = "admin"
+)
+
+// apiKeyPrefix is the prefix of all API keys. It makes the keys easily
+// identifiable by secret scanners.
+const apiKeyPrefix = "ceems_"
+
+// apiKeyRequest is the request body to create a new API key.
+type apiKeyRequest struct {
+	Name string         `json:"name"`
+	User string         `json:"username"`
+	Role string         `json:"role"`
+	TTL  model.Duration `json:"ttl"`
+}
+
+// generateAPIKey returns a new random API key.
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// hashAPIKey returns the SHA-256 hash of the key. As keys are random with high
+// entropy, a fast hash is sufficient to store them.
+func hashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(h[:])
+}
+
+// lookupAPIKey returns the API key whose hash is keyHash from DB. Expired keys
+// are never returned.
+func lookupAPIKey(ctx context.Context, dbConn *sql.DB, keyHash string, logger *slog.Logger) (*models.APIKey, error) {
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE key_hash = ",
+			strings.Join(base.APIKeysDBTableColNames, ","),
+			base.APIKeysDBTableName,
+		),
+	)
+	q.param([]string{keyHash})
+	q.query(" AND (expires_at_ts = 0 OR expires_at_ts > ")
+	q.param([]string{strconv.FormatInt(time.Now().Unix(), 10)})
+	q.query(")")
+
+	keys, err := Querier[models.APIKey](ctx, dbConn, q, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, errInvalidAPIKey
+	}
+
+	return &keys[0], nil
+}
+
+// apiKeysAdmin         godoc
+//
+//	@Summary		Admin endpoint to list API keys
+//	@Description	This admin endpoint will list all the API keys of service accounts. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Keys themselves are never returned by this
+//	@Description	endpoint as only their hashes are stored.
+//	@Security		BasicAuth
+//	@Tags			api_keys
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Success		200				{object}	Response[models.APIKey]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/api_keys/admin [get]
+//
+// GET /api_keys/admin
+// List API keys.
+func (s *CEEMSServer) apiKeysAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "API keys admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s ORDER BY id ASC",
+			strings.Join(base.APIKeysDBTableColNames, ","),
+			base.APIKeysDBTableName,
+		),
+	)
+
+	keys, err := s.queriers.apiKey(r.Context(), s.db, q, s.logger)
+	if keys == nil && err != nil {
+		s.logger.Error("Failed to fetch API keys", "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	keysResponse := Response[models.APIKey]{
+		Status: "success",
+		Data:   keys,
+	}
+	if err != nil {
+		keysResponse.Warnings = append(keysResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&keysResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// createAPIKeyAdmin         godoc
+//
+//	@Summary		Admin endpoint to create API keys
+//	@Description	This admin endpoint will create a new API key for a service account. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the request
+//	@Description	and it will be recorded as the creator of the key.
+//	@Description
+//	@Description	The request body must be a JSON object with `name` and `username` keys. The
+//	@Description	`role` key can be either `user` or `admin` and defaults to `user`. Keys with
+//	@Description	`admin` role can access admin endpoints. An optional `ttl` key like `90d` sets
+//	@Description	the expiry of the key. By default, keys never expire.
+//	@Description
+//	@Description	The key is returned _only_ in the response of this request and it cannot
+//	@Description	be retrieved later.
+//	@Security		BasicAuth
+//	@Tags			api_keys
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string			true	"Current user name"
+//	@Param			api_key			body		apiKeyRequest	true	"API key"
+//	@Success		201				{object}	Response[models.APIKey]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/api_keys/admin [post]
+//
+// POST /api_keys/admin
+// Create API key.
+func (s *CEEMSServer) createAPIKeyAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "create API key admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Decode request body
+	var req apiKeyRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidAPIKeyRequest}, s.logger)
+
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = apiKeyUserRole
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.User = strings.TrimSpace(req.User)
+
+	if req.Name == "" || req.User == "" || (req.Role != apiKeyUserRole && req.Role != apiKeyAdminRole) {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidAPIKeyRequest}, s.logger)
+
+		return
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		s.logger.Error("Failed to generate API key", "err", err)
+		errorResponse(w, r, &apiError{errorInternal, err}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+	now := time.Now()
+
+	apiKey := models.APIKey{
+		Name:      req.Name,
+		User:      req.User,
+		Role:      req.Role,
+		CreatedBy: loggedUser,
+		CreatedAt: now.In(s.timeLocation("")).Format(base.DatetimezoneLayout),
+		Key:       key,
+	}
+
+	if req.TTL > 0 {
+		apiKey.ExpiresAtTS = now.Add(time.Duration(req.TTL)).Unix()
+	}
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf(
+			"INSERT INTO %s (name,username,role,key_hash,created_by,created_at,expires_at_ts) VALUES (?,?,?,?,?,?,?)",
+			base.APIKeysDBTableName,
+		),
+		apiKey.Name, apiKey.User, apiKey.Role, hashAPIKey(key), apiKey.CreatedBy, apiKey.CreatedAt, apiKey.ExpiresAtTS,
+	)
+	if err != nil {
+		s.logger.Error("Failed to create API key", "name", apiKey.Name, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if apiKey.ID, err = res.LastInsertId(); err != nil {
+		s.logger.Error("Failed to get ID of API key", "name", apiKey.Name, "err", err)
+	}
+
+	s.logger.Info(
+		"API key created", "id", apiKey.ID, "name", apiKey.Name, "user", apiKey.User,
+		"role", apiKey.Role, "created_by", loggedUser,
+	)
+
+	// Write response
+	w.WriteHeader(http.StatusCreated)
+
+	response := Response[models.APIKey]{
+		Status: "success",
+		Data:   []models.APIKey{apiKey},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// deleteAPIKeyAdmin         godoc
+//
+//	@Summary		Admin endpoint to revoke API keys
+//	@Description	This admin endpoint will revoke the API key with the given ID. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Requests made with a revoked key will be denied immediately.
+//	@Security		BasicAuth
+//	@Tags			api_keys
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			id				path		int		true	"API key ID"
+//	@Success		204
+//	@Failure		400	{object}	Problem
+//	@Failure		401	{object}	Problem
+//	@Failure		403	{object}	Problem
+//	@Failure		404	{object}	Problem
+//	@Failure		500	{object}	Problem
+//	@Router			/api_keys/{id}/admin [delete]
+//
+// DELETE /api_keys/{id}/admin
+// Revoke API key.
+func (s *CEEMSServer) deleteAPIKeyAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "delete API key admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
+
+		return
+	}
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(), fmt.Sprintf("DELETE FROM %s WHERE id = ?", base.APIKeysDBTableName), id,
+	)
+	if err != nil {
+		s.logger.Error("Failed to revoke API key", "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errAPIKeyNotFound}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+	s.logger.Info("API key revoked", "id", id, "revoked_by", loggedUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,279 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Default sub metrics of usage that are used to estimate costs.
+const (
+	defaultBillingIngressMetric = "bytes"
+	defaultBillingEnergyMetric  = "total"
+)
+
+// Fields of billing report in the same order as CSV columns.
+var billingFields = []string{
+	"cluster_id", "project", "month", "currency", "cpu_hours", "gpu_hours", "ingress_gb",
+	"energy_kwh", "cpu_cost", "gpu_cost", "ingress_cost", "energy_cost", "total_cost",
+}
+
+// BillingRates contains the price of each resource.
+type BillingRates struct {
+	CPUHour   float64 `yaml:"cpu_hour"`
+	GPUHour   float64 `yaml:"gpu_hour"`
+	IngressGB float64 `yaml:"ingress_gb"`
+	EnergyKWh float64 `yaml:"energy_kwh"`
+}
+
+// BillingConfig contains the configuration of billing reports.
+type BillingConfig struct {
+	Currency      string       `yaml:"currency"`
+	Rates         BillingRates `yaml:"rates"`
+	IngressMetric string       `yaml:"ingress_metric"`
+	EnergyMetric  string       `yaml:"energy_metric"`
+}
+
+// Validate validates the config.
+func (c *BillingConfig) Validate() error {
+	if c.Rates.CPUHour < 0 || c.Rates.GPUHour < 0 || c.Rates.IngressGB < 0 || c.Rates.EnergyKWh < 0 {
+		return fmt.Errorf("invalid billing config: %w", errNegativeBillingRates)
+	}
+
+	return nil
+}
+
+// enabled returns true when at least one of the rates is configured.
+func (c *BillingConfig) enabled() bool {
+	return c.Rates.CPUHour > 0 || c.Rates.GPUHour > 0 || c.Rates.IngressGB > 0 || c.Rates.EnergyKWh > 0
+}
+
+// Bill is the cost of resources consumed by a project during a month.
+type Bill struct {
+	ClusterID   string  `json:"cluster_id"`
+	Project     string  `json:"project"`
+	Month       string  `json:"month"`
+	Currency    string  `json:"currency,omitempty"`
+	CPUHours    float64 `json:"cpu_hours"`
+	GPUHours    float64 `json:"gpu_hours"`
+	IngressGB   float64 `json:"ingress_gb"`
+	EnergyKWh   float64 `json:"energy_kwh"`
+	CPUCost     float64 `json:"cpu_cost"`
+	GPUCost     float64 `json:"gpu_cost"`
+	IngressCost float64 `json:"ingress_cost"`
+	EnergyCost  float64 `json:"energy_cost"`
+	TotalCost   float64 `json:"total_cost"`
+}
+
+// roundCost rounds cost to cents.
+func roundCost(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// bills aggregates daily usage into monthly bills of each project.
+func (c *BillingConfig) bills(usage []models.Usage) []Bill {
+	ingressMetric := cmp.Or(c.IngressMetric, defaultBillingIngressMetric)
+	energyMetric := cmp.Or(c.EnergyMetric, defaultBillingEnergyMetric)
+
+	billsMap := make(map[string]*Bill)
+
+	for _, u := range usage {
+		// Daily usage is keyed on midnight of each day in DB layout
+		month := u.LastUpdatedAt
+		if len(month) >= len("2006-01") {
+			month = month[:len("2006-01")]
+		}
+
+		key := strings.Join([]string{u.ClusterID, u.Project, month}, "|")
+		if _, ok := billsMap[key]; !ok {
+			billsMap[key] = &Bill{ClusterID: u.ClusterID, Project: u.Project, Month: month, Currency: c.Currency}
+		}
+
+		bill := billsMap[key]
+		bill.CPUHours += float64(u.TotalTime["alloc_cputime"]) / 3600
+		bill.GPUHours += float64(u.TotalTime["alloc_gputime"]) / 3600
+		bill.IngressGB += float64(u.TotalIngressStats[ingressMetric]) / 1e9
+		bill.EnergyKWh += float64(u.TotalCPUEnergyUsage[energyMetric] + u.TotalGPUEnergyUsage[energyMetric])
+	}
+
+	bills := make([]Bill, 0, len(billsMap))
+
+	for _, bill := range billsMap {
+		bill.CPUCost = roundCost(bill.CPUHours * c.Rates.CPUHour)
+		bill.GPUCost = roundCost(bill.GPUHours * c.Rates.GPUHour)
+		bill.IngressCost = roundCost(bill.IngressGB * c.Rates.IngressGB)
+		bill.EnergyCost = roundCost(bill.EnergyKWh * c.Rates.EnergyKWh)
+		bill.TotalCost = roundCost(bill.CPUCost + bill.GPUCost + bill.IngressCost + bill.EnergyCost)
+		bills = append(bills, *bill)
+	}
+
+	// Sort by cluster, project and month
+	slices.SortFunc(bills, func(a, b Bill) int {
+		return cmp.Or(cmp.Compare(a.ClusterID, b.ClusterID), cmp.Compare(a.Project, b.Project), cmp.Compare(a.Month, b.Month))
+	})
+
+	return bills
+}
+
+// billingQuerier estimates monthly bills of projects of users and writes them
+// in response. If users is empty, bills of all projects are returned.
+func (s *CEEMSServer) billingQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "billing endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	if !s.billing.enabled() {
+		errorResponse(w, r, &apiError{errorUnavailable, errBillingUnavailable}, s.logger)
+
+		return
+	}
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "last_updated_at", false, false)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	// Daily usage has usage of each user and project for each day
+	q := Query{}
+	q.query(
+		"SELECT cluster_id,project,last_updated_at,total_time_seconds,total_ingress_stats," +
+			"total_cpu_energy_usage_kwh,total_gpu_energy_usage_kwh FROM " + base.DailyUsageDBTableName,
+	)
+	q.query(" WHERE ")
+	q.subQuery(timeQuery)
+
+	// Limit to projects of users
+	if len(users) > 0 {
+		q.query(" AND project IN ")
+		q.subQuery(projectsSubQuery(users))
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	usage, err := s.queriers.usage(r.Context(), s.db, q, s.logger)
+	if usage == nil && err != nil {
+		s.logger.Error("Failed to fetch usage for billing", "users", strings.Join(users, ","), "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	bills := s.billing.bills(usage)
+
+	// Write CSV response when requested
+	if csvRequested(r) {
+		if err := writeCSV(w, bills, billingFields, "billing.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	billingResponse := Response[Bill]{
+		Status: "success",
+		Data:   bills,
+	}
+
+	if err != nil {
+		billingResponse.Warnings = append(billingResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&billingResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// billingReport         godoc
+//
+//	@Summary		Billing report of projects of current user
+//	@Description	This endpoint returns the monthly costs of the projects that the current user
+//	@Description	is part of. Costs are estimated from usage statistics using the rates
+//	@Description	configured for the server. The current user is always identified by the
+//	@Description	header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Costs of CPU and GPU hours, ingress traffic in GB and energy in kWh are
+//	@Description	reported for each project and month along with the total cost. Reports can be
+//	@Description	limited to certain clusters and projects using `cluster_id` and `project`
+//	@Description	query parameters. The period of the report is set by `from` and `to` query
+//	@Description	parameters similar to `/usage` endpoint.
+//	@Description
+//	@Description	The report can be exported as CSV using `format=csv` query parameter.
+//	@Security		BasicAuth
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
+//	@Success		200				{object}	Response[Bill]
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/reports/billing [get]
+//
+// GET /reports/billing
+// Get billing report of projects of current user.
+func (s *CEEMSServer) billingReport(w http.ResponseWriter, r *http.Request) {
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	s.billingQuerier([]string{dashboardUser}, w, r)
+}
+
+// billingReportAdmin         godoc
+//
+//	@Summary		Admin endpoint for billing report
+//	@Description	This admin endpoint returns the monthly costs of all projects estimated from
+//	@Description	usage statistics using the rates configured for the server. The current user
+//	@Description	is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Reports can be limited to certain clusters and
+//	@Description	projects using `cluster_id` and `project` query parameters.
+//	@Security		BasicAuth
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
+//	@Success		200				{object}	Response[Bill]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/reports/billing/admin [get]
+//
+// GET /reports/billing/admin
+// Get billing report of all projects.
+func (s *CEEMSServer) billingReportAdmin(w http.ResponseWriter, r *http.Request) {
+	s.billingQuerier(nil, w, r)
+}
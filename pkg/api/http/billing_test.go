@@ -0,0 +1,147 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingConfigBills(t *testing.T) {
+	c := BillingConfig{
+		Currency: "EUR",
+		Rates:    BillingRates{CPUHour: 0.01, GPUHour: 1, IngressGB: 0.1, EnergyKWh: 0.2},
+	}
+
+	usage := []models.Usage{
+		{
+			ClusterID:           "rm-0",
+			Project:             "prj1",
+			LastUpdatedAt:       "2024-01-10T00:00:00",
+			TotalTime:           models.MetricMap{"alloc_cputime": 36000, "alloc_gputime": 7200},
+			TotalIngressStats:   models.MetricMap{"bytes": 2e9},
+			TotalCPUEnergyUsage: models.MetricMap{"total": 10},
+			TotalGPUEnergyUsage: models.MetricMap{"total": 5},
+		},
+		{
+			ClusterID:     "rm-0",
+			Project:       "prj1",
+			LastUpdatedAt: "2024-01-11T00:00:00",
+			TotalTime:     models.MetricMap{"alloc_cputime": 36000},
+		},
+		{
+			ClusterID:     "rm-0",
+			Project:       "prj1",
+			LastUpdatedAt: "2024-02-01T00:00:00",
+			TotalTime:     models.MetricMap{"alloc_cputime": 3600},
+		},
+	}
+
+	bills := c.bills(usage)
+	require.Len(t, bills, 2)
+
+	// Usage of January must be aggregated into a single bill
+	assert.Equal(t, "2024-01", bills[0].Month)
+	assert.InDelta(t, 20, bills[0].CPUHours, 1e-9)
+	assert.InDelta(t, 2, bills[0].GPUHours, 1e-9)
+	assert.InDelta(t, 2, bills[0].IngressGB, 1e-9)
+	assert.InDelta(t, 15, bills[0].EnergyKWh, 1e-9)
+	assert.InDelta(t, 0.2, bills[0].CPUCost, 1e-9)
+	assert.InDelta(t, 2, bills[0].GPUCost, 1e-9)
+	assert.InDelta(t, 0.2, bills[0].IngressCost, 1e-9)
+	assert.InDelta(t, 3, bills[0].EnergyCost, 1e-9)
+	assert.InDelta(t, 5.4, bills[0].TotalCost, 1e-9)
+	assert.Equal(t, "EUR", bills[0].Currency)
+
+	assert.Equal(t, "2024-02", bills[1].Month)
+	assert.InDelta(t, 0.01, bills[1].TotalCost, 1e-9)
+}
+
+func TestBillingReportHandlers(t *testing.T) {
+	dir := t.TempDir()
+
+	server := setupServer(dir)
+	defer server.Shutdown(context.Background())
+
+	// Billing must be unavailable without rates
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/billing", nil)
+	req.Header.Set(dashboardUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	server.billingReport(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	db, err := setupMockDB(dir)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`
+CREATE TABLE daily_usage (
+	"id" integer not null primary key,
+	"cluster_id" text,
+	"project" text,
+	"username" text,
+	"last_updated_at" text,
+	"total_time_seconds" text,
+	"total_ingress_stats" text,
+	"total_cpu_energy_usage_kwh" text,
+	"total_gpu_energy_usage_kwh" text
+);
+INSERT INTO daily_usage VALUES(1, 'rm-0', 'prj1', 'usr1', '2024-01-10T00:00:00', '{"alloc_cputime":36000}', '{}', '{}', '{}');
+INSERT INTO daily_usage VALUES(2, 'rm-0', 'prj1', 'usr2', '2024-01-11T00:00:00', '{"alloc_cputime":36000}', '{}', '{}', '{}');
+INSERT INTO daily_usage VALUES(3, 'rm-0', 'prj3', 'usr3', '2024-01-11T00:00:00', '{"alloc_cputime":3600}', '{}', '{}', '{}');`)
+	require.NoError(t, err)
+
+	server.db = db
+	server.queriers.usage = Querier[models.Usage]
+	server.billing = BillingConfig{Rates: BillingRates{CPUHour: 0.5}}
+
+	query := func(handler http.HandlerFunc, url string) (int, Response[Bill]) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set(dashboardUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var response Response[Bill]
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w.Code, response
+	}
+
+	// Users must get bills of their projects including usage of other members
+	code, response := query(server.billingReport, "/api/v1/reports/billing?from=1704672000&to=1705276800")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "prj1", response.Data[0].Project)
+	assert.InDelta(t, 10, response.Data[0].TotalCost, 1e-9)
+
+	// Admins get bills of all projects
+	_, response = query(server.billingReportAdmin, "/api/v1/reports/billing/admin?from=1704672000&to=1705276800")
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, "prj3", response.Data[1].Project)
+
+	// Export as CSV
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/reports/billing/admin?from=1704672000&to=1705276800&format=csv", nil)
+	req.Header.Set(dashboardUserHeader, "adm1")
+
+	w = httptest.NewRecorder()
+	server.billingReportAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, billingFields, records[0])
+	assert.Equal(t, []string{"rm-0", "prj3", "2024-01", "", "1", "0", "0", "0", "0.5", "0", "0", "0", "0.5"}, records[2])
+}
@@ -0,0 +1,128 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// validator identifies the state of rows that match a query. It changes when any of
+// the matching rows are updated, added or removed.
+type validator struct {
+	numRows     int64
+	lastUpdated sql.NullString
+}
+
+// etag returns a weak entity tag of the response to request r. Weak tags are used as
+// the encoding of response can be changed by compression middleware.
+func (v validator) etag(r *http.Request) string {
+	h := sha256.New()
+	for _, part := range []string{
+		r.URL.Path, r.URL.RawQuery, r.Header.Get(loggedUserHeader), r.Header.Get(dashboardUserHeader),
+		r.Header.Get(adminUserHeader), r.Header.Get("Accept"),
+		strconv.FormatInt(v.numRows, 10), v.lastUpdated.String,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString(h.Sum(nil))[:32])
+}
+
+// lastModified returns the last update time of matching rows. A zero time is returned
+// when there are no matching rows.
+func (v validator) lastModified() time.Time {
+	if !v.lastUpdated.Valid {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(base.DatetimezoneLayout, v.lastUpdated.String)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// etagMatches returns true if any of the entity tags in If-None-Match header
+// weakly match etag.
+func etagMatches(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified evaluates the conditional headers of request against the rows of table
+// matching filter. ETag and Last-Modified headers are set on response and true is
+// returned when a 304 response has been written.
+//
+// Historical query windows do not change between refreshes of dashboards and serving
+// them as 304 avoids querying and serializing the same rows repeatedly. Any error in
+// evaluating the validator is ignored and the request is served as usual.
+func (s *CEEMSServer) notModified(w http.ResponseWriter, r *http.Request, table string, filter Query) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+
+	q := Query{}
+	q.query("SELECT COUNT(*),MAX(last_updated_at) FROM " + table)
+	q.append(filter)
+
+	queryString, queryParams := q.get()
+
+	params := make([]any, len(queryParams))
+	for i, p := range queryParams {
+		params[i] = p
+	}
+
+	// Validator is read from snapshot of request so that it matches with the response
+	conn := snapshotConn(r.Context(), s.db)
+
+	var v validator
+	if err := conn.QueryRowContext(r.Context(), queryString, params...).Scan(&v.numRows, &v.lastUpdated); err != nil { //nolint:gosec
+		s.logger.Debug("Failed to evaluate validator of conditional request", "table", table, "err", err)
+
+		return false
+	}
+
+	etag := v.etag(r)
+	w.Header().Set("ETag", etag)
+
+	lastModified := v.lastModified()
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since must be ignored when If-None-Match is present
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if !etagMatches(inm, etag) {
+			return false
+		}
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil || lastModified.Truncate(time.Second).After(t) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
@@ -0,0 +1,106 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`W/"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`W/"def", W/"abc"`, `W/"abc"`))
+	assert.True(t, etagMatches(`*`, `W/"abc"`))
+	assert.False(t, etagMatches(`W/"def"`, `W/"abc"`))
+	assert.False(t, etagMatches(``, `W/"abc"`))
+}
+
+func TestUnitsHandlerConditionalRequests(t *testing.T) {
+	dir := t.TempDir()
+
+	server := setupServer(dir)
+	defer server.Shutdown(context.Background())
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "conditional.db"))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec(`
+CREATE TABLE units (
+	"id" integer not null primary key,
+	"cluster_id" text,
+	"uuid" text,
+	"project" text,
+	"username" text,
+	"groupname" text,
+	"ignore" int,
+	"last_updated_at" text
+);
+INSERT INTO units VALUES(1, 'rm-0', '1479763', 'prj1', 'usr1', 'grp1', 0, '2024-01-10T10:00:00+0000');
+INSERT INTO units VALUES(2, 'rm-0', '1481508', 'prj1', 'usr1', 'grp1', 0, '2024-01-10T12:00:00+0000');`)
+	require.NoError(t, err)
+
+	server.db = db
+
+	query := func(header string, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units?uuid=1479763&uuid=1481508", nil)
+		req.Header.Set(dashboardUserHeader, "usr1")
+
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+
+		w := httptest.NewRecorder()
+		server.units(w, req)
+
+		return w
+	}
+
+	// Unconditional request must return validators
+	w := query("", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "Wed, 10 Jan 2024 12:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	// Matching validators must return 304 without body
+	w = query("If-None-Match", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	w = query("If-Modified-Since", "Wed, 10 Jan 2024 12:00:00 GMT")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = query("If-Modified-Since", "Wed, 10 Jan 2024 11:00:00 GMT")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Updating a matching unit must change entity tag
+	_, err = db.Exec(`UPDATE units SET last_updated_at = '2024-01-10T12:15:00+0000' WHERE uuid = '1479763'`)
+	require.NoError(t, err)
+
+	w = query("If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.NotEmpty(t, w.Body.String())
+
+	// Removing a matching unit must change entity tag
+	etag = w.Header().Get("ETag")
+
+	_, err = db.Exec(`DELETE FROM units WHERE uuid = '1481508'`)
+	require.NoError(t, err)
+
+	w = query("If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,130 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Defaults of CORS policy.
+var (
+	defaultCORSAllowedHeaders = []string{
+		"Accept", "Authorization", "Content-Type", "If-Modified-Since", "If-None-Match", apiKeyHeader,
+	}
+	corsAllowedMethods = []string{
+		http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions,
+	}
+	corsExposedHeaders = []string{
+		"Content-Disposition", "Deprecation", "ETag", "Expires", "Last-Modified", "Link", "Retry-After", "Sunset",
+	}
+)
+
+// CORSConfig contains the CORS policy of the server.
+type CORSConfig struct {
+	AllowedOrigins   []string       `yaml:"allowed_origins"`
+	AllowedHeaders   []string       `yaml:"allowed_headers"`
+	AllowCredentials bool           `yaml:"allow_credentials"`
+	MaxAge           model.Duration `yaml:"max_age"`
+}
+
+// Validate validates the config.
+func (c *CORSConfig) Validate() error {
+	// Browsers do not accept credentials with wildcard origin and reflecting
+	// any origin with credentials allows any site to make authenticated requests
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		return fmt.Errorf("invalid CORS config: %w", errCORSCredentials)
+	}
+
+	for _, origin := range c.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("invalid CORS config: %w", errInvalidCORSOrigin)
+		}
+	}
+
+	return nil
+}
+
+// allowed returns true if origin is allowed.
+func (c *CORSConfig) allowed(origin string) bool {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// corsHandler wraps next with the CORS policy in config so that browser based
+// applications served from allowed origins can make requests to the server. Preflight
+// requests are answered before they reach the router as browsers do not send any
+// credentials with them and they would be rejected by authentication middleware.
+// When no origins are allowed, next is returned as it is.
+func corsHandler(next http.Handler, c CORSConfig) http.Handler {
+	if len(c.AllowedOrigins) == 0 {
+		return next
+	}
+
+	allowedHeaders := c.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = defaultCORSAllowedHeaders
+	}
+
+	wildcard := slices.Contains(c.AllowedOrigins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		// Responses depend on origin and caches must not share them across origins
+		w.Header().Add("Vary", "Origin")
+
+		// Requests from origins that are not allowed are served without CORS
+		// headers and browsers will block their responses
+		if !c.allowed(origin) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Preflight request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsAllowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+
+			if c.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.FormatInt(int64(time.Duration(c.MaxAge).Seconds()), 10))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+
+		next.ServeHTTP(w, r)
+	})
+}
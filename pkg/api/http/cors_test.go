@@ -0,0 +1,130 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CORSConfig
+		err    error
+	}{
+		{
+			name:   "valid origins",
+			config: CORSConfig{AllowedOrigins: []string{"https://app.example.com", "http://localhost:3000"}, AllowCredentials: true},
+		},
+		{
+			name:   "wildcard origin",
+			config: CORSConfig{AllowedOrigins: []string{"*"}},
+		},
+		{
+			name:   "wildcard origin with credentials",
+			config: CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			err:    errCORSCredentials,
+		},
+		{
+			name:   "origin without scheme",
+			config: CORSConfig{AllowedOrigins: []string{"app.example.com"}},
+			err:    errInvalidCORSOrigin,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if test.err == nil {
+			require.NoError(t, err, test.name)
+		} else {
+			require.ErrorIs(t, err, test.err, test.name)
+		}
+	}
+}
+
+func TestCORSHandler(t *testing.T) {
+	var reached bool
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+
+		// Mimic authentication middleware that rejects requests without credentials
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := corsHandler(next, CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com/"},
+		AllowCredentials: true,
+		MaxAge:           model.Duration(10 * time.Minute),
+	})
+
+	// Preflight request from allowed origin must not reach next handler
+	reached = false
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/units", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.False(t, reached)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Api-Key")
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "Origin", w.Header().Get("Vary"))
+
+	// Actual request from allowed origin
+	reached = false
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Authorization", "Bearer token")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.True(t, reached)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "ETag")
+
+	// Preflight request from origin that is not allowed must not get CORS headers
+	reached = false
+	req = httptest.NewRequest(http.MethodOptions, "/api/v1/units", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.True(t, reached)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Wildcard origin must not echo origin
+	handler = corsHandler(next, CORSConfig{AllowedOrigins: []string{"*"}})
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
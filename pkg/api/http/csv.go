@@ -0,0 +1,102 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	csvContentType = "text/csv"
+	csvFlushRows   = 1000 // Number of rows after which CSV response is flushed to client
+)
+
+// csvRequested returns true if client requested CSV response either using
+// `format=csv` query parameter or `Accept: text/csv` header.
+func csvRequested(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "csv") {
+		return true
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == csvContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeCSV writes data as CSV into response writer. The header row is made of
+// fields and each row contains the values of fields in the same order. Fields
+// that are JSON objects like allocation or metric maps are encoded as JSON
+// strings. Rows are flushed to the client as they are encoded.
+func writeCSV[T any](w http.ResponseWriter, data []T, fields []string, filename string) error {
+	w.Header().Set("Content-Type", csvContentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(fields); err != nil {
+		return err
+	}
+
+	row := make([]string, len(fields))
+	rc := http.NewResponseController(w)
+
+	for irow, d := range data {
+		// Use JSON encoding of model to get values so that CSV
+		// columns are consistent with JSON responses
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(b, &values); err != nil {
+			return err
+		}
+
+		for i, field := range fields {
+			row[i] = csvValue(values[field])
+		}
+
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+
+		// Flush periodically to stream response to client
+		if (irow+1)%csvFlushRows == 0 {
+			writer.Flush()
+			rc.Flush() //nolint:errcheck
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// csvValue returns the CSV cell value of a raw JSON value. Strings are unquoted,
+// nulls are returned as empty strings and rest of the values are returned as
+// they are.
+func csvValue(v json.RawMessage) string {
+	if len(v) == 0 || bytes.Equal(v, []byte("null")) {
+		return ""
+	}
+
+	var s string
+	if v[0] == '"' && json.Unmarshal(v, &s) == nil {
+		return s
+	}
+
+	return string(v)
+}
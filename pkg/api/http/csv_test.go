@@ -0,0 +1,65 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVRequested(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		accept   string
+		expected bool
+	}{
+		{name: "json by default", url: "/units", expected: false},
+		{name: "format query param", url: "/units?format=csv", expected: true},
+		{name: "accept header", url: "/units", accept: "application/json, text/csv;q=0.9", expected: true},
+		{name: "json accept header", url: "/units", accept: "application/json", expected: false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		assert.Equal(t, test.expected, csvRequested(req), test.name)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	units := []models.Unit{
+		{
+			UUID:      "1000",
+			ClusterID: "slurm-0",
+			Name:      "job, with comma",
+			TotalTime: models.MetricMap{"walltime": 10},
+		},
+		{
+			UUID:      "1001",
+			ClusterID: "slurm-0",
+		},
+	}
+
+	w := httptest.NewRecorder()
+	err := writeCSV(w, units, []string{"uuid", "cluster_id", "name", "total_time_seconds"}, "units.csv")
+	require.NoError(t, err)
+
+	expected := `uuid,cluster_id,name,total_time_seconds
+1000,slurm-0,"job, with comma","{""walltime"":10}"
+1001,slurm-0,,
+`
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="units.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, expected, w.Body.String())
+}
@@ -0,0 +1,74 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/prometheus/common/model"
+)
+
+// DB connection modes.
+const (
+	dbReadOnly  = "ro"
+	dbReadWrite = "rw"
+)
+
+// DBPoolConfig contains the configuration of pool of DB connections used
+// by the server.
+type DBPoolConfig struct {
+	MaxOpenConns    int            `yaml:"max_open_connections"`
+	MaxIdleConns    int            `yaml:"max_idle_connections"`
+	ConnMaxLifetime model.Duration `yaml:"max_lifetime"`
+	ConnMaxIdleTime model.Duration `yaml:"max_idle_time"`
+}
+
+// Validate validates the config.
+func (c *DBPoolConfig) Validate() error {
+	if c.MaxOpenConns < 0 || c.MaxIdleConns < 0 {
+		return fmt.Errorf("invalid db_pool config: %w", errNegativeDBPoolConns)
+	}
+
+	return nil
+}
+
+// openDB opens a pool of connections to CEEMS DB in data path in the given mode
+// using the configured SQLite pragmas. Each call returns a new independent pool
+// and hence, several servers can be run in the same process.
+func openDB(data db.DataConfig, mode string, pool DBPoolConfig) (*sql.DB, error) {
+	opts := data.SQLite.Options(mode == dbReadOnly)
+	opts["_mutex"] = "no"
+	opts["mode"] = mode
+	dsn := db.MakeDSN(filepath.Join(data.Path, base.CEEMSDBName), opts)
+
+	db, err := sql.Open(sqlite3.DriverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero values keep the defaults of database/sql package
+	if pool.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
+	}
+
+	if pool.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+
+	if pool.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(time.Duration(pool.ConnMaxLifetime))
+	}
+
+	if pool.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(time.Duration(pool.ConnMaxIdleTime))
+	}
+
+	return db, nil
+}
@@ -0,0 +1,80 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenDB(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+	f.Close()
+
+	pool := DBPoolConfig{
+		MaxOpenConns:    4,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: model.Duration(time.Minute),
+	}
+
+	data := db.DataConfig{
+		Path:   tmpDir,
+		SQLite: db.SQLiteConfig{CacheSize: -4000, MmapSize: 1 << 20},
+	}
+
+	dbRO, err := openDB(data, dbReadOnly, pool)
+	require.NoError(t, err)
+
+	defer dbRO.Close()
+
+	require.NoError(t, dbRO.Ping())
+	assert.Equal(t, 4, dbRO.Stats().MaxOpenConnections)
+
+	// Pragmas must be set on read-only connections
+	var cacheSize, mmapSize int64
+
+	require.NoError(t, dbRO.QueryRow("PRAGMA cache_size").Scan(&cacheSize))
+	require.NoError(t, dbRO.QueryRow("PRAGMA mmap_size").Scan(&mmapSize))
+	assert.Equal(t, int64(-4000), cacheSize)
+	assert.Equal(t, int64(1<<20), mmapSize)
+
+	// Writes must fail on read-only connections
+	_, err = dbRO.Exec("CREATE TABLE foo (id INTEGER)")
+	require.Error(t, err)
+
+	// Pools are independent of each other
+	dbRW, err := openDB(db.DataConfig{Path: tmpDir}, dbReadWrite, DBPoolConfig{})
+	require.NoError(t, err)
+
+	defer dbRW.Close()
+
+	_, err = dbRW.Exec("CREATE TABLE foo (id INTEGER)")
+	require.NoError(t, err)
+	assert.Equal(t, 0, dbRW.Stats().MaxOpenConnections)
+
+	// Journal mode defaults to WAL on read-write connections
+	var journalMode string
+
+	require.NoError(t, dbRW.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	assert.Equal(t, "wal", journalMode)
+}
+
+func TestDBPoolConfigValidate(t *testing.T) {
+	c := DBPoolConfig{MaxOpenConns: -1}
+	require.ErrorIs(t, c.Validate(), errNegativeDBPoolConns)
+
+	c = DBPoolConfig{MaxOpenConns: 10, MaxIdleConns: 5}
+	require.NoError(t, c.Validate())
+}
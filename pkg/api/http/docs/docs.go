@@ -1358,6 +1358,14 @@ const docTemplate = `{
                     "description": "Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.",
                     "type": "string"
                 },
+                "completeness": {
+                    "description": "Fraction of aggregate metrics of unit resolved from TSDB. This map contains a fraction for each metric and an overall ` + "`" + `score` + "`" + `",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MetricMap"
+                        }
+                    ]
+                },
                 "created_at": {
                     "description": "Creation time",
                     "type": "string"
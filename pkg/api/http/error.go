@@ -14,9 +14,12 @@ import (
 var (
 	ErrMaxQueryWindow     = errors.New("maximum query window exceeded")
 	ErrMalformedTimeStamp = errors.New("malformed timestamp")
+	ErrInvalidTimeRange   = errors.New("from must not be after to")
 )
 
-// Error type in API response.
+// Error type in API response. Error types are stable and they are used as
+// codes in problem details responses so that clients can handle errors
+// programmatically.
 type errorType string
 
 // Error response.
@@ -31,72 +34,192 @@ func (e *apiError) Error() string {
 
 // List of predefined errors.
 const (
-	errorNone          errorType = ""
-	errorUnauthorized  errorType = "unauthorized"
-	errorForbidden     errorType = "forbidden"
-	errorTimeout       errorType = "timeout"
-	errorCanceled      errorType = "canceled"
-	errorExec          errorType = "execution"
-	errorBadData       errorType = "bad_data"
-	errorInternal      errorType = "internal"
-	errorUnavailable   errorType = "unavailable"
-	errorNotFound      errorType = "not_found"
-	errorNotAcceptable errorType = "not_acceptable"
+	errorUnauthorized    errorType = "unauthorized"
+	errorForbidden       errorType = "forbidden"
+	errorTimeout         errorType = "timeout"
+	errorCanceled        errorType = "canceled"
+	errorExec            errorType = "execution"
+	errorBadRequest      errorType = "bad_request"
+	errorBadTimeRange    errorType = "bad_time_range"
+	errorWindowExceeded  errorType = "window_exceeded"
+	errorInternal        errorType = "internal"
+	errorDB              errorType = "db_error"
+	errorUnavailable     errorType = "unavailable"
+	errorNotFound        errorType = "not_found"
+	errorConflict        errorType = "conflict"
+	errorNotAcceptable   errorType = "not_acceptable"
+	errorTooManyRequests errorType = "too_many_requests"
 )
 
-// Custom error codes.
-const (
-	// Non-standard status code (originally introduced by nginx) for the case when a client closes
-	// the connection while the server is still processing the request.
-	statusClientClosedConnection = 499
-)
+// problemContentType is the media type of problem details responses.
+const problemContentType = "application/problem+json"
 
-// Custom errors.
-var (
-	errNoUser            = errors.New("no user identified")
-	errNoPrivs           = errors.New("current user does not have admin privileges")
-	errInvalidRequest    = errors.New("invalid request")
-	errInvalidQueryField = errors.New("invalid query fields")
-	errMissingUUIDs      = errors.New("uuids missing in the request")
-	errNoAuth            = errors.New("user do not have permissions on uuids")
-)
+// problemTypeBaseURL is the base URL of problem types. Error type is appended to
+// it to make the URI that identifies the problem type.
+const problemTypeBaseURL = "https://mahendrapaipuri.github.io/ceems/docs/usage/ceems-api-server#"
 
-// Return error response for by setting errorString and errorType in response.
-func errorResponse[T any](w http.ResponseWriter, apiErr *apiError, logger *slog.Logger, data []T) {
-	var code int
+// Problem is the RFC 7807 problem details error response of CEEMS API server.
+type Problem struct {
+	Type     string    `json:"type"`
+	Title    string    `json:"title"`
+	Status   int       `json:"status"`
+	Detail   string    `json:"detail,omitempty"`
+	Instance string    `json:"instance,omitempty"`
+	Code     errorType `json:"code"`
+}
 
-	switch apiErr.typ { //nolint:exhaustive
-	case errorBadData:
-		code = http.StatusBadRequest
+// status returns the HTTP status code of error type.
+func (t errorType) status() int {
+	switch t {
+	case errorBadRequest, errorBadTimeRange, errorWindowExceeded:
+		return http.StatusBadRequest
 	case errorUnauthorized:
-		code = http.StatusUnauthorized
+		return http.StatusUnauthorized
 	case errorForbidden:
-		code = http.StatusForbidden
+		return http.StatusForbidden
 	case errorExec:
-		code = http.StatusUnprocessableEntity
+		return http.StatusUnprocessableEntity
 	case errorCanceled:
-		code = statusClientClosedConnection
-	case errorTimeout:
-		code = http.StatusServiceUnavailable
-	case errorInternal:
-		code = http.StatusInternalServerError
+		return statusClientClosedConnection
+	case errorTimeout, errorUnavailable:
+		return http.StatusServiceUnavailable
 	case errorNotFound:
-		code = http.StatusNotFound
+		return http.StatusNotFound
+	case errorConflict:
+		return http.StatusConflict
 	case errorNotAcceptable:
-		code = http.StatusNotAcceptable
+		return http.StatusNotAcceptable
+	case errorTooManyRequests:
+		return http.StatusTooManyRequests
 	default:
-		code = http.StatusInternalServerError
+		return http.StatusInternalServerError
 	}
+}
+
+// title returns a short human readable summary of error type.
+func (t errorType) title() string {
+	switch t {
+	case errorBadTimeRange:
+		return "Bad Time Range"
+	case errorWindowExceeded:
+		return "Maximum Query Window Exceeded"
+	case errorDB:
+		return "Database Error"
+	case errorCanceled:
+		return "Request Canceled"
+	default:
+		return http.StatusText(t.status())
+	}
+}
 
-	w.WriteHeader(code)
+// newProblem returns problem details of API error.
+func newProblem(apiErr *apiError, instance string) Problem {
+	// Use internal error type when type is unknown so that
+	// code is always one of predefined types
+	typ := apiErr.typ
+	if typ.status() == http.StatusInternalServerError && typ != errorDB {
+		typ = errorInternal
+	}
 
-	response := Response[T]{
-		Status:    "error",
-		ErrorType: apiErr.typ,
-		Error:     apiErr.err.Error(),
-		Data:      data,
+	problem := Problem{
+		Type:     problemTypeBaseURL + string(typ),
+		Title:    typ.title(),
+		Status:   typ.status(),
+		Instance: instance,
+		Code:     typ,
 	}
-	if err := json.NewEncoder(w).Encode(&response); err != nil {
+
+	if apiErr.err != nil {
+		problem.Detail = apiErr.err.Error()
+	}
+
+	return problem
+}
+
+// queryWindowError returns API error of an error returned while parsing
+// query window.
+func queryWindowError(err error) *apiError {
+	switch {
+	case errors.Is(err, ErrMaxQueryWindow):
+		return &apiError{errorWindowExceeded, err}
+	case errors.Is(err, ErrMalformedTimeStamp), errors.Is(err, ErrInvalidTimeRange):
+		return &apiError{errorBadTimeRange, err}
+	}
+
+	return &apiError{errorBadRequest, err}
+}
+
+// Custom error codes.
+const (
+	// Non-standard status code (originally introduced by nginx) for the case when a client closes
+	// the connection while the server is still processing the request.
+	statusClientClosedConnection = 499
+)
+
+// Custom errors.
+var (
+	errNoUser                 = errors.New("no user identified")
+	errNoPrivs                = errors.New("current user does not have admin privileges")
+	errInvalidRequest         = errors.New("invalid request")
+	errNegativeDBPoolConns    = errors.New("number of connections must not be negative")
+	errInvalidQueryField      = errors.New("invalid query fields")
+	errInvalidSortField       = errors.New("invalid sort_by field")
+	errInvalidSortOrder       = errors.New("invalid order. Valid values are asc and desc")
+	errInvalidLimit           = errors.New("invalid limit. Limit must be a positive integer")
+	errInvalidAggregation     = errors.New("invalid aggregate. Valid values are user and group")
+	errInvalidRollup          = errors.New("invalid rollup. Valid values are daily and monthly")
+	errMissingUUIDs           = errors.New("uuids missing in the request")
+	errNoAuth                 = errors.New("user do not have permissions on uuids")
+	errMissingClusterID       = errors.New("cluster_id missing in the request")
+	errInvalidAnnotation      = errors.New("annotation must be a JSON object with non empty note of at most 4096 characters")
+	errUnitNotFound           = errors.New("unit not found")
+	errUnitNotRunning         = errors.New("unit is not running")
+	errLiveUnavailable        = errors.New("live metrics are not available")
+	errRunningUnavailable     = errors.New("running units are not available")
+	errNodeSeriesUnavailable  = errors.New("node time series are not available")
+	errNoBackupTargets        = errors.New("no backup targets are configured")
+	errInvalidAPIKey          = errors.New("invalid or expired API key")
+	errInvalidAPIKeyRequest   = errors.New("API key request must be a JSON object with non empty name and username and role either user or admin")
+	errAPIKeyNotFound         = errors.New("API key not found")
+	errInvalidRole            = errors.New("role must be a JSON object with role either admin or user")
+	errRoleNotFound           = errors.New("role not found")
+	errMaintenanceRunning     = errors.New("another DB maintenance task is running")
+	errMaintenanceUnavailable = errors.New("DB maintenance is not available")
+	errNoBackupPath           = errors.New("backup path is not configured")
+	errSyntheticUnavailable   = errors.New("synthetic units are not enabled")
+	errInvalidSyntheticPeriod = errors.New("invalid period. to must be after from and period must not exceed 366 days")
+	errInvalidSyntheticSeed   = errors.New("invalid seed. Seed must be an integer")
+	errInvalidSyntheticUnits  = errors.New("invalid units_per_day. It must be a positive integer not exceeding 1000")
+	errRealSyntheticCluster   = errors.New("invalid cluster_id. Synthetic units cannot be injected into real clusters")
+	errBillingUnavailable     = errors.New("billing rates are not configured")
+	errNegativeBillingRates   = errors.New("billing rates must not be negative")
+	errFairshareUnavailable   = errors.New("fairshare metric is not configured")
+	errInvalidFairshareMetric = errors.New("fairshare metric must be one of cpu_hours, gpu_hours and energy_kwh")
+	errInvalidFairshareParams = errors.New("fairshare window and max shares must be positive and half life must not be negative")
+	errInvalidFootprint       = errors.New("footprints must be a JSON array of objects with non empty source other than api, non empty user and non negative values")
+	errInvalidMinGroupSize    = errors.New("min_group_size must not be negative")
+	errInvalidQuota           = errors.New("quota must be a JSON object with non empty cluster_id and project, non negative allocations with at least one of them positive and end_ts after start_ts")
+	errQuotaNotFound          = errors.New("quota not found")
+	errCORSCredentials        = errors.New("allow_credentials cannot be used with wildcard origin")
+	errInvalidCORSOrigin      = errors.New("allowed origins must be '*' or start with http:// or https://")
+	errInvalidProvisioning    = errors.New("config must be a JSON object with a resource manager or updater and same id as in path")
+	errProvisionedNotFound    = errors.New("provisioned config not found")
+	errInvalidAnalyticsEngine = errors.New("invalid engine. Valid values are sqlite and duckdb")
+	errInvalidAnalyticsSource = errors.New("invalid source. Valid values are sqlite, parquet and mirror")
+	errInvalidRefreshInterval = errors.New("refresh_interval must be positive when source is mirror")
+	errNoParquetPath          = errors.New("parquet_path must be set when source is parquet")
+	errInvalidTopMetric       = errors.New("invalid metric. Valid values are cpu_hours, gpu_hours, energy_kwh and ingress_gb")
+	errInvalidTopDimension    = errors.New("invalid by. Valid values are user, project and group")
+)
+
+// errorResponse writes API error as problem details response.
+func errorResponse(w http.ResponseWriter, r *http.Request, apiErr *apiError, logger *slog.Logger) {
+	problem := newProblem(apiErr, r.URL.Path)
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	if err := json.NewEncoder(w).Encode(&problem); err != nil {
 		logger.Error("Failed to encode response", "err", err)
 		w.Write([]byte("KO"))
 	}
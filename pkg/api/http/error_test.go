@@ -5,12 +5,99 @@ package http
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestApiError(t *testing.T) {
-	e := apiError{typ: errorBadData, err: errors.New("bad data")}
-	assert.Equal(t, "bad_data: bad data", e.Error())
+	e := apiError{typ: errorBadRequest, err: errors.New("bad data")}
+	assert.Equal(t, "bad_request: bad data", e.Error())
+}
+
+func TestNewProblem(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiErr   *apiError
+		expected Problem
+	}{
+		{
+			name:   "bad request",
+			apiErr: &apiError{errorBadRequest, errInvalidRequest},
+			expected: Problem{
+				Type:     problemTypeBaseURL + "bad_request",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   "invalid request",
+				Instance: "/api/v1/units",
+				Code:     errorBadRequest,
+			},
+		},
+		{
+			name:   "exceeded window",
+			apiErr: queryWindowError(fmt.Errorf("query: %w", ErrMaxQueryWindow)),
+			expected: Problem{
+				Type:     problemTypeBaseURL + "window_exceeded",
+				Title:    "Maximum Query Window Exceeded",
+				Status:   http.StatusBadRequest,
+				Detail:   "query: maximum query window exceeded",
+				Instance: "/api/v1/units",
+				Code:     errorWindowExceeded,
+			},
+		},
+		{
+			name:   "malformed timestamp",
+			apiErr: queryWindowError(fmt.Errorf("query parameter 'from': %w", ErrMalformedTimeStamp)),
+			expected: Problem{
+				Type:     problemTypeBaseURL + "bad_time_range",
+				Title:    "Bad Time Range",
+				Status:   http.StatusBadRequest,
+				Detail:   "query parameter 'from': malformed timestamp",
+				Instance: "/api/v1/units",
+				Code:     errorBadTimeRange,
+			},
+		},
+		{
+			name:   "invalid query parameter",
+			apiErr: queryWindowError(errInvalidTopMetric),
+			expected: Problem{
+				Type:     problemTypeBaseURL + "bad_request",
+				Title:    "Bad Request",
+				Status:   http.StatusBadRequest,
+				Detail:   errInvalidTopMetric.Error(),
+				Instance: "/api/v1/units",
+				Code:     errorBadRequest,
+			},
+		},
+		{
+			name:   "db error",
+			apiErr: &apiError{errorDB, errors.New("database is locked")},
+			expected: Problem{
+				Type:     problemTypeBaseURL + "db_error",
+				Title:    "Database Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "database is locked",
+				Instance: "/api/v1/units",
+				Code:     errorDB,
+			},
+		},
+		{
+			name:   "unknown type",
+			apiErr: &apiError{errorType("foo"), errors.New("failed")},
+			expected: Problem{
+				Type:     problemTypeBaseURL + "internal",
+				Title:    "Internal Server Error",
+				Status:   http.StatusInternalServerError,
+				Detail:   "failed",
+				Instance: "/api/v1/units",
+				Code:     errorInternal,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, newProblem(test.apiErr, "/api/v1/units"), test.name)
+	}
 }
@@ -0,0 +1,280 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
+)
+
+// Default fair-share parameters.
+const (
+	defaultFairshareWindow    = 7 * 24 * time.Hour
+	defaultFairshareMaxShares = 100
+)
+
+// Fields of fair-share report in the same order as CSV columns.
+var fairshareFields = []string{"cluster_id", "account", "usage", "normalized_usage", "shares"}
+
+// FairshareConfig contains the configuration of usage based fair-share feedback.
+// Fair-share is disabled when metric is empty.
+type FairshareConfig struct {
+	Metric    string         `yaml:"metric"`
+	Window    model.Duration `yaml:"window"`
+	HalfLife  model.Duration `yaml:"half_life"`
+	MaxShares int            `yaml:"max_shares"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *FairshareConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Set a default config
+	*c = FairshareConfig{
+		Window:    model.Duration(defaultFairshareWindow),
+		MaxShares: defaultFairshareMaxShares,
+	}
+
+	type plain FairshareConfig
+
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate validates the config.
+func (c *FairshareConfig) Validate() error {
+	if c.Metric == "" {
+		return nil
+	}
+
+	if !slices.Contains([]string{"cpu_hours", "gpu_hours", "energy_kwh"}, c.Metric) {
+		return fmt.Errorf("invalid fairshare config: %w", errInvalidFairshareMetric)
+	}
+
+	if c.Window <= 0 || c.HalfLife < 0 || c.MaxShares <= 0 {
+		return fmt.Errorf("invalid fairshare config: %w", errInvalidFairshareParams)
+	}
+
+	return nil
+}
+
+// enabled returns true when fair-share feedback is configured.
+func (c *FairshareConfig) enabled() bool {
+	return c.Metric != ""
+}
+
+// Fairshare is the recent usage of an account normalized by the usage of all
+// accounts in the cluster and the shares derived from it.
+type Fairshare struct {
+	ClusterID       string  `json:"cluster_id"`
+	Account         string  `json:"account"`
+	Usage           float64 `json:"usage"`
+	NormalizedUsage float64 `json:"normalized_usage"`
+	Shares          int     `json:"shares"`
+}
+
+// usageValue returns the value of configured metric in usage.
+func (c *FairshareConfig) usageValue(u models.Usage) float64 {
+	switch c.Metric {
+	case "cpu_hours":
+		return float64(u.TotalTime["alloc_cputime"]) / 3600
+	case "gpu_hours":
+		return float64(u.TotalTime["alloc_gputime"]) / 3600
+	default:
+		return float64(u.TotalCPUEnergyUsage[defaultBillingEnergyMetric] + u.TotalGPUEnergyUsage[defaultBillingEnergyMetric])
+	}
+}
+
+// fairshares aggregates daily usage into usage of each account. When half life
+// is configured, usage of each day is decayed by its age similar to the decay
+// of usage in Slurm's multifactor priority plugin. Accounts get shares in
+// proportion to the usage they did not consume, i.e., shares are max shares
+// times one minus normalized usage with a minimum of one share.
+func (c *FairshareConfig) fairshares(usage []models.Usage, now time.Time) []Fairshare {
+	accounts := make(map[string]*Fairshare)
+	totals := make(map[string]float64)
+
+	for _, u := range usage {
+		value := c.usageValue(u)
+
+		// Daily usage is keyed on midnight of each day in DB layout
+		if c.HalfLife > 0 && len(u.LastUpdatedAt) >= len(time.DateOnly) {
+			if day, err := time.ParseInLocation(time.DateOnly, u.LastUpdatedAt[:len(time.DateOnly)], now.Location()); err == nil {
+				age := max(now.Sub(day), 0)
+				value *= math.Pow(0.5, age.Hours()/time.Duration(c.HalfLife).Hours())
+			}
+		}
+
+		key := u.ClusterID + "|" + u.Project
+		if _, ok := accounts[key]; !ok {
+			accounts[key] = &Fairshare{ClusterID: u.ClusterID, Account: u.Project}
+		}
+
+		accounts[key].Usage += value
+		totals[u.ClusterID] += value
+	}
+
+	fairshares := make([]Fairshare, 0, len(accounts))
+
+	for _, f := range accounts {
+		if totals[f.ClusterID] > 0 {
+			f.NormalizedUsage = f.Usage / totals[f.ClusterID]
+		}
+
+		f.Shares = max(1, int(math.Round(float64(c.MaxShares)*(1-f.NormalizedUsage))))
+		fairshares = append(fairshares, *f)
+	}
+
+	// Sort by cluster and account
+	slices.SortFunc(fairshares, func(a, b Fairshare) int {
+		return cmp.Or(cmp.Compare(a.ClusterID, b.ClusterID), cmp.Compare(a.Account, b.Account))
+	})
+
+	return fairshares
+}
+
+// writeSacctmgr writes fair-share of accounts as sacctmgr commands that set the
+// shares of accounts. As cluster IDs of CEEMS need not be the same as names
+// of Slurm clusters, commands of each cluster are preceded by a comment with
+// its cluster ID.
+func writeSacctmgr(w http.ResponseWriter, fairshares []Fairshare) error {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	var b strings.Builder
+
+	var cluster string
+
+	for i, f := range fairshares {
+		if i == 0 || f.ClusterID != cluster {
+			cluster = f.ClusterID
+			fmt.Fprintf(&b, "# cluster_id: %s\n", cluster)
+		}
+
+		fmt.Fprintf(&b, "sacctmgr --immediate modify account where name=%s set fairshare=%d\n", f.Account, f.Shares)
+	}
+
+	_, err := w.Write([]byte(b.String()))
+
+	return err
+}
+
+// fairshareReportAdmin         godoc
+//
+//	@Summary		Admin endpoint for usage based fair-share
+//	@Description	This admin endpoint returns the recent usage of each account of Slurm clusters
+//	@Description	normalized by the usage of all accounts of the cluster and the fair-share
+//	@Description	shares derived from it. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. The metric, window and decay of usage are set in
+//	@Description	the fair-share config of the server. Shares of an account are the configured
+//	@Description	max shares times one minus its normalized usage with a minimum of one share.
+//	@Description
+//	@Description	The report can be exported as CSV using `format=csv` query parameter or as
+//	@Description	`sacctmgr` commands that set the shares of accounts using `format=sacctmgr`.
+//	@Security		BasicAuth
+//	@Tags			reports
+//	@Produce		json
+//	@Produce		text/csv
+//	@Produce		plain
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			format			query		string		false	"Response format"	Enums(json, csv, sacctmgr)
+//	@Success		200				{object}	Response[Fairshare]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/reports/fairshare/admin [get]
+//
+// GET /reports/fairshare/admin
+// Get usage based fair-share of accounts.
+func (s *CEEMSServer) fairshareReportAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "fairshare endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	if !s.fairshare.enabled() {
+		errorResponse(w, r, &apiError{errorUnavailable, errFairshareUnavailable}, s.logger)
+
+		return
+	}
+
+	now := time.Now().In(s.dbConfig.Data.Timezone.Location)
+	from := now.Add(-time.Duration(s.fairshare.Window)).Format(time.DateOnly)
+
+	// Fair-share is only relevant for Slurm accounts
+	q := Query{}
+	q.query(
+		"SELECT cluster_id,project,last_updated_at,total_time_seconds," +
+			"total_cpu_energy_usage_kwh,total_gpu_energy_usage_kwh FROM " + base.DailyUsageDBTableName,
+	)
+	q.query(" WHERE resource_manager = 'slurm' AND last_updated_at >= ")
+	q.param([]string{from})
+
+	if clusterIDs := r.URL.Query()["cluster_id"]; len(clusterIDs) > 0 {
+		q.query(" AND cluster_id IN ")
+		q.param(clusterIDs)
+	}
+
+	usage, err := s.queriers.usage(r.Context(), s.db, q, s.logger)
+	if usage == nil && err != nil {
+		s.logger.Error("Failed to fetch usage for fairshare", "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	fairshares := s.fairshare.fairshares(usage, now)
+
+	// Write sacctmgr commands or CSV response when requested
+	if strings.EqualFold(r.URL.Query().Get("format"), "sacctmgr") {
+		if err := writeSacctmgr(w, fairshares); err != nil {
+			s.logger.Error("Failed to write sacctmgr response", "err", err)
+		}
+
+		return
+	}
+
+	if csvRequested(r) {
+		if err := writeCSV(w, fairshares, fairshareFields, "fairshare.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	fairshareResponse := Response[Fairshare]{
+		Status: "success",
+		Data:   fairshares,
+	}
+
+	if err != nil {
+		fairshareResponse.Warnings = append(fairshareResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&fairshareResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
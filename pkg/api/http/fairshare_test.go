@@ -0,0 +1,139 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFairshareConfig(t *testing.T) {
+	var c FairshareConfig
+
+	// Defaults must be set
+	require.NoError(t, yaml.Unmarshal([]byte("metric: energy_kwh"), &c))
+	assert.Equal(t, model.Duration(defaultFairshareWindow), c.Window)
+	assert.Equal(t, defaultFairshareMaxShares, c.MaxShares)
+	require.NoError(t, c.Validate())
+
+	// Disabled config is always valid
+	require.NoError(t, (&FairshareConfig{}).Validate())
+
+	require.ErrorIs(t, (&FairshareConfig{Metric: "ingress_gb", Window: c.Window, MaxShares: 1}).Validate(), errInvalidFairshareMetric)
+	require.ErrorIs(t, (&FairshareConfig{Metric: "cpu_hours", MaxShares: 1}).Validate(), errInvalidFairshareParams)
+}
+
+func TestFairshares(t *testing.T) {
+	now := time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+
+	usage := []models.Usage{
+		{ClusterID: "rm-0", Project: "prj1", LastUpdatedAt: "2024-01-11T00:00:00", TotalTime: models.MetricMap{"alloc_cputime": 3 * 3600}},
+		{ClusterID: "rm-0", Project: "prj2", LastUpdatedAt: "2024-01-11T00:00:00", TotalTime: models.MetricMap{"alloc_cputime": 3600}},
+		{ClusterID: "rm-0", Project: "prj2", LastUpdatedAt: "2024-01-10T00:00:00", TotalTime: models.MetricMap{"alloc_cputime": 2 * 3600}},
+		{ClusterID: "rm-1", Project: "prj1", LastUpdatedAt: "2024-01-11T00:00:00"},
+	}
+
+	// Without decay
+	c := FairshareConfig{Metric: "cpu_hours", Window: model.Duration(defaultFairshareWindow), MaxShares: 100}
+	fairshares := c.fairshares(usage, now)
+	require.Len(t, fairshares, 3)
+
+	assert.Equal(t, "prj1", fairshares[0].Account)
+	assert.InDelta(t, 3, fairshares[0].Usage, 1e-9)
+	assert.InDelta(t, 0.5, fairshares[0].NormalizedUsage, 1e-9)
+	assert.Equal(t, 50, fairshares[0].Shares)
+
+	// Accounts without usage get all shares
+	assert.Equal(t, "rm-1", fairshares[2].ClusterID)
+	assert.Equal(t, 100, fairshares[2].Shares)
+
+	// Usage of previous day must be halved with a half life of one day
+	c.HalfLife = model.Duration(24 * time.Hour)
+	fairshares = c.fairshares(usage, now)
+
+	assert.InDelta(t, 2, fairshares[1].Usage, 1e-9)
+	assert.InDelta(t, 0.4, fairshares[1].NormalizedUsage, 1e-9)
+	assert.Equal(t, 60, fairshares[1].Shares)
+}
+
+func TestFairshareReportHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	server := setupServer(dir)
+	defer server.Shutdown(context.Background())
+
+	// Fair-share must be unavailable without metric
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/reports/fairshare/admin", nil)
+	req.Header.Set(dashboardUserHeader, "adm1")
+
+	w := httptest.NewRecorder()
+	server.fairshareReportAdmin(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	db, err := setupMockDB(dir)
+	require.NoError(t, err)
+
+	today := time.Now().Format(time.DateOnly)
+	old := time.Now().AddDate(0, 0, -30).Format(time.DateOnly)
+
+	_, err = db.Exec(fmt.Sprintf(`
+CREATE TABLE daily_usage (
+	"id" integer not null primary key,
+	"resource_manager" text,
+	"cluster_id" text,
+	"project" text,
+	"username" text,
+	"last_updated_at" text,
+	"total_time_seconds" text,
+	"total_cpu_energy_usage_kwh" text,
+	"total_gpu_energy_usage_kwh" text
+);
+INSERT INTO daily_usage VALUES(1, 'slurm', 'slurm-0', 'prj1', 'usr1', '%[1]sT00:00:00', '{}', '{"total":3}', '{"total":1}');
+INSERT INTO daily_usage VALUES(2, 'slurm', 'slurm-0', 'prj2', 'usr2', '%[1]sT00:00:00', '{}', '{"total":4}', '{}');
+INSERT INTO daily_usage VALUES(3, 'slurm', 'slurm-0', 'prj2', 'usr2', '%[2]sT00:00:00', '{}', '{"total":100}', '{}');
+INSERT INTO daily_usage VALUES(4, 'openstack', 'os-0', 'prj3', 'usr3', '%[1]sT00:00:00', '{}', '{"total":10}', '{}');`, today, old))
+	require.NoError(t, err)
+
+	server.db = db
+	server.queriers.usage = Querier[models.Usage]
+	server.fairshare = FairshareConfig{Metric: "energy_kwh", Window: model.Duration(defaultFairshareWindow), MaxShares: 10}
+
+	// Only recent usage of Slurm accounts must be considered
+	w = httptest.NewRecorder()
+	server.fairshareReportAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response Response[Fairshare]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []Fairshare{
+		{ClusterID: "slurm-0", Account: "prj1", Usage: 4, NormalizedUsage: 0.5, Shares: 5},
+		{ClusterID: "slurm-0", Account: "prj2", Usage: 4, NormalizedUsage: 0.5, Shares: 5},
+	}, response.Data)
+
+	// sacctmgr commands
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/reports/fairshare/admin?format=sacctmgr", nil)
+	req.Header.Set(dashboardUserHeader, "adm1")
+
+	w = httptest.NewRecorder()
+	server.fairshareReportAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(
+		t,
+		"# cluster_id: slurm-0\n"+
+			"sacctmgr --immediate modify account where name=prj1 set fairshare=5\n"+
+			"sacctmgr --immediate modify account where name=prj2 set fairshare=5\n",
+		w.Body.String(),
+	)
+}
@@ -0,0 +1,343 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Source of footprint of requests served by API server.
+const apiFootprintSource = "api"
+
+// Maximum number of footprints tracked by API server. Footprints of new users
+// and projects are not tracked once it is reached but they are still counted
+// in metrics.
+const maxFootprints = 10000
+
+// Data volume metrics served to users. Metrics are exposed on unauthenticated
+// metrics endpoint and hence, they must not reveal users and projects.
+var (
+	servedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Name:      "served_requests_total",
+		Help:      "Total number of requests served to users by source.",
+	}, []string{"source"})
+	servedRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Name:      "served_rows_total",
+		Help:      "Total number of DB rows served to users by source.",
+	}, []string{"source"})
+	servedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Name:      "served_bytes_total",
+		Help:      "Total number of response bytes served to users by source.",
+	}, []string{"source"})
+)
+
+// Footprint is the data volume served to a user in a project by a CEEMS
+// component. Source is `api` for API server and `lb_tsdb` or `lb_pyroscope`
+// for load balancers. Project is empty when requests are not scoped to a
+// single project.
+type Footprint struct {
+	Source   string `json:"source"`
+	User     string `json:"user"`
+	Project  string `json:"project"`
+	Requests int64  `json:"requests"`
+	Rows     int64  `json:"rows"`
+	Bytes    int64  `json:"bytes"`
+}
+
+// valid returns true when footprint can be reported by other components.
+func (f Footprint) valid() bool {
+	if f.Source == "" || f.Source == apiFootprintSource || f.User == "" {
+		return false
+	}
+
+	return f.Requests >= 0 && f.Rows >= 0 && f.Bytes >= 0
+}
+
+// footprintKey identifies footprint of a user in a project from a source.
+type footprintKey struct {
+	source  string
+	user    string
+	project string
+}
+
+// footprintTracker accumulates footprints since the start of server.
+type footprintTracker struct {
+	mu         sync.Mutex
+	footprints map[footprintKey]*Footprint
+	isMember   func(ctx context.Context, user string, project string) bool
+}
+
+// newFootprintTracker returns a new instance of footprintTracker. Footprints of
+// requests are attributed to a project only when isMember returns true for the
+// user and project.
+func newFootprintTracker(isMember func(context.Context, string, string) bool) *footprintTracker {
+	return &footprintTracker{
+		footprints: make(map[footprintKey]*Footprint),
+		isMember:   isMember,
+	}
+}
+
+// add adds f to accumulated footprint and updates metrics.
+func (t *footprintTracker) add(f Footprint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	servedRequests.WithLabelValues(f.Source).Add(float64(f.Requests))
+	servedRows.WithLabelValues(f.Source).Add(float64(f.Rows))
+	servedBytes.WithLabelValues(f.Source).Add(float64(f.Bytes))
+
+	key := footprintKey{f.Source, f.User, f.Project}
+	if _, ok := t.footprints[key]; !ok {
+		if len(t.footprints) >= maxFootprints {
+			return
+		}
+
+		t.footprints[key] = &Footprint{Source: f.Source, User: f.User, Project: f.Project}
+	}
+
+	t.footprints[key].Requests += f.Requests
+	t.footprints[key].Rows += f.Rows
+	t.footprints[key].Bytes += f.Bytes
+}
+
+// list returns accumulated footprints of users. Footprints of all users are
+// returned when users is empty.
+func (t *footprintTracker) list(users []string) []Footprint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	footprints := make([]Footprint, 0, len(t.footprints))
+
+	for key, f := range t.footprints {
+		if len(users) > 0 && !slices.Contains(users, key.user) {
+			continue
+		}
+
+		footprints = append(footprints, *f)
+	}
+
+	// Sort by user, project and source
+	slices.SortFunc(footprints, func(a, b Footprint) int {
+		return cmp.Or(cmp.Compare(a.User, b.User), cmp.Compare(a.Project, b.Project), cmp.Compare(a.Source, b.Source))
+	})
+
+	return footprints
+}
+
+// servedRowsKey is the context key of counter of DB rows served in a request.
+type servedRowsKey struct{}
+
+// addServedRows adds numRows to the counter of served rows in ctx, if any.
+func addServedRows(ctx context.Context, numRows int) {
+	if counter, ok := ctx.Value(servedRowsKey{}).(*atomic.Int64); ok {
+		counter.Add(int64(numRows))
+	}
+}
+
+// Middleware records number of DB rows and response bytes served to the logged
+// user. Requests without logged user like health checks and requests made by
+// other CEEMS components are not recorded.
+func (t *footprintTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Header.Get(loggedUserHeader)
+		if user == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		rows := &atomic.Int64{}
+		recorder := &middleware.ByteRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), servedRowsKey{}, rows)))
+
+		// Footprint is attributed to a project only when request is scoped to one
+		// and user belongs to it so that arbitrary projects are not tracked
+		var project string
+		if projects := r.URL.Query()["project"]; len(projects) == 1 && t.isMember != nil &&
+			t.isMember(r.Context(), user, projects[0]) {
+			project = projects[0]
+		}
+
+		t.add(Footprint{
+			Source:   apiFootprintSource,
+			User:     user,
+			Project:  project,
+			Requests: 1,
+			Rows:     rows.Load(),
+			Bytes:    recorder.Bytes,
+		})
+	})
+}
+
+// footprintResponse writes footprints of users.
+func (s *CEEMSServer) footprintResponse(w http.ResponseWriter, users []string) {
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	footprintResponse := Response[Footprint]{
+		Status: "success",
+		Data:   s.footprints.list(users),
+	}
+
+	if err := json.NewEncoder(w).Encode(&footprintResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// monitoringUsage         godoc
+//
+//	@Summary		Show footprint of current user on monitoring infrastructure
+//	@Description	This endpoint returns the number of requests, DB rows and response
+//	@Description	bytes served to the current user by API server and load balancers since
+//	@Description	the start of API server. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	Footprint is attributed to a project only when the request is scoped to
+//	@Description	a single project using `project` query parameter and the user belongs to
+//	@Description	that project. Load balancers do not attribute footprint to projects and do
+//	@Description	not serve DB rows.
+//	@Security		BasicAuth
+//	@Tags			stats
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Success		200				{object}	Response[Footprint]
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/stats/monitoring-usage [get]
+//
+// GET /stats/monitoring-usage
+// Get footprint of current user on monitoring infrastructure.
+func (s *CEEMSServer) monitoringUsage(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "monitoring usage endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	s.footprintResponse(w, []string{dashboardUser})
+}
+
+// monitoringUsageAdmin         godoc
+//
+//	@Summary		Admin endpoint for footprint of users on monitoring infrastructure
+//	@Description	This admin endpoint returns the number of requests, DB rows and response
+//	@Description	bytes served to users by API server and load balancers since the start of
+//	@Description	API server. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Footprint of all users is returned unless
+//	@Description	users are specified using `user` query parameter.
+//	@Security		BasicAuth
+//	@Tags			stats
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			user			query		[]string	false	"User name"	collectionFormat(multi)
+//	@Success		200				{object}	Response[Footprint]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/stats/monitoring-usage/admin [get]
+//
+// GET /stats/monitoring-usage/admin
+// Get footprint of users on monitoring infrastructure.
+func (s *CEEMSServer) monitoringUsageAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "monitoring usage admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	s.footprintResponse(w, r.URL.Query()["user"])
+}
+
+// reportFootprintAdmin         godoc
+//
+//	@Summary		Admin endpoint to report footprint of users
+//	@Description	This admin endpoint is used by load balancers to report the number of
+//	@Description	requests and response bytes they served to users since their last report.
+//	@Description	The reported footprints are added to the footprints of users and
+//	@Description	returned by monitoring usage endpoints.
+//	@Description
+//	@Description	Source and user of every footprint must be non-empty and source cannot
+//	@Description	be `api`. Negative values are rejected.
+//	@Security		BasicAuth
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			body			body		[]Footprint	true	"Footprints served since last report"
+//	@Success		200				{object}	Response[Footprint]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/stats/monitoring-usage/admin [post]
+//
+// POST /stats/monitoring-usage/admin
+// Report footprint of users.
+func (s *CEEMSServer) reportFootprintAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "report footprint endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	var footprints []Footprint
+
+	// Validate all footprints before adding any of them so that retries of
+	// load balancers do not count footprints twice
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024)
+	if err := json.NewDecoder(r.Body).Decode(&footprints); err != nil ||
+		slices.ContainsFunc(footprints, func(f Footprint) bool { return !f.valid() }) {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidFootprint}, s.logger)
+
+		return
+	}
+
+	for _, f := range footprints {
+		s.footprints.add(f)
+	}
+
+	s.footprintResponse(w, nil)
+}
+
+// isProjectMember returns true when user belongs to project in any of clusters.
+func (s *CEEMSServer) isProjectMember(ctx context.Context, user string, project string) bool {
+	query := fmt.Sprintf(
+		"SELECT EXISTS(SELECT 1 FROM %s WHERE name = ? AND EXISTS (SELECT 1 FROM json_each(users) WHERE value = ?))",
+		base.ProjectsDBTableName,
+	)
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, project, user).Scan(&exists); err != nil {
+		s.logger.Error("Failed to verify project of user", "user", user, "project", project, "err", err)
+
+		return false
+	}
+
+	return exists
+}
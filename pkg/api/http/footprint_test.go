@@ -0,0 +1,172 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFootprintMiddleware(t *testing.T) {
+	tracker := newFootprintTracker(func(_ context.Context, user string, project string) bool {
+		return user == "usr1" && project == "prj1"
+	})
+
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addServedRows(r.Context(), 3)
+		addServedRows(r.Context(), 2)
+		w.Write([]byte("0123456789"))
+	}))
+
+	// Requests scoped to a single project of user must be attributed to it
+	for _, url := range []string{
+		"/api/v1/units?project=prj1",
+		"/api/v1/units?project=prj1&project=prj2",
+		"/api/v1/units?project=prj3",
+		"/api/v1/health",
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if !strings.Contains(url, "health") {
+			req.Header.Set(loggedUserHeader, "usr1")
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Requests without logged user must not be recorded
+	assert.Equal(t, []Footprint{
+		{Source: "api", User: "usr1", Project: "", Requests: 2, Rows: 10, Bytes: 20},
+		{Source: "api", User: "usr1", Project: "prj1", Requests: 1, Rows: 5, Bytes: 10},
+	}, tracker.list(nil))
+	assert.Empty(t, tracker.list([]string{"usr2"}))
+}
+
+func TestFootprintTrackerLimit(t *testing.T) {
+	tracker := newFootprintTracker(nil)
+
+	for i := range maxFootprints + 1 {
+		tracker.add(Footprint{Source: "lb_limit", User: "usr" + strconv.Itoa(i), Requests: 1, Bytes: 10})
+	}
+
+	// Footprints of new users must not be tracked beyond limit
+	assert.Len(t, tracker.list(nil), maxFootprints)
+	assert.Empty(t, tracker.list([]string{"usr" + strconv.Itoa(maxFootprints)}))
+
+	// Tracked footprints must still be updated
+	tracker.add(Footprint{Source: "lb_limit", User: "usr0", Requests: 1, Bytes: 10})
+	assert.Equal(t, []Footprint{{Source: "lb_limit", User: "usr0", Requests: 2, Bytes: 20}}, tracker.list([]string{"usr0"}))
+
+	// Metrics must count all footprints without exposing users
+	assert.InDelta(t, float64(maxFootprints+2), testutil.ToFloat64(servedRequests.WithLabelValues("lb_limit")), 0)
+	assert.InDelta(t, float64(10*(maxFootprints+2)), testutil.ToFloat64(servedBytes.WithLabelValues("lb_limit")), 0)
+}
+
+func TestFootprintProjectMembership(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec(`
+INSERT INTO projects (cluster_id,name,users) VALUES ('rm-0','prj1','["usr1","usr2"]');
+INSERT INTO projects (cluster_id,name,users) VALUES ('rm-0','prj2','["usr2"]');`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	// Make requests through middlewares
+	for _, project := range []string{"prj1", "prj2", "unknown"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/projects?project="+project, nil)
+		req.Header.Set(grafanaUserHeader, "usr1")
+
+		server.server.Handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Only projects of user must be attributed
+	footprints := server.footprints.list([]string{"usr1"})
+	require.Len(t, footprints, 2)
+	assert.Equal(t, "", footprints[0].Project)
+	assert.Equal(t, int64(2), footprints[0].Requests)
+	assert.Equal(t, "prj1", footprints[1].Project)
+	assert.Equal(t, int64(1), footprints[1].Requests)
+}
+
+func TestMonitoringUsageHandlers(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	server.footprints.add(Footprint{Source: "api", User: "usr1", Requests: 2, Rows: 10, Bytes: 100})
+
+	// Footprints reported by LB must be added
+	body := `[{"source":"lb_tsdb","user":"usr1","requests":1,"bytes":50},{"source":"lb_tsdb","user":"usr2","requests":3,"bytes":20}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/stats/monitoring-usage/admin", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	server.reportFootprintAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Invalid footprints must be rejected as a whole
+	for _, body := range []string{
+		`[{"source":"lb_tsdb","user":"usr1","requests":1},{"source":"api","user":"usr1","requests":1}]`,
+		`[{"source":"lb_tsdb","user":"","requests":1}]`,
+		`[{"source":"lb_tsdb","user":"usr1","bytes":-1}]`,
+		`{"source":"lb_tsdb"}`,
+	} {
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/stats/monitoring-usage/admin", strings.NewReader(body))
+		w = httptest.NewRecorder()
+		server.reportFootprintAdmin(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	// Users must only get their own footprint
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/stats/monitoring-usage", nil)
+	req.Header.Set(dashboardUserHeader, "usr1")
+
+	w = httptest.NewRecorder()
+	server.monitoringUsage(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response Response[Footprint]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []Footprint{
+		{Source: "api", User: "usr1", Requests: 2, Rows: 10, Bytes: 100},
+		{Source: "lb_tsdb", User: "usr1", Requests: 1, Bytes: 50},
+	}, response.Data)
+
+	// Admins can filter users
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/stats/monitoring-usage/admin?user=usr2", nil)
+	req.Header.Set(dashboardUserHeader, "adm1")
+
+	w = httptest.NewRecorder()
+	server.monitoringUsageAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	response = Response[Footprint]{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []Footprint{{Source: "lb_tsdb", User: "usr2", Requests: 3, Bytes: 20}}, response.Data)
+}
@@ -0,0 +1,751 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Limits of GraphQL queries.
+const (
+	graphqlMaxDepth       = 6
+	graphqlMaxParallelism = 10
+)
+
+// graphqlSchema is the GraphQL schema of CEEMS API server. The types mirror
+// the models of REST API and the fields of Query are resolved by the same
+// handlers as the REST API.
+const graphqlSchema = `
+schema {
+	query: Query
+}
+
+# Arbitrary JSON value.
+scalar JSON
+
+# 64 bit integer.
+scalar Int64
+
+# Kind of usage statistics.
+enum UsageMode {
+	# Usage between from and to.
+	CURRENT
+	# Total usage during retention period.
+	GLOBAL
+}
+
+type Query {
+	# Compute units. Equivalent to /units and /units/admin endpoints.
+	units(
+		admin: Boolean = false
+		user: [String!]
+		clusterId: [String!]
+		project: [String!]
+		group: [String!]
+		uuid: [String!]
+		state: [String!]
+		from: String
+		to: String
+		running: Boolean = false
+	): [Unit!]!
+
+	# Usage statistics. Equivalent to /usage/{mode} and /usage/{mode}/admin endpoints.
+	usage(
+		admin: Boolean = false
+		user: [String!]
+		mode: UsageMode = CURRENT
+		clusterId: [String!]
+		project: [String!]
+		group: [String!]
+		from: String
+		to: String
+	): [Usage!]!
+
+	# Projects. Equivalent to /projects and /projects/admin endpoints.
+	projects(
+		admin: Boolean = false
+		clusterId: [String!]
+		project: [String!]
+	): [Project!]!
+}
+
+# Compute unit like a batch job, VM or pod.
+type Unit {
+	clusterId: String!
+	resourceManager: String!
+	uuid: String!
+	name: String!
+	project: String!
+	groupname: String!
+	username: String!
+	createdAt: String!
+	startedAt: String!
+	endedAt: String!
+	createdAtTs: Int64!
+	startedAtTs: Int64!
+	endedAtTs: Int64!
+	elapsed: String!
+	state: String!
+	allocation: JSON
+	totalTimeSeconds: JSON
+	avgCpuUsage: JSON
+	avgCpuMemUsage: JSON
+	totalCpuEnergyUsageKwh: JSON
+	totalCpuEmissionsGms: JSON
+	avgGpuUsage: JSON
+	avgGpuMemUsage: JSON
+	totalGpuEnergyUsageKwh: JSON
+	totalGpuEmissionsGms: JSON
+	totalIoWriteStats: JSON
+	totalIoReadStats: JSON
+	totalIngressStats: JSON
+	totalOutgressStats: JSON
+	completeness: JSON
+	tags: JSON
+}
+
+# Aggregated usage statistics of a user in a project.
+type Usage {
+	clusterId: String!
+	resourceManager: String!
+	numUnits: Int64!
+	project: String!
+	groupname: String!
+	username: String!
+	totalTimeSeconds: JSON
+	avgCpuUsage: JSON
+	avgCpuMemUsage: JSON
+	totalCpuEnergyUsageKwh: JSON
+	totalCpuEmissionsGms: JSON
+	avgGpuUsage: JSON
+	avgGpuMemUsage: JSON
+	totalGpuEnergyUsageKwh: JSON
+	totalGpuEmissionsGms: JSON
+	totalIoWriteStats: JSON
+	totalIoReadStats: JSON
+	totalIngressStats: JSON
+	totalOutgressStats: JSON
+
+	# Compute units of the user in the project.
+	units(state: [String!], from: String, to: String, running: Boolean = false): [Unit!]!
+}
+
+# Project (account, tenant or namespace) of a cluster.
+type Project {
+	uid: String!
+	clusterId: String!
+	resourceManager: String!
+	name: String!
+	users: [String!]!
+	tags: JSON
+
+	# Compute units of the project.
+	units(user: [String!], state: [String!], from: String, to: String, running: Boolean = false): [Unit!]!
+
+	# Usage statistics of the project.
+	usage(user: [String!], mode: UsageMode = CURRENT, from: String, to: String): [Usage!]!
+}
+`
+
+// graphqlRequest is the body of a GraphQL request.
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlService resolves GraphQL queries. Each field of Query and nested
+// lists are translated into a request to the corresponding REST endpoint and
+// served by the same handler so that authentication, authorization and query
+// semantics are identical for both APIs.
+type graphqlService struct {
+	logger      *slog.Logger
+	handler     http.Handler
+	routePrefix string
+	schema      *graphql.Schema
+}
+
+// newGraphQLService returns a new instance of graphqlService.
+func newGraphQLService(handler http.Handler, routePrefix string, logger *slog.Logger) *graphqlService {
+	g := &graphqlService{
+		logger:      logger,
+		handler:     handler,
+		routePrefix: routePrefix,
+	}
+
+	g.schema = graphql.MustParseSchema(
+		graphqlSchema,
+		&graphqlQueryResolver{g: g},
+		graphql.UseFieldResolvers(),
+		graphql.MaxDepth(graphqlMaxDepth),
+		graphql.MaxParallelism(graphqlMaxParallelism),
+	)
+
+	return g
+}
+
+// graphql godoc
+//
+//	@Summary		GraphQL endpoint
+//	@Description	This endpoint executes GraphQL queries on units, usage and projects.
+//	@Description
+//	@Description	It allows clients to fetch nested unit, usage and project data
+//	@Description	with field selection in a single request. Each list in the query
+//	@Description	is resolved by the corresponding REST endpoint and hence, the
+//	@Description	same authentication and authorization rules apply. Fields that
+//	@Description	query admin endpoints return errors for users without admin
+//	@Description	privileges.
+//	@Description
+//	@Description	Queries can be made using POST requests with a JSON body containing
+//	@Description	`query`, `operationName` and `variables` or GET requests with the
+//	@Description	same query parameters. The GraphQL schema can be fetched using
+//	@Description	introspection queries.
+//	@Security		BasicAuth
+//	@Tags			graphql
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			query			query		string	false	"GraphQL query"
+//	@Param			operationName	query		string	false	"Operation name"
+//	@Param			variables		query		string	false	"Variables as JSON object"
+//	@Success		200				{object}	graphql.Response
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Router			/graphql [get]
+//	@Router			/graphql [post]
+//
+// GET and POST handler for GraphQL queries.
+func (s *CEEMSServer) graphql(w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	var req graphqlRequest
+
+	switch r.Method {
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.logger.Error("Failed to decode GraphQL request", "err", err)
+			errorResponse(w, r, &apiError{errorBadRequest, fmt.Errorf("invalid graphql request: %w", err)}, s.logger)
+
+			return
+		}
+	default:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				errorResponse(w, r, &apiError{errorBadRequest, fmt.Errorf("invalid graphql variables: %w", err)}, s.logger)
+
+				return
+			}
+		}
+	}
+
+	if req.Query == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errors.New("graphql query is missing")}, s.logger)
+
+		return
+	}
+
+	// Original request is passed in context so that users can be identified
+	// in requests made by resolvers
+	ctx := context.WithValue(r.Context(), graphqlRequestKey{}, r)
+
+	resp := s.graphqlService.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Error("Failed to encode GraphQL response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// graphqlRequestKey is the context key of original HTTP request of GraphQL query.
+type graphqlRequestKey struct{}
+
+// graphqlError is returned by resolvers when the REST endpoint returns an error.
+type graphqlError struct {
+	status int
+	detail string
+}
+
+// Error implements error interface.
+func (e *graphqlError) Error() string {
+	return e.detail
+}
+
+// Extensions returns extensions of the error that are included in GraphQL response.
+func (e *graphqlError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"status": e.status}
+}
+
+// list makes a GET request to REST endpoint at path and returns the data of response.
+func list[T any](ctx context.Context, g *graphqlService, path string, values url.Values) ([]T, error) {
+	target := fmt.Sprintf("%s%s?%s", g.routePrefix, path, values.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only user headers and credentials are passed from original request. Any other headers that
+	// are used internally by CEEMS components must not be forwarded.
+	if orig, ok := ctx.Value(graphqlRequestKey{}).(*http.Request); ok {
+		for _, header := range []string{grafanaUserHeader, dashboardUserHeader, authorizationHeader, apiKeyHeader} {
+			if v := orig.Header.Get(header); v != "" {
+				req.Header.Set(header, v)
+			}
+		}
+
+		req.RemoteAddr = orig.RemoteAddr
+	}
+
+	w := &bufferedResponseWriter{header: make(http.Header)}
+	g.handler.ServeHTTP(w, req)
+
+	if w.status != 0 && w.status != http.StatusOK {
+		var problem Problem
+		if err := json.Unmarshal(w.body.Bytes(), &problem); err != nil || problem.Detail == "" {
+			return nil, &graphqlError{status: w.status, detail: http.StatusText(w.status)}
+		}
+
+		return nil, &graphqlError{status: w.status, detail: problem.Detail}
+	}
+
+	var resp Response[T]
+	if err := json.Unmarshal(w.body.Bytes(), &resp); err != nil {
+		g.logger.Error("Failed to decode response", "path", path, "err", err)
+
+		return nil, err
+	}
+
+	if len(resp.Warnings) > 0 {
+		g.logger.Debug("Warnings in response", "path", path, "warnings", resp.Warnings)
+	}
+
+	return resp.Data, nil
+}
+
+// bufferedResponseWriter is a http.ResponseWriter that writes response into
+// a buffer.
+type bufferedResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+// Header returns the response headers.
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader sets the status code. Only the first call is effective.
+func (b *bufferedResponseWriter) WriteHeader(code int) {
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+// Write writes p into buffer.
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+
+	return b.body.Write(p)
+}
+
+// SetWriteDeadline is a no-op as deadlines are handled by GraphQL handler.
+func (b *bufferedResponseWriter) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// graphqlJSON is a GraphQL scalar of arbitrary JSON values.
+type graphqlJSON struct {
+	value interface{}
+}
+
+// ImplementsGraphQLType maps this type to JSON scalar of schema.
+func (graphqlJSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+// UnmarshalGraphQL unmarshals input into graphqlJSON.
+func (j *graphqlJSON) UnmarshalGraphQL(input interface{}) error {
+	j.value = input
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (j graphqlJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.value)
+}
+
+// graphqlInt64 is a GraphQL scalar of 64 bit integers.
+type graphqlInt64 int64
+
+// ImplementsGraphQLType maps this type to Int64 scalar of schema.
+func (graphqlInt64) ImplementsGraphQLType(name string) bool {
+	return name == "Int64"
+}
+
+// UnmarshalGraphQL unmarshals input into graphqlInt64.
+func (i *graphqlInt64) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case int32:
+		*i = graphqlInt64(v)
+	case float64:
+		*i = graphqlInt64(v)
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		*i = graphqlInt64(n)
+	default:
+		return fmt.Errorf("wrong type for Int64: %T", v)
+	}
+
+	return nil
+}
+
+// graphqlArgs contains common arguments of list fields.
+type graphqlArgs struct {
+	Admin     bool
+	User      *[]string
+	ClusterID *[]string
+	Project   *[]string
+	Group     *[]string
+	UUID      *[]string
+	State     *[]string
+	Mode      string
+	From      *string
+	To        *string
+	Running   bool
+}
+
+// values returns the query parameters of REST endpoint of arguments.
+func (a graphqlArgs) values() url.Values {
+	values := url.Values{}
+
+	for name, v := range map[string]*[]string{
+		"cluster_id": a.ClusterID,
+		"project":    a.Project,
+		"group":      a.Group,
+		"uuid":       a.UUID,
+		"state":      a.State,
+	} {
+		if v != nil {
+			values[name] = *v
+		}
+	}
+
+	if a.From != nil {
+		values.Set("from", *a.From)
+	}
+
+	if a.To != nil {
+		values.Set("to", *a.To)
+	}
+
+	if a.Running {
+		values.Set("running", "true")
+	}
+
+	return values
+}
+
+// path returns path of REST endpoint of resource based on arguments.
+func (a graphqlArgs) path(resource string, values url.Values) string {
+	if a.Mode != "" {
+		if a.Mode == "GLOBAL" {
+			resource = fmt.Sprintf("%s/%s", resource, globalUsage)
+		} else {
+			resource = fmt.Sprintf("%s/%s", resource, currentUsage)
+		}
+	}
+
+	if a.Admin {
+		if a.User != nil && resource != projectsResourceName {
+			values["user"] = *a.User
+		}
+
+		return resource + "/admin"
+	}
+
+	return resource
+}
+
+// graphqlQueryResolver resolves fields of Query type.
+type graphqlQueryResolver struct {
+	g *graphqlService
+}
+
+// Units resolves units field.
+func (q *graphqlQueryResolver) Units(ctx context.Context, args graphqlArgs) ([]*graphqlUnit, error) {
+	return q.g.units(ctx, args)
+}
+
+// Usage resolves usage field.
+func (q *graphqlQueryResolver) Usage(ctx context.Context, args graphqlArgs) ([]*graphqlUsage, error) {
+	return q.g.usage(ctx, args)
+}
+
+// Projects resolves projects field.
+func (q *graphqlQueryResolver) Projects(ctx context.Context, args graphqlArgs) ([]*graphqlProject, error) {
+	values := args.values()
+
+	projects, err := list[models.Project](ctx, q.g, args.path(projectsResourceName, values), values)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*graphqlProject, len(projects))
+	for i, p := range projects {
+		resolvers[i] = projectToGraphQL(p)
+		resolvers[i].g = q.g
+		resolvers[i].admin = args.Admin
+	}
+
+	return resolvers, nil
+}
+
+// units returns resolvers of units based on args.
+func (g *graphqlService) units(ctx context.Context, args graphqlArgs) ([]*graphqlUnit, error) {
+	values := args.values()
+
+	units, err := list[models.Unit](ctx, g, args.path(unitsResourceName, values), values)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*graphqlUnit, len(units))
+	for i, u := range units {
+		resolvers[i] = unitToGraphQL(u)
+	}
+
+	return resolvers, nil
+}
+
+// usage returns resolvers of usage based on args.
+func (g *graphqlService) usage(ctx context.Context, args graphqlArgs) ([]*graphqlUsage, error) {
+	values := args.values()
+
+	usage, err := list[models.Usage](ctx, g, args.path(usageResourceName, values), values)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*graphqlUsage, len(usage))
+	for i, u := range usage {
+		resolvers[i] = usageToGraphQL(u)
+		resolvers[i].g = g
+		resolvers[i].admin = args.Admin
+	}
+
+	return resolvers, nil
+}
+
+// graphqlUnit is the Unit type of GraphQL schema.
+type graphqlUnit struct {
+	ClusterID              string
+	ResourceManager        string
+	UUID                   string
+	Name                   string
+	Project                string
+	Groupname              string
+	Username               string
+	CreatedAt              string
+	StartedAt              string
+	EndedAt                string
+	CreatedAtTS            graphqlInt64
+	StartedAtTS            graphqlInt64
+	EndedAtTS              graphqlInt64
+	Elapsed                string
+	State                  string
+	Allocation             *graphqlJSON
+	TotalTimeSeconds       *graphqlJSON
+	AvgCPUUsage            *graphqlJSON
+	AvgCPUMemUsage         *graphqlJSON
+	TotalCPUEnergyUsageKwh *graphqlJSON
+	TotalCPUEmissionsGms   *graphqlJSON
+	AvgGPUUsage            *graphqlJSON
+	AvgGPUMemUsage         *graphqlJSON
+	TotalGPUEnergyUsageKwh *graphqlJSON
+	TotalGPUEmissionsGms   *graphqlJSON
+	TotalIOWriteStats      *graphqlJSON
+	TotalIOReadStats       *graphqlJSON
+	TotalIngressStats      *graphqlJSON
+	TotalOutgressStats     *graphqlJSON
+	Completeness           *graphqlJSON
+	Tags                   *graphqlJSON
+}
+
+// graphqlUsage is the Usage type of GraphQL schema.
+type graphqlUsage struct {
+	ClusterID              string
+	ResourceManager        string
+	NumUnits               graphqlInt64
+	Project                string
+	Groupname              string
+	Username               string
+	TotalTimeSeconds       *graphqlJSON
+	AvgCPUUsage            *graphqlJSON
+	AvgCPUMemUsage         *graphqlJSON
+	TotalCPUEnergyUsageKwh *graphqlJSON
+	TotalCPUEmissionsGms   *graphqlJSON
+	AvgGPUUsage            *graphqlJSON
+	AvgGPUMemUsage         *graphqlJSON
+	TotalGPUEnergyUsageKwh *graphqlJSON
+	TotalGPUEmissionsGms   *graphqlJSON
+	TotalIOWriteStats      *graphqlJSON
+	TotalIOReadStats       *graphqlJSON
+	TotalIngressStats      *graphqlJSON
+	TotalOutgressStats     *graphqlJSON
+
+	g     *graphqlService
+	admin bool
+}
+
+// Units resolves units of the user in the project of usage.
+func (u *graphqlUsage) Units(ctx context.Context, args graphqlArgs) ([]*graphqlUnit, error) {
+	args.Admin = u.admin
+	args.User = &[]string{u.Username}
+	args.ClusterID = &[]string{u.ClusterID}
+	args.Project = &[]string{u.Project}
+
+	return u.g.units(ctx, args)
+}
+
+// graphqlProject is the Project type of GraphQL schema.
+type graphqlProject struct {
+	UID             string
+	ClusterID       string
+	ResourceManager string
+	Name            string
+	Users           []string
+	Tags            *graphqlJSON
+
+	g     *graphqlService
+	admin bool
+}
+
+// Units resolves units of the project.
+func (p *graphqlProject) Units(ctx context.Context, args graphqlArgs) ([]*graphqlUnit, error) {
+	args.Admin = p.admin
+	args.ClusterID = &[]string{p.ClusterID}
+	args.Project = &[]string{p.Name}
+
+	return p.g.units(ctx, args)
+}
+
+// Usage resolves usage statistics of the project.
+func (p *graphqlProject) Usage(ctx context.Context, args graphqlArgs) ([]*graphqlUsage, error) {
+	args.Admin = p.admin
+	args.ClusterID = &[]string{p.ClusterID}
+	args.Project = &[]string{p.Name}
+
+	return p.g.usage(ctx, args)
+}
+
+// unitToGraphQL converts unit model to GraphQL type.
+func unitToGraphQL(u models.Unit) *graphqlUnit {
+	return &graphqlUnit{
+		ClusterID:              u.ClusterID,
+		ResourceManager:        u.ResourceManager,
+		UUID:                   u.UUID,
+		Name:                   u.Name,
+		Project:                u.Project,
+		Groupname:              u.Group,
+		Username:               u.User,
+		CreatedAt:              u.CreatedAt,
+		StartedAt:              u.StartedAt,
+		EndedAt:                u.EndedAt,
+		CreatedAtTS:            graphqlInt64(u.CreatedAtTS),
+		StartedAtTS:            graphqlInt64(u.StartedAtTS),
+		EndedAtTS:              graphqlInt64(u.EndedAtTS),
+		Elapsed:                u.Elapsed,
+		State:                  u.State,
+		Allocation:             toGraphQLJSON(u.Allocation),
+		TotalTimeSeconds:       toGraphQLJSON(u.TotalTime),
+		AvgCPUUsage:            toGraphQLJSON(u.AveCPUUsage),
+		AvgCPUMemUsage:         toGraphQLJSON(u.AveCPUMemUsage),
+		TotalCPUEnergyUsageKwh: toGraphQLJSON(u.TotalCPUEnergyUsage),
+		TotalCPUEmissionsGms:   toGraphQLJSON(u.TotalCPUEmissions),
+		AvgGPUUsage:            toGraphQLJSON(u.AveGPUUsage),
+		AvgGPUMemUsage:         toGraphQLJSON(u.AveGPUMemUsage),
+		TotalGPUEnergyUsageKwh: toGraphQLJSON(u.TotalGPUEnergyUsage),
+		TotalGPUEmissionsGms:   toGraphQLJSON(u.TotalGPUEmissions),
+		TotalIOWriteStats:      toGraphQLJSON(u.TotalIOWriteStats),
+		TotalIOReadStats:       toGraphQLJSON(u.TotalIOReadStats),
+		TotalIngressStats:      toGraphQLJSON(u.TotalIngressStats),
+		TotalOutgressStats:     toGraphQLJSON(u.TotalOutgressStats),
+		Completeness:           toGraphQLJSON(u.Completeness),
+		Tags:                   toGraphQLJSON(u.Tags),
+	}
+}
+
+// usageToGraphQL converts usage model to GraphQL type.
+func usageToGraphQL(u models.Usage) *graphqlUsage {
+	return &graphqlUsage{
+		ClusterID:              u.ClusterID,
+		ResourceManager:        u.ResourceManager,
+		NumUnits:               graphqlInt64(u.NumUnits),
+		Project:                u.Project,
+		Groupname:              u.Group,
+		Username:               u.User,
+		TotalTimeSeconds:       toGraphQLJSON(u.TotalTime),
+		AvgCPUUsage:            toGraphQLJSON(u.AveCPUUsage),
+		AvgCPUMemUsage:         toGraphQLJSON(u.AveCPUMemUsage),
+		TotalCPUEnergyUsageKwh: toGraphQLJSON(u.TotalCPUEnergyUsage),
+		TotalCPUEmissionsGms:   toGraphQLJSON(u.TotalCPUEmissions),
+		AvgGPUUsage:            toGraphQLJSON(u.AveGPUUsage),
+		AvgGPUMemUsage:         toGraphQLJSON(u.AveGPUMemUsage),
+		TotalGPUEnergyUsageKwh: toGraphQLJSON(u.TotalGPUEnergyUsage),
+		TotalGPUEmissionsGms:   toGraphQLJSON(u.TotalGPUEmissions),
+		TotalIOWriteStats:      toGraphQLJSON(u.TotalIOWriteStats),
+		TotalIOReadStats:       toGraphQLJSON(u.TotalIOReadStats),
+		TotalIngressStats:      toGraphQLJSON(u.TotalIngressStats),
+		TotalOutgressStats:     toGraphQLJSON(u.TotalOutgressStats),
+	}
+}
+
+// projectToGraphQL converts project model to GraphQL type.
+func projectToGraphQL(p models.Project) *graphqlProject {
+	users := make([]string, 0, len(p.Users))
+	for _, u := range p.Users {
+		users = append(users, fmt.Sprint(u))
+	}
+
+	return &graphqlProject{
+		UID:             p.UID,
+		ClusterID:       p.ClusterID,
+		ResourceManager: p.ResourceManager,
+		Name:            p.Name,
+		Users:           users,
+		Tags:            toGraphQLJSON(p.Tags),
+	}
+}
+
+// toGraphQLJSON returns v as JSON scalar. Nil is returned for empty maps and slices.
+func toGraphQLJSON(v interface{}) *graphqlJSON {
+	if rv := reflect.ValueOf(v); !rv.IsValid() || rv.Len() == 0 {
+		return nil
+	}
+
+	return &graphqlJSON{value: v}
+}
@@ -0,0 +1,158 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphqlTestResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func graphqlRequestTest(t *testing.T, server *CEEMSServer, req *http.Request) (int, graphqlTestResponse) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	var resp graphqlTestResponse
+	if w.Code == http.StatusOK {
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	}
+
+	return w.Code, resp
+}
+
+func TestGraphQLNestedQuery(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture queries sent to units querier
+	var params [][]string
+
+	var mu sync.Mutex
+
+	server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+		_, p := q.get()
+
+		mu.Lock()
+		params = append(params, p)
+		mu.Unlock()
+
+		return mockServerUnits[:1], nil
+	}
+
+	body := `{"query": "query { projects(clusterId: [\"slurm-0\"]) { name users units(from: \"1735689600\", to: \"1735776000\") { uuid clusterId } usage { numUnits } } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, resp := graphqlRequestTest(t, server, req)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+
+	expected := `{"projects":[
+{"name":"foo","users":["foousr"],"units":[{"uuid":"1000","clusterId":"slurm-0"}],"usage":[{"numUnits":0},{"numUnits":0}]},
+{"name":"bar","users":["barusr"],"units":[{"uuid":"1000","clusterId":"slurm-0"}],"usage":[{"numUnits":0},{"numUnits":0}]}
+]}`
+	assert.JSONEq(t, expected, string(resp.Data))
+
+	// Units of each project must be filtered by cluster and project
+	require.Len(t, params, 2)
+
+	for _, p := range params {
+		assert.Contains(t, p, "foousr")
+
+		if slices.Contains(p, "foo") {
+			assert.Contains(t, p, "slurm-0")
+		} else {
+			assert.Contains(t, p, "bar")
+			assert.Contains(t, p, "os-0")
+		}
+	}
+}
+
+func TestGraphQLGetQuery(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	values := url.Values{
+		"query":     []string{"query Units($uuid: [String!]) { units(uuid: $uuid) { uuid username createdAtTs } }"},
+		"variables": []string{`{"uuid": ["1000"]}`},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/graphql?"+values.Encode(), nil)
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, resp := graphqlRequestTest(t, server, req)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+
+	expected := `{"units":[{"uuid":"1000","username":"foousr","createdAtTs":0},{"uuid":"10001","username":"barusr","createdAtTs":0}]}`
+	assert.JSONEq(t, expected, string(resp.Data))
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Non admin users cannot query admin end points
+	body := `{"query": "{ units(admin: true) { uuid } }"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, resp := graphqlRequestTest(t, server, req)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Errors, 1)
+	assert.InDelta(t, float64(http.StatusForbidden), resp.Errors[0].Extensions["status"], 0)
+
+	// Malformed time stamps
+	body = `{"query": "{ usage(from: \"foo\") { project } }"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, resp = graphqlRequestTest(t, server, req)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Errors, 1)
+	assert.InDelta(t, float64(http.StatusBadRequest), resp.Errors[0].Extensions["status"], 0)
+
+	// Unknown fields
+	body = `{"query": "{ units { foo } }"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, resp = graphqlRequestTest(t, server, req)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, resp.Errors)
+
+	// Missing query
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(`{}`))
+	req.Header.Set(grafanaUserHeader, "foousr")
+
+	code, _ = graphqlRequestTest(t, server, req)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// Request without user must be denied
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/graphql", strings.NewReader(body))
+
+	code, _ = graphqlRequestTest(t, server, req)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
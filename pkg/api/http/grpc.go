@@ -0,0 +1,442 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	ceemsv1 "github.com/mahendrapaipuri/ceems/pkg/api/proto/ceems/v1"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Metadata key in which warnings of REST API response are returned.
+const grpcWarningsKey = "warnings"
+
+// grpcService implements gRPC API of CEEMS API server. Each RPC is translated
+// into a request to the corresponding REST endpoint and served by the same
+// handler so that authentication, authorization and query semantics are
+// identical for both APIs.
+type grpcService struct {
+	ceemsv1.UnimplementedCEEMSServiceServer
+
+	logger      *slog.Logger
+	handler     http.Handler
+	routePrefix string
+}
+
+// newGRPCServer returns a HTTP server that serves gRPC API using given REST
+// API handler. The server accepts HTTP/2 connections both over TLS and
+// cleartext.
+func newGRPCServer(address string, handler http.Handler, routePrefix string, logger *slog.Logger) *http.Server {
+	grpcServer := grpc.NewServer()
+	ceemsv1.RegisterCEEMSServiceServer(grpcServer, &grpcService{
+		logger:      logger,
+		handler:     handler,
+		routePrefix: routePrefix,
+	})
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           h2c.NewHandler(grpcServer, &http2.Server{}),
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+}
+
+// ListUnits streams compute units.
+func (g *grpcService) ListUnits(req *ceemsv1.ListUnitsRequest, stream grpc.ServerStreamingServer[ceemsv1.Unit]) error {
+	values := url.Values{
+		"cluster_id": req.GetClusterId(),
+		"project":    req.GetProject(),
+		"uuid":       req.GetUuid(),
+		"state":      req.GetState(),
+		"field":      req.GetField(),
+		"format":     []string{"ndjson"},
+	}
+	setTimeParams(values, req.GetFrom(), req.GetTo())
+
+	if req.GetRunning() {
+		values.Set("running", "true")
+	}
+
+	path := unitsResourceName
+	if req.GetAdmin() {
+		path += "/admin"
+		values["user"] = req.GetUser()
+	}
+
+	body, err := g.serve(stream.Context(), path, values)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	// Units are streamed as newline delimited JSON. If an error occurs while
+	// streaming, last line will be a problem details object
+	decoder := json.NewDecoder(body)
+
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		var problem Problem
+		if err := json.Unmarshal(raw, &problem); err == nil && problem.Status != 0 && problem.Title != "" {
+			return status.Error(grpcCode(problem.Status), problem.Detail)
+		}
+
+		var unit models.Unit
+		if err := json.Unmarshal(raw, &unit); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		if err := stream.Send(unitToProto(unit)); err != nil {
+			return err
+		}
+	}
+}
+
+// ListUsage streams usage statistics.
+func (g *grpcService) ListUsage(req *ceemsv1.ListUsageRequest, stream grpc.ServerStreamingServer[ceemsv1.Usage]) error {
+	values := url.Values{
+		"cluster_id": req.GetClusterId(),
+		"project":    req.GetProject(),
+		"field":      req.GetField(),
+	}
+	setTimeParams(values, req.GetFrom(), req.GetTo())
+
+	mode := currentUsage
+	if req.GetMode() == ceemsv1.UsageMode_USAGE_MODE_GLOBAL {
+		mode = globalUsage
+	}
+
+	path := fmt.Sprintf("%s/%s", usageResourceName, mode)
+	if req.GetAdmin() {
+		path += "/admin"
+		values["user"] = req.GetUser()
+	}
+
+	resp, err := serveJSON[models.Usage](stream.Context(), g, path, values)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Warnings) > 0 {
+		stream.SetTrailer(metadata.Pairs(grpcWarningsKey, fmt.Sprint(resp.Warnings)))
+	}
+
+	for _, usage := range resp.Data {
+		if err := stream.Send(usageToProto(usage)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListProjects returns projects.
+func (g *grpcService) ListProjects(ctx context.Context, req *ceemsv1.ListProjectsRequest) (*ceemsv1.ListProjectsResponse, error) {
+	values := url.Values{
+		"cluster_id": req.GetClusterId(),
+		"project":    req.GetProject(),
+	}
+
+	path := projectsResourceName
+	if req.GetAdmin() {
+		path += "/admin"
+	}
+
+	resp, err := serveJSON[models.Project](ctx, g, path, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Warnings) > 0 {
+		grpc.SetTrailer(ctx, metadata.Pairs(grpcWarningsKey, fmt.Sprint(resp.Warnings))) //nolint:errcheck
+	}
+
+	projects := make([]*ceemsv1.Project, len(resp.Data))
+	for i, project := range resp.Data {
+		projects[i] = projectToProto(project)
+	}
+
+	return &ceemsv1.ListProjectsResponse{Projects: projects}, nil
+}
+
+// serve makes a GET request to REST endpoint at path and returns the response
+// body while it is being written by the handler. Users are identified from the
+// metadata of gRPC request exactly like headers of REST API requests.
+func (g *grpcService) serve(ctx context.Context, path string, values url.Values) (io.ReadCloser, error) {
+	target := fmt.Sprintf("%s%s?%s", g.routePrefix, path, values.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Only user headers and credentials are passed from metadata. Any other headers that are
+	// used internally by CEEMS components must not be set by gRPC clients
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, header := range []string{grafanaUserHeader, dashboardUserHeader, authorizationHeader, apiKeyHeader} {
+			if v := md.Get(header); len(v) > 0 {
+				req.Header.Set(header, v[0])
+			}
+		}
+	}
+
+	// Set client address so that rate limits are applied per client
+	if p, ok := peer.FromContext(ctx); ok {
+		req.RemoteAddr = p.Addr.String()
+	}
+
+	pr, pw := io.Pipe()
+	w := &pipeResponseWriter{
+		header: make(http.Header),
+		pw:     pw,
+		status: make(chan int, 1),
+	}
+
+	go func() {
+		defer pw.Close()
+
+		g.handler.ServeHTTP(w, req)
+
+		// Ensure status is always sent even if handler did not write anything
+		w.WriteHeader(http.StatusOK)
+	}()
+
+	code := <-w.status
+	if code == http.StatusOK {
+		return pr, nil
+	}
+
+	defer pr.Close()
+
+	var problem Problem
+	if err := json.NewDecoder(pr).Decode(&problem); err != nil || problem.Detail == "" {
+		return nil, status.Error(grpcCode(code), http.StatusText(code))
+	}
+
+	return nil, status.Error(grpcCode(code), problem.Detail)
+}
+
+// serveJSON makes a GET request to REST endpoint at path and decodes the response.
+func serveJSON[T any](ctx context.Context, g *grpcService, path string, values url.Values) (*Response[T], error) {
+	body, err := g.serve(ctx, path, values)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var resp Response[T]
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		g.logger.Error("Failed to decode response", "path", path, "err", err)
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &resp, nil
+}
+
+// pipeResponseWriter is a http.ResponseWriter that writes response body into
+// a pipe so that it can be consumed while the handler is still writing it.
+type pipeResponseWriter struct {
+	header http.Header
+	pw     *io.PipeWriter
+	status chan int
+	once   sync.Once
+}
+
+// Header returns the response headers.
+func (p *pipeResponseWriter) Header() http.Header {
+	return p.header
+}
+
+// WriteHeader sends the status code. Only the first call is effective.
+func (p *pipeResponseWriter) WriteHeader(code int) {
+	p.once.Do(func() {
+		p.status <- code
+	})
+}
+
+// Write writes b into pipe.
+func (p *pipeResponseWriter) Write(b []byte) (int, error) {
+	p.WriteHeader(http.StatusOK)
+
+	return p.pw.Write(b)
+}
+
+// Flush is a no-op as writes are unbuffered.
+func (p *pipeResponseWriter) Flush() {}
+
+// SetWriteDeadline is a no-op as deadlines are handled by gRPC server.
+func (p *pipeResponseWriter) SetWriteDeadline(time.Time) error {
+	return nil
+}
+
+// setTimeParams sets from and to query parameters when they are not empty.
+func setTimeParams(values url.Values, from, to string) {
+	if from != "" {
+		values.Set("from", from)
+	}
+
+	if to != "" {
+		values.Set("to", to)
+	}
+}
+
+// grpcCode returns gRPC status code of a HTTP status code.
+func grpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// unitToProto converts unit model into protobuf message.
+func unitToProto(u models.Unit) *ceemsv1.Unit {
+	return &ceemsv1.Unit{
+		ClusterId:              u.ClusterID,
+		ResourceManager:        u.ResourceManager,
+		Uuid:                   u.UUID,
+		Name:                   u.Name,
+		Project:                u.Project,
+		Groupname:              u.Group,
+		Username:               u.User,
+		CreatedAt:              u.CreatedAt,
+		StartedAt:              u.StartedAt,
+		EndedAt:                u.EndedAt,
+		CreatedAtTs:            u.CreatedAtTS,
+		StartedAtTs:            u.StartedAtTS,
+		EndedAtTs:              u.EndedAtTS,
+		Elapsed:                u.Elapsed,
+		State:                  u.State,
+		Allocation:             genericToProto(u.Allocation),
+		TotalTimeSeconds:       metricMapToProto(u.TotalTime),
+		AvgCpuUsage:            metricMapToProto(u.AveCPUUsage),
+		AvgCpuMemUsage:         metricMapToProto(u.AveCPUMemUsage),
+		TotalCpuEnergyUsageKwh: metricMapToProto(u.TotalCPUEnergyUsage),
+		TotalCpuEmissionsGms:   metricMapToProto(u.TotalCPUEmissions),
+		AvgGpuUsage:            metricMapToProto(u.AveGPUUsage),
+		AvgGpuMemUsage:         metricMapToProto(u.AveGPUMemUsage),
+		TotalGpuEnergyUsageKwh: metricMapToProto(u.TotalGPUEnergyUsage),
+		TotalGpuEmissionsGms:   metricMapToProto(u.TotalGPUEmissions),
+		TotalIoWriteStats:      metricMapToProto(u.TotalIOWriteStats),
+		TotalIoReadStats:       metricMapToProto(u.TotalIOReadStats),
+		TotalIngressStats:      metricMapToProto(u.TotalIngressStats),
+		TotalOutgressStats:     metricMapToProto(u.TotalOutgressStats),
+		Tags:                   genericToProto(u.Tags),
+	}
+}
+
+// usageToProto converts usage model into protobuf message.
+func usageToProto(u models.Usage) *ceemsv1.Usage {
+	return &ceemsv1.Usage{
+		ClusterId:              u.ClusterID,
+		ResourceManager:        u.ResourceManager,
+		NumUnits:               u.NumUnits,
+		Project:                u.Project,
+		Groupname:              u.Group,
+		Username:               u.User,
+		TotalTimeSeconds:       metricMapToProto(u.TotalTime),
+		AvgCpuUsage:            metricMapToProto(u.AveCPUUsage),
+		AvgCpuMemUsage:         metricMapToProto(u.AveCPUMemUsage),
+		TotalCpuEnergyUsageKwh: metricMapToProto(u.TotalCPUEnergyUsage),
+		TotalCpuEmissionsGms:   metricMapToProto(u.TotalCPUEmissions),
+		AvgGpuUsage:            metricMapToProto(u.AveGPUUsage),
+		AvgGpuMemUsage:         metricMapToProto(u.AveGPUMemUsage),
+		TotalGpuEnergyUsageKwh: metricMapToProto(u.TotalGPUEnergyUsage),
+		TotalGpuEmissionsGms:   metricMapToProto(u.TotalGPUEmissions),
+		TotalIoWriteStats:      metricMapToProto(u.TotalIOWriteStats),
+		TotalIoReadStats:       metricMapToProto(u.TotalIOReadStats),
+		TotalIngressStats:      metricMapToProto(u.TotalIngressStats),
+		TotalOutgressStats:     metricMapToProto(u.TotalOutgressStats),
+	}
+}
+
+// projectToProto converts project model into protobuf message.
+func projectToProto(p models.Project) *ceemsv1.Project {
+	users := make([]string, len(p.Users))
+	for i, user := range p.Users {
+		users[i] = fmt.Sprint(user)
+	}
+
+	var tags *structpb.ListValue
+	if len(p.Tags) > 0 {
+		tags, _ = structpb.NewList(p.Tags)
+	}
+
+	return &ceemsv1.Project{
+		Uid:             p.UID,
+		ClusterId:       p.ClusterID,
+		ResourceManager: p.ResourceManager,
+		Name:            p.Name,
+		Users:           users,
+		Tags:            tags,
+	}
+}
+
+// metricMapToProto converts metric map into protobuf map.
+func metricMapToProto(m models.MetricMap) map[string]float64 {
+	if len(m) == 0 {
+		return nil
+	}
+
+	values := make(map[string]float64, len(m))
+	for k, v := range m {
+		values[k] = float64(v)
+	}
+
+	return values
+}
+
+// genericToProto converts generic map into protobuf struct. Values that cannot be
+// represented in protobuf struct result in nil.
+func genericToProto(g models.Generic) *structpb.Struct {
+	if len(g) == 0 {
+		return nil
+	}
+
+	s, err := structpb.NewStruct(g)
+	if err != nil {
+		return nil
+	}
+
+	return s
+}
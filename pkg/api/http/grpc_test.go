@@ -0,0 +1,106 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	ceemsv1 "github.com/mahendrapaipuri/ceems/pkg/api/proto/ceems/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func setupGRPCClient(t *testing.T) ceemsv1.CEEMSServiceClient {
+	t.Helper()
+
+	server := setupServer(t.TempDir())
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	grpcServer := newGRPCServer(l.Addr().String(), server.server.Handler, "/api/v1/", server.logger)
+
+	go grpcServer.Serve(l)
+
+	t.Cleanup(func() { grpcServer.Close() })
+
+	conn, err := grpc.NewClient("passthrough:///"+l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return ceemsv1.NewCEEMSServiceClient(conn)
+}
+
+func TestGRPCListUnits(t *testing.T) {
+	client := setupGRPCClient(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grafanaUserHeader, "foousr")
+
+	stream, err := client.ListUnits(ctx, &ceemsv1.ListUnitsRequest{ClusterId: []string{"slurm-0"}})
+	require.NoError(t, err)
+
+	var uuids []string
+
+	for {
+		unit, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		uuids = append(uuids, unit.GetUuid())
+	}
+
+	assert.Equal(t, []string{"1000", "10001"}, uuids)
+}
+
+func TestGRPCListProjects(t *testing.T) {
+	client := setupGRPCClient(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grafanaUserHeader, "foousr")
+
+	var trailer metadata.MD
+
+	resp, err := client.ListProjects(ctx, &ceemsv1.ListProjectsRequest{}, grpc.Trailer(&trailer))
+	require.NoError(t, err)
+	require.Len(t, resp.GetProjects(), 2)
+	assert.Equal(t, "foo", resp.GetProjects()[0].GetName())
+	assert.Equal(t, []string{"foousr"}, resp.GetProjects()[0].GetUsers())
+
+	// Mocked querier returns an error along with projects
+	assert.Equal(t, []string{"[" + errTest.Error() + "]"}, trailer.Get(grpcWarningsKey))
+}
+
+func TestGRPCErrors(t *testing.T) {
+	client := setupGRPCClient(t)
+
+	// Request without user must be denied
+	_, err := client.ListProjects(context.Background(), &ceemsv1.ListProjectsRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), grafanaUserHeader, "foousr")
+
+	// Non admin users cannot query admin endpoints
+	_, err = client.ListProjects(ctx, &ceemsv1.ListProjectsRequest{Admin: true})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Malformed time stamps
+	stream, err := client.ListUsage(ctx, &ceemsv1.ListUsageRequest{From: "foo"})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,90 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Legacy API metrics.
+var (
+	legacyAPIRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Name:      "legacy_api_requests_total",
+		Help:      "Total number of requests made to deprecated API routes without version prefix.",
+	})
+)
+
+// LegacyAPIConfig contains the configuration of deprecated API routes without
+// version prefix.
+type LegacyAPIConfig struct {
+	Disabled bool   `yaml:"disabled"`
+	Sunset   string `yaml:"sunset"`
+}
+
+// Validate validates the config.
+func (c *LegacyAPIConfig) Validate() error {
+	if c.Sunset == "" {
+		return nil
+	}
+
+	if _, err := time.Parse(time.DateOnly, c.Sunset); err != nil {
+		return fmt.Errorf("invalid legacy API sunset date: %w", err)
+	}
+
+	return nil
+}
+
+// sunsetHeader returns the sunset date formatted as HTTP date.
+func (c *LegacyAPIConfig) sunsetHeader() string {
+	sunset, err := time.Parse(time.DateOnly, c.Sunset)
+	if err != nil {
+		return ""
+	}
+
+	return sunset.UTC().Format(http.TimeFormat)
+}
+
+// legacyAPIHandler serves the routes of API without version prefix, like
+// /api/units, as aliases of the routes of current API version. Responses of these
+// routes announce the deprecation using `Deprecation` header and point to the
+// versioned route using `Link` header with `successor-version` relation. When
+// sunset is not empty, it is set in `Sunset` header.
+func legacyAPIHandler(next http.Handler, routePrefix string, sunset string) http.Handler {
+	legacyPrefix := strings.TrimSuffix(routePrefix, base.APIVersion+"/")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, legacyPrefix) || strings.HasPrefix(r.URL.Path+"/", routePrefix) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		legacyAPIRequests.Inc()
+
+		// Rewrite path to versioned route
+		path := routePrefix + strings.TrimPrefix(r.URL.Path, legacyPrefix)
+
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, path))
+
+		if sunset != "" {
+			w.Header().Set("Sunset", sunset)
+		}
+
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = path
+		r2.URL.RawPath = ""
+		r2.RequestURI = r2.URL.RequestURI()
+
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,70 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyAPIHandler(t *testing.T) {
+	var path string
+
+	handler := legacyAPIHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}), "/ceems/api/v1/", "Thu, 31 Dec 2026 00:00:00 GMT")
+
+	for _, test := range []struct {
+		path       string
+		expected   string
+		deprecated bool
+	}{
+		{"/ceems/api/units/admin", "/ceems/api/v1/units/admin", true},
+		{"/ceems/api/v1/units/admin", "/ceems/api/v1/units/admin", false},
+		{"/ceems/api/v1", "/ceems/api/v1", false},
+		{"/ceems/metrics", "/ceems/metrics", false},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path+"?cluster_id=rm-0", nil))
+
+		assert.Equal(t, test.expected, path, test.path)
+
+		if test.deprecated {
+			assert.Equal(t, "true", w.Header().Get("Deprecation"))
+			assert.Equal(t, `</ceems/api/v1/units/admin>; rel="successor-version"`, w.Header().Get("Link"))
+			assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", w.Header().Get("Sunset"))
+		} else {
+			assert.Empty(t, w.Header().Get("Deprecation"), test.path)
+		}
+	}
+}
+
+func TestLegacyAPIConfig(t *testing.T) {
+	c := LegacyAPIConfig{Sunset: "2026-12-31"}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "Thu, 31 Dec 2026 00:00:00 GMT", c.sunsetHeader())
+
+	c = LegacyAPIConfig{Sunset: "31/12/2026"}
+	require.Error(t, c.Validate())
+}
+
+func TestLegacyAPIRoutes(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Unversioned routes must be served by versioned ones
+	req := httptest.NewRequest(http.MethodGet, "/api/units", nil)
+	req.Header.Set(grafanaUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "true", w.Header().Get("Deprecation"))
+}
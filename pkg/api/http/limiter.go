@@ -0,0 +1,103 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// Default time a request waits in queue for a free slot.
+const defaultQueueTimeout = 30 * time.Second
+
+var errServerBusy = errors.New("server is busy. Retry later")
+
+// ConcurrencyLimitConfig contains the configuration of concurrency limits of
+// expensive routes.
+type ConcurrencyLimitConfig struct {
+	MaxConcurrent int            `yaml:"max_concurrent"`
+	MaxQueued     int            `yaml:"max_queued"`
+	QueueTimeout  model.Duration `yaml:"queue_timeout"`
+}
+
+// concurrencyLimiter limits the number of requests that are served concurrently
+// by a route. Requests that cannot be served immediately wait in a bounded queue
+// and requests that do not fit in the queue or that wait longer than queue timeout
+// are rejected with 503 status.
+type concurrencyLimiter struct {
+	logger       *slog.Logger
+	slots        chan struct{} // Requests being served
+	tickets      chan struct{} // Requests being served and waiting in queue
+	queueTimeout time.Duration
+}
+
+// newConcurrencyLimiter returns a new concurrencyLimiter. A nil limiter is returned
+// when max concurrency is not positive, which does not limit any requests.
+func newConcurrencyLimiter(c ConcurrencyLimitConfig, logger *slog.Logger) *concurrencyLimiter {
+	if c.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	queueTimeout := time.Duration(c.QueueTimeout)
+	if queueTimeout <= 0 {
+		queueTimeout = defaultQueueTimeout
+	}
+
+	return &concurrencyLimiter{
+		logger:       logger,
+		slots:        make(chan struct{}, c.MaxConcurrent),
+		tickets:      make(chan struct{}, c.MaxConcurrent+max(c.MaxQueued, 0)),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Handler wraps the handler h with concurrency limits.
+func (l *concurrencyLimiter) Handler(h http.HandlerFunc) http.Handler {
+	if l == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reject immediately when queue is full
+		select {
+		case l.tickets <- struct{}{}:
+		default:
+			l.reject(w, r)
+
+			return
+		}
+		defer func() { <-l.tickets }()
+
+		// Wait for a free slot
+		timer := time.NewTimer(l.queueTimeout)
+		defer timer.Stop()
+
+		select {
+		case l.slots <- struct{}{}:
+		case <-timer.C:
+			l.reject(w, r)
+
+			return
+		case <-r.Context().Done():
+			return
+		}
+		defer func() { <-l.slots }()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// reject writes a 503 response with Retry-After header.
+func (l *concurrencyLimiter) reject(w http.ResponseWriter, r *http.Request) {
+	l.logger.Debug("Too many concurrent requests. Rejecting request", "url", r.URL.Path)
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(l.queueTimeout.Seconds()))))
+	errorResponse(w, r, &apiError{errorUnavailable, errServerBusy}, l.logger)
+}
@@ -0,0 +1,97 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var noOpLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitConfig{
+		MaxConcurrent: 1,
+		MaxQueued:     1,
+		QueueTimeout:  model.Duration(100 * time.Millisecond),
+	}, noOpLogger)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	h := l.Handler(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/units", nil))
+
+		return w
+	}
+
+	var wg sync.WaitGroup
+
+	// First request occupies the only slot
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		assert.Equal(t, http.StatusOK, serve().Code)
+	}()
+
+	<-started
+
+	// Second request waits in queue and times out
+	queued := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		queued <- serve()
+	}()
+
+	// Wait until second request is in the queue
+	require.Eventually(t, func() bool { return len(l.tickets) == 2 }, time.Second, time.Millisecond)
+
+	// Third request does not fit in the queue and must be rejected immediately
+	w := serve()
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	w = <-queued
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// Once slot is released, requests must be served again
+	close(release)
+	wg.Wait()
+
+	go func() { <-started }()
+
+	assert.Equal(t, http.StatusOK, serve().Code)
+}
+
+func TestConcurrencyLimiterDisabled(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitConfig{}, noOpLogger)
+	require.Nil(t, l)
+
+	h := l.Handler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/units", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,191 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+// liveMetricsFetcher returns recent time series of metrics of a running unit
+// ending at given time.
+type liveMetricsFetcher func(ctx context.Context, unit models.Unit, end time.Time) (*models.LiveMetrics, error)
+
+// newLiveMetricsFetcher returns a fetcher that queries the updaters configured
+// for the cluster of the unit.
+func newLiveMetricsFetcher(c db.Config, logger *slog.Logger) (liveMetricsFetcher, error) {
+	if c.Updater == nil {
+		return nil, errLiveUnavailable
+	}
+
+	clusters, err := resource.Clusters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters config: %w", err)
+	}
+
+	unitUpdater, err := c.Updater(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup updaters: %w", err)
+	}
+
+	clustersMap := make(map[string]models.Cluster, len(clusters))
+	for _, cluster := range clusters {
+		clustersMap[cluster.ID] = cluster
+	}
+
+	return func(ctx context.Context, unit models.Unit, end time.Time) (*models.LiveMetrics, error) {
+		cluster, ok := clustersMap[unit.ClusterID]
+		if !ok || len(cluster.Updaters) == 0 {
+			return nil, errLiveUnavailable
+		}
+
+		metrics, err := unitUpdater.Live(ctx, end, cluster, unit)
+
+		// Start of window is the timestamp of oldest sample
+		from := end.UnixMilli()
+
+		for _, subMetrics := range metrics {
+			for _, samples := range subMetrics {
+				if len(samples) > 0 && samples[0].Timestamp < from {
+					from = samples[0].Timestamp
+				}
+			}
+		}
+
+		return &models.LiveMetrics{
+			ClusterID: unit.ClusterID,
+			UUID:      unit.UUID,
+			From:      from,
+			To:        end.UnixMilli(),
+			Metrics:   metrics,
+		}, err
+	}, nil
+}
+
+// liveUnit         godoc
+//
+//	@Summary		Live metrics of a running compute unit
+//	@Description	This endpoint returns the time series of metrics of a running compute unit
+//	@Description	over a short recent window directly from TSDB. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Only the owner of the compute unit can fetch its live metrics and the
+//	@Description	query parameter `cluster_id` is mandatory. Clients are expected to poll
+//	@Description	this endpoint to refresh the metrics. The length of window and the
+//	@Description	metrics are configured by `live_window` and `live_queries` of TSDB
+//	@Description	updater.
+//	@Description
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			uuid			path		string	true	"Unit UUID"
+//	@Param			cluster_id		query		string	true	"Cluster ID"
+//	@Success		200				{object}	Response[models.LiveMetrics]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		404				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/units/{uuid}/live [get]
+//
+// GET /units/{uuid}/live
+// Get live metrics of a running unit.
+func (s *CEEMSServer) liveUnit(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "live unit endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Live metrics must never be cached by clients
+	w.Header().Set("Cache-Control", "no-store")
+
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Get UUID from path
+	uuid := mux.Vars(r)["uuid"]
+
+	// Get cluster ID. It is mandatory as UUIDs are only unique within a cluster
+	clusterID := r.URL.Query().Get("cluster_id")
+	if clusterID == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingClusterID}, s.logger)
+
+		return
+	}
+
+	if s.liveMetrics == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errLiveUnavailable}, s.logger)
+
+		return
+	}
+
+	// Only units owned by current user are considered
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE ignore = 0", strings.Join(base.UnitsDBTableColNames, ","), base.UnitsDBTableName))
+	q.query(" AND cluster_id = ")
+	q.param([]string{clusterID})
+	q.query(" AND uuid = ")
+	q.param([]string{uuid})
+	q.query(" AND username = ")
+	q.param([]string{loggedUser})
+
+	units, err := s.queriers.unit(r.Context(), s.db, q, s.logger)
+	if err != nil {
+		s.logger.Error("Failed to fetch unit", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if len(units) == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errUnitNotFound}, s.logger)
+
+		return
+	}
+
+	if units[0].EndedAtTS > 0 {
+		errorResponse(w, r, &apiError{errorBadRequest, errUnitNotRunning}, s.logger)
+
+		return
+	}
+
+	// Fetch live metrics from TSDB
+	liveMetrics, err := s.liveMetrics(r.Context(), units[0], time.Now())
+	if liveMetrics == nil {
+		s.logger.Error("Failed to fetch live metrics", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorUnavailable, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	liveResponse := Response[models.LiveMetrics]{
+		Status: "success",
+		Data:   []models.LiveMetrics{*liveMetrics},
+	}
+	if err != nil {
+		liveResponse.Warnings = append(liveResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&liveResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
@@ -0,0 +1,402 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+)
+
+// Maintenance operations on DB.
+const (
+	maintenanceVacuum    = "vacuum"
+	maintenanceBackup    = "backup"
+	maintenanceIntegrity = "integrity_check"
+	maintenancePurge     = "purge"
+	maintenanceRepair    = "repair"
+)
+
+// Status of maintenance tasks.
+const (
+	maintenanceRunning   = "running"
+	maintenanceCompleted = "completed"
+	maintenanceFailed    = "failed"
+)
+
+// Maximum number of maintenance tasks kept in memory.
+const maxMaintenanceTasks = 50
+
+// Maintainer performs maintenance operations on CEEMS DB.
+type Maintainer interface {
+	Vacuum(ctx context.Context) error
+	Backup(ctx context.Context) error
+	CheckIntegrity(ctx context.Context) error
+	Purge(ctx context.Context) error
+	Repair(ctx context.Context) error
+	PurgeReport(ctx context.Context) ([]db.PurgeReport, error)
+}
+
+// BackupReporter reports the status of scheduled backups of CEEMS DB to backup targets.
+type BackupReporter interface {
+	BackupStatus() *db.BackupStatus
+}
+
+// MaintenanceTask is a maintenance operation on DB triggered by an admin user.
+type MaintenanceTask struct {
+	ID          int64  `json:"id"`                 // ID of the task
+	Operation   string `json:"operation"`          // One of vacuum, backup, integrity_check, purge and repair
+	Status      string `json:"status"`             // One of running, completed and failed
+	TriggeredBy string `json:"triggered_by"`       // Admin user who triggered the task
+	StartedAt   string `json:"started_at"`         // Start time of the task
+	EndedAt     string `json:"ended_at,omitempty"` // End time of the task. Empty when task is running
+	Error       string `json:"error,omitempty"`    // Error returned by the task when it failed
+}
+
+// maintenance runs maintenance tasks on DB in background and keeps track of
+// their status. Only one task is run at a time.
+type maintenance struct {
+	logger     *slog.Logger
+	maintainer Maintainer
+	location   *time.Location
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+	mu         sync.RWMutex
+	lastID     int64
+	tasks      []MaintenanceTask
+}
+
+// newMaintenance returns a new instance of maintenance. A nil maintenance is
+// returned when maintainer is nil.
+func newMaintenance(maintainer Maintainer, location *time.Location, logger *slog.Logger) *maintenance {
+	if maintainer == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &maintenance{
+		logger:     logger,
+		maintainer: maintainer,
+		location:   location,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// start starts the maintenance operation in background and returns the task.
+func (m *maintenance) start(operation string, user string) (MaintenanceTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.tasks, func(t MaintenanceTask) bool { return t.Status == maintenanceRunning }) {
+		return MaintenanceTask{}, errMaintenanceRunning
+	}
+
+	m.lastID++
+
+	task := MaintenanceTask{
+		ID:          m.lastID,
+		Operation:   operation,
+		Status:      maintenanceRunning,
+		TriggeredBy: user,
+		StartedAt:   time.Now().In(m.location).Format(base.DatetimezoneLayout),
+	}
+
+	// Forget oldest tasks
+	m.tasks = append(m.tasks, task)
+	if len(m.tasks) > maxMaintenanceTasks {
+		m.tasks = m.tasks[len(m.tasks)-maxMaintenanceTasks:]
+	}
+
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		m.logger.Info("Starting DB maintenance", "id", task.ID, "operation", operation, "triggered_by", user)
+
+		err := m.run(operation)
+
+		m.finish(task.ID, err)
+	}()
+
+	return task, nil
+}
+
+// run executes the maintenance operation.
+func (m *maintenance) run(operation string) error {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "DB maintenance "+operation, m.logger)
+
+	switch operation {
+	case maintenanceVacuum:
+		return m.maintainer.Vacuum(m.ctx)
+	case maintenanceBackup:
+		return m.maintainer.Backup(m.ctx)
+	case maintenanceIntegrity:
+		return m.maintainer.CheckIntegrity(m.ctx)
+	case maintenancePurge:
+		return m.maintainer.Purge(m.ctx)
+	case maintenanceRepair:
+		return m.maintainer.Repair(m.ctx)
+	default:
+		return errInvalidRequest
+	}
+}
+
+// finish updates the status of task with ID id.
+func (m *maintenance) finish(id int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := slices.IndexFunc(m.tasks, func(t MaintenanceTask) bool { return t.ID == id })
+	if idx < 0 {
+		return
+	}
+
+	m.tasks[idx].EndedAt = time.Now().In(m.location).Format(base.DatetimezoneLayout)
+
+	if err != nil {
+		m.logger.Error("DB maintenance failed", "id", id, "operation", m.tasks[idx].Operation, "err", err)
+
+		m.tasks[idx].Status = maintenanceFailed
+		m.tasks[idx].Error = err.Error()
+
+		return
+	}
+
+	m.logger.Info("DB maintenance finished", "id", id, "operation", m.tasks[idx].Operation)
+
+	m.tasks[idx].Status = maintenanceCompleted
+}
+
+// list returns the tasks with IDs ids, most recent first. If ids is empty,
+// all tasks are returned.
+func (m *maintenance) list(ids []int64) []MaintenanceTask {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks := make([]MaintenanceTask, 0, len(m.tasks))
+
+	for i := len(m.tasks) - 1; i >= 0; i-- {
+		if len(ids) == 0 || slices.Contains(ids, m.tasks[i].ID) {
+			tasks = append(tasks, m.tasks[i])
+		}
+	}
+
+	return tasks
+}
+
+// stop cancels the running tasks and waits for them to return.
+func (m *maintenance) stop() {
+	if m == nil {
+		return
+	}
+
+	m.cancel()
+	m.wg.Wait()
+}
+
+// maintenanceAdmin         godoc
+//
+//	@Summary		Admin endpoint to list DB maintenance tasks
+//	@Description	This admin endpoint will list the status of DB maintenance tasks, most
+//	@Description	recent first. The current user is always identified by the header `X-Grafana-User`
+//	@Description	in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Specific tasks can be queried using `id` query
+//	@Description	parameter. Only the latest 50 tasks are kept in memory and they are lost
+//	@Description	on server restart.
+//	@Security		BasicAuth
+//	@Tags			maintenance
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			id				query		[]int	false	"Task ID"	collectionFormat(multi)
+//	@Success		200				{object}	Response[MaintenanceTask]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/maintenance/admin [get]
+//
+// GET /maintenance/admin
+// List DB maintenance tasks.
+func (s *CEEMSServer) maintenanceAdmin(w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	if s.maintenance == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errMaintenanceUnavailable}, s.logger)
+
+		return
+	}
+
+	var ids []int64
+
+	for _, v := range r.URL.Query()["id"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
+
+			return
+		}
+
+		ids = append(ids, id)
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[MaintenanceTask]{
+		Status: "success",
+		Data:   s.maintenance.list(ids),
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// startMaintenanceAdmin         godoc
+//
+//	@Summary		Admin endpoint to trigger DB maintenance tasks
+//	@Description	This admin endpoint will trigger a maintenance operation on DB without
+//	@Description	having to stop the server. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request and it will be recorded as the user who triggered
+//	@Description	the task.
+//	@Description
+//	@Description	The operation can be one of `vacuum`, `backup`, `integrity_check`, `purge` and `repair`.
+//	@Description	`backup` creates an online backup of DB in the configured backup path and
+//	@Description	`purge` deletes the entries that are older than the configured retention period.
+//	@Description	`repair` rebuilds the indexes of DB and when DB is still corrupt, it is restored
+//	@Description	from the latest valid backup in the configured backup path.
+//	@Description	Operations run in background and the created task is returned in the
+//	@Description	response whose status can be followed using `/maintenance/admin` endpoint.
+//	@Description	Only one task can be run at a time.
+//	@Security		BasicAuth
+//	@Tags			maintenance
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			operation		path		string	true	"Maintenance operation"	Enums(vacuum, backup, integrity_check, purge, repair)
+//	@Success		202				{object}	Response[MaintenanceTask]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		409				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/maintenance/{operation}/admin [post]
+//
+// POST /maintenance/{operation}/admin
+// Trigger DB maintenance task.
+func (s *CEEMSServer) startMaintenanceAdmin(w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	if s.maintenance == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errMaintenanceUnavailable}, s.logger)
+
+		return
+	}
+
+	operation := mux.Vars(r)["operation"]
+
+	// Backups can only be made when backup path is configured
+	if operation == maintenanceBackup && s.dbConfig.Data.BackupPath == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errNoBackupPath}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+
+	task, err := s.maintenance.start(operation, loggedUser)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorConflict, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusAccepted)
+
+	response := Response[MaintenanceTask]{
+		Status: "success",
+		Data:   []MaintenanceTask{task},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// purgeReportAdmin         godoc
+//
+//	@Summary		Admin endpoint to report entries that will be purged
+//	@Description	This admin endpoint will report the number of rows and approximate bytes per
+//	@Description	table and per month that will be deleted when expired entries are purged from
+//	@Description	DB, without deleting them. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. It is advised to check the report before triggering
+//	@Description	`purge` operation or reducing retention period as deleted data cannot be recovered.
+//	@Security		BasicAuth
+//	@Tags			maintenance
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Success		200				{object}	Response[db.PurgeReport]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/maintenance/purge/admin [get]
+//
+// GET /maintenance/purge/admin
+// Report entries that will be purged.
+func (s *CEEMSServer) purgeReportAdmin(w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	if s.maintenance == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errMaintenanceUnavailable}, s.logger)
+
+		return
+	}
+
+	reports, err := s.maintenance.maintainer.PurgeReport(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to report expired entries", "err", err)
+		errorResponse(w, r, &apiError{errorInternal, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[db.PurgeReport]{
+		Status: "success",
+		Data:   reports,
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
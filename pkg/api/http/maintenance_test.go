@@ -0,0 +1,168 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockIntegrity = errors.New("database disk image is malformed")
+
+type mockMaintainer struct {
+	release chan struct{}
+}
+
+func (m *mockMaintainer) Vacuum(ctx context.Context) error {
+	select {
+	case <-m.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *mockMaintainer) Backup(_ context.Context) error {
+	return nil
+}
+
+func (m *mockMaintainer) CheckIntegrity(_ context.Context) error {
+	return errMockIntegrity
+}
+
+func (m *mockMaintainer) Purge(_ context.Context) error {
+	return nil
+}
+
+func (m *mockMaintainer) Repair(_ context.Context) error {
+	return nil
+}
+
+func (m *mockMaintainer) PurgeReport(_ context.Context) ([]db.PurgeReport, error) {
+	return []db.PurgeReport{{Table: "units", Month: "2024-01", Rows: 10, Bytes: 1000}}, nil
+}
+
+func TestMaintenanceHandlers(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	maintainer := &mockMaintainer{release: make(chan struct{})}
+	server.maintenance = newMaintenance(maintainer, time.UTC, noOpLogger)
+
+	start := func(operation string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/maintenance/"+operation+"/admin", nil)
+		req.Header.Set(loggedUserHeader, "adm1")
+		req = mux.SetURLVars(req, map[string]string{"operation": operation})
+
+		w := httptest.NewRecorder()
+		server.startMaintenanceAdmin(w, req)
+
+		return w
+	}
+
+	list := func(query string) []MaintenanceTask {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/maintenance/admin"+query, nil)
+		req.Header.Set(loggedUserHeader, "adm1")
+
+		w := httptest.NewRecorder()
+		server.maintenanceAdmin(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response Response[MaintenanceTask]
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+		return response.Data
+	}
+
+	// Start vacuum which blocks until it is released
+	w := start(maintenanceVacuum)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var response Response[MaintenanceTask]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, maintenanceRunning, response.Data[0].Status)
+	assert.Equal(t, "adm1", response.Data[0].TriggeredBy)
+
+	// Starting another task while vacuum is running must be a conflict
+	w = start(maintenancePurge)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Backups need a backup path
+	w = start(maintenanceBackup)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Release vacuum and wait for it to complete
+	close(maintainer.release)
+	assert.Eventually(t, func() bool {
+		return list("?id=1")[0].Status == maintenanceCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	// Failed tasks must report their errors
+	w = start(maintenanceIntegrity)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Eventually(t, func() bool {
+		return list("?id=2")[0].Status == maintenanceFailed
+	}, time.Second, 10*time.Millisecond)
+
+	// Repair after a failed integrity check
+	w = start(maintenanceRepair)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	assert.Eventually(t, func() bool {
+		return list("?id=3")[0].Status == maintenanceCompleted
+	}, time.Second, 10*time.Millisecond)
+
+	tasks := list("")
+	require.Len(t, tasks, 3)
+	assert.Equal(t, maintenanceRepair, tasks[0].Operation)
+	assert.Equal(t, maintenanceIntegrity, tasks[1].Operation)
+	assert.Equal(t, errMockIntegrity.Error(), tasks[1].Error)
+	assert.NotEmpty(t, tasks[1].EndedAt)
+	assert.Equal(t, maintenanceVacuum, tasks[2].Operation)
+}
+
+func TestPurgeReportHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/maintenance/purge/admin", nil)
+	req.Header.Set(loggedUserHeader, "adm1")
+
+	// Without maintainer, endpoint must be unavailable
+	w := httptest.NewRecorder()
+	server.purgeReportAdmin(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	server.maintenance = newMaintenance(&mockMaintainer{}, time.UTC, noOpLogger)
+
+	w = httptest.NewRecorder()
+	server.purgeReportAdmin(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response Response[db.PurgeReport]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, []db.PurgeReport{{Table: "units", Month: "2024-01", Rows: 10, Bytes: 1000}}, response.Data)
+}
+
+func TestMaintenanceUnavailable(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/maintenance/vacuum/admin", nil)
+	req = mux.SetURLVars(req, map[string]string{"operation": maintenanceVacuum})
+
+	w := httptest.NewRecorder()
+	server.startMaintenanceAdmin(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
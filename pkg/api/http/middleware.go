@@ -12,15 +12,19 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 )
 
 // Headers.
 const (
-	grafanaUserHeader   = "X-Grafana-User"
-	dashboardUserHeader = "X-Dashboard-User"
-	loggedUserHeader    = "X-Logged-User"
-	adminUserHeader     = "X-Admin-User"
-	ceemsUserHeader     = "X-Ceems-User" // Special header that will be included in requests from CEEMS LB
+	grafanaUserHeader   = middleware.GrafanaUserHeader
+	dashboardUserHeader = middleware.DashboardUserHeader
+	loggedUserHeader    = middleware.LoggedUserHeader
+	adminUserHeader     = middleware.AdminUserHeader
+	authorizationHeader = "Authorization"
+	apiKeyHeader        = "X-Api-Key"
 )
 
 // Debug end point regex match.
@@ -28,6 +32,11 @@ var (
 	debugEndpoints = regexp.MustCompile("/debug/(.*)")
 )
 
+// groupMembership resolves membership of users in admin group.
+type groupMembership interface {
+	IsMember(ctx context.Context, user string) bool
+}
+
 // Define our struct.
 type authenticationMiddleware struct {
 	logger          *slog.Logger
@@ -35,6 +44,11 @@ type authenticationMiddleware struct {
 	whitelistedURLs *regexp.Regexp
 	db              *sql.DB
 	adminUsers      func(context.Context, *sql.DB, *slog.Logger) []string
+	apiKey          func(context.Context, *sql.DB, string, *slog.Logger) (*models.APIKey, error)
+	jwt             *middleware.JWTAuthenticator  // Nil when JWT authentication is disabled
+	oidc            *middleware.OIDCAuthenticator // Nil when OIDC authentication is disabled
+	ldap            groupMembership               // Nil when LDAP admin group is not configured
+	internalSecret  string                        // Shared secret of requests from other CEEMS components
 }
 
 // Middleware function, which will be called for each request.
@@ -46,6 +60,12 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 
 		var q url.Values
 
+		var apiKey *models.APIKey
+
+		var isAdmin, isAdminGroup bool
+
+		var err error
+
 		// If requested URI is one of the following, skip checking for user header
 		//  - Root document
 		//  - /health endpoint
@@ -64,45 +84,87 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 		}
 
 		// If request has "special" CEEMS header, pass through. It must be
-		// coming from other CEEMS components
-		if _, ok := r.Header[ceemsUserHeader]; ok {
+		// coming from other CEEMS components. Untrusted CEEMS headers are removed
+		// and request goes through the normal authentication
+		if amw.isCEEMSRequest(r) {
 			goto end
 		}
 
-		// Remove any X-Admin-User header or X-Logged-User if passed
-		r.Header.Del(adminUserHeader)
-		r.Header.Del(loggedUserHeader)
+		middleware.DelCEEMSUser(r)
+
+		// Service accounts are identified by their API keys. When JWT authentication
+		// is enabled, validate bearer token and get logged user from its claims. When
+		// OIDC authentication is enabled, exchange access token for user and group
+		// claims. Grafana user header is not trusted in all these cases
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			if apiKey, err = amw.lookupAPIKey(r, key); err != nil {
+				amw.logger.Error("Invalid API key. Denying authentication", "err", err)
+
+				// Write an error and stop the handler chain
+				errorResponse(w, r, &apiError{errorUnauthorized, errInvalidAPIKey}, amw.logger)
+
+				return
+			}
+
+			loggedUser = apiKey.User
+		} else if amw.jwt != nil {
+			if loggedUser, err = amw.jwt.LoggedUser(r); err != nil {
+				amw.logger.Error("Invalid bearer token. Denying authentication", "err", err)
+
+				// Write an error and stop the handler chain
+				errorResponse(w, r, &apiError{errorUnauthorized, err}, amw.logger)
+
+				return
+			}
+		} else if amw.oidc != nil {
+			if loggedUser, isAdminGroup, err = amw.oidc.LoggedUser(r); err != nil {
+				amw.logger.Error("Invalid access token. Denying authentication", "err", err)
+
+				// Write an error and stop the handler chain
+				errorResponse(w, r, &apiError{errorUnauthorized, err}, amw.logger)
 
-		// Check if username header is available
-		loggedUser = r.Header.Get(grafanaUserHeader)
-		if loggedUser == "" {
-			amw.logger.Error("Grafana user Header not found. Denying authentication")
+				return
+			}
+		} else {
+			// Check if username header is available and set logged user header
+			loggedUser = middleware.LoggedUser(r)
+			if loggedUser == "" {
+				amw.logger.Error("Grafana user Header not found. Denying authentication")
 
-			// Write an error and stop the handler chain
-			errorResponse[any](w, &apiError{errorUnauthorized, errNoUser}, amw.logger, nil)
+				// Write an error and stop the handler chain
+				errorResponse(w, r, &apiError{errorUnauthorized, errNoUser}, amw.logger)
 
-			return
+				return
+			}
 		}
 
 		amw.logger.Info("middleware", "loggedUser", loggedUser, "url", r.URL)
 
-		// Set logged user header
-		r.Header.Set(loggedUserHeader, loggedUser)
-
 		// Set user in URL query as well as we will use it as key for caching
 		q = r.URL.Query()
 		q.Add("logged_user", loggedUser)
 		r.URL.RawQuery = q.Encode()
 
-		// Fetch admin users from DB
-		admUsers = amw.adminUsers(r.Context(), amw.db, amw.logger)
+		// Admin privileges of service accounts are set by the role of their API
+		// keys. Users in admin groups of OIDC provider are admins as well. For the
+		// rest, fetch admin users from DB and resolve membership of LDAP admin group
+		// when configured
+		if apiKey != nil {
+			isAdmin = apiKey.Role == apiKeyAdminRole
+		} else if isAdminGroup {
+			isAdmin = true
+		} else {
+			admUsers = amw.adminUsers(r.Context(), amw.db, amw.logger)
+			isAdmin = slices.Contains(admUsers, loggedUser) ||
+				(amw.ldap != nil && amw.ldap.IsMember(r.Context(), loggedUser))
+		}
 
 		// If current user is in list of admin users, get "actual" user from
 		// X-Dashboard-User header. For normal users, this header will be exactly same
 		// as their username.
 		// For admin users who can look at dashboard of "any" user this will be the
 		// username of the "impersonated" user and we take it into account
-		if slices.Contains(admUsers, loggedUser) {
+		if isAdmin {
 			// Set X-Admin-User header
 			r.Header.Set(adminUserHeader, loggedUser)
 
@@ -120,7 +182,7 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 				amw.logger.Error("Unprivileged user accessing admin endpoint", "user", loggedUser, "url", r.URL)
 
 				// Write an error and stop the handler chain
-				errorResponse[any](w, &apiError{errorForbidden, errNoPrivs}, amw.logger, nil)
+				errorResponse(w, r, &apiError{errorForbidden, errNoPrivs}, amw.logger)
 
 				return
 			}
@@ -133,3 +195,40 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 		next.ServeHTTP(w, r)
 	})
 }
+
+// isCEEMSRequest returns true if the request is made by other CEEMS components.
+// When internal secret is configured, requests must carry it. Without it, CEEMS
+// user header is trusted only when users are identified by Grafana user header
+// and not by API keys, JWT or OIDC tokens.
+func (amw *authenticationMiddleware) isCEEMSRequest(r *http.Request) bool {
+	if amw.internalSecret == "" && (amw.jwt != nil || amw.oidc != nil || r.Header.Get(apiKeyHeader) != "") {
+		return false
+	}
+
+	return middleware.IsCEEMSRequest(r, amw.internalSecret)
+}
+
+// lookupAPIKey returns the API key of service account from DB. Any headers that
+// can only be set by CEEMS components are removed from the request and logged
+// user header is set to the user of API key.
+func (amw *authenticationMiddleware) lookupAPIKey(r *http.Request, key string) (*models.APIKey, error) {
+	// Remove any X-Admin-User header or X-Logged-User if passed
+	r.Header.Del(adminUserHeader)
+	r.Header.Del(loggedUserHeader)
+
+	if amw.apiKey == nil {
+		return nil, errInvalidAPIKey
+	}
+
+	apiKey, err := amw.apiKey(r.Context(), amw.db, hashAPIKey(key), amw.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set Grafana user header as well so that the user is identified
+	// consistently by all the handlers
+	r.Header.Set(grafanaUserHeader, apiKey.User)
+	r.Header.Set(loggedUserHeader, apiKey.User)
+
+	return apiKey, nil
+}
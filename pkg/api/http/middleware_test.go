@@ -10,10 +10,16 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func mockAdminUsers(_ context.Context, _ *sql.DB, _ *slog.Logger) []string {
@@ -139,3 +145,188 @@ func TestMiddlewareAdminFailurePresetHeader(t *testing.T) {
 	// Should not contain adminHeader
 	assert.Equal(t, "", req.Header.Get(adminUserHeader))
 }
+
+func TestMiddlewareJWT(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("supersecret"), 0o600))
+
+	jwtAuth, err := middleware.NewJWTAuthenticator(middleware.JWTConfig{SecretFile: secretFile})
+	require.NoError(t, err)
+
+	// Create an instance of middleware with JWT authentication
+	amw := authenticationMiddleware{
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+		jwt:             jwtAuth,
+	}
+	handlerToTest := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Grafana user header must not be trusted
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set(grafanaUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// User must be identified from token
+	token, err := jwt.NewWithClaims(
+		jwt.SigningMethodHS256, jwt.MapClaims{"sub": "usr2", "exp": time.Now().Add(time.Hour).Unix()},
+	).SignedString([]byte("supersecret"))
+	require.NoError(t, err)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set(grafanaUserHeader, "usr1")
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "usr2", req.Header.Get(loggedUserHeader))
+	assert.Equal(t, "usr2", req.Header.Get(dashboardUserHeader))
+
+	// CEEMS user header must not bypass authentication on admin end points
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units/admin", nil)
+	req.Header.Set(middleware.CEEMSUserHeader, "admin")
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+	assert.Empty(t, req.Header.Get(middleware.CEEMSUserHeader))
+}
+
+func TestMiddlewareOIDC(t *testing.T) {
+	// Mock OIDC provider
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			w.Write([]byte(`{"userinfo_endpoint": "` + server.URL + `/userinfo"}`))
+		case "/userinfo":
+			if r.Header.Get(authorizationHeader) != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			w.Write([]byte(`{"preferred_username": "usr2", "groups": ["admins"]}`))
+		}
+	}))
+	defer server.Close()
+
+	oidcAuth, err := middleware.NewOIDCAuthenticator(
+		middleware.OIDCConfig{IssuerURL: server.URL, AdminGroups: []string{"admins"}},
+	)
+	require.NoError(t, err)
+
+	// Create an instance of middleware with OIDC authentication
+	amw := authenticationMiddleware{
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+		oidc:            oidcAuth,
+	}
+	handlerToTest := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// Grafana user header must not be trusted
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set(grafanaUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// Invalid access tokens must be denied
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set(authorizationHeader, "Bearer foo")
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// Users in admin groups must be able to access admin end points
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units/admin", nil)
+	req.Header.Set(authorizationHeader, "Bearer token")
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "usr2", req.Header.Get(loggedUserHeader))
+	assert.Equal(t, "usr2", req.Header.Get(adminUserHeader))
+
+	// CEEMS user header must not bypass authentication on admin end points
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units/admin", nil)
+	req.Header.Set(middleware.CEEMSUserHeader, "admin")
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+}
+
+func TestMiddlewareCEEMSRequest(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("supersecret"), 0o600))
+
+	jwtAuth, err := middleware.NewJWTAuthenticator(middleware.JWTConfig{SecretFile: secretFile})
+	require.NoError(t, err)
+
+	// Create an instance of middleware with JWT authentication and internal secret
+	amw := authenticationMiddleware{
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+		jwt:             jwtAuth,
+		internalSecret:  "internal",
+	}
+	handlerToTest := amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		secret string
+		code   int
+	}{
+		{name: "without internal secret", code: 401},
+		{name: "with wrong internal secret", secret: "foo", code: 401},
+		{name: "with internal secret", secret: "internal", code: 200},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clusters/admin", nil)
+		req.Header.Set(middleware.CEEMSUserHeader, "admin")
+
+		if test.secret != "" {
+			req.Header.Set(middleware.CEEMSTokenHeader, test.secret)
+		}
+
+		w := httptest.NewRecorder()
+		handlerToTest.ServeHTTP(w, req)
+		assert.Equal(t, test.code, w.Code, test.name)
+	}
+
+	// Requests with API keys must not be able to use CEEMS user header when
+	// internal secret is not configured
+	amw = authenticationMiddleware{
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		whitelistedURLs: regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)"),
+		adminUsers:      mockAdminUsers,
+	}
+	handlerToTest = amw.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clusters/admin", nil)
+	req.Header.Set(middleware.CEEMSUserHeader, "admin")
+	req.Header.Set(apiKeyHeader, "foo")
+
+	w := httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// Without any token authentication, CEEMS user header is trusted
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/clusters/admin", nil)
+	req.Header.Set(middleware.CEEMSUserHeader, "admin")
+
+	w = httptest.NewRecorder()
+	handlerToTest.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+}
@@ -0,0 +1,434 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/marcboeker/go-duckdb"
+)
+
+// Names of DuckDB mirror of units table and its staging tables.
+const (
+	analyticsMirrorName = "ceems_analytics.duckdb"
+	mirrorChangesTable  = "units_changes"
+	mirrorKeysTable     = "units_keys"
+)
+
+// Columns that identify a unit uniquely across units table and its partitions.
+const mirrorKeyColumns = "cluster_id, uuid, started_at_ts"
+
+// Default interval at which mirror is refreshed.
+const defaultMirrorRefreshInterval = 5 * time.Minute
+
+// mirrorColumn is a column of mirrored table.
+type mirrorColumn struct {
+	name string
+	typ  string
+}
+
+// analyticsMirror mirrors units table of SQLite DB along with its monthly
+// partitions into a DuckDB DB file on which analytical queries are made. Units
+// that are updated since the last refresh are copied incrementally using
+// last_updated_at column and units that are removed from SQLite DB, for instance,
+// by retention, are removed from mirror when mirror has more units than SQLite DB.
+// Units are identified by their cluster ID, UUID and start time as IDs are not
+// unique across partitions.
+type analyticsMirror struct {
+	logger   *slog.Logger
+	src      *sql.DB // SQLite DB
+	dst      *sql.DB // DuckDB DB
+	interval time.Duration
+	columns  []mirrorColumn
+	mu       sync.Mutex
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newAnalyticsMirror returns a new analyticsMirror. Mirror is recreated when the
+// columns of units table in SQLite DB have changed, for instance, after migrations.
+func newAnalyticsMirror(ctx context.Context, src, dst *sql.DB, interval time.Duration, logger *slog.Logger) (*analyticsMirror, error) {
+	m := &analyticsMirror{
+		logger:   logger,
+		src:      src,
+		dst:      dst,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+
+	if err := m.setup(ctx); err != nil {
+		return nil, fmt.Errorf("failed to setup analytics mirror: %w", err)
+	}
+
+	return m, nil
+}
+
+// duckdbType returns the DuckDB type of SQLite column type. SQLite columns
+// do not enforce their types and values that do not match type are converted
+// by mirrorValue.
+func duckdbType(sqliteType string) string {
+	switch t := strings.ToLower(sqliteType); {
+	case strings.Contains(t, "int"):
+		return "BIGINT"
+	case strings.Contains(t, "real"), strings.Contains(t, "floa"), strings.Contains(t, "doub"):
+		return "DOUBLE"
+	default:
+		return "VARCHAR"
+	}
+}
+
+// mirrorValue converts value scanned from SQLite to the Go type of DuckDB type
+// as appender does not convert values.
+func mirrorValue(value any, typ string) any {
+	switch typ {
+	case "BIGINT":
+		switch v := value.(type) {
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		}
+
+		return nil
+	case "DOUBLE":
+		switch v := value.(type) {
+		case int64:
+			return float64(v)
+		case float64:
+			return v
+		}
+
+		return nil
+	default:
+		switch v := value.(type) {
+		case nil:
+			return nil
+		case string:
+			return v
+		case []byte:
+			return string(v)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+}
+
+// setup creates mirror and staging tables with the columns of units table in
+// SQLite DB. Existing mirror is dropped when its columns are different.
+func (m *analyticsMirror) setup(ctx context.Context) error {
+	rows, err := m.src.QueryContext(ctx, "SELECT name, type FROM pragma_table_info(?) ORDER BY cid", base.UnitsDBTableName)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			rows.Close()
+
+			return err
+		}
+
+		m.columns = append(m.columns, mirrorColumn{name, duckdbType(typ)})
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(m.columns) == 0 {
+		return fmt.Errorf("%s table not found", base.UnitsDBTableName)
+	}
+
+	if rows, err = m.dst.QueryContext(
+		ctx,
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_name = ? ORDER BY ordinal_position",
+		base.UnitsDBTableName,
+	); err != nil {
+		return err
+	}
+
+	var existing []mirrorColumn
+
+	for rows.Next() {
+		var c mirrorColumn
+		if err := rows.Scan(&c.name, &c.typ); err != nil {
+			rows.Close()
+
+			return err
+		}
+
+		existing = append(existing, c)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	defs := make([]string, len(m.columns))
+	for i, c := range m.columns {
+		defs[i] = fmt.Sprintf("%q %s", c.name, c.typ)
+	}
+
+	var stmts []string
+
+	if !slices.Equal(existing, m.columns) {
+		m.logger.Info("Creating analytics mirror of units table")
+
+		stmts = append(stmts, fmt.Sprintf("CREATE OR REPLACE TABLE %s (%s)", base.UnitsDBTableName, strings.Join(defs, ",")))
+	}
+
+	stmts = append(
+		stmts,
+		fmt.Sprintf("CREATE OR REPLACE TABLE %s (%s)", mirrorChangesTable, strings.Join(defs, ",")),
+		fmt.Sprintf("CREATE OR REPLACE TABLE %s (cluster_id VARCHAR, uuid VARCHAR, started_at_ts BIGINT)", mirrorKeysTable),
+	)
+
+	for _, stmt := range stmts {
+		if _, err := m.dst.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendRows appends rows to table of DuckDB using an appender on conn.
+func appendRows(ctx context.Context, conn *sql.Conn, table string, rows *sql.Rows, types []string) (int, error) {
+	numRows := 0
+
+	err := conn.Raw(func(driverConn any) error {
+		dc, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+
+		appender, err := duckdb.NewAppenderFromConn(dc, "", table)
+		if err != nil {
+			return err
+		}
+
+		values := make([]any, len(types))
+		dest := make([]any, len(types))
+
+		for i := range values {
+			dest[i] = &values[i]
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(dest...); err != nil {
+				appender.Close()
+
+				return err
+			}
+
+			row := make([]driver.Value, len(types))
+			for i, v := range values {
+				row[i] = mirrorValue(v, types[i])
+			}
+
+			if err := appender.AppendRow(row...); err != nil {
+				appender.Close()
+
+				return err
+			}
+
+			numRows++
+		}
+
+		if err := rows.Err(); err != nil {
+			appender.Close()
+
+			return err
+		}
+
+		// Closing appender flushes the appended rows
+		return appender.Close()
+	})
+
+	return numRows, err
+}
+
+// refresh copies units updated since the last refresh to mirror and removes
+// the units that do not exist anymore in SQLite DB. Units are read from units
+// table and its monthly partitions.
+func (m *analyticsMirror) refresh(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "analytics mirror refresh", m.logger)
+
+	conn, err := m.dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Units updated at watermark are copied again as more units might have been
+	// updated at the same time after last refresh
+	var watermark string
+	if err := conn.QueryRowContext(
+		ctx, fmt.Sprintf("SELECT COALESCE(MAX(last_updated_at), '') FROM %s", base.UnitsDBTableName),
+	).Scan(&watermark); err != nil {
+		return err
+	}
+
+	names := make([]string, len(m.columns))
+	types := make([]string, len(m.columns))
+
+	for i, c := range m.columns {
+		names[i] = fmt.Sprintf("%q", c.name)
+		types[i] = c.typ
+	}
+
+	source, err := UnitsSource(ctx, m.src, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	rows, err := m.src.QueryContext(
+		ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE last_updated_at >= ?", strings.Join(names, ","), source), //nolint:gosec
+		watermark,
+	)
+	if err != nil {
+		return err
+	}
+
+	numChanges, err := appendRows(ctx, conn, mirrorChangesTable, rows, types)
+	rows.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to copy updated units: %w", err)
+	}
+
+	// Updated units replace the existing ones in mirror. A unit that is being moved
+	// to a partition can be found in both units table and partition and only its
+	// most recent update is kept
+	if err := execInTx(ctx, conn, []string{
+		fmt.Sprintf(
+			"DELETE FROM %[1]s WHERE (%[3]s) IN (SELECT (%[3]s) FROM %[2]s)",
+			base.UnitsDBTableName, mirrorChangesTable, mirrorKeyColumns,
+		),
+		fmt.Sprintf(
+			"INSERT INTO %[1]s SELECT DISTINCT ON (%[3]s) * FROM %[2]s ORDER BY %[3]s, last_updated_at DESC",
+			base.UnitsDBTableName, mirrorChangesTable, mirrorKeyColumns,
+		),
+		"DELETE FROM " + mirrorChangesTable,
+	}); err != nil {
+		return fmt.Errorf("failed to update mirror: %w", err)
+	}
+
+	// As mirror has all the units of SQLite DB after update, units have been
+	// removed from SQLite DB only when mirror has more units
+	var numSrc, numDst int64
+	if err := m.src.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+source).Scan(&numSrc); err != nil { //nolint:gosec
+		return err
+	}
+
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+base.UnitsDBTableName).Scan(&numDst); err != nil {
+		return err
+	}
+
+	m.logger.Debug("Analytics mirror refreshed", "updated_units", numChanges, "units", numDst)
+
+	if numDst <= numSrc {
+		return nil
+	}
+
+	if rows, err = m.src.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s", mirrorKeyColumns, source)); err != nil { //nolint:gosec
+		return err
+	}
+
+	_, err = appendRows(ctx, conn, mirrorKeysTable, rows, []string{"VARCHAR", "VARCHAR", "BIGINT"})
+	rows.Close()
+
+	if err != nil {
+		return fmt.Errorf("failed to copy keys of units: %w", err)
+	}
+
+	if err := execInTx(ctx, conn, []string{
+		fmt.Sprintf(
+			"DELETE FROM %[1]s WHERE (%[3]s) NOT IN (SELECT (%[3]s) FROM %[2]s)",
+			base.UnitsDBTableName, mirrorKeysTable, mirrorKeyColumns,
+		),
+		"DELETE FROM " + mirrorKeysTable,
+	}); err != nil {
+		return fmt.Errorf("failed to remove units from mirror: %w", err)
+	}
+
+	return nil
+}
+
+// execInTx executes stmts in a single transaction on conn.
+func execInTx(ctx context.Context, conn *sql.Conn, stmts []string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// start refreshes mirror immediately and then periodically until it is stopped.
+func (m *analyticsMirror) start() {
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// Stop an ongoing refresh when mirror is stopped
+		go func() {
+			<-m.done
+			cancel()
+		}()
+
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := m.refresh(ctx); err != nil && ctx.Err() == nil {
+				m.logger.Error("Failed to refresh analytics mirror", "err", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop stops refreshing mirror and waits for ongoing refresh to finish.
+func (m *analyticsMirror) stop() {
+	close(m.done)
+	m.wg.Wait()
+}
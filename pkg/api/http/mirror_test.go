@@ -0,0 +1,125 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsMirror(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	src, err := sql.Open("sqlite3", filepath.Join(dir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer src.Close()
+
+	_, err = src.Exec(`
+CREATE TABLE units (
+	"id" integer not null primary key,
+	"cluster_id" text,
+	"uuid" text,
+	"started_at_ts" integer,
+	"project" text,
+	"elapsed" integer,
+	"last_updated_at" text
+);
+INSERT INTO units VALUES(1, 'slurm-0', '1000', 1, 'prj1', 10, '2024-01-01T00:00:00');
+INSERT INTO units VALUES(2, 'slurm-0', '1001', 2, 'prj1', 20, '2024-01-01T00:00:00');
+INSERT INTO units VALUES(3, 'slurm-0', '1002', 3, 'prj2', 'NA', '2024-01-01T00:00:00');`)
+	require.NoError(t, err)
+
+	dst, err := openAnalyticsDB(dir, AnalyticsConfig{Engine: "duckdb", Source: "mirror"})
+	require.NoError(t, err)
+
+	defer dst.Close()
+
+	m, err := newAnalyticsMirror(ctx, src, dst, time.Minute, logger)
+	require.NoError(t, err)
+
+	// sums returns total elapsed of projects in mirror
+	sums := func() map[string]int64 {
+		rows, err := dst.Query("SELECT project, SUM(elapsed) FROM units GROUP BY project")
+		require.NoError(t, err)
+
+		defer rows.Close()
+
+		sums := make(map[string]int64)
+
+		for rows.Next() {
+			var project string
+
+			var sum sql.NullInt64
+			require.NoError(t, rows.Scan(&project, &sum))
+
+			sums[project] = sum.Int64
+		}
+
+		return sums
+	}
+
+	// Values that do not match column type must be dropped
+	require.NoError(t, m.refresh(ctx))
+	assert.Equal(t, map[string]int64{"prj1": 30, "prj2": 0}, sums())
+
+	// Updated, new and removed units must be mirrored
+	_, err = src.Exec(`
+UPDATE units SET elapsed = 40, last_updated_at = '2024-01-02T00:00:00' WHERE id = 2;
+INSERT INTO units VALUES(4, 'slurm-0', '1003', 4, 'prj2', 50, '2024-01-02T00:00:00');
+DELETE FROM units WHERE id IN (1, 3);`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.refresh(ctx))
+	assert.Equal(t, map[string]int64{"prj1": 40, "prj2": 50}, sums())
+
+	// Units moved to monthly partitions must be kept in mirror. Partitioned
+	// unit can have the same ID as a new unit in units table
+	_, err = src.Exec(`
+CREATE TABLE units_2024_01 AS SELECT * FROM units WHERE id = 2;
+DELETE FROM units WHERE id = 2;
+INSERT INTO units VALUES(2, 'slurm-1', '1001', 5, 'prj3', 60, '2024-01-03T00:00:00');`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.refresh(ctx))
+	assert.Equal(t, map[string]int64{"prj1": 40, "prj2": 50, "prj3": 60}, sums())
+
+	// Units removed from partitions must be removed from mirror as well
+	_, err = src.Exec(`DELETE FROM units_2024_01`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.refresh(ctx))
+	assert.Equal(t, map[string]int64{"prj2": 50, "prj3": 60}, sums())
+
+	// Mirror must be kept when columns have not changed
+	m, err = newAnalyticsMirror(ctx, src, dst, time.Minute, logger)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"prj2": 50, "prj3": 60}, sums())
+
+	// Mirror must be recreated when columns have changed
+	_, err = src.Exec(`ALTER TABLE units ADD COLUMN "state" text; ALTER TABLE units_2024_01 ADD COLUMN "state" text`)
+	require.NoError(t, err)
+
+	m, err = newAnalyticsMirror(ctx, src, dst, time.Minute, logger)
+	require.NoError(t, err)
+	assert.Empty(t, sums())
+
+	// Mirror must be refreshed in background until it is stopped
+	m.start()
+	defer m.stop()
+
+	assert.Eventually(t, func() bool { return len(sums()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, map[string]int64{"prj2": 50, "prj3": 60}, sums())
+}
@@ -0,0 +1,84 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	ndjsonContentType = "application/x-ndjson"
+	ndjsonFlushRows   = 1000 // Number of rows after which NDJSON response is flushed to client
+)
+
+// ndjsonRequested returns true if client requested newline delimited JSON
+// response either using `format=ndjson` query parameter or
+// `Accept: application/x-ndjson` header.
+func ndjsonRequested(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "ndjson") {
+		return true
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == ndjsonContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ndjsonWriter writes values as newline delimited JSON and flushes them
+// periodically to the client.
+type ndjsonWriter struct {
+	rc       *http.ResponseController
+	encoder  *json.Encoder
+	instance string
+	rows     int
+}
+
+// newNDJSONWriter sets the response headers and returns a new instance of ndjsonWriter.
+func newNDJSONWriter(w http.ResponseWriter, r *http.Request) *ndjsonWriter {
+	w.Header().Set("Content-Type", ndjsonContentType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	return &ndjsonWriter{
+		rc:       http.NewResponseController(w),
+		encoder:  json.NewEncoder(w),
+		instance: r.URL.Path,
+	}
+}
+
+// Write encodes v as a single line.
+func (n *ndjsonWriter) Write(v any) error {
+	if err := n.encoder.Encode(v); err != nil {
+		return err
+	}
+
+	n.rows++
+
+	// Flush periodically to stream response to client
+	if n.rows%ndjsonFlushRows == 0 {
+		n.rc.Flush() //nolint:errcheck
+	}
+
+	return nil
+}
+
+// Close writes the error, if any, as problem details in the last line and flushes
+// the remaining rows to client.
+func (n *ndjsonWriter) Close(err error) error {
+	if err != nil {
+		if encErr := n.encoder.Encode(newProblem(&apiError{errorDB, err}, n.instance)); encErr != nil {
+			return encErr
+		}
+	}
+
+	n.rc.Flush() //nolint:errcheck
+
+	return nil
+}
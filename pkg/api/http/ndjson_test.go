@@ -0,0 +1,53 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONRequested(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		accept   string
+		expected bool
+	}{
+		{name: "json by default", url: "/units", expected: false},
+		{name: "format query param", url: "/units?format=ndjson", expected: true},
+		{name: "accept header", url: "/units", accept: "application/json, application/x-ndjson", expected: true},
+		{name: "csv format", url: "/units?format=csv", expected: false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		if test.accept != "" {
+			req.Header.Set("Accept", test.accept)
+		}
+
+		assert.Equal(t, test.expected, ndjsonRequested(req), test.name)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	writer := newNDJSONWriter(w, httptest.NewRequest(http.MethodGet, "/api/v1/units", nil))
+	require.NoError(t, writer.Write(map[string]string{"uuid": "1000"}))
+	require.NoError(t, writer.Write(map[string]string{"uuid": "1001"}))
+	require.NoError(t, writer.Close(errors.New("failed to scan 1 rows")))
+
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(
+		t,
+		`{"uuid":"1000"}`+"\n"+`{"uuid":"1001"}`+"\n"+`{"type":"`+problemTypeBaseURL+`db_error","title":"Database Error","status":500,"detail":"failed to scan 1 rows","instance":"/api/v1/units","code":"db_error"}`+"\n",
+		w.Body.String(),
+	)
+}
@@ -0,0 +1,362 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+// Kinds of anomalies reported on nodes.
+const (
+	unitFailureAnomaly = "unit_failure"
+	preemptionAnomaly  = "preemption"
+	annotationAnomaly  = "annotation"
+)
+
+// failedUnitStates are the states of units that are flagged as anomalies
+// in node reports.
+var failedUnitStates = []string{
+	"FAILED", "NODE_FAIL", "OUT_OF_MEMORY", "BOOT_FAIL", "TIMEOUT", "DEADLINE",
+}
+
+// nodeSeriesFetcher returns time series of metrics of a node in a given cluster
+// between start and end times.
+type nodeSeriesFetcher func(
+	ctx context.Context, clusterID string, hostname string, start time.Time, end time.Time,
+) (map[string]map[string][]models.Sample, error)
+
+// newNodeSeriesFetcher returns a fetcher that queries the updaters configured
+// for the cluster of the node.
+func newNodeSeriesFetcher(c db.Config, logger *slog.Logger) (nodeSeriesFetcher, error) {
+	if c.Updater == nil {
+		return nil, errNodeSeriesUnavailable
+	}
+
+	clusters, err := resource.Clusters()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clusters config: %w", err)
+	}
+
+	unitUpdater, err := c.Updater(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup updaters: %w", err)
+	}
+
+	clustersMap := make(map[string]models.Cluster, len(clusters))
+	for _, cluster := range clusters {
+		clustersMap[cluster.ID] = cluster
+	}
+
+	return func(
+		ctx context.Context, clusterID string, hostname string, start time.Time, end time.Time,
+	) (map[string]map[string][]models.Sample, error) {
+		cluster, ok := clustersMap[clusterID]
+		if !ok || len(cluster.Updaters) == 0 {
+			return nil, errNodeSeriesUnavailable
+		}
+
+		return unitUpdater.NodeSeries(ctx, start, end, cluster, hostname)
+	}, nil
+}
+
+// nodeReportAdmin         godoc
+//
+//	@Summary		Admin endpoint for incident report of a compute node
+//	@Description	This admin endpoint returns the incident report of a given compute node
+//	@Description	in a time window. The report combines the compute units of _any_ user
+//	@Description	that ran on the node, node stats, time series of node metrics like power
+//	@Description	and temperature and the anomalies flagged on the node.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured in the server.
+//	@Description
+//	@Description	See the user endpoint for the details of query parameters.
+//	@Security		BasicAuth
+//	@Tags			nodes
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			hostname		path		string	true	"Hostname of the node"
+//	@Param			cluster_id		query		string	true	"Cluster ID"
+//	@Param			from			query		string	false	"From timestamp"
+//	@Param			to				query		string	false	"To timestamp"
+//	@Success		200				{object}	Response[models.NodeReport]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/nodes/{hostname}/report/admin [get]
+//
+// GET /nodes/{hostname}/report/admin
+// Get incident report of a node including units of all users.
+func (s *CEEMSServer) nodeReportAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "node report admin endpoint", s.logger)
+
+	// Make report and write response
+	s.nodeReportQuerier(nil, w, r)
+}
+
+// nodeReport         godoc
+//
+//	@Summary		Incident report of a compute node
+//	@Description	This endpoint returns the incident report of a given compute node in a
+//	@Description	time window. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	The report combines the compute units that ran on the node, node stats
+//	@Description	estimated by updaters, time series of node metrics like power and
+//	@Description	temperature fetched from TSDB and the anomalies flagged on the node.
+//	@Description	Anomalies are failed compute units, preemptions and annotations of compute
+//	@Description	units. Only the compute units of current user and their anomalies are included
+//	@Description	in the report.
+//	@Description
+//	@Description	The query parameter `cluster_id` is mandatory as hostnames are only unique
+//	@Description	within a cluster. The query parameters `from` and `to` can be used to control
+//	@Description	the time window. By default, report of the last one week will be returned.
+//	@Description	Time series are configured by `node_series_queries` of TSDB updater and if
+//	@Description	they cannot be fetched, the rest of the report is returned with a warning.
+//	@Security		BasicAuth
+//	@Tags			nodes
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			hostname		path		string	true	"Hostname of the node"
+//	@Param			cluster_id		query		string	true	"Cluster ID"
+//	@Param			from			query		string	false	"From timestamp"
+//	@Param			to				query		string	false	"To timestamp"
+//	@Success		200				{object}	Response[models.NodeReport]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/nodes/{hostname}/report [get]
+//
+// GET /nodes/{hostname}/report
+// Get incident report of a node.
+func (s *CEEMSServer) nodeReport(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "node report endpoint", s.logger)
+
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Make report and write response
+	s.nodeReportQuerier([]string{loggedUser}, w, r)
+}
+
+// nodeReportQuerier makes incident report of a node. When users is not empty,
+// only units of the given users are included in the report.
+func (s *CEEMSServer) nodeReportQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get hostname from path
+	hostname := mux.Vars(r)["hostname"]
+
+	// Get cluster ID. It is mandatory as hostnames are only unique within a cluster
+	clusterID := r.URL.Query().Get("cluster_id")
+	if clusterID == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingClusterID}, s.logger)
+
+		return
+	}
+
+	// Get query window
+	fromTime, toTime, err := s.getQueryWindowTimes(r)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	report := models.NodeReport{
+		Hostname:  hostname,
+		ClusterID: clusterID,
+		From:      fromTime.UnixMilli(),
+		To:        toTime.UnixMilli(),
+	}
+
+	var warnings []string
+
+	// Units that were running on the node at any time during the window. Units that
+	// are still running or have terminated after the window can be among them
+	table := s.unitsTable(r.Context(), fromTime, time.Time{})
+
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE ignore = 0", strings.Join(base.UnitsDBTableColNames, ","), table))
+	q.query(" AND cluster_id = ")
+	q.param([]string{clusterID})
+	q.query(fmt.Sprintf(" AND id IN (SELECT unit_id FROM %s WHERE node = ", base.UnitNodesDBTableName))
+	q.param([]string{hostname})
+	q.query(") AND started_at_ts <= ")
+	q.param([]string{fmt.Sprintf("%d", report.To)})
+	q.query(" AND (ended_at_ts = 0 OR ended_at_ts >= ")
+	q.param([]string{fmt.Sprintf("%d", report.From)})
+	q.query(")")
+
+	if len(users) > 0 {
+		q.query(" AND username IN ")
+		q.param(users)
+	}
+
+	q.query(" ORDER BY started_at_ts ASC")
+
+	if report.Units, err = s.queriers.unit(r.Context(), s.db, q, s.logger); err != nil {
+		if report.Units == nil {
+			s.logger.Error("Failed to fetch units of node", "hostname", hostname, "cluster_id", clusterID, "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+
+		warnings = append(warnings, err.Error())
+	}
+
+	// Node stats estimated by updaters
+	q = Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE hostname = ", base.NodesDBTableName))
+	q.param([]string{hostname})
+	q.query(" AND cluster_id = ")
+	q.param([]string{clusterID})
+	q.query(" AND period_start BETWEEN ")
+	q.param([]string{fromTime.Format(base.DatetimeLayout)})
+	q.query(" AND ")
+	q.param([]string{toTime.Format(base.DatetimeLayout)})
+	q.query(" ORDER BY period_start ASC")
+
+	if report.Stats, err = s.queriers.node(r.Context(), s.db, q, s.logger); err != nil {
+		if report.Stats == nil {
+			s.logger.Error("Failed to fetch node stats", "hostname", hostname, "cluster_id", clusterID, "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+
+		warnings = append(warnings, err.Error())
+	}
+
+	// Anomalies of units on the node
+	anomalies, err := s.nodeAnomalies(r.Context(), clusterID, report.Units)
+	if err != nil {
+		s.logger.Error("Failed to fetch anomalies of node", "hostname", hostname, "cluster_id", clusterID, "err", err)
+		warnings = append(warnings, err.Error())
+	}
+
+	report.Anomalies = anomalies
+
+	// Time series of node from TSDB. Report is still useful without them
+	if s.nodeSeries == nil {
+		warnings = append(warnings, errNodeSeriesUnavailable.Error())
+	} else {
+		if report.Series, err = s.nodeSeries(r.Context(), clusterID, hostname, fromTime, toTime); err != nil {
+			s.logger.Error("Failed to fetch time series of node", "hostname", hostname, "cluster_id", clusterID, "err", err)
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	reportResponse := Response[models.NodeReport]{
+		Status:   "success",
+		Data:     []models.NodeReport{report},
+		Warnings: warnings,
+	}
+
+	if err = json.NewEncoder(w).Encode(&reportResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// nodeAnomalies returns the anomalies of units sorted by time. Failed units are
+// flagged from their states whereas preemptions and annotations of the units
+// are fetched from DB.
+func (s *CEEMSServer) nodeAnomalies(ctx context.Context, clusterID string, units []models.Unit) ([]models.NodeAnomaly, error) {
+	if len(units) == 0 {
+		return nil, nil
+	}
+
+	anomalies := make([]models.NodeAnomaly, 0)
+	uuids := make([]string, len(units))
+
+	for i, unit := range units {
+		uuids[i] = unit.UUID
+
+		if slices.Contains(failedUnitStates, unit.State) {
+			anomalies = append(anomalies, models.NodeAnomaly{
+				Kind:      unitFailureAnomaly,
+				ClusterID: unit.ClusterID,
+				UUID:      unit.UUID,
+				Time:      unit.EndedAt,
+				Message:   unit.State,
+			})
+		}
+	}
+
+	// Preemptions of units
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE cluster_id = ", base.PreemptionsDBTableName))
+	q.param([]string{clusterID})
+	q.query(" AND uuid IN ")
+	q.param(uuids)
+
+	preemptions, err := s.queriers.preempt(ctx, s.db, q, s.logger)
+	if preemptions == nil && err != nil {
+		return anomalies, fmt.Errorf("failed to fetch preemptions: %w", err)
+	}
+
+	for _, preemption := range preemptions {
+		anomalies = append(anomalies, models.NodeAnomaly{
+			Kind:      preemptionAnomaly,
+			ClusterID: preemption.ClusterID,
+			UUID:      preemption.UUID,
+			Time:      preemption.PreemptedAt,
+			Message:   "preempted after " + preemption.Elapsed,
+		})
+	}
+
+	// Annotations of units
+	q = Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE cluster_id = ", base.AnnotationsDBTableName))
+	q.param([]string{clusterID})
+	q.query(" AND uuid IN ")
+	q.param(uuids)
+
+	annotations, err := s.queriers.annot(ctx, s.db, q, s.logger)
+	if annotations == nil && err != nil {
+		return anomalies, fmt.Errorf("failed to fetch annotations: %w", err)
+	}
+
+	for _, annotation := range annotations {
+		anomalies = append(anomalies, models.NodeAnomaly{
+			Kind:      annotationAnomaly,
+			ClusterID: annotation.ClusterID,
+			UUID:      annotation.UUID,
+			Time:      annotation.CreatedAt,
+			Message:   annotation.Note,
+			Author:    annotation.Author,
+		})
+	}
+
+	// Times of all anomalies are in same layout
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		return anomalies[i].Time < anomalies[j].Time
+	})
+
+	return anomalies, nil
+}
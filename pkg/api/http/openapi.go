@@ -0,0 +1,293 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/http/docs"
+)
+
+// openAPIVersion is the version of OpenAPI specification served by API server.
+const openAPIVersion = "3.0.3"
+
+// Swagger 2.0 parameter keywords that are moved to schema in OpenAPI 3.
+var paramSchemaKeys = []string{
+	"type", "format", "items", "enum", "default", "minimum", "maximum",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems",
+}
+
+// openAPISpec returns OpenAPI 3 specification of API server. The specification
+// is converted from Swagger 2.0 specification generated by swag from handlers
+// and response models so that both specs are always in sync.
+//
+// serverURL is used as the URL of the API server in the specification.
+func openAPISpec(serverURL string) ([]byte, error) {
+	var spec map[string]any
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		return nil, err
+	}
+
+	// Update references to definitions
+	spec = rewriteRefs(spec).(map[string]any) //nolint:forcetypeassert
+
+	globalProduces := stringSlice(spec["produces"])
+
+	openapi := map[string]any{
+		"openapi": openAPIVersion,
+		"info":    spec["info"],
+		"servers": []map[string]string{{"url": serverURL}},
+		"paths":   map[string]any{},
+		"components": map[string]any{
+			"schemas":         valueOrEmpty(spec["definitions"]),
+			"securitySchemes": securitySchemes(spec["securityDefinitions"]),
+		},
+	}
+
+	for _, key := range []string{"tags", "externalDocs", "security"} {
+		if v, ok := spec[key]; ok {
+			openapi[key] = v
+		}
+	}
+
+	paths, _ := spec["paths"].(map[string]any)
+	for path, item := range paths {
+		ops, _ := item.(map[string]any)
+
+		newOps := make(map[string]any, len(ops))
+		for method, op := range ops {
+			if o, ok := op.(map[string]any); ok {
+				newOps[method] = convertOperation(o, globalProduces)
+			}
+		}
+
+		openapi["paths"].(map[string]any)[path] = newOps //nolint:forcetypeassert
+	}
+
+	return json.Marshal(openapi)
+}
+
+// convertOperation converts Swagger 2.0 operation into OpenAPI 3 operation.
+func convertOperation(op map[string]any, globalProduces []string) map[string]any {
+	produces := stringSlice(op["produces"])
+	if len(produces) == 0 {
+		produces = globalProduces
+	}
+
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	consumes := stringSlice(op["consumes"])
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+
+	newOp := make(map[string]any, len(op))
+
+	for key, value := range op {
+		switch key {
+		case "produces", "consumes", "parameters", "responses":
+			continue
+		default:
+			newOp[key] = value
+		}
+	}
+
+	// Parameters
+	params, _ := op["parameters"].([]any)
+
+	var newParams []map[string]any
+
+	for _, p := range params {
+		param, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		// Body parameters become request body
+		if param["in"] == "body" {
+			newOp["requestBody"] = map[string]any{
+				"description": param["description"],
+				"required":    param["required"] == true,
+				"content":     mediaTypes(consumes, param["schema"]),
+			}
+
+			continue
+		}
+
+		newParams = append(newParams, convertParameter(param))
+	}
+
+	if len(newParams) > 0 {
+		newOp["parameters"] = newParams
+	}
+
+	// Responses
+	responses, _ := op["responses"].(map[string]any)
+	newResponses := make(map[string]any, len(responses))
+
+	for code, r := range responses {
+		resp, ok := r.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		newResp := map[string]any{"description": resp["description"]}
+		if schema, ok := resp["schema"]; ok {
+			newResp["content"] = mediaTypes(produces, schema)
+		}
+
+		newResponses[code] = newResp
+	}
+
+	newOp["responses"] = newResponses
+
+	return newOp
+}
+
+// convertParameter converts Swagger 2.0 non body parameter into OpenAPI 3 parameter.
+func convertParameter(param map[string]any) map[string]any {
+	schema := make(map[string]any)
+	newParam := make(map[string]any)
+
+	for key, value := range param {
+		switch key {
+		case "collectionFormat":
+			// Repeated query parameters like ?uuid=1&uuid=2 are described by
+			// form style with explode
+			newParam["style"] = "form"
+			newParam["explode"] = value == "multi"
+		default:
+			newParam[key] = value
+		}
+	}
+
+	for _, key := range paramSchemaKeys {
+		if value, ok := newParam[key]; ok {
+			schema[key] = value
+			delete(newParam, key)
+		}
+	}
+
+	newParam["schema"] = schema
+
+	return newParam
+}
+
+// securitySchemes converts Swagger 2.0 security definitions into OpenAPI 3
+// security schemes.
+func securitySchemes(v any) map[string]any {
+	defs, _ := v.(map[string]any)
+	schemes := make(map[string]any, len(defs))
+
+	for name, d := range defs {
+		def, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		switch def["type"] {
+		case "basic":
+			schemes[name] = map[string]any{"type": "http", "scheme": "basic"}
+		default:
+			schemes[name] = def
+		}
+	}
+
+	return schemes
+}
+
+// rewriteRefs replaces Swagger 2.0 definitions references with OpenAPI 3
+// component references.
+func rewriteRefs(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				t[key] = strings.Replace(ref, "#/definitions/", "#/components/schemas/", 1)
+			} else {
+				t[key] = rewriteRefs(value)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = rewriteRefs(t[i])
+		}
+	}
+
+	return v
+}
+
+// mediaTypes returns content of request body or response with schema for
+// each media type.
+func mediaTypes(types []string, schema any) map[string]any {
+	content := make(map[string]any, len(types))
+
+	for _, t := range types {
+		// swag uses plain as short name for text/plain
+		if t == "plain" {
+			t = "text/plain"
+		}
+
+		content[t] = map[string]any{"schema": schema}
+	}
+
+	return content
+}
+
+// stringSlice returns slice of strings from a JSON array.
+func stringSlice(v any) []string {
+	values, _ := v.([]any)
+
+	var s []string
+
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			s = append(s, str)
+		}
+	}
+
+	return s
+}
+
+// valueOrEmpty returns v if it is not nil or an empty JSON object.
+func valueOrEmpty(v any) any {
+	if v == nil {
+		return map[string]any{}
+	}
+
+	return v
+}
+
+// openAPI        godoc
+//
+//	@Summary		OpenAPI specification
+//	@Description	This endpoint returns the OpenAPI 3 specification of CEEMS API server. It can be
+//	@Description	used by third party tools to generate clients for the API server.
+//	@Tags			swagger
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}
+//	@Failure		500	{object}	Problem
+//	@Router			/swagger.json [get]
+//
+// GET /swagger.json
+// Get OpenAPI 3 specification.
+func (s *CEEMSServer) openAPI(w http.ResponseWriter, r *http.Request) {
+	// Server URL is relative to the location of the specification so that it
+	// works behind reverse proxies and with route prefixes
+	spec, err := openAPISpec(strings.TrimSuffix(r.URL.Path, "/swagger.json"))
+	if err != nil {
+		s.logger.Error("Failed to generate OpenAPI specification", "err", err)
+		errorResponse(w, r, &apiError{errorInternal, err}, s.logger)
+
+		return
+	}
+
+	s.setHeaders(w)
+	w.WriteHeader(http.StatusOK)
+	w.Write(spec)
+}
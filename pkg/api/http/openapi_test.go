@@ -0,0 +1,78 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	b, err := openAPISpec("/api/v1")
+	require.NoError(t, err)
+
+	// References must point to components
+	assert.NotContains(t, string(b), "#/definitions/")
+
+	var spec struct {
+		OpenAPI string              `json:"openapi"`
+		Servers []map[string]string `json:"servers"`
+		Paths   map[string]map[string]struct {
+			Parameters []map[string]any `json:"parameters"`
+			Responses  map[string]struct {
+				Content map[string]any `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas         map[string]any `json:"schemas"`
+			SecuritySchemes map[string]any `json:"securitySchemes"`
+		} `json:"components"`
+	}
+
+	require.NoError(t, json.Unmarshal(b, &spec))
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.Equal(t, []map[string]string{{"url": "/api/v1"}}, spec.Servers)
+	assert.NotEmpty(t, spec.Components.Schemas)
+	assert.Equal(t, map[string]any{"type": "http", "scheme": "basic"}, spec.Components.SecuritySchemes["BasicAuth"])
+
+	// Parameters must have schema and repeated query parameters must be exploded
+	units, ok := spec.Paths["/units"]["get"]
+	require.True(t, ok)
+
+	for _, param := range units.Parameters {
+		assert.Contains(t, param, "schema")
+		assert.NotContains(t, param, "type")
+
+		if param["name"] == "uuid" {
+			assert.Equal(t, true, param["explode"])
+			assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, param["schema"])
+		}
+	}
+
+	assert.Contains(t, units.Responses["200"].Content, "application/json")
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/ceems/api/v1/swagger.json", nil)
+
+	w := httptest.NewRecorder()
+	server.openAPI(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var spec map[string]any
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, []any{map[string]any{"url": "/ceems/api/v1"}}, spec["servers"])
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+)
+
+// rowQueryer makes queries that return a single row.
+type rowQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// UnitsSource returns the source of units terminated in the window [from, to]
+// to be used in FROM clauses of queries. When terminated units are partitioned
+// by month, it is the union of units table and the partitions that overlap with
+// the window aliased as units table. Zero from and to mean unbounded window.
+//
+// Running units are always in units table and hence, it is always included.
+func UnitsSource(ctx context.Context, dbConn rowQueryer, from, to time.Time) (string, error) {
+	var names sql.NullString
+	if err := dbConn.QueryRowContext(
+		ctx,
+		"SELECT group_concat(name, ',') FROM (SELECT name FROM sqlite_master WHERE type = 'table' AND name GLOB ? ORDER BY name)",
+		base.UnitsDBTableName+"_[0-9][0-9][0-9][0-9]_[0-9][0-9]",
+	).Scan(&names); err != nil {
+		return base.UnitsDBTableName, err
+	}
+
+	if !names.Valid || names.String == "" {
+		return base.UnitsDBTableName, nil
+	}
+
+	selects := []string{"SELECT * FROM " + base.UnitsDBTableName}
+
+	for _, name := range strings.Split(names.String, ",") {
+		start, err := time.Parse(base.UnitsPartitionLayout, strings.TrimPrefix(name, base.UnitsDBTableName+"_"))
+		if err != nil {
+			continue
+		}
+
+		// Skip partitions that do not overlap with window
+		if (!to.IsZero() && start.After(to)) || (!from.IsZero() && !start.AddDate(0, 1, 0).After(from)) {
+			continue
+		}
+
+		selects = append(selects, "SELECT * FROM "+name)
+	}
+
+	if len(selects) == 1 {
+		return base.UnitsDBTableName, nil
+	}
+
+	return fmt.Sprintf("(%s) AS %s", strings.Join(selects, " UNION ALL "), base.UnitsDBTableName), nil
+}
@@ -0,0 +1,149 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitsSource(t *testing.T) {
+	dbConn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	ctx := context.Background()
+
+	// Without partitions, units table is the source
+	_, err = dbConn.Exec("CREATE TABLE units (id integer, ended_at text); INSERT INTO units VALUES (3, '');")
+	require.NoError(t, err)
+
+	source, err := UnitsSource(ctx, dbConn, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "units", source)
+
+	_, err = dbConn.Exec(
+		"CREATE TABLE units_2024_01 (id integer, ended_at text); INSERT INTO units_2024_01 VALUES (1, '2024-01-10');" +
+			"CREATE TABLE units_2024_03 (id integer, ended_at text); INSERT INTO units_2024_03 VALUES (2, '2024-03-10');" +
+			"CREATE TABLE units_backup (id integer, ended_at text);",
+	)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		from, to time.Time
+		ids      []int
+	}{
+		{name: "all partitions", ids: []int{1, 2, 3}},
+		{name: "overlapping partitions", from: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), ids: []int{1, 2, 3}},
+		{name: "recent partitions", from: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), ids: []int{2, 3}},
+		{
+			name: "window without partitions",
+			from: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			to:   time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC),
+			ids:  []int{3},
+		},
+	} {
+		source, err := UnitsSource(ctx, dbConn, tc.from, tc.to)
+		require.NoError(t, err, tc.name)
+
+		rows, err := dbConn.Query("SELECT units.id FROM " + source + " ORDER BY id") //nolint:gosec
+		require.NoError(t, err, tc.name)
+
+		var ids []int
+
+		for rows.Next() {
+			var id int
+			require.NoError(t, rows.Scan(&id))
+
+			ids = append(ids, id)
+		}
+
+		rows.Close()
+		assert.Equal(t, tc.ids, ids, tc.name)
+	}
+}
+
+func TestQueriesWithPartitionedUnits(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add a terminated unit and move it to a monthly partition
+	_, err = dbConn.Exec(
+		"INSERT INTO units (resource_manager, cluster_id, uuid, username, project, created_at, created_at_ts, " +
+			"started_at, ended_at, ended_at_ts, last_updated_at, total_cpu_energy_usage_kwh) VALUES " +
+			"('slurm', 'slurm-1', '1', 'usr1', 'prj1', '2024-01-10T10:00:00+0000', 1704880800000, " +
+			"'2024-01-10T10:00:00+0000', '2024-01-10T12:00:00+0000', 1704888000000, '2024-01-10T12:00:00+0000', '{\"total\": 1.5}');" +
+			"INSERT INTO users (uid, cluster_id, resource_manager, name, projects, last_updated_at) VALUES " +
+			"('1000', 'slurm-1', 'slurm', 'usr1', '[\"prj1\"]', '2024-01-10T12:00:00+0000');" +
+			"CREATE TABLE units_2024_01 AS SELECT * FROM units; DELETE FROM units;",
+	)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.cluster = Querier[models.Cluster]
+	server.queriers.user = Querier[models.User]
+	server.queriers.key = Querier[models.Key]
+
+	// Clusters of partitioned units must be listed
+	req := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/clusters/admin", nil)
+	w := httptest.NewRecorder()
+	server.clustersAdmin(w, req)
+
+	var clusters Response[models.Cluster]
+
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&clusters))
+	require.Len(t, clusters.Data, 1)
+	assert.Equal(t, "slurm-1", clusters.Data[0].ID)
+
+	// Last activity of users must be estimated from partitioned units
+	req = httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/users/admin", nil)
+	w = httptest.NewRecorder()
+	server.usersAdmin(w, req)
+
+	var users Response[models.User]
+
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&users))
+	require.Len(t, users.Data, 1)
+	assert.Equal(t, "2024-01-10T12:00:00+0000", users.Data[0].LastActivityAt)
+
+	// Metric keys of partitioned units must be used in aggregate queries
+	timeQuery := Query{}
+	timeQuery.query("last_updated_at > ")
+	timeQuery.param([]string{"2024-01-01T00:00:00+0000"})
+
+	req = httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/usage/current/admin", nil)
+	query := server.aggQueryBuilder(
+		req, "total_cpu_energy_usage_kwh", server.unitsTable(context.Background(), time.Time{}, time.Time{}), timeQuery,
+	)
+	assert.Contains(t, query, "json_each(total_cpu_energy_usage_kwh,'$.total')")
+}
@@ -0,0 +1,147 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// PrivacyConfig contains the configuration of aggregation floor of usage
+// returned to non-admin users. Usage of projects and groups with fewer distinct
+// users than min group size can identify individuals and it is suppressed.
+// Floor is disabled when min group size is less than 2.
+type PrivacyConfig struct {
+	MinGroupSize int `yaml:"min_group_size"`
+}
+
+// Validate validates the config.
+func (c *PrivacyConfig) Validate() error {
+	if c.MinGroupSize < 0 {
+		return fmt.Errorf("invalid privacy config: %w", errInvalidMinGroupSize)
+	}
+
+	return nil
+}
+
+// enabled returns true when aggregation floor is configured.
+func (c *PrivacyConfig) enabled() bool {
+	return c.MinGroupSize > 1
+}
+
+// groupSize is the number of distinct users of a project or group in a cluster.
+type groupSize struct {
+	ClusterID string `sql:"cluster_id"`
+	Name      string `sql:"name"`
+	NumUsers  int64  `sql:"num_users"`
+}
+
+// groupKey identifies a project or group in a cluster.
+type groupKey struct {
+	clusterID string
+	name      string
+}
+
+// groupSizes returns the number of distinct users having usage in each project
+// or group, depending on column, of the projects of users.
+func (s *CEEMSServer) groupSizes(ctx context.Context, column string, users []string) (map[groupKey]int64, error) {
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT cluster_id,%[1]s AS name,COUNT(DISTINCT username) AS num_users FROM %[2]s",
+			column, base.UsageDBTableName,
+		),
+	)
+	q.query(" WHERE project IN ")
+	q.subQuery(projectsSubQuery(users))
+	q.query(" GROUP BY cluster_id," + column)
+
+	// Floor must not be applied on partial sizes
+	rows, err := s.queriers.size(ctx, s.db, q, s.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[groupKey]int64, len(rows))
+	for _, r := range rows {
+		sizes[groupKey{r.ClusterID, r.Name}] = r.NumUsers
+	}
+
+	return sizes, nil
+}
+
+// suppressUsage removes usage of projects, or groups when usage is aggregated by
+// group, that have fewer distinct users than min group size. Usage of the caller
+// is always kept as it does not reveal usage of other users. Number of suppressed
+// rows is returned along with remaining usage. Usage is never suppressed when
+// caller is empty, i.e., for admin requests.
+func (s *CEEMSServer) suppressUsage(ctx context.Context, caller string, usage []models.Usage, groupAgg bool) ([]models.Usage, int, error) {
+	if !s.privacy.enabled() || caller == "" || len(usage) == 0 {
+		return usage, 0, nil
+	}
+
+	column := "project"
+	if groupAgg {
+		column = "groupname"
+	}
+
+	sizes, err := s.groupSizes(ctx, column, []string{caller})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kept := slices.DeleteFunc(slices.Clone(usage), func(u models.Usage) bool {
+		if groupAgg {
+			return sizes[groupKey{u.ClusterID, u.Group}] < int64(s.privacy.MinGroupSize)
+		}
+
+		return u.User != caller && sizes[groupKey{u.ClusterID, u.Project}] < int64(s.privacy.MinGroupSize)
+	})
+
+	return kept, len(usage) - len(kept), nil
+}
+
+// largeProjects returns the projects that have at least min group size distinct
+// users in every cluster they have usage in. Projects are returned as such when
+// caller is empty, i.e., for admin requests.
+func (s *CEEMSServer) largeProjects(ctx context.Context, caller string, projects []string) ([]string, error) {
+	if !s.privacy.enabled() || caller == "" {
+		return projects, nil
+	}
+
+	sizes, err := s.groupSizes(ctx, "project", []string{caller})
+	if err != nil {
+		return nil, err
+	}
+
+	// Projects without any usage are considered small as well
+	isLarge := make(map[string]bool)
+
+	for key, size := range sizes {
+		if large, ok := isLarge[key.name]; ok && !large {
+			continue
+		}
+
+		isLarge[key.name] = size >= int64(s.privacy.MinGroupSize)
+	}
+
+	large := make([]string, 0, len(projects))
+
+	for _, p := range projects {
+		if isLarge[p] {
+			large = append(large, p)
+		}
+	}
+
+	return large, nil
+}
+
+// suppressedWarning returns the warning included in responses with suppressed rows.
+func (c *PrivacyConfig) suppressedWarning(numSuppressed int) string {
+	return fmt.Sprintf("%d rows of projects or groups with fewer than %d users are suppressed", numSuppressed, c.MinGroupSize)
+}
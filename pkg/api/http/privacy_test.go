@@ -0,0 +1,115 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivacyConfig(t *testing.T) {
+	require.NoError(t, (&PrivacyConfig{}).Validate())
+	require.NoError(t, (&PrivacyConfig{MinGroupSize: 5}).Validate())
+	require.ErrorIs(t, (&PrivacyConfig{MinGroupSize: -1}).Validate(), errInvalidMinGroupSize)
+
+	// Group size of 1 does not suppress anything
+	assert.False(t, (&PrivacyConfig{MinGroupSize: 1}).enabled())
+}
+
+func TestSuppressUsage(t *testing.T) {
+	dir := t.TempDir()
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "privacy.db"))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	_, err = db.Exec(`
+CREATE TABLE projects (
+	"name" text,
+	"users" text
+);
+CREATE TABLE usage (
+	"cluster_id" text,
+	"project" text,
+	"groupname" text,
+	"username" text
+);
+INSERT INTO projects VALUES('prj1', '["usr1","usr2","usr3"]');
+INSERT INTO projects VALUES('prj2', '["usr1","usr4"]');
+INSERT INTO projects VALUES('prj3', '["usr5"]');
+INSERT INTO usage VALUES('rm-0', 'prj1', 'grp1', 'usr1');
+INSERT INTO usage VALUES('rm-0', 'prj1', 'grp1', 'usr2');
+INSERT INTO usage VALUES('rm-0', 'prj1', 'grp1', 'usr3');
+INSERT INTO usage VALUES('rm-0', 'prj2', 'grp2', 'usr1');
+INSERT INTO usage VALUES('rm-0', 'prj2', 'grp2', 'usr4');
+INSERT INTO usage VALUES('rm-0', 'prj3', 'grp3', 'usr5');`)
+	require.NoError(t, err)
+
+	server := setupServer(dir)
+	defer server.Shutdown(context.Background())
+
+	server.db = db
+	server.queriers.size = Querier[groupSize]
+	server.privacy = PrivacyConfig{MinGroupSize: 3}
+
+	ctx := context.Background()
+
+	// Usage of other users in small projects must be suppressed
+	usage := []models.Usage{
+		{ClusterID: "rm-0", Project: "prj1", User: "usr2"},
+		{ClusterID: "rm-0", Project: "prj2", User: "usr1"},
+		{ClusterID: "rm-0", Project: "prj2", User: "usr4"},
+	}
+
+	kept, numSuppressed, err := server.suppressUsage(ctx, "usr1", usage, false)
+	require.NoError(t, err)
+	assert.Equal(t, usage[:2], kept)
+	assert.Equal(t, 1, numSuppressed)
+
+	// Usage of small groups must be suppressed
+	groups := []models.Usage{
+		{ClusterID: "rm-0", Group: "grp1"},
+		{ClusterID: "rm-0", Group: "grp2"},
+	}
+
+	kept, numSuppressed, err = server.suppressUsage(ctx, "usr1", groups, true)
+	require.NoError(t, err)
+	assert.Equal(t, groups[:1], kept)
+	assert.Equal(t, 1, numSuppressed)
+
+	// Admin requests must not be suppressed
+	kept, numSuppressed, err = server.suppressUsage(ctx, "", usage, false)
+	require.NoError(t, err)
+	assert.Equal(t, usage, kept)
+	assert.Zero(t, numSuppressed)
+
+	// Only large projects must be ranked in top reports
+	projects, err := server.largeProjects(ctx, "usr1", []string{"prj1", "prj2", "prj4"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prj1"}, projects)
+
+	// Top reports of projects and groups must have a floor on number of users
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/reports/top?metric=cpu_hours&by=group", nil)
+	q, err := server.topQuery(request, projects, 3)
+	require.NoError(t, err)
+
+	query, _ := q.get()
+	assert.Contains(t, query, "HAVING COUNT(DISTINCT username) >= 3")
+
+	request = httptest.NewRequest(http.MethodGet, "/api/v1/reports/top?metric=cpu_hours&by=user", nil)
+	q, err = server.topQuery(request, projects, 3)
+	require.NoError(t, err)
+
+	query, _ = q.get()
+	assert.NotContains(t, query, "HAVING")
+}
@@ -0,0 +1,380 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
+	"gopkg.in/yaml.v3"
+)
+
+// Maximum size of provisioned config.
+const maxProvisionedConfigSize = 1 << 20
+
+// provisionedKinds maps the kinds in URL path to the kinds of provisioned configs.
+var provisionedKinds = map[string]string{
+	"clusters": models.ProvisionedCluster,
+	"updaters": models.ProvisionedUpdater,
+}
+
+// provisionedSpec returns the config with secrets redacted. Config is decoded into
+// the type of its kind so that secrets are marshalled as redacted values.
+func provisionedSpec(kind string, config string) (map[string]any, error) {
+	var v any
+
+	switch kind {
+	case models.ProvisionedCluster:
+		v = &models.Cluster{}
+	default:
+		v = &updater.Instance{}
+	}
+
+	if err := yaml.Unmarshal([]byte(config), v); err != nil {
+		return nil, err
+	}
+
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec map[string]any
+	if err := yaml.Unmarshal(out, &spec); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
+
+// parseProvisionedConfig validates the config of kind in body and returns the
+// config to be stored along with the type of resource manager or updater. ID of
+// config is always set to the one in URL path.
+func parseProvisionedConfig(kind string, id string, body []byte) (string, string, error) {
+	// Config must be a JSON or YAML object
+	var raw map[string]any
+	if err := yaml.Unmarshal(body, &raw); err != nil || raw == nil {
+		return "", "", errInvalidProvisioning
+	}
+
+	if v, ok := raw["id"]; ok && v != id {
+		return "", "", errInvalidProvisioning
+	}
+
+	raw["id"] = id
+
+	config, err := yaml.Marshal(raw)
+	if err != nil {
+		return "", "", errInvalidProvisioning
+	}
+
+	// Ensure that config can be decoded
+	var configType string
+
+	switch kind {
+	case models.ProvisionedCluster:
+		var cluster models.Cluster
+		if err := yaml.Unmarshal(config, &cluster); err != nil {
+			return "", "", errInvalidProvisioning
+		}
+
+		configType = cluster.Manager
+	default:
+		var instance updater.Instance
+		if err := yaml.Unmarshal(config, &instance); err != nil {
+			return "", "", errInvalidProvisioning
+		}
+
+		configType = instance.Updater
+	}
+
+	if strings.TrimSpace(configType) == "" {
+		return "", "", errInvalidProvisioning
+	}
+
+	return string(config), configType, nil
+}
+
+// provisionedAdmin         godoc
+//
+//	@Summary		Admin endpoint to list provisioned configs
+//	@Description	This admin endpoint returns the clusters or updaters provisioned using
+//	@Description	API server. When `id` is set in the path, only config with that ID is
+//	@Description	returned. Secrets in the configs are always redacted. The current user
+//	@Description	is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Clusters and updaters in the config file are not returned as config file
+//	@Description	is only used to bootstrap the server.
+//	@Security		BasicAuth
+//	@Tags			provisioning
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			kind			path		string	true	"Kind of config"	Enums(clusters, updaters)
+//	@Param			id				path		string	false	"ID of cluster or updater"
+//	@Success		200				{object}	Response[models.ProvisionedConfig]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		404				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/provisioning/{kind}/admin [get]
+//	@Router			/provisioning/{kind}/{id}/admin [get]
+//
+// GET /provisioning/{kind}/admin
+// GET /provisioning/{kind}/{id}/admin
+// List provisioned configs.
+func (s *CEEMSServer) provisionedAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "provisioning admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	kind := provisionedKinds[mux.Vars(r)["kind"]]
+	id := mux.Vars(r)["id"]
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE kind = ",
+			strings.Join(base.ProvisionedDBTableColNames, ","),
+			base.ProvisionedDBTableName,
+		),
+	)
+	q.param([]string{kind})
+
+	if id != "" {
+		q.query(" AND config_id = ")
+		q.param([]string{id})
+	}
+
+	q.query(" ORDER BY config_id ASC")
+
+	configs, err := s.queriers.prov(r.Context(), s.db, q, s.logger)
+	if configs == nil && err != nil {
+		s.logger.Error("Failed to fetch provisioned configs", "kind", kind, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if id != "" && len(configs) == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errProvisionedNotFound}, s.logger)
+
+		return
+	}
+
+	var warnings []string
+
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	// Redact secrets in configs
+	for i := range configs {
+		if configs[i].Spec, err = provisionedSpec(configs[i].Kind, configs[i].Config); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid config of %s: %s", configs[i].ConfigID, err))
+		}
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[models.ProvisionedConfig]{
+		Status:   "success",
+		Data:     configs,
+		Warnings: warnings,
+	}
+
+	if err = json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// putProvisionedAdmin         godoc
+//
+//	@Summary		Admin endpoint to create or replace provisioned configs
+//	@Description	This admin endpoint creates or replaces the config of a cluster or an
+//	@Description	updater with the given ID. The current user is always identified by the
+//	@Description	header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The request body must be a JSON object with same keys as the clusters
+//	@Description	or updaters in config file. If `id` key is present, it must be the same
+//	@Description	as the ID in path. Clusters and updaters with same ID in config file
+//	@Description	take precedence over provisioned ones. Provisioned configs are loaded
+//	@Description	when the server starts and hence, changes take effect after a restart.
+//	@Security		BasicAuth
+//	@Tags			provisioning
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			kind			path		string	true	"Kind of config"	Enums(clusters, updaters)
+//	@Param			id				path		string	true	"ID of cluster or updater"
+//	@Param			config			body		object	true	"Config"
+//	@Success		200				{object}	Response[models.ProvisionedConfig]
+//	@Success		201				{object}	Response[models.ProvisionedConfig]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/provisioning/{kind}/{id}/admin [put]
+//
+// PUT /provisioning/{kind}/{id}/admin
+// Create or replace provisioned config.
+func (s *CEEMSServer) putProvisionedAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "put provisioning admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	kind := provisionedKinds[mux.Vars(r)["kind"]]
+	id := mux.Vars(r)["id"]
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxProvisionedConfigSize))
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidProvisioning}, s.logger)
+
+		return
+	}
+
+	config, configType, err := parseProvisionedConfig(kind, id, body)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+
+	provisioned := models.ProvisionedConfig{
+		Kind:      kind,
+		ConfigID:  id,
+		Type:      configType,
+		Config:    config,
+		UpdatedBy: loggedUser,
+		UpdatedAt: time.Now().In(s.timeLocation("")).Format(base.DatetimezoneLayout),
+	}
+
+	// Check if config exists already to set status code
+	var exists bool
+
+	//nolint:gosec
+	if err := s.dbRW.QueryRowContext(
+		r.Context(),
+		fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE kind = ? AND config_id = ?)", base.ProvisionedDBTableName),
+		kind, id,
+	).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check provisioned config", "kind", kind, "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	//nolint:gosec
+	if _, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf(
+			`INSERT INTO %s (kind,config_id,type,config,updated_by,updated_at) VALUES (?,?,?,?,?,?)
+ON CONFLICT(kind,config_id) DO UPDATE SET type = excluded.type, config = excluded.config,
+updated_by = excluded.updated_by, updated_at = excluded.updated_at`,
+			base.ProvisionedDBTableName,
+		),
+		provisioned.Kind, provisioned.ConfigID, provisioned.Type, provisioned.Config,
+		provisioned.UpdatedBy, provisioned.UpdatedAt,
+	); err != nil {
+		s.logger.Error("Failed to save provisioned config", "kind", kind, "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	s.logger.Info("Provisioned config saved", "kind", kind, "id", id, "type", configType, "updated_by", loggedUser)
+
+	// Write response
+	if exists {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	provisioned.Spec, _ = provisionedSpec(kind, config)
+
+	response := Response[models.ProvisionedConfig]{
+		Status: "success",
+		Data:   []models.ProvisionedConfig{provisioned},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// deleteProvisionedAdmin         godoc
+//
+//	@Summary		Admin endpoint to delete provisioned configs
+//	@Description	This admin endpoint deletes the config of a cluster or an updater with
+//	@Description	the given ID. Changes take effect after a restart of the server. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the
+//	@Description	request.
+//	@Security		BasicAuth
+//	@Tags			provisioning
+//	@Produce		json
+//	@Param			X-Grafana-User	header	string	true	"Current user name"
+//	@Param			kind			path	string	true	"Kind of config"	Enums(clusters, updaters)
+//	@Param			id				path	string	true	"ID of cluster or updater"
+//	@Success		204
+//	@Failure		401	{object}	Problem
+//	@Failure		403	{object}	Problem
+//	@Failure		404	{object}	Problem
+//	@Failure		500	{object}	Problem
+//	@Router			/provisioning/{kind}/{id}/admin [delete]
+//
+// DELETE /provisioning/{kind}/{id}/admin
+// Delete provisioned config.
+func (s *CEEMSServer) deleteProvisionedAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "delete provisioning admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	kind := provisionedKinds[mux.Vars(r)["kind"]]
+	id := mux.Vars(r)["id"]
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf("DELETE FROM %s WHERE kind = ? AND config_id = ?", base.ProvisionedDBTableName),
+		kind, id,
+	)
+	if err != nil {
+		s.logger.Error("Failed to delete provisioned config", "kind", kind, "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errProvisionedNotFound}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+	s.logger.Info("Provisioned config deleted", "kind", kind, "id", id, "deleted_by", loggedUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
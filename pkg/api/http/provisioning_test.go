@@ -0,0 +1,140 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProvisionedConfig(t *testing.T) {
+	config, configType, err := parseProvisionedConfig(
+		models.ProvisionedCluster, "slurm-0", []byte(`{"manager": "slurm", "updaters": ["tsdb-0"]}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "slurm", configType)
+	assert.Contains(t, config, "id: slurm-0")
+
+	config, configType, err = parseProvisionedConfig(
+		models.ProvisionedUpdater, "tsdb-0", []byte(`{"id": "tsdb-0", "updater": "tsdb", "web": {"url": "http://localhost:9090"}}`),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "tsdb", configType)
+	assert.Contains(t, config, "url: http://localhost:9090")
+
+	// Invalid configs
+	for _, body := range []string{
+		`[]`,
+		`{"id": "slurm-1", "manager": "slurm"}`,
+		`{"web": {"url": "http://localhost"}}`,
+		`{"manager": "slurm", "web": "foo"}`,
+	} {
+		_, _, err = parseProvisionedConfig(models.ProvisionedCluster, "slurm-0", []byte(body))
+		assert.ErrorIs(t, err, errInvalidProvisioning, body)
+	}
+}
+
+func TestProvisioningHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add admin user
+	_, err = dbConn.Exec(`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','')`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.prov = Querier[models.ProvisionedConfig]
+
+	// Make request through middlewares. Server limits requests to 10 per minute
+	do := func(method, path, user, body string) (*httptest.ResponseRecorder, Response[models.ProvisionedConfig]) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(grafanaUserHeader, user)
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.ProvisionedConfig]
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w, response
+	}
+
+	body := `{"manager": "slurm", "web": {"url": "http://localhost:6820", "basic_auth": {"username": "ceems", "password": "secret"}}}`
+
+	// Only admins can provision clusters
+	w, _ := do(http.MethodPut, "/api/v1/provisioning/clusters/slurm-0/admin", "usr1", body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, resp := do(http.MethodPut, "/api/v1/provisioning/clusters/slurm-0/admin", "adm1", body)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "slurm", resp.Data[0].Type)
+	assert.Equal(t, "adm1", resp.Data[0].UpdatedBy)
+
+	// Replacing config must return OK
+	w, _ = do(http.MethodPut, "/api/v1/provisioning/clusters/slurm-0/admin", "adm1", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = do(http.MethodPut, "/api/v1/provisioning/updaters/tsdb-0/admin", "adm1", `{"updater": "tsdb"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// Secrets must be redacted
+	w, resp = do(http.MethodGet, "/api/v1/provisioning/clusters/admin", "adm1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "slurm-0", resp.Data[0].ConfigID)
+	assert.NotContains(t, w.Body.String(), "secret")
+
+	web, ok := resp.Data[0].Spec["web"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "http://localhost:6820", web["url"])
+
+	w, resp = do(http.MethodGet, "/api/v1/provisioning/updaters/tsdb-0/admin", "adm1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tsdb", resp.Data[0].Type)
+
+	// Config must be stored with ID and secrets
+	var config string
+
+	require.NoError(t, dbConn.QueryRow(`SELECT config FROM provisioned_configs WHERE config_id = 'slurm-0'`).Scan(&config))
+	assert.Contains(t, config, "id: slurm-0")
+	assert.Contains(t, config, "password: secret")
+
+	w, _ = do(http.MethodDelete, "/api/v1/provisioning/clusters/slurm-0/admin", "adm1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w, _ = do(http.MethodGet, "/api/v1/provisioning/clusters/slurm-0/admin", "adm1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
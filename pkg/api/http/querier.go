@@ -9,14 +9,49 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/internal/structset"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
 var queryRegexp = regexp.MustCompile("SELECT (.*?) FROM (.*)")
 
+// likeEscaper escapes wildcards of LIKE operator.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// DB query metrics.
+var (
+	dbQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of DB queries made by API end points in seconds by model.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"model"})
+	dbQueryRows = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "db",
+		Name:      "query_rows",
+		Help:      "Number of rows returned by DB queries made by API end points by model.",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"model"})
+)
+
+// observeQuery updates DB query metrics of model T and counter of rows served
+// in the request.
+func observeQuery[T any](ctx context.Context, start time.Time, numRows int) {
+	model := strings.ToLower(reflect.TypeOf(new(T)).Elem().Name())
+
+	dbQueryDuration.WithLabelValues(model).Observe(time.Since(start).Seconds())
+	dbQueryRows.WithLabelValues(model).Observe(float64(numRows))
+
+	addServedRows(ctx, numRows)
+}
+
 // Query builder struct.
 type Query struct {
 	builder strings.Builder
@@ -41,11 +76,25 @@ func (q *Query) subQuery(sq Query) {
 	q.params = append(q.params, subQueryParams...)
 }
 
+// Add another query to builder.
+func (q *Query) append(sq Query) {
+	query, queryParams := sq.get()
+	q.builder.WriteString(query)
+	q.params = append(q.params, queryParams...)
+}
+
 // Get current query string and its parameters.
 func (q *Query) get() (string, []string) {
 	return q.builder.String(), q.params
 }
 
+// likePattern returns a LIKE pattern that matches s anywhere in the value. The
+// wildcards of LIKE in s are escaped with backslash which must be declared as
+// escape character in the query.
+func likePattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
 // projectsSubQuery returns a sub query that returns projects of users
 // With my limited SQL skills the best query I came up with is following:
 // SELECT * FROM usage WHERE project IN (SELECT name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value = 'usr1'))
@@ -112,6 +161,12 @@ func scanRows[T any](rows *sql.Rows, numRows int) ([]T, error) {
 		rowIdx++
 	}
 
+	// Number of rows can be smaller than estimated numRows when query has
+	// LIMIT clause. Drop the unused preallocated values
+	if numRows > rowIdx {
+		values = values[:rowIdx]
+	}
+
 	// If we failed to scan any rows, return error which will be included in warnings
 	// in the response
 	if scanErrs > 0 {
@@ -136,7 +191,7 @@ func countRows(ctx context.Context, dbConn *sql.DB, query Query) (int, error) {
 	// Prepare SQL statements
 	countQuery := queryRegexp.ReplaceAllString(queryString, "SELECT COUNT(*) FROM $2")
 
-	countStmt, err := dbConn.Prepare(countQuery)
+	countStmt, err := snapshotConn(ctx, dbConn).PrepareContext(ctx, countQuery)
 	if err != nil {
 		return 0, err
 	}
@@ -180,9 +235,22 @@ func Querier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *sl
 
 	var err error
 
+	start := time.Now()
+
 	// If requested model is units, get number of rows
 	switch any(*new(T)).(type) {
 	case models.Unit:
+		// Count and rows must be read from the same snapshot. Otherwise, rows
+		// committed in between are silently dropped while scanning
+		var done func()
+
+		if ctx, done, err = withSnapshot(ctx, dbConn); err != nil {
+			logger.Error("Failed to start read transaction", "err", err)
+
+			return nil, err
+		}
+		defer done()
+
 		if numRows, err = countRows(ctx, dbConn, query); err != nil {
 			logger.Error("Failed to get rows count", "err", err)
 
@@ -192,18 +260,99 @@ func Querier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *sl
 		numRows = 0
 	}
 
+	rows, closeRows, err := queryRows(ctx, dbConn, query, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows()
+
+	// Loop through rows, using Scan to assign column data to struct fields.
+	queryString, queryParams := query.get()
+	logger.Debug(
+		"DB query", "query", queryString, "queryParams", strings.Join(queryParams, ","),
+		"num_rows", numRows,
+	)
+
+	values, err := scanRows[T](rows, numRows)
+
+	observeQuery[T](ctx, start, len(values))
+
+	return values, err
+}
+
+// StreamQuerier queries the DB and calls fn for each row as it is scanned
+// without buffering all the rows in memory. Rows that cannot be scanned are
+// skipped and reported in the returned error. Iteration stops at the first
+// error returned by fn.
+func StreamQuerier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *slog.Logger, fn func(T) error) error {
+	start := time.Now()
+
+	rows, closeRows, err := queryRows(ctx, dbConn, query, logger)
+	if err != nil {
+		return err
+	}
+	defer closeRows()
+
+	queryString, queryParams := query.get()
+	logger.Debug("DB stream query", "query", queryString, "queryParams", strings.Join(queryParams, ","))
+
+	var columns []string
+
+	if columns, err = rows.Columns(); err != nil {
+		return fmt.Errorf("cannot fetch columns: %w", err)
+	}
+
+	var value T
+
+	indexes := structset.CachedFieldIndexes(reflect.TypeOf(&value).Elem())
+	scanErrs := 0
+	numRows := 0
+
+	defer func() { observeQuery[T](ctx, start, numRows) }()
+
+	for rows.Next() {
+		// Always start from a zero value so that fields of previous row
+		// do not leak into current one
+		value = *new(T)
+
+		if err := structset.ScanRow(rows, columns, indexes, &value); err != nil {
+			scanErrs++
+
+			continue
+		}
+
+		numRows++
+
+		if err := fn(value); err != nil {
+			return err
+		}
+	}
+
+	if scanErrs > 0 {
+		err = fmt.Errorf("failed to scan %d rows", scanErrs)
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		err = errors.Join(err, errRows)
+	}
+
+	return err
+}
+
+// queryRows prepares and executes query and returns the resulting rows. The
+// returned function must be called to release rows and statement.
+func queryRows(ctx context.Context, dbConn *sql.DB, query Query, logger *slog.Logger) (*sql.Rows, func(), error) {
 	// Get query string and params
 	queryString, queryParams := query.get()
 
-	queryStmt, err := dbConn.Prepare(queryString)
+	queryStmt, err := snapshotConn(ctx, dbConn).PrepareContext(ctx, queryString)
 	if err != nil {
 		logger.Error("Failed prepare query statement",
 			"query", queryString, "queryParams", strings.Join(queryParams, ","), "err", err,
 		)
 
-		return nil, err
+		return nil, nil, err
 	}
-	defer queryStmt.Close()
 
 	// queryParams has to be an inteface. Do casting here
 	qParams := make([]interface{}, len(queryParams))
@@ -217,15 +366,13 @@ func Querier[T any](ctx context.Context, dbConn *sql.DB, query Query, logger *sl
 			"query", queryString, "queryParams", strings.Join(queryParams, ","), "err", err,
 		)
 
-		return nil, err
-	}
-	defer rows.Close()
+		queryStmt.Close()
 
-	// Loop through rows, using Scan to assign column data to struct fields.
-	logger.Debug(
-		"DB query", "query", queryString, "queryParams", strings.Join(queryParams, ","),
-		"num_rows", numRows,
-	)
+		return nil, nil, err
+	}
 
-	return scanRows[T](rows, numRows)
+	return rows, func() {
+		rows.Close()
+		queryStmt.Close()
+	}, nil
 }
@@ -14,11 +14,26 @@ import (
 	"testing"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// histogramSamples returns sample count and sum of histogram h.
+func histogramSamples(t *testing.T, h prometheus.Observer) (uint64, float64) {
+	t.Helper()
+
+	m := &dto.Metric{}
+	require.NoError(t, h.(prometheus.Metric).Write(m))
+
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
+
 func setupTestDB() (*sql.DB, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
@@ -172,8 +187,113 @@ func TestUnitsQuerier(t *testing.T) {
 	units, err := Querier[models.Unit](context.Background(), db, q, logger)
 	require.NoError(t, err)
 	assert.Equal(t, expectedUnits, units)
+
+	// Query with sort and limit must not return empty units
+	q = Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT * FROM %s WHERE ignore = 0 ORDER BY json_extract(total_time_seconds, '$.walltime') DESC LIMIT 2",
+			base.UnitsDBTableName,
+		),
+	)
+
+	units, err = Querier[models.Unit](context.Background(), db, q, logger)
+	require.NoError(t, err)
+	require.Len(t, units, 2)
+
+	for _, unit := range units {
+		assert.NotEmpty(t, unit.UUID)
+	}
+}
+
+func TestUnitsQuerierNodeFilter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(t.TempDir(), base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", logger)
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	_, err = dbConn.Exec(`INSERT INTO units (id,cluster_id,uuid,started_at_ts,ignore) VALUES (1,'slurm-0','1000',1,0),(2,'slurm-0','1001',2,0)`)
+	require.NoError(t, err)
+	_, err = dbConn.Exec(`INSERT INTO unit_nodes (unit_id,node) VALUES (1,'compute-0'),(1,'compute-1'),(2,'compute-10')`)
+	require.NoError(t, err)
+
+	// Query with node filter must match only exact node names
+	for node, expectedUUIDs := range map[string][]string{"compute-1": {"1000"}, "compute-10": {"1001"}, "compute-": {}} {
+		q := Query{}
+		q.query(fmt.Sprintf("SELECT uuid FROM %s WHERE ignore = 0", base.UnitsDBTableName))
+		q.query(fmt.Sprintf(" AND id IN (SELECT unit_id FROM %s WHERE node IN ", base.UnitNodesDBTableName))
+		q.param([]string{node})
+		q.query(")")
+
+		units, err := Querier[models.Unit](context.Background(), dbConn, q, logger)
+		require.NoError(t, err)
+
+		uuids := []string{}
+		for _, unit := range units {
+			uuids = append(uuids, unit.UUID)
+		}
+
+		assert.Equal(t, expectedUUIDs, uuids, node)
+	}
 }
 
+func TestStreamQuerier(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	db, err := setupTestDB()
+	require.NoError(t, err, "failed to setup test DB")
+	defer db.Close()
+
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT * FROM %s WHERE ignore = 0 AND username in ('usr1') AND cluster_id in ('slurm-0')",
+			base.UnitsDBTableName,
+		),
+	)
+
+	countBefore, rowsBefore := histogramSamples(t, dbQueryRows.WithLabelValues("unit"))
+
+	// Streamed units must be same as the ones returned by Querier
+	expectedUnits, err := Querier[models.Unit](context.Background(), db, q, logger)
+	require.NoError(t, err)
+
+	var units []models.Unit
+
+	err = StreamQuerier(context.Background(), db, q, logger, func(u models.Unit) error {
+		units = append(units, u)
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, expectedUnits, units)
+
+	// Both queries must be observed in DB query metrics
+	countAfter, rowsAfter := histogramSamples(t, dbQueryRows.WithLabelValues("unit"))
+	assert.Equal(t, countBefore+2, countAfter)
+	assert.InDelta(t, rowsBefore+float64(2*len(units)), rowsAfter, 0)
+
+	count, _ := histogramSamples(t, dbQueryDuration.WithLabelValues("unit"))
+	assert.GreaterOrEqual(t, count, uint64(2))
+
+	// Error returned by callback must stop iteration
+	var numCalls int
+
+	err = StreamQuerier(context.Background(), db, q, logger, func(u models.Unit) error {
+		numCalls++
+
+		return errTest
+	})
+	require.ErrorIs(t, err, errTest)
+	assert.Equal(t, 1, numCalls)
+}
 func TestUsageQuerier(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
@@ -287,6 +407,23 @@ func TestUserQuerier(t *testing.T) {
 	users, err := Querier[models.User](context.Background(), db, q, logger)
 	require.NoError(t, err)
 	assert.Equal(t, expectedUsers, users)
+
+	// Query with last activity of user
+	q = Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT *, COALESCE((SELECT CASE WHEN ended_at_ts > created_at_ts THEN ended_at ELSE created_at END FROM %[1]s "+
+				"WHERE %[1]s.username = %[2]s.name AND %[1]s.cluster_id = %[2]s.cluster_id "+
+				"ORDER BY MAX(created_at_ts, ended_at_ts) DESC LIMIT 1), '') AS last_activity_at FROM %[2]s "+
+				"WHERE name IN ('usr1') AND cluster_id IN ('slurm-1')",
+			base.UnitsDBTableName, base.UsersDBTableName,
+		),
+	)
+
+	expectedUsers[0].LastActivityAt = "2023-12-21T15:57:23+0100"
+	users, err = Querier[models.User](context.Background(), db, q, logger)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUsers, users)
 }
 
 func TestClusterQuerier(t *testing.T) {
@@ -420,3 +557,41 @@ func TestSubQueryBuilder(t *testing.T) {
 	require.Equal(t, expectedQueryString, queryString)
 	assert.Equal(t, expectedQueryParams, queryParams)
 }
+
+func TestLikePatternSearch(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	assert.Equal(t, `%50\%\_done\\%`, likePattern(`50%_done\`))
+
+	db, err := setupTestDB()
+	require.NoError(t, err, "failed to setup test DB")
+	defer db.Close()
+
+	tests := []struct {
+		term  string
+		uuids []string
+	}{
+		{term: "SCRIPT1", uuids: []string{"147975", "1479763", "1479765"}},
+		{term: "/home/usr15", uuids: []string{"11508"}},
+		{term: "test%script"},
+	}
+
+	for _, test := range tests {
+		q := Query{}
+		q.query(fmt.Sprintf("SELECT uuid FROM %s WHERE cluster_id = 'slurm-0' AND (name LIKE ", base.UnitsDBTableName))
+		q.param([]string{likePattern(test.term)})
+		q.query(` ESCAPE '\' OR json_extract(tags, '$.workdir') LIKE `)
+		q.param([]string{likePattern(test.term)})
+		q.query(` ESCAPE '\')`)
+
+		units, err := Querier[models.Unit](context.Background(), db, q, logger)
+		require.NoError(t, err, test.term)
+
+		var uuids []string
+		for _, u := range units {
+			uuids = append(uuids, u.UUID)
+		}
+
+		assert.ElementsMatch(t, test.uuids, uuids, test.term)
+	}
+}
@@ -0,0 +1,491 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// quotaRequest is the request body to create or update a quota.
+type quotaRequest struct {
+	ClusterID string  `json:"cluster_id"`
+	Project   string  `json:"project"`
+	CPUHours  float64 `json:"cpu_hours"`
+	GPUHours  float64 `json:"gpu_hours"`
+	EnergyKWh float64 `json:"energy_kwh"`
+	StartTS   int64   `json:"start_ts"`
+	EndTS     int64   `json:"end_ts"`
+}
+
+// valid returns true if the quota request is valid.
+func (q *quotaRequest) valid() bool {
+	q.ClusterID = strings.TrimSpace(q.ClusterID)
+	q.Project = strings.TrimSpace(q.Project)
+
+	if q.ClusterID == "" || q.Project == "" {
+		return false
+	}
+
+	if q.CPUHours < 0 || q.GPUHours < 0 || q.EnergyKWh < 0 || q.CPUHours+q.GPUHours+q.EnergyKWh == 0 {
+		return false
+	}
+
+	return q.StartTS >= 0 && (q.EndTS == 0 || q.EndTS > q.StartTS)
+}
+
+// consumedQuotas sets resources consumed by projects during the period of each
+// quota estimated from daily usage statistics.
+func (s *CEEMSServer) consumedQuotas(ctx context.Context, quotas []models.Quota) error {
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	projects := make([]string, 0, len(quotas))
+	for _, quota := range quotas {
+		projects = append(projects, quota.Project)
+	}
+
+	slices.Sort(projects)
+
+	q := Query{}
+	q.query(
+		"SELECT cluster_id,project,last_updated_at,total_time_seconds,total_cpu_energy_usage_kwh," +
+			"total_gpu_energy_usage_kwh FROM " + base.DailyUsageDBTableName + " WHERE project IN ",
+	)
+	q.param(slices.Compact(projects))
+
+	usage, err := s.queriers.usage(ctx, s.db, q, s.logger)
+	if usage == nil && err != nil {
+		return err
+	}
+
+	// Energy consumption is estimated using same sub metric as billing
+	energyMetric := cmp.Or(s.billing.EnergyMetric, defaultBillingEnergyMetric)
+	location := s.timeLocation("")
+
+	for i := range quotas {
+		// Daily usage is keyed on midnight of each day in DB layout and hence
+		// string comparison is enough to check if usage is within quota period
+		var start, end string
+		if quotas[i].StartTS > 0 {
+			t := time.Unix(quotas[i].StartTS, 0).In(location)
+			start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location).Format(base.DatetimeLayout)
+		}
+
+		if quotas[i].EndTS > 0 {
+			end = time.Unix(quotas[i].EndTS, 0).In(location).Format(base.DatetimeLayout)
+		}
+
+		consumed := models.MetricMap{"cpu_hours": 0, "gpu_hours": 0, "energy_kwh": 0}
+
+		for _, u := range usage {
+			if u.ClusterID != quotas[i].ClusterID || u.Project != quotas[i].Project {
+				continue
+			}
+
+			if u.LastUpdatedAt < start || (end != "" && u.LastUpdatedAt > end) {
+				continue
+			}
+
+			consumed["cpu_hours"] += u.TotalTime["alloc_cputime"] / 3600
+			consumed["gpu_hours"] += u.TotalTime["alloc_gputime"] / 3600
+			consumed["energy_kwh"] += u.TotalCPUEnergyUsage[energyMetric] + u.TotalGPUEnergyUsage[energyMetric]
+		}
+
+		// Fraction is only set for limited resources
+		fraction := make(models.MetricMap)
+
+		for name, allocated := range map[string]float64{
+			"cpu_hours":  quotas[i].CPUHours,
+			"gpu_hours":  quotas[i].GPUHours,
+			"energy_kwh": quotas[i].EnergyKWh,
+		} {
+			if allocated > 0 {
+				fraction[name] = consumed[name] / models.JSONFloat(allocated)
+			}
+		}
+
+		quotas[i].Consumed = consumed
+		quotas[i].Fraction = fraction
+	}
+
+	return err
+}
+
+// quotasQuerier fetches quotas of projects of users along with their consumption
+// and writes them in response. If users is empty, quotas of all projects are returned.
+func (s *CEEMSServer) quotasQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "quotas endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE 1=1",
+			strings.Join(base.QuotasDBTableColNames, ","),
+			base.QuotasDBTableName,
+		),
+	)
+
+	// Limit to projects of users
+	if len(users) > 0 {
+		q.query(" AND project IN ")
+		q.subQuery(projectsSubQuery(users))
+	}
+
+	// Add common query parameters
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+	q.query(" ORDER BY cluster_id ASC, project ASC")
+
+	quotas, err := s.queriers.quota(r.Context(), s.db, q, s.logger)
+	if quotas == nil && err != nil {
+		s.logger.Error("Failed to fetch quotas", "users", strings.Join(users, ","), "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Estimate consumption of quotas
+	var warnings []string
+
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	if err := s.consumedQuotas(r.Context(), quotas); err != nil {
+		s.logger.Error("Failed to estimate consumption of quotas", "err", err)
+
+		warnings = append(warnings, err.Error())
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	quotasResponse := Response[models.Quota]{
+		Status:   "success",
+		Data:     quotas,
+		Warnings: warnings,
+	}
+
+	if err = json.NewEncoder(w).Encode(&quotasResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// quotas         godoc
+//
+//	@Summary		Quotas of projects of current user
+//	@Description	This endpoint returns the quotas of the projects that the current user is
+//	@Description	part of along with the resources consumed by the projects during the quota
+//	@Description	period. The current user is always identified by the header `X-Grafana-User`
+//	@Description	in the request.
+//	@Description
+//	@Description	Consumed CPU hours, GPU hours and energy are estimated from the usage
+//	@Description	statistics of the projects and `used_fraction` gives the fraction of the
+//	@Description	allocated resources that have been consumed. Portals can use this fraction
+//	@Description	to warn users approaching the limits. Quotas can be limited to certain
+//	@Description	clusters and projects using `cluster_id` and `project` query parameters.
+//	@Security		BasicAuth
+//	@Tags			quotas
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Quota]
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/quotas [get]
+//
+// GET /quotas
+// Get quotas of projects of current user.
+func (s *CEEMSServer) quotas(w http.ResponseWriter, r *http.Request) {
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	s.quotasQuerier([]string{dashboardUser}, w, r)
+}
+
+// quotasAdmin         godoc
+//
+//	@Summary		Admin endpoint to list quotas
+//	@Description	This admin endpoint returns the quotas of all projects along with the
+//	@Description	resources consumed by the projects during the quota period. The current
+//	@Description	user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Security		BasicAuth
+//	@Tags			quotas
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Quota]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/quotas/admin [get]
+//
+// GET /quotas/admin
+// List quotas.
+func (s *CEEMSServer) quotasAdmin(w http.ResponseWriter, r *http.Request) {
+	s.quotasQuerier(nil, w, r)
+}
+
+// decodeQuotaRequest decodes and validates quota request body.
+func decodeQuotaRequest(w http.ResponseWriter, r *http.Request) (*quotaRequest, error) {
+	var req quotaRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !req.valid() {
+		return nil, errInvalidQuota
+	}
+
+	return &req, nil
+}
+
+// createQuotaAdmin         godoc
+//
+//	@Summary		Admin endpoint to create quotas
+//	@Description	This admin endpoint will create a quota for a project. If a quota exists
+//	@Description	already for the project, it will be replaced. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The request body must be a JSON object with `cluster_id` and `project` keys
+//	@Description	and at least one of `cpu_hours`, `gpu_hours` and `energy_kwh` allocations.
+//	@Description	Allocations that are zero are not limited. Optional `start_ts` and `end_ts`
+//	@Description	keys set the quota period as unix timestamps in seconds. By default, all
+//	@Description	usage of the project is accounted.
+//	@Security		BasicAuth
+//	@Tags			quotas
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string			true	"Current user name"
+//	@Param			quota			body		quotaRequest	true	"Quota"
+//	@Success		201				{object}	Response[models.Quota]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/quotas/admin [post]
+//
+// POST /quotas/admin
+// Create quota.
+func (s *CEEMSServer) createQuotaAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "create quota admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	req, err := decodeQuotaRequest(w, r)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+
+	quota := models.Quota{
+		ClusterID: req.ClusterID,
+		Project:   req.Project,
+		CPUHours:  req.CPUHours,
+		GPUHours:  req.GPUHours,
+		EnergyKWh: req.EnergyKWh,
+		StartTS:   req.StartTS,
+		EndTS:     req.EndTS,
+		UpdatedBy: loggedUser,
+		UpdatedAt: time.Now().In(s.timeLocation("")).Format(base.DatetimezoneLayout),
+	}
+
+	//nolint:gosec
+	if err = s.dbRW.QueryRowContext(
+		r.Context(),
+		fmt.Sprintf(
+			`INSERT INTO %s (cluster_id,project,cpu_hours,gpu_hours,energy_kwh,start_ts,end_ts,updated_by,updated_at)
+VALUES (?,?,?,?,?,?,?,?,?) ON CONFLICT(cluster_id,project) DO UPDATE SET
+cpu_hours = excluded.cpu_hours, gpu_hours = excluded.gpu_hours, energy_kwh = excluded.energy_kwh,
+start_ts = excluded.start_ts, end_ts = excluded.end_ts, updated_by = excluded.updated_by,
+updated_at = excluded.updated_at RETURNING id`,
+			base.QuotasDBTableName,
+		),
+		quota.ClusterID, quota.Project, quota.CPUHours, quota.GPUHours, quota.EnergyKWh,
+		quota.StartTS, quota.EndTS, quota.UpdatedBy, quota.UpdatedAt,
+	).Scan(&quota.ID); err != nil {
+		s.logger.Error("Failed to create quota", "cluster_id", quota.ClusterID, "project", quota.Project, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	s.logger.Info(
+		"Quota created", "id", quota.ID, "cluster_id", quota.ClusterID, "project", quota.Project,
+		"created_by", loggedUser,
+	)
+
+	// Write response
+	w.WriteHeader(http.StatusCreated)
+
+	response := Response[models.Quota]{
+		Status: "success",
+		Data:   []models.Quota{quota},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// updateQuotaAdmin         godoc
+//
+//	@Summary		Admin endpoint to update quotas
+//	@Description	This admin endpoint will update the quota with the given ID. The current
+//	@Description	user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The request body has the same format as the one used to create quotas.
+//	@Security		BasicAuth
+//	@Tags			quotas
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header	string			true	"Current user name"
+//	@Param			id				path	int				true	"Quota ID"
+//	@Param			quota			body	quotaRequest	true	"Quota"
+//	@Success		204
+//	@Failure		400	{object}	Problem
+//	@Failure		401	{object}	Problem
+//	@Failure		403	{object}	Problem
+//	@Failure		404	{object}	Problem
+//	@Failure		500	{object}	Problem
+//	@Router			/quotas/{id}/admin [put]
+//
+// PUT /quotas/{id}/admin
+// Update quota.
+func (s *CEEMSServer) updateQuotaAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "update quota admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
+
+		return
+	}
+
+	req, err := decodeQuotaRequest(w, r)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf(
+			`UPDATE %s SET cluster_id = ?, project = ?, cpu_hours = ?, gpu_hours = ?, energy_kwh = ?,
+start_ts = ?, end_ts = ?, updated_by = ?, updated_at = ? WHERE id = ?`,
+			base.QuotasDBTableName,
+		),
+		req.ClusterID, req.Project, req.CPUHours, req.GPUHours, req.EnergyKWh, req.StartTS, req.EndTS,
+		loggedUser, time.Now().In(s.timeLocation("")).Format(base.DatetimezoneLayout), id,
+	)
+	if err != nil {
+		s.logger.Error("Failed to update quota", "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errQuotaNotFound}, s.logger)
+
+		return
+	}
+
+	s.logger.Info("Quota updated", "id", id, "updated_by", loggedUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteQuotaAdmin         godoc
+//
+//	@Summary		Admin endpoint to delete quotas
+//	@Description	This admin endpoint will delete the quota with the given ID. The current
+//	@Description	user is always identified by the header `X-Grafana-User` in the request.
+//	@Security		BasicAuth
+//	@Tags			quotas
+//	@Produce		json
+//	@Param			X-Grafana-User	header	string	true	"Current user name"
+//	@Param			id				path	int		true	"Quota ID"
+//	@Success		204
+//	@Failure		400	{object}	Problem
+//	@Failure		401	{object}	Problem
+//	@Failure		403	{object}	Problem
+//	@Failure		404	{object}	Problem
+//	@Failure		500	{object}	Problem
+//	@Router			/quotas/{id}/admin [delete]
+//
+// DELETE /quotas/{id}/admin
+// Delete quota.
+func (s *CEEMSServer) deleteQuotaAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "delete quota admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
+
+		return
+	}
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(), fmt.Sprintf("DELETE FROM %s WHERE id = ?", base.QuotasDBTableName), id,
+	)
+	if err != nil {
+		s.logger.Error("Failed to delete quota", "id", id, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errQuotaNotFound}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+	s.logger.Info("Quota deleted", "id", id, "deleted_by", loggedUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
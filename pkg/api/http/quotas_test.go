@@ -0,0 +1,147 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaRequestValid(t *testing.T) {
+	for _, test := range []struct {
+		req   quotaRequest
+		valid bool
+	}{
+		{quotaRequest{ClusterID: "rm-0", Project: "prj1", CPUHours: 10}, true},
+		{quotaRequest{ClusterID: "rm-0", Project: "prj1", EnergyKWh: 10, StartTS: 10, EndTS: 20}, true},
+		{quotaRequest{ClusterID: " ", Project: "prj1", CPUHours: 10}, false},
+		{quotaRequest{ClusterID: "rm-0", CPUHours: 10}, false},
+		{quotaRequest{ClusterID: "rm-0", Project: "prj1"}, false},
+		{quotaRequest{ClusterID: "rm-0", Project: "prj1", CPUHours: 10, GPUHours: -1}, false},
+		{quotaRequest{ClusterID: "rm-0", Project: "prj1", CPUHours: 10, StartTS: 20, EndTS: 10}, false},
+	} {
+		assert.Equal(t, test.valid, test.req.valid(), test.req)
+	}
+}
+
+func TestQuotasHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add admin user, projects and usage
+	_, err = dbConn.Exec(`
+INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','');
+INSERT INTO projects (cluster_id,name,users) VALUES ('rm-0','prj1','["usr1","usr2"]');
+INSERT INTO projects (cluster_id,name,users) VALUES ('rm-0','prj2','["usr2"]');
+INSERT INTO daily_usage (cluster_id,project,username,total_time_seconds,total_cpu_energy_usage_kwh,last_updated_at)
+VALUES ('rm-0','prj1','usr1','{"alloc_cputime":36000}','{"total":2}','2024-01-01T00:00:00');
+INSERT INTO daily_usage (cluster_id,project,username,total_time_seconds,total_cpu_energy_usage_kwh,last_updated_at)
+VALUES ('rm-0','prj1','usr2','{"alloc_cputime":36000,"alloc_gputime":3600}','{"total":3}','2024-01-02T00:00:00');
+INSERT INTO daily_usage (cluster_id,project,username,total_time_seconds,last_updated_at)
+VALUES ('rm-0','prj1','usr1','{"alloc_cputime":360000}','2023-12-01T00:00:00');`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.quota = Querier[models.Quota]
+	server.queriers.usage = Querier[models.Usage]
+
+	// Make request through middlewares. Server limits requests to 10 per minute
+	do := func(method, path, user, body string) (*httptest.ResponseRecorder, Response[models.Quota]) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(grafanaUserHeader, user)
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.Quota]
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w, response
+	}
+
+	// Only admins can create quotas
+	body := `{"cluster_id": "rm-0", "project": "prj1", "cpu_hours": 40, "energy_kwh": 10, "start_ts": 1704067200}`
+	w, _ := do(http.MethodPost, "/api/v1/quotas/admin", "usr1", body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, resp := do(http.MethodPost, "/api/v1/quotas/admin", "adm1", body)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "adm1", resp.Data[0].UpdatedBy)
+
+	quotaID := strconv.FormatInt(resp.Data[0].ID, 10)
+
+	// Creating quota for same project must replace existing one
+	w, resp = do(http.MethodPost, "/api/v1/quotas/admin", "adm1", strings.Replace(body, "40", "20", 1))
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, quotaID, strconv.FormatInt(resp.Data[0].ID, 10))
+
+	w, _ = do(http.MethodPost, "/api/v1/quotas/admin", "adm1", `{"cluster_id": "rm-0", "project": "prj2", "gpu_hours": 5}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	// Users get quotas of only their projects with consumption during quota period
+	w, resp = do(http.MethodGet, "/api/v1/quotas", "usr1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.InDelta(t, 20, resp.Data[0].CPUHours, 1e-9)
+	assert.InDelta(t, 20, float64(resp.Data[0].Consumed["cpu_hours"]), 1e-9)
+	assert.InDelta(t, 1, float64(resp.Data[0].Consumed["gpu_hours"]), 1e-9)
+	assert.InDelta(t, 5, float64(resp.Data[0].Consumed["energy_kwh"]), 1e-9)
+	assert.InDelta(t, 1, float64(resp.Data[0].Fraction["cpu_hours"]), 1e-9)
+	assert.InDelta(t, 0.5, float64(resp.Data[0].Fraction["energy_kwh"]), 1e-9)
+	assert.NotContains(t, resp.Data[0].Fraction, "gpu_hours")
+
+	_, resp = do(http.MethodGet, "/api/v1/quotas/admin?project=prj2", "adm1", "")
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "prj2", resp.Data[0].Project)
+
+	// Update and delete quotas
+	w, _ = do(
+		http.MethodPut, "/api/v1/quotas/"+quotaID+"/admin", "adm1",
+		`{"cluster_id": "rm-0", "project": "prj1", "cpu_hours": 40, "end_ts": 1704067200}`,
+	)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	_, resp = do(http.MethodGet, "/api/v1/quotas", "usr1", "")
+	require.Len(t, resp.Data, 1)
+	assert.InDelta(t, 110, float64(resp.Data[0].Consumed["cpu_hours"]), 1e-9)
+
+	w, _ = do(http.MethodDelete, "/api/v1/quotas/"+quotaID+"/admin", "adm1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w, _ = do(http.MethodDelete, "/api/v1/quotas/"+quotaID+"/admin", "adm1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,176 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interval at which idle token buckets are purged.
+const bucketPurgeInterval = time.Minute
+
+var errTooManyRequests = errors.New("too many requests. Retry later")
+
+// RateLimitConfig contains the token bucket settings of a rate limit.
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// UserRateLimitConfig contains the per user rate limits. Default limits apply to
+// all routes and they can be overridden for each route using resource name of
+// the route like units, usage, etc.
+type UserRateLimitConfig struct {
+	RateLimitConfig `yaml:",inline"`
+	Routes          map[string]RateLimitConfig `yaml:"routes"`
+}
+
+// tokenBucket is the token bucket of a user on a given route.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	limit  RateLimitConfig
+}
+
+// refill adds the tokens accumulated since last update.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(
+		float64(b.limit.Burst),
+		b.tokens+now.Sub(b.last).Seconds()*b.limit.RequestsPerSecond,
+	)
+	b.last = now
+}
+
+// userRateLimiter limits the rate of requests of each user using token buckets.
+// Users are identified by the logged user header set by authentication middleware
+// and hence, limiter must be placed after it in the middleware chain.
+type userRateLimiter struct {
+	logger      *slog.Logger
+	routePrefix string
+	defaults    RateLimitConfig
+	routes      map[string]RateLimitConfig
+	mu          sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastPurge   time.Time
+	now         func() time.Time
+}
+
+// newUserRateLimiter returns a new userRateLimiter. A nil limiter is returned when
+// no rate limits are configured.
+func newUserRateLimiter(c UserRateLimitConfig, routePrefix string, logger *slog.Logger) *userRateLimiter {
+	enabled := c.RequestsPerSecond > 0
+
+	routes := make(map[string]RateLimitConfig, len(c.Routes))
+	for route, limit := range c.Routes {
+		routes[route] = withDefaultBurst(limit)
+		enabled = enabled || limit.RequestsPerSecond > 0
+	}
+
+	if !enabled {
+		return nil
+	}
+
+	return &userRateLimiter{
+		logger:      logger,
+		routePrefix: routePrefix,
+		defaults:    withDefaultBurst(c.RateLimitConfig),
+		routes:      routes,
+		buckets:     make(map[string]*tokenBucket),
+		lastPurge:   time.Now(),
+		now:         time.Now,
+	}
+}
+
+// Middleware implements mux.MiddlewareFunc.
+func (l *userRateLimiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Requests that are not authenticated like health checks are not limited
+		user := r.Header.Get(loggedUserHeader)
+		if user == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		route, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, l.routePrefix), "/")
+
+		limit, ok := l.routes[route]
+		if !ok {
+			limit = l.defaults
+		}
+
+		if limit.RequestsPerSecond <= 0 {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if wait, ok := l.allow(route+"/"+user, limit); !ok {
+			l.logger.Debug("Rate limit exceeded. Rejecting request", "user", user, "url", r.URL.Path)
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			errorResponse(w, r, &apiError{errorTooManyRequests, errTooManyRequests}, l.logger)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from the bucket of key. When bucket is empty, it returns
+// false along with the time until the next token is available.
+func (l *userRateLimiter) allow(key string, limit RateLimitConfig) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	// Purge buckets that are full as they are equivalent to new ones
+	if now.Sub(l.lastPurge) > bucketPurgeInterval {
+		for k, b := range l.buckets {
+			if b.refill(now); b.tokens >= float64(b.limit.Burst) {
+				delete(l.buckets, k)
+			}
+		}
+
+		l.lastPurge = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		l.buckets[key] = b
+	}
+
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+
+		return 0, true
+	}
+
+	return time.Duration((1 - b.tokens) / limit.RequestsPerSecond * float64(time.Second)), false
+}
+
+// withDefaultBurst sets burst to the requests per second when it is not set.
+func withDefaultBurst(c RateLimitConfig) RateLimitConfig {
+	if c.Burst <= 0 {
+		c.Burst = max(1, int(math.Ceil(c.RequestsPerSecond)))
+	}
+
+	return c
+}
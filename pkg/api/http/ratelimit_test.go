@@ -0,0 +1,93 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRateLimiter(t *testing.T) {
+	l := newUserRateLimiter(UserRateLimitConfig{
+		RateLimitConfig: RateLimitConfig{RequestsPerSecond: 1, Burst: 2},
+		Routes: map[string]RateLimitConfig{
+			"usage":  {RequestsPerSecond: 0.5},
+			"health": {},
+		},
+	}, "/api/v1/", noOpLogger)
+	require.NotNil(t, l)
+
+	// Mock clock
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if user != "" {
+			req.Header.Set(loggedUserHeader, user)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Default limits allow a burst of two requests
+	assert.Equal(t, http.StatusOK, serve("/api/v1/units", "foo").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/units/admin", "foo").Code)
+
+	w := serve("/api/v1/units", "foo")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	// Other users and routes have their own buckets
+	assert.Equal(t, http.StatusOK, serve("/api/v1/units", "bar").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/projects", "foo").Code)
+
+	// Route specific limits with default burst
+	assert.Equal(t, http.StatusOK, serve("/api/v1/usage/current", "foo").Code)
+
+	w = serve("/api/v1/usage/current", "foo")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	// Routes without limits and unauthenticated requests are not limited
+	for range 5 {
+		assert.Equal(t, http.StatusOK, serve("/api/v1/health", "foo").Code)
+		assert.Equal(t, http.StatusOK, serve("/api/v1/units", "").Code)
+	}
+
+	// Tokens are refilled over time
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/units", "foo").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("/api/v1/units", "foo").Code)
+
+	// Full buckets are purged
+	now = now.Add(2 * bucketPurgeInterval)
+	assert.Equal(t, http.StatusOK, serve("/api/v1/units", "foo").Code)
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestUserRateLimiterDisabled(t *testing.T) {
+	l := newUserRateLimiter(UserRateLimitConfig{}, "/api/v1/", noOpLogger)
+	assert.Nil(t, l)
+
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/units", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
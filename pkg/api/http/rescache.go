@@ -0,0 +1,214 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/prometheus/common/model"
+)
+
+// Maximum size of a response body that is cached.
+const maxCachedResponseSize = 8 << 20
+
+// Default maximum number of cached responses.
+const defaultResponseCacheEntries = 1000
+
+// ResponseCacheConfig contains the configuration of cache of responses of
+// expensive routes.
+type ResponseCacheConfig struct {
+	TTL        model.Duration `yaml:"ttl"`
+	MaxEntries uint64         `yaml:"max_entries"`
+}
+
+// cachedResponse is a response stored in cache.
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// responseCache caches the successful responses of GET requests for a short TTL.
+// Responses are keyed on the user headers, Accept header, path and normalized
+// query of request so that users loading the same dashboard do not trigger same
+// DB queries.
+type responseCache struct {
+	logger *slog.Logger
+	ttl    time.Duration
+	cache  *ttlcache.Cache[string, cachedResponse]
+}
+
+// newResponseCache returns a new responseCache. A nil cache is returned when TTL
+// is not positive, which does not cache any responses.
+func newResponseCache(c ResponseCacheConfig, logger *slog.Logger) *responseCache {
+	if c.TTL <= 0 {
+		return nil
+	}
+
+	if c.MaxEntries == 0 {
+		c.MaxEntries = defaultResponseCacheEntries
+	}
+
+	cache := ttlcache.New(
+		ttlcache.WithTTL[string, cachedResponse](time.Duration(c.TTL)),
+		ttlcache.WithCapacity[string, cachedResponse](c.MaxEntries),
+		ttlcache.WithDisableTouchOnHit[string, cachedResponse](),
+	)
+
+	// Starts automatic expired item deletion
+	go cache.Start()
+
+	return &responseCache{
+		logger: logger,
+		ttl:    time.Duration(c.TTL),
+		cache:  cache,
+	}
+}
+
+// Stop stops the automatic deletion of expired items.
+func (c *responseCache) Stop() {
+	if c == nil {
+		return
+	}
+
+	c.cache.Stop()
+}
+
+// Handler wraps the handler h with response cache.
+func (c *responseCache) Handler(h http.Handler) http.Handler {
+	if c == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.ServeHTTP(w, r)
+
+			return
+		}
+
+		key := c.key(r)
+
+		if item := c.cache.Get(key); item != nil {
+			resp := item.Value()
+
+			for name, values := range resp.header {
+				w.Header()[name] = values
+			}
+
+			w.Header().Set("Expires", item.ExpiresAt().UTC().Format(http.TimeFormat))
+
+			// Honor conditional requests when cached response has an entity tag
+			if etag := resp.header.Get("ETag"); etag != "" && etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			w.WriteHeader(resp.status)
+			w.Write(resp.body)
+
+			return
+		}
+
+		rec := &cacheRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r)
+
+		if rec.status == http.StatusOK && !rec.overflow {
+			c.cache.Set(key, cachedResponse{status: rec.status, header: rec.header, body: rec.body.Bytes()}, ttlcache.DefaultTTL)
+		}
+	})
+}
+
+// key returns the cache key of request. Time stamps in `from` and `to` query
+// parameters are rounded to TTL so that requests made within the same TTL
+// share the cached response.
+func (c *responseCache) key(r *http.Request) string {
+	ttlSeconds := max(1, int64(c.ttl.Seconds()))
+	q := make(url.Values)
+
+	for name, values := range r.URL.Query() {
+		values = slices.Clone(values)
+
+		if name == "from" || name == "to" {
+			for i, v := range values {
+				if ts, err := strconv.ParseInt(v, 10, 64); err == nil {
+					values[i] = strconv.FormatInt(ts-ts%ttlSeconds, 10)
+				}
+			}
+		}
+
+		slices.Sort(values)
+		q[name] = values
+	}
+
+	h := sha256.New()
+	for _, part := range []string{
+		r.URL.Path, q.Encode(), r.Header.Get(loggedUserHeader), r.Header.Get(dashboardUserHeader),
+		r.Header.Get(adminUserHeader), r.Header.Get("Accept"),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheRecorder records the response of handler while writing it to underlying
+// response writer.
+type cacheRecorder struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	overflow bool
+}
+
+// WriteHeader records status code and headers and writes it to underlying response writer.
+func (r *cacheRecorder) WriteHeader(code int) {
+	if r.status != 0 {
+		return
+	}
+
+	// Headers must be recorded before writing them as outer middlewares
+	// like compression can modify them
+	r.status = code
+	r.header = r.Header().Clone()
+	r.header.Del("Content-Encoding")
+	r.header.Del("Content-Length")
+
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write records b and writes it to underlying response writer.
+func (r *cacheRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxCachedResponseSize {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns underlying response writer. It is used by http.ResponseController.
+func (r *cacheRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
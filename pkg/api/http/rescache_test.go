@@ -0,0 +1,133 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	c := newResponseCache(ResponseCacheConfig{TTL: model.Duration(time.Minute)}, noOpLogger)
+	require.NotNil(t, c)
+
+	defer c.Stop()
+
+	var calls int
+
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+
+	serve := func(url, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set(loggedUserHeader, user)
+		req.Header.Set(dashboardUserHeader, user)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// First request must hit the handler
+	w := serve("/api/v1/units?project=foo&project=bar&from=1700000010", "usr1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"success"}`, w.Body.String())
+	assert.Empty(t, w.Header().Get("Expires"))
+	assert.Equal(t, 1, calls)
+
+	// Same query with different order of parameters and time stamp within TTL
+	// must be served from cache
+	w = serve("/api/v1/units?from=1700000025&project=bar&project=foo", "usr1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"success"}`, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("Expires"))
+	assert.Equal(t, 1, calls)
+
+	// Different users must not share cached responses
+	serve("/api/v1/units?project=foo&project=bar&from=1700000010", "usr2")
+	assert.Equal(t, 2, calls)
+
+	// Errors must not be cached
+	serve("/api/v1/units?fail=1", "usr1")
+	serve("/api/v1/units?fail=1", "usr1")
+	assert.Equal(t, 4, calls)
+
+	// Non GET requests must not be cached
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/units?project=foo&project=bar&from=1700000010", nil)
+	req.Header.Set(loggedUserHeader, "usr1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 5, calls)
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	c := newResponseCache(ResponseCacheConfig{}, noOpLogger)
+	assert.Nil(t, c)
+
+	var calls int
+
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for range 2 {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/units", nil))
+	}
+
+	assert.Equal(t, 2, calls)
+	c.Stop()
+}
+
+func TestResponseCacheConditionalRequests(t *testing.T) {
+	c := newResponseCache(ResponseCacheConfig{TTL: model.Duration(time.Minute)}, noOpLogger)
+	require.NotNil(t, c)
+
+	defer c.Stop()
+
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `W/"abc"`)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+
+	serve := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+		req.Header.Set(dashboardUserHeader, "usr1")
+		req.Header.Set("If-None-Match", etag)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Populate cache
+	w := serve(`W/"def"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Cached responses must honor If-None-Match
+	w = serve(`W/"abc"`)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = serve(`W/"def"`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"success"}`, w.Body.String())
+}
@@ -0,0 +1,308 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Get reservations that overlap with query window.
+func (s *CEEMSServer) reservationsQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	// Get query window time stamps
+	fromTime, toTime, err := s.getQueryWindowTimes(r)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE 1=1",
+			strings.Join(base.ReservationsDBTableColNames, ","),
+			base.ReservationsDBTableName,
+		),
+	)
+
+	// Reservations are owned by projects. Return only reservations of
+	// projects that users belong to
+	if len(users) > 0 {
+		q.query(" AND project IN ")
+		q.subQuery(projectsSubQuery(users))
+	}
+
+	// Add project and cluster_id query params
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Reservations that overlap with query window
+	q.query(" AND started_at <= ")
+	q.param([]string{toTime.Format(base.DatetimeLayout)})
+	q.query(" AND ended_at >= ")
+	q.param([]string{fromTime.Format(base.DatetimeLayout)})
+	q.query(" ORDER BY cluster_id ASC, started_at ASC")
+
+	// Make query and get reservations
+	reservations, err := s.queriers.resv(r.Context(), s.db, q, s.logger)
+	if reservations == nil && err != nil {
+		s.logger.Error("Failed to fetch reservations", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	reservationsResponse := Response[models.Reservation]{
+		Status: "success",
+		Data:   reservations,
+	}
+	if err != nil {
+		reservationsResponse.Warnings = append(reservationsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&reservationsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// reservations         godoc
+//
+//	@Summary		Show reservations of projects
+//	@Description	This endpoint will show the resource reservations of the projects that the
+//	@Description	current user belongs to. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	Each reservation reports the reserved, allocated and idle times of each
+//	@Description	reserved resource as estimated by the resource manager.
+//	@Description
+//	@Description	If the query parameters `project` and/or `cluster_id` are provided, only the
+//	@Description	reservations of given project(s) and/or cluster(s) will be returned. The query
+//	@Description	parameters `from` and `to` can be used to control the time window. All the
+//	@Description	reservations that overlap with the time window will be returned. By default,
+//	@Description	reservations of the last one week will be returned.
+//	@Security		BasicAuth
+//	@Tags			reservations
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Reservation]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/reservations [get]
+//
+// GET /reservations
+// Get reservations of projects of current user.
+func (s *CEEMSServer) reservations(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "reservations endpoint", s.logger)
+
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Get reservations
+	s.reservationsQuerier([]string{loggedUser}, w, r)
+}
+
+// reservationsAdmin         godoc
+//
+//	@Summary		Admin endpoint to fetch reservations
+//	@Description	This admin endpoint will show the resource reservations of all projects. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If the query parameters `project` and/or `cluster_id` are provided, only the
+//	@Description	reservations of given project(s) and/or cluster(s) will be returned. The query
+//	@Description	parameters `from` and `to` can be used to control the time window. All the
+//	@Description	reservations that overlap with the time window will be returned. By default,
+//	@Description	reservations of the last one week will be returned.
+//	@Security		BasicAuth
+//	@Tags			reservations
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Reservation]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/reservations/admin [get]
+//
+// GET /reservations/admin
+// Get reservations of all projects.
+func (s *CEEMSServer) reservationsAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "reservations admin endpoint", s.logger)
+
+	// Get reservations
+	s.reservationsQuerier(nil, w, r)
+}
+
+// Get preemption events in query window.
+func (s *CEEMSServer) preemptionsQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current user from header
+	_, dashboardUser := s.getUser(r)
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE 1=1",
+			strings.Join(base.PreemptionsDBTableColNames, ","),
+			base.PreemptionsDBTableName,
+		),
+	)
+
+	if len(users) > 0 {
+		q.query(" AND username IN ")
+		q.param(users)
+	}
+
+	// Add project and cluster_id query params
+	q = s.getCommonQueryParams(&q, r.URL.Query())
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "preempted_at", false, false)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	q.query(" AND ")
+	q.subQuery(timeQuery)
+	q.query(" ORDER BY cluster_id ASC, preempted_at ASC")
+
+	// Make query and get preemptions
+	preemptions, err := s.queriers.preempt(r.Context(), s.db, q, s.logger)
+	if preemptions == nil && err != nil {
+		s.logger.Error("Failed to fetch preemptions", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	preemptionsResponse := Response[models.Preemption]{
+		Status: "success",
+		Data:   preemptions,
+	}
+	if err != nil {
+		preemptionsResponse.Warnings = append(preemptionsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&preemptionsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// preemptions         godoc
+//
+//	@Summary		Show preemptions of current user
+//	@Description	This endpoint will show the preemption events of compute units of the
+//	@Description	current user. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	If the query parameters `project` and/or `cluster_id` are provided, only the
+//	@Description	preemptions of given project(s) and/or cluster(s) will be returned. The query
+//	@Description	parameters `from` and `to` can be used to control the time window. By default,
+//	@Description	preemptions of the last one week will be returned.
+//	@Security		BasicAuth
+//	@Tags			preemptions
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Preemption]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/preemptions [get]
+//
+// GET /preemptions
+// Get preemptions of current user.
+func (s *CEEMSServer) preemptions(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "preemptions endpoint", s.logger)
+
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Get preemptions
+	s.preemptionsQuerier([]string{loggedUser}, w, r)
+}
+
+// preemptionsAdmin         godoc
+//
+//	@Summary		Admin endpoint to fetch preemptions
+//	@Description	This admin endpoint will show the preemption events of compute units of
+//	@Description	all users. The current user is always identified by the header `X-Grafana-User`
+//	@Description	in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	If the query parameters `user`, `project` and/or `cluster_id` are provided,
+//	@Description	only the preemptions of given user(s), project(s) and/or cluster(s) will be
+//	@Description	returned. The query parameters `from` and `to` can be used to control the time
+//	@Description	window. By default, preemptions of the last one week will be returned.
+//	@Security		BasicAuth
+//	@Tags			preemptions
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Preemption]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/preemptions/admin [get]
+//
+// GET /preemptions/admin
+// Get preemptions of all users.
+func (s *CEEMSServer) preemptionsAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "preemptions admin endpoint", s.logger)
+
+	// Get preemptions
+	s.preemptionsQuerier(r.URL.Query()["user"], w, r)
+}
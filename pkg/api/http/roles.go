@@ -0,0 +1,285 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Roles of users.
+const (
+	userRole  = "user"
+	adminRole = "admin"
+)
+
+// apiRoleSource is the source of roles assigned using API server. Roles of
+// other sources are synchronized by updater.
+const apiRoleSource = "api"
+
+// roleRequest is the request body to assign a role to a user.
+type roleRequest struct {
+	Role string `json:"role"`
+}
+
+// rolesAdmin         godoc
+//
+//	@Summary		Admin endpoint to list roles
+//	@Description	This admin endpoint will list the roles of users from all sources. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server.
+//	@Description
+//	@Description	Roles with `api` source are assigned using this API and the roles of other
+//	@Description	sources like `ceems`, `grafana` and `ldap` are synchronized by the updater.
+//	@Description	A user is an admin when they have `admin` role in any source unless they
+//	@Description	have `user` role with `api` source.
+//	@Description
+//	@Description	Roles can be filtered by `user` and `source` query parameters.
+//	@Security		BasicAuth
+//	@Tags			roles
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			user			query		[]string	false	"Username"	collectionFormat(multi)
+//	@Param			source			query		[]string	false	"Source"	collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Role]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/roles/admin [get]
+//
+// GET /roles/admin
+// List roles.
+func (s *CEEMSServer) rolesAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "roles admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Make query
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE 1=1",
+			strings.Join(base.RolesDBTableColNames, ","),
+			base.RolesDBTableName,
+		),
+	)
+
+	if users := r.URL.Query()["user"]; len(users) > 0 {
+		q.query(" AND username IN ")
+		q.param(users)
+	}
+
+	if sources := r.URL.Query()["source"]; len(sources) > 0 {
+		q.query(" AND source IN ")
+		q.param(sources)
+	}
+
+	q.query(" ORDER BY username ASC, source ASC")
+
+	roles, err := s.queriers.role(r.Context(), s.db, q, s.logger)
+	if roles == nil && err != nil {
+		s.logger.Error("Failed to fetch roles", "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	rolesResponse := Response[models.Role]{
+		Status: "success",
+		Data:   roles,
+	}
+	if err != nil {
+		rolesResponse.Warnings = append(rolesResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&rolesResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// putRoleAdmin         godoc
+//
+//	@Summary		Admin endpoint to assign roles
+//	@Description	This admin endpoint assigns a role to the user in path. The current user
+//	@Description	is always identified by the header `X-Grafana-User` in the request and it
+//	@Description	will be recorded as the user who assigned the role.
+//	@Description
+//	@Description	The request body must be a JSON object with `role` key that can be either
+//	@Description	`admin` or `user`. Roles assigned using this endpoint take precedence over
+//	@Description	the roles synchronized from other sources and hence, `user` role revokes
+//	@Description	admin privileges of user from all sources. Changes take effect immediately.
+//	@Security		BasicAuth
+//	@Tags			roles
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			user			path		string		true	"Username"
+//	@Param			role			body		roleRequest	true	"Role"
+//	@Success		200				{object}	Response[models.Role]
+//	@Success		201				{object}	Response[models.Role]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/roles/{user}/admin [put]
+//
+// PUT /roles/{user}/admin
+// Assign role to user.
+func (s *CEEMSServer) putRoleAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "put role admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	user := strings.TrimSpace(mux.Vars(r)["user"])
+
+	// Decode request body
+	var req roleRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRole}, s.logger)
+
+		return
+	}
+
+	if user == "" || (req.Role != userRole && req.Role != adminRole) {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRole}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+
+	role := models.Role{
+		User:          user,
+		Role:          req.Role,
+		Source:        apiRoleSource,
+		UpdatedBy:     loggedUser,
+		LastUpdatedAt: time.Now().Format(base.DatetimeLayout),
+	}
+
+	// Check if role exists already to set status code
+	var exists bool
+
+	//nolint:gosec
+	if err := s.dbRW.QueryRowContext(
+		r.Context(),
+		fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE username = ? AND source = ?)", base.RolesDBTableName),
+		role.User, role.Source,
+	).Scan(&exists); err != nil {
+		s.logger.Error("Failed to check role", "user", user, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	//nolint:gosec
+	if _, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf(
+			`INSERT INTO %s (username,role,source,updated_by,last_updated_at) VALUES (?,?,?,?,?)
+ON CONFLICT(username,source) DO UPDATE SET role = excluded.role, updated_by = excluded.updated_by,
+last_updated_at = excluded.last_updated_at`,
+			base.RolesDBTableName,
+		),
+		role.User, role.Role, role.Source, role.UpdatedBy, role.LastUpdatedAt,
+	); err != nil {
+		s.logger.Error("Failed to assign role", "user", user, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	s.logger.Info("Role assigned", "user", user, "role", role.Role, "updated_by", loggedUser)
+
+	// Write response
+	if exists {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	response := Response[models.Role]{
+		Status: "success",
+		Data:   []models.Role{role},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// deleteRoleAdmin         godoc
+//
+//	@Summary		Admin endpoint to delete roles
+//	@Description	This admin endpoint deletes the role assigned to the user in path using
+//	@Description	API. Roles synchronized from other sources are not affected and they apply
+//	@Description	again. The current user is always identified by the header `X-Grafana-User`
+//	@Description	in the request.
+//	@Security		BasicAuth
+//	@Tags			roles
+//	@Produce		json
+//	@Param			X-Grafana-User	header	string	true	"Current user name"
+//	@Param			user			path	string	true	"Username"
+//	@Success		204
+//	@Failure		401	{object}	Problem
+//	@Failure		403	{object}	Problem
+//	@Failure		404	{object}	Problem
+//	@Failure		500	{object}	Problem
+//	@Router			/roles/{user}/admin [delete]
+//
+// DELETE /roles/{user}/admin
+// Delete role of user.
+func (s *CEEMSServer) deleteRoleAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "delete role admin endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	user := mux.Vars(r)["user"]
+
+	//nolint:gosec
+	res, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf("DELETE FROM %s WHERE username = ? AND source = ?", base.RolesDBTableName),
+		user, apiRoleSource,
+	)
+	if err != nil {
+		s.logger.Error("Failed to delete role", "user", user, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		errorResponse(w, r, &apiError{errorNotFound, errRoleNotFound}, s.logger)
+
+		return
+	}
+
+	loggedUser, _ := s.getUser(r)
+	s.logger.Info("Role deleted", "user", user, "deleted_by", loggedUser)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolesHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add admin user synchronized by updater
+	_, err = dbConn.Exec(`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','grafana','')`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.role = Querier[models.Role]
+
+	// Make request through middlewares. Server limits requests to 10 per minute
+	do := func(method, path, user, body string) (*httptest.ResponseRecorder, Response[models.Role]) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(grafanaUserHeader, user)
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.Role]
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w, response
+	}
+
+	// Only admins can assign roles
+	w, _ := do(http.MethodPut, "/api/v1/roles/usr1/admin", "usr1", `{"role": "admin"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = do(http.MethodPut, "/api/v1/roles/usr1/admin", "adm1", `{"role": "owner"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, resp := do(http.MethodPut, "/api/v1/roles/usr1/admin", "adm1", `{"role": "admin"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "api", resp.Data[0].Source)
+	assert.Equal(t, "adm1", resp.Data[0].UpdatedBy)
+
+	// Roles assigned using API must take precedence over synchronized ones
+	w, _ = do(http.MethodPut, "/api/v1/roles/adm1/admin", "usr1", `{"role": "user"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w, resp = do(http.MethodGet, "/api/v1/roles/admin?user=adm1", "usr1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, resp.Data, 2)
+
+	w, _ = do(http.MethodGet, "/api/v1/roles/admin", "adm1", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Synchronized roles must apply again once API role is deleted
+	w, _ = do(http.MethodDelete, "/api/v1/roles/adm1/admin", "usr1", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w, _ = do(http.MethodDelete, "/api/v1/roles/adm1/admin", "adm1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,176 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+)
+
+// Window that is used to fetch running units from resource managers. Units
+// that were active in the last minute are fetched and only the ones that
+// have not ended yet are retained.
+const runningUnitsWindow = time.Minute
+
+// runningUnitsFetcher returns units that are running at given time by querying
+// resource managers directly.
+type runningUnitsFetcher func(ctx context.Context, at time.Time) ([]models.ClusterUnits, error)
+
+// newRunningUnitsFetcher returns a fetcher that queries the resource managers
+// of all configured clusters.
+func newRunningUnitsFetcher(c db.Config, logger *slog.Logger) (runningUnitsFetcher, error) {
+	if c.ResourceManager == nil {
+		return nil, errRunningUnavailable
+	}
+
+	manager, err := c.ResourceManager(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup resource manager: %w", err)
+	}
+
+	return func(ctx context.Context, at time.Time) ([]models.ClusterUnits, error) {
+		clusterUnits, err := manager.FetchUnits(ctx, at.Add(-runningUnitsWindow), at)
+
+		// Retain only units that have not ended
+		for i := range clusterUnits {
+			clusterUnits[i].Units = slices.DeleteFunc(clusterUnits[i].Units, func(u models.Unit) bool {
+				return u.EndedAtTS > 0
+			})
+		}
+
+		return clusterUnits, err
+	}, nil
+}
+
+// runningUnitsQuerier fetches running units of users from resource managers
+// and writes them in response. If users is empty, units of all users are returned.
+func (s *CEEMSServer) runningUnitsQuerier(users []string, w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "running units endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Running units change all the time and must never be cached by clients
+	w.Header().Set("Cache-Control", "no-store")
+
+	if s.runningUnits == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errRunningUnavailable}, s.logger)
+
+		return
+	}
+
+	clusterIDs := r.URL.Query()["cluster_id"]
+
+	clusterUnits, err := s.runningUnits(r.Context(), time.Now())
+	if err != nil && len(clusterUnits) == 0 {
+		s.logger.Error("Failed to fetch running units", "err", err)
+		errorResponse(w, r, &apiError{errorUnavailable, err}, s.logger)
+
+		return
+	}
+
+	units := make([]models.Unit, 0)
+
+	for _, cu := range clusterUnits {
+		if len(clusterIDs) > 0 && !slices.Contains(clusterIDs, cu.Cluster.ID) {
+			continue
+		}
+
+		for _, unit := range cu.Units {
+			if len(users) > 0 && !slices.Contains(users, unit.User) {
+				continue
+			}
+
+			units = append(units, unit)
+		}
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	unitsResponse := Response[models.Unit]{
+		Status: "success",
+		Data:   units,
+	}
+
+	// When some of the resource managers failed, return partial response with warning
+	if err != nil {
+		unitsResponse.Warnings = append(unitsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&unitsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// runningUnits         godoc
+//
+//	@Summary		Running compute units of current user
+//	@Description	This endpoint returns the compute units of the current user that are running
+//	@Description	right now. Units are fetched directly from resource managers instead of DB
+//	@Description	and hence, units that started after the last DB update are included as well.
+//	@Description	The current user is always identified by the header `X-Grafana-User` in the
+//	@Description	request.
+//	@Description
+//	@Description	As resource managers are queried on every request, this endpoint is more
+//	@Description	expensive than `/units` and clients must not poll it too often. Units can be
+//	@Description	filtered by clusters using `cluster_id` query parameter. Aggregate metrics
+//	@Description	of units are not available in the response.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Unit]
+//	@Failure		401				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/units/current [get]
+//
+// GET /units/current
+// Get running units of current user.
+func (s *CEEMSServer) currentUnits(w http.ResponseWriter, r *http.Request) {
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	s.runningUnitsQuerier([]string{loggedUser}, w, r)
+}
+
+// currentUnitsAdmin         godoc
+//
+//	@Summary		Admin endpoint for running compute units
+//	@Description	This admin endpoint returns the compute units of all users that are running
+//	@Description	right now fetched directly from resource managers. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. Units can be filtered by clusters and users using
+//	@Description	`cluster_id` and `user` query parameters, respectively.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
+//	@Success		200				{object}	Response[models.Unit]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/units/current/admin [get]
+//
+// GET /units/current/admin
+// Get running units of all users.
+func (s *CEEMSServer) currentUnitsAdmin(w http.ResponseWriter, r *http.Request) {
+	s.runningUnitsQuerier(r.URL.Query()["user"], w, r)
+}
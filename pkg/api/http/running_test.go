@@ -0,0 +1,146 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errMockFetch = errors.New("failed to execute squeue")
+
+type mockRunningFetcher struct{}
+
+func (m mockRunningFetcher) FetchUnits(_ context.Context, start time.Time, end time.Time) ([]models.ClusterUnits, error) {
+	return []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units: []models.Unit{
+				{UUID: "1", EndedAtTS: start.UnixMilli()},
+				{UUID: "2", StartedAtTS: end.UnixMilli()},
+			},
+		},
+	}, nil
+}
+
+func (m mockRunningFetcher) FetchUsersProjects(
+	_ context.Context,
+	_ time.Time,
+) ([]models.ClusterUsers, []models.ClusterProjects, error) {
+	return nil, nil, nil
+}
+
+func TestNewRunningUnitsFetcher(t *testing.T) {
+	// Without resource manager, fetcher must not be available
+	_, err := newRunningUnitsFetcher(db.Config{}, noOpLogger)
+	require.ErrorIs(t, err, errRunningUnavailable)
+
+	fetcher, err := newRunningUnitsFetcher(db.Config{
+		ResourceManager: func(logger *slog.Logger) (*resource.Manager, error) {
+			return &resource.Manager{Fetchers: []resource.Fetcher{mockRunningFetcher{}}, Logger: logger}, nil
+		},
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	// Units that have ended must be dropped
+	clusterUnits, err := fetcher(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Len(t, clusterUnits, 1)
+	require.Len(t, clusterUnits[0].Units, 1)
+	assert.Equal(t, "2", clusterUnits[0].Units[0].UUID)
+}
+
+func TestRunningUnitsHandlers(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	fetchErr := error(nil)
+
+	server.runningUnits = func(_ context.Context, _ time.Time) ([]models.ClusterUnits, error) {
+		return []models.ClusterUnits{
+			{
+				Cluster: models.Cluster{ID: "slurm-0"},
+				Units: []models.Unit{
+					{UUID: "1", ClusterID: "slurm-0", User: "usr1"},
+					{UUID: "2", ClusterID: "slurm-0", User: "usr2"},
+				},
+			},
+			{
+				Cluster: models.Cluster{ID: "slurm-1"},
+				Units: []models.Unit{
+					{UUID: "3", ClusterID: "slurm-1", User: "usr1"},
+				},
+			},
+		}, fetchErr
+	}
+
+	query := func(handler http.HandlerFunc, url string) (int, Response[models.Unit]) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set(loggedUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var response Response[models.Unit]
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+
+		return w.Code, response
+	}
+
+	uuids := func(units []models.Unit) []string {
+		var uuids []string
+		for _, u := range units {
+			uuids = append(uuids, u.UUID)
+		}
+
+		return uuids
+	}
+
+	// Only units of current user must be returned
+	code, response := query(server.currentUnits, "/api/v1/units/current")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"1", "3"}, uuids(response.Data))
+
+	// Filter on cluster
+	_, response = query(server.currentUnits, "/api/v1/units/current?cluster_id=slurm-1")
+	assert.Equal(t, []string{"3"}, uuids(response.Data))
+
+	// Admin gets units of all users
+	_, response = query(server.currentUnitsAdmin, "/api/v1/units/current/admin")
+	assert.Equal(t, []string{"1", "2", "3"}, uuids(response.Data))
+
+	_, response = query(server.currentUnitsAdmin, "/api/v1/units/current/admin?user=usr2")
+	assert.Equal(t, []string{"2"}, uuids(response.Data))
+
+	// Partial failures must be returned as warnings
+	fetchErr = errMockFetch
+	code, response = query(server.currentUnits, "/api/v1/units/current")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"1", "3"}, uuids(response.Data))
+	assert.Equal(t, []string{errMockFetch.Error()}, response.Warnings)
+}
+
+func TestRunningUnitsUnavailable(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units/current", nil)
+	req.Header.Set(loggedUserHeader, "usr1")
+
+	w := httptest.NewRecorder()
+	server.currentUnits(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
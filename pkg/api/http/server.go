@@ -16,7 +16,6 @@ import (
 	"net/http"
 	_ "net/http/pprof" // #nosec
 	"net/url"
-	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
@@ -24,15 +23,17 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-chi/httprate"
 	"github.com/gorilla/mux"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/db"
 	"github.com/mahendrapaipuri/ceems/pkg/api/http/docs"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
-	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/mahendrapaipuri/ceems/pkg/ldap"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/exporter-toolkit/web"
@@ -41,13 +42,21 @@ import (
 
 // API Resources names.
 const (
-	unitsResourceName      = "units"
-	usageResourceName      = "usage"
-	adminUsersResourceName = "admin_users"
-	usersResourceName      = "users"
-	projectsResourceName   = "projects"
-	clustersResourceName   = "clusters"
-	statsResourceName      = "stats"
+	unitsResourceName        = "units"
+	usageResourceName        = "usage"
+	rolesResourceName        = "roles"
+	usersResourceName        = "users"
+	projectsResourceName     = "projects"
+	clustersResourceName     = "clusters"
+	statsResourceName        = "stats"
+	nodesResourceName        = "nodes"
+	reservationsResourceName = "reservations"
+	preemptionsResourceName  = "preemptions"
+	apiKeysResourceName      = "api_keys"
+	maintenanceResourceName  = "maintenance"
+	reportsResourceName      = "reports"
+	quotasResourceName       = "quotas"
+	provisioningResourceName = "provisioning"
 )
 
 // Usage modes.
@@ -58,14 +67,31 @@ const (
 
 // WebConfig makes HTTP web config from CLI args.
 type WebConfig struct {
-	Addresses        []string
-	WebSystemdSocket bool
-	WebConfigFile    string
-	RoutePrefix      string                  `yaml:"route_prefix"`
-	MaxQueryPeriod   model.Duration          `yaml:"max_query"`
-	RequestsLimit    int                     `yaml:"requests_limit"`
-	URL              string                  `yaml:"url"`
-	HTTPClientConfig config.HTTPClientConfig `yaml:",inline"`
+	Addresses        []string                 `yaml:"-"`
+	GRPCAddress      string                   `yaml:"-"`
+	WebSystemdSocket bool                     `yaml:"-"`
+	WebConfigFile    string                   `yaml:"-"`
+	RoutePrefix      string                   `yaml:"route_prefix"`
+	MaxQueryPeriod   model.Duration           `yaml:"max_query"`
+	AdminMaxQuery    *model.Duration          `yaml:"admin_max_query"`
+	RequestsLimit    int                      `yaml:"requests_limit"`
+	URL              string                   `yaml:"url"`
+	JWT              middleware.JWTConfig     `yaml:"jwt"`
+	OIDC             middleware.OIDCConfig    `yaml:"oidc"`
+	InternalSecret   config.Secret            `yaml:"internal_secret"`
+	ConcurrencyLimit ConcurrencyLimitConfig   `yaml:"concurrency_limit"`
+	UserRateLimit    UserRateLimitConfig      `yaml:"user_rate_limit"`
+	ResponseCache    ResponseCacheConfig      `yaml:"response_cache"`
+	DBPool           DBPoolConfig             `yaml:"db_pool"`
+	Connections      common.ConnectionsConfig `yaml:"connections"`
+	Billing          BillingConfig            `yaml:"billing"`
+	Fairshare        FairshareConfig          `yaml:"fairshare"`
+	Privacy          PrivacyConfig            `yaml:"privacy"`
+	LegacyAPI        LegacyAPIConfig          `yaml:"legacy_api"`
+	CORS             CORSConfig               `yaml:"cors"`
+	Analytics        AnalyticsConfig          `yaml:"analytics"`
+	EnableSynthetic  bool                     `yaml:"-"`
+	HTTPClientConfig config.HTTPClientConfig  `yaml:",inline"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -95,9 +121,12 @@ func (c *WebConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Config makes a server config.
 type Config struct {
-	Logger *slog.Logger
-	Web    WebConfig
-	DB     db.Config
+	Logger     *slog.Logger
+	Web        WebConfig
+	DB         db.Config
+	Maintainer Maintainer     // Performs maintenance operations on DB. Maintenance endpoints are disabled when nil
+	Injector   Injector       // Inserts synthetic units into DB. Used only when synthetic units are enabled
+	Backups    BackupReporter // Reports status of scheduled backups of DB in health end point
 }
 
 type queriers struct {
@@ -108,36 +137,102 @@ type queriers struct {
 	cluster func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Cluster, error)
 	stat    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Stat, error)
 	key     func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Key, error)
+	node    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Node, error)
+	annot   func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Annotation, error)
+	resv    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Reservation, error)
+	preempt func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Preemption, error)
+	apiKey  func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.APIKey, error)
+	role    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Role, error)
+	step    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.UnitStep, error)
+	script  func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.UnitScript, error)
+	quota   func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.Quota, error)
+	prov    func(context.Context, *sql.DB, Query, *slog.Logger) ([]models.ProvisionedConfig, error)
+	top     func(context.Context, *sql.DB, Query, *slog.Logger) ([]TopConsumer, error)
+	size    func(context.Context, *sql.DB, Query, *slog.Logger) ([]groupSize, error)
+
+	unitStream func(context.Context, *sql.DB, Query, *slog.Logger, func(models.Unit) error) error
 }
 
 // CEEMSServer struct implements HTTP server for stats.
 type CEEMSServer struct {
-	logger         *slog.Logger
-	server         *http.Server
-	webConfig      *web.FlagConfig
-	db             *sql.DB
-	dbConfig       db.Config
-	maxQueryPeriod time.Duration
-	queriers       queriers
-	usageCache     *ttlcache.Cache[uint64, []models.Usage] // Cache that stores usage query results
-	healthCheck    func(*sql.DB, *slog.Logger) bool
+	logger              *slog.Logger
+	server              *http.Server
+	grpcServer          *http.Server // Serves gRPC API. Nil when gRPC API is disabled
+	graphqlService      *graphqlService
+	webConfig           *web.FlagConfig
+	conns               common.ConnectionsConfig
+	db                  *sql.DB
+	dbRW                *sql.DB          // Read-write connection used by endpoints that modify DB
+	analyticsDB         *sql.DB          // DuckDB connection used by analytical queries. Nil when queries are made on SQLite
+	analyticsMirror     *analyticsMirror // Mirrors units table into DuckDB. Nil when analytics source is not mirror
+	dbConfig            db.Config
+	maxQueryPeriod      time.Duration
+	adminMaxQueryPeriod time.Duration
+	queriers            queriers
+	usageCache          *ttlcache.Cache[uint64, []models.Usage] // Cache that stores usage query results
+	responseCache       *responseCache                          // Cache that stores responses of units and usage end points
+	healthCheck         func(*sql.DB, *slog.Logger) bool
+	liveMetrics         liveMetricsFetcher  // Fetches live metrics of running units. Nil when no updaters are configured
+	nodeSeries          nodeSeriesFetcher   // Fetches time series of nodes. Nil when no updaters are configured
+	runningUnits        runningUnitsFetcher // Fetches running units from resource managers. Nil when no resource manager is configured
+	maintenance         *maintenance        // Runs maintenance tasks on DB. Nil when no maintainer is configured
+	injector            Injector            // Inserts synthetic units into DB. Nil when synthetic units are disabled
+	realClusterIDs      []string            // IDs of clusters in config into which synthetic units must never be injected
+	backups             BackupReporter      // Reports status of scheduled backups of DB. Nil when not configured
+	billing             BillingConfig       // Rates used to estimate costs of projects
+	fairshare           FairshareConfig     // Parameters of usage based fair-share of accounts
+	privacy             PrivacyConfig       // Aggregation floor of usage returned to non-admin users
+	footprints          *footprintTracker   // Data volume served to users by API server and load balancers
 }
 
 // Response defines the response model of CEEMSAPIServer.
 type Response[T any] struct {
-	Status    string    `json:"status"`
-	Data      []T       `json:"data"`
-	ErrorType errorType `json:"errorType,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	Warnings  []string  `json:"warnings,omitempty"`
+	Status   string   `json:"status"`
+	Data     []T      `json:"data"`
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 var (
-	aggUsageQueries    = make(map[string]string, len(base.UsageDBTableColNames))
-	cacheTTL           = 15 * time.Minute
-	defaultQueryWindow = 24 * time.Hour // One day
+	// Units table columns that store JSON objects. Keys of these objects can be
+	// used in sort_by query parameter like `total_time_seconds.walltime`.
+	unitsJSONColNames = []string{
+		"allocation", "total_time_seconds", "avg_cpu_usage", "avg_cpu_mem_usage",
+		"total_cpu_energy_usage_kwh", "total_cpu_emissions_gms", "avg_gpu_usage",
+		"avg_gpu_mem_usage", "total_gpu_energy_usage_kwh", "total_gpu_emissions_gms",
+		"total_io_write_stats", "total_io_read_stats", "total_ingress_stats",
+		"total_outgress_stats", "completeness", "tags",
+	}
+	jsonKeyRegex = regexp.MustCompile("^[a-zA-Z0-9_]+$")
 )
 
+var (
+	aggUsageQueries      = make(map[string]string, len(base.UsageDBTableColNames))
+	groupAggUsageQueries = make(map[string]string, len(base.UsageDBTableColNames))
+	cacheTTL             = 15 * time.Minute
+	defaultQueryWindow   = 24 * time.Hour // One day
+)
+
+const (
+	maxAnnotationLength = 4096            // Maximum number of characters in an annotation
+	verifyMaxAge        = 5 * time.Minute // Duration for which clients can cache ownership of units
+)
+
+// Aggregation levels of usage statistics.
+const (
+	userUsageAggregation  = "user"
+	groupUsageAggregation = "group"
+)
+
+// Usage rollup tables from which current usage can be estimated.
+const (
+	dailyUsageRollup   = "daily"
+	monthlyUsageRollup = "monthly"
+)
+
+// Columns that are meaningless when usage is aggregated by group and hence,
+// returned as empty strings.
+var groupAggUsageOmittedCols = []string{"username", "project"}
+
 const (
 	// Query to get quick stats like active projects, groups, jobs, etc.
 	statsQuery = `cluster_id,resource_manager,COUNT(*) AS num_units,COUNT(CASE WHEN ended_at_ts > 0 THEN 1 END) as num_inactive_units,COUNT(CASE WHEN ended_at_ts = 0 THEN 1 END) as num_active_units,COUNT(DISTINCT project) AS num_projects,COUNT(DISTINCT username) AS num_users`
@@ -164,6 +259,28 @@ func init() {
 			aggUsageQueries[col] = col
 		}
 	}
+
+	// When global usage is aggregated by group, usage of all users and projects
+	// of a group is aggregated using custom aggregate functions of metric maps
+	for _, col := range base.UsageDBTableColNames {
+		switch {
+		case strings.HasPrefix(col, "num"):
+			groupAggUsageQueries[col] = fmt.Sprintf("SUM(%[1]s) AS %[1]s", col)
+		case strings.HasPrefix(col, "total"):
+			groupAggUsageQueries[col] = fmt.Sprintf("sum_metric_map_agg(%[1]s) AS %[1]s", col)
+		case strings.HasPrefix(col, "avg"):
+			groupAggUsageQueries[col] = fmt.Sprintf(
+				"avg_metric_map_agg(%[1]s, CAST(json_extract(total_time_seconds, '$.%[2]s') AS REAL)) AS %[1]s",
+				col, db.Weights[col],
+			)
+		case col == "last_updated_at":
+			groupAggUsageQueries[col] = "MAX(last_updated_at) AS last_updated_at"
+		case slices.Contains(groupAggUsageOmittedCols, col):
+			groupAggUsageQueries[col] = fmt.Sprintf("'' AS %s", col)
+		default:
+			groupAggUsageQueries[col] = col
+		}
+	}
 }
 
 // Ping DB for connection test.
@@ -196,7 +313,11 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 			WebSystemdSocket:   &c.Web.WebSystemdSocket,
 			WebConfigFile:      &c.Web.WebConfigFile,
 		},
+		conns:          c.Web.Connections,
 		dbConfig:       c.DB,
+		billing:        c.Web.Billing,
+		fairshare:      c.Web.Fairshare,
+		privacy:        c.Web.Privacy,
 		maxQueryPeriod: time.Duration(c.Web.MaxQueryPeriod),
 		queriers: queriers{
 			unit:    Querier[models.Unit],
@@ -206,10 +327,34 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 			cluster: Querier[models.Cluster],
 			stat:    Querier[models.Stat],
 			key:     Querier[models.Key],
+			node:    Querier[models.Node],
+			annot:   Querier[models.Annotation],
+			resv:    Querier[models.Reservation],
+			preempt: Querier[models.Preemption],
+			apiKey:  Querier[models.APIKey],
+			role:    Querier[models.Role],
+			step:    Querier[models.UnitStep],
+			script:  Querier[models.UnitScript],
+			quota:   Querier[models.Quota],
+			prov:    Querier[models.ProvisionedConfig],
+			top:     Querier[TopConsumer],
+			size:    Querier[groupSize],
+
+			unitStream: StreamQuerier[models.Unit],
 		},
 		healthCheck: getDBStatus,
 	}
 
+	// Footprints are attributed only to the projects that users belong to
+	server.footprints = newFootprintTracker(server.isProjectMember)
+
+	// Admin users get the same query window as other users unless it is
+	// configured explicitly
+	server.adminMaxQueryPeriod = server.maxQueryPeriod
+	if c.Web.AdminMaxQuery != nil {
+		server.adminMaxQueryPeriod = time.Duration(*c.Web.AdminMaxQuery)
+	}
+
 	// Get route prefix based on external URL path
 	var routePrefix string
 	if c.Web.RoutePrefix != "/" {
@@ -220,6 +365,9 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 
 	c.Logger.Debug("CEEMS API server running on prefix", "prefix", routePrefix)
 
+	// Metrics of API server are exposed outside of API prefix
+	metricsPath := strings.TrimSuffix(c.Web.RoutePrefix, "/") + "/metrics"
+
 	// Create a sub router with apiVersion as PathPrefix
 	subRouter := router.PathPrefix(routePrefix).Subrouter()
 
@@ -236,29 +384,128 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 			<body>
 			<h1>Compute Stats</h1>
 			<p><a href="swagger/index.html">Swagger API</a></p>
+			<p><a href="swagger.json">OpenAPI Specification</a></p>
 			</body>
 			</html>`))
 	})
 
+	// Expensive end points that can scan large parts of DB are limited in
+	// concurrency to protect the DB connection pool. Units and usage end points
+	// have their own limiters so that one does not starve the other.
+	unitsLimiter := newConcurrencyLimiter(c.Web.ConcurrencyLimit, c.Logger)
+	usageLimiter := newConcurrencyLimiter(c.Web.ConcurrencyLimit, c.Logger)
+
+	// Responses of units and usage end points are cached for a short TTL. Cache
+	// is placed before concurrency limits so that cache hits do not wait for slots
+	server.responseCache = newResponseCache(c.Web.ResponseCache, c.Logger)
+	cached := server.responseCache.Handler
+
+	// End points that make several queries read from the same snapshot of DB
+	snapshot := server.snapshot
+
 	// Allow only GET methods
 	subRouter.HandleFunc("/health", server.health).Methods(http.MethodGet)
 	subRouter.HandleFunc("/"+usersResourceName, server.users).Methods(http.MethodGet)
 	subRouter.HandleFunc("/"+projectsResourceName, server.projects).Methods(http.MethodGet)
-	subRouter.HandleFunc("/"+unitsResourceName, server.units).Methods(http.MethodGet)
-	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}", usageResourceName), server.usage).
+	subRouter.Handle("/"+unitsResourceName, cached(unitsLimiter.Handler(snapshot(server.units)))).Methods(http.MethodGet)
+	subRouter.Handle(fmt.Sprintf("/%s/{mode:(?:current|global)}", usageResourceName), cached(usageLimiter.Handler(snapshot(server.usage)))).
 		Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/verify", unitsResourceName), server.verifyUnitsOwnership).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/verify", unitsResourceName), server.verifyUnitsOwnershipBatch).
+		Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{hostname}/energy", nodesResourceName), server.nodeEnergy).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{hostname}/report", nodesResourceName), server.nodeReport).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/annotations", unitsResourceName), server.annotations).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/annotations", unitsResourceName), server.addAnnotation).
+		Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/steps", unitsResourceName), server.unitSteps).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/current", unitsResourceName), server.currentUnits).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/live", unitsResourceName), server.liveUnit).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc("/"+reservationsResourceName, server.reservations).Methods(http.MethodGet)
+	subRouter.HandleFunc("/"+preemptionsResourceName, server.preemptions).Methods(http.MethodGet)
+	subRouter.HandleFunc("/graphql", server.graphql).Methods(http.MethodGet, http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/billing", reportsResourceName), server.billingReport).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/top", reportsResourceName), server.topReport).Methods(http.MethodGet)
+	subRouter.HandleFunc("/"+quotasResourceName, snapshot(server.quotas)).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/monitoring-usage", statsResourceName), server.monitoringUsage).
+		Methods(http.MethodGet)
 
 	// Admin end points
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", usersResourceName), server.usersAdmin).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", projectsResourceName), server.projectsAdmin).Methods(http.MethodGet)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", clustersResourceName), server.clustersAdmin).Methods(http.MethodGet)
-	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", unitsResourceName), server.unitsAdmin).Methods(http.MethodGet)
-	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}/admin", usageResourceName), server.usageAdmin).
+	subRouter.Handle(fmt.Sprintf("/%s/admin", unitsResourceName), cached(unitsLimiter.Handler(snapshot(server.unitsAdmin)))).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{uuid}/script/admin", unitsResourceName), server.unitScriptAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/current/admin", unitsResourceName), server.currentUnitsAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/synthetic/admin", unitsResourceName), server.syntheticUnitsAdmin).
+		Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", reservationsResourceName), server.reservationsAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{hostname}/report/admin", nodesResourceName), server.nodeReportAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", preemptionsResourceName), server.preemptionsAdmin).
 		Methods(http.MethodGet)
+	subRouter.Handle(fmt.Sprintf("/%s/{mode:(?:current|global)}/admin", usageResourceName), cached(usageLimiter.Handler(snapshot(server.usageAdmin)))).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/monitoring-usage/admin", statsResourceName), server.monitoringUsageAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/monitoring-usage/admin", statsResourceName), server.reportFootprintAdmin).
+		Methods(http.MethodPost)
 	subRouter.HandleFunc(fmt.Sprintf("/%s/{mode:(?:current|global)}/admin", statsResourceName), server.statsAdmin).
 		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/billing/admin", reportsResourceName), server.billingReportAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/top/admin", reportsResourceName), server.topReportAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/fairshare/admin", reportsResourceName), server.fairshareReportAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", quotasResourceName), snapshot(server.quotasAdmin)).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", quotasResourceName), server.createQuotaAdmin).
+		Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{id:[0-9]+}/admin", quotasResourceName), server.updateQuotaAdmin).
+		Methods(http.MethodPut)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{id:[0-9]+}/admin", quotasResourceName), server.deleteQuotaAdmin).
+		Methods(http.MethodDelete)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{kind:(?:clusters|updaters)}/admin", provisioningResourceName), server.provisionedAdmin,
+	).Methods(http.MethodGet)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{kind:(?:clusters|updaters)}/{id:[a-zA-Z0-9-_]+}/admin", provisioningResourceName),
+		server.provisionedAdmin,
+	).Methods(http.MethodGet)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{kind:(?:clusters|updaters)}/{id:[a-zA-Z0-9-_]+}/admin", provisioningResourceName),
+		server.putProvisionedAdmin,
+	).Methods(http.MethodPut)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{kind:(?:clusters|updaters)}/{id:[a-zA-Z0-9-_]+}/admin", provisioningResourceName),
+		server.deleteProvisionedAdmin,
+	).Methods(http.MethodDelete)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", apiKeysResourceName), server.apiKeysAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", apiKeysResourceName), server.createAPIKeyAdmin).
+		Methods(http.MethodPost)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{id:[0-9]+}/admin", apiKeysResourceName), server.deleteAPIKeyAdmin).
+		Methods(http.MethodDelete)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", rolesResourceName), server.rolesAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{user}/admin", rolesResourceName), server.putRoleAdmin).Methods(http.MethodPut)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/{user}/admin", rolesResourceName), server.deleteRoleAdmin).
+		Methods(http.MethodDelete)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/admin", maintenanceResourceName), server.maintenanceAdmin).Methods(http.MethodGet)
+	subRouter.HandleFunc(fmt.Sprintf("/%s/purge/admin", maintenanceResourceName), server.purgeReportAdmin).
+		Methods(http.MethodGet)
+	subRouter.HandleFunc(
+		fmt.Sprintf("/%s/{operation:(?:vacuum|backup|integrity_check|purge|repair)}/admin", maintenanceResourceName),
+		server.startMaintenanceAdmin,
+	).Methods(http.MethodPost)
 
 	// A demo end point that returns mocked data for units and/or usage tables
 	subRouter.HandleFunc("/demo/{resource:(?:units|usage)}", server.demo).Methods(http.MethodGet)
@@ -266,27 +513,61 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 	// pprof debug end points. Expose them only on localhost
 	router.PathPrefix("/debug/").Handler(http.DefaultServeMux).Host("localhost")
 
+	// Prometheus metrics of API server
+	router.Handle(metricsPath, promhttp.Handler()).Methods(http.MethodGet)
+
+	// OpenAPI 3 specification and Swagger UI
+	subRouter.HandleFunc("/swagger.json", server.openAPI).Methods(http.MethodGet)
 	subRouter.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("doc.json"), // The url pointing to API definition
+		httpSwagger.URL("../swagger.json"), // The url pointing to API definition
 		httpSwagger.DeepLinking(true),
 		httpSwagger.DocExpansion("list"),
 		httpSwagger.DomID("swagger-ui"),
 	)).Methods(http.MethodGet)
 
 	// Open DB connection
-	dsn := fmt.Sprintf(
-		"file:%s?%s",
-		filepath.Join(c.DB.Data.Path, base.CEEMSDBName),
-		"_mutex=no&mode=ro&_busy_timeout=5000",
-	)
-	if server.db, err = sql.Open(sqlite3.DriverName, dsn); err != nil {
+	if server.db, err = openDB(c.DB.Data, dbReadOnly, c.Web.DBPool); err != nil {
 		return nil, func() {}, fmt.Errorf("failed to open DB: %w", err)
 	}
 
+	// Open a read-write DB connection for endpoints that modify DB like annotations
+	if server.dbRW, err = openDB(c.DB.Data, dbReadWrite, c.Web.DBPool); err != nil {
+		return nil, func() {}, fmt.Errorf("failed to open read-write DB: %w", err)
+	}
+
+	// Open DuckDB connection for analytical queries when configured
+	if server.analyticsDB, err = openAnalyticsDB(c.DB.Data.Path, c.Web.Analytics); err != nil {
+		return nil, func() {}, fmt.Errorf("failed to open analytics DB: %w", err)
+	}
+
+	// Keep DuckDB mirror of units table up to date in background
+	if server.analyticsDB != nil && c.Web.Analytics.Source == mirrorAnalyticsSource {
+		if server.analyticsMirror, err = newAnalyticsMirror(
+			context.Background(), server.db, server.analyticsDB, time.Duration(c.Web.Analytics.RefreshInterval), c.Logger,
+		); err != nil {
+			server.analyticsDB.Close()
+
+			return nil, func() {}, err
+		}
+
+		server.analyticsMirror.start()
+	}
+
+	// Add common middlewares
+	metrics, err := middleware.NewMetrics("ceems_api_server", prometheus.DefaultRegisterer, middleware.RouteTemplate)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("failed to register HTTP metrics: %w", err)
+	}
+
+	router.Use(
+		mux.MiddlewareFunc(middleware.Logging(c.Logger)), metrics.Middleware,
+		mux.MiddlewareFunc(middleware.SecurityHeaders()), mux.MiddlewareFunc(middleware.Compress()),
+	)
+
 	// Rate limit requests by RealIP
 	if c.Web.RequestsLimit > 0 {
 		c.Logger.Debug("Rate limiting settings", "reqs_per_minute", c.Web.RequestsLimit)
-		router.Use(httprate.LimitByRealIP(c.Web.RequestsLimit, time.Minute))
+		router.Use(mux.MiddlewareFunc(middleware.RateLimit(c.Web.RequestsLimit)))
 	}
 
 	// Add a middleware that verifies headers and pass them in requests
@@ -294,12 +575,110 @@ func New(c *Config) (*CEEMSServer, func(), error) {
 	amw := authenticationMiddleware{
 		logger:          c.Logger,
 		routerPrefix:    routePrefix,
-		whitelistedURLs: regexp.MustCompile(routePrefix + "(swagger|health|demo)(.*)"),
+		whitelistedURLs: regexp.MustCompile(routePrefix + "(swagger|health|demo)(.*)|^" + regexp.QuoteMeta(metricsPath) + "$"),
 		db:              server.db,
 		adminUsers:      adminUsers,
+		apiKey:          lookupAPIKey,
+		internalSecret:  string(c.Web.InternalSecret),
+	}
+
+	// When JWT authentication is enabled, users are identified from bearer
+	// tokens instead of Grafana user header
+	if c.Web.JWT.Enabled() && c.Web.OIDC.Enabled() {
+		return nil, func() {}, errors.New("only one of JWT and OIDC authentication can be enabled")
+	}
+
+	if c.Web.JWT.Enabled() {
+		if amw.jwt, err = middleware.NewJWTAuthenticator(c.Web.JWT); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to setup JWT authentication: %w", err)
+		}
+	}
+
+	// When OIDC authentication is enabled, users and their groups are identified
+	// by exchanging access tokens at the userinfo endpoint of the provider
+	if c.Web.OIDC.Enabled() {
+		if amw.oidc, err = middleware.NewOIDCAuthenticator(c.Web.OIDC); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to setup OIDC authentication: %w", err)
+		}
+	}
+
+	// When LDAP admin group is configured, admin membership is resolved at request
+	// time so that changes in the group do not need server restarts. When members
+	// of group are synchronized to roles by updater, they are not resolved again
+	if c.DB.Admin.LDAP.Enabled() && !c.DB.Admin.LDAP.SyncMembers {
+		if amw.ldap, err = ldap.New(c.DB.Admin.LDAP, c.Logger.With("client", "ldap")); err != nil {
+			return nil, func() {}, fmt.Errorf("failed to setup LDAP client: %w", err)
+		}
 	}
+
 	router.Use(amw.Middleware)
 
+	// Rate limit requests of each user. This must be after authentication
+	// middleware as users are resolved by it
+	router.Use(newUserRateLimiter(c.Web.UserRateLimit, routePrefix, c.Logger).Middleware)
+
+	// Record data volume served to users. This must be after authentication
+	// middleware as well so that logged user is known
+	router.Use(server.footprints.Middleware)
+
+	// GraphQL queries are resolved by REST end points. Requests made by resolvers
+	// are only authenticated and they are not subjected to other middlewares
+	// like rate limiting as the GraphQL request itself is.
+	server.graphqlService = newGraphQLService(amw.Middleware(subRouter), routePrefix, c.Logger)
+
+	// Serve routes without version prefix as deprecated aliases of versioned routes
+	if !c.Web.LegacyAPI.Disabled {
+		server.server.Handler = legacyAPIHandler(router, routePrefix, c.Web.LegacyAPI.sunsetHeader())
+	}
+
+	// Apply CORS policy so that browser based applications can use the API
+	server.server.Handler = corsHandler(server.server.Handler, c.Web.CORS)
+
+	// Configure HTTP/2 and keep-alives of connections
+	if err := common.ConfigureHTTPServer(server.server, c.Web.Connections); err != nil {
+		return nil, func() {}, err
+	}
+
+	// Serve gRPC API on a separate listener using the same handlers
+	if c.Web.GRPCAddress != "" {
+		server.grpcServer = newGRPCServer(c.Web.GRPCAddress, router, routePrefix, c.Logger)
+
+		// Apply same allowed CIDRs as HTTP server
+		if server.grpcServer.Handler, err = common.AllowListHandler(server.grpcServer.Handler, c.Web.Connections.AllowedCIDRs); err != nil {
+			return nil, func() {}, err
+		}
+	}
+
+	// Setup live metrics of running units from updaters. If it fails, live
+	// endpoint will respond with unavailable error
+	if server.liveMetrics, err = newLiveMetricsFetcher(c.DB, c.Logger); err != nil {
+		c.Logger.Warn("Live metrics of units are disabled", "err", err)
+	}
+
+	// Setup time series of nodes from updaters. If it fails, node reports
+	// will not include time series
+	if server.nodeSeries, err = newNodeSeriesFetcher(c.DB, c.Logger); err != nil {
+		c.Logger.Warn("Time series of nodes are disabled", "err", err)
+	}
+
+	// Setup fetching running units from resource managers. If it fails, current
+	// units endpoints will respond with unavailable error
+	if server.runningUnits, err = newRunningUnitsFetcher(c.DB, c.Logger); err != nil {
+		c.Logger.Warn("Running units from resource managers are disabled", "err", err)
+	}
+
+	// Setup maintenance of DB
+	server.maintenance = newMaintenance(c.Maintainer, c.DB.Data.Timezone.Location, c.Logger)
+
+	// Status of scheduled backups is reported in health end point
+	server.backups = c.Backups
+
+	// Synthetic units must be enabled explicitly as they pollute DB
+	if c.Web.EnableSynthetic {
+		server.injector = c.Injector
+		server.realClusterIDs = configuredClusterIDs(c.Logger)
+	}
+
 	// Instantiate new cache for storing current usage query results with TTL of 15 min
 	server.usageCache = ttlcache.New(
 		ttlcache.WithTTL[uint64, []models.Usage](cacheTTL),
@@ -344,7 +723,26 @@ func (s *CEEMSServer) Start() error {
 
 	s.logger.Info("Starting " + base.CEEMSServerAppName)
 
-	if err := web.ListenAndServe(s.server, s.webConfig, s.logger); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	// Start gRPC server. TLS and basic auth are configured from the same web
+	// config file as HTTP server
+	if s.grpcServer != nil {
+		go func() {
+			webSystemdSocket := false
+			flags := &web.FlagConfig{
+				WebListenAddresses: &[]string{s.grpcServer.Addr},
+				WebSystemdSocket:   &webSystemdSocket,
+				WebConfigFile:      s.webConfig.WebConfigFile,
+			}
+
+			s.logger.Info("Starting gRPC server", "address", s.grpcServer.Addr)
+
+			if err := common.ListenAndServe(s.grpcServer, flags, s.conns, s.logger); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("Failed to Listen and Serve gRPC server", "err", err)
+			}
+		}()
+	}
+
+	if err := common.ListenAndServe(s.server, s.webConfig, s.conns, s.logger); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		s.logger.Error("Failed to Listen and Serve HTTP server", "err", err)
 
 		return err
@@ -355,6 +753,12 @@ func (s *CEEMSServer) Start() error {
 
 // Shutdown server.
 func (s *CEEMSServer) Shutdown(ctx context.Context) error {
+	// Stop expiring items of response cache
+	s.responseCache.Stop()
+
+	// Cancel running maintenance tasks
+	s.maintenance.stop()
+
 	// Close DB connection
 	if err := s.db.Close(); err != nil {
 		s.logger.Error("Failed to close DB connection", "err", err)
@@ -362,6 +766,24 @@ func (s *CEEMSServer) Shutdown(ctx context.Context) error {
 		return err
 	}
 
+	if err := s.dbRW.Close(); err != nil {
+		s.logger.Error("Failed to close read-write DB connection", "err", err)
+
+		return err
+	}
+
+	if s.analyticsMirror != nil {
+		s.analyticsMirror.stop()
+	}
+
+	if s.analyticsDB != nil {
+		if err := s.analyticsDB.Close(); err != nil {
+			s.logger.Error("Failed to close analytics DB connection", "err", err)
+
+			return err
+		}
+	}
+
 	// Shutdown the server
 	if err := s.server.Shutdown(ctx); err != nil {
 		s.logger.Error("Failed to shutdown HTTP server", "err", err)
@@ -369,6 +791,14 @@ func (s *CEEMSServer) Shutdown(ctx context.Context) error {
 		return err
 	}
 
+	if s.grpcServer != nil {
+		if err := s.grpcServer.Shutdown(ctx); err != nil {
+			s.logger.Error("Failed to shutdown gRPC server", "err", err)
+
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -377,6 +807,13 @@ func (s *CEEMSServer) getUser(r *http.Request) (string, string) {
 	return r.Header.Get(loggedUserHeader), r.Header.Get(dashboardUserHeader)
 }
 
+// isAdmin returns true if user has been identified as admin by authentication
+// middleware. Middleware resolves admin users from all the sources which are DB,
+// LDAP group, OIDC admin groups and roles of API keys.
+func (s *CEEMSServer) isAdmin(r *http.Request, user string) bool {
+	return user != "" && r.Header.Get(adminUserHeader) == user
+}
+
 // setHeaders sets common response headers.
 func (s *CEEMSServer) setHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
@@ -401,14 +838,26 @@ func (s *CEEMSServer) setWriteDeadline(deadline time.Duration, w http.ResponseWr
 //	@Description
 //	@Description	A healthy server returns 200 response code and any other
 //	@Description	responses should be treated as unhealthy server.
+//	@Description
+//	@Description	When `backups` query parameter is present, status of scheduled
+//	@Description	backups of DB to backup targets is returned in JSON. A 503 response
+//	@Description	is returned when the last scheduled backup failed.
 //	@Tags			health
 //	@Produce		plain
-//	@Success		200	{string}	OK
-//	@Failure		503	{string}	KO
+//	@Param			backups	query		bool	false	"Return status of scheduled backups"
+//	@Success		200		{string}	OK
+//	@Failure		503		{string}	KO
 //	@Router			/health [get]
 //
 // Check status of server.
 func (s *CEEMSServer) health(w http.ResponseWriter, r *http.Request) {
+	// Report status of scheduled backups when requested
+	if _, ok := r.URL.Query()["backups"]; ok {
+		s.backupsHealth(w, r)
+
+		return
+	}
+
 	if !s.healthCheck(s.db, s.logger) {
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -420,6 +869,40 @@ func (s *CEEMSServer) health(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// backupsHealth writes status of scheduled backups of DB.
+func (s *CEEMSServer) backupsHealth(w http.ResponseWriter, r *http.Request) {
+	var status *db.BackupStatus
+	if s.backups != nil {
+		status = s.backups.BackupStatus()
+	}
+
+	if status == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errNoBackupTargets}, s.logger)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	response := Response[db.BackupStatus]{
+		Status: "success",
+		Data:   []db.BackupStatus{*status},
+	}
+
+	if status.Healthy() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		response.Status = "error"
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
 // getCommonQueryParams fetches project and running query parameters and add them to query.
 func (s *CEEMSServer) getCommonQueryParams(q *Query, urlValues url.Values) Query {
 	// Get project query parameters if any
@@ -437,16 +920,71 @@ func (s *CEEMSServer) getCommonQueryParams(q *Query, urlValues url.Values) Query
 	return *q
 }
 
-// getQueriedFields returns a slice of queried fields.
+// getGroupQueryParams fetches group query parameters and add them to query.
+func (s *CEEMSServer) getGroupQueryParams(q *Query, urlValues url.Values) Query {
+	if groups := urlValues["group"]; len(groups) > 0 {
+		q.query(" AND groupname IN ")
+		q.param(groups)
+	}
+
+	return *q
+}
+
+// usageGroupAggregation returns true when usage statistics must be aggregated by
+// group using `aggregate` query parameter. By default, usage statistics are
+// aggregated by user and project.
+func usageGroupAggregation(urlValues url.Values) (bool, error) {
+	switch urlValues.Get("aggregate") {
+	case "", userUsageAggregation:
+		return false, nil
+	case groupUsageAggregation:
+		return true, nil
+	default:
+		return false, errInvalidAggregation
+	}
+}
+
+// usageRollupTable returns the rollup table from which current usage must be
+// estimated using `rollup` query parameter. Rollup tables have usage aggregated
+// per day or month and hence, query window is effectively rounded to the days
+// or months of the rollups. An empty table is returned when usage must be
+// estimated from units. Deprecated `experimental` query parameter is same as
+// daily rollup.
+func usageRollupTable(urlValues url.Values) (string, error) {
+	switch urlValues.Get("rollup") {
+	case "":
+		if _, ok := urlValues["experimental"]; ok {
+			return base.DailyUsageDBTableName, nil
+		}
+
+		return "", nil
+	case dailyUsageRollup:
+		return base.DailyUsageDBTableName, nil
+	case monthlyUsageRollup:
+		return base.MonthlyUsageDBTableName, nil
+	default:
+		return "", errInvalidRollup
+	}
+}
+
+// getQueriedFields returns a slice of queried fields. Fields can be passed
+// either as repeated `field` query parameters or as a comma separated list
+// in `fields` query parameter.
 func (s *CEEMSServer) getQueriedFields(urlValues url.Values, validFieldNames []string) []string {
 	// Get fields query parameters if any
 	var queriedFields []string
 
-	if fields := urlValues["field"]; len(fields) > 0 {
+	var fields []string
+
+	for _, v := range append(urlValues["field"], urlValues["fields"]...) {
+		fields = append(fields, strings.Split(v, ",")...)
+	}
+
+	if len(fields) > 0 {
 		// Check if fields are valid field names
 		for _, f := range fields {
 			f = strings.TrimSpace(f)
-			if slices.Contains(validFieldNames, f) {
+			if slices.Contains(validFieldNames, f) && !slices.Contains(queriedFields, f) {
 				queriedFields = append(queriedFields, f)
 			}
 		}
@@ -457,6 +995,64 @@ func (s *CEEMSServer) getQueriedFields(urlValues url.Values, validFieldNames []s
 	return queriedFields
 }
 
+// getSortQuery returns ORDER BY and LIMIT clauses based on `sort_by`, `order` and
+// `limit` query parameters. Only the fields in validFieldNames and keys of
+// jsonFieldNames using dot notation are valid sort fields.
+func (s *CEEMSServer) getSortQuery(urlValues url.Values, validFieldNames []string, jsonFieldNames []string) (string, error) {
+	var sortFields []string
+
+	// Get sort order. Default is ascending
+	order := "ASC"
+
+	if o := strings.TrimSpace(urlValues.Get("order")); o != "" {
+		switch strings.ToLower(o) {
+		case "asc":
+			order = "ASC"
+		case "desc":
+			order = "DESC"
+		default:
+			return "", errInvalidSortOrder
+		}
+	}
+
+	for _, f := range urlValues["sort_by"] {
+		f = strings.TrimSpace(f)
+
+		// Sort by a key of JSON column
+		if col, key, ok := strings.Cut(f, "."); ok {
+			if !slices.Contains(jsonFieldNames, col) || !jsonKeyRegex.MatchString(key) {
+				return "", fmt.Errorf("%w: %s", errInvalidSortField, f)
+			}
+
+			sortFields = append(sortFields, fmt.Sprintf("json_extract(%s, '$.%s') %s", col, key, order))
+
+			continue
+		}
+
+		if !slices.Contains(validFieldNames, f) {
+			return "", fmt.Errorf("%w: %s", errInvalidSortField, f)
+		}
+
+		sortFields = append(sortFields, fmt.Sprintf("%s %s", f, order))
+	}
+
+	// Always sort by cluster and uuid to get deterministic order
+	sortFields = append(sortFields, "cluster_id ASC", "uuid ASC")
+	sortQuery := " ORDER BY " + strings.Join(sortFields, ", ")
+
+	// Add limit if found
+	if l := strings.TrimSpace(urlValues.Get("limit")); l != "" {
+		limit, err := strconv.ParseUint(l, 10, 64)
+		if err != nil || limit == 0 {
+			return "", errInvalidLimit
+		}
+
+		sortQuery += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	return sortQuery + " ", nil
+}
+
 // timeLocation returns `time.Location` based on location name.
 func (s *CEEMSServer) timeLocation(l string) *time.Location {
 	if l == "" {
@@ -470,9 +1066,47 @@ func (s *CEEMSServer) timeLocation(l string) *time.Location {
 	}
 }
 
-// getQueryWindow returns `from` and `to` time stamps from query vars and
-// cast them into proper format.
-func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running bool, terminated bool) (Query, error) {
+// parseTimeParam parses the value of `from` and `to` query parameters into time.Time.
+// Besides the formats supported by common.ParseTime, unix timestamps in milliseconds
+// and times relative to now like `now-24h` are supported.
+func parseTimeParam(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	// Relative times
+	if s == "now" {
+		return now, nil
+	}
+
+	if d, ok := strings.CutPrefix(s, "now-"); ok {
+		duration, err := model.ParseDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return now.Add(-time.Duration(duration)), nil
+	}
+
+	if d, ok := strings.CutPrefix(s, "now+"); ok {
+		duration, err := model.ParseDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return now.Add(time.Duration(duration)), nil
+	}
+
+	// Unix timestamps in seconds will have at most 11 digits until year 5138.
+	// Any integer with more digits is treated as timestamp in milliseconds
+	if ts, err := strconv.ParseInt(s, 10, 64); err == nil && (ts >= 1e11 || ts <= -1e11) {
+		return time.UnixMilli(ts), nil
+	}
+
+	return common.ParseTime(s)
+}
+
+// getQueryWindowTimes returns `from` and `to` times from query vars. If they
+// are absent, default query window ending at current time is returned.
+func (s *CEEMSServer) getQueryWindowTimes(r *http.Request) (time.Time, time.Time, error) {
 	q := r.URL.Query()
 
 	var fromTime, toTime time.Time
@@ -482,12 +1116,12 @@ func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running boo
 		fromTime = time.Now().Add(-defaultQueryWindow).In(s.dbConfig.Data.Timezone.Location)
 	} else {
 		// Return error response if from is not a timestamp
-		if ts, err := strconv.ParseInt(f, 10, 64); err != nil {
+		if ts, err := parseTimeParam(f, time.Now()); err != nil {
 			s.logger.Error("Failed to parse from timestamp", "from", f, "err", err)
 
-			return Query{}, fmt.Errorf("query parameter 'from': %w", ErrMalformedTimeStamp)
+			return fromTime, toTime, fmt.Errorf("query parameter 'from': %w", ErrMalformedTimeStamp)
 		} else {
-			fromTime = time.Unix(ts, 0).In(s.dbConfig.Data.Timezone.Location)
+			fromTime = ts.In(s.dbConfig.Data.Timezone.Location)
 		}
 	}
 
@@ -496,27 +1130,53 @@ func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running boo
 		toTime = time.Now().In(s.dbConfig.Data.Timezone.Location)
 	} else {
 		// Return error response if to is not a timestamp
-		if ts, err := strconv.ParseInt(t, 10, 64); err != nil {
+		if ts, err := parseTimeParam(t, time.Now()); err != nil {
 			s.logger.Error("Failed to parse to timestamp", "to", t, "err", err)
 
-			return Query{}, fmt.Errorf("query parameter 'to': %w", ErrMalformedTimeStamp)
+			return fromTime, toTime, fmt.Errorf("query parameter 'to': %w", ErrMalformedTimeStamp)
 		} else {
-			toTime = time.Unix(ts, 0).In(s.dbConfig.Data.Timezone.Location)
+			toTime = ts.In(s.dbConfig.Data.Timezone.Location)
 		}
 	}
 
+	// Reject inverted time ranges
+	if fromTime.After(toTime) {
+		s.logger.Error(
+			"Invalid query time range",
+			"from", fromTime.Format(time.DateTime), "to", toTime.Format(time.DateTime),
+		)
+
+		return fromTime, toTime, ErrInvalidTimeRange
+	}
+
 	// If difference between from and to is more than max query period, return with empty
 	// response. This is to prevent users from making "big" requests that can "potentially"
-	// choke server and end up in OOM errors
-	if s.maxQueryPeriod > 0*time.Second && toTime.Sub(fromTime) > s.maxQueryPeriod {
+	// choke server and end up in OOM errors. Admin users can have a different limit.
+	maxQueryPeriod := s.maxQueryPeriod
+	if r.Header.Get(adminUserHeader) != "" {
+		maxQueryPeriod = s.adminMaxQueryPeriod
+	}
+
+	if maxQueryPeriod > 0*time.Second && toTime.Sub(fromTime) > maxQueryPeriod {
 		s.logger.Error(
 			"Exceeded maximum query time window",
-			"max_query_window", s.maxQueryPeriod,
+			"max_query_window", maxQueryPeriod,
 			"from", fromTime.Format(time.DateTime), "to", toTime.Format(time.DateTime),
 			"query_window", toTime.Sub(fromTime).String(),
 		)
 
-		return Query{}, ErrMaxQueryWindow
+		return fromTime, toTime, ErrMaxQueryWindow
+	}
+
+	return fromTime, toTime, nil
+}
+
+// getQueryWindow returns `from` and `to` time stamps from query vars and
+// cast them into proper format.
+func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running bool, terminated bool) (Query, error) {
+	fromTime, toTime, err := s.getQueryWindowTimes(r)
+	if err != nil {
+		return Query{}, err
 	}
 
 	// Initialise a sub query for adding time window to main query
@@ -544,6 +1204,17 @@ func (s *CEEMSServer) getQueryWindow(r *http.Request, column string, running boo
 	return subQuery, nil
 }
 
+// unitsTable returns the source of units terminated in the window [from, to] including
+// monthly partitions of units. Units table is returned when partitions cannot be listed.
+func (s *CEEMSServer) unitsTable(ctx context.Context, from, to time.Time) string {
+	source, err := UnitsSource(ctx, snapshotConn(ctx, s.db), from, to)
+	if err != nil {
+		s.logger.Error("Failed to list partitions of units table", "err", err)
+	}
+
+	return source
+}
+
 // roundQueryWindow rounds `to` and `from` query parameters to nearest multiple of
 // `cacheTTL`.
 func (s *CEEMSServer) roundQueryWindow(r *http.Request) error {
@@ -563,12 +1234,12 @@ func (s *CEEMSServer) roundQueryWindow(r *http.Request) error {
 		)
 	} else {
 		// Return error response if from is not a timestamp
-		if ts, err := strconv.ParseInt(f, 10, 64); err != nil {
+		if ts, err := parseTimeParam(f, time.Now()); err != nil {
 			s.logger.Error("Failed to parse from timestamp", "from", f, "err", err)
 
 			return fmt.Errorf("query parameter 'from': %w", ErrMalformedTimeStamp)
 		} else {
-			q.Set("from", strconv.FormatInt(common.Round(ts, cacheTTLSeconds), 10))
+			q.Set("from", strconv.FormatInt(common.Round(ts.Unix(), cacheTTLSeconds), 10))
 		}
 	}
 
@@ -584,12 +1255,12 @@ func (s *CEEMSServer) roundQueryWindow(r *http.Request) error {
 		)
 	} else {
 		// Return error response if from is not a timestamp
-		if ts, err := strconv.ParseInt(t, 10, 64); err != nil {
+		if ts, err := parseTimeParam(t, time.Now()); err != nil {
 			s.logger.Error("Failed to parse from timestamp", "to", t, "err", err)
 
 			return fmt.Errorf("query parameter 'to': %w", ErrMalformedTimeStamp)
 		} else {
-			q.Set("to", strconv.FormatInt(common.Round(ts, cacheTTLSeconds), 10))
+			q.Set("to", strconv.FormatInt(common.Round(ts.Unix(), cacheTTLSeconds), 10))
 		}
 	}
 
@@ -623,6 +1294,27 @@ func (s *CEEMSServer) inTargetTimeLocation(tz string, units []models.Unit) []mod
 	return units
 }
 
+// streamUnits writes compute units as newline delimited JSON while they are being
+// scanned from DB so that memory usage is bounded for large result sets.
+func (s *CEEMSServer) streamUnits(w http.ResponseWriter, r *http.Request, q Query) {
+	writer := newNDJSONWriter(w, r)
+	tz := r.URL.Query().Get("timezone")
+	unit := make([]models.Unit, 1)
+
+	err := s.queriers.unitStream(r.Context(), s.db, q, s.logger, func(u models.Unit) error {
+		unit[0] = u
+
+		return writer.Write(s.inTargetTimeLocation(tz, unit)[0])
+	})
+	if err != nil {
+		s.logger.Error("Failed to stream units", "err", err)
+	}
+
+	if err := writer.Close(err); err != nil {
+		s.logger.Error("Failed to encode NDJSON response", "err", err)
+	}
+}
+
 // unitsQuerier queries for compute units and write response.
 func (s *CEEMSServer) unitsQuerier(
 	queriedUsers []string,
@@ -633,6 +1325,10 @@ func (s *CEEMSServer) unitsQuerier(
 
 	var running bool
 
+	var from, to time.Time
+
+	var table string
+
 	var err error
 
 	// Get current logged user and dashboard user from headers
@@ -651,14 +1347,22 @@ func (s *CEEMSServer) unitsQuerier(
 	queriedFields := s.getQueriedFields(r.URL.Query(), base.UnitsDBTableColNames)
 	if len(queriedFields) == 0 {
 		s.logger.Error("Invalid query fields", "loggedUser", loggedUser, "err", errInvalidQueryField)
-		errorResponse[any](w, &apiError{errorBadData, errInvalidQueryField}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidQueryField}, s.logger)
 
 		return
 	}
 
-	// Initialise query builder
+	// Get sort query parameters if any
+	sortQuery, err := s.getSortQuery(r.URL.Query(), base.UnitsDBTableColNames, unitsJSONColNames)
+	if err != nil {
+		s.logger.Error("Invalid sort query parameters", "loggedUser", loggedUser, "err", err)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	// Initialise query builder for the conditions on units
 	q := Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UnitsDBTableName))
 
 	// Query for only unignored units
 	q.query(" WHERE ignore = 0 ")
@@ -671,6 +1375,71 @@ func (s *CEEMSServer) unitsQuerier(
 
 	// Add common query parameters
 	q = s.getCommonQueryParams(&q, r.URL.Query())
+	q = s.getGroupQueryParams(&q, r.URL.Query())
+
+	// Add state filter if present. SLURM reports states like CANCELLED with
+	// the uid of the user who cancelled the job, e.g., "CANCELLED by 1000".
+	// So states are matched on their prefix as well
+	if states := r.URL.Query()["state"]; len(states) > 0 {
+		q.query(" AND (state IN ")
+		q.param(states)
+
+		for _, state := range states {
+			q.query(" OR state LIKE ")
+			q.param([]string{likeEscaper.Replace(state) + " %"})
+			q.query(` ESCAPE '\'`)
+		}
+
+		q.query(")")
+	}
+
+	// Add exit code filter if present. Both exit_code and exitcode are
+	// accepted as query parameter
+	if exitCodes := slices.Concat(r.URL.Query()["exit_code"], r.URL.Query()["exitcode"]); len(exitCodes) > 0 {
+		q.query(" AND json_extract(tags, '$.exit_code') IN ")
+		q.param(exitCodes)
+	}
+
+	// Add partition and qos filters if present
+	if partitions := r.URL.Query()["partition"]; len(partitions) > 0 {
+		q.query(" AND json_extract(tags, '$.partition') IN ")
+		q.param(partitions)
+	}
+
+	if qos := r.URL.Query()["qos"]; len(qos) > 0 {
+		q.query(" AND json_extract(tags, '$.qos') IN ")
+		q.param(qos)
+	}
+
+	// Add node filter if present. Nodes of units are normalized into unit nodes
+	// table at ingest so that units can be looked up using its index on node
+	if nodes := r.URL.Query()["node"]; len(nodes) > 0 {
+		q.query(fmt.Sprintf(" AND id IN (SELECT unit_id FROM %s WHERE node IN ", base.UnitNodesDBTableName))
+		q.param(nodes)
+		q.query(")")
+	}
+
+	// Add search filter on name and work directory of units if present. Special
+	// characters of LIKE are escaped so that search terms are matched literally
+	if terms := r.URL.Query()["search"]; len(terms) > 0 {
+		q.query(" AND (")
+
+		for iterm, term := range terms {
+			if iterm > 0 {
+				q.query(" OR ")
+			}
+
+			pattern := likePattern(term)
+
+			q.query("name LIKE ")
+			q.param([]string{pattern})
+			q.query(` ESCAPE '\' OR json_extract(tags, '$.workdir') LIKE `)
+			q.param([]string{pattern})
+			q.query(` ESCAPE '\'`)
+		}
+
+		q.query(")")
+	}
 
 	// Check if uuid present in query params and add them
 	// If any of uuid query params are present
@@ -694,7 +1463,7 @@ func (s *CEEMSServer) unitsQuerier(
 	// Get query window time stamps
 	timeQuery, err = s.getQueryWindow(r, "ended_at", running, false)
 	if err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+		errorResponse(w, r, queryWindowError(err), s.logger)
 
 		return
 	}
@@ -703,15 +1472,36 @@ func (s *CEEMSServer) unitsQuerier(
 	q.query(" AND ")
 	q.subQuery(timeQuery)
 
+	// Only partitions of units overlapping with query window need to be queried
+	from, to, _ = s.getQueryWindowTimes(r)
+
 queryUnits:
-	// Sort by uuid
-	q.query(" ORDER BY cluster_id ASC, uuid ASC ")
+	// Source of units including their monthly partitions, if any
+	table = s.unitsTable(r.Context(), from, to)
+
+	// Respond with 304 when units matching the query have not been modified
+	if s.notModified(w, r, table, q) {
+		return
+	}
+
+	// Select queried fields and sort units
+	uq := Query{}
+	uq.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), table))
+	uq.append(q)
+	uq.query(sortQuery)
+
+	// Stream units as they are scanned when NDJSON response is requested
+	if ndjsonRequested(r) {
+		s.streamUnits(w, r, uq)
+
+		return
+	}
 
 	// Get all user units in the given time window
-	units, err := s.queriers.unit(r.Context(), s.db, q, s.logger)
+	units, err := s.queriers.unit(r.Context(), s.db, uq, s.logger)
 	if units == nil && err != nil {
 		s.logger.Error("Failed to fetch units", "loggedUser", loggedUser, "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -719,6 +1509,15 @@ queryUnits:
 	// Convert times to time zone provided in the query
 	units = s.inTargetTimeLocation(r.URL.Query().Get("timezone"), units)
 
+	// Write CSV response when requested
+	if csvRequested(r) {
+		if err := writeCSV(w, units, queriedFields, "units.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
+
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -753,6 +1552,9 @@ queryUnits:
 //	@Description
 //	@Description	In order to return the running compute units as well, use the query parameter `running`.
 //	@Description
+//	@Description	Query parameters `from` and `to` accept unix timestamps in seconds or milliseconds,
+//	@Description	RFC3339 formatted times and times relative to now like `now-24h`.
+//	@Description
 //	@Description	If `to` query parameter is not provided, current time will be used. If `from`
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs. If query
@@ -761,23 +1563,45 @@ queryUnits:
 //	@Description
 //	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
 //	@Description	fields will be included in the response if they are _non-empty_.
+//	@Description
+//	@Description	Units can be sorted using `sort_by` query parameter with `order` being either `asc` or `desc`.
+//	@Description	Keys of metric maps and allocation can be used with dot notation, for instance,
+//	@Description	`sort_by=total_time_seconds.walltime&order=desc&limit=10` returns ten longest units.
+//	@Description
+//	@Description	For large result sets, use `format=ndjson` to stream one unit per line as they are read
+//	@Description	from DB. If an error occurs while streaming, the last line will be a problem details object.
 //	@Security		BasicAuth
 //	@Tags			units
 //	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
 //	@Param			X-Grafana-User	header		string		true	"Current user name"
 //	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
+//	@Param			state			query		[]string	false	"Unit state"	collectionFormat(multi)
+//	@Param			exit_code		query		[]string	false	"Unit exit code"	collectionFormat(multi)
+//	@Param			exitcode		query		[]string	false	"Unit exit code. Alias of exit_code"	collectionFormat(multi)
+//	@Param			partition		query		[]string	false	"Partition"		collectionFormat(multi)
+//	@Param			qos				query		[]string	false	"QoS"			collectionFormat(multi)
+//	@Param			node			query		[]string	false	"Node name"		collectionFormat(multi)
+//	@Param			search			query		[]string	false	"Search term in name or work directory of unit"	collectionFormat(multi)
 //	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			group			query		[]string	false	"Group"			collectionFormat(multi)
 //	@Param			user			query		[]string	false	"User name"		collectionFormat(multi)
 //	@Param			running			query		bool		false	"Whether to fetch running units"
 //	@Param			from			query		string		false	"From timestamp"
 //	@Param			to				query		string		false	"To timestamp"
 //	@Param			timezone		query		string		false	"Time zone in IANA format"
 //	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			fields			query		string		false	"Comma separated list of fields to return in response"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV and ndjson for streamed newline delimited JSON response"	Enums(json, csv, ndjson)
+//	@Param			sort_by			query		[]string	false	"Fields to sort units"	collectionFormat(multi)
+//	@Param			order			query		string		false	"Sort order: asc or desc"
+//	@Param			limit			query		int			false	"Maximum number of units to return"
 //	@Success		200				{object}	Response[models.Unit]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
 //	@Router			/units/admin [get]
 //
 // GET /units/admin
@@ -804,6 +1628,9 @@ func (s *CEEMSServer) unitsAdmin(w http.ResponseWriter, r *http.Request) {
 //	@Description
 //	@Description	In order to return the running compute units as well, use the query parameter `running`.
 //	@Description
+//	@Description	Query parameters `from` and `to` accept unix timestamps in seconds or milliseconds,
+//	@Description	RFC3339 formatted times and times relative to now like `now-24h`.
+//	@Description
 //	@Description	If `to` query parameter is not provided, current time will be used. If `from`
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs. If query
@@ -812,22 +1639,44 @@ func (s *CEEMSServer) unitsAdmin(w http.ResponseWriter, r *http.Request) {
 //	@Description
 //	@Description	To limit the number of fields in the response, use `field` query parameter. By default, all
 //	@Description	fields will be included in the response if they are _non-empty_.
+//	@Description
+//	@Description	Units can be sorted using `sort_by` query parameter with `order` being either `asc` or `desc`.
+//	@Description	Keys of metric maps and allocation can be used with dot notation, for instance,
+//	@Description	`sort_by=total_time_seconds.walltime&order=desc&limit=10` returns ten longest units.
+//	@Description
+//	@Description	For large result sets, use `format=ndjson` to stream one unit per line as they are read
+//	@Description	from DB. If an error occurs while streaming, the last line will be a problem details object.
 //	@Security		BasicAuth
 //	@Tags			units
 //	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
 //	@Param			X-Grafana-User	header		string		true	"Current user name"
 //	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
+//	@Param			state			query		[]string	false	"Unit state"	collectionFormat(multi)
+//	@Param			exit_code		query		[]string	false	"Unit exit code"	collectionFormat(multi)
+//	@Param			exitcode		query		[]string	false	"Unit exit code. Alias of exit_code"	collectionFormat(multi)
+//	@Param			partition		query		[]string	false	"Partition"		collectionFormat(multi)
+//	@Param			qos				query		[]string	false	"QoS"			collectionFormat(multi)
+//	@Param			node			query		[]string	false	"Node name"		collectionFormat(multi)
+//	@Param			search			query		[]string	false	"Search term in name or work directory of unit"	collectionFormat(multi)
 //	@Param			project			query		[]string	false	"Project"		collectionFormat(multi)
+//	@Param			group			query		[]string	false	"Group"			collectionFormat(multi)
 //	@Param			running			query		bool		false	"Whether to fetch running units"
 //	@Param			from			query		string		false	"From timestamp"
 //	@Param			to				query		string		false	"To timestamp"
 //	@Param			timezone		query		string		false	"Time zone in IANA format"
 //	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			fields			query		string		false	"Comma separated list of fields to return in response"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV and ndjson for streamed newline delimited JSON response"	Enums(json, csv, ndjson)
+//	@Param			sort_by			query		[]string	false	"Fields to sort units"	collectionFormat(multi)
+//	@Param			order			query		string		false	"Sort order: asc or desc"
+//	@Param			limit			query		int			false	"Maximum number of units to return"
 //	@Success		200				{object}	Response[models.Unit]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
 //	@Router			/units [get]
 //
 // GET /units
@@ -836,93 +1685,541 @@ func (s *CEEMSServer) units(w http.ResponseWriter, r *http.Request) {
 	// Measure elapsed time
 	defer common.TimeTrack(time.Now(), "units endpoint", s.logger)
 
-	// Get current logged user and dashboard user from headers
-	_, dashboardUser := s.getUser(r)
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	// Query for units and write response
+	s.unitsQuerier([]string{dashboardUser}, w, r)
+}
+
+// verifyUnitsOwnership         godoc
+//
+//	@Summary		Verify unit ownership
+//	@Description	This endpoint will check if the current user is the owner of the
+//	@Description	queried UUIDs. The current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	A response of 200 means that the current user is the owner of the queried UUIDs.
+//	@Description	Any other response code should be treated as the current user not being the owner
+//	@Description	of the queried units.
+//	@Description
+//	@Description	The ownership check passes if any of the following conditions are `true`:
+//	@Description	- If the current user is the _direct_ owner of the compute unit.
+//	@Description	- If the current user belongs to the same account/project/namespace as
+//	@Description	the compute unit. This means the users belonging to the same project can
+//	@Description	access each others compute units.
+//	@Description
+//	@Description	The above checks must pass for **all** the queried units.
+//	@Description	If the check does not pass for at least one queried unit, a response 403 will be
+//	@Description	returned.
+//	@Description
+//	@Description	Any 500 response codes should be treated as failed check as well.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			time			query		[]string	false	"Timestamps"	collectionFormat(multi)
+//	@Success		200				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/units/verify [get]
+//
+// GET /units/verify
+// Verify the user ownership for queried units.
+func (s *CEEMSServer) verifyUnitsOwnership(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "verify endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	// Get cluster ID
+	clusterID := r.URL.Query()["cluster_id"]
+
+	// Get list of queried uuids
+	uuids := r.URL.Query()["uuid"]
+	if len(uuids) == 0 {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingUUIDs}, s.logger)
+
+		return
+	}
+
+	// Get start time of queried uuids
+	var starts []int64
+
+	for _, s := range r.URL.Query()["time"] {
+		if is, err := strconv.ParseInt(s, 10, 64); err == nil {
+			starts = append(starts, is)
+		}
+	}
+
+	// Check if user is owner of the queries uuids
+	if VerifyOwnership(r.Context(), dashboardUser, s.isAdmin(r, dashboardUser), clusterID, uuids, starts, s.db, s.logger) {
+		w.WriteHeader(http.StatusOK)
+
+		response := Response[string]{
+			Status: "success",
+		}
+		if err := json.NewEncoder(w).Encode(&response); err != nil {
+			s.logger.Error("Failed to encode response", "err", err)
+			w.Write([]byte("KO"))
+		}
+	} else {
+		errorResponse(w, r, &apiError{errorForbidden, errNoAuth}, s.logger)
+	}
+}
+
+// verifyUnitsOwnershipBatch         godoc
+//
+//	@Summary		Verify ownership of a batch of units
+//	@Description	This endpoint returns the ownership status of each queried UUID for the
+//	@Description	current user. The current user is always identified by the header
+//	@Description	`X-Grafana-User` in the request.
+//	@Description
+//	@Description	Unlike `GET /units/verify`, this endpoint does not fail when the current user
+//	@Description	does not own at least one of the queried units. It reports which of the units
+//	@Description	the user owns so that clients like CEEMS LB can authorize queries in bulk in one
+//	@Description	round trip. The same ownership rules as `GET /units/verify` apply.
+//	@Description
+//	@Description	As ownership of a unit never changes, the responses can be cached privately by
+//	@Description	clients for the duration given in `Cache-Control` header. Clients must only cache
+//	@Description	the units that user owns as units that are not in DB yet are reported as not owned.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string			true	"Current user name"
+//	@Param			request			body		VerifyRequest	true	"UUIDs, cluster IDs and start times of units"
+//	@Success		200				{object}	Response[UnitOwnership]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/units/verify [post]
+//
+// POST /units/verify
+// Verify the user ownership for a batch of units.
+func (s *CEEMSServer) verifyUnitsOwnershipBatch(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "batch verify endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current logged user and dashboard user from headers
+	_, dashboardUser := s.getUser(r)
+
+	// Decode request
+	var req VerifyRequest
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1024*1024)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
+
+		return
+	}
+
+	if len(req.UUIDs) == 0 {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingUUIDs}, s.logger)
+
+		return
+	}
+
+	ownership, err := UnitsOwnership(
+		r.Context(), dashboardUser, s.isAdmin(r, dashboardUser), req.ClusterIDs, req.UUIDs, req.Starts, s.db, s.logger,
+	)
+	if err != nil {
+		s.logger.Error("Failed to verify ownership of units", "user", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Ownership depends on the user and hence, responses must only be cached
+	// privately
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(verifyMaxAge.Seconds())))
+	w.Header().Add("Vary", grafanaUserHeader)
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	response := Response[UnitOwnership]{
+		Status: "success",
+		Data:   ownership,
+	}
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// unitAccess checks if the logged user can access annotations and steps of the
+// unit in the request and returns unit's UUID and cluster ID. If the user
+// cannot access the unit, an error response is written and ok will be false.
+func (s *CEEMSServer) unitAccess(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	// Get current logged user from headers
+	loggedUser, _ := s.getUser(r)
+
+	// Get UUID from path
+	uuid := mux.Vars(r)["uuid"]
+
+	// Get cluster ID. It is mandatory as UUIDs are only unique within a cluster
+	clusterID := r.URL.Query().Get("cluster_id")
+	if clusterID == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingClusterID}, s.logger)
+
+		return "", "", false
+	}
+
+	// Only owners of the unit and admins can access it
+	if !VerifyOwnership(r.Context(), loggedUser, s.isAdmin(r, loggedUser), []string{clusterID}, []string{uuid}, nil, s.db, s.logger) {
+		errorResponse(w, r, &apiError{errorForbidden, errNoAuth}, s.logger)
+
+		return "", "", false
+	}
+
+	return uuid, clusterID, true
+}
+
+// annotations         godoc
+//
+//	@Summary		Show annotations of a compute unit
+//	@Description	This endpoint will show the annotations of a given compute unit. The
+//	@Description	current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request.
+//	@Description
+//	@Description	Only the owners of the compute unit and admin users can fetch the
+//	@Description	annotations. The query parameter `cluster_id` is mandatory.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Param		uuid			path		string	true	"Unit UUID"
+//	@Param		cluster_id		query		string	true	"Cluster ID"
+//	@Success	200				{object}	Response[models.Annotation]
+//	@Failure	400				{object}	Problem
+//	@Failure	401				{object}	Problem
+//	@Failure	403				{object}	Problem
+//	@Failure	500				{object}	Problem
+//	@Router		/units/{uuid}/annotations [get]
+//
+// GET /units/{uuid}/annotations
+// Get annotations of a unit.
+func (s *CEEMSServer) annotations(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "annotations endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Check if user can access annotations
+	uuid, clusterID, ok := s.unitAccess(w, r)
+	if !ok {
+		return
+	}
+
+	// Make query
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE cluster_id = ", base.AnnotationsDBTableName))
+	q.param([]string{clusterID})
+	q.query(" AND uuid = ")
+	q.param([]string{uuid})
+	q.query(" ORDER BY created_at ASC")
+
+	// Make query and get annotations
+	annotations, err := s.queriers.annot(r.Context(), s.db, q, s.logger)
+	if annotations == nil && err != nil {
+		s.logger.Error("Failed to fetch annotations", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	annotationsResponse := Response[models.Annotation]{
+		Status: "success",
+		Data:   annotations,
+	}
+	if err != nil {
+		annotationsResponse.Warnings = append(annotationsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&annotationsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// addAnnotation         godoc
+//
+//	@Summary		Add annotation to a compute unit
+//	@Description	This endpoint will add a free text annotation to a given compute unit.
+//	@Description	The current user is always identified by the header `X-Grafana-User` in
+//	@Description	the request and it will be recorded as the author of the annotation.
+//	@Description
+//	@Description	Only the owners of the compute unit and admin users can add the
+//	@Description	annotations. The query parameter `cluster_id` is mandatory.
+//	@Description
+//	@Description	The request body must be a JSON object with `note` key containing
+//	@Description	a non empty note of at most 4096 characters.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Accept		json
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string				true	"Current user name"
+//	@Param		uuid			path		string				true	"Unit UUID"
+//	@Param		cluster_id		query		string				true	"Cluster ID"
+//	@Param		annotation		body		models.Annotation	true	"Annotation"
+//	@Success	201				{object}	Response[models.Annotation]
+//	@Failure	400				{object}	Problem
+//	@Failure	401				{object}	Problem
+//	@Failure	403				{object}	Problem
+//	@Failure	500				{object}	Problem
+//	@Router		/units/{uuid}/annotations [post]
+//
+// POST /units/{uuid}/annotations
+// Add annotation to a unit.
+func (s *CEEMSServer) addAnnotation(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "add annotation endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Check if user can access annotations
+	uuid, clusterID, ok := s.unitAccess(w, r)
+	if !ok {
+		return
+	}
+
+	// Decode request body
+	var annotation models.Annotation
+
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	if err := json.NewDecoder(r.Body).Decode(&annotation); err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidAnnotation}, s.logger)
+
+		return
+	}
+
+	annotation.Note = strings.TrimSpace(annotation.Note)
+	if annotation.Note == "" || len(annotation.Note) > maxAnnotationLength {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidAnnotation}, s.logger)
+
+		return
+	}
+
+	// Set rest of the fields from request. Do not trust the ones in request body
+	loggedUser, _ := s.getUser(r)
+	annotation.ClusterID = clusterID
+	annotation.UUID = uuid
+	annotation.Author = loggedUser
+	annotation.CreatedAt = time.Now().In(s.timeLocation("")).Format(base.DatetimezoneLayout)
+
+	//nolint:gosec
+	if _, err := s.dbRW.ExecContext(
+		r.Context(),
+		fmt.Sprintf(
+			"INSERT INTO %s (cluster_id,uuid,author,note,created_at) VALUES (?,?,?,?,?)",
+			base.AnnotationsDBTableName,
+		),
+		annotation.ClusterID, annotation.UUID, annotation.Author, annotation.Note, annotation.CreatedAt,
+	); err != nil {
+		s.logger.Error("Failed to add annotation", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusCreated)
+
+	response := Response[models.Annotation]{
+		Status: "success",
+		Data:   []models.Annotation{annotation},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
+// unitSteps         godoc
+//
+//	@Summary		Show steps of a compute unit
+//	@Description	This endpoint will show the steps of a given compute unit, like job steps
+//	@Description	of SLURM, along with the resources consumed by each step. The current user
+//	@Description	is always identified by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Only the owners of the compute unit and admin users can fetch the
+//	@Description	steps. The query parameter `cluster_id` is mandatory.
+//	@Description
+//	@Description	Steps are only available for the resource managers that report them and
+//	@Description	when fetching them is enabled in the cluster configuration.
+//	@Description
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Param		uuid			path		string	true	"Unit UUID"
+//	@Param		cluster_id		query		string	true	"Cluster ID"
+//	@Success	200				{object}	Response[models.UnitStep]
+//	@Failure	400				{object}	Problem
+//	@Failure	401				{object}	Problem
+//	@Failure	403				{object}	Problem
+//	@Failure	500				{object}	Problem
+//	@Router		/units/{uuid}/steps [get]
+//
+// GET /units/{uuid}/steps
+// Get steps of a unit.
+func (s *CEEMSServer) unitSteps(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "unit steps endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
 
-	// Query for units and write response
-	s.unitsQuerier([]string{dashboardUser}, w, r)
+	// Check if user can access unit
+	uuid, clusterID, ok := s.unitAccess(w, r)
+	if !ok {
+		return
+	}
+
+	// Make query. Terminated units can be in any of the partitions
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE unit_id IN (SELECT id FROM %s WHERE cluster_id = ",
+			strings.Join(base.UnitStepsDBTableColNames, ","),
+			base.UnitStepsDBTableName,
+			s.unitsTable(r.Context(), time.Time{}, time.Time{}),
+		),
+	)
+	q.param([]string{clusterID})
+	q.query(" AND uuid = ")
+	q.param([]string{uuid})
+	q.query(") ORDER BY started_at_ts ASC, step_id ASC")
+
+	// Make query and get steps
+	steps, err := s.queriers.step(r.Context(), s.db, q, s.logger)
+	if steps == nil && err != nil {
+		s.logger.Error("Failed to fetch unit steps", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	stepsResponse := Response[models.UnitStep]{
+		Status: "success",
+		Data:   steps,
+	}
+	if err != nil {
+		stepsResponse.Warnings = append(stepsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&stepsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
 }
 
-// verifyUnitsOwnership         godoc
+// unitScriptAdmin         godoc
 //
-//	@Summary		Verify unit ownership
-//	@Description	This endpoint will check if the current user is the owner of the
-//	@Description	queried UUIDs. The current user is always identified by the header `X-Grafana-User` in
-//	@Description	the request.
-//	@Description
-//	@Description	A response of 200 means that the current user is the owner of the queried UUIDs.
-//	@Description	Any other response code should be treated as the current user not being the owner
-//	@Description	of the queried units.
+//	@Summary		Admin endpoint to show script of a compute unit
+//	@Description	This admin endpoint will show the script and environment with which a
+//	@Description	given compute unit has been submitted. The current user is always
+//	@Description	identified by the header `X-Grafana-User` in the request.
 //	@Description
-//	@Description	The ownership check passes if any of the following conditions are `true`:
-//	@Description	- If the current user is the _direct_ owner of the compute unit.
-//	@Description	- If the current user belongs to the same account/project/namespace as
-//	@Description	the compute unit. This means the users belonging to the same project can
-//	@Description	access each others compute units.
+//	@Description	The user who is making the request must be in the list of admin users
+//	@Description	configured for the server. The query parameter `cluster_id` is mandatory.
 //	@Description
-//	@Description	The above checks must pass for **all** the queried units.
-//	@Description	If the check does not pass for at least one queried unit, a response 403 will be
-//	@Description	returned.
+//	@Description	Scripts are only available for the resource managers that report them and
+//	@Description	when fetching them is enabled in the cluster configuration.
 //	@Description
-//	@Description	Any 500 response codes should be treated as failed check as well.
-//	@Security		BasicAuth
-//	@Tags			units
-//	@Produce		json
-//	@Param			X-Grafana-User	header		string		true	"Current user name"
-//	@Param			uuid			query		[]string	false	"Unit UUID"		collectionFormat(multi)
-//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
-//	@Param			time			query		[]string	false	"Timestamps"	collectionFormat(multi)
-//	@Success		200				{object}	Response[any]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
-//	@Router			/units/verify [get]
+//	@Security	BasicAuth
+//	@Tags		units
+//	@Produce	json
+//	@Param		X-Grafana-User	header		string	true	"Current user name"
+//	@Param		uuid			path		string	true	"Unit UUID"
+//	@Param		cluster_id		query		string	true	"Cluster ID"
+//	@Success	200				{object}	Response[models.UnitScript]
+//	@Failure	400				{object}	Problem
+//	@Failure	401				{object}	Problem
+//	@Failure	403				{object}	Problem
+//	@Failure	500				{object}	Problem
+//	@Router		/units/{uuid}/script/admin [get]
 //
-// GET /units/verify
-// Verify the user ownership for queried units.
-func (s *CEEMSServer) verifyUnitsOwnership(w http.ResponseWriter, r *http.Request) {
+// GET /units/{uuid}/script/admin
+// Get script of a unit.
+func (s *CEEMSServer) unitScriptAdmin(w http.ResponseWriter, r *http.Request) {
 	// Measure elapsed time
-	defer common.TimeTrack(time.Now(), "verify endpoint", s.logger)
+	defer common.TimeTrack(time.Now(), "unit script admin endpoint", s.logger)
 
 	// Set headers
 	s.setHeaders(w)
 
-	// Get current logged user and dashboard user from headers
-	_, dashboardUser := s.getUser(r)
-
-	// Get cluster ID
-	clusterID := r.URL.Query()["cluster_id"]
+	// Get UUID from path
+	uuid := mux.Vars(r)["uuid"]
 
-	// Get list of queried uuids
-	uuids := r.URL.Query()["uuid"]
-	if len(uuids) == 0 {
-		errorResponse[any](w, &apiError{errorBadData, errMissingUUIDs}, s.logger, nil)
+	// Get cluster ID. It is mandatory as UUIDs are only unique within a cluster
+	clusterID := r.URL.Query().Get("cluster_id")
+	if clusterID == "" {
+		errorResponse(w, r, &apiError{errorBadRequest, errMissingClusterID}, s.logger)
 
 		return
 	}
 
-	// Get start time of queried uuids
-	var starts []int64
+	// Make query. Terminated units can be in any of the partitions
+	q := Query{}
+	q.query(
+		fmt.Sprintf(
+			"SELECT %s FROM %s WHERE unit_id IN (SELECT id FROM %s WHERE cluster_id = ",
+			strings.Join(base.UnitScriptsDBTableColNames, ","),
+			base.UnitScriptsDBTableName,
+			s.unitsTable(r.Context(), time.Time{}, time.Time{}),
+		),
+	)
+	q.param([]string{clusterID})
+	q.query(" AND uuid = ")
+	q.param([]string{uuid})
+	q.query(") ORDER BY unit_id ASC")
 
-	for _, s := range r.URL.Query()["time"] {
-		if is, err := strconv.ParseInt(s, 10, 64); err == nil {
-			starts = append(starts, is)
-		}
+	// Make query and get scripts
+	scripts, err := s.queriers.script(r.Context(), s.db, q, s.logger)
+	if scripts == nil && err != nil {
+		s.logger.Error("Failed to fetch unit script", "uuid", uuid, "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
 	}
 
-	// Check if user is owner of the queries uuids
-	if VerifyOwnership(r.Context(), dashboardUser, clusterID, uuids, starts, s.db, s.logger) {
-		w.WriteHeader(http.StatusOK)
+	// Write response
+	w.WriteHeader(http.StatusOK)
 
-		response := Response[string]{
-			Status: "success",
-		}
-		if err := json.NewEncoder(w).Encode(&response); err != nil {
-			s.logger.Error("Failed to encode response", "err", err)
-			w.Write([]byte("KO"))
-		}
-	} else {
-		errorResponse[any](w, &apiError{errorForbidden, errNoAuth}, s.logger, nil)
+	scriptsResponse := Response[models.UnitScript]{
+		Status: "success",
+		Data:   scripts,
+	}
+	if err != nil {
+		scriptsResponse.Warnings = append(scriptsResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&scriptsResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
 	}
 }
 
@@ -942,8 +2239,8 @@ func (s *CEEMSServer) verifyUnitsOwnership(w http.ResponseWriter, r *http.Reques
 //	@Produce	json
 //	@Param		X-Grafana-User	header		string	true	"Current user name"
 //	@Success	200				{object}	Response[models.Cluster]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/clusters/admin [get]
 //
 // GET /clusters/admin
@@ -963,7 +2260,7 @@ func (s *CEEMSServer) clustersAdmin(w http.ResponseWriter, r *http.Request) {
 	q.query(
 		fmt.Sprintf(
 			"SELECT DISTINCT cluster_id, resource_manager FROM %s ORDER BY cluster_id ASC",
-			base.UnitsDBTableName,
+			s.unitsTable(r.Context(), time.Time{}, time.Time{}),
 		),
 	)
 
@@ -971,7 +2268,7 @@ func (s *CEEMSServer) clustersAdmin(w http.ResponseWriter, r *http.Request) {
 	clusterIDs, err := s.queriers.cluster(r.Context(), s.db, q, s.logger)
 	if clusterIDs == nil && err != nil {
 		s.logger.Error("Failed to fetch cluster IDs", "user", dashboardUser, "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -993,14 +2290,115 @@ func (s *CEEMSServer) clustersAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// nodeEnergy         godoc
+//
+//	@Summary		Show node energy usage and emissions
+//	@Description	This endpoint will show energy usage and emissions of a given compute node
+//	@Description	aggregated into hourly periods. The current user is always identified by the
+//	@Description	header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	Node level metrics are estimated by the updaters independent of the compute
+//	@Description	units that ran on the node. Hence, they include the energy usage of idle nodes
+//	@Description	as well.
+//	@Description
+//	@Description	If the query parameter `cluster_id` is provided, only the node stats of
+//	@Description	given cluster(s) will be returned. The query parameters `from` and `to` can be
+//	@Description	used to control the time window. By default, stats of the last one week will
+//	@Description	be returned.
+//	@Security		BasicAuth
+//	@Tags			nodes
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string		true	"Current user name"
+//	@Param			hostname		path		string		true	"Hostname of the node"
+//	@Param			cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
+//	@Param			from			query		string		false	"From timestamp"
+//	@Param			to				query		string		false	"To timestamp"
+//	@Success		200				{object}	Response[models.Node]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Router			/nodes/{hostname}/energy [get]
+//
+// GET /nodes/{hostname}/energy
+// Get energy usage and emissions of a node.
+func (s *CEEMSServer) nodeEnergy(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "node energy endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	// Get current user from header
+	loggedUser, _ := s.getUser(r)
+
+	// Get hostname from path
+	hostname := mux.Vars(r)["hostname"]
+
+	// Make query
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT * FROM %s WHERE hostname = ", base.NodesDBTableName))
+	q.param([]string{hostname})
+
+	if clusterIDs := r.URL.Query()["cluster_id"]; len(clusterIDs) > 0 {
+		q.query(" AND cluster_id IN ")
+		q.param(clusterIDs)
+	}
+
+	// Get query window time stamps
+	timeQuery, err := s.getQueryWindow(r, "period_start", false, false)
+	if err != nil {
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	q.query(" AND ")
+	q.subQuery(timeQuery)
+	q.query(" ORDER BY cluster_id ASC, period_start ASC")
+
+	// Make query and get node stats
+	nodes, err := s.queriers.node(r.Context(), s.db, q, s.logger)
+	if nodes == nil && err != nil {
+		s.logger.Error("Failed to fetch node stats", "loggedUser", loggedUser, "hostname", hostname, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Write response
+	w.WriteHeader(http.StatusOK)
+
+	nodesResponse := Response[models.Node]{
+		Status: "success",
+		Data:   nodes,
+	}
+	if err != nil {
+		nodesResponse.Warnings = append(nodesResponse.Warnings, err.Error())
+	}
+
+	if err = json.NewEncoder(w).Encode(&nodesResponse); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
+
 // Get user details.
 func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *http.Request) {
 	// Set headers
 	s.setHeaders(w)
 
-	// Make query
+	// Make query. Last activity of user is the creation or end time of the
+	// most recent compute unit of the user, whichever is latest. Units source
+	// is aliased as units table when it includes partitions
 	q := Query{}
-	q.query("SELECT * FROM " + base.UsersDBTableName)
+	q.query(
+		fmt.Sprintf(
+			"SELECT *, COALESCE((SELECT CASE WHEN ended_at_ts > created_at_ts THEN ended_at ELSE created_at END FROM %[1]s "+
+				"WHERE %[3]s.username = %[2]s.name AND %[3]s.cluster_id = %[2]s.cluster_id "+
+				"ORDER BY MAX(created_at_ts, ended_at_ts) DESC LIMIT 1), '') AS last_activity_at FROM %[2]s",
+			s.unitsTable(r.Context(), time.Time{}, time.Time{}), base.UsersDBTableName, base.UnitsDBTableName,
+		),
+	)
 	// If no user is queried, return all users. This can happen only for admin
 	// end points
 	if len(users) == 0 {
@@ -1023,7 +2421,7 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 	userModels, err := s.queriers.user(r.Context(), s.db, q, s.logger)
 	if userModels == nil && err != nil {
 		s.logger.Error("Failed to fetch user details", "users", strings.Join(users, ","), "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -1060,8 +2458,8 @@ func (s *CEEMSServer) usersQuerier(users []string, w http.ResponseWriter, r *htt
 //	@Param		X-Grafana-User	header		string		true	"Current user name"
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Success	200				{object}	Response[models.User]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/users [get]
 //
 // GET /users
@@ -1090,7 +2488,9 @@ func (s *CEEMSServer) users(w http.ResponseWriter, r *http.Request) {
 //	@Description	When the query parameter `user` is empty, all users will be returned
 //	@Description	in the response.
 //	@Description
-//	@Description	The details include list of projects that user is currently a part of.
+//	@Description	The details include list of projects that user is currently a part of and
+//	@Description	the timestamp of last activity of the user, which is the creation or end
+//	@Description	time of the most recent compute unit of the user.
 //	@Description
 //	@Security	BasicAuth
 //	@Tags		users
@@ -1099,8 +2499,8 @@ func (s *CEEMSServer) users(w http.ResponseWriter, r *http.Request) {
 //	@Param		user			query		[]string	false	"User name"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Success	200				{object}	Response[models.User]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/users/admin [get]
 //
 // GET /users/admin
@@ -1151,7 +2551,7 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 			"Failed to fetch project details",
 			"users", strings.Join(users, ","), "err", err,
 		)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -1191,8 +2591,8 @@ func (s *CEEMSServer) projectsQuerier(users []string, w http.ResponseWriter, r *
 //	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Success	200				{object}	Response[models.Project]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/projects [get]
 //
 // GET /projects
@@ -1229,8 +2629,8 @@ func (s *CEEMSServer) projects(w http.ResponseWriter, r *http.Request) {
 //	@Param		project			query		[]string	false	"Project"		collectionFormat(multi)
 //	@Param		cluster_id		query		[]string	false	"Cluster ID"	collectionFormat(multi)
 //	@Success	200				{object}	Response[models.Project]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/projects/admin [get]
 //
 // GET /projects/admin
@@ -1247,17 +2647,19 @@ func (s *CEEMSServer) projectsAdmin(w http.ResponseWriter, r *http.Request) {
 func (s *CEEMSServer) aggQueryBuilder(
 	r *http.Request,
 	metric string,
+	unitsTable string,
 	timeQuery Query,
 ) string {
 	// Query to return all unqiue json keys
 	q := Query{}
-	q.query(fmt.Sprintf("SELECT DISTINCT json_each.key AS name FROM %s, json_each(%s)", base.UnitsDBTableName, metric))
+	q.query(fmt.Sprintf("SELECT DISTINCT json_each.key AS name FROM %s, json_each(%s)", unitsTable, metric))
 
 	// Ignore null values
 	q.query(" WHERE json_each.key IS NOT NULL ")
 
 	// Add common query parameters
 	q = s.getCommonQueryParams(&q, r.URL.Query())
+	q = s.getGroupQueryParams(&q, r.URL.Query())
 
 	// Add time query as sub query to main query
 	q.query(" AND ")
@@ -1293,15 +2695,16 @@ func (s *CEEMSServer) aggQueryBuilder(
 }
 
 // GET /usage/current
-// Get current usage statistics.
-func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.ResponseWriter, r *http.Request) {
+// Get current usage statistics. Caller is the non-admin user making the request
+// and it is empty for admin requests whose usage is never suppressed.
+func (s *CEEMSServer) currentUsage(users []string, caller string, fields []string, w http.ResponseWriter, r *http.Request) {
 	var usage []models.Usage
 
 	var groupby []string
 
-	var targetTable string
+	var targetTable, unitsTable string
 
-	var q, timeQuery Query
+	var q, filter, timeQuery Query
 
 	queryParts := make([]string, len(fields))
 
@@ -1313,9 +2716,12 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 
 	var err, qErrs error
 
+	// Check if usage must be aggregated by group
+	groupAgg, _ := usageGroupAggregation(r.URL.Query())
+
 	// Round `to` and `from` query parameters to cacheTTL
 	if err := s.roundQueryWindow(r); err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
 
 		return
 	}
@@ -1327,8 +2733,32 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 	// Get query window time stamps
 	timeQuery, err = s.getQueryWindow(r, "last_updated_at", false, terminated)
 	if err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+		errorResponse(w, r, queryWindowError(err), s.logger)
+
+		return
+	}
+
+	// Usage is estimated from rollup tables when requested
+	unitsTable = s.unitsTable(r.Context(), time.Time{}, time.Time{})
+	if targetTable, _ = usageRollupTable(r.URL.Query()); targetTable == "" {
+		targetTable = unitsTable
+	}
+
+	// First select all projects that user is part of using subquery
+	filter = Query{}
+	filter.query(" WHERE project IN ")
+	filter.subQuery(projectsSubQuery(users)) // Get sub query for projects
+
+	// Add common query parameters
+	filter = s.getCommonQueryParams(&filter, r.URL.Query())
+	filter = s.getGroupQueryParams(&filter, r.URL.Query())
+
+	// Add time query as sub query to main query
+	filter.query(" AND ")
+	filter.subQuery(timeQuery)
 
+	// Respond with 304 when units in the query window have not been modified
+	if s.notModified(w, r, targetTable, filter) {
 		return
 	}
 
@@ -1358,7 +2788,7 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 			go func(i int, f string) {
 				defer wg.Done()
 
-				if query := s.aggQueryBuilder(r, f, timeQuery); query != "" {
+				if query := s.aggQueryBuilder(r, f, unitsTable, timeQuery); query != "" {
 					queryParts[i] = query
 				} else {
 					mu.Lock()
@@ -1366,6 +2796,8 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 					mu.Unlock()
 				}
 			}(iField, field)
+		} else if groupAgg && slices.Contains(groupAggUsageOmittedCols, field) {
+			queryParts[iField] = groupAggUsageQueries[field]
 		} else {
 			queryParts[iField] = aggUsageQueries[field]
 		}
@@ -1397,16 +2829,12 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 		}
 	}
 
-	if _, ok := r.URL.Query()["experimental"]; ok {
-		targetTable = base.DailyUsageDBTableName
-
+	if targetTable == base.DailyUsageDBTableName || targetTable == base.MonthlyUsageDBTableName {
 		for iQuery, query := range queries {
 			if strings.Contains(query, "COUNT") {
 				queries[iQuery] = "SUM(u.num_units) AS num_units"
 			}
 		}
-	} else {
-		targetTable = base.UnitsDBTableName
 	}
 
 	// Make query
@@ -1419,24 +2847,19 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 			strings.Join(virtualTables, " LEFT JOIN "),
 		),
 	)
+	q.append(filter)
 
-	// First select all projects that user is part of using subquery
-	q.query(" WHERE project IN ")
-	q.subQuery(projectsSubQuery(users)) // Get sub query for projects
-
-	// Add common query parameters
-	q = s.getCommonQueryParams(&q, r.URL.Query())
-
-	// Add time query as sub query to main query
-	q.query(" AND ")
-	q.subQuery(timeQuery)
-
-	// Finally add GROUP BY clause. Always group by username,project
-	groupby = []string{"username", "project"}
+	// Finally add GROUP BY clause. Always group by username,project unless
+	// usage is aggregated by group
+	if groupAgg {
+		groupby = []string{"cluster_id", "groupname"}
+	} else {
+		groupby = []string{"username", "project"}
 
-	for _, q := range r.URL.Query()["groupby"] {
-		if q != "" {
-			groupby = append(groupby, q)
+		for _, q := range r.URL.Query()["groupby"] {
+			if q != "" {
+				groupby = append(groupby, q)
+			}
 		}
 	}
 	// Remove duplicates values
@@ -1444,14 +2867,18 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 	groupby = slices.Compact(groupby)
 	q.query(" GROUP BY " + strings.Join(groupby, ","))
 
-	// Sort by cluster_id, username and project
-	q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	// Sort by cluster_id, username and project or by cluster_id and groupname
+	if groupAgg {
+		q.query(" ORDER BY cluster_id ASC, groupname ASC ")
+	} else {
+		q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	}
 
 	// Make query and check for returned number of rows
 	usage, err = s.queriers.usage(r.Context(), s.db, q, s.logger)
 	if usage == nil && err != nil {
 		s.logger.Error("Failed to fetch current usage statistics", "users", strings.Join(users, ","), "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -1462,6 +2889,24 @@ func (s *CEEMSServer) currentUsage(users []string, fields []string, w http.Respo
 	}
 
 writer:
+	// Suppress usage of small projects and groups for non-admin users
+	usage, numSuppressed, sErr := s.suppressUsage(r.Context(), caller, usage, groupAgg)
+	if sErr != nil {
+		s.logger.Error("Failed to suppress usage of small groups", "user", caller, "err", sErr)
+		errorResponse(w, r, &apiError{errorDB, sErr}, s.logger)
+
+		return
+	}
+
+	// Write CSV response when requested
+	if csvRequested(r) {
+		if err := writeCSV(w, usage, fields, "usage.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
+
+		return
+	}
+
 	// Write response
 	w.WriteHeader(http.StatusOK)
 
@@ -1477,6 +2922,10 @@ writer:
 		usageResponse.Warnings = append(usageResponse.Warnings, err.Error())
 	}
 
+	if numSuppressed > 0 {
+		usageResponse.Warnings = append(usageResponse.Warnings, s.privacy.suppressedWarning(numSuppressed))
+	}
+
 	if err = json.NewEncoder(w).Encode(&usageResponse); err != nil {
 		s.logger.Error("Failed to encode response", "err", err)
 		w.Write([]byte("KO"))
@@ -1484,30 +2933,73 @@ writer:
 }
 
 // GET /usage/global
-// Get global usage statistics.
-func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http.ResponseWriter, r *http.Request) {
+// Get global usage statistics. Caller is the non-admin user making the request
+// and it is empty for admin requests whose usage is never suppressed.
+func (s *CEEMSServer) globalUsage(users []string, caller string, queriedFields []string, w http.ResponseWriter, r *http.Request) {
 	// Get sub query for projects
 	qSub := projectsSubQuery(users)
 
-	// Make query
-	q := Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(queriedFields, ","), base.UsageDBTableName))
+	// Check if usage must be aggregated by group
+	groupAgg, _ := usageGroupAggregation(r.URL.Query())
+
+	selectFields := queriedFields
+	if groupAgg {
+		selectFields = make([]string, len(queriedFields))
+		for iField, field := range queriedFields {
+			selectFields[iField] = groupAggUsageQueries[field]
+		}
+	}
 
 	// First select all projects that user is part of using subquery
-	q.query(" WHERE project IN ")
-	q.subQuery(qSub)
+	filter := Query{}
+	filter.query(" WHERE project IN ")
+	filter.subQuery(qSub)
 
 	// Add common query parameters
-	q = s.getCommonQueryParams(&q, r.URL.Query())
+	filter = s.getCommonQueryParams(&filter, r.URL.Query())
+	filter = s.getGroupQueryParams(&filter, r.URL.Query())
 
-	// Sort by cluster_id, username and project
-	q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	// Respond with 304 when usage of projects has not been modified
+	if s.notModified(w, r, base.UsageDBTableName, filter) {
+		return
+	}
+
+	// Make query
+	q := Query{}
+	q.query(fmt.Sprintf("SELECT %s FROM %s", strings.Join(selectFields, ","), base.UsageDBTableName))
+	q.append(filter)
+
+	// Sort by cluster_id, username and project or aggregate by cluster_id
+	// and groupname
+	if groupAgg {
+		q.query(" GROUP BY cluster_id,groupname ORDER BY cluster_id ASC, groupname ASC ")
+	} else {
+		q.query(" ORDER BY cluster_id ASC, username ASC, project ASC ")
+	}
 
 	// Make query and check for returned number of rows
 	usage, err := s.queriers.usage(r.Context(), s.db, q, s.logger)
 	if usage == nil && err != nil {
 		s.logger.Error("Failed to fetch global usage statistics", "users", strings.Join(users, ","), "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	// Suppress usage of small projects and groups for non-admin users
+	usage, numSuppressed, sErr := s.suppressUsage(r.Context(), caller, usage, groupAgg)
+	if sErr != nil {
+		s.logger.Error("Failed to suppress usage of small groups", "user", caller, "err", sErr)
+		errorResponse(w, r, &apiError{errorDB, sErr}, s.logger)
+
+		return
+	}
+
+	// Write CSV response when requested
+	if csvRequested(r) {
+		if err := writeCSV(w, usage, queriedFields, "usage.csv"); err != nil {
+			s.logger.Error("Failed to encode CSV response", "err", err)
+		}
 
 		return
 	}
@@ -1523,6 +3015,10 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 		usageResponse.Warnings = append(usageResponse.Warnings, err.Error())
 	}
 
+	if numSuppressed > 0 {
+		usageResponse.Warnings = append(usageResponse.Warnings, s.privacy.suppressedWarning(numSuppressed))
+	}
+
 	if err = json.NewEncoder(w).Encode(&usageResponse); err != nil {
 		s.logger.Error("Failed to encode response", "err", err)
 		w.Write([]byte("KO"))
@@ -1547,6 +3043,23 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 //	@Description	The statistics can be limited to certain projects by passing `project` query,
 //	@Description	parameter.
 //	@Description
+//	@Description	By default, usage statistics are aggregated by user and project. For sites that
+//	@Description	organize allocations around Unix groups, statistics can be aggregated by group
+//	@Description	using `aggregate=group` query parameter. Statistics can be limited to certain
+//	@Description	groups by passing `group` query parameter.
+//	@Description
+//	@Description	For long query windows, `current` usage can be estimated from the usage that
+//	@Description	is aggregated per day or month by passing `rollup=daily` or `rollup=monthly`
+//	@Description	query parameter. In that case, query window is effectively rounded to the
+//	@Description	days or months of the rollups.
+//	@Description
+//	@Description	When an aggregation floor is configured, usage of other users in projects and
+//	@Description	usage of groups that have fewer distinct users than the floor are suppressed.
+//	@Description	Usage of the current user is always returned.
+//	@Description
+//	@Description	Query parameters `from` and `to` accept unix timestamps in seconds or milliseconds,
+//	@Description	RFC3339 formatted times and times relative to now like `now-24h`.
+//	@Description
 //	@Description	If `to` query parameter is not provided, current time will be used. If `from`
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
@@ -1570,16 +3083,22 @@ func (s *CEEMSServer) globalUsage(users []string, queriedFields []string, w http
 //	@Security		BasicAuth
 //	@Tags			usage
 //	@Produce		json
+//	@Produce		text/csv
 //	@Param			X-Grafana-User	header		string		true	"Current user name"
 //	@Param			mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
 //	@Param			cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
 //	@Param			project			query		[]string	false	"Project"												collectionFormat(multi)
+//	@Param			group			query		[]string	false	"Group"													collectionFormat(multi)
+//	@Param			aggregate		query		string		false	"Aggregate usage by user and project or by group"		Enums(user, group)
+//	@Param			rollup			query		string		false	"Estimate current usage from daily or monthly rollups"	Enums(daily, monthly)
 //	@Param			from			query		string		false	"From timestamp"
 //	@Param			to				query		string		false	"To timestamp"
 //	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			fields			query		string		false	"Comma separated list of fields to return in response"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
 //	@Success		200				{object}	Response[models.Usage]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
+//	@Failure		401				{object}	Problem
+//	@Failure		500				{object}	Problem
 //	@Router			/usage/{mode} [get]
 //
 // GET /usage/{mode}
@@ -1599,7 +3118,7 @@ func (s *CEEMSServer) usage(w http.ResponseWriter, r *http.Request) {
 
 	var exists bool
 	if mode, exists = mux.Vars(r)["mode"]; !exists {
-		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
 
 		return
 	}
@@ -1608,19 +3127,35 @@ func (s *CEEMSServer) usage(w http.ResponseWriter, r *http.Request) {
 	queriedFields := s.getQueriedFields(r.URL.Query(), base.UsageDBTableColNames)
 	if len(queriedFields) == 0 {
 		s.logger.Error("Invalid query fields", "loggedUser", dashboardUser)
-		errorResponse[any](w, &apiError{errorBadData, errInvalidQueryField}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidQueryField}, s.logger)
+
+		return
+	}
+
+	// Check aggregation query parameter
+	if _, err := usageGroupAggregation(r.URL.Query()); err != nil {
+		s.logger.Error("Invalid usage aggregation", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	// Check rollup query parameter
+	if _, err := usageRollupTable(r.URL.Query()); err != nil {
+		s.logger.Error("Invalid usage rollup", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
 
 		return
 	}
 
 	// handle current usage query
 	if mode == currentUsage {
-		s.currentUsage([]string{dashboardUser}, queriedFields, w, r)
+		s.currentUsage([]string{dashboardUser}, dashboardUser, queriedFields, w, r)
 	}
 
 	// handle global usage query
 	if mode == globalUsage {
-		s.globalUsage([]string{dashboardUser}, queriedFields, w, r)
+		s.globalUsage([]string{dashboardUser}, dashboardUser, queriedFields, w, r)
 	}
 }
 
@@ -1645,6 +3180,19 @@ func (s *CEEMSServer) usage(w http.ResponseWriter, r *http.Request) {
 //	@Description	The statistics can be limited to certain projects by passing `project` query,
 //	@Description	parameter.
 //	@Description
+//	@Description	By default, usage statistics are aggregated by user and project. For sites that
+//	@Description	organize allocations around Unix groups, statistics can be aggregated by group
+//	@Description	using `aggregate=group` query parameter. Statistics can be limited to certain
+//	@Description	groups by passing `group` query parameter.
+//	@Description
+//	@Description	For long query windows, `current` usage can be estimated from the usage that
+//	@Description	is aggregated per day or month by passing `rollup=daily` or `rollup=monthly`
+//	@Description	query parameter. In that case, query window is effectively rounded to the
+//	@Description	days or months of the rollups.
+//	@Description
+//	@Description	Query parameters `from` and `to` accept unix timestamps in seconds or milliseconds,
+//	@Description	RFC3339 formatted times and times relative to now like `now-24h`.
+//	@Description
 //	@Description	If `to` query parameter is not provided, current time will be used. If `from`
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
@@ -1668,18 +3216,24 @@ func (s *CEEMSServer) usage(w http.ResponseWriter, r *http.Request) {
 //	@Security		BasicAuth
 //	@Tags			usage
 //	@Produce		json
+//	@Produce		text/csv
 //	@Param			X-Grafana-User	header		string		true	"Current user name"
 //	@Param			mode			path		string		true	"Whether to get usage stats within a period or global"	Enums(current, global)
 //	@Param			cluster_id		query		[]string	false	"cluster ID"											collectionFormat(multi)
 //	@Param			project			query		[]string	false	"Project"
+//	@Param			group			query		[]string	false	"Group"	collectionFormat(multi)
+//	@Param			aggregate		query		string		false	"Aggregate usage by user and project or by group"	Enums(user, group)
+//	@Param			rollup			query		string		false	"Estimate current usage from daily or monthly rollups"	Enums(daily, monthly)
 //	@Param			user			query		[]string	false	"Username"	collectionFormat(multi)
 //	@Param			from			query		string		false	"From timestamp"
 //	@Param			to				query		string		false	"To timestamp"
 //	@Param			field			query		[]string	false	"Fields to return in response"	collectionFormat(multi)
+//	@Param			fields			query		string		false	"Comma separated list of fields to return in response"
+//	@Param			format			query		string		false	"Response format. Use csv for CSV response"	Enums(json, csv)
 //	@Success		200				{object}	Response[models.Usage]
-//	@Failure		401				{object}	Response[any]
-//	@Failure		403				{object}	Response[any]
-//	@Failure		500				{object}	Response[any]
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
 //	@Router			/usage/{mode}/admin [get]
 //
 // GET /usage/{mode}/admin
@@ -1699,7 +3253,7 @@ func (s *CEEMSServer) usageAdmin(w http.ResponseWriter, r *http.Request) {
 
 	var exists bool
 	if mode, exists = mux.Vars(r)["mode"]; !exists {
-		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
 
 		return
 	}
@@ -1708,19 +3262,35 @@ func (s *CEEMSServer) usageAdmin(w http.ResponseWriter, r *http.Request) {
 	queriedFields := s.getQueriedFields(r.URL.Query(), base.UsageDBTableColNames)
 	if len(queriedFields) == 0 {
 		s.logger.Error("Invalid query fields", "loggedUser", dashboardUser)
-		errorResponse[any](w, &apiError{errorBadData, errInvalidQueryField}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidQueryField}, s.logger)
+
+		return
+	}
+
+	// Check aggregation query parameter
+	if _, err := usageGroupAggregation(r.URL.Query()); err != nil {
+		s.logger.Error("Invalid usage aggregation", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	// Check rollup query parameter
+	if _, err := usageRollupTable(r.URL.Query()); err != nil {
+		s.logger.Error("Invalid usage rollup", "loggedUser", dashboardUser, "err", err)
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
 
 		return
 	}
 
 	// handle current usage query
 	if mode == currentUsage {
-		s.currentUsage(r.URL.Query()["user"], queriedFields, w, r)
+		s.currentUsage(r.URL.Query()["user"], "", queriedFields, w, r)
 	}
 
 	// handle global usage query
 	if mode == globalUsage {
-		s.globalUsage(r.URL.Query()["user"], queriedFields, w, r)
+		s.globalUsage(r.URL.Query()["user"], "", queriedFields, w, r)
 	}
 }
 
@@ -1740,16 +3310,17 @@ func (s *CEEMSServer) currentStats(users []string, w http.ResponseWriter, r *htt
 
 	// Make query
 	q = Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, base.UnitsDBTableName))
-
 	// Get query window time stamps
 	timeQuery, err = s.getQueryWindow(r, "ended_at", true, false)
 	if err != nil {
-		errorResponse[any](w, &apiError{errorBadData, err}, s.logger, nil)
+		errorResponse(w, r, queryWindowError(err), s.logger)
 
 		return
 	}
 
+	from, to, _ := s.getQueryWindowTimes(r)
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, s.unitsTable(r.Context(), from, to)))
+
 	// Add time sub query to main query
 	q.query(" AND ")
 	q.subQuery(timeQuery)
@@ -1770,7 +3341,7 @@ func (s *CEEMSServer) currentStats(users []string, w http.ResponseWriter, r *htt
 	stats, err = s.queriers.stat(r.Context(), s.db, q, s.logger)
 	if stats == nil && err != nil {
 		s.logger.Error("Failed to fetch current quick stats", "users", strings.Join(users, ","), "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -1806,7 +3377,7 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 
 	// Make query
 	q = Query{}
-	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, base.UnitsDBTableName))
+	q.query(fmt.Sprintf("SELECT %s FROM %s WHERE 1=1", statsQuery, s.unitsTable(r.Context(), time.Time{}, time.Time{})))
 
 	// Get cluster_id query parameters if any
 	if clusterIDs := r.URL.Query()["cluster_id"]; len(clusterIDs) > 0 {
@@ -1824,7 +3395,7 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 	stats, err = s.queriers.stat(r.Context(), s.db, q, s.logger)
 	if stats == nil && err != nil {
 		s.logger.Error("Failed to fetch global quick stats", "users", strings.Join(users, ","), "err", err)
-		errorResponse[any](w, &apiError{errorInternal, err}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
 
 		return
 	}
@@ -1866,6 +3437,9 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 //	@Description
 //	@Description	The statistics include current number of active users, projects, jobs, _etc_.
 //	@Description
+//	@Description	Query parameters `from` and `to` accept unix timestamps in seconds or milliseconds,
+//	@Description	RFC3339 formatted times and times relative to now like `now-24h`.
+//	@Description
 //	@Description	If `to` query parameter is not provided, current time will be used. If `from`
 //	@Description	query parameter is not used, a default query window of 24 hours will be used.
 //	@Description	It means if `to` is provided, `from` will be calculated as `to` - 24hrs.
@@ -1879,9 +3453,9 @@ func (s *CEEMSServer) globalStats(users []string, w http.ResponseWriter, r *http
 //	@Param		from			query		string		false	"From timestamp"
 //	@Param		to				query		string		false	"To timestamp"
 //	@Success	200				{object}	Response[models.Stat]
-//	@Failure	401				{object}	Response[any]
-//	@Failure	403				{object}	Response[any]
-//	@Failure	500				{object}	Response[any]
+//	@Failure	401				{object}	Problem
+//	@Failure	403				{object}	Problem
+//	@Failure	500				{object}	Problem
 //	@Router		/stats/{mode}/admin [get]
 //
 // GET /stats/{mode}/admin
@@ -1898,7 +3472,7 @@ func (s *CEEMSServer) statsAdmin(w http.ResponseWriter, r *http.Request) {
 
 	var exists bool
 	if mode, exists = mux.Vars(r)["mode"]; !exists {
-		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
 
 		return
 	}
@@ -1933,7 +3507,7 @@ func (s *CEEMSServer) statsAdmin(w http.ResponseWriter, r *http.Request) {
 //	@Param			resource	path		string	true	"Whether to return mock units or usage data"	Enums(units, usage)
 //	@Success		200			{object}	Response[models.Unit]
 //	@Success		200			{object}	Response[models.Usage]
-//	@Failure		500			{object}	Response[any]
+//	@Failure		500			{object}	Problem
 //	@Router			/demo/{resource} [get]
 //
 // GET /demo/{units,usage}
@@ -1950,7 +3524,7 @@ func (s *CEEMSServer) demo(w http.ResponseWriter, r *http.Request) {
 
 	var exists bool
 	if resourceType, exists = mux.Vars(r)["resource"]; !exists {
-		errorResponse[any](w, &apiError{errorBadData, errInvalidRequest}, s.logger, nil)
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidRequest}, s.logger)
 
 		return
 	}
@@ -15,14 +15,20 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -64,6 +70,9 @@ var (
 	mockKeys = []models.Key{
 		{Name: "global"},
 	}
+	mockNodes = []models.Node{
+		{ClusterID: "slurm-0", ResourceManager: "slurm", Hostname: "compute-0", PeriodStart: "2024-01-01T00:00:00+0000"},
+	}
 	errTest = errors.New("failed to query 10 rows")
 )
 
@@ -93,6 +102,9 @@ func setupServer(d string) *CEEMSServer {
 		cluster: clusterQuerier,
 		stat:    statQuerier,
 		key:     keyQuerier,
+		node:    nodeQuerier,
+
+		unitStream: unitStreamQuerier,
 	}
 
 	return server
@@ -102,6 +114,16 @@ func unitQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger)
 	return mockServerUnits, nil
 }
 
+func unitStreamQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger, fn func(models.Unit) error) error {
+	for _, unit := range mockServerUnits {
+		if err := fn(unit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func usageQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Usage, error) {
 	return mockServerUsage, nil
 }
@@ -126,6 +148,10 @@ func keyQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) (
 	return mockKeys, nil
 }
 
+func nodeQuerier(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Node, error) {
+	return mockNodes, nil
+}
+
 func keyQuerierErr(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Key, error) {
 	return nil, errors.New("failed query")
 }
@@ -334,6 +360,26 @@ func TestUnitsHandler(t *testing.T) {
 }
 
 // Test usage and usage admin handlers.
+func TestUsageRollupTable(t *testing.T) {
+	for query, expected := range map[string]string{
+		"":                            "",
+		"rollup=daily":                base.DailyUsageDBTableName,
+		"rollup=monthly":              base.MonthlyUsageDBTableName,
+		"experimental":                base.DailyUsageDBTableName,
+		"experimental&rollup=monthly": base.MonthlyUsageDBTableName,
+	} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+
+		table, err := usageRollupTable(values)
+		require.NoError(t, err, query)
+		assert.Equal(t, expected, table, query)
+	}
+
+	_, err := usageRollupTable(url.Values{"rollup": []string{"weekly"}})
+	require.ErrorIs(t, err, errInvalidRollup)
+}
+
 func TestUsageHandlers(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -517,6 +563,56 @@ func TestUsageErrorHandlers(t *testing.T) {
 	}
 }
 
+func TestUsageHandlersWithGroupAggregation(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	server.queriers.usage = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Usage, error) {
+		query, params = q.get()
+
+		return mockServerUsage, nil
+	}
+
+	for _, mode := range []string{"current", "global"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/usage/"+mode, nil)
+		req.Header.Set("X-Grafana-User", "foousr")
+
+		q := req.URL.Query()
+		q.Add("aggregate", "group")
+		q.Add("group", "grp1")
+		q.Add("field", "username")
+		q.Add("field", "groupname")
+		q.Add("field", "num_units")
+		req.URL.RawQuery = q.Encode()
+		req = mux.SetURLVars(req, map[string]string{"mode": mode})
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		server.usage(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, mode)
+		assert.Contains(t, query, "'' AS username", mode)
+		assert.Contains(t, query, " AND groupname IN (?)", mode)
+		assert.Contains(t, query, " GROUP BY cluster_id,groupname", mode)
+		assert.Contains(t, params, "grp1", mode)
+	}
+
+	// Invalid aggregation
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/usage/global?aggregate=foo", nil)
+	req.Header.Set("X-Grafana-User", "foousr")
+	req = mux.SetURLVars(req, map[string]string{"mode": "global"})
+
+	w := httptest.NewRecorder()
+	server.usage(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 // Test stats admin handlers.
 func TestStatsHandlers(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -636,6 +732,58 @@ func TestVerifyHandler(t *testing.T) {
 	}
 }
 
+// Test batch verify handler.
+func TestVerifyBatchHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	db, err := setupMockDB(t.TempDir())
+	require.NoError(t, err)
+
+	server.db = db
+
+	tests := []struct {
+		name string
+		body string
+		code int
+	}{
+		{
+			name: "malformed body",
+			body: "uuids",
+			code: 400,
+		},
+		{
+			name: "missing uuids",
+			body: `{"cluster_ids": ["rm-0"]}`,
+			code: 400,
+		},
+		{
+			name: "ownership of units",
+			body: `{"uuids": ["1479763", "1479765"], "cluster_ids": ["rm-0"]}`,
+			code: 200,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodPost, "/api/"+base.APIVersion+"/units/verify", strings.NewReader(test.body))
+		request.Header.Set(dashboardUserHeader, "usr1")
+
+		w := httptest.NewRecorder()
+		server.verifyUnitsOwnershipBatch(w, request)
+
+		require.Equal(t, test.code, w.Code, test.name)
+
+		if test.code != 200 {
+			continue
+		}
+
+		var response Response[UnitOwnership]
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, []UnitOwnership{{UUID: "1479763", Owned: true}, {UUID: "1479765", Owned: false}}, response.Data)
+		assert.Equal(t, "private, max-age=300", w.Header().Get("Cache-Control"))
+	}
+}
+
 // Test demo handlers.
 func TestDemoHandlers(t *testing.T) {
 	tmpDir := t.TempDir()
@@ -769,13 +917,53 @@ func TestUnitsHandlerWithMalformedQueryParams(t *testing.T) {
 	require.NoError(t, err)
 
 	// Unmarshal byte into structs.
-	var response Response[any]
+	var problem Problem
 
-	json.Unmarshal(data, &response)
+	json.Unmarshal(data, &problem)
 
-	assert.Equal(t, "error", response.Status)
-	assert.Equal(t, errorType("bad_data"), response.ErrorType)
-	assert.Empty(t, response.Data)
+	assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, errorType("bad_time_range"), problem.Code)
+	assert.Equal(t, "/api/v1/units", problem.Instance)
+}
+
+// Test /units when from query parameter is after to.
+func TestUnitsHandlerWithInvalidTimeRange(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	f, err := os.Create(filepath.Join(tmpDir, base.CEEMSDBName))
+	if err != nil {
+		require.NoError(t, err)
+	}
+
+	defer f.Close()
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	// Add user header
+	req.Header.Set("X-Grafana-User", "foo")
+	// Add from query parameter after to
+	q := req.URL.Query()
+	q.Add("from", "1685570400")
+	q.Add("to", "1685566800")
+	req.URL.RawQuery = q.Encode()
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.units(w, req)
+
+	res := w.Result()
+	defer res.Body.Close()
+
+	var problem Problem
+
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&problem))
+
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, errorType("bad_time_range"), problem.Code)
+	assert.Equal(t, "from must not be after to", problem.Detail)
 }
 
 // Test /units when from/to query parameters exceed max time window.
@@ -813,13 +1001,59 @@ func TestUnitsHandlerWithQueryWindowExceeded(t *testing.T) {
 	require.NoError(t, err)
 
 	// Unmarshal byte into structs.
-	var response Response[any]
+	var problem Problem
 
-	json.Unmarshal(data, &response)
+	json.Unmarshal(data, &problem)
 
-	assert.Equal(t, "error", response.Status)
-	assert.Equal(t, "maximum query window exceeded", response.Error)
-	assert.Empty(t, response.Data)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, errorType("window_exceeded"), problem.Code)
+	assert.Equal(t, "maximum query window exceeded", problem.Detail)
+}
+
+func TestGetQueryWindowTimesAdminLimit(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	tests := []struct {
+		name        string
+		adminPeriod time.Duration
+		admin       bool
+		err         error
+	}{
+		{
+			name:        "user exceeding max query window",
+			adminPeriod: 24 * time.Hour * 365,
+			err:         ErrMaxQueryWindow,
+		},
+		{
+			name:        "admin within admin max query window",
+			adminPeriod: 24 * time.Hour * 365,
+			admin:       true,
+		},
+		{
+			name:        "admin exceeding admin max query window",
+			adminPeriod: 24 * time.Hour * 30,
+			admin:       true,
+			err:         ErrMaxQueryWindow,
+		},
+		{
+			name:  "admin with unlimited query window",
+			admin: true,
+		},
+	}
+
+	for _, test := range tests {
+		server.adminMaxQueryPeriod = test.adminPeriod
+
+		// Query window of 5 months
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units?from=1672527600&to=1685570400", nil)
+		if test.admin {
+			req.Header.Set(adminUserHeader, "adm1")
+		}
+
+		_, _, err := server.getQueryWindowTimes(req)
+		require.ErrorIs(t, err, test.err, test.name)
+	}
 }
 
 // Test /units when from/to query parameters exceed max time window but when unit uuids
@@ -912,3 +1146,1265 @@ func TestUnitsHandlerWithUnituuidsQueryParams(t *testing.T) {
 // 		t.Errorf("expected usage %#v usage, got %#v", expectedUsage, response.Data)
 // 	}
 // }
+
+func TestParseTimeParam(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		input  string
+		result time.Time
+		fail   bool
+	}{
+		{
+			input:  "1700000000",
+			result: now,
+		},
+		{
+			input:  "1700000000000",
+			result: now,
+		},
+		{
+			input:  "1700000000.5",
+			result: now.Add(500 * time.Millisecond),
+		},
+		{
+			input:  "2023-11-14T22:13:20Z",
+			result: now,
+		},
+		{
+			input:  "now",
+			result: now,
+		},
+		{
+			input:  "now-24h",
+			result: now.Add(-24 * time.Hour),
+		},
+		{
+			input:  "now-1w",
+			result: now.Add(-7 * 24 * time.Hour),
+		},
+		{
+			input:  "now+1h",
+			result: now.Add(time.Hour),
+		},
+		{
+			input: "now-foo",
+			fail:  true,
+		},
+		{
+			input: "10-12-2023",
+			fail:  true,
+		},
+	}
+
+	for _, test := range tests {
+		ts, err := parseTimeParam(test.input, now)
+		if test.fail {
+			require.Error(t, err, test.input)
+
+			continue
+		}
+
+		require.NoError(t, err, test.input)
+		assert.True(t, test.result.Equal(ts), "%s: expected %s, got %s", test.input, test.result, ts)
+	}
+}
+
+func TestGetSortQuery(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	tests := []struct {
+		name   string
+		params url.Values
+		query  string
+		fail   bool
+	}{
+		{
+			name:   "default order",
+			params: url.Values{},
+			query:  " ORDER BY cluster_id ASC, uuid ASC ",
+		},
+		{
+			name:   "sort by column desc",
+			params: url.Values{"sort_by": []string{"started_at_ts"}, "order": []string{"desc"}},
+			query:  " ORDER BY started_at_ts DESC, cluster_id ASC, uuid ASC ",
+		},
+		{
+			name:   "sort by json key with limit",
+			params: url.Values{"sort_by": []string{"total_time_seconds.walltime"}, "order": []string{"DESC"}, "limit": []string{"10"}},
+			query:  " ORDER BY json_extract(total_time_seconds, '$.walltime') DESC, cluster_id ASC, uuid ASC LIMIT 10 ",
+		},
+		{
+			name:   "unknown column",
+			params: url.Values{"sort_by": []string{"started_at_ts; DROP TABLE units"}},
+			fail:   true,
+		},
+		{
+			name:   "invalid json key",
+			params: url.Values{"sort_by": []string{"allocation.cpus')"}},
+			fail:   true,
+		},
+		{
+			name:   "json key on non json column",
+			params: url.Values{"sort_by": []string{"uuid.foo"}},
+			fail:   true,
+		},
+		{
+			name:   "invalid order",
+			params: url.Values{"sort_by": []string{"uuid"}, "order": []string{"up"}},
+			fail:   true,
+		},
+		{
+			name:   "invalid limit",
+			params: url.Values{"limit": []string{"-1"}},
+			fail:   true,
+		},
+	}
+
+	for _, test := range tests {
+		query, err := server.getSortQuery(test.params, base.UnitsDBTableColNames, unitsJSONColNames)
+		if test.fail {
+			require.Error(t, err, test.name)
+
+			continue
+		}
+
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.query, query, test.name)
+	}
+}
+
+func TestGetQueriedFields(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	validFields := []string{"uuid", "state", "elapsed", "project"}
+
+	tests := []struct {
+		name   string
+		params url.Values
+		fields []string
+	}{
+		{
+			name:   "no fields",
+			params: url.Values{},
+			fields: validFields,
+		},
+		{
+			name:   "repeated field params",
+			params: url.Values{"field": []string{"uuid", "state"}},
+			fields: []string{"uuid", "state"},
+		},
+		{
+			name:   "comma separated fields",
+			params: url.Values{"fields": []string{"uuid, elapsed,foo"}},
+			fields: []string{"uuid", "elapsed"},
+		},
+		{
+			name:   "mixed and duplicated fields",
+			params: url.Values{"field": []string{"state"}, "fields": []string{"state,project"}},
+			fields: []string{"state", "project"},
+		},
+		{
+			name:   "only invalid fields",
+			params: url.Values{"fields": []string{"foo,bar"}},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.fields, server.getQueriedFields(test.params, validFields), test.name)
+	}
+}
+
+func TestUnitsHandlerWithStateAndExitCodeQueryParams(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+		query, params = q.get()
+
+		return mockServerUnits, nil
+	}
+
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.Header.Set("X-Grafana-User", "foousr")
+	req.Header.Set("X-Dashboard-User", "foousr")
+
+	q := req.URL.Query()
+	q.Add("state", "FAILED")
+	q.Add("state", "TIMEOUT")
+	q.Add("exit_code", "1:0")
+	q.Add("exitcode", "2:0")
+	req.URL.RawQuery = q.Encode()
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.units(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, query, ` AND (state IN (?,?) OR state LIKE (?) ESCAPE '\' OR state LIKE (?) ESCAPE '\')`)
+	assert.Contains(t, query, " AND json_extract(tags, '$.exit_code') IN (?,?)")
+	assert.Subset(t, params, []string{"FAILED", "TIMEOUT", "FAILED %", "TIMEOUT %", "1:0", "2:0"})
+}
+
+func TestUnitsHandlerWithStateAndExitCodeQueryParamsWithDB(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add units with different states and exit codes
+	for _, unit := range []struct {
+		uuid, state, exitCode string
+	}{
+		{"1", "CANCELLED by 1000", "0:15"},
+		{"2", "CANCELLED", "0:15"},
+		{"3", "FAILED", "1:0"},
+		{"4", "COMPLETED", "0:0"},
+		{"5", "CANCELLEDX", "0:0"},
+	} {
+		_, err = dbConn.Exec(
+			"INSERT INTO units (resource_manager, cluster_id, uuid, username, project, state, tags, ignore, created_at, created_at_ts, "+
+				"started_at, ended_at, ended_at_ts, last_updated_at) VALUES "+
+				"('slurm', 'slurm-0', ?, 'usr1', 'prj1', ?, json_object('exit_code', ?), 0, '2024-01-10T10:00:00+0000', 1704880800000, "+
+				"'2024-01-10T10:00:00+0000', '2024-01-10T12:00:00+0000', 1704888000000, '2024-01-10T12:00:00+0000')",
+			unit.uuid, unit.state, unit.exitCode,
+		)
+		require.NoError(t, err)
+	}
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.unit = Querier[models.Unit]
+
+	tests := []struct {
+		name  string
+		query url.Values
+		uuids []string
+	}{
+		{
+			name:  "state matched on prefix",
+			query: url.Values{"state": []string{"CANCELLED"}},
+			uuids: []string{"1", "2"},
+		},
+		{
+			name:  "multiple states",
+			query: url.Values{"state": []string{"CANCELLED", "FAILED"}},
+			uuids: []string{"1", "2", "3"},
+		},
+		{
+			name:  "exit_code",
+			query: url.Values{"exit_code": []string{"1:0"}},
+			uuids: []string{"3"},
+		},
+		{
+			name:  "exitcode",
+			query: url.Values{"exitcode": []string{"0:15", "0:0"}, "state": []string{"CANCELLED"}},
+			uuids: []string{"1", "2"},
+		},
+	}
+
+	for _, test := range tests {
+		test.query.Set("from", "1704873600")
+		test.query.Set("to", "1704960000")
+
+		req := httptest.NewRequest(http.MethodGet, "/api/"+base.APIVersion+"/units/admin?"+test.query.Encode(), nil)
+		req.Header.Set("X-Grafana-User", "adm1")
+
+		w := httptest.NewRecorder()
+		server.unitsAdmin(w, req)
+
+		var units Response[models.Unit]
+
+		require.Equal(t, http.StatusOK, w.Code, test.name)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&units), test.name)
+
+		uuids := make([]string, 0, len(units.Data))
+		for _, unit := range units.Data {
+			uuids = append(uuids, unit.UUID)
+		}
+
+		assert.ElementsMatch(t, test.uuids, uuids, test.name)
+	}
+}
+
+func TestUnitsHandlerWithPartitionQoSAndNodeQueryParams(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+		query, params = q.get()
+
+		return mockServerUnits, nil
+	}
+
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units/admin", nil)
+	req.Header.Set("X-Grafana-User", "adm1")
+
+	q := req.URL.Query()
+	q.Add("partition", "gpu")
+	q.Add("qos", "normal")
+	q.Add("node", "compute-0")
+	q.Add("node", "compute-1")
+	q.Add("search", "train_50%")
+	req.URL.RawQuery = q.Encode()
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.unitsAdmin(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, query, " AND json_extract(tags, '$.partition') IN (?)")
+	assert.Contains(t, query, " AND json_extract(tags, '$.qos') IN (?)")
+	assert.Contains(t, query, " AND id IN (SELECT unit_id FROM unit_nodes WHERE node IN (?,?))")
+	assert.Contains(t, query, ` AND (name LIKE (?) ESCAPE '\' OR json_extract(tags, '$.workdir') LIKE (?) ESCAPE '\')`)
+	assert.Subset(t, params, []string{"gpu", "normal", "compute-0", "compute-1", `%train\_50\%%`})
+}
+
+func TestNodeEnergyHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	server.queriers.node = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Node, error) {
+		query, params = q.get()
+
+		return mockNodes, nil
+	}
+
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/nodes/compute-0/energy", nil)
+	req.Header.Set("X-Grafana-User", "foousr")
+	req = mux.SetURLVars(req, map[string]string{"hostname": "compute-0"})
+
+	q := req.URL.Query()
+	q.Add("cluster_id", "slurm-0")
+	req.URL.RawQuery = q.Encode()
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.nodeEnergy(w, req)
+	res := w.Result()
+	defer res.Body.Close()
+
+	// Unmarshal byte into structs
+	var response Response[models.Node]
+	json.NewDecoder(res.Body).Decode(&response)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "success", response.Status)
+	assert.Equal(t, mockNodes, response.Data)
+	assert.Contains(t, query, "SELECT * FROM nodes WHERE hostname = (?) AND cluster_id IN (?) AND (period_start BETWEEN (?) AND (?))")
+	assert.Subset(t, params, []string{"compute-0", "slurm-0"})
+}
+
+func TestNodeReportHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture queries sent to queriers
+	var unitQuery, preemptQuery string
+
+	var unitParams []string
+
+	units := []models.Unit{
+		{ClusterID: "slurm-0", UUID: "1000", User: "foousr", State: "COMPLETED", EndedAt: "2024-10-01T11:00:00+0200"},
+		{ClusterID: "slurm-0", UUID: "1001", User: "foousr", State: "NODE_FAIL", EndedAt: "2024-10-01T12:00:00+0200"},
+	}
+	series := map[string]map[string][]models.Sample{
+		"power": {"ipmi": {{Timestamp: 2000, Value: 250}}},
+	}
+
+	server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+		unitQuery, unitParams = q.get()
+
+		return units, nil
+	}
+	server.queriers.node = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Node, error) {
+		return mockNodes, nil
+	}
+	server.queriers.preempt = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Preemption, error) {
+		preemptQuery, _ = q.get()
+
+		return []models.Preemption{
+			{ClusterID: "slurm-0", UUID: "1000", PreemptedAt: "2024-10-01T10:00:00+0200", Elapsed: "01:00:00"},
+		}, nil
+	}
+	server.queriers.annot = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Annotation, error) {
+		return []models.Annotation{
+			{ClusterID: "slurm-0", UUID: "1001", Author: "foousr", Note: "node crashed", CreatedAt: "2024-10-01T13:00:00+0200"},
+		}, nil
+	}
+
+	makeRequest := func(path string, handler http.HandlerFunc, query string) (int, Response[models.NodeReport]) {
+		req := httptest.NewRequest(http.MethodGet, path+"?"+query, nil)
+		req.Header.Set(loggedUserHeader, "foousr")
+		req = mux.SetURLVars(req, map[string]string{"hostname": "compute-0"})
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		var response Response[models.NodeReport]
+		json.NewDecoder(w.Result().Body).Decode(&response)
+
+		return w.Code, response
+	}
+
+	// Without time series of nodes, report is returned with warning
+	code, response := makeRequest("/api/v1/nodes/compute-0/report", server.nodeReport, "cluster_id=slurm-0")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, []string{errNodeSeriesUnavailable.Error()}, response.Warnings)
+	assert.Contains(t, unitQuery, "AND id IN (SELECT unit_id FROM unit_nodes WHERE node = (?))")
+	assert.Contains(t, unitQuery, "AND username IN (?)")
+	assert.Subset(t, unitParams, []string{"slurm-0", "compute-0", "foousr"})
+	assert.Contains(t, preemptQuery, "SELECT * FROM preemptions WHERE cluster_id = (?) AND uuid IN (?,?)")
+
+	report := response.Data[0]
+	assert.Equal(t, "compute-0", report.Hostname)
+	assert.Equal(t, units, report.Units)
+	assert.Equal(t, mockNodes, report.Stats)
+	assert.Equal(t, []models.NodeAnomaly{
+		{Kind: preemptionAnomaly, ClusterID: "slurm-0", UUID: "1000", Time: "2024-10-01T10:00:00+0200", Message: "preempted after 01:00:00"},
+		{Kind: unitFailureAnomaly, ClusterID: "slurm-0", UUID: "1001", Time: "2024-10-01T12:00:00+0200", Message: "NODE_FAIL"},
+		{Kind: annotationAnomaly, ClusterID: "slurm-0", UUID: "1001", Time: "2024-10-01T13:00:00+0200", Message: "node crashed", Author: "foousr"},
+	}, report.Anomalies)
+
+	// Admin report includes units of all users and time series
+	server.nodeSeries = func(ctx context.Context, clusterID, hostname string, start, end time.Time) (map[string]map[string][]models.Sample, error) {
+		return series, nil
+	}
+
+	code, response = makeRequest("/api/v1/nodes/compute-0/report/admin", server.nodeReportAdmin, "cluster_id=slurm-0")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, response.Data, 1)
+	assert.Empty(t, response.Warnings)
+	assert.NotContains(t, unitQuery, "AND username IN")
+	assert.Equal(t, series, response.Data[0].Series)
+
+	// Cluster ID is mandatory
+	code, _ = makeRequest("/api/v1/nodes/compute-0/report", server.nodeReport, "")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAnnotationsHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add admin user
+	_, err = dbConn.Exec(`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','')`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.annot = Querier[models.Annotation]
+
+	// Test cases
+	tests := []struct {
+		name    string
+		user    string
+		method  string
+		query   string
+		body    string
+		handler func(http.ResponseWriter, *http.Request)
+		code    int
+	}{
+		{
+			name:    "add annotation by admin",
+			user:    "adm1",
+			method:  http.MethodPost,
+			query:   "cluster_id=slurm-0",
+			body:    `{"note": "crashed due to quota"}`,
+			handler: server.addAnnotation,
+			code:    http.StatusCreated,
+		},
+		{
+			name:    "add annotation by non owner",
+			user:    "foousr",
+			method:  http.MethodPost,
+			query:   "cluster_id=slurm-0",
+			body:    `{"note": "crashed due to quota"}`,
+			handler: server.addAnnotation,
+			code:    http.StatusForbidden,
+		},
+		{
+			name:    "add empty annotation",
+			user:    "adm1",
+			method:  http.MethodPost,
+			query:   "cluster_id=slurm-0",
+			body:    `{"note": "  "}`,
+			handler: server.addAnnotation,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "get annotations without cluster_id",
+			user:    "adm1",
+			method:  http.MethodGet,
+			handler: server.annotations,
+			code:    http.StatusBadRequest,
+		},
+		{
+			name:    "get annotations",
+			user:    "adm1",
+			method:  http.MethodGet,
+			query:   "cluster_id=slurm-0",
+			handler: server.annotations,
+			code:    http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(test.method, "/api/v1/units/1000/annotations?"+test.query, strings.NewReader(test.body))
+		request.Header.Set(loggedUserHeader, test.user)
+		request = mux.SetURLVars(request, map[string]string{"uuid": "1000"})
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, request)
+
+		assert.Equal(t, test.code, w.Code, test.name)
+
+		if test.code != http.StatusOK {
+			continue
+		}
+
+		var response Response[models.Annotation]
+
+		require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&response))
+		require.Len(t, response.Data, 1, test.name)
+		assert.Equal(t, "crashed due to quota", response.Data[0].Note)
+		assert.Equal(t, "adm1", response.Data[0].Author)
+		assert.Equal(t, "1000", response.Data[0].UUID)
+	}
+}
+
+type mockGroupMembership []string
+
+func (m mockGroupMembership) IsMember(_ context.Context, user string) bool {
+	return slices.Contains(m, user)
+}
+
+func TestUnitAccessByAdminSources(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add unit of usr1 and admin user in DB
+	for _, stmt := range []string{
+		`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','')`,
+		`INSERT INTO projects (cluster_id,name,users) VALUES ('slurm-0','prj1','["usr1"]')`,
+		`INSERT INTO units (id,cluster_id,uuid,project,username,started_at_ts) VALUES (1,'slurm-0','1000','prj1','usr1',1000)`,
+	} {
+		_, err = dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.annot = Querier[models.Annotation]
+
+	// Mock OIDC provider with admins group
+	var oidcServer *httptest.Server
+
+	oidcServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			w.Write([]byte(`{"userinfo_endpoint": "` + oidcServer.URL + `/userinfo"}`))
+		case "/userinfo":
+			w.Write([]byte(`{"preferred_username": "oidcadm", "groups": ["admins"]}`))
+		}
+	}))
+	defer oidcServer.Close()
+
+	oidcAuth, err := middleware.NewOIDCAuthenticator(
+		middleware.OIDCConfig{IssuerURL: oidcServer.URL, AdminGroups: []string{"admins"}},
+	)
+	require.NoError(t, err)
+
+	// Admin users from DB and LDAP group are identified by Grafana user header,
+	// OIDC admins by their access token and service accounts by their API key
+	newMiddleware := func(amw authenticationMiddleware) authenticationMiddleware {
+		amw.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+		amw.whitelistedURLs = regexp.MustCompile("/api/v1/(swagger|debug|health|demo)(.*)")
+		amw.adminUsers = adminUsers
+		amw.db = dbConn
+
+		return amw
+	}
+
+	ldapAMW := newMiddleware(authenticationMiddleware{ldap: mockGroupMembership{"ldapadm"}})
+	oidcAMW := newMiddleware(authenticationMiddleware{oidc: oidcAuth})
+	apiKeyAMW := newMiddleware(authenticationMiddleware{
+		apiKey: func(_ context.Context, _ *sql.DB, _ string, _ *slog.Logger) (*models.APIKey, error) {
+			return &models.APIKey{User: "svcadm", Role: apiKeyAdminRole}, nil
+		},
+	})
+
+	tests := []struct {
+		name   string
+		amw    authenticationMiddleware
+		header string
+		value  string
+		code   int
+	}{
+		{name: "admin in DB", amw: ldapAMW, header: grafanaUserHeader, value: "adm1", code: http.StatusOK},
+		{name: "admin in LDAP group", amw: ldapAMW, header: grafanaUserHeader, value: "ldapadm", code: http.StatusOK},
+		{name: "admin in OIDC admin group", amw: oidcAMW, header: authorizationHeader, value: "Bearer token", code: http.StatusOK},
+		{name: "service account with admin API key", amw: apiKeyAMW, header: apiKeyHeader, value: "key", code: http.StatusOK},
+		{name: "non owner", amw: ldapAMW, header: grafanaUserHeader, value: "usr2", code: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		newRequest := func(method, target, body string) *http.Request {
+			req := httptest.NewRequest(method, target, strings.NewReader(body))
+			req.Header.Set(test.header, test.value)
+
+			return mux.SetURLVars(req, map[string]string{"uuid": "1000"})
+		}
+
+		// Annotations of units of other users
+		w := httptest.NewRecorder()
+		test.amw.Middleware(http.HandlerFunc(server.annotations)).ServeHTTP(
+			w, newRequest(http.MethodGet, "/api/v1/units/1000/annotations?cluster_id=slurm-0", ""),
+		)
+		assert.Equal(t, test.code, w.Code, "annotations: %s", test.name)
+
+		// Verify ownership of units of other users
+		w = httptest.NewRecorder()
+		test.amw.Middleware(http.HandlerFunc(server.verifyUnitsOwnership)).ServeHTTP(
+			w, newRequest(http.MethodGet, "/api/v1/units/verify?cluster_id=slurm-0&uuid=1000", ""),
+		)
+		assert.Equal(t, test.code, w.Code, "verify: %s", test.name)
+
+		w = httptest.NewRecorder()
+		test.amw.Middleware(http.HandlerFunc(server.verifyUnitsOwnershipBatch)).ServeHTTP(
+			w, newRequest(http.MethodPost, "/api/v1/units/verify", `{"uuids": ["1000"], "cluster_ids": ["slurm-0"]}`),
+		)
+		require.Equal(t, http.StatusOK, w.Code, "batch verify: %s", test.name)
+
+		var response Response[UnitOwnership]
+
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		assert.Equal(t, []UnitOwnership{{UUID: "1000", Owned: test.code == http.StatusOK}}, response.Data, "batch verify: %s", test.name)
+	}
+}
+
+func TestUnitStepsHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add unit with steps, its project and admin user
+	for _, stmt := range []string{
+		`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','')`,
+		`INSERT INTO projects (cluster_id,name,users) VALUES ('slurm-0','prj1','["usr1"]')`,
+		`INSERT INTO units (id,cluster_id,uuid,project,username,started_at_ts) VALUES (1,'slurm-0','1000','prj1','usr1',1000)`,
+		`INSERT INTO unit_steps VALUES (1,'0','train','COMPLETED','','',2000,3000,'','0:0','compute-0','{}','{"alloc_gputime":4800}','')`,
+		`INSERT INTO unit_steps VALUES (1,'batch','batch','RUNNING','','',1000,0,'','0:0','compute-0','{}','{"alloc_gputime":100}','')`,
+	} {
+		_, err = dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.step = Querier[models.UnitStep]
+
+	// Test cases
+	tests := []struct {
+		name  string
+		user  string
+		query string
+		code  int
+	}{
+		{
+			name:  "get steps by owner",
+			user:  "usr1",
+			query: "cluster_id=slurm-0",
+			code:  http.StatusOK,
+		},
+		{
+			name:  "get steps by admin",
+			user:  "adm1",
+			query: "cluster_id=slurm-0",
+			code:  http.StatusOK,
+		},
+		{
+			name:  "get steps by non owner",
+			user:  "foousr",
+			query: "cluster_id=slurm-0",
+			code:  http.StatusForbidden,
+		},
+		{
+			name: "get steps without cluster_id",
+			user: "usr1",
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/units/1000/steps?"+test.query, nil)
+		request.Header.Set(loggedUserHeader, test.user)
+		request = mux.SetURLVars(request, map[string]string{"uuid": "1000"})
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		server.unitSteps(w, request)
+
+		assert.Equal(t, test.code, w.Code, test.name)
+
+		if test.code != http.StatusOK {
+			continue
+		}
+
+		var response Response[models.UnitStep]
+
+		require.NoError(t, json.NewDecoder(w.Result().Body).Decode(&response))
+		require.Len(t, response.Data, 2, test.name)
+		assert.Empty(t, response.Warnings)
+		assert.Equal(t, "batch", response.Data[0].StepID)
+		assert.Equal(t, "0", response.Data[1].StepID)
+		assert.Equal(t, "train", response.Data[1].Name)
+		assert.Equal(t, models.JSONFloat(4800), response.Data[1].TotalTime["alloc_gputime"])
+	}
+}
+
+func TestUnitScriptAdminHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add unit with script
+	for _, stmt := range []string{
+		`INSERT INTO units (id,cluster_id,uuid,username,started_at_ts) VALUES (1,'slurm-0','1000','usr1',1000)`,
+		`INSERT INTO unit_scripts VALUES (1,'#!/bin/bash','HOME=/home/usr1',0,'')`,
+	} {
+		_, err = dbConn.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.script = Querier[models.UnitScript]
+
+	makeRequest := func(query string) (int, Response[models.UnitScript]) {
+		request := httptest.NewRequest(http.MethodGet, "/api/v1/units/1000/script/admin?"+query, nil)
+		request.Header.Set(loggedUserHeader, "adm1")
+		request = mux.SetURLVars(request, map[string]string{"uuid": "1000"})
+
+		w := httptest.NewRecorder()
+		server.unitScriptAdmin(w, request)
+
+		var response Response[models.UnitScript]
+
+		json.NewDecoder(w.Result().Body).Decode(&response)
+
+		return w.Code, response
+	}
+
+	code, response := makeRequest("cluster_id=slurm-0")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []models.UnitScript{{Script: "#!/bin/bash", Environment: "HOME=/home/usr1"}}, response.Data)
+
+	// Unit without script
+	code, response = makeRequest("cluster_id=slurm-1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, response.Data)
+
+	// Cluster ID is mandatory
+	code, _ = makeRequest("")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestAPIKeysHandlers(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add admin user
+	_, err = dbConn.Exec(`INSERT INTO roles (username,role,source,last_updated_at) VALUES ('adm1','admin','ceems','')`)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.queriers.apiKey = Querier[models.APIKey]
+
+	// Make request through middlewares
+	do := func(method, path string, headers map[string]string, body string) (*httptest.ResponseRecorder, Response[models.APIKey]) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		server.server.Handler.ServeHTTP(w, req)
+
+		var response Response[models.APIKey]
+		if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w, response
+	}
+
+	admin := map[string]string{grafanaUserHeader: "adm1"}
+
+	// Create keys with user and admin roles
+	w, resp := do(http.MethodPost, "/api/v1/api_keys/admin", admin, `{"name": "report", "username": "svc-report"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "user", resp.Data[0].Role)
+	assert.Equal(t, "adm1", resp.Data[0].CreatedBy)
+	assert.True(t, strings.HasPrefix(resp.Data[0].Key, apiKeyPrefix))
+
+	userKey := resp.Data[0]
+
+	w, resp = do(
+		http.MethodPost, "/api/v1/api_keys/admin", admin,
+		`{"name": "billing", "username": "svc-billing", "role": "admin", "ttl": "30d"}`,
+	)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Len(t, resp.Data, 1)
+	assert.Positive(t, resp.Data[0].ExpiresAtTS)
+
+	adminKey := resp.Data[0]
+
+	// Invalid role
+	w, _ = do(http.MethodPost, "/api/v1/api_keys/admin", admin, `{"name": "foo", "username": "foo", "role": "root"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Keys must never be listed
+	w, resp = do(http.MethodGet, "/api/v1/api_keys/admin", admin, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, resp.Data, 2)
+
+	for _, key := range resp.Data {
+		assert.Empty(t, key.Key)
+		assert.Empty(t, key.Hash)
+	}
+
+	// Service accounts are identified by their keys and Grafana user header is ignored
+	w, _ = do(http.MethodGet, "/api/v1/units", map[string]string{apiKeyHeader: userKey.Key, grafanaUserHeader: "adm1"}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w, _ = do(http.MethodGet, "/api/v1/units/admin", map[string]string{apiKeyHeader: userKey.Key, grafanaUserHeader: "adm1"}, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w, _ = do(http.MethodGet, "/api/v1/units/admin", map[string]string{apiKeyHeader: adminKey.Key}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Revoked keys must be denied
+	w, _ = do(http.MethodDelete, "/api/v1/api_keys/"+strconv.FormatInt(userKey.ID, 10)+"/admin", admin, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w, _ = do(http.MethodGet, "/api/v1/units", map[string]string{apiKeyHeader: userKey.Key}, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUnitsHandlerWithCSVFormat(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units?format=csv&fields=uuid,cluster_id", nil)
+	req.Header.Set("X-Grafana-User", "foousr")
+	req.Header.Set("X-Dashboard-User", "foousr")
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.units(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "uuid,cluster_id\n1000,slurm-0\n10001,os-0\n", w.Body.String())
+}
+
+func TestUnitsHandlerWithNDJSONFormat(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Create request
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units?format=ndjson", nil)
+	req.Header.Set("X-Grafana-User", "foousr")
+	req.Header.Set("X-Dashboard-User", "foousr")
+
+	// Start recorder
+	w := httptest.NewRecorder()
+	server.units(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+
+	// Each line must be a unit
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, len(mockServerUnits))
+
+	for i, line := range lines {
+		var unit models.Unit
+
+		require.NoError(t, json.Unmarshal([]byte(line), &unit))
+		assert.Equal(t, mockServerUnits[i].UUID, unit.UUID)
+	}
+}
+
+func TestLiveUnitHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	runningUnit := models.Unit{ClusterID: "slurm-0", UUID: "1000", User: "foousr", StartedAtTS: 1000}
+	expectedMetrics := map[string]map[string][]models.Sample{
+		"cpu_usage": {"usage": {{Timestamp: 2000, Value: 1.5}, {Timestamp: 3000, Value: 2.5}}},
+	}
+
+	server.liveMetrics = func(ctx context.Context, unit models.Unit, end time.Time) (*models.LiveMetrics, error) {
+		return &models.LiveMetrics{
+			ClusterID: unit.ClusterID,
+			UUID:      unit.UUID,
+			From:      2000,
+			To:        3000,
+			Metrics:   expectedMetrics,
+		}, nil
+	}
+
+	tests := []struct {
+		name   string
+		units  []models.Unit
+		query  string
+		code   int
+		detail string
+	}{
+		{
+			name:  "running unit",
+			units: []models.Unit{runningUnit},
+			query: "cluster_id=slurm-0",
+			code:  http.StatusOK,
+		},
+		{
+			name:   "missing cluster id",
+			units:  []models.Unit{runningUnit},
+			code:   http.StatusBadRequest,
+			detail: errMissingClusterID.Error(),
+		},
+		{
+			name:   "unit not found",
+			query:  "cluster_id=slurm-0",
+			code:   http.StatusNotFound,
+			detail: errUnitNotFound.Error(),
+		},
+		{
+			name:   "unit not running",
+			units:  []models.Unit{{ClusterID: "slurm-0", UUID: "1000", EndedAtTS: 5000}},
+			query:  "cluster_id=slurm-0",
+			code:   http.StatusBadRequest,
+			detail: errUnitNotRunning.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		server.queriers.unit = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Unit, error) {
+			query, params = q.get()
+
+			return test.units, nil
+		}
+
+		// Create request
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/units/1000/live?"+test.query, nil)
+		req.Header.Set(loggedUserHeader, "foousr")
+		req = mux.SetURLVars(req, map[string]string{"uuid": "1000"})
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		server.liveUnit(w, req)
+
+		assert.Equal(t, test.code, w.Code, test.name)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), test.name)
+
+		if test.code != http.StatusOK {
+			var problem Problem
+
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&problem), test.name)
+			assert.Equal(t, test.detail, problem.Detail, test.name)
+
+			continue
+		}
+
+		var response Response[models.LiveMetrics]
+
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response), test.name)
+		require.Len(t, response.Data, 1, test.name)
+		assert.Equal(t, expectedMetrics, response.Data[0].Metrics, test.name)
+		assert.Contains(t, query, "AND cluster_id = (?) AND uuid = (?) AND username = (?)", test.name)
+		assert.Equal(t, []string{"slurm-0", "1000", "foousr"}, params, test.name)
+	}
+
+	// Live endpoint must be unavailable when no updaters are configured
+	server.liveMetrics = nil
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units/1000/live?cluster_id=slurm-0", nil)
+	req.Header.Set(loggedUserHeader, "foousr")
+	req = mux.SetURLVars(req, map[string]string{"uuid": "1000"})
+
+	w := httptest.NewRecorder()
+	server.liveUnit(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestReservationsHandlers(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	mockReservations := []models.Reservation{
+		{ClusterID: "slurm-0", ResourceManager: "slurm", Name: "resv1", Project: "foo"},
+	}
+	server.queriers.resv = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Reservation, error) {
+		query, params = q.get()
+
+		return mockReservations, nil
+	}
+
+	tests := []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request)
+		url     string
+		query   string
+		params  []string
+	}{
+		{
+			name:    "user",
+			handler: server.reservations,
+			url:     "/api/v1/reservations?cluster_id=slurm-0&from=1735689600&to=1735776000",
+			query:   "WHERE 1=1 AND project IN (SELECT name FROM projects WHERE EXISTS (SELECT 1 FROM json_each(users) WHERE value IN (?))) AND cluster_id IN (?) AND started_at <= (?) AND ended_at >= (?)",
+			params:  []string{"foousr", "slurm-0", "2025-01-02T00:00:00", "2025-01-01T00:00:00"},
+		},
+		{
+			name:    "admin",
+			handler: server.reservationsAdmin,
+			url:     "/api/v1/reservations/admin?project=foo&from=1735689600&to=1735776000",
+			query:   "WHERE 1=1 AND project IN (?) AND started_at <= (?) AND ended_at >= (?)",
+			params:  []string{"foo", "2025-01-02T00:00:00", "2025-01-01T00:00:00"},
+		},
+	}
+
+	for _, test := range tests {
+		// Create request
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		req.Header.Set(loggedUserHeader, "foousr")
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, req)
+
+		// Unmarshal byte into structs
+		var response Response[models.Reservation]
+		json.NewDecoder(w.Body).Decode(&response)
+
+		assert.Equal(t, http.StatusOK, w.Code, test.name)
+		assert.Equal(t, mockReservations, response.Data, test.name)
+		assert.Contains(t, query, test.query, test.name)
+		assert.Equal(t, test.params, params, test.name)
+	}
+}
+
+func TestPreemptionsHandlers(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Capture query sent to querier
+	var query string
+
+	var params []string
+
+	mockPreemptions := []models.Preemption{
+		{ClusterID: "slurm-0", ResourceManager: "slurm", UUID: "1000", User: "foousr"},
+	}
+	server.queriers.preempt = func(ctx context.Context, db *sql.DB, q Query, logger *slog.Logger) ([]models.Preemption, error) {
+		query, params = q.get()
+
+		return mockPreemptions, nil
+	}
+
+	tests := []struct {
+		name    string
+		handler func(http.ResponseWriter, *http.Request)
+		url     string
+		query   string
+		params  []string
+	}{
+		{
+			name:    "user",
+			handler: server.preemptions,
+			url:     "/api/v1/preemptions?user=barusr&from=1735689600&to=1735776000",
+			query:   "WHERE 1=1 AND username IN (?) AND (preempted_at BETWEEN (?) AND (?))",
+			params:  []string{"foousr", "2025-01-01T00:00:00", "2025-01-02T00:00:00"},
+		},
+		{
+			name:    "admin",
+			handler: server.preemptionsAdmin,
+			url:     "/api/v1/preemptions/admin?user=barusr&cluster_id=slurm-0&from=1735689600&to=1735776000",
+			query:   "WHERE 1=1 AND username IN (?) AND cluster_id IN (?) AND (preempted_at BETWEEN (?) AND (?))",
+			params:  []string{"barusr", "slurm-0", "2025-01-01T00:00:00", "2025-01-02T00:00:00"},
+		},
+	}
+
+	for _, test := range tests {
+		// Create request
+		req := httptest.NewRequest(http.MethodGet, test.url, nil)
+		req.Header.Set(loggedUserHeader, "foousr")
+
+		// Start recorder
+		w := httptest.NewRecorder()
+		test.handler(w, req)
+
+		// Unmarshal byte into structs
+		var response Response[models.Preemption]
+		json.NewDecoder(w.Body).Decode(&response)
+
+		assert.Equal(t, http.StatusOK, w.Code, test.name)
+		assert.Equal(t, mockPreemptions, response.Data, test.name)
+		assert.Contains(t, query, test.query, test.name)
+		assert.Equal(t, test.params, params, test.name)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Make a request to demo end point so that it is recorded in metrics
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/demo/units", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Metrics end point must not need authentication
+	w = httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	assert.Contains(t, body, `ceems_api_server_http_requests_total{code="200",handler="/api/v1/demo/{resource:(?:units|usage)}",method="GET"}`)
+	assert.Contains(t, body, `ceems_api_server_http_request_duration_seconds_bucket{handler="/api/v1/demo/{resource:(?:units|usage)}",method="GET"`)
+}
+
+type mockBackupReporter struct {
+	status *db.BackupStatus
+}
+
+func (m *mockBackupReporter) BackupStatus() *db.BackupStatus {
+	return m.status
+}
+
+func TestHealthBackupsHandler(t *testing.T) {
+	server := setupServer(t.TempDir())
+	defer server.Shutdown(context.Background())
+
+	// Backups are not configured
+	w := httptest.NewRecorder()
+	server.health(w, httptest.NewRequest(http.MethodGet, "/api/v1/health?backups", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// Last backup succeeded
+	reporter := &mockBackupReporter{status: &db.BackupStatus{
+		LastAttemptAt: "2024-01-01T00:00:00+0000",
+		LastSuccessAt: "2024-01-01T00:00:00+0000",
+		LastBackup:    "ceems-202401010000.db",
+		Targets:       []db.BackupTargetStatus{{Name: "minio", LastSuccessAt: "2024-01-01T00:00:00+0000"}},
+	}}
+	server.backups = reporter
+
+	w = httptest.NewRecorder()
+	server.health(w, httptest.NewRequest(http.MethodGet, "/api/v1/health?backups", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response Response[db.BackupStatus]
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, *reporter.status, response.Data[0])
+
+	// Last backup failed
+	reporter.status.LastError = "failed to upload backup to minio"
+
+	w = httptest.NewRecorder()
+	server.health(w, httptest.NewRequest(http.MethodGet, "/api/v1/health?backups", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,73 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+)
+
+// snapshotKey is the context key of DB snapshot of a request.
+type snapshotKey struct{}
+
+// snapshot is a read transaction on a DB.
+type snapshot struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// queryer prepares and makes queries. It is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// withSnapshot starts a read transaction on dbConn and returns a context that carries
+// it along with a function that ends the transaction. As CEEMS DB is in WAL mode, all
+// the queries made in a read transaction read from the same snapshot of DB even when
+// updaters commit new rows in the meantime.
+//
+// If ctx already carries a snapshot of dbConn, it is reused and the returned function
+// does nothing. This lets queriers that make several queries, like count and rows, to
+// use snapshot of request when there is one.
+func withSnapshot(ctx context.Context, dbConn *sql.DB) (context.Context, func(), error) {
+	if s, ok := ctx.Value(snapshotKey{}).(snapshot); ok && s.db == dbConn {
+		return ctx, func() {}, nil
+	}
+
+	tx, err := dbConn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return ctx, func() {}, err
+	}
+
+	// Read transactions do not modify DB and rolling back is enough to end them
+	return context.WithValue(ctx, snapshotKey{}, snapshot{db: dbConn, tx: tx}), func() { tx.Rollback() }, nil
+}
+
+// snapshotConn returns the read transaction on dbConn carried by ctx if present.
+// Else dbConn is returned.
+func snapshotConn(ctx context.Context, dbConn *sql.DB) queryer {
+	if s, ok := ctx.Value(snapshotKey{}).(snapshot); ok && s.db == dbConn {
+		return s.tx
+	}
+
+	return dbConn
+}
+
+// snapshot wraps the handler h of end points that make several queries so that
+// all of them read from the same snapshot of DB. Without it, results of different
+// queries, like units and their usage, can be inconsistent when an update is
+// committed in the middle of request.
+func (s *CEEMSServer) snapshot(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, done, err := withSnapshot(r.Context(), s.db)
+		if err != nil {
+			s.logger.Debug("Failed to start read transaction", "path", r.URL.Path, "err", err)
+		}
+		defer done()
+
+		h(w, r.WithContext(ctx))
+	}
+}
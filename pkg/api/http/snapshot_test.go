@@ -0,0 +1,67 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	writer, err := sql.Open("sqlite3", filepath.Join(dir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer writer.Close()
+
+	_, err = writer.Exec("PRAGMA journal_mode=WAL; CREATE TABLE units (id integer); INSERT INTO units VALUES (1);")
+	require.NoError(t, err)
+
+	reader, err := openDB(db.DataConfig{Path: dir}, dbReadOnly, DBPoolConfig{})
+	require.NoError(t, err)
+
+	defer reader.Close()
+
+	count := func(ctx context.Context) int {
+		var n int
+
+		require.NoError(t, snapshotConn(ctx, reader).QueryRowContext(ctx, "SELECT COUNT(*) FROM units").Scan(&n))
+
+		return n
+	}
+
+	ctx, done, err := withSnapshot(context.Background(), reader)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count(ctx))
+
+	// Rows committed after the first read must not be visible in snapshot
+	_, err = writer.Exec("INSERT INTO units VALUES (2)")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, count(ctx))
+	assert.Equal(t, 2, count(context.Background()))
+
+	// Nested snapshots of same DB must reuse the existing one
+	nestedCtx, nestedDone, err := withSnapshot(ctx, reader)
+	require.NoError(t, err)
+	nestedDone()
+
+	assert.Equal(t, 1, count(nestedCtx))
+
+	// Snapshot must not be used for other DBs
+	assert.Equal(t, queryer(writer), snapshotConn(ctx, writer))
+
+	done()
+
+	assert.Equal(t, 2, count(context.Background()))
+}
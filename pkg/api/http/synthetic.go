@@ -0,0 +1,395 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+)
+
+// Limits on synthetic units that can be injected in a single request.
+const (
+	defaultSyntheticUnitsPerDay = 20
+	maxSyntheticUnitsPerDay     = 1000
+	maxSyntheticPeriod          = 366 * 24 * time.Hour
+	defaultSyntheticClusterID   = "synthetic-0"
+	syntheticTag                = "synthetic"
+)
+
+// Injector inserts units, users and projects into DB.
+type Injector interface {
+	Inject(
+		ctx context.Context,
+		start time.Time,
+		end time.Time,
+		units []models.ClusterUnits,
+		users []models.ClusterUsers,
+		projects []models.ClusterProjects,
+	) error
+}
+
+// SyntheticUnits is the summary of synthetic units injected into DB.
+type SyntheticUnits struct {
+	ClusterID string `json:"cluster_id"`
+	Seed      int64  `json:"seed"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	NumUnits  int    `json:"num_units"`
+}
+
+// syntheticGenerator generates deterministic synthetic units for a given seed.
+type syntheticGenerator struct {
+	rng       *rand.Rand
+	cluster   models.Cluster
+	location  *time.Location
+	users     map[string][]string
+	projects  map[string][]string
+	numPerDay int
+}
+
+// newSyntheticGenerator returns a new instance of syntheticGenerator.
+func newSyntheticGenerator(seed int64, clusterID string, numPerDay int, location *time.Location) *syntheticGenerator {
+	return &syntheticGenerator{
+		rng:       rand.New(rand.NewSource(seed)), // #nosec
+		cluster:   models.Cluster{ID: clusterID, Manager: "slurm"},
+		location:  location,
+		users:     make(map[string][]string),
+		projects:  make(map[string][]string),
+		numPerDay: numPerDay,
+	}
+}
+
+// between returns a random number in [minBound, maxBound).
+func (g *syntheticGenerator) between(minBound, maxBound int64) int64 {
+	if maxBound <= minBound {
+		return minBound
+	}
+
+	return minBound + g.rng.Int63n(maxBound-minBound)
+}
+
+// format returns time stamp in milliseconds formatted in DB time zone.
+func (g *syntheticGenerator) format(ts int64) string {
+	return time.UnixMilli(ts).In(g.location).Format(base.DatetimezoneLayout)
+}
+
+// units returns units that ran in the window between start and end. UUIDs are
+// derived from day and index of the unit so that injecting the same period
+// with the same seed is idempotent.
+func (g *syntheticGenerator) units(start, end time.Time) []models.Unit {
+	startTS, endTS := start.UnixMilli(), end.UnixMilli()
+	day := start.Unix() / 86400
+
+	units := make([]models.Unit, g.numPerDay)
+
+	for i := range g.numPerDay {
+		user := users[g.rng.Intn(len(users))]
+		project := projects["slurm"][g.rng.Intn(len(projects["slurm"]))]
+
+		if !slices.Contains(g.users[user], project) {
+			g.users[user] = append(g.users[user], project)
+		}
+
+		if !slices.Contains(g.projects[project], user) {
+			g.projects[project] = append(g.projects[project], user)
+		}
+
+		startedAt := g.between(startTS, endTS)
+		endedAt := g.between(startedAt+1000, min(endTS, startedAt+int64(4*time.Hour/time.Millisecond)))
+		createdAt := startedAt - g.between(5000, 3600000)
+		elapsed := float64(endedAt-startedAt) / 1000
+
+		cpus := g.between(1, 65)
+		mem := g.between(1, 257) * 1024
+		gpus := g.between(0, 5)
+
+		units[i] = models.Unit{
+			ResourceManager: g.cluster.Manager,
+			ClusterID:       g.cluster.ID,
+			UUID:            fmt.Sprintf("%d%04d", day, i),
+			Name:            fmt.Sprintf("synthetic-%d", i),
+			Project:         project,
+			User:            user,
+			Group:           "group",
+			CreatedAt:       g.format(createdAt),
+			StartedAt:       g.format(startedAt),
+			EndedAt:         g.format(endedAt),
+			CreatedAtTS:     createdAt,
+			StartedAtTS:     startedAt,
+			EndedAtTS:       endedAt,
+			Elapsed:         (time.Duration(endedAt-startedAt) * time.Millisecond).Truncate(time.Second).String(),
+			State:           "COMPLETED",
+			Allocation: models.Allocation{
+				"cpus": cpus,
+				"mem":  mem,
+				"gpus": gpus,
+			},
+			TotalTime: models.MetricMap{
+				"walltime":         models.JSONFloat(elapsed),
+				"alloc_cputime":    models.JSONFloat(float64(cpus) * elapsed),
+				"alloc_cpumemtime": models.JSONFloat(float64(mem) * elapsed),
+				"alloc_gputime":    models.JSONFloat(float64(gpus) * elapsed),
+				"alloc_gpumemtime": models.JSONFloat(float64(gpus) * elapsed),
+			},
+			AveCPUUsage:         models.MetricMap{"global": models.JSONFloat(g.rng.Float64() * 100)},
+			AveCPUMemUsage:      models.MetricMap{"global": models.JSONFloat(g.rng.Float64() * 100)},
+			TotalCPUEnergyUsage: models.MetricMap{"total": models.JSONFloat(float64(cpus) * elapsed * 1e-6)},
+			TotalCPUEmissions:   models.MetricMap{"rte_total": models.JSONFloat(float64(cpus) * elapsed * 5e-5)},
+			Tags: models.Tag{
+				syntheticTag: true,
+				"partition":  "synthetic",
+				"qos":        "normal",
+				"workdir":    fmt.Sprintf("/home/%s/synthetic-%d", user, i),
+			},
+		}
+
+		if gpus > 0 {
+			units[i].AveGPUUsage = models.MetricMap{"global": models.JSONFloat(g.rng.Float64() * 100)}
+			units[i].AveGPUMemUsage = models.MetricMap{"global": models.JSONFloat(g.rng.Float64() * 100)}
+			units[i].TotalGPUEnergyUsage = models.MetricMap{"total": models.JSONFloat(float64(gpus) * elapsed * 1e-4)}
+			units[i].TotalGPUEmissions = models.MetricMap{"rte_total": models.JSONFloat(float64(gpus) * elapsed * 5e-3)}
+		}
+	}
+
+	return units
+}
+
+// associations returns users and projects of units generated so far.
+func (g *syntheticGenerator) associations(at time.Time) ([]models.ClusterUsers, []models.ClusterProjects) {
+	lastUpdatedAt := at.In(g.location).Format(base.DatetimezoneLayout)
+
+	var userModels []models.User
+
+	for name, projs := range g.users {
+		slices.Sort(projs)
+
+		var projectsList models.List
+		for _, p := range projs {
+			projectsList = append(projectsList, p)
+		}
+
+		userModels = append(userModels, models.User{Name: name, Projects: projectsList, LastUpdatedAt: lastUpdatedAt})
+	}
+
+	var projectModels []models.Project
+
+	for name, usrs := range g.projects {
+		slices.Sort(usrs)
+
+		var usersList models.List
+		for _, u := range usrs {
+			usersList = append(usersList, u)
+		}
+
+		projectModels = append(projectModels, models.Project{Name: name, Users: usersList, LastUpdatedAt: lastUpdatedAt})
+	}
+
+	return []models.ClusterUsers{{Cluster: g.cluster, Users: userModels}},
+		[]models.ClusterProjects{{Cluster: g.cluster, Projects: projectModels}}
+}
+
+// configuredClusterIDs returns IDs of clusters found in the configuration of
+// resource managers.
+func configuredClusterIDs(logger *slog.Logger) []string {
+	clusters, err := resource.Clusters()
+	if err != nil {
+		logger.Warn("Failed to read clusters config. Only clusters in DB are protected from synthetic units", "err", err)
+
+		return nil
+	}
+
+	ids := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		ids[i] = cluster.ID
+	}
+
+	return ids
+}
+
+// isRealCluster returns true if the cluster is configured or it has units in DB
+// that are not synthetic. Synthetic units in a real cluster will be accounted in
+// its usage and overwrite associations of its users and projects.
+func (s *CEEMSServer) isRealCluster(ctx context.Context, clusterID string) (bool, error) {
+	if slices.Contains(s.realClusterIDs, clusterID) {
+		return true, nil
+	}
+
+	query := fmt.Sprintf(
+		"SELECT EXISTS(SELECT 1 FROM %s WHERE cluster_id = ? AND json_extract(tags, '$.%s') IS NULL)",
+		s.unitsTable(ctx, time.Time{}, time.Time{}), syntheticTag,
+	)
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, query, clusterID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// syntheticUnitsAdmin         godoc
+//
+//	@Summary		Admin endpoint to inject synthetic compute units
+//	@Description	This admin endpoint generates synthetic compute units and inserts them into
+//	@Description	DB along with their aggregate usage so that dashboards and reports can be
+//	@Description	validated before real data accumulates. The current user is always identified
+//	@Description	by the header `X-Grafana-User` in the request.
+//	@Description
+//	@Description	This endpoint is available only when server is started with the flag
+//	@Description	`--web.enable-synthetic-units`. Units are generated for each day between
+//	@Description	`from` and `to` using the given `seed` and hence, injecting the same period with
+//	@Description	the same seed always generates identical units. Units are assigned to the
+//	@Description	cluster given by `cluster_id` which must be different from real clusters. Clusters
+//	@Description	that are configured or have units in DB that are not synthetic are rejected.
+//	@Security		BasicAuth
+//	@Tags			units
+//	@Produce		json
+//	@Param			X-Grafana-User	header		string	true	"Current user name"
+//	@Param			cluster_id		query		string	false	"Cluster ID"	default(synthetic-0)
+//	@Param			from			query		string	false	"From timestamp"
+//	@Param			to				query		string	false	"To timestamp"
+//	@Param			seed			query		int		false	"Seed of random generator"	default(1)
+//	@Param			units_per_day	query		int		false	"Number of units per day"	default(20)
+//	@Success		201				{object}	Response[SyntheticUnits]
+//	@Failure		400				{object}	Problem
+//	@Failure		401				{object}	Problem
+//	@Failure		403				{object}	Problem
+//	@Failure		500				{object}	Problem
+//	@Failure		503				{object}	Problem
+//	@Router			/units/synthetic/admin [post]
+//
+// POST /units/synthetic/admin
+// Inject synthetic units into DB.
+func (s *CEEMSServer) syntheticUnitsAdmin(w http.ResponseWriter, r *http.Request) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "synthetic units endpoint", s.logger)
+
+	// Set headers
+	s.setHeaders(w)
+
+	if s.injector == nil {
+		errorResponse(w, r, &apiError{errorUnavailable, errSyntheticUnavailable}, s.logger)
+
+		return
+	}
+
+	q := r.URL.Query()
+
+	start, end, err := s.getQueryWindowTimes(r)
+	if err != nil {
+		errorResponse(w, r, &apiError{errorBadRequest, err}, s.logger)
+
+		return
+	}
+
+	if !end.After(start) || end.Sub(start) > maxSyntheticPeriod {
+		errorResponse(w, r, &apiError{errorBadRequest, errInvalidSyntheticPeriod}, s.logger)
+
+		return
+	}
+
+	clusterID := defaultSyntheticClusterID
+	if c := q.Get("cluster_id"); c != "" {
+		clusterID = c
+	}
+
+	// Synthetic units must never be mixed with units of real clusters
+	isReal, err := s.isRealCluster(r.Context(), clusterID)
+	if err != nil {
+		s.logger.Error("Failed to check cluster of synthetic units", "cluster_id", clusterID, "err", err)
+		errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+		return
+	}
+
+	if isReal {
+		errorResponse(w, r, &apiError{errorBadRequest, errRealSyntheticCluster}, s.logger)
+
+		return
+	}
+
+	var seed int64 = 1
+	if v := q.Get("seed"); v != "" {
+		if seed, err = strconv.ParseInt(v, 10, 64); err != nil {
+			errorResponse(w, r, &apiError{errorBadRequest, errInvalidSyntheticSeed}, s.logger)
+
+			return
+		}
+	}
+
+	numPerDay := defaultSyntheticUnitsPerDay
+	if v := q.Get("units_per_day"); v != "" {
+		if numPerDay, err = strconv.Atoi(v); err != nil || numPerDay <= 0 || numPerDay > maxSyntheticUnitsPerDay {
+			errorResponse(w, r, &apiError{errorBadRequest, errInvalidSyntheticUnits}, s.logger)
+
+			return
+		}
+	}
+
+	generator := newSyntheticGenerator(seed, clusterID, numPerDay, s.dbConfig.Data.Timezone.Location)
+
+	// Inject units day by day so that daily usage is aggregated on the days
+	// units ran
+	var numUnits int
+
+	for dayStart := start; dayStart.Before(end); {
+		dayEnd := dayStart.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+
+		units := generator.units(dayStart, dayEnd)
+		users, projects := generator.associations(dayEnd)
+
+		if err := s.injector.Inject(
+			r.Context(), dayStart, dayEnd, []models.ClusterUnits{{Cluster: generator.cluster, Units: units}}, users, projects,
+		); err != nil {
+			s.logger.Error("Failed to inject synthetic units", "cluster_id", clusterID, "err", err)
+			errorResponse(w, r, &apiError{errorDB, err}, s.logger)
+
+			return
+		}
+
+		numUnits += len(units)
+		dayStart = dayEnd
+	}
+
+	s.logger.Info(
+		"Synthetic units injected", "cluster_id", clusterID, "seed", seed, "num_units", numUnits,
+		"from", start.Format(time.DateTime), "to", end.Format(time.DateTime),
+	)
+
+	// Write response
+	w.WriteHeader(http.StatusCreated)
+
+	response := Response[SyntheticUnits]{
+		Status: "success",
+		Data: []SyntheticUnits{
+			{
+				ClusterID: clusterID,
+				Seed:      seed,
+				From:      start.Format(base.DatetimezoneLayout),
+				To:        end.Format(base.DatetimezoneLayout),
+				NumUnits:  numUnits,
+			},
+		},
+	}
+
+	if err := json.NewEncoder(w).Encode(&response); err != nil {
+		s.logger.Error("Failed to encode response", "err", err)
+		w.Write([]byte("KO"))
+	}
+}
@@ -0,0 +1,140 @@
+//go:build cgo
+// +build cgo
+
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/db"
+	db_migrator "github.com/mahendrapaipuri/ceems/pkg/api/db/migrator"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockInjector struct {
+	windows [][2]time.Time
+	units   []models.Unit
+	users   []models.ClusterUsers
+}
+
+func (m *mockInjector) Inject(
+	_ context.Context,
+	start time.Time,
+	end time.Time,
+	units []models.ClusterUnits,
+	users []models.ClusterUsers,
+	_ []models.ClusterProjects,
+) error {
+	m.windows = append(m.windows, [2]time.Time{start, end})
+	m.units = append(m.units, units[0].Units...)
+	m.users = users
+
+	return nil
+}
+
+func TestSyntheticGenerator(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	// Same seed must generate identical units
+	units := newSyntheticGenerator(42, "synthetic-0", 10, time.UTC).units(start, end)
+	assert.Equal(t, units, newSyntheticGenerator(42, "synthetic-0", 10, time.UTC).units(start, end))
+	assert.NotEqual(t, units, newSyntheticGenerator(43, "synthetic-0", 10, time.UTC).units(start, end))
+
+	for _, unit := range units {
+		assert.Equal(t, "synthetic-0", unit.ClusterID)
+		assert.GreaterOrEqual(t, unit.StartedAtTS, start.UnixMilli())
+		assert.Less(t, unit.StartedAtTS, end.UnixMilli())
+		assert.Greater(t, unit.EndedAtTS, unit.StartedAtTS)
+		assert.Contains(t, unit.TotalTime, "alloc_gpumemtime")
+	}
+}
+
+func TestSyntheticUnitsAdmin(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create DB and apply migrations
+	dbConn, err := sql.Open(sqlite3.DriverName, filepath.Join(tmpDir, base.CEEMSDBName))
+	require.NoError(t, err)
+
+	defer dbConn.Close()
+
+	migrator, err := db_migrator.New(db.MigrationsFS, "migrations", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.NoError(t, migrator.ApplyMigrations(dbConn))
+
+	// Add a real unit and a synthetic unit injected earlier
+	_, err = dbConn.Exec(
+		`INSERT INTO units (cluster_id,uuid,tags) VALUES ('slurm-0','1000','{"partition": "cpu"}');` +
+			`INSERT INTO units (cluster_id,uuid,tags) VALUES ('synthetic-0','1','{"synthetic": true}');`,
+	)
+	require.NoError(t, err)
+
+	server := setupServer(tmpDir)
+	defer server.Shutdown(context.Background())
+
+	server.realClusterIDs = []string{"slurm-1"}
+
+	query := func(url string) (int, Response[SyntheticUnits]) {
+		req := httptest.NewRequest(http.MethodPost, url, nil)
+		req.Header.Set(loggedUserHeader, "adm1")
+		req.Header.Set(adminUserHeader, "adm1")
+
+		w := httptest.NewRecorder()
+		server.syntheticUnitsAdmin(w, req)
+
+		// Errors are returned as problem details
+		var response Response[SyntheticUnits]
+		if w.Code == http.StatusCreated {
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		}
+
+		return w.Code, response
+	}
+
+	// Endpoint must be unavailable without injector
+	code, _ := query("/api/v1/units/synthetic/admin")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	injector := &mockInjector{}
+	server.injector = injector
+
+	// Units must be injected for each day in the period
+	code, response := query("/api/v1/units/synthetic/admin?from=1704067200&to=1704189600&units_per_day=5&seed=7")
+	require.Equal(t, http.StatusCreated, code)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, "synthetic-0", response.Data[0].ClusterID)
+	assert.Equal(t, int64(7), response.Data[0].Seed)
+	assert.Equal(t, 10, response.Data[0].NumUnits)
+	require.Len(t, injector.windows, 2)
+	assert.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Unix(), injector.windows[0][1].Unix())
+	assert.Len(t, injector.units, 10)
+	assert.NotEmpty(t, injector.users[0].Users)
+	assert.Contains(t, injector.units[0].Tags, syntheticTag)
+
+	// Invalid requests
+	for _, url := range []string{
+		"/api/v1/units/synthetic/admin?from=1704189600&to=1704067200",
+		"/api/v1/units/synthetic/admin?seed=abc",
+		"/api/v1/units/synthetic/admin?units_per_day=0",
+		"/api/v1/units/synthetic/admin?units_per_day=10000",
+		"/api/v1/units/synthetic/admin?cluster_id=slurm-0",
+		"/api/v1/units/synthetic/admin?cluster_id=slurm-1",
+	} {
+		code, _ = query(url)
+		assert.Equal(t, http.StatusBadRequest, code, url)
+	}
+}
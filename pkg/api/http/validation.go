@@ -3,10 +3,12 @@ package http
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
@@ -16,13 +18,20 @@ const (
 	startTimeTol = 3600000 // 1 hour in milliseconds
 )
 
-// adminUsers returns a slice of admin users fetched from DB.
+// adminUsers returns a slice of admin users fetched from DB. Users who have
+// admin role in any source are admins unless they have user role assigned
+// using API server.
 func adminUsers(ctx context.Context, dbConn *sql.DB, logger *slog.Logger) []string {
 	var users []string
 
 	//nolint:gosec
 	rows, err := dbConn.QueryContext(
-		ctx, "SELECT users FROM "+base.AdminUsersDBTableName,
+		ctx,
+		fmt.Sprintf(
+			"SELECT DISTINCT username FROM %[1]s WHERE role = ? AND username NOT IN (SELECT username FROM %[1]s WHERE source = ? AND role = ?)",
+			base.RolesDBTableName,
+		),
+		adminRole, apiRoleSource, userRole,
 	)
 	if err != nil {
 		logger.Error("Failed to query for admin users", "err", err)
@@ -32,19 +41,15 @@ func adminUsers(ctx context.Context, dbConn *sql.DB, logger *slog.Logger) []stri
 	defer rows.Close()
 
 	// Scan users rows
-	var usersList models.List
 	for rows.Next() {
-		if err := rows.Scan(&usersList); err != nil {
+		var user string
+		if err := rows.Scan(&user); err != nil {
 			logger.Error("Failed to scan row for admin users query", "err", err)
 
 			continue
 		}
 
-		for _, user := range usersList {
-			if userString, ok := user.(string); ok {
-				users = append(users, userString)
-			}
-		}
+		users = append(users, user)
 	}
 
 	// Ref: http://go-database-sql.org/errors.html
@@ -56,10 +61,63 @@ func adminUsers(ctx context.Context, dbConn *sql.DB, logger *slog.Logger) []stri
 	return users
 }
 
-// VerifyOwnership returns true if user is the owner of queried units.
+// ownershipTable returns the source of units to verify ownership including their
+// monthly partitions. Units cannot terminate before they start and hence, partitions
+// that are older than the earliest start of units are skipped.
+func ownershipTable(ctx context.Context, db *sql.DB, starts []int64, logger *slog.Logger) string {
+	var from time.Time
+	if len(starts) > 0 {
+		from = time.UnixMilli(slices.Min(starts) - startTimeTol)
+	}
+
+	table, err := UnitsSource(ctx, db, from, time.Time{})
+	if err != nil {
+		logger.Error("Failed to list partitions of units table", "err", err)
+	}
+
+	return table
+}
+
+// ownershipQuery returns the query that fetches the units among uuids that
+// belong to the projects of user from table.
+func ownershipQuery(table string, user string, clusterIDs []string, uuids []string, starts []int64) Query {
+	// Get sub query for projects
+	qSub := projectsSubQuery([]string{user})
+
+	// Make query
+	q := Query{}
+	q.query("SELECT uuid,cluster_id FROM " + table)
+
+	// Add project sub query
+	q.query(" WHERE project IN ")
+	q.subQuery(qSub)
+
+	// Add cluster IDs conditional clause
+	q.query(" AND cluster_id IN ")
+	q.param(clusterIDs)
+
+	// Add uuids in question
+	q.query(" AND uuid IN ")
+	q.param(uuids)
+
+	// Get min and max of starts and use 1 hour as tolerance for boundaries
+	if len(starts) > 0 {
+		q.query(" AND started_at_ts BETWEEN ")
+		q.param([]string{strconv.FormatInt(slices.Min(starts)-startTimeTol, 10)})
+		q.query(" AND ")
+		q.param([]string{strconv.FormatInt(slices.Max(starts)+startTimeTol, 10)})
+	}
+
+	return q
+}
+
+// VerifyOwnership returns true if user is the owner of queried units. Admin users
+// in DB are resolved here and isAdmin must be true when user has been identified
+// as admin by other sources like LDAP group, OIDC admin groups or API key roles.
 func VerifyOwnership(
 	ctx context.Context,
 	user string,
+	isAdmin bool,
 	clusterIDs []string,
 	uuids []string,
 	starts []int64,
@@ -74,8 +132,8 @@ func VerifyOwnership(
 		return true
 	}
 
-	// If current user is in list of admin users, pass the check
-	if slices.Contains(adminUsers(ctx, db, logger), user) {
+	// If current user is admin, pass the check
+	if isAdmin || slices.Contains(adminUsers(ctx, db, logger), user) {
 		return true
 	}
 
@@ -91,32 +149,8 @@ func VerifyOwnership(
 
 	logger.Debug("UUIDs in query", "user", user, "cluster_id", strings.Join(clusterIDs, ","), "queried_uuids", strings.Join(uuids, ","))
 
-	// Get sub query for projects
-	qSub := projectsSubQuery([]string{user})
-
 	// Make query
-	q := Query{}
-	q.query("SELECT uuid,cluster_id FROM " + base.UnitsDBTableName)
-
-	// Add project sub query
-	q.query(" WHERE project IN ")
-	q.subQuery(qSub)
-
-	// Add cluster IDs conditional clause
-	q.query(" AND cluster_id IN ")
-	q.param(clusterIDs)
-
-	// Add uuids in question
-	q.query(" AND uuid IN ")
-	q.param(uuids)
-
-	// Get min and max of starts and use 1 hour as tolerance for boundaries
-	if len(starts) > 0 {
-		q.query(" AND started_at_ts BETWEEN ")
-		q.param([]string{strconv.FormatInt(slices.Min(starts)-startTimeTol, 10)})
-		q.query(" AND ")
-		q.param([]string{strconv.FormatInt(slices.Max(starts)+startTimeTol, 10)})
-	}
+	q := ownershipQuery(ownershipTable(ctx, db, starts, logger), user, clusterIDs, uuids, starts)
 
 	// Run query and get response
 	units, err := Querier[models.Unit](ctx, db, q, logger)
@@ -152,3 +186,62 @@ func VerifyOwnership(
 	// }
 	return true
 }
+
+// VerifyRequest is the request to verify ownership of a batch of compute units.
+type VerifyRequest struct {
+	UUIDs      []string `json:"uuids"`
+	ClusterIDs []string `json:"cluster_ids"`
+	Starts     []int64  `json:"starts,omitempty"`
+}
+
+// UnitOwnership is the ownership status of a compute unit.
+type UnitOwnership struct {
+	UUID  string `json:"uuid"`
+	Owned bool   `json:"owned"`
+}
+
+// UnitsOwnership returns the ownership status of each queried unit for the user.
+// Unlike VerifyOwnership, it reports which of the queried units user owns
+// instead of failing when user does not own at least one of them. isAdmin has
+// the same meaning as in VerifyOwnership.
+func UnitsOwnership(
+	ctx context.Context,
+	user string,
+	isAdmin bool,
+	clusterIDs []string,
+	uuids []string,
+	starts []int64,
+	db *sql.DB,
+	logger *slog.Logger,
+) ([]UnitOwnership, error) {
+	ownership := make([]UnitOwnership, len(uuids))
+	for i, uuid := range uuids {
+		ownership[i] = UnitOwnership{UUID: uuid}
+	}
+
+	// Admin users own all units. If no DB connection is provided, pass the check
+	// just like VerifyOwnership.
+	if db == nil || isAdmin || slices.Contains(adminUsers(ctx, db, logger), user) {
+		for i := range ownership {
+			ownership[i].Owned = true
+		}
+
+		return ownership, nil
+	}
+
+	// If the data is incomplete, user does not own any unit
+	if len(clusterIDs) == 0 || user == "" || len(uuids) == 0 {
+		return ownership, nil
+	}
+
+	units, err := Querier[models.Unit](ctx, db, ownershipQuery(ownershipTable(ctx, db, starts, logger), user, clusterIDs, uuids, starts), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ownership {
+		ownership[i].Owned = slices.ContainsFunc(units, func(u models.Unit) bool { return u.UUID == ownership[i].UUID })
+	}
+
+	return ownership, nil
+}
@@ -81,13 +81,18 @@ INSERT INTO users VALUES(4, 'rm-1', 'usr1', '["prj1"]');
 INSERT INTO users VALUES(5, 'rm-1', 'usr2', '["prj1"]');
 INSERT INTO users VALUES(6, 'rm-1', 'usr4', '["prj4"]');
 INSERT INTO users VALUES(7, 'rm-1', 'usr5', '["prj5"]');
-CREATE TABLE admin_users (
+CREATE TABLE roles (
 	"id" integer not null primary key,
-	"source" text,
-	"users" text
+	"username" text,
+	"role" text,
+	"source" text
 );
-INSERT INTO admin_users VALUES(1, 'ceems', '["adm1","adm2","adm3"]');
-INSERT INTO admin_users VALUES(2, 'grafana', '["adm4","adm5","adm6"]');
+INSERT INTO roles VALUES(1, 'adm1', 'admin', 'ceems');
+INSERT INTO roles VALUES(2, 'adm2', 'admin', 'ceems');
+INSERT INTO roles VALUES(3, 'adm3', 'admin', 'ceems');
+INSERT INTO roles VALUES(4, 'adm4', 'admin', 'grafana');
+INSERT INTO roles VALUES(5, 'adm5', 'admin', 'grafana');
+INSERT INTO roles VALUES(6, 'adm6', 'admin', 'grafana');
 COMMIT;`
 
 	_, err = db.Exec(stmts)
@@ -108,6 +113,7 @@ func TestVerifyOwnership(t *testing.T) {
 		uuids  []string
 		starts []int64
 		user   string
+		admin  bool
 		verify bool
 	}{
 		{
@@ -172,6 +178,14 @@ func TestVerifyOwnership(t *testing.T) {
 			user:   "adm1",
 			verify: true,
 		},
+		{
+			name:   "pass due to admin from other sources",
+			uuids:  []string{"1479765", "123"},
+			rmID:   "rm-1",
+			user:   "usr3",
+			admin:  true,
+			verify: true,
+		},
 		{
 			name:   "pass due to no uuid",
 			uuids:  []string{},
@@ -185,6 +199,7 @@ func TestVerifyOwnership(t *testing.T) {
 		result := VerifyOwnership(
 			context.Background(),
 			test.user,
+			test.admin,
 			[]string{test.rmID},
 			test.uuids,
 			test.starts,
@@ -195,6 +210,40 @@ func TestVerifyOwnership(t *testing.T) {
 	}
 }
 
+func TestUnitsOwnership(t *testing.T) {
+	db, err := setupMockDB(t.TempDir())
+	require.NoError(t, err, "failed to setup test DB")
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Only units of same project must be owned
+	ownership, err := UnitsOwnership(
+		context.Background(), "usr1", false, []string{"rm-0"}, []string{"1479763", "1481508", "1479765", "123"}, nil, db, logger,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []UnitOwnership{
+		{UUID: "1479763", Owned: true},
+		{UUID: "1481508", Owned: true},
+		{UUID: "1479765", Owned: false},
+		{UUID: "123", Owned: false},
+	}, ownership)
+
+	// Admin users own all units
+	ownership, err = UnitsOwnership(context.Background(), "adm1", false, nil, []string{"1479765", "123"}, nil, db, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []UnitOwnership{{UUID: "1479765", Owned: true}, {UUID: "123", Owned: true}}, ownership)
+
+	// Admin users from other sources own all units as well
+	ownership, err = UnitsOwnership(context.Background(), "usr3", true, nil, []string{"1479765", "123"}, nil, db, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []UnitOwnership{{UUID: "1479765", Owned: true}, {UUID: "123", Owned: true}}, ownership)
+
+	// Without cluster IDs, no units are owned
+	ownership, err = UnitsOwnership(context.Background(), "usr1", false, nil, []string{"1479763"}, nil, db, logger)
+	require.NoError(t, err)
+	assert.Equal(t, []UnitOwnership{{UUID: "1479763", Owned: false}}, ownership)
+}
+
 func TestAdminUsers(t *testing.T) {
 	db, err := setupMockDB(t.TempDir())
 	require.NoError(t, err, "failed to setup test DB")
@@ -6,50 +6,64 @@ import (
 )
 
 const (
-	unitsTableName      = "units"
-	usageTableName      = "usage"
-	dailyUsageTableName = "daily_usage"
-	projectsTableName   = "projects"
-	usersTableName      = "users"
-	adminUsersTableName = "admin_users"
+	unitsTableName        = "units"
+	usageTableName        = "usage"
+	dailyUsageTableName   = "daily_usage"
+	monthlyUsageTableName = "monthly_usage"
+	projectsTableName     = "projects"
+	usersTableName        = "users"
+	rolesTableName        = "roles"
+	nodesTableName        = "nodes"
+	annotationsTableName  = "annotations"
+	reservationsTableName = "reservations"
+	preemptionsTableName  = "preemptions"
+	apiKeysTableName      = "api_keys"
+	quotasTableName       = "quotas"
+	provisionedTableName  = "provisioned_configs"
+	unitNodesTableName    = "unit_nodes"
+	unitStepsTableName    = "unit_steps"
+	unitScriptsTableName  = "unit_scripts"
 )
 
 // Unit is an abstract compute unit that can mean Job (batchjobs), VM (cloud) or Pod (k8s).
 type Unit struct {
-	ID                  int64      `json:"-"                                    sql:"id"                         sqlitetype:"integer not null primary key"`
-	ClusterID           string     `json:"cluster_id,omitempty"                 sql:"cluster_id"                 sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
-	ResourceManager     string     `json:"resource_manager,omitempty"           sql:"resource_manager"           sqlitetype:"text"`    // Name of the resource manager that owns compute unit. Eg slurm, openstack, kubernetes, etc
-	UUID                string     `json:"uuid"                                 sql:"uuid"                       sqlitetype:"text"`    // Unique identifier of unit. It can be Job ID for batch jobs, UUID for pods in k8s or VMs in Openstack
-	Name                string     `json:"name,omitempty"                       sql:"name"                       sqlitetype:"text"`    // Name of compute unit
-	Project             string     `json:"project,omitempty"                    sql:"project"                    sqlitetype:"text"`    // Account in batch systems, Tenant in Openstack, Namespace in k8s
-	Group               string     `json:"groupname,omitempty"                  sql:"groupname"                  sqlitetype:"text"`    // User group
-	User                string     `json:"username,omitempty"                   sql:"username"                   sqlitetype:"text"`    // Username
-	CreatedAt           string     `json:"created_at,omitempty"                 sql:"created_at"                 sqlitetype:"text"`    // Creation time
-	StartedAt           string     `json:"started_at,omitempty"                 sql:"started_at"                 sqlitetype:"text"`    // Start time
-	EndedAt             string     `json:"ended_at,omitempty"                   sql:"ended_at"                   sqlitetype:"text"`    // End time
-	CreatedAtTS         int64      `json:"created_at_ts,omitempty"              sql:"created_at_ts"              sqlitetype:"integer"` // Creation timestamp
-	StartedAtTS         int64      `json:"started_at_ts,omitempty"              sql:"started_at_ts"              sqlitetype:"integer"` // Start timestamp
-	EndedAtTS           int64      `json:"ended_at_ts,omitempty"                sql:"ended_at_ts"                sqlitetype:"integer"` // End timestamp
-	Elapsed             string     `json:"elapsed,omitempty"                    sql:"elapsed"                    sqlitetype:"text"`    // Human readable total elapsed time string
-	State               string     `json:"state,omitempty"                      sql:"state"                      sqlitetype:"text"`    // Current state of unit
-	Allocation          Allocation `json:"allocation,omitempty"                 sql:"allocation"                 sqlitetype:"text"`    // Allocation map of unit. Only string and int64 values are supported in map
-	TotalTime           MetricMap  `json:"total_time_seconds,omitempty"         sql:"total_time_seconds"         sqlitetype:"text"`    // Different types of times in seconds consumed by the unit. This map contains at minimum `walltime`, `alloc_cputime`, `alloc_cpumemtime`, `alloc_gputime` and `alloc_gpumem_time` keys.
-	AveCPUUsage         MetricMap  `json:"avg_cpu_usage,omitempty"              sql:"avg_cpu_usage"              sqlitetype:"text"`    // Average CPU usage(s) during lifetime of unit
-	AveCPUMemUsage      MetricMap  `json:"avg_cpu_mem_usage,omitempty"          sql:"avg_cpu_mem_usage"          sqlitetype:"text"`    // Average CPU memory usage(s) during lifetime of unit
-	TotalCPUEnergyUsage MetricMap  `json:"total_cpu_energy_usage_kwh,omitempty" sql:"total_cpu_energy_usage_kwh" sqlitetype:"text"`    // Total CPU energy usage(s) in kWh during lifetime of unit
-	TotalCPUEmissions   MetricMap  `json:"total_cpu_emissions_gms,omitempty"    sql:"total_cpu_emissions_gms"    sqlitetype:"text"`    // Total CPU emissions from source(s) in grams during lifetime of unit
-	AveGPUUsage         MetricMap  `json:"avg_gpu_usage,omitempty"              sql:"avg_gpu_usage"              sqlitetype:"text"`    // Average GPU usage(s) during lifetime of unit
-	AveGPUMemUsage      MetricMap  `json:"avg_gpu_mem_usage,omitempty"          sql:"avg_gpu_mem_usage"          sqlitetype:"text"`    // Average GPU memory usage(s) during lifetime of unit
-	TotalGPUEnergyUsage MetricMap  `json:"total_gpu_energy_usage_kwh,omitempty" sql:"total_gpu_energy_usage_kwh" sqlitetype:"text"`    // Total GPU energy usage(s) in kWh during lifetime of unit
-	TotalGPUEmissions   MetricMap  `json:"total_gpu_emissions_gms,omitempty"    sql:"total_gpu_emissions_gms"    sqlitetype:"text"`    // Total GPU emissions from source(s) in grams during lifetime of unit
-	TotalIOWriteStats   MetricMap  `json:"total_io_write_stats,omitempty"       sql:"total_io_write_stats"       sqlitetype:"text"`    // Total IO write statistics during lifetime of unit
-	TotalIOReadStats    MetricMap  `json:"total_io_read_stats,omitempty"        sql:"total_io_read_stats"        sqlitetype:"text"`    // Total IO read statistics GB during lifetime of unit
-	TotalIngressStats   MetricMap  `json:"total_ingress_stats,omitempty"        sql:"total_ingress_stats"        sqlitetype:"text"`    // Total Ingress statistics of unit
-	TotalOutgressStats  MetricMap  `json:"total_outgress_stats,omitempty"       sql:"total_outgress_stats"       sqlitetype:"text"`    // Total Outgress statistics of unit
-	Tags                Tag        `json:"tags,omitempty"                       sql:"tags"                       sqlitetype:"text"`    // A map to store generic info. String and int64 are valid value types of map
-	Ignore              int        `json:"-"                                    sql:"ignore"                     sqlitetype:"integer"` // Whether to ignore unit
-	NumUpdates          int64      `json:"-"                                    sql:"num_updates"                sqlitetype:"integer"` // Number of updates. This is used internally to update aggregate metrics
-	LastUpdatedAt       string     `json:"-"                                    sql:"last_updated_at"            sqlitetype:"text"`    // Last updated time. It can be used to clean up DB
+	ID                  int64       `json:"-"                                    sql:"id"                         sqlitetype:"integer not null primary key"`
+	ClusterID           string      `json:"cluster_id,omitempty"                 sql:"cluster_id"                 sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager     string      `json:"resource_manager,omitempty"           sql:"resource_manager"           sqlitetype:"text"`    // Name of the resource manager that owns compute unit. Eg slurm, openstack, kubernetes, etc
+	UUID                string      `json:"uuid"                                 sql:"uuid"                       sqlitetype:"text"`    // Unique identifier of unit. It can be Job ID for batch jobs, UUID for pods in k8s or VMs in Openstack
+	Name                string      `json:"name,omitempty"                       sql:"name"                       sqlitetype:"text"`    // Name of compute unit
+	Project             string      `json:"project,omitempty"                    sql:"project"                    sqlitetype:"text"`    // Account in batch systems, Tenant in Openstack, Namespace in k8s
+	Group               string      `json:"groupname,omitempty"                  sql:"groupname"                  sqlitetype:"text"`    // User group
+	User                string      `json:"username,omitempty"                   sql:"username"                   sqlitetype:"text"`    // Username
+	CreatedAt           string      `json:"created_at,omitempty"                 sql:"created_at"                 sqlitetype:"text"`    // Creation time
+	StartedAt           string      `json:"started_at,omitempty"                 sql:"started_at"                 sqlitetype:"text"`    // Start time
+	EndedAt             string      `json:"ended_at,omitempty"                   sql:"ended_at"                   sqlitetype:"text"`    // End time
+	CreatedAtTS         int64       `json:"created_at_ts,omitempty"              sql:"created_at_ts"              sqlitetype:"integer"` // Creation timestamp
+	StartedAtTS         int64       `json:"started_at_ts,omitempty"              sql:"started_at_ts"              sqlitetype:"integer"` // Start timestamp
+	EndedAtTS           int64       `json:"ended_at_ts,omitempty"                sql:"ended_at_ts"                sqlitetype:"integer"` // End timestamp
+	Elapsed             string      `json:"elapsed,omitempty"                    sql:"elapsed"                    sqlitetype:"text"`    // Human readable total elapsed time string
+	State               string      `json:"state,omitempty"                      sql:"state"                      sqlitetype:"text"`    // Current state of unit
+	Allocation          Allocation  `json:"allocation,omitempty"                 sql:"allocation"                 sqlitetype:"text"`    // Allocation map of unit. Only string and int64 values are supported in map
+	TotalTime           MetricMap   `json:"total_time_seconds,omitempty"         sql:"total_time_seconds"         sqlitetype:"text"`    // Different types of times in seconds consumed by the unit. This map contains at minimum `walltime`, `alloc_cputime`, `alloc_cpumemtime`, `alloc_gputime` and `alloc_gpumem_time` keys.
+	AveCPUUsage         MetricMap   `json:"avg_cpu_usage,omitempty"              sql:"avg_cpu_usage"              sqlitetype:"text"`    // Average CPU usage(s) during lifetime of unit
+	AveCPUMemUsage      MetricMap   `json:"avg_cpu_mem_usage,omitempty"          sql:"avg_cpu_mem_usage"          sqlitetype:"text"`    // Average CPU memory usage(s) during lifetime of unit
+	TotalCPUEnergyUsage MetricMap   `json:"total_cpu_energy_usage_kwh,omitempty" sql:"total_cpu_energy_usage_kwh" sqlitetype:"text"`    // Total CPU energy usage(s) in kWh during lifetime of unit
+	TotalCPUEmissions   MetricMap   `json:"total_cpu_emissions_gms,omitempty"    sql:"total_cpu_emissions_gms"    sqlitetype:"text"`    // Total CPU emissions from source(s) in grams during lifetime of unit
+	AveGPUUsage         MetricMap   `json:"avg_gpu_usage,omitempty"              sql:"avg_gpu_usage"              sqlitetype:"text"`    // Average GPU usage(s) during lifetime of unit
+	AveGPUMemUsage      MetricMap   `json:"avg_gpu_mem_usage,omitempty"          sql:"avg_gpu_mem_usage"          sqlitetype:"text"`    // Average GPU memory usage(s) during lifetime of unit
+	TotalGPUEnergyUsage MetricMap   `json:"total_gpu_energy_usage_kwh,omitempty" sql:"total_gpu_energy_usage_kwh" sqlitetype:"text"`    // Total GPU energy usage(s) in kWh during lifetime of unit
+	TotalGPUEmissions   MetricMap   `json:"total_gpu_emissions_gms,omitempty"    sql:"total_gpu_emissions_gms"    sqlitetype:"text"`    // Total GPU emissions from source(s) in grams during lifetime of unit
+	TotalIOWriteStats   MetricMap   `json:"total_io_write_stats,omitempty"       sql:"total_io_write_stats"       sqlitetype:"text"`    // Total IO write statistics during lifetime of unit
+	TotalIOReadStats    MetricMap   `json:"total_io_read_stats,omitempty"        sql:"total_io_read_stats"        sqlitetype:"text"`    // Total IO read statistics GB during lifetime of unit
+	TotalIngressStats   MetricMap   `json:"total_ingress_stats,omitempty"        sql:"total_ingress_stats"        sqlitetype:"text"`    // Total Ingress statistics of unit
+	TotalOutgressStats  MetricMap   `json:"total_outgress_stats,omitempty"       sql:"total_outgress_stats"       sqlitetype:"text"`    // Total Outgress statistics of unit
+	Completeness        MetricMap   `json:"completeness,omitempty"               sql:"completeness"               sqlitetype:"text"`    // Fraction of aggregate metrics of unit resolved from TSDB. This map contains a fraction for each metric and an overall `score`
+	Tags                Tag         `json:"tags,omitempty"                       sql:"tags"                       sqlitetype:"text"`    // A map to store generic info. String and int64 are valid value types of map
+	Ignore              int         `json:"-"                                    sql:"ignore"                     sqlitetype:"integer"` // Whether to ignore unit
+	NumUpdates          int64       `json:"-"                                    sql:"num_updates"                sqlitetype:"integer"` // Number of updates. This is used internally to update aggregate metrics
+	LastUpdatedAt       string      `json:"-"                                    sql:"last_updated_at"            sqlitetype:"text"`    // Last updated time. It can be used to clean up DB
+	Steps               []UnitStep  `json:"-"                                    sql:"-"`                                               // Steps of unit. They are stored in unit steps table
+	Script              *UnitScript `json:"-"                                    sql:"-"`                                               // Script of unit. It is stored in unit scripts table
 }
 
 // TableName returns the table which units are stored into.
@@ -120,6 +134,16 @@ func (DailyUsage) TableName() string {
 	return dailyUsageTableName
 }
 
+// MonthlyUsage statistics of each project/tenant/namespace.
+type MonthlyUsage struct {
+	Usage
+}
+
+// TableName returns the table which usage stats are stored into.
+func (MonthlyUsage) TableName() string {
+	return monthlyUsageTableName
+}
+
 // Stat represents high level statistics of each cluster.
 type Stat struct {
 	ClusterID        string `json:"cluster_id"         sql:"cluster_id"         sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit. It is used to differentiate multiple clusters of same resource manager.
@@ -142,6 +166,311 @@ func (s Stat) TagMap(keyTag string, valueTag string) map[string]string {
 	return structset.StructFieldTagMap(s, keyTag, valueTag)
 }
 
+// Node is the container for energy usage and emissions of a compute node
+// during a given period independent of compute units.
+type Node struct {
+	ID               int64     `json:"-"                                sql:"id"                     sqlitetype:"integer not null primary key"`
+	ClusterID        string    `json:"cluster_id"                       sql:"cluster_id"             sqlitetype:"text"`    // Identifier of the resource manager that owns node. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager  string    `json:"resource_manager"                 sql:"resource_manager"       sqlitetype:"text"`    // Name of the resource manager that owns node. Eg slurm, openstack, kubernetes, etc
+	Hostname         string    `json:"hostname"                         sql:"hostname"               sqlitetype:"text"`    // Hostname of the node
+	PeriodStart      string    `json:"period_start"                     sql:"period_start"           sqlitetype:"text"`    // Start time of the aggregation period
+	PeriodStartTS    int64     `json:"period_start_ts"                  sql:"period_start_ts"        sqlitetype:"integer"` // Start timestamp of the aggregation period
+	TotalTime        MetricMap `json:"total_time_seconds,omitempty"     sql:"total_time_seconds"     sqlitetype:"text"`    // Time in seconds for which node metrics have been aggregated during the period. Contains `walltime` key.
+	TotalEnergyUsage MetricMap `json:"total_energy_usage_kwh,omitempty" sql:"total_energy_usage_kwh" sqlitetype:"text"`    // Total energy usage(s) from source(s) in kWh during the period
+	TotalEmissions   MetricMap `json:"total_emissions_gms,omitempty"    sql:"total_emissions_gms"    sqlitetype:"text"`    // Total emissions from source(s) in grams during the period
+	NumUpdates       int64     `json:"-"                                sql:"num_updates"            sqlitetype:"integer"` // Number of updates
+	LastUpdatedAt    string    `json:"-"                                sql:"last_updated_at"        sqlitetype:"text"`    // Last updated time
+}
+
+// TableName returns the table which node stats are stored into.
+func (Node) TableName() string {
+	return nodesTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (n Node) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(n, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (n Node) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(n, keyTag, valueTag)
+}
+
+// Annotation is the container for a free text note attached to a compute unit.
+type Annotation struct {
+	ID        int64  `json:"-"          sql:"id"         sqlitetype:"integer not null primary key"`
+	ClusterID string `json:"cluster_id" sql:"cluster_id" sqlitetype:"text"` // Identifier of the resource manager that owns compute unit.
+	UUID      string `json:"uuid"       sql:"uuid"       sqlitetype:"text"` // Unique identifier of compute unit
+	Author    string `json:"author"     sql:"author"     sqlitetype:"text"` // Name of the user who made the annotation
+	Note      string `json:"note"       sql:"note"       sqlitetype:"text"` // Free text note
+	CreatedAt string `json:"created_at" sql:"created_at" sqlitetype:"text"` // Creation time of annotation
+}
+
+// TableName returns the table which annotations are stored into.
+func (Annotation) TableName() string {
+	return annotationsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (a Annotation) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(a, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (a Annotation) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(a, keyTag, valueTag)
+}
+
+// Reservation is the container for the usage of resources reserved in a cluster.
+// Reserved, allocated and idle times are in TRES-seconds and keyed by TRES names.
+type Reservation struct {
+	ID              int64      `json:"-"                               sql:"id"                     sqlitetype:"integer not null primary key"`
+	ClusterID       string     `json:"cluster_id"                      sql:"cluster_id"             sqlitetype:"text"`    // Identifier of the resource manager that owns reservation.
+	ResourceManager string     `json:"resource_manager"                sql:"resource_manager"       sqlitetype:"text"`    // Name of the resource manager that owns reservation. Eg slurm
+	Name            string     `json:"name"                            sql:"name"                   sqlitetype:"text"`    // Name of the reservation
+	Project         string     `json:"project"                         sql:"project"                sqlitetype:"text"`    // Project that reserved the resources
+	StartedAt       string     `json:"started_at"                      sql:"started_at"             sqlitetype:"text"`    // Start time of reservation
+	EndedAt         string     `json:"ended_at"                        sql:"ended_at"               sqlitetype:"text"`    // End time of reservation
+	StartedAtTS     int64      `json:"started_at_ts"                   sql:"started_at_ts"          sqlitetype:"integer"` // Start timestamp of reservation
+	EndedAtTS       int64      `json:"ended_at_ts"                     sql:"ended_at_ts"            sqlitetype:"integer"` // End timestamp of reservation
+	Nodes           string     `json:"nodes"                           sql:"nodes"                  sqlitetype:"text"`    // Nodes in the reservation
+	Allocation      Allocation `json:"allocation,omitempty"            sql:"allocation"             sqlitetype:"text"`    // Count of each reserved TRES
+	ReservedTime    MetricMap  `json:"reserved_time_seconds,omitempty"  sql:"reserved_time_seconds"  sqlitetype:"text"`   // Reserved time of each TRES
+	AllocatedTime   MetricMap  `json:"allocated_time_seconds,omitempty" sql:"allocated_time_seconds" sqlitetype:"text"`   // Time each TRES was allocated to compute units
+	IdleTime        MetricMap  `json:"idle_time_seconds,omitempty"      sql:"idle_time_seconds"      sqlitetype:"text"`   // Time each TRES was reserved but unused
+	NumUpdates      int64      `json:"-"                               sql:"num_updates"            sqlitetype:"integer"` // Number of updates
+	LastUpdatedAt   string     `json:"-"                               sql:"last_updated_at"        sqlitetype:"text"`    // Last updated time
+}
+
+// TableName returns the table which reservations are stored into.
+func (Reservation) TableName() string {
+	return reservationsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (r Reservation) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(r, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (r Reservation) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(r, keyTag, valueTag)
+}
+
+// Preemption is the container for a preemption event of a compute unit.
+type Preemption struct {
+	ID              int64      `json:"-"                    sql:"id"               sqlitetype:"integer not null primary key"`
+	ClusterID       string     `json:"cluster_id"           sql:"cluster_id"       sqlitetype:"text"`    // Identifier of the resource manager that owns compute unit.
+	ResourceManager string     `json:"resource_manager"     sql:"resource_manager" sqlitetype:"text"`    // Name of the resource manager that owns compute unit. Eg slurm
+	UUID            string     `json:"uuid"                 sql:"uuid"             sqlitetype:"text"`    // Unique identifier of compute unit
+	Name            string     `json:"name"                 sql:"name"             sqlitetype:"text"`    // Name of compute unit
+	Project         string     `json:"project"              sql:"project"          sqlitetype:"text"`    // Project of compute unit
+	Group           string     `json:"groupname"            sql:"groupname"        sqlitetype:"text"`    // User group
+	User            string     `json:"username"             sql:"username"         sqlitetype:"text"`    // Username
+	StartedAt       string     `json:"started_at"           sql:"started_at"       sqlitetype:"text"`    // Start time of compute unit
+	PreemptedAt     string     `json:"preempted_at"         sql:"preempted_at"     sqlitetype:"text"`    // Preemption time
+	PreemptedAtTS   int64      `json:"preempted_at_ts"      sql:"preempted_at_ts"  sqlitetype:"integer"` // Preemption timestamp
+	Elapsed         string     `json:"elapsed"              sql:"elapsed"          sqlitetype:"text"`    // Human readable elapsed time of compute unit before preemption
+	Allocation      Allocation `json:"allocation,omitempty" sql:"allocation"       sqlitetype:"text"`    // Allocation of compute unit
+	Tags            Tag        `json:"tags,omitempty"       sql:"tags"             sqlitetype:"text"`    // Meta data tags of compute unit
+	LastUpdatedAt   string     `json:"-"                    sql:"last_updated_at"  sqlitetype:"text"`    // Last updated time
+}
+
+// TableName returns the table which preemptions are stored into.
+func (Preemption) TableName() string {
+	return preemptionsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (p Preemption) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(p, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (p Preemption) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(p, keyTag, valueTag)
+}
+
+// APIKey is the container for an API key of a service account. Only the hash of
+// the key is stored in the DB and the key itself is returned only once when it is
+// created.
+type APIKey struct {
+	ID          int64  `json:"id"                   sql:"id"            sqlitetype:"integer not null primary key"`
+	Name        string `json:"name"                 sql:"name"          sqlitetype:"text"`    // Name of the key
+	User        string `json:"username"             sql:"username"      sqlitetype:"text"`    // Username that is identified by the key
+	Role        string `json:"role"                 sql:"role"          sqlitetype:"text"`    // Role of the key. Either user or admin
+	Hash        string `json:"-"                    sql:"key_hash"      sqlitetype:"text"`    // SHA-256 hash of the key
+	CreatedBy   string `json:"created_by"           sql:"created_by"    sqlitetype:"text"`    // Admin user who created the key
+	CreatedAt   string `json:"created_at"           sql:"created_at"    sqlitetype:"text"`    // Creation time of the key
+	ExpiresAtTS int64  `json:"expires_at_ts"        sql:"expires_at_ts" sqlitetype:"integer"` // Expiry timestamp of the key. Zero means key never expires
+	Key         string `json:"key,omitempty"        sql:"-"`                                  // Key. Only set in the response when key is created
+}
+
+// TableName returns the table which API keys are stored into.
+func (APIKey) TableName() string {
+	return apiKeysTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (k APIKey) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(k, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (k APIKey) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(k, keyTag, valueTag)
+}
+
+// Quota is the container for resources allocated to a project. Consumption of the
+// project is estimated from usage statistics within the quota period and it is
+// not stored in the DB.
+type Quota struct {
+	ID        int64     `json:"id"                      sql:"id"          sqlitetype:"integer not null primary key"`
+	ClusterID string    `json:"cluster_id"              sql:"cluster_id"  sqlitetype:"text"`    // Identifier of the resource manager that owns project
+	Project   string    `json:"project"                 sql:"project"     sqlitetype:"text"`    // Name of the project
+	CPUHours  float64   `json:"cpu_hours"               sql:"cpu_hours"   sqlitetype:"real"`    // Allocated CPU hours. Zero means no limit
+	GPUHours  float64   `json:"gpu_hours"               sql:"gpu_hours"   sqlitetype:"real"`    // Allocated GPU hours. Zero means no limit
+	EnergyKWh float64   `json:"energy_kwh"              sql:"energy_kwh"  sqlitetype:"real"`    // Allocated energy budget in kWh. Zero means no limit
+	StartTS   int64     `json:"start_ts"                sql:"start_ts"    sqlitetype:"integer"` // Start timestamp of quota period. Zero means since beginning
+	EndTS     int64     `json:"end_ts"                  sql:"end_ts"      sqlitetype:"integer"` // End timestamp of quota period. Zero means no end
+	UpdatedBy string    `json:"updated_by"              sql:"updated_by"  sqlitetype:"text"`    // Admin user who last updated the quota
+	UpdatedAt string    `json:"updated_at"              sql:"updated_at"  sqlitetype:"text"`    // Last updated time of the quota
+	Consumed  MetricMap `json:"consumed,omitempty"      sql:"-"`                                // Resources consumed by project during quota period
+	Fraction  MetricMap `json:"used_fraction,omitempty" sql:"-"`                                // Fraction of allocated resources consumed by project
+}
+
+// TableName returns the table which quotas are stored into.
+func (Quota) TableName() string {
+	return quotasTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (q Quota) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(q, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (q Quota) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(q, keyTag, valueTag)
+}
+
+// Kinds of provisioned configs.
+const (
+	ProvisionedCluster = "cluster"
+	ProvisionedUpdater = "updater"
+)
+
+// ProvisionedConfig is the config of a cluster or an updater instance that is
+// provisioned using API server.
+type ProvisionedConfig struct {
+	ID        int64          `json:"-"                sql:"id"         sqlitetype:"integer not null primary key"`
+	Kind      string         `json:"kind"             sql:"kind"       sqlitetype:"text"` // Kind of config. Either cluster or updater
+	ConfigID  string         `json:"id"               sql:"config_id"  sqlitetype:"text"` // ID of cluster or updater instance
+	Type      string         `json:"type"             sql:"type"       sqlitetype:"text"` // Name of resource manager or updater
+	Config    string         `json:"-"                sql:"config"     sqlitetype:"text"` // Raw config in same format as config file
+	Spec      map[string]any `json:"config,omitempty" sql:"-"`                            // Config with secrets redacted
+	UpdatedBy string         `json:"updated_by"       sql:"updated_by" sqlitetype:"text"` // Admin user who last updated the config
+	UpdatedAt string         `json:"updated_at"       sql:"updated_at" sqlitetype:"text"` // Last updated time of the config
+}
+
+// TableName returns the table which provisioned configs are stored into.
+func (ProvisionedConfig) TableName() string {
+	return provisionedTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (p ProvisionedConfig) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(p, tag)
+}
+
+// UnitNode is the node on which a compute unit has run. It normalizes the
+// node list of units so that units can be looked up by node.
+type UnitNode struct {
+	UnitID int64  `json:"-"    sql:"unit_id" sqlitetype:"integer not null"` // ID of unit in units table
+	Node   string `json:"node" sql:"node"    sqlitetype:"text not null"`    // Name of node
+}
+
+// TableName returns the table which unit nodes are stored into.
+func (UnitNode) TableName() string {
+	return unitNodesTableName
+}
+
+// UnitStep is a step of compute unit, like job steps of SLURM. Steps are
+// stored as child rows of units so that resources consumed by each step of
+// a unit can be looked up.
+type UnitStep struct {
+	UnitID        int64      `json:"-"                            sql:"unit_id"            sqlitetype:"integer not null"` // ID of unit in units table
+	StepID        string     `json:"step_id"                      sql:"step_id"            sqlitetype:"text not null"`    // Identifier of step within unit. Eg 0, batch, extern
+	Name          string     `json:"name,omitempty"               sql:"name"               sqlitetype:"text"`             // Name of step
+	State         string     `json:"state,omitempty"              sql:"state"              sqlitetype:"text"`             // Current state of step
+	StartedAt     string     `json:"started_at,omitempty"         sql:"started_at"         sqlitetype:"text"`             // Start time
+	EndedAt       string     `json:"ended_at,omitempty"           sql:"ended_at"           sqlitetype:"text"`             // End time
+	StartedAtTS   int64      `json:"started_at_ts,omitempty"      sql:"started_at_ts"      sqlitetype:"integer"`          // Start timestamp
+	EndedAtTS     int64      `json:"ended_at_ts,omitempty"        sql:"ended_at_ts"        sqlitetype:"integer"`          // End timestamp
+	Elapsed       string     `json:"elapsed,omitempty"            sql:"elapsed"            sqlitetype:"text"`             // Human readable total elapsed time string
+	ExitCode      string     `json:"exit_code,omitempty"          sql:"exit_code"          sqlitetype:"text"`             // Exit code of step
+	Nodelist      string     `json:"nodelist,omitempty"           sql:"nodelist"           sqlitetype:"text"`             // Nodes on which step has run
+	Allocation    Allocation `json:"allocation,omitempty"         sql:"allocation"         sqlitetype:"text"`             // Allocation map of step
+	TotalTime     MetricMap  `json:"total_time_seconds,omitempty" sql:"total_time_seconds" sqlitetype:"text"`             // Different types of times in seconds consumed by the step during its lifetime
+	LastUpdatedAt string     `json:"-"                            sql:"last_updated_at"    sqlitetype:"text"`             // Last updated time
+}
+
+// TableName returns the table which unit steps are stored into.
+func (UnitStep) TableName() string {
+	return unitStepsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (s UnitStep) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(s, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (s UnitStep) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(s, keyTag, valueTag)
+}
+
+// UnitScript is the script and environment with which a compute unit, like
+// a batch job, has been submitted. Scripts and environments larger than the
+// configured limit are truncated.
+type UnitScript struct {
+	UnitID        int64  `json:"-"                     sql:"unit_id"         sqlitetype:"integer not null primary key"` // ID of unit in units table
+	Script        string `json:"script"                sql:"script"          sqlitetype:"text"`                         // Script of unit
+	Environment   string `json:"environment,omitempty" sql:"environment"     sqlitetype:"text"`                         // Environment variables of unit
+	Truncated     bool   `json:"truncated"             sql:"truncated"       sqlitetype:"integer"`                      // Whether script or environment has been truncated
+	LastUpdatedAt string `json:"-"                     sql:"last_updated_at" sqlitetype:"text"`                         // Last updated time
+}
+
+// TableName returns the table which unit scripts are stored into.
+func (UnitScript) TableName() string {
+	return unitScriptsTableName
+}
+
+// TagNames returns a slice of all tag names.
+func (s UnitScript) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(s, tag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (s UnitScript) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(s, keyTag, valueTag)
+}
+
+// TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
+// field names are used as map keys.
+func (p ProvisionedConfig) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(p, keyTag, valueTag)
+}
+
 // Project is the container for a given account/tenant/namespace of cluster.
 type Project struct {
 	ID              int64  `json:"-"                sql:"id"               sqlitetype:"integer not null primary key"`
@@ -172,14 +501,15 @@ func (p Project) TagMap(keyTag string, valueTag string) map[string]string {
 
 // User is the container for a given user of cluster.
 type User struct {
-	ID              int64  `json:"-"                sql:"id"               sqlitetype:"integer not null primary key"`
-	UID             string `json:"uid,omitempty"    sql:"uid"              sqlitetype:"text"` // Unique identifier of the user provided by cluster
-	ClusterID       string `json:"cluster_id"       sql:"cluster_id"       sqlitetype:"text"` // Identifier of the resource manager that owns user. It is used to differentiate multiple clusters of same resource manager.
-	ResourceManager string `json:"resource_manager" sql:"resource_manager" sqlitetype:"text"` // Name of the resource manager that owns user. Eg slurm, openstack, kubernetes, etc
-	Name            string `json:"name"             sql:"name"             sqlitetype:"text"` // Name of the user
-	Projects        List   `json:"projects"         sql:"projects"         sqlitetype:"text"` // List of projects of the user
-	Tags            List   `json:"tags,omitempty"   sql:"tags"             sqlitetype:"text"` // List of meta data tags of the user
-	LastUpdatedAt   string `json:"-"                sql:"last_updated_at"  sqlitetype:"text"` // Last Updated time
+	ID              int64  `json:"-"                          sql:"id"               sqlitetype:"integer not null primary key"`
+	UID             string `json:"uid,omitempty"              sql:"uid"              sqlitetype:"text"` // Unique identifier of the user provided by cluster
+	ClusterID       string `json:"cluster_id"                 sql:"cluster_id"       sqlitetype:"text"` // Identifier of the resource manager that owns user. It is used to differentiate multiple clusters of same resource manager.
+	ResourceManager string `json:"resource_manager"           sql:"resource_manager" sqlitetype:"text"` // Name of the resource manager that owns user. Eg slurm, openstack, kubernetes, etc
+	Name            string `json:"name"                       sql:"name"             sqlitetype:"text"` // Name of the user
+	Projects        List   `json:"projects"                   sql:"projects"         sqlitetype:"text"` // List of projects of the user
+	Tags            List   `json:"tags,omitempty"             sql:"tags"             sqlitetype:"text"` // List of meta data tags of the user
+	LastUpdatedAt   string `json:"-"                          sql:"last_updated_at"  sqlitetype:"text"` // Last Updated time
+	LastActivityAt  string `json:"last_activity_at,omitempty" sql:"last_activity_at"`                   // Creation or end time of most recent compute unit of user. It is not stored in DB and estimated from units table
 }
 
 // TableName returns the table which admin users list is stored into.
@@ -198,28 +528,31 @@ func (u User) TagMap(keyTag string, valueTag string) map[string]string {
 	return structset.StructFieldTagMap(u, keyTag, valueTag)
 }
 
-// AdminUsers from different sources.
-type AdminUsers struct {
-	ID            int64  `json:"-"      sql:"id"              sqlitetype:"integer not null primary key"`
-	Source        string `json:"source" sql:"source"          sqlitetype:"text"` // Source of admin users
-	Users         List   `json:"users"  sql:"users"           sqlitetype:"text"` // List of users. In DB, users will be stored with | delimiter
-	LastUpdatedAt string `json:"-"      sql:"last_updated_at" sqlitetype:"text"` // Last Updated time
+// Role is the role of a user assigned by a source. Roles assigned using API
+// server take precedence over the ones synchronized from other sources.
+type Role struct {
+	ID            int64  `json:"-"               sql:"id"              sqlitetype:"integer not null primary key"`
+	User          string `json:"username"        sql:"username"        sqlitetype:"text"` // Username
+	Role          string `json:"role"            sql:"role"            sqlitetype:"text"` // Role of user. Either admin or user
+	Source        string `json:"source"          sql:"source"          sqlitetype:"text"` // Source of role. One of api, ceems, grafana and ldap
+	UpdatedBy     string `json:"updated_by"      sql:"updated_by"      sqlitetype:"text"` // Admin user who assigned the role. Only set for api source
+	LastUpdatedAt string `json:"last_updated_at" sql:"last_updated_at" sqlitetype:"text"` // Last updated time
 }
 
-// TableName returns the table which admin users list is stored into.
-func (AdminUsers) TableName() string {
-	return adminUsersTableName
+// TableName returns the table which roles are stored into.
+func (Role) TableName() string {
+	return rolesTableName
 }
 
 // TagNames returns a slice of all tag names.
-func (a AdminUsers) TagNames(tag string) []string {
-	return structset.StructFieldTagValues(a, tag)
+func (r Role) TagNames(tag string) []string {
+	return structset.StructFieldTagValues(r, tag)
 }
 
 // TagMap returns a map of tags based on keyTag and valueTag. If keyTag is empty,
 // field names are used as map keys.
-func (a AdminUsers) TagMap(keyTag string, valueTag string) map[string]string {
-	return structset.StructFieldTagMap(a, keyTag, valueTag)
+func (r Role) TagMap(keyTag string, valueTag string) map[string]string {
+	return structset.StructFieldTagMap(r, keyTag, valueTag)
 }
 
 // Key represents arbritrary keys used in metric maps.
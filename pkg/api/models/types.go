@@ -339,6 +339,18 @@ type ClusterUnits struct {
 	Units   []Unit
 }
 
+// ClusterNodes is the container for the node stats of a given cluster.
+type ClusterNodes struct {
+	Cluster Cluster
+	Nodes   []Node
+}
+
+// ClusterReservations is the container for the reservations of a given cluster.
+type ClusterReservations struct {
+	Cluster      Cluster
+	Reservations []Reservation
+}
+
 // ClusterProjects is the container for the projects for a given cluster.
 type ClusterProjects struct {
 	Cluster  Cluster
@@ -350,3 +362,41 @@ type ClusterUsers struct {
 	Cluster Cluster
 	Users   []User
 }
+
+// Sample is a single value of a time series.
+type Sample struct {
+	Timestamp int64     `json:"timestamp"` // Unix timestamp in milliseconds
+	Value     JSONFloat `json:"value"`
+}
+
+// LiveMetrics contains the recent time series of metrics of a running compute unit.
+type LiveMetrics struct {
+	ClusterID string                         `json:"cluster_id"`
+	UUID      string                         `json:"uuid"`
+	From      int64                          `json:"from"` // Unix timestamp in milliseconds
+	To        int64                          `json:"to"`   // Unix timestamp in milliseconds
+	Metrics   map[string]map[string][]Sample `json:"metrics"`
+}
+
+// NodeAnomaly is an event flagged on a compute node during a time window.
+type NodeAnomaly struct {
+	Kind      string `json:"kind"`              // Kind of anomaly. One of unit_failure, preemption and annotation
+	ClusterID string `json:"cluster_id"`        // Cluster ID of unit
+	UUID      string `json:"uuid,omitempty"`    // UUID of unit that the anomaly is related to
+	Time      string `json:"time"`              // Time of the anomaly
+	Message   string `json:"message,omitempty"` // Details of the anomaly like unit state or annotation note
+	Author    string `json:"author,omitempty"`  // Author of annotation
+}
+
+// NodeReport combines compute units, node stats, time series and anomalies
+// of a compute node in a time window for post-incident reviews.
+type NodeReport struct {
+	Hostname  string                         `json:"hostname"`
+	ClusterID string                         `json:"cluster_id"`
+	From      int64                          `json:"from"` // Unix timestamp in milliseconds
+	To        int64                          `json:"to"`   // Unix timestamp in milliseconds
+	Units     []Unit                         `json:"units"`
+	Stats     []Node                         `json:"stats"`
+	Series    map[string]map[string][]Sample `json:"series"`
+	Anomalies []NodeAnomaly                  `json:"anomalies"`
+}
@@ -0,0 +1,1487 @@
+// Protocol buffer definitions of gRPC API of CEEMS API server.
+//
+// The messages mirror the models of REST API and the RPCs are served by the
+// same handlers as the REST API. Hence, the semantics of the request fields are
+// identical to the query parameters of the corresponding REST endpoints.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.2
+// 	protoc        v5.29.2
+// source: ceems/v1/ceems.proto
+
+package ceemsv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// UsageMode is the kind of usage statistics.
+type UsageMode int32
+
+const (
+	// Defaults to current usage.
+	UsageMode_USAGE_MODE_UNSPECIFIED UsageMode = 0
+	// Usage between from and to.
+	UsageMode_USAGE_MODE_CURRENT UsageMode = 1
+	// Total usage during retention period.
+	UsageMode_USAGE_MODE_GLOBAL UsageMode = 2
+)
+
+// Enum value maps for UsageMode.
+var (
+	UsageMode_name = map[int32]string{
+		0: "USAGE_MODE_UNSPECIFIED",
+		1: "USAGE_MODE_CURRENT",
+		2: "USAGE_MODE_GLOBAL",
+	}
+	UsageMode_value = map[string]int32{
+		"USAGE_MODE_UNSPECIFIED": 0,
+		"USAGE_MODE_CURRENT":     1,
+		"USAGE_MODE_GLOBAL":      2,
+	}
+)
+
+func (x UsageMode) Enum() *UsageMode {
+	p := new(UsageMode)
+	*p = x
+	return p
+}
+
+func (x UsageMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UsageMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_ceems_v1_ceems_proto_enumTypes[0].Descriptor()
+}
+
+func (UsageMode) Type() protoreflect.EnumType {
+	return &file_ceems_v1_ceems_proto_enumTypes[0]
+}
+
+func (x UsageMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UsageMode.Descriptor instead.
+func (UsageMode) EnumDescriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{0}
+}
+
+// ListUnitsRequest is the request of ListUnits.
+type ListUnitsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Query units of any user. Requires admin privileges.
+	Admin bool `protobuf:"varint,1,opt,name=admin,proto3" json:"admin,omitempty"`
+	// Users of units. Only used when admin is true.
+	User      []string `protobuf:"bytes,2,rep,name=user,proto3" json:"user,omitempty"`
+	ClusterId []string `protobuf:"bytes,3,rep,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Project   []string `protobuf:"bytes,4,rep,name=project,proto3" json:"project,omitempty"`
+	Uuid      []string `protobuf:"bytes,5,rep,name=uuid,proto3" json:"uuid,omitempty"`
+	State     []string `protobuf:"bytes,6,rep,name=state,proto3" json:"state,omitempty"`
+	// Start of query window. Same formats as REST API are accepted.
+	From string `protobuf:"bytes,7,opt,name=from,proto3" json:"from,omitempty"`
+	// End of query window. Same formats as REST API are accepted.
+	To string `protobuf:"bytes,8,opt,name=to,proto3" json:"to,omitempty"`
+	// Include running units.
+	Running bool `protobuf:"varint,9,opt,name=running,proto3" json:"running,omitempty"`
+	// Fields to include in units. All fields are included when empty.
+	Field         []string `protobuf:"bytes,10,rep,name=field,proto3" json:"field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUnitsRequest) Reset() {
+	*x = ListUnitsRequest{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUnitsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUnitsRequest) ProtoMessage() {}
+
+func (x *ListUnitsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUnitsRequest.ProtoReflect.Descriptor instead.
+func (*ListUnitsRequest) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListUnitsRequest) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+func (x *ListUnitsRequest) GetUser() []string {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetClusterId() []string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetProject() []string {
+	if x != nil {
+		return x.Project
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetUuid() []string {
+	if x != nil {
+		return x.Uuid
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetState() []string {
+	if x != nil {
+		return x.State
+	}
+	return nil
+}
+
+func (x *ListUnitsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListUnitsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListUnitsRequest) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *ListUnitsRequest) GetField() []string {
+	if x != nil {
+		return x.Field
+	}
+	return nil
+}
+
+// ListUsageRequest is the request of ListUsage.
+type ListUsageRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Query usage of any user. Requires admin privileges.
+	Admin bool `protobuf:"varint,1,opt,name=admin,proto3" json:"admin,omitempty"`
+	// Users of usage. Only used when admin is true.
+	User      []string  `protobuf:"bytes,2,rep,name=user,proto3" json:"user,omitempty"`
+	Mode      UsageMode `protobuf:"varint,3,opt,name=mode,proto3,enum=ceems.api.v1.UsageMode" json:"mode,omitempty"`
+	ClusterId []string  `protobuf:"bytes,4,rep,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Project   []string  `protobuf:"bytes,5,rep,name=project,proto3" json:"project,omitempty"`
+	// Start of query window. Only used in current mode.
+	From string `protobuf:"bytes,6,opt,name=from,proto3" json:"from,omitempty"`
+	// End of query window. Only used in current mode.
+	To string `protobuf:"bytes,7,opt,name=to,proto3" json:"to,omitempty"`
+	// Fields to include in usage. All fields are included when empty.
+	Field         []string `protobuf:"bytes,8,rep,name=field,proto3" json:"field,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsageRequest) Reset() {
+	*x = ListUsageRequest{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsageRequest) ProtoMessage() {}
+
+func (x *ListUsageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsageRequest.ProtoReflect.Descriptor instead.
+func (*ListUsageRequest) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListUsageRequest) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+func (x *ListUsageRequest) GetUser() []string {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ListUsageRequest) GetMode() UsageMode {
+	if x != nil {
+		return x.Mode
+	}
+	return UsageMode_USAGE_MODE_UNSPECIFIED
+}
+
+func (x *ListUsageRequest) GetClusterId() []string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return nil
+}
+
+func (x *ListUsageRequest) GetProject() []string {
+	if x != nil {
+		return x.Project
+	}
+	return nil
+}
+
+func (x *ListUsageRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *ListUsageRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListUsageRequest) GetField() []string {
+	if x != nil {
+		return x.Field
+	}
+	return nil
+}
+
+// ListProjectsRequest is the request of ListProjects.
+type ListProjectsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Query projects of any user. Requires admin privileges.
+	Admin         bool     `protobuf:"varint,1,opt,name=admin,proto3" json:"admin,omitempty"`
+	ClusterId     []string `protobuf:"bytes,2,rep,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	Project       []string `protobuf:"bytes,3,rep,name=project,proto3" json:"project,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProjectsRequest) Reset() {
+	*x = ListProjectsRequest{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProjectsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProjectsRequest) ProtoMessage() {}
+
+func (x *ListProjectsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProjectsRequest.ProtoReflect.Descriptor instead.
+func (*ListProjectsRequest) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListProjectsRequest) GetAdmin() bool {
+	if x != nil {
+		return x.Admin
+	}
+	return false
+}
+
+func (x *ListProjectsRequest) GetClusterId() []string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return nil
+}
+
+func (x *ListProjectsRequest) GetProject() []string {
+	if x != nil {
+		return x.Project
+	}
+	return nil
+}
+
+// ListProjectsResponse is the response of ListProjects.
+type ListProjectsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Projects      []*Project             `protobuf:"bytes,1,rep,name=projects,proto3" json:"projects,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListProjectsResponse) Reset() {
+	*x = ListProjectsResponse{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListProjectsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListProjectsResponse) ProtoMessage() {}
+
+func (x *ListProjectsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListProjectsResponse.ProtoReflect.Descriptor instead.
+func (*ListProjectsResponse) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListProjectsResponse) GetProjects() []*Project {
+	if x != nil {
+		return x.Projects
+	}
+	return nil
+}
+
+// Unit is a compute unit like a batch job, VM or pod.
+type Unit struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	ClusterId              string                 `protobuf:"bytes,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	ResourceManager        string                 `protobuf:"bytes,2,opt,name=resource_manager,json=resourceManager,proto3" json:"resource_manager,omitempty"`
+	Uuid                   string                 `protobuf:"bytes,3,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Name                   string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Project                string                 `protobuf:"bytes,5,opt,name=project,proto3" json:"project,omitempty"`
+	Groupname              string                 `protobuf:"bytes,6,opt,name=groupname,proto3" json:"groupname,omitempty"`
+	Username               string                 `protobuf:"bytes,7,opt,name=username,proto3" json:"username,omitempty"`
+	CreatedAt              string                 `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	StartedAt              string                 `protobuf:"bytes,9,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	EndedAt                string                 `protobuf:"bytes,10,opt,name=ended_at,json=endedAt,proto3" json:"ended_at,omitempty"`
+	CreatedAtTs            int64                  `protobuf:"varint,11,opt,name=created_at_ts,json=createdAtTs,proto3" json:"created_at_ts,omitempty"`
+	StartedAtTs            int64                  `protobuf:"varint,12,opt,name=started_at_ts,json=startedAtTs,proto3" json:"started_at_ts,omitempty"`
+	EndedAtTs              int64                  `protobuf:"varint,13,opt,name=ended_at_ts,json=endedAtTs,proto3" json:"ended_at_ts,omitempty"`
+	Elapsed                string                 `protobuf:"bytes,14,opt,name=elapsed,proto3" json:"elapsed,omitempty"`
+	State                  string                 `protobuf:"bytes,15,opt,name=state,proto3" json:"state,omitempty"`
+	Allocation             *structpb.Struct       `protobuf:"bytes,16,opt,name=allocation,proto3" json:"allocation,omitempty"`
+	TotalTimeSeconds       map[string]float64     `protobuf:"bytes,17,rep,name=total_time_seconds,json=totalTimeSeconds,proto3" json:"total_time_seconds,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgCpuUsage            map[string]float64     `protobuf:"bytes,18,rep,name=avg_cpu_usage,json=avgCpuUsage,proto3" json:"avg_cpu_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgCpuMemUsage         map[string]float64     `protobuf:"bytes,19,rep,name=avg_cpu_mem_usage,json=avgCpuMemUsage,proto3" json:"avg_cpu_mem_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalCpuEnergyUsageKwh map[string]float64     `protobuf:"bytes,20,rep,name=total_cpu_energy_usage_kwh,json=totalCpuEnergyUsageKwh,proto3" json:"total_cpu_energy_usage_kwh,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalCpuEmissionsGms   map[string]float64     `protobuf:"bytes,21,rep,name=total_cpu_emissions_gms,json=totalCpuEmissionsGms,proto3" json:"total_cpu_emissions_gms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgGpuUsage            map[string]float64     `protobuf:"bytes,22,rep,name=avg_gpu_usage,json=avgGpuUsage,proto3" json:"avg_gpu_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgGpuMemUsage         map[string]float64     `protobuf:"bytes,23,rep,name=avg_gpu_mem_usage,json=avgGpuMemUsage,proto3" json:"avg_gpu_mem_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalGpuEnergyUsageKwh map[string]float64     `protobuf:"bytes,24,rep,name=total_gpu_energy_usage_kwh,json=totalGpuEnergyUsageKwh,proto3" json:"total_gpu_energy_usage_kwh,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalGpuEmissionsGms   map[string]float64     `protobuf:"bytes,25,rep,name=total_gpu_emissions_gms,json=totalGpuEmissionsGms,proto3" json:"total_gpu_emissions_gms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIoWriteStats      map[string]float64     `protobuf:"bytes,26,rep,name=total_io_write_stats,json=totalIoWriteStats,proto3" json:"total_io_write_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIoReadStats       map[string]float64     `protobuf:"bytes,27,rep,name=total_io_read_stats,json=totalIoReadStats,proto3" json:"total_io_read_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIngressStats      map[string]float64     `protobuf:"bytes,28,rep,name=total_ingress_stats,json=totalIngressStats,proto3" json:"total_ingress_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalOutgressStats     map[string]float64     `protobuf:"bytes,29,rep,name=total_outgress_stats,json=totalOutgressStats,proto3" json:"total_outgress_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	Tags                   *structpb.Struct       `protobuf:"bytes,30,opt,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Unit) Reset() {
+	*x = Unit{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Unit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Unit) ProtoMessage() {}
+
+func (x *Unit) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Unit.ProtoReflect.Descriptor instead.
+func (*Unit) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Unit) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *Unit) GetResourceManager() string {
+	if x != nil {
+		return x.ResourceManager
+	}
+	return ""
+}
+
+func (x *Unit) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Unit) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Unit) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Unit) GetGroupname() string {
+	if x != nil {
+		return x.Groupname
+	}
+	return ""
+}
+
+func (x *Unit) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Unit) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *Unit) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *Unit) GetEndedAt() string {
+	if x != nil {
+		return x.EndedAt
+	}
+	return ""
+}
+
+func (x *Unit) GetCreatedAtTs() int64 {
+	if x != nil {
+		return x.CreatedAtTs
+	}
+	return 0
+}
+
+func (x *Unit) GetStartedAtTs() int64 {
+	if x != nil {
+		return x.StartedAtTs
+	}
+	return 0
+}
+
+func (x *Unit) GetEndedAtTs() int64 {
+	if x != nil {
+		return x.EndedAtTs
+	}
+	return 0
+}
+
+func (x *Unit) GetElapsed() string {
+	if x != nil {
+		return x.Elapsed
+	}
+	return ""
+}
+
+func (x *Unit) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Unit) GetAllocation() *structpb.Struct {
+	if x != nil {
+		return x.Allocation
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalTimeSeconds() map[string]float64 {
+	if x != nil {
+		return x.TotalTimeSeconds
+	}
+	return nil
+}
+
+func (x *Unit) GetAvgCpuUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgCpuUsage
+	}
+	return nil
+}
+
+func (x *Unit) GetAvgCpuMemUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgCpuMemUsage
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalCpuEnergyUsageKwh() map[string]float64 {
+	if x != nil {
+		return x.TotalCpuEnergyUsageKwh
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalCpuEmissionsGms() map[string]float64 {
+	if x != nil {
+		return x.TotalCpuEmissionsGms
+	}
+	return nil
+}
+
+func (x *Unit) GetAvgGpuUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgGpuUsage
+	}
+	return nil
+}
+
+func (x *Unit) GetAvgGpuMemUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgGpuMemUsage
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalGpuEnergyUsageKwh() map[string]float64 {
+	if x != nil {
+		return x.TotalGpuEnergyUsageKwh
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalGpuEmissionsGms() map[string]float64 {
+	if x != nil {
+		return x.TotalGpuEmissionsGms
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalIoWriteStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIoWriteStats
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalIoReadStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIoReadStats
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalIngressStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIngressStats
+	}
+	return nil
+}
+
+func (x *Unit) GetTotalOutgressStats() map[string]float64 {
+	if x != nil {
+		return x.TotalOutgressStats
+	}
+	return nil
+}
+
+func (x *Unit) GetTags() *structpb.Struct {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+// Usage is the aggregated usage statistics of a user in a project.
+type Usage struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	ClusterId              string                 `protobuf:"bytes,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	ResourceManager        string                 `protobuf:"bytes,2,opt,name=resource_manager,json=resourceManager,proto3" json:"resource_manager,omitempty"`
+	NumUnits               int64                  `protobuf:"varint,3,opt,name=num_units,json=numUnits,proto3" json:"num_units,omitempty"`
+	Project                string                 `protobuf:"bytes,4,opt,name=project,proto3" json:"project,omitempty"`
+	Groupname              string                 `protobuf:"bytes,5,opt,name=groupname,proto3" json:"groupname,omitempty"`
+	Username               string                 `protobuf:"bytes,6,opt,name=username,proto3" json:"username,omitempty"`
+	TotalTimeSeconds       map[string]float64     `protobuf:"bytes,7,rep,name=total_time_seconds,json=totalTimeSeconds,proto3" json:"total_time_seconds,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgCpuUsage            map[string]float64     `protobuf:"bytes,8,rep,name=avg_cpu_usage,json=avgCpuUsage,proto3" json:"avg_cpu_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgCpuMemUsage         map[string]float64     `protobuf:"bytes,9,rep,name=avg_cpu_mem_usage,json=avgCpuMemUsage,proto3" json:"avg_cpu_mem_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalCpuEnergyUsageKwh map[string]float64     `protobuf:"bytes,10,rep,name=total_cpu_energy_usage_kwh,json=totalCpuEnergyUsageKwh,proto3" json:"total_cpu_energy_usage_kwh,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalCpuEmissionsGms   map[string]float64     `protobuf:"bytes,11,rep,name=total_cpu_emissions_gms,json=totalCpuEmissionsGms,proto3" json:"total_cpu_emissions_gms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgGpuUsage            map[string]float64     `protobuf:"bytes,12,rep,name=avg_gpu_usage,json=avgGpuUsage,proto3" json:"avg_gpu_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	AvgGpuMemUsage         map[string]float64     `protobuf:"bytes,13,rep,name=avg_gpu_mem_usage,json=avgGpuMemUsage,proto3" json:"avg_gpu_mem_usage,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalGpuEnergyUsageKwh map[string]float64     `protobuf:"bytes,14,rep,name=total_gpu_energy_usage_kwh,json=totalGpuEnergyUsageKwh,proto3" json:"total_gpu_energy_usage_kwh,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalGpuEmissionsGms   map[string]float64     `protobuf:"bytes,15,rep,name=total_gpu_emissions_gms,json=totalGpuEmissionsGms,proto3" json:"total_gpu_emissions_gms,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIoWriteStats      map[string]float64     `protobuf:"bytes,16,rep,name=total_io_write_stats,json=totalIoWriteStats,proto3" json:"total_io_write_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIoReadStats       map[string]float64     `protobuf:"bytes,17,rep,name=total_io_read_stats,json=totalIoReadStats,proto3" json:"total_io_read_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalIngressStats      map[string]float64     `protobuf:"bytes,18,rep,name=total_ingress_stats,json=totalIngressStats,proto3" json:"total_ingress_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	TotalOutgressStats     map[string]float64     `protobuf:"bytes,19,rep,name=total_outgress_stats,json=totalOutgressStats,proto3" json:"total_outgress_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *Usage) Reset() {
+	*x = Usage{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Usage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Usage) ProtoMessage() {}
+
+func (x *Usage) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Usage.ProtoReflect.Descriptor instead.
+func (*Usage) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Usage) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *Usage) GetResourceManager() string {
+	if x != nil {
+		return x.ResourceManager
+	}
+	return ""
+}
+
+func (x *Usage) GetNumUnits() int64 {
+	if x != nil {
+		return x.NumUnits
+	}
+	return 0
+}
+
+func (x *Usage) GetProject() string {
+	if x != nil {
+		return x.Project
+	}
+	return ""
+}
+
+func (x *Usage) GetGroupname() string {
+	if x != nil {
+		return x.Groupname
+	}
+	return ""
+}
+
+func (x *Usage) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Usage) GetTotalTimeSeconds() map[string]float64 {
+	if x != nil {
+		return x.TotalTimeSeconds
+	}
+	return nil
+}
+
+func (x *Usage) GetAvgCpuUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgCpuUsage
+	}
+	return nil
+}
+
+func (x *Usage) GetAvgCpuMemUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgCpuMemUsage
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalCpuEnergyUsageKwh() map[string]float64 {
+	if x != nil {
+		return x.TotalCpuEnergyUsageKwh
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalCpuEmissionsGms() map[string]float64 {
+	if x != nil {
+		return x.TotalCpuEmissionsGms
+	}
+	return nil
+}
+
+func (x *Usage) GetAvgGpuUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgGpuUsage
+	}
+	return nil
+}
+
+func (x *Usage) GetAvgGpuMemUsage() map[string]float64 {
+	if x != nil {
+		return x.AvgGpuMemUsage
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalGpuEnergyUsageKwh() map[string]float64 {
+	if x != nil {
+		return x.TotalGpuEnergyUsageKwh
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalGpuEmissionsGms() map[string]float64 {
+	if x != nil {
+		return x.TotalGpuEmissionsGms
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalIoWriteStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIoWriteStats
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalIoReadStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIoReadStats
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalIngressStats() map[string]float64 {
+	if x != nil {
+		return x.TotalIngressStats
+	}
+	return nil
+}
+
+func (x *Usage) GetTotalOutgressStats() map[string]float64 {
+	if x != nil {
+		return x.TotalOutgressStats
+	}
+	return nil
+}
+
+// Project is a project (account, tenant or namespace) of a cluster.
+type Project struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Uid             string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	ClusterId       string                 `protobuf:"bytes,2,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
+	ResourceManager string                 `protobuf:"bytes,3,opt,name=resource_manager,json=resourceManager,proto3" json:"resource_manager,omitempty"`
+	Name            string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Users           []string               `protobuf:"bytes,5,rep,name=users,proto3" json:"users,omitempty"`
+	Tags            *structpb.ListValue    `protobuf:"bytes,6,opt,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Project) Reset() {
+	*x = Project{}
+	mi := &file_ceems_v1_ceems_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Project) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Project) ProtoMessage() {}
+
+func (x *Project) ProtoReflect() protoreflect.Message {
+	mi := &file_ceems_v1_ceems_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Project.ProtoReflect.Descriptor instead.
+func (*Project) Descriptor() ([]byte, []int) {
+	return file_ceems_v1_ceems_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Project) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Project) GetClusterId() string {
+	if x != nil {
+		return x.ClusterId
+	}
+	return ""
+}
+
+func (x *Project) GetResourceManager() string {
+	if x != nil {
+		return x.ResourceManager
+	}
+	return ""
+}
+
+func (x *Project) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Project) GetUsers() []string {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *Project) GetTags() *structpb.ListValue {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+var File_ceems_v1_ceems_proto protoreflect.FileDescriptor
+
+var file_ceems_v1_ceems_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x65, 0x6d, 0x73,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0xf3, 0x01, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x6e, 0x69, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x12, 0x12, 0x0a,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65,
+	0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75,
+	0x69, 0x64, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74, 0x6f, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69,
+	0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x0a, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x22, 0xdc, 0x01, 0x0a, 0x10, 0x4c, 0x69, 0x73,
+	0x74, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x61, 0x64,
+	0x6d, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x2b, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04,
+	0x6d, 0x6f, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f,
+	0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x74,
+	0x6f, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x22, 0x64, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x61, 0x64, 0x6d, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x61,
+	0x64, 0x6d, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x22, 0x49, 0x0a,
+	0x14, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x52, 0x08,
+	0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x22, 0xce, 0x14, 0x0a, 0x04, 0x55, 0x6e, 0x69,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x49, 0x64,
+	0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x6d, 0x61, 0x6e,
+	0x61, 0x67, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x75,
+	0x75, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x1c, 0x0a,
+	0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x5f, 0x61,
+	0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x22, 0x0a, 0x0d, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x5f, 0x74,
+	0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x41, 0x74, 0x54, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x5f,
+	0x61, 0x74, 0x5f, 0x74, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x65, 0x64, 0x41, 0x74, 0x54, 0x73, 0x12, 0x1e, 0x0a, 0x0b, 0x65, 0x6e, 0x64, 0x65,
+	0x64, 0x5f, 0x61, 0x74, 0x5f, 0x74, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65,
+	0x6e, 0x64, 0x65, 0x64, 0x41, 0x74, 0x54, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6c, 0x61, 0x70,
+	0x73, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x65, 0x6c, 0x61, 0x70, 0x73,
+	0x65, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12, 0x37, 0x0a, 0x0a, 0x61, 0x6c, 0x6c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53,
+	0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x56, 0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f,
+	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e,
+	0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69,
+	0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69,
+	0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x47, 0x0a, 0x0d, 0x61, 0x76, 0x67,
+	0x5f, 0x63, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x23, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e,
+	0x55, 0x6e, 0x69, 0x74, 0x2e, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x61, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x51, 0x0a, 0x11, 0x61, 0x76, 0x67, 0x5f, 0x63, 0x70, 0x75, 0x5f, 0x6d, 0x65,
+	0x6d, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x13, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e,
+	0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69,
+	0x74, 0x2e, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x61, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x6a, 0x0a, 0x1a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63,
+	0x70, 0x75, 0x5f, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f,
+	0x6b, 0x77, 0x68, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x63, 0x65, 0x65, 0x6d,
+	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f,
+	0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x16, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x43, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b, 0x77,
+	0x68, 0x12, 0x63, 0x0a, 0x17, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x70, 0x75, 0x5f, 0x65,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x67, 0x6d, 0x73, 0x18, 0x15, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x14, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x12, 0x47, 0x0a, 0x0d, 0x61, 0x76, 0x67, 0x5f, 0x67, 0x70,
+	0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x16, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e,
+	0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69,
+	0x74, 0x2e, 0x41, 0x76, 0x67, 0x47, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0b, 0x61, 0x76, 0x67, 0x47, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x51, 0x0a, 0x11, 0x61, 0x76, 0x67, 0x5f, 0x67, 0x70, 0x75, 0x5f, 0x6d, 0x65, 0x6d, 0x5f, 0x75,
+	0x73, 0x61, 0x67, 0x65, 0x18, 0x17, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x63, 0x65, 0x65,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x41,
+	0x76, 0x67, 0x47, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x0e, 0x61, 0x76, 0x67, 0x47, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x6a, 0x0a, 0x1a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x67, 0x70, 0x75, 0x5f,
+	0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x6b, 0x77, 0x68,
+	0x18, 0x18, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x47, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b, 0x77,
+	0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x16, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75,
+	0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b, 0x77, 0x68, 0x12, 0x63,
+	0x0a, 0x17, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x67, 0x70, 0x75, 0x5f, 0x65, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x67, 0x6d, 0x73, 0x18, 0x19, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x2c, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55,
+	0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x14, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x47, 0x6d, 0x73, 0x12, 0x5a, 0x0a, 0x14, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6f, 0x5f,
+	0x77, 0x72, 0x69, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x1a, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x29, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x11, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12,
+	0x57, 0x0a, 0x13, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6f, 0x5f, 0x72, 0x65, 0x61, 0x64,
+	0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x63,
+	0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74,
+	0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x52,
+	0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x59, 0x0a, 0x13, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x5f, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18,
+	0x1c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49,
+	0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x12, 0x5c, 0x0a, 0x14, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6f, 0x75, 0x74,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x1d, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x2a, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x55, 0x6e, 0x69, 0x74, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75, 0x74, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x12, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75, 0x74, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x12, 0x2b, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x1a, 0x43,
+	0x0a, 0x15, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x1a, 0x3e, 0x0a, 0x10, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x49, 0x0a, 0x1b, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43,
+	0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b, 0x77, 0x68,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x1a, 0x47, 0x0a, 0x19, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6d, 0x69,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3e, 0x0a, 0x10, 0x41, 0x76,
+	0x67, 0x47, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x41, 0x76,
+	0x67, 0x47, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x49, 0x0a,
+	0x1b, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55,
+	0x73, 0x61, 0x67, 0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x47, 0x0a, 0x19, 0x54, 0x6f, 0x74, 0x61,
+	0x6c, 0x47, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x1a, 0x44, 0x0a, 0x16, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69, 0x74,
+	0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x43, 0x0a, 0x15, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x49, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x44, 0x0a, 0x16,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x1a, 0x45, 0x0a, 0x17, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75, 0x74, 0x67, 0x72,
+	0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xfa, 0x11, 0x0a, 0x05, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72,
+	0x49, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x6d,
+	0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x1b, 0x0a,
+	0x09, 0x6e, 0x75, 0x6d, 0x5f, 0x75, 0x6e, 0x69, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x6e, 0x75, 0x6d, 0x55, 0x6e, 0x69, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x72,
+	0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x72, 0x6f,
+	0x6a, 0x65, 0x63, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x57,
+	0x0a, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x73, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x63, 0x65, 0x65,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69, 0x6d, 0x65,
+	0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x48, 0x0a, 0x0d, 0x61, 0x76, 0x67, 0x5f, 0x63,
+	0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24,
+	0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x2e, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x61, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x52, 0x0a, 0x11, 0x61, 0x76, 0x67, 0x5f, 0x63, 0x70, 0x75, 0x5f, 0x6d, 0x65, 0x6d,
+	0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x63,
+	0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x2e, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x61, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d,
+	0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x6b, 0x0a, 0x1a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63,
+	0x70, 0x75, 0x5f, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x5f,
+	0x6b, 0x77, 0x68, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x63, 0x65, 0x65, 0x6d,
+	0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54,
+	0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x16, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x43, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b,
+	0x77, 0x68, 0x12, 0x64, 0x0a, 0x17, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x63, 0x70, 0x75, 0x5f,
+	0x65, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x67, 0x6d, 0x73, 0x18, 0x0b, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70,
+	0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x14, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x12, 0x48, 0x0a, 0x0d, 0x61, 0x76, 0x67, 0x5f,
+	0x67, 0x70, 0x75, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x24, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55,
+	0x73, 0x61, 0x67, 0x65, 0x2e, 0x41, 0x76, 0x67, 0x47, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x61, 0x76, 0x67, 0x47, 0x70, 0x75, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x52, 0x0a, 0x11, 0x61, 0x76, 0x67, 0x5f, 0x67, 0x70, 0x75, 0x5f, 0x6d, 0x65,
+	0x6d, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e,
+	0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x2e, 0x41, 0x76, 0x67, 0x47, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0e, 0x61, 0x76, 0x67, 0x47, 0x70, 0x75, 0x4d, 0x65,
+	0x6d, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x6b, 0x0a, 0x1a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x67, 0x70, 0x75, 0x5f, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65,
+	0x5f, 0x6b, 0x77, 0x68, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2f, 0x2e, 0x63, 0x65, 0x65,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73,
+	0x61, 0x67, 0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x16, 0x74, 0x6f, 0x74,
+	0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65,
+	0x4b, 0x77, 0x68, 0x12, 0x64, 0x0a, 0x17, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x67, 0x70, 0x75,
+	0x5f, 0x65, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x67, 0x6d, 0x73, 0x18, 0x0f,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x47,
+	0x70, 0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x14, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6d, 0x69,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x12, 0x5b, 0x0a, 0x14, 0x74, 0x6f, 0x74,
+	0x61, 0x6c, 0x5f, 0x69, 0x6f, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x6f, 0x74,
+	0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69, 0x74,
+	0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x58, 0x0a, 0x13, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f,
+	0x69, 0x6f, 0x5f, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x11, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f,
+	0x52, 0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x10,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x52, 0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x12, 0x5a, 0x0a, 0x13, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e,
+	0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x2e, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x5d, 0x0a, 0x14,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6f, 0x75, 0x74, 0x67, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x73,
+	0x74, 0x61, 0x74, 0x73, 0x18, 0x13, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x63, 0x65, 0x65,
+	0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x2e,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75, 0x74, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x12, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75,
+	0x74, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x1a, 0x43, 0x0a, 0x15, 0x54,
+	0x6f, 0x74, 0x61, 0x6c, 0x54, 0x69, 0x6d, 0x65, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x1a, 0x3e, 0x0a, 0x10, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x1a, 0x41, 0x0a, 0x13, 0x41, 0x76, 0x67, 0x43, 0x70, 0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61,
+	0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x1a, 0x49, 0x0a, 0x1b, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45,
+	0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67, 0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x47,
+	0x0a, 0x19, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x70, 0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3e, 0x0a, 0x10, 0x41, 0x76, 0x67, 0x47, 0x70,
+	0x75, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x41, 0x0a, 0x13, 0x41, 0x76, 0x67, 0x47, 0x70,
+	0x75, 0x4d, 0x65, 0x6d, 0x55, 0x73, 0x61, 0x67, 0x65, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x49, 0x0a, 0x1b, 0x54, 0x6f,
+	0x74, 0x61, 0x6c, 0x47, 0x70, 0x75, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x4b, 0x77, 0x68, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x47, 0x0a, 0x19, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x47, 0x70,
+	0x75, 0x45, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x47, 0x6d, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x44,
+	0x0a, 0x16, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x57, 0x72, 0x69, 0x74, 0x65, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x1a, 0x43, 0x0a, 0x15, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x49, 0x6f, 0x52,
+	0x65, 0x61, 0x64, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x44, 0x0a, 0x16, 0x54, 0x6f, 0x74,
+	0x61, 0x6c, 0x49, 0x6e, 0x67, 0x72, 0x65, 0x73, 0x73, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a,
+	0x45, 0x0a, 0x17, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4f, 0x75, 0x74, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xbf, 0x01, 0x0a, 0x07, 0x50, 0x72, 0x6f, 0x6a, 0x65,
+	0x63, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x75, 0x69, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x5f,
+	0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f,
+	0x6d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x72,
+	0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4d, 0x61, 0x6e, 0x61, 0x67, 0x65, 0x72, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x05, 0x75, 0x73, 0x65, 0x72, 0x73, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c,
+	0x75, 0x65, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x2a, 0x56, 0x0a, 0x09, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x16, 0x55, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x4d,
+	0x4f, 0x44, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10,
+	0x00, 0x12, 0x16, 0x0a, 0x12, 0x55, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f,
+	0x43, 0x55, 0x52, 0x52, 0x45, 0x4e, 0x54, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11, 0x55, 0x53, 0x41,
+	0x47, 0x45, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x47, 0x4c, 0x4f, 0x42, 0x41, 0x4c, 0x10, 0x02,
+	0x32, 0xec, 0x01, 0x0a, 0x0c, 0x43, 0x45, 0x45, 0x4d, 0x53, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x41, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x6e, 0x69, 0x74, 0x73, 0x12, 0x1e,
+	0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69,
+	0x73, 0x74, 0x55, 0x6e, 0x69, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12,
+	0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x6e,
+	0x69, 0x74, 0x30, 0x01, 0x12, 0x42, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x1e, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x55, 0x73, 0x61, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x13, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31,
+	0x2e, 0x55, 0x73, 0x61, 0x67, 0x65, 0x30, 0x01, 0x12, 0x55, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74,
+	0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x12, 0x21, 0x2e, 0x63, 0x65, 0x65, 0x6d, 0x73,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x63, 0x65,
+	0x65, 0x6d, 0x73, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42,
+	0x41, 0x5a, 0x3f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x61,
+	0x68, 0x65, 0x6e, 0x64, 0x72, 0x61, 0x70, 0x61, 0x69, 0x70, 0x75, 0x72, 0x69, 0x2f, 0x63, 0x65,
+	0x65, 0x6d, 0x73, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x2f, 0x63, 0x65, 0x65, 0x6d, 0x73, 0x2f, 0x76, 0x31, 0x3b, 0x63, 0x65, 0x65, 0x6d, 0x73,
+	0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_ceems_v1_ceems_proto_rawDescOnce sync.Once
+	file_ceems_v1_ceems_proto_rawDescData = file_ceems_v1_ceems_proto_rawDesc
+)
+
+func file_ceems_v1_ceems_proto_rawDescGZIP() []byte {
+	file_ceems_v1_ceems_proto_rawDescOnce.Do(func() {
+		file_ceems_v1_ceems_proto_rawDescData = protoimpl.X.CompressGZIP(file_ceems_v1_ceems_proto_rawDescData)
+	})
+	return file_ceems_v1_ceems_proto_rawDescData
+}
+
+var file_ceems_v1_ceems_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_ceems_v1_ceems_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
+var file_ceems_v1_ceems_proto_goTypes = []any{
+	(UsageMode)(0),               // 0: ceems.api.v1.UsageMode
+	(*ListUnitsRequest)(nil),     // 1: ceems.api.v1.ListUnitsRequest
+	(*ListUsageRequest)(nil),     // 2: ceems.api.v1.ListUsageRequest
+	(*ListProjectsRequest)(nil),  // 3: ceems.api.v1.ListProjectsRequest
+	(*ListProjectsResponse)(nil), // 4: ceems.api.v1.ListProjectsResponse
+	(*Unit)(nil),                 // 5: ceems.api.v1.Unit
+	(*Usage)(nil),                // 6: ceems.api.v1.Usage
+	(*Project)(nil),              // 7: ceems.api.v1.Project
+	nil,                          // 8: ceems.api.v1.Unit.TotalTimeSecondsEntry
+	nil,                          // 9: ceems.api.v1.Unit.AvgCpuUsageEntry
+	nil,                          // 10: ceems.api.v1.Unit.AvgCpuMemUsageEntry
+	nil,                          // 11: ceems.api.v1.Unit.TotalCpuEnergyUsageKwhEntry
+	nil,                          // 12: ceems.api.v1.Unit.TotalCpuEmissionsGmsEntry
+	nil,                          // 13: ceems.api.v1.Unit.AvgGpuUsageEntry
+	nil,                          // 14: ceems.api.v1.Unit.AvgGpuMemUsageEntry
+	nil,                          // 15: ceems.api.v1.Unit.TotalGpuEnergyUsageKwhEntry
+	nil,                          // 16: ceems.api.v1.Unit.TotalGpuEmissionsGmsEntry
+	nil,                          // 17: ceems.api.v1.Unit.TotalIoWriteStatsEntry
+	nil,                          // 18: ceems.api.v1.Unit.TotalIoReadStatsEntry
+	nil,                          // 19: ceems.api.v1.Unit.TotalIngressStatsEntry
+	nil,                          // 20: ceems.api.v1.Unit.TotalOutgressStatsEntry
+	nil,                          // 21: ceems.api.v1.Usage.TotalTimeSecondsEntry
+	nil,                          // 22: ceems.api.v1.Usage.AvgCpuUsageEntry
+	nil,                          // 23: ceems.api.v1.Usage.AvgCpuMemUsageEntry
+	nil,                          // 24: ceems.api.v1.Usage.TotalCpuEnergyUsageKwhEntry
+	nil,                          // 25: ceems.api.v1.Usage.TotalCpuEmissionsGmsEntry
+	nil,                          // 26: ceems.api.v1.Usage.AvgGpuUsageEntry
+	nil,                          // 27: ceems.api.v1.Usage.AvgGpuMemUsageEntry
+	nil,                          // 28: ceems.api.v1.Usage.TotalGpuEnergyUsageKwhEntry
+	nil,                          // 29: ceems.api.v1.Usage.TotalGpuEmissionsGmsEntry
+	nil,                          // 30: ceems.api.v1.Usage.TotalIoWriteStatsEntry
+	nil,                          // 31: ceems.api.v1.Usage.TotalIoReadStatsEntry
+	nil,                          // 32: ceems.api.v1.Usage.TotalIngressStatsEntry
+	nil,                          // 33: ceems.api.v1.Usage.TotalOutgressStatsEntry
+	(*structpb.Struct)(nil),      // 34: google.protobuf.Struct
+	(*structpb.ListValue)(nil),   // 35: google.protobuf.ListValue
+}
+var file_ceems_v1_ceems_proto_depIdxs = []int32{
+	0,  // 0: ceems.api.v1.ListUsageRequest.mode:type_name -> ceems.api.v1.UsageMode
+	7,  // 1: ceems.api.v1.ListProjectsResponse.projects:type_name -> ceems.api.v1.Project
+	34, // 2: ceems.api.v1.Unit.allocation:type_name -> google.protobuf.Struct
+	8,  // 3: ceems.api.v1.Unit.total_time_seconds:type_name -> ceems.api.v1.Unit.TotalTimeSecondsEntry
+	9,  // 4: ceems.api.v1.Unit.avg_cpu_usage:type_name -> ceems.api.v1.Unit.AvgCpuUsageEntry
+	10, // 5: ceems.api.v1.Unit.avg_cpu_mem_usage:type_name -> ceems.api.v1.Unit.AvgCpuMemUsageEntry
+	11, // 6: ceems.api.v1.Unit.total_cpu_energy_usage_kwh:type_name -> ceems.api.v1.Unit.TotalCpuEnergyUsageKwhEntry
+	12, // 7: ceems.api.v1.Unit.total_cpu_emissions_gms:type_name -> ceems.api.v1.Unit.TotalCpuEmissionsGmsEntry
+	13, // 8: ceems.api.v1.Unit.avg_gpu_usage:type_name -> ceems.api.v1.Unit.AvgGpuUsageEntry
+	14, // 9: ceems.api.v1.Unit.avg_gpu_mem_usage:type_name -> ceems.api.v1.Unit.AvgGpuMemUsageEntry
+	15, // 10: ceems.api.v1.Unit.total_gpu_energy_usage_kwh:type_name -> ceems.api.v1.Unit.TotalGpuEnergyUsageKwhEntry
+	16, // 11: ceems.api.v1.Unit.total_gpu_emissions_gms:type_name -> ceems.api.v1.Unit.TotalGpuEmissionsGmsEntry
+	17, // 12: ceems.api.v1.Unit.total_io_write_stats:type_name -> ceems.api.v1.Unit.TotalIoWriteStatsEntry
+	18, // 13: ceems.api.v1.Unit.total_io_read_stats:type_name -> ceems.api.v1.Unit.TotalIoReadStatsEntry
+	19, // 14: ceems.api.v1.Unit.total_ingress_stats:type_name -> ceems.api.v1.Unit.TotalIngressStatsEntry
+	20, // 15: ceems.api.v1.Unit.total_outgress_stats:type_name -> ceems.api.v1.Unit.TotalOutgressStatsEntry
+	34, // 16: ceems.api.v1.Unit.tags:type_name -> google.protobuf.Struct
+	21, // 17: ceems.api.v1.Usage.total_time_seconds:type_name -> ceems.api.v1.Usage.TotalTimeSecondsEntry
+	22, // 18: ceems.api.v1.Usage.avg_cpu_usage:type_name -> ceems.api.v1.Usage.AvgCpuUsageEntry
+	23, // 19: ceems.api.v1.Usage.avg_cpu_mem_usage:type_name -> ceems.api.v1.Usage.AvgCpuMemUsageEntry
+	24, // 20: ceems.api.v1.Usage.total_cpu_energy_usage_kwh:type_name -> ceems.api.v1.Usage.TotalCpuEnergyUsageKwhEntry
+	25, // 21: ceems.api.v1.Usage.total_cpu_emissions_gms:type_name -> ceems.api.v1.Usage.TotalCpuEmissionsGmsEntry
+	26, // 22: ceems.api.v1.Usage.avg_gpu_usage:type_name -> ceems.api.v1.Usage.AvgGpuUsageEntry
+	27, // 23: ceems.api.v1.Usage.avg_gpu_mem_usage:type_name -> ceems.api.v1.Usage.AvgGpuMemUsageEntry
+	28, // 24: ceems.api.v1.Usage.total_gpu_energy_usage_kwh:type_name -> ceems.api.v1.Usage.TotalGpuEnergyUsageKwhEntry
+	29, // 25: ceems.api.v1.Usage.total_gpu_emissions_gms:type_name -> ceems.api.v1.Usage.TotalGpuEmissionsGmsEntry
+	30, // 26: ceems.api.v1.Usage.total_io_write_stats:type_name -> ceems.api.v1.Usage.TotalIoWriteStatsEntry
+	31, // 27: ceems.api.v1.Usage.total_io_read_stats:type_name -> ceems.api.v1.Usage.TotalIoReadStatsEntry
+	32, // 28: ceems.api.v1.Usage.total_ingress_stats:type_name -> ceems.api.v1.Usage.TotalIngressStatsEntry
+	33, // 29: ceems.api.v1.Usage.total_outgress_stats:type_name -> ceems.api.v1.Usage.TotalOutgressStatsEntry
+	35, // 30: ceems.api.v1.Project.tags:type_name -> google.protobuf.ListValue
+	1,  // 31: ceems.api.v1.CEEMSService.ListUnits:input_type -> ceems.api.v1.ListUnitsRequest
+	2,  // 32: ceems.api.v1.CEEMSService.ListUsage:input_type -> ceems.api.v1.ListUsageRequest
+	3,  // 33: ceems.api.v1.CEEMSService.ListProjects:input_type -> ceems.api.v1.ListProjectsRequest
+	5,  // 34: ceems.api.v1.CEEMSService.ListUnits:output_type -> ceems.api.v1.Unit
+	6,  // 35: ceems.api.v1.CEEMSService.ListUsage:output_type -> ceems.api.v1.Usage
+	4,  // 36: ceems.api.v1.CEEMSService.ListProjects:output_type -> ceems.api.v1.ListProjectsResponse
+	34, // [34:37] is the sub-list for method output_type
+	31, // [31:34] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
+}
+
+func init() { file_ceems_v1_ceems_proto_init() }
+func file_ceems_v1_ceems_proto_init() {
+	if File_ceems_v1_ceems_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_ceems_v1_ceems_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   33,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ceems_v1_ceems_proto_goTypes,
+		DependencyIndexes: file_ceems_v1_ceems_proto_depIdxs,
+		EnumInfos:         file_ceems_v1_ceems_proto_enumTypes,
+		MessageInfos:      file_ceems_v1_ceems_proto_msgTypes,
+	}.Build()
+	File_ceems_v1_ceems_proto = out.File
+	file_ceems_v1_ceems_proto_rawDesc = nil
+	file_ceems_v1_ceems_proto_goTypes = nil
+	file_ceems_v1_ceems_proto_depIdxs = nil
+}
@@ -0,0 +1,232 @@
+// Protocol buffer definitions of gRPC API of CEEMS API server.
+//
+// The messages mirror the models of REST API and the RPCs are served by the
+// same handlers as the REST API. Hence, the semantics of the request fields are
+// identical to the query parameters of the corresponding REST endpoints.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.2
+// source: ceems/v1/ceems.proto
+
+package ceemsv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CEEMSService_ListUnits_FullMethodName    = "/ceems.api.v1.CEEMSService/ListUnits"
+	CEEMSService_ListUsage_FullMethodName    = "/ceems.api.v1.CEEMSService/ListUsage"
+	CEEMSService_ListProjects_FullMethodName = "/ceems.api.v1.CEEMSService/ListProjects"
+)
+
+// CEEMSServiceClient is the client API for CEEMSService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CEEMSService exposes compute units, usage statistics and projects of
+// CEEMS API server.
+//
+// The current user must be set in `x-grafana-user` metadata of each call.
+type CEEMSServiceClient interface {
+	// ListUnits streams compute units. It is equivalent to `/units` and
+	// `/units/admin` REST endpoints.
+	ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Unit], error)
+	// ListUsage streams usage statistics. It is equivalent to `/usage/{mode}`
+	// and `/usage/{mode}/admin` REST endpoints.
+	ListUsage(ctx context.Context, in *ListUsageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Usage], error)
+	// ListProjects returns projects. It is equivalent to `/projects` and
+	// `/projects/admin` REST endpoints.
+	ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error)
+}
+
+type cEEMSServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCEEMSServiceClient(cc grpc.ClientConnInterface) CEEMSServiceClient {
+	return &cEEMSServiceClient{cc}
+}
+
+func (c *cEEMSServiceClient) ListUnits(ctx context.Context, in *ListUnitsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Unit], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CEEMSService_ServiceDesc.Streams[0], CEEMSService_ListUnits_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListUnitsRequest, Unit]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CEEMSService_ListUnitsClient = grpc.ServerStreamingClient[Unit]
+
+func (c *cEEMSServiceClient) ListUsage(ctx context.Context, in *ListUsageRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Usage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CEEMSService_ServiceDesc.Streams[1], CEEMSService_ListUsage_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListUsageRequest, Usage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CEEMSService_ListUsageClient = grpc.ServerStreamingClient[Usage]
+
+func (c *cEEMSServiceClient) ListProjects(ctx context.Context, in *ListProjectsRequest, opts ...grpc.CallOption) (*ListProjectsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProjectsResponse)
+	err := c.cc.Invoke(ctx, CEEMSService_ListProjects_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CEEMSServiceServer is the server API for CEEMSService service.
+// All implementations must embed UnimplementedCEEMSServiceServer
+// for forward compatibility.
+//
+// CEEMSService exposes compute units, usage statistics and projects of
+// CEEMS API server.
+//
+// The current user must be set in `x-grafana-user` metadata of each call.
+type CEEMSServiceServer interface {
+	// ListUnits streams compute units. It is equivalent to `/units` and
+	// `/units/admin` REST endpoints.
+	ListUnits(*ListUnitsRequest, grpc.ServerStreamingServer[Unit]) error
+	// ListUsage streams usage statistics. It is equivalent to `/usage/{mode}`
+	// and `/usage/{mode}/admin` REST endpoints.
+	ListUsage(*ListUsageRequest, grpc.ServerStreamingServer[Usage]) error
+	// ListProjects returns projects. It is equivalent to `/projects` and
+	// `/projects/admin` REST endpoints.
+	ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error)
+	mustEmbedUnimplementedCEEMSServiceServer()
+}
+
+// UnimplementedCEEMSServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCEEMSServiceServer struct{}
+
+func (UnimplementedCEEMSServiceServer) ListUnits(*ListUnitsRequest, grpc.ServerStreamingServer[Unit]) error {
+	return status.Errorf(codes.Unimplemented, "method ListUnits not implemented")
+}
+func (UnimplementedCEEMSServiceServer) ListUsage(*ListUsageRequest, grpc.ServerStreamingServer[Usage]) error {
+	return status.Errorf(codes.Unimplemented, "method ListUsage not implemented")
+}
+func (UnimplementedCEEMSServiceServer) ListProjects(context.Context, *ListProjectsRequest) (*ListProjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProjects not implemented")
+}
+func (UnimplementedCEEMSServiceServer) mustEmbedUnimplementedCEEMSServiceServer() {}
+func (UnimplementedCEEMSServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeCEEMSServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CEEMSServiceServer will
+// result in compilation errors.
+type UnsafeCEEMSServiceServer interface {
+	mustEmbedUnimplementedCEEMSServiceServer()
+}
+
+func RegisterCEEMSServiceServer(s grpc.ServiceRegistrar, srv CEEMSServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCEEMSServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CEEMSService_ServiceDesc, srv)
+}
+
+func _CEEMSService_ListUnits_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListUnitsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CEEMSServiceServer).ListUnits(m, &grpc.GenericServerStream[ListUnitsRequest, Unit]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CEEMSService_ListUnitsServer = grpc.ServerStreamingServer[Unit]
+
+func _CEEMSService_ListUsage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListUsageRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CEEMSServiceServer).ListUsage(m, &grpc.GenericServerStream[ListUsageRequest, Usage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CEEMSService_ListUsageServer = grpc.ServerStreamingServer[Usage]
+
+func _CEEMSService_ListProjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CEEMSServiceServer).ListProjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CEEMSService_ListProjects_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CEEMSServiceServer).ListProjects(ctx, req.(*ListProjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CEEMSService_ServiceDesc is the grpc.ServiceDesc for CEEMSService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CEEMSService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ceems.api.v1.CEEMSService",
+	HandlerType: (*CEEMSServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListProjects",
+			Handler:    _CEEMSService_ListProjects_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListUnits",
+			Handler:       _CEEMSService_ListUnits_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListUsage",
+			Handler:       _CEEMSService_ListUsage_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ceems/v1/ceems.proto",
+}
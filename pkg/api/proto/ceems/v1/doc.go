@@ -0,0 +1,5 @@
+// Package ceemsv1 contains the protocol buffer messages and gRPC service of
+// CEEMS API server.
+package ceemsv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative ceems/v1/ceems.proto
@@ -42,18 +42,28 @@ type Fetcher interface {
 	) ([]models.ClusterUsers, []models.ClusterProjects, error)
 }
 
+// ReservationFetcher is the optional interface implemented by resource managers
+// that support reservations of resources.
+type ReservationFetcher interface {
+	// FetchReservations fetches usage of reservations between start and end times
+	FetchReservations(ctx context.Context, start time.Time, end time.Time) ([]models.ClusterReservations, error)
+}
+
 // Manager implements the interface to fetch compute units from different resource managers.
 type Manager struct {
-	Fetchers []Fetcher
-	Logger   *slog.Logger
+	Fetchers   []Fetcher
+	Logger     *slog.Logger
+	clusterIDs []string
+	isDefault  bool
 }
 
 var factories = make(map[string]func(cluster models.Cluster, logger *slog.Logger) (Fetcher, error))
 
 // Mutex lock.
 var (
-	unitFetcherLock = sync.RWMutex{}
-	userFetcherLock = sync.RWMutex{}
+	unitFetcherLock        = sync.RWMutex{}
+	userFetcherLock        = sync.RWMutex{}
+	reservationFetcherLock = sync.RWMutex{}
 )
 
 // Register registers the resource manager into factory.
@@ -94,22 +104,45 @@ func checkConfig(managers []string, config *Config[models.Cluster]) (map[string]
 
 // managerConfig returns the configuration of resource managers.
 func managerConfig() (*Config[models.Cluster], error) {
+	return managerConfigFromFile(base.ConfigFilePath)
+}
+
+// managerConfigFromFile returns the configuration of resource managers found
+// in the file at path.
+func managerConfigFromFile(path string) (*Config[models.Cluster], error) {
 	// Make config from file
-	config, err := common.MakeConfig[Config[models.Cluster]](base.ConfigFilePath)
+	config, err := common.MakeConfig[Config[models.Cluster]](path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Set directories
 	for i := range len(config.Clusters) {
-		config.Clusters[i].Web.HTTPClientConfig.SetDirectory(filepath.Dir(base.ConfigFilePath))
+		config.Clusters[i].Web.HTTPClientConfig.SetDirectory(filepath.Dir(path))
 	}
 
 	return config, nil
 }
 
-// New creates a new Manager struct instance.
+// Clusters returns the clusters found in the configuration of resource managers.
+func Clusters() ([]models.Cluster, error) {
+	config, err := managerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Clusters, nil
+}
+
+// New creates a new Manager struct instance using the configuration file of
+// CEEMS API server.
 func New(logger *slog.Logger) (*Manager, error) {
+	return NewFromFile(base.ConfigFilePath, logger)
+}
+
+// NewFromFile creates a new Manager struct instance using the clusters found in
+// the configuration file at path.
+func NewFromFile(path string, logger *slog.Logger) (*Manager, error) {
 	var fetcher Fetcher
 
 	var registeredManagers []string
@@ -126,7 +159,7 @@ func New(logger *slog.Logger) (*Manager, error) {
 	}
 
 	// Get current config
-	config, err := managerConfig()
+	config, err := managerConfigFromFile(path)
 	if err != nil {
 		logger.Error("Failed to parse resource manager config", "err", err)
 
@@ -186,7 +219,64 @@ func New(logger *slog.Logger) (*Manager, error) {
 		}
 	}
 
-	return &Manager{Fetchers: fetchers, Logger: logger}, nil
+	clusterIDs := make([]string, len(config.Clusters))
+	for i, cluster := range config.Clusters {
+		clusterIDs[i] = cluster.ID
+	}
+
+	return &Manager{
+		Fetchers:   fetchers,
+		Logger:     logger,
+		clusterIDs: clusterIDs,
+		isDefault:  len(config.Clusters) == 0,
+	}, nil
+}
+
+// Add adds fetchers of clusters that are not managed by manager yet. It is used to
+// add the clusters provisioned using API server to the ones found in config file.
+// Invalid clusters are skipped and the errors are returned. Default manager is
+// removed once a cluster has been added.
+func (b *Manager) Add(clusters []models.Cluster) error {
+	var registeredManagers []string
+
+	for manager := range factories {
+		if manager != defaultManager {
+			registeredManagers = append(registeredManagers, manager)
+		}
+	}
+
+	var errs error
+
+	for _, cluster := range clusters {
+		if slices.Contains(b.clusterIDs, cluster.ID) {
+			b.Logger.Warn("Skipping provisioned cluster as it is already configured", "id", cluster.ID)
+
+			continue
+		}
+
+		if _, err := checkConfig(registeredManagers, &Config[models.Cluster]{Clusters: []models.Cluster{cluster}}); err != nil {
+			errs = errors.Join(errs, err)
+
+			continue
+		}
+
+		fetcher, err := factories[cluster.Manager](cluster, b.Logger.With("manager", cluster.Manager))
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to setup cluster %s: %w", cluster.ID, err))
+
+			continue
+		}
+
+		if b.isDefault {
+			b.Fetchers = nil
+			b.isDefault = false
+		}
+
+		b.Fetchers = append(b.Fetchers, fetcher)
+		b.clusterIDs = append(b.clusterIDs, cluster.ID)
+	}
+
+	return errs
 }
 
 // FetchUnits implements collection jobs between start and end times.
@@ -268,3 +358,51 @@ func (b Manager) FetchUsersProjects(
 
 	return clusterUsers, clusterProjects, errs
 }
+
+// FetchReservations fetches usage of reservations between start and end times from
+// resource managers that support reservations.
+func (b Manager) FetchReservations(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterReservations, error) {
+	// Measure elapsed time
+	defer common.TimeTrack(time.Now(), "reservations fetcher", b.Logger)
+
+	var clusterReservations []models.ClusterReservations
+
+	var errs error
+
+	var wg sync.WaitGroup
+
+	for _, fetcher := range b.Fetchers {
+		// Skip resource managers that do not support reservations
+		reservationFetcher, ok := fetcher.(ReservationFetcher)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(f ReservationFetcher) {
+			defer wg.Done()
+
+			reservations, err := f.FetchReservations(ctx, start, end)
+
+			reservationFetcherLock.Lock()
+			defer reservationFetcherLock.Unlock()
+
+			if err != nil {
+				errs = errors.Join(errs, err)
+
+				return
+			}
+
+			clusterReservations = append(clusterReservations, reservations...)
+		}(reservationFetcher)
+	}
+
+	wg.Wait()
+
+	return clusterReservations, errs
+}
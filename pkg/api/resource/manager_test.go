@@ -334,3 +334,30 @@ func TestNewManagerWithNoClusters(t *testing.T) {
 	assert.Empty(t, users[0].Users)
 	assert.Empty(t, projects[0].Projects)
 }
+
+func TestManagerAddClusters(t *testing.T) {
+	// Make mock config
+	base.ConfigFilePath = mockConfig(t.TempDir(), "empty_instance")
+
+	// Register mock manager
+	Register("mock", NewMockResourceManager)
+
+	// Create new manager
+	manager, err := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	require.Len(t, manager.Fetchers, 1)
+
+	// Default manager must be replaced and invalid clusters skipped
+	err = manager.Add([]models.Cluster{
+		{ID: "mock-0", Manager: "mock"},
+		{ID: "mock-0", Manager: "mock"},
+		{ID: "unknown-0", Manager: "unknown"},
+	})
+	require.Error(t, err)
+	require.Len(t, manager.Fetchers, 1)
+
+	units, err := manager.FetchUnits(context.Background(), time.Now(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "mock", units[0].Cluster.ID)
+	assert.Len(t, units[0].Units, 1)
+}
@@ -3,6 +3,7 @@ package slurm
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"os/user"
@@ -35,6 +36,9 @@ var (
 		"Z": 1024 * 1024 * 1024 * 1024 * 1024,
 	}
 
+	// Header of batch scripts and environments of jobs in sacct output.
+	scriptHeaderRegex = regexp.MustCompile(`^(?:Batch Script|Environment used) for ([0-9_+.]+)`)
+
 	// Required capabilities to execute SLURM commands.
 	requiredCaps = []string{"cap_setuid", "cap_setgid"}
 )
@@ -133,7 +137,13 @@ func preflightsCLI(slurm *slurmScheduler) error {
 }
 
 // Parse sacct command output and return batchjob slice.
-func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]models.Unit, int) {
+func parseSacctCmdOutput(sacctOutput string, fields []string, start time.Time, end time.Time) ([]models.Unit, int) {
+	// Index of each field in the output
+	fieldMap := make(map[string]int, len(fields))
+	for idx, field := range fields {
+		fieldMap[field] = idx
+	}
+
 	// No header in output
 	sacctOutputLines := strings.Split(sacctOutput, "\n")
 
@@ -156,10 +166,10 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 			var jobStat models.Unit
 
 			components := strings.Split(l, "|")
-			jobid := components[sacctFieldMap["jobidraw"]]
+			jobid := components[fieldMap["jobidraw"]]
 
 			// Ignore if we cannot get all components
-			if len(components) < len(sacctFields) {
+			if len(components) < len(fields) {
 				wg.Done()
 
 				return
@@ -173,7 +183,7 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 			}
 
 			// Ignore jobs that never ran
-			if components[sacctFieldMap["nodelist"]] == "None assigned" {
+			if components[fieldMap["nodelist"]] == "None assigned" {
 				wg.Done()
 
 				return
@@ -181,68 +191,29 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 
 			// Attempt to convert strings to int and ignore any errors in conversion
 			var gidInt, uidInt int64
-			gidInt, _ = strconv.ParseInt(components[sacctFieldMap["gid"]], 10, 64)
-			uidInt, _ = strconv.ParseInt(components[sacctFieldMap["uid"]], 10, 64)
-			// elapsedSeconds, _ = strconv.ParseInt(components[sacctFieldMap["elapsedraw"]], 10, 64)
+			gidInt, _ = strconv.ParseInt(components[fieldMap["gid"]], 10, 64)
+			uidInt, _ = strconv.ParseInt(components[fieldMap["uid"]], 10, 64)
+			// elapsedSeconds, _ = strconv.ParseInt(components[fieldMap["elapsedraw"]], 10, 64)
 
 			// Convert time strings to configured time location
 			eventTS := make(map[string]int64, 3)
 
 			for _, c := range []string{"submit", "start", "end"} {
-				if t, err := time.Parse(base.DatetimezoneLayout, components[sacctFieldMap[c]]); err == nil {
-					components[sacctFieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
+				if t, err := time.Parse(base.DatetimezoneLayout, components[fieldMap[c]]); err == nil {
+					components[fieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
 				}
 
-				eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[sacctFieldMap[c]])
+				eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[fieldMap[c]])
 			}
 
-			// Parse alloctres to get billing, nnodes, ncpus, ngpus and mem
-			var billing, nnodes, ncpus, ngpus int64
-
-			var memString string
+			// Parse alloctres and reqtres into allocation of unit
+			allocation := parseTRES(components[fieldMap["alloctres"]], "")
+			ncpus, _ := allocation["cpus"].(int64)
+			ngpus, _ := allocation["gpus"].(int64)
+			mem, _ := allocation["mem"].(int64)
 
-			for _, elem := range strings.Split(components[sacctFieldMap["alloctres"]], ",") {
-				tresKV := strings.Split(elem, "=")
-				if tresKV[0] == "billing" {
-					billing, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
-
-				if tresKV[0] == "node" {
-					nnodes, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
-
-				if tresKV[0] == "cpu" {
-					ncpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
-				// For MIG devices, it can be gres/gpu:<MIG ID>
-				// https://github.com/SchedMD/slurm/blob/db91ac3046b3b7b845cce4a99127db8c6f14a8e8/testsuite/expect/test39.19#L70
-				// Use a regex gres\/gpu:([^=]+)=(\d+) for identifying number of instances
-				// For the moment, use strings.HasPrefix to identify GPU
-				if strings.HasPrefix(tresKV[0], "gres/gpu") {
-					ngpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
-				}
-
-				if tresKV[0] == "mem" {
-					memString = tresKV[1]
-				}
-			}
-
-			// If mem is not empty string, convert the units [K|M|G|T] into numeric bytes
-			// The following logic covers the cases when memory is of form 200M, 250.5G
-			// and also without unit eg 20000, 40000. When there is no unit we assume
-			// it is already in bytes
-			matches := memRegex.FindStringSubmatch(memString)
-
-			var mem int64
-
-			if len(matches) >= 2 {
-				if memFloat, err := strconv.ParseFloat(matches[1], 64); err == nil {
-					if len(matches) == 3 {
-						if unitConv, ok := toBytes[matches[2]]; ok {
-							mem = int64(memFloat) * unitConv
-						}
-					}
-				}
+			if reqtres := components[fieldMap["reqtres"]]; reqtres != "" {
+				maps.Copy(allocation, parseTRES(reqtres, "req_"))
 			}
 
 			// Assume job's elapsed time during this interval overlaps with interval's
@@ -308,56 +279,63 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 				gpuMemSeconds = elapsedSeconds
 			}
 
-			// Expand nodelist range expressions
-			allNodes := helper.NodelistParser(components[sacctFieldMap["nodelist"]])
-			nodelistExp := strings.Join(allNodes, "|")
+			// Get license time of each allocated license in current interval
+			totalTime := models.MetricMap{
+				"walltime":         models.JSONFloat(elapsedSeconds),
+				"alloc_cputime":    models.JSONFloat(cpuSeconds),
+				"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
+				"alloc_gputime":    models.JSONFloat(gpuSeconds),
+				"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
+			}
 
-			// Allocation
-			allocation := models.Allocation{
-				"nodes":   nnodes,
-				"cpus":    ncpus,
-				"mem":     mem,
-				"gpus":    ngpus,
-				"billing": billing,
+			for key, value := range allocation {
+				if license, ok := strings.CutPrefix(key, "licenses_"); ok {
+					if n, ok := value.(int64); ok {
+						totalTime["alloc_licensetime_"+license] = models.JSONFloat(n * elapsedSeconds)
+					}
+				}
 			}
 
+			// Expand nodelist range expressions
+			allNodes := helper.NodelistParser(components[fieldMap["nodelist"]])
+			nodelistExp := strings.Join(allNodes, "|")
+
 			// Tags
 			tags := models.Tag{
 				"uid":         uidInt,
 				"gid":         gidInt,
-				"partition":   components[sacctFieldMap["partition"]],
-				"qos":         components[sacctFieldMap["qos"]],
-				"exit_code":   components[sacctFieldMap["exitcode"]],
-				"nodelist":    components[sacctFieldMap["nodelist"]],
+				"partition":   components[fieldMap["partition"]],
+				"qos":         components[fieldMap["qos"]],
+				"exit_code":   components[fieldMap["exitcode"]],
+				"nodelist":    components[fieldMap["nodelist"]],
 				"nodelistexp": nodelistExp,
-				"workdir":     components[sacctFieldMap["workdir"]],
+				"workdir":     components[fieldMap["workdir"]],
+			}
+
+			// Add any extra fields requested in config as tags
+			for _, field := range fields[len(sacctFields):] {
+				tags[field] = components[fieldMap[field]]
 			}
 
 			// Make jobStats struct for each job and put it in jobs slice
 			jobStat = models.Unit{
 				ResourceManager: "slurm",
 				UUID:            jobid,
-				Name:            components[sacctFieldMap["jobname"]],
-				Project:         components[sacctFieldMap["account"]],
-				Group:           components[sacctFieldMap["group"]],
-				User:            components[sacctFieldMap["user"]],
-				CreatedAt:       components[sacctFieldMap["submit"]],
-				StartedAt:       components[sacctFieldMap["start"]],
-				EndedAt:         components[sacctFieldMap["end"]],
+				Name:            components[fieldMap["jobname"]],
+				Project:         components[fieldMap["account"]],
+				Group:           components[fieldMap["group"]],
+				User:            components[fieldMap["user"]],
+				CreatedAt:       components[fieldMap["submit"]],
+				StartedAt:       components[fieldMap["start"]],
+				EndedAt:         components[fieldMap["end"]],
 				CreatedAtTS:     eventTS["submit"],
 				StartedAtTS:     eventTS["start"],
 				EndedAtTS:       eventTS["end"],
-				Elapsed:         components[sacctFieldMap["elapsed"]],
-				State:           components[sacctFieldMap["state"]],
+				Elapsed:         components[fieldMap["elapsed"]],
+				State:           components[fieldMap["state"]],
 				Allocation:      allocation,
-				TotalTime: models.MetricMap{
-					"walltime":         models.JSONFloat(elapsedSeconds),
-					"alloc_cputime":    models.JSONFloat(cpuSeconds),
-					"alloc_cpumemtime": models.JSONFloat(cpuMemSeconds),
-					"alloc_gputime":    models.JSONFloat(gpuSeconds),
-					"alloc_gpumemtime": models.JSONFloat(gpuMemSeconds),
-				},
-				Tags: tags,
+				TotalTime:       totalTime,
+				Tags:            tags,
 			}
 
 			jobLock.Lock()
@@ -373,6 +351,212 @@ func parseSacctCmdOutput(sacctOutput string, start time.Time, end time.Time) ([]
 	return jobs, numJobs
 }
 
+// Parse sacct command output of job steps and return steps keyed by job ID.
+func parseSacctStepsCmdOutput(sacctOutput string, loc *time.Location) map[string][]models.UnitStep {
+	// Index of each field in the output
+	fieldMap := make(map[string]int, len(sacctStepFields))
+	for idx, field := range sacctStepFields {
+		fieldMap[field] = idx
+	}
+
+	steps := make(map[string][]models.UnitStep)
+
+	for _, line := range strings.Split(sacctOutput, "\n") {
+		components := strings.Split(line, "|")
+
+		// Ignore if we cannot get all components
+		if len(components) < len(sacctStepFields) {
+			continue
+		}
+
+		// Ignore jobs and keep only their steps
+		jobid, stepid, ok := strings.Cut(components[fieldMap["jobidraw"]], ".")
+		if !ok || stepid == "" {
+			continue
+		}
+
+		// Convert time strings to configured time location
+		eventTS := make(map[string]int64, 2)
+
+		for _, c := range []string{"start", "end"} {
+			if t, err := time.Parse(base.DatetimezoneLayout, components[fieldMap[c]]); err == nil {
+				components[fieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
+			}
+
+			eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[fieldMap[c]])
+		}
+
+		// Times are estimated over the entire lifetime of step as steps are
+		// replaced by their latest state
+		allocation := parseTRES(components[fieldMap["alloctres"]], "")
+		ncpus, _ := allocation["cpus"].(int64)
+		ngpus, _ := allocation["gpus"].(int64)
+		mem, _ := allocation["mem"].(int64)
+
+		elapsedSeconds, _ := strconv.ParseInt(components[fieldMap["elapsedraw"]], 10, 64)
+
+		totalTime := models.MetricMap{
+			"walltime":         models.JSONFloat(elapsedSeconds),
+			"alloc_cputime":    models.JSONFloat(ncpus * elapsedSeconds),
+			"alloc_cpumemtime": models.JSONFloat(mem * elapsedSeconds / toBytes["M"]),
+			"alloc_gputime":    models.JSONFloat(ngpus * elapsedSeconds),
+			"alloc_gpumemtime": models.JSONFloat(0),
+		}
+
+		if ngpus > 0 {
+			totalTime["alloc_gpumemtime"] = models.JSONFloat(elapsedSeconds)
+		}
+
+		steps[jobid] = append(steps[jobid], models.UnitStep{
+			StepID:      stepid,
+			Name:        components[fieldMap["jobname"]],
+			State:       components[fieldMap["state"]],
+			StartedAt:   components[fieldMap["start"]],
+			EndedAt:     components[fieldMap["end"]],
+			StartedAtTS: eventTS["start"],
+			EndedAtTS:   eventTS["end"],
+			Elapsed:     components[fieldMap["elapsed"]],
+			ExitCode:    components[fieldMap["exitcode"]],
+			Nodelist:    components[fieldMap["nodelist"]],
+			Allocation:  allocation,
+			TotalTime:   totalTime,
+		})
+	}
+
+	return steps
+}
+
+// Parse sacct command output of batch scripts or environments and return them
+// keyed by job ID. Each script is preceded by a header with job ID and a separator.
+func parseSacctScriptsCmdOutput(sacctOutput string) map[string]string {
+	scripts := make(map[string]string)
+
+	var jobid string
+
+	var lines []string
+
+	flush := func() {
+		if jobid != "" {
+			scripts[jobid] = strings.Join(lines, "\n")
+		}
+	}
+
+	for _, line := range strings.Split(sacctOutput, "\n") {
+		if matches := scriptHeaderRegex.FindStringSubmatch(line); matches != nil {
+			flush()
+
+			jobid, lines = matches[1], nil
+
+			continue
+		}
+
+		// Skip separator following header
+		if len(lines) == 0 && strings.Trim(line, "-") == "" {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	flush()
+
+	return scripts
+}
+
+// truncateScript returns script truncated to at most maxSize bytes without
+// breaking UTF-8 characters and true when script has been truncated.
+func truncateScript(script string, maxSize int) (string, bool) {
+	script = strings.TrimRight(script, "\n")
+	if len(script) <= maxSize {
+		return script, false
+	}
+
+	return strings.ToValidUTF8(script[:maxSize], ""), true
+}
+
+// parseTRES parses TRES string like billing=80,cpu=160,gres/gpu=8,gres/gpu:a100=8,mem=320G,node=2
+// into allocation. Counts of typed GPUs are stored as gpus_<type>, counts of licenses as
+// licenses_<name> and all keys are prefixed with prefix.
+func parseTRES(tres string, prefix string) models.Allocation {
+	var billing, nnodes, ncpus, ngpus, mem int64
+
+	var memString string
+
+	haveGPUs := false
+	gpuTypes := make(map[string]int64)
+	licenses := make(map[string]int64)
+
+	for _, elem := range strings.Split(tres, ",") {
+		tresKV := strings.SplitN(elem, "=", 2)
+		if len(tresKV) != 2 {
+			continue
+		}
+
+		switch {
+		case tresKV[0] == "billing":
+			billing, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		case tresKV[0] == "node":
+			nnodes, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		case tresKV[0] == "cpu":
+			ncpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
+		case tresKV[0] == "mem":
+			memString = tresKV[1]
+		case tresKV[0] == "gres/gpu":
+			ngpus, _ = strconv.ParseInt(tresKV[1], 10, 64)
+			haveGPUs = true
+		// Typed GPUs are reported as gres/gpu:<type>. For MIG devices, type is
+		// the MIG profile
+		// https://github.com/SchedMD/slurm/blob/db91ac3046b3b7b845cce4a99127db8c6f14a8e8/testsuite/expect/test39.19#L70
+		case strings.HasPrefix(tresKV[0], "gres/gpu:"):
+			gpuTypes[strings.TrimPrefix(tresKV[0], "gres/gpu:")], _ = strconv.ParseInt(tresKV[1], 10, 64)
+		// Licenses are reported as license/<name> when they are tracked as TRES
+		case strings.HasPrefix(tresKV[0], "license/"):
+			licenses[strings.TrimPrefix(tresKV[0], "license/")], _ = strconv.ParseInt(tresKV[1], 10, 64)
+		}
+	}
+
+	// When only typed GPUs are present, total is sum of all types
+	if !haveGPUs {
+		for _, n := range gpuTypes {
+			ngpus += n
+		}
+	}
+
+	// If mem is not empty string, convert the units [K|M|G|T] into numeric bytes
+	// The following logic covers the cases when memory is of form 200M, 250.5G
+	// and also without unit eg 20000, 40000. When there is no unit we assume
+	// it is already in bytes
+	matches := memRegex.FindStringSubmatch(memString)
+
+	if len(matches) >= 2 {
+		if memFloat, err := strconv.ParseFloat(matches[1], 64); err == nil {
+			if len(matches) == 3 {
+				if unitConv, ok := toBytes[matches[2]]; ok {
+					mem = int64(memFloat) * unitConv
+				}
+			}
+		}
+	}
+
+	allocation := models.Allocation{
+		prefix + "nodes":   nnodes,
+		prefix + "cpus":    ncpus,
+		prefix + "mem":     mem,
+		prefix + "gpus":    ngpus,
+		prefix + "billing": billing,
+	}
+
+	for gpuType, n := range gpuTypes {
+		allocation[prefix+"gpus_"+gpuType] = n
+	}
+
+	for license, n := range licenses {
+		allocation[prefix+"licenses_"+license] = n
+	}
+
+	return allocation
+}
+
 // Parse sacctmgr command output and return association.
 func parseSacctMgrCmdOutput(sacctMgrOutput string, currentTime string) ([]models.User, []models.Project) {
 	// No header in output
@@ -485,6 +669,113 @@ func parseSacctMgrCmdOutput(sacctMgrOutput string, currentTime string) ([]models
 	return userModels, projectModels
 }
 
+// Parse sreport reservation utilization command output and return reservations.
+// sreport outputs one line for each TRES of a reservation.
+func parseSreportCmdOutput(sreportOutput string, loc *time.Location) []models.Reservation {
+	var reservations []models.Reservation
+
+	// Index of reservation in slice keyed by name and start time
+	idx := make(map[string]int)
+
+	for _, line := range strings.Split(sreportOutput, "\n") {
+		components := strings.Split(line, "|")
+
+		// Ignore if we cannot get all components
+		if len(components) < len(sreportFields) {
+			continue
+		}
+
+		// Convert time strings to configured time location
+		eventTS := make(map[string]int64, 2)
+
+		for _, c := range []string{"start", "end"} {
+			if t, err := time.Parse(base.DatetimezoneLayout, components[sreportFieldMap[c]]); err == nil {
+				components[sreportFieldMap[c]] = t.In(loc).Format(base.DatetimezoneLayout)
+			}
+
+			eventTS[c] = helper.TimeToTimestamp(base.DatetimezoneLayout, components[sreportFieldMap[c]])
+		}
+
+		name := components[sreportFieldMap["name"]]
+		key := name + "|" + components[sreportFieldMap["start"]]
+
+		i, ok := idx[key]
+		if !ok {
+			reservations = append(reservations, models.Reservation{
+				ResourceManager: "slurm",
+				Name:            name,
+				StartedAt:       components[sreportFieldMap["start"]],
+				EndedAt:         components[sreportFieldMap["end"]],
+				StartedAtTS:     eventTS["start"],
+				EndedAtTS:       eventTS["end"],
+				Nodes:           components[sreportFieldMap["nodes"]],
+				Allocation:      models.Allocation{},
+				ReservedTime:    models.MetricMap{},
+				AllocatedTime:   models.MetricMap{},
+				IdleTime:        models.MetricMap{},
+			})
+			i = len(reservations) - 1
+			idx[key] = i
+		}
+
+		// Use TRES names as keys. gres/gpu will be stored as gres_gpu
+		tres := strings.ReplaceAll(components[sreportFieldMap["tresname"]], "/", "_")
+		if tres == "" {
+			continue
+		}
+
+		count, _ := strconv.ParseInt(components[sreportFieldMap["trescount"]], 10, 64)
+		reserved, _ := strconv.ParseFloat(components[sreportFieldMap["trestime"]], 64)
+		allocated, _ := strconv.ParseFloat(components[sreportFieldMap["allocated"]], 64)
+		idle, _ := strconv.ParseFloat(components[sreportFieldMap["idle"]], 64)
+
+		reservations[i].Allocation[tres] = count
+		reservations[i].ReservedTime[tres] = models.JSONFloat(reserved)
+		reservations[i].AllocatedTime[tres] = models.JSONFloat(allocated)
+		reservations[i].IdleTime[tres] = models.JSONFloat(idle)
+	}
+
+	return reservations
+}
+
+// Parse scontrol show reservation command output and return a map of reservation
+// name to accounts that can use the reservation. Excluded accounts are ignored.
+func parseScontrolResvCmdOutput(scontrolOutput string) map[string]string {
+	accounts := make(map[string]string)
+
+	for _, line := range strings.Split(scontrolOutput, "\n") {
+		var name string
+
+		var resvAccounts []string
+
+		for _, field := range strings.Fields(line) {
+			key, value, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+
+			switch key {
+			case "ReservationName":
+				name = value
+			case "Accounts":
+				for _, account := range strings.Split(value, ",") {
+					if account == "" || account == "(null)" || strings.HasPrefix(account, "-") {
+						continue
+					}
+
+					resvAccounts = append(resvAccounts, account)
+				}
+			}
+		}
+
+		if name != "" {
+			accounts[name] = strings.Join(resvAccounts, ",")
+		}
+	}
+
+	return accounts
+}
+
 // runSacctCmd executes sacct command and return output.
 func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time) ([]byte, error) {
 	// If we are fetching historical data, do not use RUNNING state as it can report
@@ -502,9 +793,6 @@ func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time)
 		states = slurmStates
 	}
 
-	// sacct path
-	sacctPath := filepath.Join(s.cluster.CLI.Path, "sacct")
-
 	// Use SLURM_TIME_FORMAT env var to get timezone offset
 	env := []string{"SLURM_TIME_FORMAT=%Y-%m-%dT%H:%M:%S%z"}
 	for name, value := range s.cluster.CLI.EnvVars {
@@ -514,45 +802,61 @@ func (s *slurmScheduler) runSacctCmd(ctx context.Context, start, end time.Time)
 	// Use jobIDRaw that outputs the array jobs as regular job IDs instead of id_array format
 	args := []string{
 		"-D", "-X", "--noheader", "--allusers", "--parsable2",
-		"--format", strings.Join(sacctFields, ","),
+		"--format", strings.Join(s.sacct.Fields, ","),
 		"--state", strings.Join(states, ","),
 		"--starttime", start.Format(base.DatetimeLayout),
 		"--endtime", end.Format(base.DatetimeLayout),
 	}
 
-	// Run command as slurm user
-	if s.cmdExecMode == capabilityMode {
-		// Get security context
-		var securityCtx *security.SecurityContext
+	// Fetch jobs from all clusters of federation
+	if len(s.sacct.Clusters) > 0 {
+		args = append(args, "-M", strings.Join(s.sacct.Clusters, ","))
+	}
 
-		var ok bool
-		if securityCtx, ok = s.securityContexts[slurmExecCmdCtx]; !ok {
-			return nil, security.ErrNoSecurityCtx
-		}
+	args = append(args, s.sacct.ExtraArgs...)
 
-		cmd := []string{sacctPath}
-		cmd = append(cmd, args...)
+	return s.runCmd(ctx, "sacct", args, env)
+}
 
-		// security context data
-		dataPtr := &security.ExecSecurityCtxData{
-			Context: ctx,
-			Cmd:     cmd,
-			Environ: env,
-			Logger:  s.logger,
-			UID:     0,
-			GID:     0,
-		}
+// runSacctStepsCmd executes sacct command to get steps of jobIDs and return output.
+func (s *slurmScheduler) runSacctStepsCmd(ctx context.Context, jobIDs []string) ([]byte, error) {
+	// Use SLURM_TIME_FORMAT env var to get timezone offset
+	env := []string{"SLURM_TIME_FORMAT=%Y-%m-%dT%H:%M:%S%z"}
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
 
-		return executeInSecurityContext(securityCtx, dataPtr)
-	} else if s.cmdExecMode == sudoMode {
-		// Important that we need to export env as well as we set environment variables in the
-		// command execution
-		args = append([]string{"-E", sacctPath}, args...)
+	// Without -X flag, sacct reports steps of jobs along with jobs
+	args := []string{
+		"--noheader", "--allusers", "--parsable2",
+		"--format", strings.Join(sacctStepFields, ","),
+		"--jobs", strings.Join(jobIDs, ","),
+	}
 
-		return internal_osexec.ExecuteContext(ctx, sudoMode, args, env)
+	// Fetch jobs from all clusters of federation
+	if len(s.sacct.Clusters) > 0 {
+		args = append(args, "-M", strings.Join(s.sacct.Clusters, ","))
 	}
 
-	return internal_osexec.ExecuteContext(ctx, sacctPath, args, env)
+	return s.runCmd(ctx, "sacct", args, env)
+}
+
+// runSacctScriptsCmd executes sacct command with flag to get batch scripts or
+// environments of jobIDs and return output.
+func (s *slurmScheduler) runSacctScriptsCmd(ctx context.Context, jobIDs []string, flag string) ([]byte, error) {
+	env := []string{}
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	args := []string{flag, "--jobs", strings.Join(jobIDs, ",")}
+
+	// Fetch jobs from all clusters of federation
+	if len(s.sacct.Clusters) > 0 {
+		args = append(args, "-M", strings.Join(s.sacct.Clusters, ","))
+	}
+
+	return s.runCmd(ctx, "sacct", args, env)
 }
 
 // Run sacctmgr command and return output.
@@ -560,15 +864,53 @@ func (s *slurmScheduler) runSacctMgrCmd(ctx context.Context) ([]byte, error) {
 	// Use jobIDRaw that outputs the array jobs as regular job IDs instead of id_array format
 	args := []string{"--parsable2", "--noheader", "list", "associations", "format=Account,User"}
 
-	// sacct path
-	sacctMgrPath := filepath.Join(s.cluster.CLI.Path, "sacctmgr")
+	// Use SLURM_TIME_FORMAT env var to get timezone offset
+	var env []string
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
 
+	return s.runCmd(ctx, "sacctmgr", args, env)
+}
+
+// runSreportCmd executes sreport command to get reservations utilization between
+// start and end times and returns output.
+func (s *slurmScheduler) runSreportCmd(ctx context.Context, start, end time.Time) ([]byte, error) {
 	// Use SLURM_TIME_FORMAT env var to get timezone offset
+	env := []string{"SLURM_TIME_FORMAT=%Y-%m-%dT%H:%M:%S%z"}
+	for name, value := range s.cluster.CLI.EnvVars {
+		env = append(env, fmt.Sprintf("%s=%s", name, value))
+	}
+
+	// Report times in TRES-seconds
+	args := []string{
+		"--noheader", "--parsable2", "-t", "Seconds",
+		"reservation", "utilization",
+		"start=" + start.Format(base.DatetimeLayout),
+		"end=" + end.Format(base.DatetimeLayout),
+		"format=" + strings.Join(sreportFields, ","),
+	}
+
+	return s.runCmd(ctx, "sreport", args, env)
+}
+
+// runScontrolResvCmd executes scontrol command to get current reservations and
+// returns output.
+func (s *slurmScheduler) runScontrolResvCmd(ctx context.Context) ([]byte, error) {
 	var env []string
 	for name, value := range s.cluster.CLI.EnvVars {
 		env = append(env, fmt.Sprintf("%s=%s", name, value))
 	}
 
+	return s.runCmd(ctx, "scontrol", []string{"--oneliner", "show", "reservation"}, env)
+}
+
+// runCmd executes SLURM command found in CLI path using the configured execution
+// mode and returns output.
+func (s *slurmScheduler) runCmd(ctx context.Context, name string, args []string, env []string) ([]byte, error) {
+	// Command path
+	cmdPath := filepath.Join(s.cluster.CLI.Path, name)
+
 	// Run command as slurm user
 	if s.cmdExecMode == capabilityMode {
 		// Get security context
@@ -579,7 +921,7 @@ func (s *slurmScheduler) runSacctMgrCmd(ctx context.Context) ([]byte, error) {
 			return nil, security.ErrNoSecurityCtx
 		}
 
-		cmd := []string{sacctMgrPath}
+		cmd := []string{cmdPath}
 		cmd = append(cmd, args...)
 
 		// security context data
@@ -596,12 +938,12 @@ func (s *slurmScheduler) runSacctMgrCmd(ctx context.Context) ([]byte, error) {
 	} else if s.cmdExecMode == sudoMode {
 		// Important that we need to export env as well as we set environment variables in the
 		// command execution
-		args = append([]string{"-E", sacctMgrPath}, args...)
+		args = append([]string{"-E", cmdPath}, args...)
 
 		return internal_osexec.ExecuteContext(ctx, sudoMode, args, env)
 	}
 
-	return internal_osexec.ExecuteContext(ctx, sacctMgrPath, args, env)
+	return internal_osexec.ExecuteContext(ctx, cmdPath, args, env)
 }
 
 // executeInSecurityContext executes SLURM command within a security context.
@@ -7,8 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -30,39 +32,128 @@ func TestPreflightsCLI(t *testing.T) {
 }
 
 func TestParseSacctCmdOutput(t *testing.T) {
-	units, numUnits := parseSacctCmdOutput(sacctCmdOutput, start, end)
+	units, numUnits := parseSacctCmdOutput(sacctCmdOutput, sacctFields, start, end)
 	require.ElementsMatch(t, units, expectedBatchJobs)
 	require.Equal(t, 2, numUnits)
 
 	// Job finished in past
-	sacctCmdOutput1 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-20T14:37:02+0100|2023-02-20T14:37:07+0100|2023-02-20T15:37:07+0100|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
-	units, _ = parseSacctCmdOutput(sacctCmdOutput1, start, end)
+	sacctCmdOutput1 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-20T14:37:02+0100|2023-02-20T14:37:07+0100|2023-02-20T15:37:07+0100|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput1, sacctFields, start, end)
 	// Check if elapsed time corresponds to real elapsed time of job
 	assert.InEpsilon(t, 3600, float64(units[0].TotalTime["walltime"]), 0)
 
 	// Job created but not started
-	sacctCmdOutput2 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:37:02+0100|NA|NA|01:49:22|3000|0:0|PENDING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
-	units, _ = parseSacctCmdOutput(sacctCmdOutput2, start, end)
+	sacctCmdOutput2 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:37:02+0100|NA|NA|01:49:22|3000|0:0|PENDING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput2, sacctFields, start, end)
 	// Check if elapsed time corresponds to real elapsed time of job
 	assert.Equal(t, 0, int(units[0].TotalTime["walltime"]))
 
 	// Job started inside current interval
-	sacctCmdOutput3 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:10:00+0100|2023-02-21T15:10:00+0100|NA|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
-	units, _ = parseSacctCmdOutput(sacctCmdOutput3, start, end)
+	sacctCmdOutput3 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:10:00+0100|2023-02-21T15:10:00+0100|NA|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput3, sacctFields, start, end)
 	// Check if elapsed time corresponds to real elapsed time of job
 	assert.InEpsilon(t, 300, float64(units[0].TotalTime["walltime"]), 0)
 
 	// Job ended inside current interval
-	sacctCmdOutput4 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:10:00+0100|2023-02-21T14:10:00+0100|2023-02-21T15:10:00+0100|01:49:22|3000|0:0|COMPLETED|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
-	units, _ = parseSacctCmdOutput(sacctCmdOutput4, start, end)
+	sacctCmdOutput4 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:10:00+0100|2023-02-21T14:10:00+0100|2023-02-21T15:10:00+0100|01:49:22|3000|0:0|COMPLETED|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput4, sacctFields, start, end)
 	// Check if elapsed time corresponds to real elapsed time of job
 	assert.InEpsilon(t, 600, float64(units[0].TotalTime["walltime"]), 0)
 
 	// Job started and ended inside current interval
-	sacctCmdOutput5 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:10:00+0100|2023-02-21T15:10:00+0100|2023-02-21T15:12:00+0100|01:49:22|3000|0:0|COMPLETED|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
-	units, _ = parseSacctCmdOutput(sacctCmdOutput5, start, end)
+	sacctCmdOutput5 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:10:00+0100|2023-02-21T15:10:00+0100|2023-02-21T15:12:00+0100|01:49:22|3000|0:0|COMPLETED|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320G,node=2|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput5, sacctFields, start, end)
 	// Check if elapsed time corresponds to real elapsed time of job
 	assert.InEpsilon(t, 120, float64(units[0].TotalTime["walltime"]), 0)
+
+	// Job with licenses
+	sacctCmdOutput6 := `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T15:10:00+0100|2023-02-21T15:10:00+0100|2023-02-21T15:12:00+0100|01:49:22|3000|0:0|COMPLETED|billing=80,cpu=160,license/matlab=2,mem=320G,node=2|billing=80,cpu=160,license/matlab=2,mem=320G,node=2|compute-0|test_script1|/home/usr`
+	units, _ = parseSacctCmdOutput(sacctCmdOutput6, sacctFields, start, end)
+	// Check if license allocation and time are estimated
+	assert.Equal(t, int64(2), units[0].Allocation["licenses_matlab"])
+	assert.Equal(t, int64(2), units[0].Allocation["req_licenses_matlab"])
+	assert.InEpsilon(t, 240, float64(units[0].TotalTime["alloc_licensetime_matlab"]), 0)
+}
+
+func TestParseTRES(t *testing.T) {
+	// MIG instances are reported only as typed GPUs
+	allocation := parseTRES("billing=4,cpu=8,gres/gpu:1g.5gb=2,gres/gpu:3g.20gb=1,mem=16G,node=1", "req_")
+	assert.Equal(t, models.Allocation{
+		"req_billing":      int64(4),
+		"req_cpus":         int64(8),
+		"req_gpus":         int64(3),
+		"req_gpus_1g.5gb":  int64(2),
+		"req_gpus_3g.20gb": int64(1),
+		"req_mem":          int64(17179869184),
+		"req_nodes":        int64(1),
+	}, allocation)
+
+	// Untyped GPU count takes precedence over typed ones
+	allocation = parseTRES("cpu=2,gres/gpu=2,gres/gpu:a100=1,gres/gpu:v100=1,node=1", "")
+	assert.Equal(t, int64(2), allocation["gpus"])
+	assert.Equal(t, int64(1), allocation["gpus_a100"])
+	assert.Equal(t, int64(1), allocation["gpus_v100"])
+
+	// Malformed TRES must not panic
+	allocation = parseTRES("", "")
+	assert.Equal(t, int64(0), allocation["cpus"])
+}
+
+func TestParseSacctStepsCmdOutput(t *testing.T) {
+	sacctOutput := `1479763|test_script1|RUNNING|2023-02-21T14:37:07+0100|Unknown|00:37:53|2273|0:0|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-[0-1]
+1479763.batch|batch|RUNNING|2023-02-21T14:37:07+0100|Unknown|00:37:53|2273|0:0|cpu=80,gres/gpu=4,mem=160G,node=1|compute-0
+1479763.0|train|COMPLETED|2023-02-21T14:38:00+0100|2023-02-21T14:48:00+0100|00:10:00|600|0:0|cpu=160,gres/gpu=8,mem=320G,node=2|compute-[0-1]
+1481508.extern|extern|COMPLETED|2023-02-21T13:49:06+0100|2023-02-21T15:10:23+0100|01:21:17|4877|0:0|billing=1,cpu=2,mem=4M,node=1|compute-0`
+
+	steps := parseSacctStepsCmdOutput(sacctOutput, time.UTC)
+	require.Len(t, steps["1479763"], 2)
+	require.Len(t, steps["1481508"], 1)
+
+	step := steps["1479763"][1]
+	assert.Equal(t, "0", step.StepID)
+	assert.Equal(t, "train", step.Name)
+	assert.Equal(t, "2023-02-21T13:38:00+0000", step.StartedAt)
+	assert.Equal(t, int64(1676986680000), step.StartedAtTS)
+	assert.Equal(t, "compute-[0-1]", step.Nodelist)
+	assert.Equal(t, models.MetricMap{
+		"walltime":         models.JSONFloat(600),
+		"alloc_cputime":    models.JSONFloat(96000),
+		"alloc_cpumemtime": models.JSONFloat(196608000),
+		"alloc_gputime":    models.JSONFloat(4800),
+		"alloc_gpumemtime": models.JSONFloat(600),
+	}, step.TotalTime)
+
+	// Steps without GPUs must not have GPU times
+	assert.Equal(t, "extern", steps["1481508"][0].StepID)
+	assert.Equal(t, models.JSONFloat(0), steps["1481508"][0].TotalTime["alloc_gputime"])
+}
+
+func TestParseSacctScriptsCmdOutput(t *testing.T) {
+	sacctOutput := `Batch Script for 1479763
+--------------------------------------------------------------------------------
+#!/bin/bash
+#SBATCH --gres=gpu:8
+
+srun python train.py
+
+Batch Script for 1481508
+--------------------------------------------------------------------------------
+#!/bin/bash
+hostname
+`
+
+	scripts := parseSacctScriptsCmdOutput(sacctOutput)
+	require.Len(t, scripts, 2)
+	assert.Equal(t, "#!/bin/bash\nhostname\n", scripts["1481508"])
+
+	script, truncated := truncateScript(scripts["1479763"], 1024)
+	assert.Equal(t, "#!/bin/bash\n#SBATCH --gres=gpu:8\n\nsrun python train.py", script)
+	assert.False(t, truncated)
+
+	// Truncated scripts must be valid UTF-8
+	script, truncated = truncateScript("echo héllo", 7)
+	assert.Equal(t, "echo h", script)
+	assert.True(t, truncated)
 }
 
 func TestParseSacctMgrCmdOutput(t *testing.T) {
@@ -70,3 +161,52 @@ func TestParseSacctMgrCmdOutput(t *testing.T) {
 	require.ElementsMatch(t, expectedUsers, users)
 	require.ElementsMatch(t, expectedProjects, projects)
 }
+
+func TestParseSreportCmdOutput(t *testing.T) {
+	sreportOutput := `resv1|2023-02-21T14:00:00+0100|2023-02-22T14:00:00+0100|compute-[0-1]|cpu|64|230400|30400|200000
+resv1|2023-02-21T14:00:00+0100|2023-02-22T14:00:00+0100|compute-[0-1]|gres/gpu|8|28800|3800|25000
+resv2|2023-02-21T15:00:00+0100|2023-02-21T16:00:00+0100|compute-2|cpu|32|57600|57600|0`
+
+	reservations := parseSreportCmdOutput(sreportOutput, time.UTC)
+	expected := []models.Reservation{
+		{
+			ResourceManager: "slurm",
+			Name:            "resv1",
+			StartedAt:       "2023-02-21T13:00:00+0000",
+			EndedAt:         "2023-02-22T13:00:00+0000",
+			StartedAtTS:     1676984400000,
+			EndedAtTS:       1677070800000,
+			Nodes:           "compute-[0-1]",
+			Allocation:      models.Allocation{"cpu": int64(64), "gres_gpu": int64(8)},
+			ReservedTime:    models.MetricMap{"cpu": 230400, "gres_gpu": 28800},
+			AllocatedTime:   models.MetricMap{"cpu": 30400, "gres_gpu": 3800},
+			IdleTime:        models.MetricMap{"cpu": 200000, "gres_gpu": 25000},
+		},
+		{
+			ResourceManager: "slurm",
+			Name:            "resv2",
+			StartedAt:       "2023-02-21T14:00:00+0000",
+			EndedAt:         "2023-02-21T15:00:00+0000",
+			StartedAtTS:     1676988000000,
+			EndedAtTS:       1676991600000,
+			Nodes:           "compute-2",
+			Allocation:      models.Allocation{"cpu": int64(32)},
+			ReservedTime:    models.MetricMap{"cpu": 57600},
+			AllocatedTime:   models.MetricMap{"cpu": 57600},
+			IdleTime:        models.MetricMap{"cpu": 0},
+		},
+	}
+	assert.Equal(t, expected, reservations)
+}
+
+func TestParseScontrolResvCmdOutput(t *testing.T) {
+	scontrolOutput := `ReservationName=resv1 StartTime=2023-02-21T14:00:00 EndTime=2023-02-22T14:00:00 Duration=1-00:00:00 Nodes=compute-[0-1] NodeCnt=2 CoreCnt=64 Features=(null) PartitionName=(null) Flags=SPEC_NODES TRES=cpu=64 Users=(null) Groups=(null) Accounts=prj1 Licenses=(null) State=ACTIVE
+ReservationName=resv2 StartTime=2023-02-21T15:00:00 EndTime=2023-02-21T16:00:00 Duration=01:00:00 Nodes=compute-2 NodeCnt=1 CoreCnt=32 Features=(null) PartitionName=(null) Flags=SPEC_NODES TRES=cpu=32 Users=usr1 Groups=(null) Accounts=(null) Licenses=(null) State=INACTIVE
+ReservationName=resv3 StartTime=2023-02-21T15:00:00 EndTime=2023-02-21T16:00:00 Duration=01:00:00 Nodes=compute-3 NodeCnt=1 CoreCnt=32 Features=(null) PartitionName=(null) Flags=SPEC_NODES TRES=cpu=32 Users=(null) Groups=(null) Accounts=prj2,-prj3,prj4 Licenses=(null) State=INACTIVE`
+
+	accounts := parseScontrolResvCmdOutput(scontrolOutput)
+	assert.Equal(t, map[string]string{"resv1": "prj1", "resv2": "", "resv3": "prj2,prj4"}, accounts)
+
+	// No reservations
+	assert.Empty(t, parseScontrolResvCmdOutput("No reservations in the system"))
+}
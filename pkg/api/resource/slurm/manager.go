@@ -6,6 +6,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/api/resource"
+	"github.com/prometheus/common/model"
 )
 
 // Execution modes.
@@ -31,6 +34,33 @@ const (
 	slurmExecCmdCtx = "slurm_exec_cmd"
 )
 
+// sacctConfig contains the customizations of sacct command of a given cluster.
+type sacctConfig struct {
+	// Fields are requested from sacct in addition to the ones that CEEMS needs
+	// and they are added to the tags of units.
+	Fields []string `yaml:"fields"`
+	// ExtraArgs are appended to the sacct command.
+	ExtraArgs []string `yaml:"extra_args"`
+	// FetchWindow splits the sacct queries into windows of this duration.
+	FetchWindow model.Duration `yaml:"fetch_window"`
+	// Clusters of SLURM federation to fetch jobs from using -M flag.
+	Clusters []string `yaml:"clusters"`
+	// Steps enables fetching the steps of jobs.
+	Steps bool `yaml:"steps"`
+	// Scripts enables fetching the batch scripts of jobs.
+	Scripts bool `yaml:"scripts"`
+	// ScriptsEnvironment enables fetching the environment of jobs along with scripts.
+	ScriptsEnvironment bool `yaml:"scripts_environment"`
+	// MaxScriptSize is the maximum size in bytes of scripts and environments.
+	// Larger ones are truncated.
+	MaxScriptSize int `yaml:"max_script_size"`
+}
+
+// slurmConfig is the SLURM specific extra_config of cluster.
+type slurmConfig struct {
+	Sacct sacctConfig `yaml:"sacct"`
+}
+
 // slurmScheduler is the struct containing the configuration of a given slurm cluster.
 type slurmScheduler struct {
 	logger           *slog.Logger
@@ -38,23 +68,38 @@ type slurmScheduler struct {
 	fetchMode        string // Whether to fetch from REST API or CLI commands
 	cmdExecMode      string // If sacct mode is chosen, the mode of executing command, ie, sudo or cap or native
 	securityContexts map[string]*security.SecurityContext
+	sacct            sacctConfig
 }
 
 const slurmBatchScheduler = "slurm"
 
+// Number of jobs whose steps or scripts are fetched in a single sacct command.
+const sacctJobsBatchSize = 500
+
+// Default maximum size in bytes of scripts and environments of jobs.
+const defaultMaxScriptSize = 64 * 1024
+
 var (
 	jobLock     = sync.RWMutex{}
 	assocLock   = sync.RWMutex{}
 	sacctFields = []string{
 		"jobidraw", "partition", "qos", "account", "group", "gid", "user", "uid",
 		"submit", "start", "end", "elapsed", "elapsedraw", "exitcode", "state",
-		"alloctres", "nodelist", "jobname", "workdir",
+		"alloctres", "reqtres", "nodelist", "jobname", "workdir",
+	}
+	sacctStepFields = []string{
+		"jobidraw", "jobname", "state", "start", "end", "elapsed", "elapsedraw",
+		"exitcode", "alloctres", "nodelist",
 	}
 	slurmStates = []string{
 		"CANCELLED", "COMPLETED", "FAILED", "NODE_FAIL", "PREEMPTED", "TIMEOUT",
 		"RUNNING",
 	}
-	sacctFieldMap = make(map[string]int, len(sacctFields))
+	sreportFields = []string{
+		"name", "start", "end", "nodes", "tresname", "trescount", "trestime",
+		"allocated", "idle",
+	}
+	sreportFieldMap = make(map[string]int, len(sreportFields))
 )
 
 func init() {
@@ -62,8 +107,8 @@ func init() {
 	resource.Register(slurmBatchScheduler, New)
 
 	// Convert slice to map with index as value
-	for idx, field := range sacctFields {
-		sacctFieldMap[field] = idx
+	for idx, field := range sreportFields {
+		sreportFieldMap[field] = idx
 	}
 }
 
@@ -76,6 +121,36 @@ func New(cluster models.Cluster, logger *slog.Logger) (resource.Fetcher, error)
 		securityContexts: make(map[string]*security.SecurityContext),
 	}
 
+	// Read sacct customizations from extra_config
+	config := slurmConfig{}
+	if err := cluster.Extra.Decode(&config); err != nil {
+		logger.Error("Failed to decode extra_config for SLURM cluster", "id", cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	// Always request the fields that are needed to build units
+	fields := slices.Clone(sacctFields)
+
+	// Originating cluster of jobs is needed for federated SLURM
+	if len(config.Sacct.Clusters) > 0 {
+		config.Sacct.Fields = append(config.Sacct.Fields, "cluster")
+	}
+
+	for _, field := range config.Sacct.Fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+
+	config.Sacct.Fields = fields
+
+	if config.Sacct.MaxScriptSize <= 0 {
+		config.Sacct.MaxScriptSize = defaultMaxScriptSize
+	}
+
+	slurmScheduler.sacct = config.Sacct
+
 	if err := preflightChecks(&slurmScheduler); err != nil {
 		return nil, err
 	}
@@ -127,34 +202,225 @@ func (s *slurmScheduler) FetchUsersProjects(
 		}
 
 		return []models.ClusterUsers{
-				{Cluster: s.cluster, Users: users},
-			}, []models.ClusterProjects{
-				{Cluster: s.cluster, Projects: projects},
-			}, nil
+			{Cluster: s.cluster, Users: users},
+		}, []models.ClusterProjects{
+			{Cluster: s.cluster, Projects: projects},
+		}, nil
 	}
 
 	return nil, nil, fmt.Errorf("unknown fetch mode for projects for SLURM cluster %s", s.cluster.ID)
 }
 
+// FetchReservations fetches usage of SLURM reservations.
+func (s *slurmScheduler) FetchReservations(
+	ctx context.Context,
+	start time.Time,
+	end time.Time,
+) ([]models.ClusterReservations, error) {
+	if s.fetchMode == cliMode {
+		reservations, err := s.fetchFromSreport(ctx, start, end)
+		if err != nil {
+			s.logger.Error("Failed to execute SLURM sreport command", "cluster_id", s.cluster.ID, "err", err)
+
+			return nil, err
+		}
+
+		return []models.ClusterReservations{{Cluster: s.cluster, Reservations: reservations}}, nil
+	}
+
+	return nil, fmt.Errorf("unknown fetch mode for reservations SLURM cluster %s", s.cluster.ID)
+}
+
 // Get jobs from slurm sacct command.
 func (s *slurmScheduler) fetchFromSacct(ctx context.Context, start time.Time, end time.Time) ([]models.Unit, error) {
-	// startTime := start.Format(base.DatetimeLayout)
-	// endTime := end.Format(base.DatetimeLayout)
-	// Execute sacct command between start and end times
-	sacctOutput, err := s.runSacctCmd(ctx, start, end)
-	if err != nil {
-		s.logger.Error("Failed to run sacct command", "cluster_id", s.cluster.ID, "err", err)
+	// When fetch window is not configured, fetch entire period in one go
+	window := time.Duration(s.sacct.FetchWindow)
+	if window <= 0 {
+		window = end.Sub(start)
+	}
+
+	var jobs []models.Unit
+
+	// Jobs that span across windows are reported in each window. Keep only the
+	// latest record of them
+	seen := make(map[string]int)
+
+	for windowStart := start; windowStart.Before(end); windowStart = windowStart.Add(window) {
+		windowEnd := windowStart.Add(window)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+
+		// Execute sacct command between start and end times of window
+		sacctOutput, err := s.runSacctCmd(ctx, windowStart, windowEnd)
+		if err != nil {
+			s.logger.Error("Failed to run sacct command", "cluster_id", s.cluster.ID, "err", err)
 
-		return []models.Unit{}, err
+			return []models.Unit{}, err
+		}
+
+		// Parse sacct output and create BatchJob structs slice
+		// Metrics of units are estimated over entire period and not the window
+		windowJobs, numJobs := parseSacctCmdOutput(string(sacctOutput), s.sacct.Fields, start, end)
+
+		// In a federation, sibling jobs share the same job ID on different clusters
+		if len(s.sacct.Clusters) > 0 {
+			windowJobs = dedupeSiblingJobs(windowJobs)
+		}
+		s.logger.Debug(
+			"SLURM jobs fetched in window", "cluster_id", s.cluster.ID,
+			"start", windowStart, "end", windowEnd, "num_jobs", numJobs,
+		)
+
+		for _, job := range windowJobs {
+			if job.UUID == "" {
+				continue
+			}
+
+			if idx, ok := seen[job.UUID]; ok {
+				jobs[idx] = job
+
+				continue
+			}
+
+			seen[job.UUID] = len(jobs)
+			jobs = append(jobs, job)
+		}
+	}
+
+	s.logger.Info("SLURM jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", len(jobs))
+
+	if s.sacct.Steps {
+		s.fetchStepsFromSacct(ctx, jobs, end.Location())
 	}
 
-	// Parse sacct output and create BatchJob structs slice
-	jobs, numJobs := parseSacctCmdOutput(string(sacctOutput), start, end)
-	s.logger.Info("SLURM jobs fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_jobs", numJobs)
+	if s.sacct.Scripts {
+		s.fetchScriptsFromSacct(ctx, jobs, start)
+	}
 
 	return jobs, nil
 }
 
+// Get steps of jobs from slurm sacct command. Failing to fetch steps is not
+// fatal and steps of jobs will be updated in the next update.
+func (s *slurmScheduler) fetchStepsFromSacct(ctx context.Context, jobs []models.Unit, loc *time.Location) {
+	numSteps := 0
+
+	// Query steps in batches of jobs to keep the command line short
+	for batch := range slices.Chunk(jobs, sacctJobsBatchSize) {
+		jobIDs := make([]string, len(batch))
+		for i, job := range batch {
+			jobIDs[i] = job.UUID
+		}
+
+		sacctOutput, err := s.runSacctStepsCmd(ctx, jobIDs)
+		if err != nil {
+			s.logger.Warn("Failed to run sacct command for job steps", "cluster_id", s.cluster.ID, "err", err)
+
+			return
+		}
+
+		steps := parseSacctStepsCmdOutput(string(sacctOutput), loc)
+		for i := range batch {
+			batch[i].Steps = steps[batch[i].UUID]
+			numSteps += len(batch[i].Steps)
+		}
+	}
+
+	s.logger.Debug("SLURM job steps fetched", "cluster_id", s.cluster.ID, "num_steps", numSteps)
+}
+
+// Get batch scripts and optionally environments of jobs that started after start
+// from slurm sacct command. Scripts of jobs do not change and hence, scripts of
+// jobs that started before start have been fetched in previous updates. Failing
+// to fetch scripts is not fatal.
+func (s *slurmScheduler) fetchScriptsFromSacct(ctx context.Context, jobs []models.Unit, start time.Time) {
+	var newJobs []*models.Unit
+
+	for i := range jobs {
+		if jobs[i].StartedAtTS >= start.UnixMilli() {
+			newJobs = append(newJobs, &jobs[i])
+		}
+	}
+
+	numScripts := 0
+
+	for batch := range slices.Chunk(newJobs, sacctJobsBatchSize) {
+		jobIDs := make([]string, len(batch))
+		for i, job := range batch {
+			jobIDs[i] = job.UUID
+		}
+
+		sacctOutput, err := s.runSacctScriptsCmd(ctx, jobIDs, "--batch-script")
+		if err != nil {
+			s.logger.Warn("Failed to run sacct command for job scripts", "cluster_id", s.cluster.ID, "err", err)
+
+			return
+		}
+
+		scripts := parseSacctScriptsCmdOutput(string(sacctOutput))
+
+		var envs map[string]string
+
+		if s.sacct.ScriptsEnvironment {
+			if sacctOutput, err = s.runSacctScriptsCmd(ctx, jobIDs, "--env-vars"); err != nil {
+				s.logger.Warn("Failed to run sacct command for job environments", "cluster_id", s.cluster.ID, "err", err)
+			} else {
+				envs = parseSacctScriptsCmdOutput(string(sacctOutput))
+			}
+		}
+
+		for _, job := range batch {
+			script, ok := scripts[job.UUID]
+			if !ok {
+				continue
+			}
+
+			job.Script = &models.UnitScript{}
+			job.Script.Script, job.Script.Truncated = truncateScript(script, s.sacct.MaxScriptSize)
+
+			var truncated bool
+
+			job.Script.Environment, truncated = truncateScript(envs[job.UUID], s.sacct.MaxScriptSize)
+			job.Script.Truncated = job.Script.Truncated || truncated
+			numScripts++
+		}
+	}
+
+	s.logger.Debug("SLURM job scripts fetched", "cluster_id", s.cluster.ID, "num_scripts", numScripts)
+}
+
+// Get reservations from slurm sreport command. Accounts of the reservations are
+// fetched from scontrol command which only reports current reservations.
+func (s *slurmScheduler) fetchFromSreport(ctx context.Context, start time.Time, end time.Time) ([]models.Reservation, error) {
+	// Execute sreport command between start and end times
+	sreportOutput, err := s.runSreportCmd(ctx, start, end)
+	if err != nil {
+		s.logger.Error("Failed to run sreport command", "cluster_id", s.cluster.ID, "err", err)
+
+		return nil, err
+	}
+
+	reservations := parseSreportCmdOutput(string(sreportOutput), end.Location())
+
+	// Get accounts of current reservations. If it fails, accounts of reservations
+	// fetched in the previous updates will be used
+	if len(reservations) > 0 {
+		if scontrolOutput, err := s.runScontrolResvCmd(ctx); err != nil {
+			s.logger.Warn("Failed to run scontrol command", "cluster_id", s.cluster.ID, "err", err)
+		} else {
+			accounts := parseScontrolResvCmdOutput(string(scontrolOutput))
+			for i := range reservations {
+				reservations[i].Project = accounts[reservations[i].Name]
+			}
+		}
+	}
+
+	s.logger.Info("SLURM reservations fetched", "cluster_id", s.cluster.ID, "start", start, "end", end, "num_reservations", len(reservations))
+
+	return reservations, nil
+}
+
 // Get user project association from slurm sacctmgr command.
 func (s *slurmScheduler) fetchFromSacctMgr(
 	ctx context.Context,
@@ -177,3 +443,34 @@ func (s *slurmScheduler) fetchFromSacctMgr(
 
 	return users, projects, nil
 }
+
+// dedupeSiblingJobs returns jobs without the sibling jobs of federated SLURM.
+// Sibling jobs have the same job ID on different clusters of federation and only
+// one of them actually runs. The job that started and ran the longest is kept
+// so that migrated jobs are not counted twice.
+func dedupeSiblingJobs(jobs []models.Unit) []models.Unit {
+	deduped := make([]models.Unit, 0, len(jobs))
+	seen := make(map[string]int)
+
+	for _, job := range jobs {
+		if job.UUID == "" {
+			continue
+		}
+
+		idx, ok := seen[job.UUID]
+		if !ok {
+			seen[job.UUID] = len(deduped)
+			deduped = append(deduped, job)
+
+			continue
+		}
+
+		// Prefer the sibling that started and ran for longer
+		if sibling := deduped[idx]; (job.StartedAtTS > 0 && sibling.StartedAtTS == 0) ||
+			(job.StartedAtTS > 0 && job.TotalTime["walltime"] > sibling.TotalTime["walltime"]) {
+			deduped[idx] = job
+		}
+	}
+
+	return deduped
+}
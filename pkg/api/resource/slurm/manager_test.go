@@ -7,20 +7,23 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	start, _       = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:00:00+0100")
 	end, _         = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
 	current, _     = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
-	sacctCmdOutput = `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:37:02+0100|2023-02-21T14:37:07+0100|NA|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,mem=320.5G,node=2|compute-0|test_script1|/home/usr
-1481508|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T13:49:20+0100|2023-02-21T13:49:06+0100|2023-02-21T15:10:23+0100|00:08:17|4920|0:0|COMPLETED|billing=1,cpu=2,mem=4M,node=1|compute-[0-2]|test_script2|/home/usr`
+	sacctCmdOutput = `1479763|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T14:37:02+0100|2023-02-21T14:37:07+0100|NA|01:49:22|3000|0:0|RUNNING|billing=80,cpu=160,energy=1439089,gres/gpu=8,gres/gpu:a100=8,mem=320.5G,node=2|billing=80,cpu=160,gres/gpu:a100=8,mem=320G,node=2|compute-0|test_script1|/home/usr
+1481508|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T13:49:20+0100|2023-02-21T13:49:06+0100|2023-02-21T15:10:23+0100|00:08:17|4920|0:0|COMPLETED|billing=1,cpu=2,mem=4M,node=1||compute-[0-2]|test_script2|/home/usr`
 	sacctMgrCmdOutput = `root|
 root|root
 prj1|
@@ -56,11 +59,18 @@ prj4|usr3`
 			},
 			State: "RUNNING",
 			Allocation: models.Generic{
-				"cpus":    int64(160),
-				"gpus":    int64(8),
-				"mem":     int64(343597383680),
-				"nodes":   int64(2),
-				"billing": int64(80),
+				"cpus":          int64(160),
+				"gpus":          int64(8),
+				"gpus_a100":     int64(8),
+				"mem":           int64(343597383680),
+				"nodes":         int64(2),
+				"billing":       int64(80),
+				"req_cpus":      int64(160),
+				"req_gpus":      int64(8),
+				"req_gpus_a100": int64(8),
+				"req_mem":       int64(343597383680),
+				"req_nodes":     int64(2),
+				"req_billing":   int64(80),
 			},
 			Tags: models.Generic{
 				"gid":         int64(1000),
@@ -193,3 +203,225 @@ printf """%s"""`, sacctMgrCmdOutput)
 		require.NoError(t, err)
 	}
 }
+
+func TestSLURMFetcherSacctConfig(t *testing.T) {
+	// Write sacct executable that records its arguments
+	tmpDir := t.TempDir()
+	argsPath := filepath.Join(tmpDir, "args")
+	sacctPath := filepath.Join(tmpDir, "sacct")
+	sacctScript := fmt.Sprintf(`#!/bin/bash
+echo "$@" >> %s
+printf """%s"""`, argsPath, strings.ReplaceAll(sacctCmdOutput, "\n", "|resv1\n")+"|resv1")
+	os.WriteFile(sacctPath, []byte(sacctScript), 0o700) // #nosec
+
+	var extra yaml.Node
+
+	err := yaml.Unmarshal([]byte(`
+sacct:
+  fields:
+    - Reservation
+    - jobidraw
+  extra_args:
+    - --partition=part1
+  fetch_window: 10m`), &extra)
+	require.NoError(t, err)
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		CLI:     models.CLIConfig{Path: tmpDir},
+		Extra:   *extra.Content[0],
+	}
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	start, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:00:00+0100")
+	end, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
+
+	clusterUnits, err := slurm.FetchUnits(context.Background(), start, end)
+	require.NoError(t, err)
+
+	// Jobs reported in both windows must be deduplicated
+	units := clusterUnits[0].Units
+	require.Len(t, units, 2)
+	assert.Equal(t, "1479763", units[0].UUID)
+	assert.Equal(t, "resv1", units[0].Tags["reservation"])
+
+	// sacct must be executed once per window with extra fields and args
+	args, err := os.ReadFile(argsPath)
+	require.NoError(t, err)
+
+	calls := strings.Split(strings.TrimSpace(string(args)), "\n")
+	require.Len(t, calls, 2)
+
+	for _, call := range calls {
+		assert.Contains(t, call, strings.Join(sacctFields, ",")+",reservation ")
+		assert.True(t, strings.HasSuffix(call, "--partition=part1"))
+	}
+
+	assert.Contains(t, calls[0], "--starttime 2023-02-21T15:00:00 --endtime 2023-02-21T15:10:00")
+	assert.Contains(t, calls[1], "--starttime 2023-02-21T15:10:00 --endtime 2023-02-21T15:15:00")
+}
+
+func TestSLURMFetcherFederation(t *testing.T) {
+	// Write sacct executable that records its arguments. Job 1481508 has a sibling
+	// on cluster c1 that never started
+	tmpDir := t.TempDir()
+	argsPath := filepath.Join(tmpDir, "args")
+	sacctPath := filepath.Join(tmpDir, "sacct")
+	sacctOutput := `1481508|part1|qos1|acc1|grp|1000|usr|1000|2023-02-21T13:49:20+0100|Unknown|2023-02-21T15:01:23+0100|00:00:00|0|0:0|CANCELLED|billing=1,cpu=2,mem=4M,node=1||compute-1|test_script2|/home/usr|c1
+` + strings.ReplaceAll(sacctCmdOutput, "\n", "|c2\n") + "|c2"
+	sacctScript := fmt.Sprintf(`#!/bin/bash
+echo "$@" >> %s
+printf """%s"""`, argsPath, sacctOutput)
+	os.WriteFile(sacctPath, []byte(sacctScript), 0o700) // #nosec
+
+	var extra yaml.Node
+
+	err := yaml.Unmarshal([]byte(`
+sacct:
+  clusters:
+    - c1
+    - c2`), &extra)
+	require.NoError(t, err)
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		CLI:     models.CLIConfig{Path: tmpDir},
+		Extra:   *extra.Content[0],
+	}
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	start, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:00:00+0100")
+	end, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
+
+	clusterUnits, err := slurm.FetchUnits(context.Background(), start, end)
+	require.NoError(t, err)
+
+	// Sibling jobs must be deduplicated and originating cluster recorded
+	units := clusterUnits[0].Units
+	require.Len(t, units, 2)
+
+	for _, unit := range units {
+		assert.Equal(t, "c2", unit.Tags["cluster"], unit.UUID)
+	}
+
+	// sacct must be executed on all clusters of federation
+	args, err := os.ReadFile(argsPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), strings.Join(sacctFields, ",")+",cluster ")
+	assert.Contains(t, string(args), "-M c1,c2")
+}
+
+func TestSLURMFetcherSteps(t *testing.T) {
+	// Write sacct executable that reports steps of jobs when job IDs are requested
+	tmpDir := t.TempDir()
+	argsPath := filepath.Join(tmpDir, "args")
+	sacctPath := filepath.Join(tmpDir, "sacct")
+	sacctStepsOutput := `1479763|test_script1|RUNNING|2023-02-21T14:37:07+0100|Unknown|00:37:53|2273|0:0|billing=80,cpu=160,gres/gpu=8,mem=320G,node=2|compute-0
+1479763.batch|batch|RUNNING|2023-02-21T14:37:07+0100|Unknown|00:37:53|2273|0:0|cpu=80,gres/gpu=4,mem=160G,node=1|compute-0
+1479763.0|train|RUNNING|2023-02-21T14:38:00+0100|Unknown|00:37:00|2220|0:0|cpu=160,gres/gpu=8,mem=320G,node=2|compute-0`
+	sacctScript := fmt.Sprintf(`#!/bin/bash
+echo "$@" >> %s
+if [[ "$*" == *--jobs* ]]; then
+printf """%s"""
+else
+printf """%s"""
+fi`, argsPath, sacctStepsOutput, sacctCmdOutput)
+	os.WriteFile(sacctPath, []byte(sacctScript), 0o700) // #nosec
+
+	var extra yaml.Node
+
+	err := yaml.Unmarshal([]byte(`
+sacct:
+  steps: true`), &extra)
+	require.NoError(t, err)
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		CLI:     models.CLIConfig{Path: tmpDir},
+		Extra:   *extra.Content[0],
+	}
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	start, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:00:00+0100")
+	end, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
+
+	clusterUnits, err := slurm.FetchUnits(context.Background(), start, end)
+	require.NoError(t, err)
+
+	// Steps must be attached to their jobs
+	units := clusterUnits[0].Units
+	require.Len(t, units, 2)
+	require.Len(t, units[0].Steps, 2)
+	assert.Equal(t, "batch", units[0].Steps[0].StepID)
+	assert.Equal(t, "0", units[0].Steps[1].StepID)
+	assert.Empty(t, units[1].Steps)
+
+	// Steps of all jobs must be fetched in a single sacct command
+	args, err := os.ReadFile(argsPath)
+	require.NoError(t, err)
+
+	calls := strings.Split(strings.TrimSpace(string(args)), "\n")
+	require.Len(t, calls, 2)
+	assert.Contains(t, calls[1], "--jobs 1479763,1481508")
+	assert.NotContains(t, calls[1], "-X")
+}
+
+func TestSLURMFetcherScripts(t *testing.T) {
+	// Write sacct executable that reports scripts and environments of jobs
+	tmpDir := t.TempDir()
+	sacctPath := filepath.Join(tmpDir, "sacct")
+	sacctScript := fmt.Sprintf(`#!/bin/bash
+if [[ "$1" == "--batch-script" ]]; then
+printf "Batch Script for 1479763\n-----\n#!/bin/bash\nsrun python train.py\n"
+elif [[ "$1" == "--env-vars" ]]; then
+printf "Environment used for 1479763\n-----\nHOME=/home/usr\nPATH=/usr/bin\n"
+else
+printf """%s"""
+fi`, sacctCmdOutput)
+	os.WriteFile(sacctPath, []byte(sacctScript), 0o700) // #nosec
+
+	var extra yaml.Node
+
+	err := yaml.Unmarshal([]byte(`
+sacct:
+  scripts: true
+  scripts_environment: true
+  max_script_size: 20`), &extra)
+	require.NoError(t, err)
+
+	cluster := models.Cluster{
+		ID:      "slurm-0",
+		Manager: "slurm",
+		CLI:     models.CLIConfig{Path: tmpDir},
+		Extra:   *extra.Content[0],
+	}
+
+	slurm, err := New(cluster, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Only jobs started in the period must have their scripts fetched
+	start, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T14:00:00+0100")
+	end, _ = time.Parse(base.DatetimezoneLayout, "2023-02-21T15:15:00+0100")
+
+	clusterUnits, err := slurm.FetchUnits(context.Background(), start, end)
+	require.NoError(t, err)
+
+	units := clusterUnits[0].Units
+	require.Len(t, units, 2)
+	require.NotNil(t, units[0].Script)
+	assert.Equal(t, &models.UnitScript{
+		Script:      "#!/bin/bash\nsrun pyt",
+		Environment: "HOME=/home/usr\nPATH=",
+		Truncated:   true,
+	}, units[0].Script)
+	assert.Nil(t, units[1].Script)
+}
@@ -0,0 +1,407 @@
+package tsdb
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/mahendrapaipuri/ceems/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+// Defaults of backpressure config.
+const (
+	defaultErrorRateThreshold = 0.2
+	defaultRetryPeriod        = 6 * time.Hour
+	maxPendingUnits           = 10000
+)
+
+// Prefix of aggregate metrics that are summed over update intervals. Only these
+// metrics are retried as averages are weighted by the intervals in which they
+// were resolved and missing intervals do not bias them.
+const totalMetricPrefix = "total_"
+
+// Names of aggregate metrics that are summed over update intervals.
+var totalMetricNames = []string{
+	"total_cpu_energy_usage_kwh", "total_cpu_emissions_gms", "total_gpu_energy_usage_kwh",
+	"total_gpu_emissions_gms", "total_io_write_stats", "total_io_read_stats",
+	"total_ingress_stats", "total_outgress_stats",
+}
+
+// Backpressure metrics.
+var (
+	tsdbDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "tsdb_updater",
+		Name:      "degraded",
+		Help:      "Whether TSDB is considered degraded based on error rate and latency of queries",
+	}, []string{"id"})
+	queryConcurrency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "tsdb_updater",
+		Name:      "query_concurrency",
+		Help:      "Current maximum number of concurrent queries made to TSDB",
+	}, []string{"id"})
+	pendingRetryUnits = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "tsdb_updater",
+		Name:      "pending_retry_units",
+		Help:      "Number of units whose aggregate metrics are pending to be retried",
+	}, []string{"id"})
+)
+
+// backpressureConfig contains the config to reduce load on TSDB when it is
+// degraded.
+type backpressureConfig struct {
+	MaxConcurrency     int            `yaml:"max_concurrency"`
+	ErrorRateThreshold float64        `yaml:"error_rate_threshold"`
+	LatencyThreshold   model.Duration `yaml:"latency_threshold"`
+	DeferredMetrics    []string       `yaml:"deferred_metrics"`
+	RetryPeriod        model.Duration `yaml:"retry_period"`
+}
+
+// fetchResult is the result of aggregate metrics queries of a batch of units.
+type fetchResult struct {
+	metrics    map[string]map[string]tsdb.Metric
+	failed     map[string]map[string]string // Queries that failed
+	numQueries int
+	latency    time.Duration // Maximum latency of queries
+}
+
+// pendingBatch is a batch of units whose aggregate metrics failed to be fetched
+// during an update interval.
+type pendingBatch struct {
+	start   time.Time
+	end     time.Time
+	units   map[string]models.Unit
+	queries map[string]map[string]string
+}
+
+// backpressure tracks the health of TSDB across update intervals.
+type backpressure struct {
+	id          string
+	config      backpressureConfig
+	mu          sync.Mutex
+	degraded    bool
+	concurrency int
+	pending     map[string][]*pendingBatch // Pending batches of each cluster
+}
+
+// newBackpressure returns a new instance of backpressure.
+func newBackpressure(id string, config backpressureConfig) *backpressure {
+	return &backpressure{
+		id:          id,
+		config:      config,
+		concurrency: config.MaxConcurrency,
+		pending:     make(map[string][]*pendingBatch),
+	}
+}
+
+// limit returns the current maximum number of concurrent queries for numQueries.
+func (b *backpressure) limit(numQueries int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.concurrency <= 0 || b.concurrency > numQueries {
+		return max(numQueries, 1)
+	}
+
+	return b.concurrency
+}
+
+// isDegraded returns true when TSDB is degraded.
+func (b *backpressure) isDegraded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.degraded
+}
+
+// observe updates the health of TSDB based on the result of a batch of queries.
+// Concurrency is halved when TSDB is degraded and doubled back when it recovers.
+func (b *backpressure) observe(result fetchResult) bool {
+	if result.numQueries == 0 {
+		return b.isDegraded()
+	}
+
+	var numFailed int
+	for _, queries := range result.failed {
+		numFailed += len(queries)
+	}
+
+	errorRate := float64(numFailed) / float64(result.numQueries)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.degraded = errorRate > b.config.ErrorRateThreshold ||
+		(b.config.LatencyThreshold > 0 && result.latency > time.Duration(b.config.LatencyThreshold))
+
+	if b.concurrency <= 0 {
+		b.concurrency = result.numQueries
+	}
+
+	if b.degraded {
+		b.concurrency = max(b.concurrency/2, 1)
+	} else {
+		b.concurrency *= 2
+		if b.config.MaxConcurrency > 0 {
+			b.concurrency = min(b.concurrency, b.config.MaxConcurrency)
+		}
+	}
+
+	tsdbDegraded.WithLabelValues(b.id).Set(boolToFloat(b.degraded))
+	queryConcurrency.WithLabelValues(b.id).Set(float64(b.concurrency))
+
+	return b.degraded
+}
+
+// markPending marks the queries of units during interval between start and end to
+// be retried later. Only the queries of metrics that are summed over intervals
+// are retried.
+func (b *backpressure) markPending(
+	clusterID string,
+	start time.Time,
+	end time.Time,
+	units []models.Unit,
+	queries map[string]map[string]string,
+) {
+	if b.config.RetryPeriod <= 0 || len(units) == 0 {
+		return
+	}
+
+	retryQueries := make(map[string]map[string]string)
+
+	for metricName, subQueries := range queries {
+		if strings.HasPrefix(metricName, totalMetricPrefix) && len(subQueries) > 0 {
+			retryQueries[metricName] = subQueries
+		}
+	}
+
+	if len(retryQueries) == 0 {
+		return
+	}
+
+	batch := &pendingBatch{
+		start:   start,
+		end:     end,
+		units:   make(map[string]models.Unit, len(units)),
+		queries: retryQueries,
+	}
+
+	for _, unit := range units {
+		batch.units[unit.UUID] = unit
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.numPending()+len(batch.units) > maxPendingUnits {
+		return
+	}
+
+	b.pending[clusterID] = append(b.pending[clusterID], batch)
+
+	pendingRetryUnits.WithLabelValues(b.id).Set(float64(b.numPending()))
+}
+
+// take removes and returns the pending batches of cluster that are not older
+// than retry period.
+func (b *backpressure) take(clusterID string, now time.Time) []*pendingBatch {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	batches := slices.DeleteFunc(b.pending[clusterID], func(p *pendingBatch) bool {
+		return now.Sub(p.start) > time.Duration(b.config.RetryPeriod)
+	})
+	delete(b.pending, clusterID)
+
+	pendingRetryUnits.WithLabelValues(b.id).Set(float64(b.numPending()))
+
+	return batches
+}
+
+// restore adds the batches back to pending batches of cluster. Copies of
+// pending units are refreshed with units of current update interval so that
+// their state is up to date when they are retried.
+func (b *backpressure) restore(clusterID string, batches []*pendingBatch, units []models.Unit) {
+	if len(batches) == 0 {
+		return
+	}
+
+	for _, unit := range units {
+		for _, batch := range batches {
+			if _, ok := batch.units[unit.UUID]; ok {
+				batch.units[unit.UUID] = unit
+			}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending[clusterID] = append(batches, b.pending[clusterID]...)
+
+	pendingRetryUnits.WithLabelValues(b.id).Set(float64(b.numPending()))
+}
+
+// numPending returns the number of pending units. Must be called with lock held.
+func (b *backpressure) numPending() int {
+	var n int
+
+	for _, batches := range b.pending {
+		for _, batch := range batches {
+			n += len(batch.units)
+		}
+	}
+
+	return n
+}
+
+// retry fetches the aggregate metrics of pending units of cluster and merges
+// them into units. Units that are not in the current update interval are
+// appended with zero total time so that only their metrics are accounted.
+func (t *tsdbUpdater) retry(
+	ctx context.Context,
+	clusterID string,
+	batches []*pendingBatch,
+	units []models.Unit,
+) []models.Unit {
+	if len(batches) == 0 {
+		return units
+	}
+
+	settings := t.Settings(ctx)
+
+	var failed []*pendingBatch
+
+	for _, batch := range batches {
+		duration := batch.end.Sub(batch.start).Truncate(time.Minute)
+
+		result := t.fetchAggMetrics(ctx, batch.end, duration, slices.Collect(maps.Keys(batch.units)), batch.queries, settings)
+		t.pressure.observe(result)
+
+		if len(result.failed) > 0 {
+			failed = append(failed, batch)
+
+			continue
+		}
+
+		t.Logger.Info(
+			"Retried aggregate metrics of units", "cluster_id", clusterID, "num_units", len(batch.units),
+			"start", batch.start, "end", batch.end,
+		)
+
+		for uuid, unit := range batch.units {
+			idx := slices.IndexFunc(units, func(u models.Unit) bool { return u.UUID == uuid })
+			if idx < 0 {
+				units = append(units, retryUnit(unit))
+				idx = len(units) - 1
+			}
+
+			for metricName, metrics := range result.metrics {
+				field := unitMetricMap(&units[idx], metricName)
+				if field == nil {
+					continue
+				}
+
+				if *field == nil {
+					*field = make(models.MetricMap)
+				}
+
+				for name, metric := range metrics {
+					if value, exists := metric[uuid]; exists {
+						(*field)[name] += sanitizeValue(value)
+					}
+				}
+			}
+		}
+	}
+
+	t.pressure.restore(clusterID, failed, units)
+
+	return units
+}
+
+// unitMetricMap returns the pointer to metric map of unit that corresponds to
+// the aggregate metric name. Nil is returned for unknown metrics.
+func unitMetricMap(unit *models.Unit, metricName string) *models.MetricMap {
+	switch metricName {
+	case "total_cpu_energy_usage_kwh":
+		return &unit.TotalCPUEnergyUsage
+	case "total_cpu_emissions_gms":
+		return &unit.TotalCPUEmissions
+	case "total_gpu_energy_usage_kwh":
+		return &unit.TotalGPUEnergyUsage
+	case "total_gpu_emissions_gms":
+		return &unit.TotalGPUEmissions
+	case "total_io_write_stats":
+		return &unit.TotalIOWriteStats
+	case "total_io_read_stats":
+		return &unit.TotalIOReadStats
+	case "total_ingress_stats":
+		return &unit.TotalIngressStats
+	case "total_outgress_stats":
+		return &unit.TotalOutgressStats
+	}
+
+	return nil
+}
+
+// retryUnit returns a copy of unit with all aggregate metrics reset. Total time
+// is set to zero so that averages of unit are not modified when it is updated
+// in DB.
+func retryUnit(unit models.Unit) models.Unit {
+	unit.TotalTime = models.MetricMap{
+		"walltime":         0,
+		"alloc_cputime":    0,
+		"alloc_cpumemtime": 0,
+		"alloc_gputime":    0,
+		"alloc_gpumemtime": 0,
+	}
+	unit.AveCPUUsage = make(models.MetricMap)
+	unit.AveCPUMemUsage = make(models.MetricMap)
+	unit.AveGPUUsage = make(models.MetricMap)
+	unit.AveGPUMemUsage = make(models.MetricMap)
+	unit.Completeness = make(models.MetricMap)
+
+	for _, metricName := range totalMetricNames {
+		*unitMetricMap(&unit, metricName) = make(models.MetricMap)
+	}
+
+	return unit
+}
+
+// batchUnits returns units whose UUIDs are in uuids.
+func batchUnits(units []models.Unit, uuids []string) []models.Unit {
+	uuidSet := make(map[string]struct{}, len(uuids))
+	for _, uuid := range uuids {
+		uuidSet[uuid] = struct{}{}
+	}
+
+	batch := make([]models.Unit, 0, len(uuids))
+
+	for _, unit := range units {
+		if _, ok := uuidSet[unit.UUID]; ok {
+			batch = append(batch, unit)
+		}
+	}
+
+	return batch
+}
+
+// boolToFloat converts bool to float64.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
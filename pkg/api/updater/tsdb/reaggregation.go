@@ -0,0 +1,235 @@
+package tsdb
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Re-aggregation metrics.
+var (
+	reaggregatedUnits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "tsdb_updater",
+		Name:      "reaggregated_units_total",
+		Help:      "Total number of units whose aggregate metrics were corrected with late samples",
+	}, []string{"id"})
+)
+
+// lateBatch is a batch of units that ended close to the end of an update interval.
+// Samples of these units can be scraped or ingested by TSDB after the interval has
+// been updated and hence, their aggregate metrics are re-evaluated later.
+type lateBatch struct {
+	start time.Time
+	end   time.Time
+	units map[string]models.Unit
+}
+
+// reaggregator tracks units that need to be re-aggregated.
+type reaggregator struct {
+	lookback time.Duration
+	mu       sync.Mutex
+	batches  map[string][]*lateBatch // Batches of each cluster
+}
+
+// newReaggregator returns a new instance of reaggregator.
+func newReaggregator(lookback time.Duration) *reaggregator {
+	return &reaggregator{
+		lookback: lookback,
+		batches:  make(map[string][]*lateBatch),
+	}
+}
+
+// track adds the units that ended within lookback of end of update interval
+// for re-aggregation. Ignored units are not tracked as their time series are
+// deleted.
+func (r *reaggregator) track(clusterID string, start time.Time, end time.Time, units []models.Unit) {
+	if r.lookback <= 0 {
+		return
+	}
+
+	batch := &lateBatch{
+		start: start,
+		end:   end,
+		units: make(map[string]models.Unit),
+	}
+
+	for _, unit := range units {
+		if unit.UUID == "" || unit.Ignore == 1 || unit.EndedAtTS <= 0 ||
+			unit.EndedAtTS > end.UnixMilli() || end.UnixMilli()-unit.EndedAtTS > r.lookback.Milliseconds() {
+			continue
+		}
+
+		// Clone aggregate metrics as unit maps are shared and can be modified
+		// by later retries
+		for _, metricName := range totalMetricNames {
+			if field := unitMetricMap(&unit, metricName); *field != nil {
+				*field = maps.Clone(*field)
+			}
+		}
+
+		batch.units[unit.UUID] = unit
+	}
+
+	if len(batch.units) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var numUnits int
+
+	for _, batches := range r.batches {
+		for _, b := range batches {
+			numUnits += len(b.units)
+		}
+	}
+
+	if numUnits+len(batch.units) > maxPendingUnits {
+		return
+	}
+
+	r.batches[clusterID] = append(r.batches[clusterID], batch)
+}
+
+// due removes and returns the batches of cluster whose lookback has elapsed
+// at now.
+func (r *reaggregator) due(clusterID string, now time.Time) []*lateBatch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due []*lateBatch
+
+	r.batches[clusterID] = slices.DeleteFunc(r.batches[clusterID], func(b *lateBatch) bool {
+		if now.Before(b.end.Add(r.lookback)) {
+			return false
+		}
+
+		due = append(due, b)
+
+		return true
+	})
+
+	if len(r.batches[clusterID]) == 0 {
+		delete(r.batches, clusterID)
+	}
+
+	return due
+}
+
+// reaggregate re-evaluates the `total_*` aggregate metrics of units that ended close
+// to the end of previous update intervals over a window extended by lookback. The
+// difference with the values that were estimated during the update is added to
+// units so that samples scraped after the update are accounted. Units that are
+// not in the current update interval are appended with zero total time.
+func (t *tsdbUpdater) reaggregate(
+	ctx context.Context,
+	clusterID string,
+	endTime time.Time,
+	units []models.Unit,
+) []models.Unit {
+	batches := t.late.due(clusterID, endTime)
+	if len(batches) == 0 {
+		return units
+	}
+
+	// Re-aggregation is a best effort correction and it is skipped when TSDB is
+	// not healthy
+	if !t.Available() || t.pressure.isDegraded() {
+		t.Logger.Debug("Skipping re-aggregation of units as TSDB is unhealthy", "cluster_id", clusterID)
+
+		return units
+	}
+
+	queries := make(map[string]map[string]string)
+
+	for metricName, subQueries := range t.config.Queries {
+		if strings.HasPrefix(metricName, totalMetricPrefix) {
+			queries[metricName] = subQueries
+		}
+	}
+
+	if len(queries) == 0 {
+		return units
+	}
+
+	settings := t.Settings(ctx)
+
+	for _, batch := range batches {
+		queryTime := batch.end.Add(t.late.lookback)
+		duration := queryTime.Sub(batch.start).Truncate(time.Minute)
+
+		result := t.fetchAggMetrics(ctx, queryTime, duration, slices.Collect(maps.Keys(batch.units)), queries, settings)
+		t.pressure.observe(result)
+
+		var numUnits int
+
+		for uuid, unit := range batch.units {
+			var corrected bool
+
+			for metricName, metrics := range result.metrics {
+				estimated := unitMetricMap(&unit, metricName)
+				if estimated == nil {
+					continue
+				}
+
+				for name, metric := range metrics {
+					// Only metrics that were resolved during update are corrected.
+					// Missing ones are retried by backpressure
+					value, exists := metric[uuid]
+					if !exists {
+						continue
+					}
+
+					prev, exists := (*estimated)[name]
+					if !exists {
+						continue
+					}
+
+					delta := sanitizeValue(value) - prev
+					if delta <= 0 {
+						continue
+					}
+
+					idx := slices.IndexFunc(units, func(u models.Unit) bool { return u.UUID == uuid })
+					if idx < 0 {
+						units = append(units, retryUnit(unit))
+						idx = len(units) - 1
+					}
+
+					field := unitMetricMap(&units[idx], metricName)
+					if *field == nil {
+						*field = make(models.MetricMap)
+					}
+
+					(*field)[name] += delta
+					corrected = true
+				}
+			}
+
+			if corrected {
+				numUnits++
+			}
+		}
+
+		if numUnits > 0 {
+			t.Logger.Info(
+				"Re-aggregated metrics of units with late samples", "cluster_id", clusterID,
+				"num_units", numUnits, "start", batch.start, "end", batch.end,
+			)
+
+			reaggregatedUnits.WithLabelValues(t.id).Add(float64(numUnits))
+		}
+	}
+
+	return units
+}
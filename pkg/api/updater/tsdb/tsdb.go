@@ -3,19 +3,25 @@ package tsdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
 	"maps"
 	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/mahendrapaipuri/ceems/pkg/api/base"
 	"github.com/mahendrapaipuri/ceems/pkg/api/helper"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 )
 
@@ -27,19 +33,52 @@ const (
 // Use a conservative maximum number of series to be loaded in memory for queries.
 const (
 	defaultQueryMaxSeries = 50
+	defaultLiveWindow     = 15 * time.Minute
+	maxNodeSeriesSamples  = 500
 )
 
+// Default pseudo project to which energy of idle power baseline of nodes is assigned.
+const (
+	defaultBaselineProject = "infrastructure"
+	baselineUUIDPrefix     = "baseline"
+)
+
+// Custom errors.
+var (
+	errTSDBUnavailable       = errors.New("TSDB is unavailable")
+	errInvalidErrorThreshold = errors.New("backpressure error_rate_threshold must be between 0 and 1")
+	errInvalidConcurrency    = errors.New("backpressure max_concurrency must be non-negative")
+)
+
+// baselineConfig contains the queries that estimate the energy consumed by idle
+// power baseline of nodes. This energy is assigned to a pseudo project in usage
+// stats as it is not attributable to any compute unit.
+type baselineConfig struct {
+	Queries map[string]string `yaml:"queries"`
+	Project string            `yaml:"project"`
+}
+
 // config is the container for the configuration of a given TSDB instance.
 type tsdbConfig struct {
 	QueryMaxSeries int                          `yaml:"query_max_series"`
 	CutoffDuration model.Duration               `yaml:"cutoff_duration"`
 	Queries        map[string]map[string]string `yaml:"queries"`
+	NodeQueries    map[string]map[string]string `yaml:"node_queries"`
+	LiveQueries    map[string]map[string]string `yaml:"live_queries"`
+	SeriesQueries  map[string]map[string]string `yaml:"node_series_queries"`
+	LiveWindow     model.Duration               `yaml:"live_window"`
+	EnergyBaseline baselineConfig               `yaml:"energy_baseline"`
 	LabelsToDrop   []string                     `yaml:"labels_to_drop"`
+	Backpressure   backpressureConfig           `yaml:"backpressure"`
+	Reaggregation  model.Duration               `yaml:"reaggregation_lookback"`
 }
 
 // Embed TSDB struct into our TSDBUpdater struct.
 type tsdbUpdater struct {
-	config *tsdbConfig
+	id       string
+	config   *tsdbConfig
+	pressure *backpressure
+	late     *reaggregator
 	*tsdb.TSDB
 }
 
@@ -48,6 +87,19 @@ var (
 	metricLock = sync.RWMutex{}
 )
 
+// Key of overall completeness score in the completeness metric map of units.
+const completenessScoreKey = "score"
+
+// Completeness metrics.
+var (
+	unitsCompleteness = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: base.CEEMSServerAppName,
+		Subsystem: "tsdb_updater",
+		Name:      "units_completeness_ratio",
+		Help:      "Average fraction of aggregate metrics resolved from TSDB of units in the last update",
+	}, []string{"id", "metric"})
+)
+
 // Register TSDB updater
 // tsdb will estimate time averaged metrics and update units struct
 // It will also remove ignored units time series.
@@ -60,6 +112,12 @@ func New(instance updater.Instance, logger *slog.Logger) (updater.Updater, error
 	// Make TSDB config from instances extra config
 	config := tsdbConfig{
 		QueryMaxSeries: defaultQueryMaxSeries,
+		LiveWindow:     model.Duration(defaultLiveWindow),
+		EnergyBaseline: baselineConfig{Project: defaultBaselineProject},
+		Backpressure: backpressureConfig{
+			ErrorRateThreshold: defaultErrorRateThreshold,
+			RetryPeriod:        model.Duration(defaultRetryPeriod),
+		},
 	}
 	if err := instance.Extra.Decode(&config); err != nil {
 		logger.Error("Failed to setup TSDB updater", "id", instance.ID, "err", err)
@@ -67,6 +125,19 @@ func New(instance updater.Instance, logger *slog.Logger) (updater.Updater, error
 		return nil, err
 	}
 
+	// Validate backpressure config
+	if config.Backpressure.ErrorRateThreshold < 0 || config.Backpressure.ErrorRateThreshold > 1 {
+		logger.Error("Failed to setup TSDB updater", "id", instance.ID, "err", errInvalidErrorThreshold)
+
+		return nil, errInvalidErrorThreshold
+	}
+
+	if config.Backpressure.MaxConcurrency < 0 {
+		logger.Error("Failed to setup TSDB updater", "id", instance.ID, "err", errInvalidConcurrency)
+
+		return nil, errInvalidConcurrency
+	}
+
 	// Create instances of TSDB
 	tsdb, err := tsdb.New(
 		instance.Web.URL,
@@ -82,7 +153,10 @@ func New(instance updater.Instance, logger *slog.Logger) (updater.Updater, error
 	logger.Info("TSDB updater setup successful", "id", instance.ID)
 
 	return &tsdbUpdater{
+		instance.ID,
 		&config,
+		newBackpressure(instance.ID, config.Backpressure),
+		newReaggregator(time.Duration(config.Reaggregation)),
 		tsdb,
 	}, nil
 }
@@ -94,13 +168,390 @@ func (t *tsdbUpdater) Update(
 	endTime time.Time,
 	units []models.ClusterUnits,
 ) []models.ClusterUnits {
-	for _, clusterUnit := range units {
-		clusterUnit.Units = t.update(ctx, startTime, endTime, clusterUnit.Units)
+	for i := range units {
+		clusterID := units[i].Cluster.ID
+
+		// Units marked for retry in previous update intervals
+		pending := t.pressure.take(clusterID, endTime)
+
+		units[i].Units = t.update(ctx, clusterID, startTime, endTime, units[i].Units)
+
+		// Retry pending units only when TSDB has recovered. Else keep them for
+		// next update interval
+		if t.Available() && !t.pressure.isDegraded() {
+			units[i].Units = t.retry(ctx, clusterID, pending, units[i].Units)
+		} else {
+			t.pressure.restore(clusterID, pending, units[i].Units)
+		}
+
+		// Correct metrics of units that ended close to end of previous intervals
+		// with samples that arrived after those intervals were updated
+		units[i].Units = t.reaggregate(ctx, clusterID, endTime, units[i].Units)
+
+		// Add a pseudo unit that holds energy of idle power baseline of nodes
+		if baseline := t.baselineUnit(ctx, startTime, endTime, units[i].Cluster); baseline != nil {
+			units[i].Units = append(units[i].Units, *baseline)
+		}
 	}
 
 	return units
 }
 
+// baselineUnit returns a pseudo unit of baseline project that contains the energy
+// consumed by idle power baseline of all nodes of cluster during the interval.
+// Queries must return a vector with `hostname` label. Nil is returned when no
+// baseline queries are configured or they return no data.
+func (t *tsdbUpdater) baselineUnit(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	cluster models.Cluster,
+) *models.Unit {
+	// Bail if TSDB is unavailable or there are no baseline queries
+	if !t.Available() || len(t.config.EnergyBaseline.Queries) == 0 {
+		return nil
+	}
+
+	duration := endTime.Sub(startTime).Truncate(time.Minute)
+
+	// Get current TSDB settings
+	settings := t.Settings(ctx)
+
+	// If duration is less than rateInterval bail
+	if duration < settings.RateInterval {
+		return nil
+	}
+
+	// Template data
+	tmplData := map[string]interface{}{
+		"ScrapeInterval":          settings.ScrapeInterval,
+		"ScrapeIntervalMilli":     settings.ScrapeInterval.Milliseconds(),
+		"EvaluationInterval":      settings.EvaluationInterval,
+		"EvaluationIntervalMilli": settings.EvaluationInterval.Milliseconds(),
+		"RateInterval":            settings.RateInterval,
+		"Range":                   duration,
+	}
+
+	energy := make(models.MetricMap)
+
+	for subMetricName, query := range t.config.EnergyBaseline.Queries {
+		tsdbQuery, err := t.queryBuilder("baseline_"+subMetricName, query, tmplData)
+		if err != nil {
+			t.Logger.Error(
+				"Failed to build baseline query from template", "metric", subMetricName,
+				"query_template", query, "err", err,
+			)
+
+			continue
+		}
+
+		metric, err := t.QueryByLabel(ctx, tsdbQuery, endTime, "hostname")
+		if err != nil {
+			t.Logger.Error("Failed to fetch baseline energy from TSDB", "metric", subMetricName, "err", err)
+
+			continue
+		}
+
+		if len(metric) == 0 {
+			continue
+		}
+
+		var total float64
+		for _, value := range metric {
+			total += float64(sanitizeValue(value))
+		}
+
+		energy[subMetricName] = models.JSONFloat(total)
+	}
+
+	if len(energy) == 0 {
+		return nil
+	}
+
+	project := t.config.EnergyBaseline.Project
+
+	return &models.Unit{
+		ClusterID:       cluster.ID,
+		ResourceManager: cluster.Manager,
+		UUID:            fmt.Sprintf("%s-%d", baselineUUIDPrefix, endTime.Unix()),
+		Name:            "idle-power-baseline",
+		Project:         project,
+		Group:           project,
+		User:            project,
+		CreatedAt:       startTime.Format(base.DatetimezoneLayout),
+		StartedAt:       startTime.Format(base.DatetimezoneLayout),
+		EndedAt:         endTime.Format(base.DatetimezoneLayout),
+		CreatedAtTS:     startTime.UnixMilli(),
+		StartedAtTS:     startTime.UnixMilli(),
+		EndedAtTS:       endTime.UnixMilli(),
+		Elapsed:         time.Time{}.Add(endTime.Sub(startTime)).Format(time.TimeOnly),
+		State:           "COMPLETED",
+		TotalTime: models.MetricMap{
+			"walltime":         models.JSONFloat(endTime.Sub(startTime).Seconds()),
+			"alloc_cputime":    models.JSONFloat(0),
+			"alloc_cpumemtime": models.JSONFloat(0),
+			"alloc_gputime":    models.JSONFloat(0),
+			"alloc_gpumemtime": models.JSONFloat(0),
+		},
+		TotalCPUEnergyUsage: energy,
+	}
+}
+
+// UpdateNodes fetches energy usage and emissions of nodes of cluster from TSDB.
+// Queries must return a vector with `hostname` label.
+func (t *tsdbUpdater) UpdateNodes(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	cluster models.Cluster,
+) []models.Node {
+	// Bail if TSDB is unavailable or there are no node queries
+	if !t.Available() || len(t.config.NodeQueries) == 0 {
+		return nil
+	}
+
+	duration := endTime.Sub(startTime).Truncate(time.Minute)
+
+	// Get current TSDB settings
+	settings := t.Settings(ctx)
+
+	// If duration is less than rateInterval bail
+	if duration < settings.RateInterval {
+		return nil
+	}
+
+	// Template data
+	tmplData := map[string]interface{}{
+		"ScrapeInterval":          settings.ScrapeInterval,
+		"ScrapeIntervalMilli":     settings.ScrapeInterval.Milliseconds(),
+		"EvaluationInterval":      settings.EvaluationInterval,
+		"EvaluationIntervalMilli": settings.EvaluationInterval.Milliseconds(),
+		"RateInterval":            settings.RateInterval,
+		"Range":                   duration,
+	}
+
+	nodes := make(map[string]*models.Node)
+
+	for metricName, queries := range t.config.NodeQueries {
+		for subMetricName, query := range queries {
+			tsdbQuery, err := t.queryBuilder(fmt.Sprintf("%s_%s", metricName, subMetricName), query, tmplData)
+			if err != nil {
+				t.Logger.Error(
+					"Failed to build node query from template", "metric", metricName,
+					"query_template", query, "err", err,
+				)
+
+				continue
+			}
+
+			metric, err := t.QueryByLabel(ctx, tsdbQuery, endTime, "hostname")
+			if err != nil {
+				t.Logger.Error("Failed to fetch node metrics from TSDB", "metric", metricName, "err", err)
+
+				continue
+			}
+
+			for hostname, value := range metric {
+				if _, ok := nodes[hostname]; !ok {
+					nodes[hostname] = &models.Node{
+						ClusterID:        cluster.ID,
+						ResourceManager:  cluster.Manager,
+						Hostname:         hostname,
+						TotalTime:        models.MetricMap{"walltime": models.JSONFloat(duration.Seconds())},
+						TotalEnergyUsage: make(models.MetricMap),
+						TotalEmissions:   make(models.MetricMap),
+					}
+				}
+
+				switch metricName {
+				case "total_energy_usage_kwh":
+					nodes[hostname].TotalEnergyUsage[subMetricName] = sanitizeValue(value)
+				case "total_emissions_gms":
+					nodes[hostname].TotalEmissions[subMetricName] = sanitizeValue(value)
+				}
+			}
+		}
+	}
+
+	// Return nodes in a stable order
+	hostnames := slices.Sorted(maps.Keys(nodes))
+
+	nodeStats := make([]models.Node, len(hostnames))
+	for i, hostname := range hostnames {
+		nodeStats[i] = *nodes[hostname]
+	}
+
+	return nodeStats
+}
+
+// Live returns the time series of metrics of a running unit over the live window
+// ending at endTime. Queries must return a matrix with `uuid` label.
+func (t *tsdbUpdater) Live(
+	ctx context.Context,
+	endTime time.Time,
+	unit models.Unit,
+) (map[string]map[string][]models.Sample, error) {
+	if !t.Available() {
+		return nil, errTSDBUnavailable
+	}
+
+	metrics := make(map[string]map[string][]models.Sample)
+
+	if len(t.config.LiveQueries) == 0 {
+		return metrics, nil
+	}
+
+	// Get current TSDB settings
+	settings := t.Settings(ctx)
+
+	// Do not query before the start of unit
+	startTime := endTime.Add(-time.Duration(t.config.LiveWindow))
+	if unit.StartedAtTS > 0 && startTime.UnixMilli() < unit.StartedAtTS {
+		startTime = time.UnixMilli(unit.StartedAtTS)
+	}
+
+	// Template data
+	tmplData := map[string]interface{}{
+		"UUIDs":                   unit.UUID,
+		"ScrapeInterval":          settings.ScrapeInterval,
+		"ScrapeIntervalMilli":     settings.ScrapeInterval.Milliseconds(),
+		"EvaluationInterval":      settings.EvaluationInterval,
+		"EvaluationIntervalMilli": settings.EvaluationInterval.Milliseconds(),
+		"RateInterval":            settings.RateInterval,
+		"Range":                   endTime.Sub(startTime).Truncate(time.Second),
+	}
+
+	step := strconv.FormatFloat(settings.ScrapeInterval.Seconds(), 'f', -1, 64)
+
+	var errs error
+
+	for metricName, queries := range t.config.LiveQueries {
+		for subMetricName, query := range queries {
+			tsdbQuery, err := t.queryBuilder(fmt.Sprintf("live_%s_%s", metricName, subMetricName), query, tmplData)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to build query %s.%s: %w", metricName, subMetricName, err))
+
+				continue
+			}
+
+			rangeMetric, err := t.RangeQueryByLabel(ctx, tsdbQuery, startTime, endTime, step, "uuid")
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to query %s.%s: %w", metricName, subMetricName, err))
+
+				continue
+			}
+
+			if metrics[metricName] == nil {
+				metrics[metricName] = make(map[string][]models.Sample)
+			}
+
+			metrics[metricName][subMetricName] = toSamples(rangeMetric[unit.UUID])
+		}
+	}
+
+	return metrics, errs
+}
+
+// NodeSeries returns the time series of metrics of a node between startTime
+// and endTime. Queries must return a matrix with `hostname` label.
+func (t *tsdbUpdater) NodeSeries(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	hostname string,
+) (map[string]map[string][]models.Sample, error) {
+	if !t.Available() {
+		return nil, errTSDBUnavailable
+	}
+
+	series := make(map[string]map[string][]models.Sample)
+
+	if len(t.config.SeriesQueries) == 0 {
+		return series, nil
+	}
+
+	// Get current TSDB settings
+	settings := t.Settings(ctx)
+
+	// Template data
+	tmplData := map[string]interface{}{
+		"Hostname":                hostname,
+		"ScrapeInterval":          settings.ScrapeInterval,
+		"ScrapeIntervalMilli":     settings.ScrapeInterval.Milliseconds(),
+		"EvaluationInterval":      settings.EvaluationInterval,
+		"EvaluationIntervalMilli": settings.EvaluationInterval.Milliseconds(),
+		"RateInterval":            settings.RateInterval,
+		"Range":                   endTime.Sub(startTime).Truncate(time.Second),
+	}
+
+	// Limit number of samples of each series for long windows
+	stepDuration := max(settings.ScrapeInterval, endTime.Sub(startTime)/maxNodeSeriesSamples).Truncate(time.Second)
+	step := strconv.FormatFloat(max(stepDuration.Seconds(), 1), 'f', -1, 64)
+
+	var errs error
+
+	for metricName, queries := range t.config.SeriesQueries {
+		for subMetricName, query := range queries {
+			tsdbQuery, err := t.queryBuilder(fmt.Sprintf("node_series_%s_%s", metricName, subMetricName), query, tmplData)
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to build query %s.%s: %w", metricName, subMetricName, err))
+
+				continue
+			}
+
+			rangeMetric, err := t.RangeQueryByLabel(ctx, tsdbQuery, startTime, endTime, step, "hostname")
+			if err != nil {
+				errs = errors.Join(errs, fmt.Errorf("failed to query %s.%s: %w", metricName, subMetricName, err))
+
+				continue
+			}
+
+			if series[metricName] == nil {
+				series[metricName] = make(map[string][]models.Sample)
+			}
+
+			series[metricName][subMetricName] = toSamples(rangeMetric[hostname])
+		}
+	}
+
+	return series, errs
+}
+
+// toSamples converts values of TSDB range query into samples. Values that cannot
+// be parsed are skipped.
+func toSamples(values []interface{}) []models.Sample {
+	samples := make([]models.Sample, 0, len(values))
+
+	for _, v := range values {
+		pair, ok := v.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+
+		ts, ok := pair[0].(float64)
+		if !ok {
+			continue
+		}
+
+		str, ok := pair[1].(string)
+		if !ok {
+			continue
+		}
+
+		val, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			continue
+		}
+
+		samples = append(samples, models.Sample{
+			Timestamp: int64(ts * 1000),
+			Value:     sanitizeValue(val),
+		})
+	}
+
+	return samples
+}
+
 // Return query string from template.
 func (t *tsdbUpdater) queryBuilder(name string, queryTemplate string, data map[string]interface{}) (string, error) {
 	tmpl := template.Must(template.New(name).Parse(queryTemplate))
@@ -113,19 +564,24 @@ func (t *tsdbUpdater) queryBuilder(name string, queryTemplate string, data map[s
 	return builder.String(), nil
 }
 
-// Get time averaged value of each metric identified by label uuid.
+// Get time averaged value of each metric identified by label uuid. Queries that
+// failed are returned in the result so that they can be retried later.
 func (t *tsdbUpdater) fetchAggMetrics(
 	ctx context.Context,
 	queryTime time.Time,
 	duration time.Duration,
 	uuids []string,
+	queries map[string]map[string]string,
 	settings *tsdb.Settings,
-) map[string]map[string]tsdb.Metric {
-	aggMetrics := make(map[string]map[string]tsdb.Metric, len(t.config.Queries))
+) fetchResult {
+	result := fetchResult{
+		metrics: make(map[string]map[string]tsdb.Metric, len(queries)),
+		failed:  make(map[string]map[string]string),
+	}
 
 	// If duration is less than rateInterval bail
 	if duration < settings.RateInterval {
-		return aggMetrics
+		return result
 	}
 
 	// UPDATE 20250110: Not necessary anymore as we estimate the batch size dynamically
@@ -139,10 +595,15 @@ func (t *tsdbUpdater) fetchAggMetrics(
 
 	// Start a wait group
 	var wg sync.WaitGroup
-	for _, queries := range t.config.Queries {
-		wg.Add(len(queries))
+	for _, subQueries := range queries {
+		result.numQueries += len(subQueries)
 	}
 
+	wg.Add(result.numQueries)
+
+	// Limit number of concurrent queries based on health of TSDB
+	sem := make(chan struct{}, t.pressure.limit(result.numQueries))
+
 	// Template data
 	tmplData := map[string]interface{}{
 		"UUIDs":                   strings.Join(uuids, "|"),
@@ -154,12 +615,16 @@ func (t *tsdbUpdater) fetchAggMetrics(
 		"Range":                   duration,
 	}
 
-	// Loop over t.config.queries map and make queries
-	for metricName, queries := range t.config.Queries {
-		for subMetricName, query := range queries {
+	// Loop over queries map and make queries
+	for metricName, subQueries := range queries {
+		for subMetricName, query := range subQueries {
 			go func(n string, sn string, q string) {
 				defer wg.Done()
 
+				sem <- struct{}{}
+
+				defer func() { <-sem }()
+
 				var aggMetric tsdb.Metric
 
 				var err error
@@ -174,20 +639,33 @@ func (t *tsdbUpdater) fetchAggMetrics(
 					return
 				}
 
-				if aggMetric, err = t.Query(ctx, tsdbQuery, queryTime); err != nil {
+				start := time.Now()
+
+				aggMetric, err = t.Query(ctx, tsdbQuery, queryTime)
+
+				metricLock.Lock()
+				defer metricLock.Unlock()
+
+				result.latency = max(result.latency, time.Since(start))
+
+				if err != nil {
 					t.Logger.Error(
 						"Failed to fetch metrics from TSDB", "metric", n, "duration",
 						duration, "scrape_int", settings.ScrapeInterval,
 						"rate_int", settings.RateInterval, "err", err,
 					)
+
+					if result.failed[n] == nil {
+						result.failed[n] = make(map[string]string)
+					}
+
+					result.failed[n][sn] = q
 				} else {
-					metricLock.Lock()
-					if aggMetrics[n] == nil {
-						aggMetrics[n] = make(map[string]tsdb.Metric)
+					if result.metrics[n] == nil {
+						result.metrics[n] = make(map[string]tsdb.Metric)
 					}
 
-					aggMetrics[n][sn] = aggMetric
-					metricLock.Unlock()
+					result.metrics[n][sn] = aggMetric
 				}
 			}(metricName, subMetricName, query)
 		}
@@ -196,18 +674,27 @@ func (t *tsdbUpdater) fetchAggMetrics(
 	// Wait for all go routines
 	wg.Wait()
 
-	return aggMetrics
+	return result
 }
 
 // Fetch unit metrics from TSDB and update UnitStat struct for each unit.
 func (t *tsdbUpdater) update(
 	ctx context.Context,
+	clusterID string,
 	startTime time.Time,
 	endTime time.Time,
 	units []models.Unit,
 ) []models.Unit {
-	// Bail if TSDB is unavailable or there are no units to update
-	if !t.Available() || len(units) == 0 {
+	// Bail if there are no units to update
+	if len(units) == 0 {
+		return units
+	}
+
+	// If TSDB is unavailable, mark all units for retry so that their metrics
+	// are fetched once TSDB is back
+	if !t.Available() {
+		t.pressure.markPending(clusterID, startTime, endTime, units, t.config.Queries)
+
 		return units
 	}
 
@@ -278,8 +765,39 @@ func (t *tsdbUpdater) update(
 
 			return units
 		default:
+			// When TSDB is degraded, skip deferred metrics to reduce load
+			queries := t.config.Queries
+			if t.pressure.isDegraded() && len(t.config.Backpressure.DeferredMetrics) > 0 {
+				queries = maps.Clone(queries)
+				maps.DeleteFunc(queries, func(name string, _ map[string]string) bool {
+					return slices.Contains(t.config.Backpressure.DeferredMetrics, name)
+				})
+			}
+
 			// Get aggregate metrics of present chunk
-			batchedAggMetrics := t.fetchAggMetrics(ctx, endTime, duration, batchUUIDs, settings)
+			result := t.fetchAggMetrics(ctx, endTime, duration, batchUUIDs, queries, settings)
+			batchedAggMetrics := result.metrics
+
+			// Update health of TSDB and mark units of failed and skipped
+			// queries for retry
+			if t.pressure.observe(result) {
+				t.Logger.Warn(
+					"TSDB is degraded. Reducing query concurrency", "batch_id", iBatch,
+					"failed_metrics", len(result.failed), "latency", result.latency,
+				)
+			}
+
+			retryQueries := result.failed
+
+			for name, subQueries := range t.config.Queries {
+				if _, ok := queries[name]; !ok {
+					retryQueries[name] = subQueries
+				}
+			}
+
+			if len(retryQueries) > 0 {
+				t.pressure.markPending(clusterID, startTime, endTime, batchUnits(units, batchUUIDs), retryQueries)
+			}
 
 			// Merge metrics map of each metric type. Metric map has uuid as key and hence
 			// merging is safe as UUID is "unique" during the given update interval
@@ -306,6 +824,11 @@ func (t *tsdbUpdater) update(
 		}
 	}
 
+	// Completeness can be estimated only when aggregate metrics have been queried
+	estimateCompleteness := duration >= settings.RateInterval && len(t.config.Queries) > 0
+	completenessSums := make(map[string]float64)
+	numUnits := 0
+
 	// Update all units
 	// NOTE: We can improve this by using reflect package by naming queries
 	// after field names. That will allow us to dynamically look up struct
@@ -313,6 +836,17 @@ func (t *tsdbUpdater) update(
 	for i := range len(units) {
 		uuid := units[i].UUID
 
+		// Estimate fraction of aggregate metrics resolved for the unit
+		if estimateCompleteness && uuid != "" {
+			units[i].Completeness = t.completeness(uuid, aggMetrics)
+
+			for name, value := range units[i].Completeness {
+				completenessSums[name] += float64(value)
+			}
+
+			numUnits++
+		}
+
 		// Update with CPU metrics
 		if metrics, mExists := aggMetrics["avg_cpu_usage"]; mExists {
 			units[i].AveCPUUsage = make(models.MetricMap)
@@ -438,6 +972,16 @@ func (t *tsdbUpdater) update(
 		}
 	}
 
+	// Update completeness metrics
+	if numUnits > 0 {
+		for name, sum := range completenessSums {
+			unitsCompleteness.WithLabelValues(t.id, name).Set(sum / float64(numUnits))
+		}
+	}
+
+	// Track units that ended close to end of interval for re-aggregation
+	t.late.track(clusterID, startTime, endTime, units)
+
 	// Finally delete time series
 	if err := t.deleteTimeSeries(ctx, startTime, endTime, ignoredUnits); err != nil {
 		t.Logger.Error("Failed to delete time series in TSDB", "err", err)
@@ -446,6 +990,38 @@ func (t *tsdbUpdater) update(
 	return units
 }
 
+// completeness returns the fraction of configured queries of each aggregate metric
+// that returned a value for unit identified by uuid. Overall fraction of all
+// queries is set in the score key.
+func (t *tsdbUpdater) completeness(uuid string, aggMetrics map[string]map[string]tsdb.Metric) models.MetricMap {
+	completeness := make(models.MetricMap, len(t.config.Queries)+1)
+
+	var resolved, total int
+
+	for metricName, queries := range t.config.Queries {
+		var metricResolved int
+
+		for subMetricName := range queries {
+			if _, exists := aggMetrics[metricName][subMetricName][uuid]; exists {
+				metricResolved++
+			}
+		}
+
+		if len(queries) > 0 {
+			completeness[metricName] = models.JSONFloat(float64(metricResolved) / float64(len(queries)))
+		}
+
+		resolved += metricResolved
+		total += len(queries)
+	}
+
+	if total > 0 {
+		completeness[completenessScoreKey] = models.JSONFloat(float64(resolved) / float64(total))
+	}
+
+	return completeness
+}
+
 // Delete time series data of ignored units.
 func (t *tsdbUpdater) deleteTimeSeries(
 	ctx context.Context,
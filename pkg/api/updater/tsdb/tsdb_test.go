@@ -8,12 +8,14 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/api/updater"
 	"github.com/mahendrapaipuri/ceems/pkg/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -54,6 +56,20 @@ func mockTSDBServer() *httptest.Server {
 	return server
 }
 
+func mockCompleteness(value float64) models.MetricMap {
+	completeness := models.MetricMap{completenessScoreKey: models.JSONFloat(value)}
+
+	for _, name := range []string{
+		"avg_cpu_usage", "avg_cpu_mem_usage", "total_cpu_energy_usage_kwh", "total_cpu_emissions_gms",
+		"avg_gpu_usage", "avg_gpu_mem_usage", "total_gpu_energy_usage_kwh", "total_gpu_emissions_gms",
+		"total_io_write_stats", "total_io_read_stats", "total_ingress_stats", "total_outgress_stats",
+	} {
+		completeness[name] = models.JSONFloat(value)
+	}
+
+	return completeness
+}
+
 func mockInstanceConfig(url string) (updater.Instance, error) {
 	config := `
 ---
@@ -201,6 +217,7 @@ func TestTSDBUpdateSuccessSingleInstance(t *testing.T) {
 				"drops":   models.JSONFloat(1.1),
 				"errors":  models.JSONFloat(1.1),
 			},
+			Completeness: mockCompleteness(1),
 		},
 		{
 			UUID:        "2",
@@ -235,6 +252,7 @@ func TestTSDBUpdateSuccessSingleInstance(t *testing.T) {
 				"drops":   models.JSONFloat(2.2),
 				"errors":  models.JSONFloat(2.2),
 			},
+			Completeness: mockCompleteness(1),
 		},
 		{
 			UUID:        "3",
@@ -260,6 +278,7 @@ func TestTSDBUpdateSuccessSingleInstance(t *testing.T) {
 			TotalIOReadStats:    models.MetricMap{},
 			TotalIngressStats:   models.MetricMap{},
 			TotalOutgressStats:  models.MetricMap{},
+			Completeness:        mockCompleteness(0),
 		},
 	}
 
@@ -272,6 +291,310 @@ func TestTSDBUpdateSuccessSingleInstance(t *testing.T) {
 	}
 }
 
+func TestTSDBUpdateCompleteness(t *testing.T) {
+	// Start test server that resolves only `foo` queries
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := []interface{}{}
+		if r.FormValue("query") == "foo" {
+			result = append(result, map[string]interface{}{
+				"metric": map[string]string{"uuid": "1"},
+				"value":  []interface{}{12345, "1.1"},
+			})
+		}
+
+		expected := tsdb.Response{
+			Status: "success",
+			Data:   map[string]interface{}{"resultType": "vector", "result": result},
+		}
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	// Make mock instance config
+	instance, err := mockInstanceConfig(server.URL)
+	require.NoError(t, err)
+
+	instance.ID = "completeness"
+
+	currTime := time.Now()
+
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "default", Updaters: []string{"completeness"}},
+			Units: []models.Unit{
+				{
+					UUID:        "1",
+					StartedAtTS: currTime.Add(-3000 * time.Second).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+				{
+					UUID:        "2",
+					StartedAtTS: currTime.Add(-3000 * time.Second).UnixMilli(),
+					EndedAtTS:   currTime.UnixMilli(),
+				},
+			},
+		},
+	}
+
+	tsdb, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	updatedUnits := tsdb.Update(context.Background(), currTime.Add(-5*time.Minute), currTime, units)
+
+	// Unit 1 must have all queries resolved except `bar` ones
+	completeness := updatedUnits[0].Units[0].Completeness
+	assert.Equal(t, models.JSONFloat(1), completeness["avg_cpu_usage"])
+	assert.Equal(t, models.JSONFloat(0.5), completeness["total_io_write_stats"])
+	assert.Equal(t, models.JSONFloat(0.5), completeness["total_ingress_stats"])
+	assert.Equal(t, models.JSONFloat(0.7), completeness[completenessScoreKey])
+
+	// Unit 2 must have none
+	assert.Equal(t, mockCompleteness(0), updatedUnits[0].Units[1].Completeness)
+
+	// Check summary metric which is average over units
+	assert.InEpsilon(t, 0.35, testutil.ToFloat64(unitsCompleteness.WithLabelValues("completeness", completenessScoreKey)), 1e-9)
+	assert.InEpsilon(t, 0.25, testutil.ToFloat64(unitsCompleteness.WithLabelValues("completeness", "total_io_read_stats")), 1e-9)
+}
+
+func TestTSDBUpdateBackpressure(t *testing.T) {
+	// Start test server that fails `bar` queries while TSDB is degraded
+	var degraded atomic.Bool
+
+	degraded.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("query") == "bar" && degraded.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		expected := tsdb.Response{
+			Status: "success",
+			Data: map[string]interface{}{
+				"resultType": "vector",
+				"result": []interface{}{
+					map[string]interface{}{
+						"metric": map[string]string{"uuid": "1"},
+						"value":  []interface{}{12345, "1.1"},
+					},
+					map[string]interface{}{
+						"metric": map[string]string{"uuid": "2"},
+						"value":  []interface{}{12345, "2.2"},
+					},
+				},
+			},
+		}
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+queries:
+  avg_cpu_usage:
+    usage: foo
+  total_cpu_energy_usage_kwh:
+    total: bar
+backpressure:
+  max_concurrency: 4
+  deferred_metrics:
+    - avg_cpu_usage`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "backpressure",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	currTime := time.Now()
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units:   []models.Unit{{UUID: "1", State: "RUNNING"}},
+		},
+	}
+
+	// First update must mark unit for retry and reduce concurrency
+	updatedUnits := tsdbUpdater.Update(context.Background(), currTime.Add(-10*time.Minute), currTime.Add(-5*time.Minute), units)
+	require.Len(t, updatedUnits[0].Units, 1)
+	assert.Equal(t, models.MetricMap{"usage": 1.1}, updatedUnits[0].Units[0].AveCPUUsage)
+	assert.Nil(t, updatedUnits[0].Units[0].TotalCPUEnergyUsage)
+	assert.InDelta(t, 1, testutil.ToFloat64(tsdbDegraded.WithLabelValues("backpressure")), 0)
+	assert.InDelta(t, 2, testutil.ToFloat64(queryConcurrency.WithLabelValues("backpressure")), 0)
+	assert.InDelta(t, 1, testutil.ToFloat64(pendingRetryUnits.WithLabelValues("backpressure")), 0)
+
+	// Once TSDB recovers, deferred metrics must be skipped for first batch and
+	// pending unit must be retried
+	degraded.Store(false)
+
+	units = []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units:   []models.Unit{{UUID: "2", State: "RUNNING"}},
+		},
+	}
+	updatedUnits = tsdbUpdater.Update(context.Background(), currTime.Add(-5*time.Minute), currTime, units)
+	require.Len(t, updatedUnits[0].Units, 2)
+	assert.Nil(t, updatedUnits[0].Units[0].AveCPUUsage)
+	assert.Equal(t, models.MetricMap{"total": 2.2}, updatedUnits[0].Units[0].TotalCPUEnergyUsage)
+
+	// Retried unit must only carry the missing totals
+	retried := updatedUnits[0].Units[1]
+	assert.Equal(t, "1", retried.UUID)
+	assert.Equal(t, models.MetricMap{"total": 1.1}, retried.TotalCPUEnergyUsage)
+	assert.Equal(t, models.JSONFloat(0), retried.TotalTime["walltime"])
+	assert.Empty(t, retried.AveCPUUsage)
+	assert.InDelta(t, 0, testutil.ToFloat64(tsdbDegraded.WithLabelValues("backpressure")), 0)
+	assert.InDelta(t, 0, testutil.ToFloat64(pendingRetryUnits.WithLabelValues("backpressure")), 0)
+}
+
+func TestTSDBUpdateReaggregation(t *testing.T) {
+	// Start test server that returns more energy once late samples are ingested
+	var late atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := "1.1"
+		if late.Load() {
+			value = "1.5"
+		}
+
+		expected := tsdb.Response{
+			Status: "success",
+			Data: map[string]interface{}{
+				"resultType": "vector",
+				"result": []interface{}{
+					map[string]interface{}{
+						"metric": map[string]string{"uuid": "1"},
+						"value":  []interface{}{12345, value},
+					},
+					map[string]interface{}{
+						"metric": map[string]string{"uuid": "2"},
+						"value":  []interface{}{12345, value},
+					},
+				},
+			},
+		}
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+queries:
+  avg_cpu_usage:
+    usage: foo
+  total_cpu_energy_usage_kwh:
+    total: bar
+reaggregation_lookback: 2m`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "reaggregation",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	currTime := time.Now()
+	boundary := currTime.Add(-5 * time.Minute)
+
+	// Unit 1 ended close to end of interval and unit 2 long before it
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units: []models.Unit{
+				{
+					UUID:        "1",
+					StartedAtTS: currTime.Add(-time.Hour).UnixMilli(),
+					EndedAtTS:   boundary.Add(-30 * time.Second).UnixMilli(),
+				},
+				{
+					UUID:        "2",
+					StartedAtTS: currTime.Add(-time.Hour).UnixMilli(),
+					EndedAtTS:   boundary.Add(-4 * time.Minute).UnixMilli(),
+				},
+			},
+		},
+	}
+
+	updatedUnits := tsdbUpdater.Update(context.Background(), currTime.Add(-10*time.Minute), boundary, units)
+	require.Len(t, updatedUnits[0].Units, 2)
+	assert.Equal(t, models.MetricMap{"total": 1.1}, updatedUnits[0].Units[0].TotalCPUEnergyUsage)
+
+	// Next update must add the energy of late samples of unit 1 only
+	late.Store(true)
+
+	units = []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units:   []models.Unit{{UUID: "3", State: "RUNNING"}},
+		},
+	}
+	updatedUnits = tsdbUpdater.Update(context.Background(), boundary, currTime, units)
+	require.Len(t, updatedUnits[0].Units, 2)
+
+	reaggregated := updatedUnits[0].Units[1]
+	assert.Equal(t, "1", reaggregated.UUID)
+	assert.InDelta(t, 0.4, float64(reaggregated.TotalCPUEnergyUsage["total"]), 1e-9)
+	assert.Equal(t, models.JSONFloat(0), reaggregated.TotalTime["walltime"])
+	assert.Empty(t, reaggregated.AveCPUUsage)
+	assert.InDelta(t, 1, testutil.ToFloat64(reaggregatedUnits.WithLabelValues("reaggregation")), 0)
+
+	// Units must be re-aggregated only once
+	units = []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0"},
+			Units:   []models.Unit{{UUID: "3", State: "RUNNING"}},
+		},
+	}
+	updatedUnits = tsdbUpdater.Update(context.Background(), currTime, currTime.Add(5*time.Minute), units)
+	require.Len(t, updatedUnits[0].Units, 1)
+}
+
+func TestTSDBBackpressureInvalidConfig(t *testing.T) {
+	for _, config := range []string{
+		"backpressure:\n  error_rate_threshold: 1.5",
+		"backpressure:\n  max_concurrency: -1",
+	} {
+		var extraConfig yaml.Node
+
+		err := yaml.Unmarshal([]byte(config), &extraConfig)
+		require.NoError(t, err)
+
+		instance := updater.Instance{ID: "invalid", Updater: "tsdb", Web: models.WebConfig{URL: "http://localhost:9090"}, Extra: extraConfig}
+
+		_, err = New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.Error(t, err, config)
+	}
+}
+
 func TestTSDBUpdateFailMaxDuration(t *testing.T) {
 	// Start test server
 	server := mockTSDBServer()
@@ -458,3 +781,315 @@ func TestTSDBUpdateFailNoTSDB(t *testing.T) {
 	updatedUnits := tsdb.Update(context.Background(), time.Now().Add(-5*time.Minute), time.Now(), units)
 	assert.Equal(t, expectedUnits, updatedUnits)
 }
+
+func TestTSDBUpdateNodes(t *testing.T) {
+	// Start test server
+	expected := tsdb.Response{
+		Status: "success",
+		Data: map[string]interface{}{
+			"resultType": "vector",
+			"result": []interface{}{
+				map[string]interface{}{
+					"metric": map[string]string{
+						"hostname": "compute-1",
+					},
+					"value": []interface{}{
+						12345, "2.2",
+					},
+				},
+				map[string]interface{}{
+					"metric": map[string]string{
+						"hostname": "compute-0",
+					},
+					"value": []interface{}{
+						12345, "NaN",
+					},
+				},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+node_queries:
+  total_energy_usage_kwh:
+    ipmi: foo
+  total_emissions_gms:
+    rte: bar`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "default",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	nodeUpdater, ok := tsdbUpdater.(updater.NodeUpdater)
+	require.True(t, ok)
+
+	currTime := time.Now()
+	cluster := models.Cluster{ID: "slurm-0", Manager: "slurm"}
+	nodes := nodeUpdater.UpdateNodes(context.Background(), currTime.Add(-15*time.Minute), currTime, cluster)
+
+	expectedNodes := []models.Node{
+		{
+			ClusterID:        "slurm-0",
+			ResourceManager:  "slurm",
+			Hostname:         "compute-0",
+			TotalTime:        models.MetricMap{"walltime": 900},
+			TotalEnergyUsage: models.MetricMap{"ipmi": 0},
+			TotalEmissions:   models.MetricMap{"rte": 0},
+		},
+		{
+			ClusterID:        "slurm-0",
+			ResourceManager:  "slurm",
+			Hostname:         "compute-1",
+			TotalTime:        models.MetricMap{"walltime": 900},
+			TotalEnergyUsage: models.MetricMap{"ipmi": 2.2},
+			TotalEmissions:   models.MetricMap{"rte": 2.2},
+		},
+	}
+	assert.Equal(t, expectedNodes, nodes)
+}
+
+func TestTSDBUpdateEnergyBaseline(t *testing.T) {
+	// Start test server
+	expected := tsdb.Response{
+		Status: "success",
+		Data: map[string]interface{}{
+			"resultType": "vector",
+			"result": []interface{}{
+				map[string]interface{}{
+					"metric": map[string]string{"hostname": "compute-0"},
+					"value":  []interface{}{12345, "0.5"},
+				},
+				map[string]interface{}{
+					"metric": map[string]string{"hostname": "compute-1"},
+					"value":  []interface{}{12345, "1.5"},
+				},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+energy_baseline:
+  queries:
+    total: foo`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "default",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	currTime := time.Now()
+	units := []models.ClusterUnits{
+		{
+			Cluster: models.Cluster{ID: "slurm-0", Manager: "slurm"},
+			Units:   []models.Unit{{UUID: "1"}},
+		},
+	}
+	updatedUnits := tsdbUpdater.Update(context.Background(), currTime.Add(-15*time.Minute), currTime, units)
+
+	// Baseline energy of all nodes must be added as a pseudo unit of infrastructure project
+	require.Len(t, updatedUnits[0].Units, 2)
+
+	baseline := updatedUnits[0].Units[1]
+	assert.Equal(t, fmt.Sprintf("baseline-%d", currTime.Unix()), baseline.UUID)
+	assert.Equal(t, "slurm-0", baseline.ClusterID)
+	assert.Equal(t, "infrastructure", baseline.Project)
+	assert.Equal(t, "infrastructure", baseline.User)
+	assert.Equal(t, models.MetricMap{"total": 2}, baseline.TotalCPUEnergyUsage)
+	assert.Equal(t, models.JSONFloat(900), baseline.TotalTime["walltime"])
+	assert.Equal(t, "00:15:00", baseline.Elapsed)
+}
+
+func TestTSDBLive(t *testing.T) {
+	// Start test server
+	expected := tsdb.Response{
+		Status: "success",
+		Data: map[string]interface{}{
+			"resultType": "matrix",
+			"result": []interface{}{
+				map[string]interface{}{
+					"metric": map[string]string{
+						"uuid": "1",
+					},
+					"values": []interface{}{
+						[]interface{}{1735045200, "1.5"},
+						[]interface{}{1735045230, "NaN"},
+						[]interface{}{1735045260, "2.5"},
+					},
+				},
+				map[string]interface{}{
+					"metric": map[string]string{
+						"uuid": "2",
+					},
+					"values": []interface{}{
+						[]interface{}{1735045200, "10"},
+					},
+				},
+			},
+		},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+live_window: 5m
+live_queries:
+  cpu_usage:
+    usage: foo{uuid=~"{{.UUIDs}}"}
+  gpu_power_usage:
+    total: bar{uuid=~"{{.UUIDs}}"}`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "default",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	liveUpdater, ok := tsdbUpdater.(updater.LiveUpdater)
+	require.True(t, ok)
+
+	currTime := time.Now()
+	unit := models.Unit{UUID: "1", StartedAtTS: currTime.Add(-time.Minute).UnixMilli()}
+	metrics, err := liveUpdater.Live(context.Background(), currTime, unit)
+	require.NoError(t, err)
+
+	expectedSamples := []models.Sample{
+		{Timestamp: 1735045200000, Value: 1.5},
+		{Timestamp: 1735045230000, Value: 0},
+		{Timestamp: 1735045260000, Value: 2.5},
+	}
+	assert.Equal(t, map[string]map[string][]models.Sample{
+		"cpu_usage":       {"usage": expectedSamples},
+		"gpu_power_usage": {"total": expectedSamples},
+	}, metrics)
+}
+
+func TestTSDBNodeSeries(t *testing.T) {
+	// Start test server
+	expected := tsdb.Response{
+		Status: "success",
+		Data: map[string]interface{}{
+			"resultType": "matrix",
+			"result": []interface{}{
+				map[string]interface{}{
+					"metric": map[string]string{
+						"hostname": "compute-0",
+					},
+					"values": []interface{}{
+						[]interface{}{1735045200, "250"},
+						[]interface{}{1735045260, "300"},
+					},
+				},
+				map[string]interface{}{
+					"metric": map[string]string{
+						"hostname": "compute-1",
+					},
+					"values": []interface{}{
+						[]interface{}{1735045200, "100"},
+					},
+				},
+			},
+		},
+	}
+
+	var query string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		query = r.Form.Get("query")
+
+		if err := json.NewEncoder(w).Encode(&expected); err != nil {
+			w.Write([]byte("KO"))
+		}
+	}))
+	defer server.Close()
+
+	config := `
+---
+node_series_queries:
+  power_watts:
+    ipmi: foo{hostname="{{.Hostname}}"}`
+
+	var extraConfig yaml.Node
+
+	err := yaml.Unmarshal([]byte(config), &extraConfig)
+	require.NoError(t, err)
+
+	instance := updater.Instance{
+		ID:      "default",
+		Updater: "tsdb",
+		Web: models.WebConfig{
+			URL: server.URL,
+		},
+		Extra: extraConfig,
+	}
+
+	tsdbUpdater, err := New(instance, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	seriesUpdater, ok := tsdbUpdater.(updater.NodeSeriesUpdater)
+	require.True(t, ok)
+
+	currTime := time.Now()
+	series, err := seriesUpdater.NodeSeries(context.Background(), currTime.Add(-time.Hour), currTime, "compute-0")
+	require.NoError(t, err)
+
+	assert.Equal(t, `foo{hostname="compute-0"}`, query)
+	assert.Equal(t, map[string]map[string][]models.Sample{
+		"power_watts": {"ipmi": {{Timestamp: 1735045200000, Value: 250}, {Timestamp: 1735045260000, Value: 300}}},
+	}, series)
+}
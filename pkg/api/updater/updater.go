@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"path/filepath"
 	"slices"
 	"time"
@@ -51,6 +52,38 @@ type Updater interface {
 	) []models.ClusterUnits
 }
 
+// NodeUpdater is the optional interface implemented by updaters that can estimate
+// energy usage and emissions of compute nodes independent of compute units.
+type NodeUpdater interface {
+	UpdateNodes(
+		ctx context.Context,
+		startTime time.Time,
+		endTime time.Time,
+		cluster models.Cluster,
+	) []models.Node
+}
+
+// LiveUpdater is the optional interface implemented by updaters that can return
+// recent time series of metrics of running compute units.
+type LiveUpdater interface {
+	Live(
+		ctx context.Context,
+		endTime time.Time,
+		unit models.Unit,
+	) (map[string]map[string][]models.Sample, error)
+}
+
+// NodeSeriesUpdater is the optional interface implemented by updaters that can
+// return time series of metrics, like power and temperature, of compute nodes.
+type NodeSeriesUpdater interface {
+	NodeSeries(
+		ctx context.Context,
+		startTime time.Time,
+		endTime time.Time,
+		hostname string,
+	) (map[string]map[string][]models.Sample, error)
+}
+
 // UnitUpdater implements the interface to update compute units from different updaters.
 type UnitUpdater struct {
 	Updaters map[string]Updater
@@ -100,22 +133,34 @@ func checkConfig(updaters []string, config *Config[Instance]) (map[string][]Inst
 
 // updaterConfig returns the configuration of updaters.
 func updaterConfig() (*Config[Instance], error) {
+	return updaterConfigFromFile(base.ConfigFilePath)
+}
+
+// updaterConfigFromFile returns the configuration of updaters found in the
+// file at path.
+func updaterConfigFromFile(path string) (*Config[Instance], error) {
 	// Merge default config with provided config
-	config, err := common.MakeConfig[Config[Instance]](base.ConfigFilePath)
+	config, err := common.MakeConfig[Config[Instance]](path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Set directories
 	for i := range len(config.Instances) {
-		config.Instances[i].Web.HTTPClientConfig.SetDirectory(filepath.Dir(base.ConfigFilePath))
+		config.Instances[i].Web.HTTPClientConfig.SetDirectory(filepath.Dir(path))
 	}
 
 	return config, nil
 }
 
-// New creates a new UnitUpdater.
+// New creates a new UnitUpdater using the configuration file of CEEMS API server.
 func New(logger *slog.Logger) (*UnitUpdater, error) {
+	return NewFromFile(base.ConfigFilePath, logger)
+}
+
+// NewFromFile creates a new UnitUpdater using the updaters found in the
+// configuration file at path.
+func NewFromFile(path string, logger *slog.Logger) (*UnitUpdater, error) {
 	var updater Updater
 
 	updaters := make(map[string]Updater)
@@ -130,7 +175,7 @@ func New(logger *slog.Logger) (*UnitUpdater, error) {
 	}
 
 	// Get current config
-	config, err := updaterConfig()
+	config, err := updaterConfigFromFile(path)
 	if err != nil {
 		logger.Error("Failed to parse updater config", "err", err)
 
@@ -165,6 +210,44 @@ func New(logger *slog.Logger) (*UnitUpdater, error) {
 	}, nil
 }
 
+// Add adds updaters of instances whose IDs are not in use yet. It is used to add
+// the updaters provisioned using API server to the ones found in config file.
+// Invalid instances are skipped and the errors are returned.
+func (u *UnitUpdater) Add(instances []Instance) error {
+	registeredUpdaters := slices.Collect(maps.Keys(updaterFactories))
+
+	var errs error
+
+	for _, instance := range instances {
+		if _, ok := u.Updaters[instance.ID]; ok {
+			u.Logger.Warn("Skipping provisioned updater as it is already configured", "id", instance.ID)
+
+			continue
+		}
+
+		if _, err := checkConfig(registeredUpdaters, &Config[Instance]{Instances: []Instance{instance}}); err != nil {
+			errs = errors.Join(errs, err)
+
+			continue
+		}
+
+		updater, err := updaterFactories[instance.Updater](instance, u.Logger.With("updater", instance.Updater))
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to setup updater %s: %w", instance.ID, err))
+
+			continue
+		}
+
+		if u.Updaters == nil {
+			u.Updaters = make(map[string]Updater)
+		}
+
+		u.Updaters[instance.ID] = updater
+	}
+
+	return errs
+}
+
 // Update implements updating units using registered updaters.
 func (u UnitUpdater) Update(
 	ctx context.Context,
@@ -207,3 +290,127 @@ func (u UnitUpdater) Update(
 
 	return clusterUnits
 }
+
+// UpdateNodes returns node stats of clusters from registered updaters that
+// implement NodeUpdater interface.
+func (u UnitUpdater) UpdateNodes(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	clusters []models.Cluster,
+) []models.ClusterNodes {
+	var clusterNodes []models.ClusterNodes
+
+	for _, cluster := range clusters {
+		var nodes []models.Node
+
+		for _, updaterID := range cluster.Updaters {
+			updater, ok := u.Updaters[updaterID]
+			if !ok {
+				continue
+			}
+
+			// Skip updaters that do not support node stats
+			if nodeUpdater, ok := updater.(NodeUpdater); ok {
+				nodes = append(nodes, nodeUpdater.UpdateNodes(ctx, startTime, endTime, cluster)...)
+			}
+		}
+
+		if len(nodes) > 0 {
+			clusterNodes = append(clusterNodes, models.ClusterNodes{Cluster: cluster, Nodes: nodes})
+		}
+	}
+
+	return clusterNodes
+}
+
+// Live returns recent time series of metrics of a running unit from registered
+// updaters of cluster that implement LiveUpdater interface.
+func (u UnitUpdater) Live(
+	ctx context.Context,
+	endTime time.Time,
+	cluster models.Cluster,
+	unit models.Unit,
+) (map[string]map[string][]models.Sample, error) {
+	metrics := make(map[string]map[string][]models.Sample)
+
+	var errs error
+
+	for _, updaterID := range cluster.Updaters {
+		updater, ok := u.Updaters[updaterID]
+		if !ok {
+			continue
+		}
+
+		// Skip updaters that do not support live metrics
+		liveUpdater, ok := updater.(LiveUpdater)
+		if !ok {
+			continue
+		}
+
+		liveMetrics, err := liveUpdater.Live(ctx, endTime, unit)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("updater %s: %w", updaterID, err))
+
+			continue
+		}
+
+		for name, subMetrics := range liveMetrics {
+			if metrics[name] == nil {
+				metrics[name] = make(map[string][]models.Sample)
+			}
+
+			for subName, samples := range subMetrics {
+				metrics[name][subName] = samples
+			}
+		}
+	}
+
+	return metrics, errs
+}
+
+// NodeSeries returns time series of metrics of a node from registered updaters
+// of cluster that implement NodeSeriesUpdater interface.
+func (u UnitUpdater) NodeSeries(
+	ctx context.Context,
+	startTime time.Time,
+	endTime time.Time,
+	cluster models.Cluster,
+	hostname string,
+) (map[string]map[string][]models.Sample, error) {
+	series := make(map[string]map[string][]models.Sample)
+
+	var errs error
+
+	for _, updaterID := range cluster.Updaters {
+		updater, ok := u.Updaters[updaterID]
+		if !ok {
+			continue
+		}
+
+		// Skip updaters that do not support node series
+		seriesUpdater, ok := updater.(NodeSeriesUpdater)
+		if !ok {
+			continue
+		}
+
+		nodeSeries, err := seriesUpdater.NodeSeries(ctx, startTime, endTime, hostname)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("updater %s: %w", updaterID, err))
+
+			continue
+		}
+
+		for name, subSeries := range nodeSeries {
+			if series[name] == nil {
+				series[name] = make(map[string][]models.Sample)
+			}
+
+			for subName, samples := range subSeries {
+				series[name][subName] = samples
+			}
+		}
+	}
+
+	return series, errs
+}
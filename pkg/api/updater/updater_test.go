@@ -1,12 +1,17 @@
 package updater
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -162,3 +167,30 @@ func TestOneInstanceConfig(t *testing.T) {
 	_, err = checkConfig([]string{"tsdb"}, cfg)
 	assert.NoError(t, err)
 }
+
+type mockUpdater struct{}
+
+func (mockUpdater) Update(_ context.Context, _ time.Time, _ time.Time, units []models.ClusterUnits) []models.ClusterUnits {
+	return units
+}
+
+func TestUnitUpdaterAddInstances(t *testing.T) {
+	Register("mock", func(_ Instance, _ *slog.Logger) (Updater, error) {
+		return mockUpdater{}, nil
+	})
+
+	u := &UnitUpdater{
+		Updaters: map[string]Updater{"default": mockUpdater{}},
+		Logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// Existing and invalid instances must be skipped
+	err := u.Add([]Instance{
+		{ID: "default", Updater: "mock"},
+		{ID: "mock-0", Updater: "mock"},
+		{ID: "unknown-0", Updater: "unknown"},
+	})
+	require.Error(t, err)
+	assert.Len(t, u.Updaters, 2)
+	assert.Contains(t, u.Updaters, "mock-0")
+}
@@ -0,0 +1,272 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const acceleratorCollectorSubsystem = "accelerator"
+
+// Accelerator represents a non-GPU accelerator device like FPGA.
+type Accelerator struct {
+	vendor   string
+	index    string
+	model    string
+	busID    string
+	devNodes []string
+}
+
+// String implements Stringer interface of the Accelerator struct.
+func (a Accelerator) String() string {
+	return fmt.Sprintf(
+		"vendor: %s; index: %s; model: %s; bus_id: %s; dev_nodes: %s",
+		a.vendor, a.index, a.model, a.busID, strings.Join(a.devNodes, ","),
+	)
+}
+
+// acceleratorStats contains the current stats of an accelerator. Nil values
+// indicate that the stat is not reported by the vendor tool.
+type acceleratorStats struct {
+	power       *float64
+	utilization *float64
+}
+
+// acceleratorProvider is the interface that vendor specific accelerator
+// implementations must satisfy.
+type acceleratorProvider interface {
+	// Devices returns accelerator devices found on the host. Only vendor,
+	// model and busID of devices need to be populated.
+	Devices() ([]Accelerator, error)
+	// Stats returns current stats of devices keyed by bus ID.
+	Stats(devs []Accelerator) (map[string]acceleratorStats, error)
+}
+
+// acceleratorProviders contains factories of all registered accelerator providers.
+var acceleratorProviders = make(map[string]func(logger *slog.Logger) acceleratorProvider)
+
+// registerAcceleratorProvider registers a new accelerator provider for vendor.
+func registerAcceleratorProvider(vendor string, factory func(logger *slog.Logger) acceleratorProvider) {
+	acceleratorProviders[vendor] = factory
+}
+
+// acceleratorCollectorEnabled returns true if accelerator collector is enabled.
+func acceleratorCollectorEnabled() bool {
+	if state, ok := collectorState[acceleratorCollectorSubsystem]; ok && state != nil {
+		return *state
+	}
+
+	return false
+}
+
+// newAcceleratorProviders returns the providers that found at least one device
+// on the host along with all the devices.
+func newAcceleratorProviders(logger *slog.Logger) (map[string]acceleratorProvider, []Accelerator) {
+	providers := make(map[string]acceleratorProvider)
+
+	var accelDevs []Accelerator
+
+	// Iterate over providers in a deterministic order so that indices of
+	// devices are stable across restarts
+	for _, vendor := range slices.Sorted(maps.Keys(acceleratorProviders)) {
+		provider := acceleratorProviders[vendor](logger.With("vendor", vendor))
+
+		devs, err := provider.Devices()
+		if err != nil || len(devs) == 0 {
+			logger.Debug("No accelerator devices found", "vendor", vendor, "err", err)
+
+			continue
+		}
+
+		logger.Info("Accelerator devices found", "vendor", vendor, "num_devs", len(devs))
+
+		for _, dev := range devs {
+			dev.vendor = vendor
+			dev.busID = strings.ToLower(dev.busID)
+			dev.index = strconv.FormatInt(int64(len(accelDevs)), 10)
+			dev.devNodes = acceleratorDevNodes(dev.busID)
+
+			logger.Debug("Accelerator device", "dev", dev)
+
+			accelDevs = append(accelDevs, dev)
+		}
+
+		providers[vendor] = provider
+	}
+
+	return providers, accelDevs
+}
+
+// GetAcceleratorDevices returns all accelerator devices found on the host
+// using registered providers.
+func GetAcceleratorDevices(logger *slog.Logger) []Accelerator {
+	_, devs := newAcceleratorProviders(logger)
+
+	return devs
+}
+
+// acceleratorDevNodes returns the character device nodes under /dev that belong
+// to the PCI device identified by busID. It walks `/sys/dev/char` and selects
+// the devices whose sysfs path contains busID.
+func acceleratorDevNodes(busID string) []string {
+	charDevsDir := sysFilePath("dev/char")
+
+	entries, err := os.ReadDir(charDevsDir)
+	if err != nil {
+		return nil
+	}
+
+	var devNodes []string
+
+	for _, entry := range entries {
+		target, err := filepath.EvalSymlinks(filepath.Join(charDevsDir, entry.Name()))
+		if err != nil || !strings.Contains(target+"/", "/"+busID+"/") {
+			continue
+		}
+
+		uevent, err := os.ReadFile(filepath.Join(target, "uevent"))
+		if err != nil {
+			continue
+		}
+
+		for _, line := range strings.Split(string(uevent), "\n") {
+			if devName, ok := strings.CutPrefix(line, "DEVNAME="); ok {
+				devNodes = append(devNodes, filepath.Join("/dev", devName))
+			}
+		}
+	}
+
+	slices.Sort(devNodes)
+
+	return devNodes
+}
+
+// lookupAcceleratorCmd checks if cmd path provided by CLI exists and falls back
+// to cmd on host.
+func lookupAcceleratorCmd(cmdPath string, cmd string) (string, error) {
+	if cmdPath != "" {
+		if _, err := os.Stat(cmdPath); err != nil {
+			return "", err
+		}
+
+		return cmdPath, nil
+	}
+
+	if _, err := exec.LookPath(cmd); err != nil {
+		return "", err
+	}
+
+	return cmd, nil
+}
+
+// createTempReport returns path to a new temporary file that can be used by
+// vendor tools to write reports.
+func createTempReport(pattern string) (string, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+type acceleratorCollector struct {
+	logger          *slog.Logger
+	hostname        string
+	providers       map[string]acceleratorProvider
+	devs            map[string][]Accelerator
+	powerDesc       *prometheus.Desc
+	utilizationDesc *prometheus.Desc
+}
+
+func init() {
+	RegisterCollector(acceleratorCollectorSubsystem, defaultDisabled, NewAcceleratorCollector)
+}
+
+// NewAcceleratorCollector returns a new Collector exposing power and utilization
+// of non-GPU accelerators like FPGAs.
+func NewAcceleratorCollector(logger *slog.Logger) (Collector, error) {
+	providers, accelDevs := newAcceleratorProviders(logger)
+	if len(accelDevs) == 0 {
+		return nil, errors.New("no accelerator devices found")
+	}
+
+	// Group devices by vendor
+	devs := make(map[string][]Accelerator)
+	for _, dev := range accelDevs {
+		devs[dev.vendor] = append(devs[dev.vendor], dev)
+	}
+
+	labels := []string{"hostname", "vendor", "index", "accelid", "model"}
+
+	return &acceleratorCollector{
+		logger:    logger,
+		hostname:  hostname,
+		providers: providers,
+		devs:      devs,
+		powerDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acceleratorCollectorSubsystem, "power_watts"),
+			"Current power consumption of accelerator in watts",
+			labels, nil,
+		),
+		utilizationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acceleratorCollectorSubsystem, "utilization_ratio"),
+			"Current utilization of accelerator (0-1)",
+			labels, nil,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes accelerator metrics.
+func (c *acceleratorCollector) Update(ch chan<- prometheus.Metric) error {
+	for vendor, provider := range c.providers {
+		stats, err := provider.Stats(c.devs[vendor])
+		if err != nil {
+			c.logger.Error("Failed to fetch accelerator stats", "vendor", vendor, "err", err)
+
+			continue
+		}
+
+		for _, dev := range c.devs[vendor] {
+			s, ok := stats[dev.busID]
+			if !ok {
+				continue
+			}
+
+			if s.power != nil {
+				ch <- prometheus.MustNewConstMetric(
+					c.powerDesc, prometheus.GaugeValue, *s.power, c.hostname, dev.vendor, dev.index, dev.busID, dev.model,
+				)
+			}
+
+			if s.utilization != nil {
+				ch <- prometheus.MustNewConstMetric(
+					c.utilizationDesc, prometheus.GaugeValue, *s.utilization, c.hostname, dev.vendor, dev.index, dev.busID, dev.model,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Stop releases system resources used by the collector.
+func (c *acceleratorCollector) Stop(_ context.Context) error {
+	c.logger.Debug("Stopping", "collector", acceleratorCollectorSubsystem)
+
+	return nil
+}
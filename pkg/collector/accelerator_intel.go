@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/internal/osexec"
+)
+
+// Used for e2e tests.
+var fpgainfoPath = CEEMSExporterApp.Flag(
+	"collector.accelerator.fpgainfo-path",
+	"Absolute path to fpgainfo binary. Use only for testing.",
+).Hidden().Default("").String()
+
+// Regexes to parse fpgainfo output.
+var (
+	fpgainfoBDFRegex   = regexp.MustCompile(`^PCIe s:b:d\.f\s*:\s*([0-9a-fA-F:.]+)`)
+	fpgainfoModelRegex = regexp.MustCompile(`^Device Id\s*:\s*(\S+)`)
+	fpgainfoPowerRegex = regexp.MustCompile(`^\(\s*\d+\)\s*(?:Board Power|Total Input Power)\s*:\s*([0-9.]+)\s*Watts`)
+)
+
+type intelProvider struct {
+	logger *slog.Logger
+}
+
+func init() {
+	registerAcceleratorProvider("intel", newIntelProvider)
+}
+
+// newIntelProvider returns a new provider for Intel FPGAs based on `fpgainfo`
+// tool of OPAE.
+func newIntelProvider(logger *slog.Logger) acceleratorProvider {
+	return &intelProvider{logger: logger}
+}
+
+// Devices returns Intel devices found on the host.
+func (p *intelProvider) Devices() ([]Accelerator, error) {
+	devs, _, err := p.power()
+
+	return devs, err
+}
+
+// Stats returns power of Intel devices. fpgainfo does not report utilization.
+func (p *intelProvider) Stats(_ []Accelerator) (map[string]acceleratorStats, error) {
+	_, stats, err := p.power()
+
+	return stats, err
+}
+
+// power executes `fpgainfo power` and returns devices and their stats.
+func (p *intelProvider) power() ([]Accelerator, map[string]acceleratorStats, error) {
+	fpgainfoCmd, err := lookupAcceleratorCmd(*fpgainfoPath, "fpgainfo")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find fpgainfo command: %w", err)
+	}
+
+	out, err := osexec.Execute(fpgainfoCmd, []string{"power"}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	devs, stats := parseFpgainfoPowerOutput(string(out))
+
+	return devs, stats, nil
+}
+
+// parseFpgainfoPowerOutput parses output of `fpgainfo power`.
+// Example output:
+//
+//	//****** POWER ******//
+//	Object Id                        : 0xEF00000
+//	PCIe s:b:d.f                     : 0000:3B:00.0
+//	Device Id                        : 0x0B30
+//	...
+//	( 1) Board Power                 : 69.24 Watts
+//
+// Each device starts with a `POWER` header.
+func parseFpgainfoPowerOutput(out string) ([]Accelerator, map[string]acceleratorStats) {
+	var devs []Accelerator
+
+	stats := make(map[string]acceleratorStats)
+
+	var dev *Accelerator
+
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+
+		if matches := fpgainfoBDFRegex.FindStringSubmatch(line); len(matches) > 1 {
+			devs = append(devs, Accelerator{busID: strings.ToLower(matches[1])})
+			dev = &devs[len(devs)-1]
+
+			continue
+		}
+
+		if dev == nil {
+			continue
+		}
+
+		if matches := fpgainfoModelRegex.FindStringSubmatch(line); len(matches) > 1 {
+			dev.model = matches[1]
+
+			continue
+		}
+
+		if matches := fpgainfoPowerRegex.FindStringSubmatch(line); len(matches) > 1 {
+			if power, err := strconv.ParseFloat(matches[1], 64); err == nil {
+				stats[dev.busID] = acceleratorStats{power: &power}
+			}
+		}
+	}
+
+	return devs, stats
+}
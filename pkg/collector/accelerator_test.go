@@ -0,0 +1,143 @@
+package collector
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSysDevChar creates a mock `/sys/dev/char` with accelerator device nodes.
+func mockSysDevChar(t *testing.T) string {
+	t.Helper()
+
+	sysDir := t.TempDir()
+
+	devs := map[string][]string{
+		"226:128": {"devices/pci0000:3a/0000:3a:00.0/0000:3b:00.1/drm/renderD128", "dri/renderD128"},
+		"226:129": {"devices/pci0000:d7/0000:d7:00.0/0000:d8:00.1/drm/renderD129", "dri/renderD129"},
+		"241:0":   {"devices/pci0000:ae/0000:ae:00.0/0000:af:00.0/fpga_region/region0/dfl-port.0", "dfl-port.0"},
+		"4:0":     {"devices/virtual/tty/tty0", "tty0"},
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(sysDir, "dev/char"), 0o755))
+
+	for devNum, dev := range devs {
+		require.NoError(t, os.MkdirAll(filepath.Join(sysDir, dev[0]), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(sysDir, dev[0], "uevent"), []byte("DEVNAME="+dev[1]+"\n"), 0o600))
+		require.NoError(t, os.Symlink(filepath.Join("../..", dev[0]), filepath.Join(sysDir, "dev/char", devNum)))
+	}
+
+	return sysDir
+}
+
+func expectedAccelerators() []Accelerator {
+	return []Accelerator{
+		{
+			vendor:   "intel",
+			index:    "0",
+			model:    "0x0B30",
+			busID:    "0000:af:00.0",
+			devNodes: []string{"/dev/dfl-port.0"},
+		},
+		{
+			vendor:   "xilinx",
+			index:    "1",
+			model:    "xilinx_u250_gen3x16_xdma_shell_4_1",
+			busID:    "0000:3b:00.1",
+			devNodes: []string{"/dev/dri/renderD128"},
+		},
+		{
+			vendor:   "xilinx",
+			index:    "2",
+			model:    "xilinx_u55c_gen3x16_xdma_base_3",
+			busID:    "0000:d8:00.1",
+			devNodes: []string{"/dev/dri/renderD129"},
+		},
+	}
+}
+
+func TestGetAcceleratorDevices(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--path.sysfs", mockSysDevChar(t),
+			"--collector.accelerator.xbutil-path", "testdata/xbutil",
+			"--collector.accelerator.fpgainfo-path", "testdata/fpgainfo",
+		},
+	)
+	require.NoError(t, err)
+
+	devs := GetAcceleratorDevices(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Equal(t, expectedAccelerators(), devs)
+}
+
+func TestAcceleratorCollector(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--path.sysfs", mockSysDevChar(t),
+			"--collector.accelerator.xbutil-path", "testdata/xbutil",
+			"--collector.accelerator.fpgainfo-path", "testdata/fpgainfo",
+		},
+	)
+	require.NoError(t, err)
+
+	collector, err := NewAcceleratorCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	metrics := make(chan prometheus.Metric, 10)
+	require.NoError(t, collector.Update(metrics))
+	close(metrics)
+
+	// Power of all three devices and utilization of one Xilinx device
+	// that has compute units
+	var numMetrics int
+	for range metrics {
+		numMetrics++
+	}
+
+	assert.Equal(t, 4, numMetrics)
+}
+
+func TestAcceleratorCollectorNoDevices(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--collector.accelerator.xbutil-path", "testdata/nonexistent",
+			"--collector.accelerator.fpgainfo-path", "testdata/nonexistent",
+		},
+	)
+	require.NoError(t, err)
+
+	_, err = NewAcceleratorCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.Error(t, err)
+}
+
+func TestParseXbutilDeviceReport(t *testing.T) {
+	report := `{"devices": [{"device_id": "0000:3B:00.1", "electrical": {"power_consumption_watts": "27.5"},
+"dynamic_regions": [{"compute_units": [{"name": "a", "status": {"bit_mask": "0x1"}}, {"name": "b", "status": {"bit_mask": "0x4"}}]}]}]}`
+
+	stats, err := parseXbutilDeviceReport([]byte(report))
+	require.NoError(t, err)
+	require.Contains(t, stats, "0000:3b:00.1")
+	assert.InEpsilon(t, 27.5, *stats["0000:3b:00.1"].power, 0)
+	assert.InEpsilon(t, 0.5, *stats["0000:3b:00.1"].utilization, 0)
+
+	// Malformed report
+	_, err = parseXbutilDeviceReport([]byte("foo"))
+	require.Error(t, err)
+}
+
+func TestParseFpgainfoPowerOutput(t *testing.T) {
+	out, err := os.ReadFile("testdata/fpgainfo")
+	require.NoError(t, err)
+
+	devs, stats := parseFpgainfoPowerOutput(string(out))
+	assert.Equal(t, []Accelerator{{model: "0x0B30", busID: "0000:af:00.0"}}, devs)
+	require.Contains(t, stats, "0000:af:00.0")
+	assert.InEpsilon(t, 69.24, *stats["0000:af:00.0"].power, 0)
+	assert.Nil(t, stats["0000:af:00.0"].utilization)
+}
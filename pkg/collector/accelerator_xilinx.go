@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mahendrapaipuri/ceems/internal/osexec"
+)
+
+// Used for e2e tests.
+var xbutilPath = CEEMSExporterApp.Flag(
+	"collector.accelerator.xbutil-path",
+	"Absolute path to xbutil binary. Use only for testing.",
+).Hidden().Default("").String()
+
+// XRT compute unit status bit that indicates the CU is idle.
+// Ref: https://xilinx.github.io/XRT/master/html/xrt_kernel_executions.html
+const xrtCUStatusIdle = 0x4
+
+// xbutilHostReport is the JSON report of `xbutil examine`.
+type xbutilHostReport struct {
+	System struct {
+		Host struct {
+			Devices []struct {
+				BDF     string `json:"bdf"`
+				VBNV    string `json:"vbnv"`
+				IsReady string `json:"is_ready"`
+			} `json:"devices"`
+		} `json:"host"`
+	} `json:"system"`
+}
+
+// xbutilDeviceReport is the JSON report of
+// `xbutil examine --report electrical dynamic-regions`.
+type xbutilDeviceReport struct {
+	Devices []struct {
+		DeviceID   string `json:"device_id"`
+		Electrical struct {
+			PowerConsumptionWatts string `json:"power_consumption_watts"`
+		} `json:"electrical"`
+		DynamicRegions []struct {
+			ComputeUnits []struct {
+				Name   string `json:"name"`
+				Status struct {
+					BitMask string `json:"bit_mask"`
+				} `json:"status"`
+			} `json:"compute_units"`
+		} `json:"dynamic_regions"`
+	} `json:"devices"`
+}
+
+type xilinxProvider struct {
+	logger *slog.Logger
+}
+
+func init() {
+	registerAcceleratorProvider("xilinx", newXilinxProvider)
+}
+
+// newXilinxProvider returns a new provider for Xilinx (AMD) FPGAs based on
+// `xbutil` tool of XRT.
+func newXilinxProvider(logger *slog.Logger) acceleratorProvider {
+	return &xilinxProvider{logger: logger}
+}
+
+// Devices returns Xilinx devices found on the host.
+func (p *xilinxProvider) Devices() ([]Accelerator, error) {
+	out, err := p.examine(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseXbutilHostReport(out)
+}
+
+// Stats returns power and utilization of Xilinx devices. Utilization is
+// estimated as the fraction of compute units that are not idle.
+func (p *xilinxProvider) Stats(devs []Accelerator) (map[string]acceleratorStats, error) {
+	stats := make(map[string]acceleratorStats)
+
+	// xbutil reports device level stats only for one device at a time
+	for _, dev := range devs {
+		out, err := p.examine([]string{"--device", dev.busID, "--report", "electrical", "dynamic-regions"})
+		if err != nil {
+			p.logger.Debug("Failed to examine device", "bdf", dev.busID, "err", err)
+
+			continue
+		}
+
+		devStats, err := parseXbutilDeviceReport(out)
+		if err != nil {
+			p.logger.Debug("Failed to parse device report", "bdf", dev.busID, "err", err)
+
+			continue
+		}
+
+		for busID, s := range devStats {
+			stats[busID] = s
+		}
+	}
+
+	return stats, nil
+}
+
+// examine executes `xbutil examine` with args and returns the JSON report.
+// xbutil can only write JSON reports to files and hence, we use a temporary
+// file for each call.
+func (p *xilinxProvider) examine(args []string) ([]byte, error) {
+	xbutilCmd, err := lookupAcceleratorCmd(*xbutilPath, "xbutil")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find xbutil command: %w", err)
+	}
+
+	reportPath, err := createTempReport("ceems-xbutil-*.json")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(reportPath)
+
+	args = append([]string{"examine"}, args...)
+	args = append(args, "--format", "JSON", "--output", reportPath, "--force")
+
+	if _, err := osexec.Execute(xbutilCmd, args, nil); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(reportPath)
+}
+
+// parseXbutilHostReport returns devices from host report of xbutil.
+func parseXbutilHostReport(out []byte) ([]Accelerator, error) {
+	var report xbutilHostReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+
+	var devs []Accelerator
+
+	for _, dev := range report.System.Host.Devices {
+		if dev.BDF == "" {
+			continue
+		}
+
+		devs = append(devs, Accelerator{model: dev.VBNV, busID: strings.ToLower(dev.BDF)})
+	}
+
+	return devs, nil
+}
+
+// parseXbutilDeviceReport returns stats of devices from device report of xbutil.
+func parseXbutilDeviceReport(out []byte) (map[string]acceleratorStats, error) {
+	var report xbutilDeviceReport
+	if err := json.Unmarshal(out, &report); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]acceleratorStats)
+
+	for _, dev := range report.Devices {
+		var s acceleratorStats
+
+		if power, err := strconv.ParseFloat(dev.Electrical.PowerConsumptionWatts, 64); err == nil {
+			s.power = &power
+		}
+
+		var numCUs, numBusyCUs float64
+
+		for _, region := range dev.DynamicRegions {
+			for _, cu := range region.ComputeUnits {
+				mask, err := strconv.ParseUint(strings.TrimPrefix(cu.Status.BitMask, "0x"), 16, 64)
+				if err != nil {
+					continue
+				}
+
+				numCUs++
+
+				if mask&xrtCUStatusIdle == 0 {
+					numBusyCUs++
+				}
+			}
+		}
+
+		if numCUs > 0 {
+			util := numBusyCUs / numCUs
+			s.utilization = &util
+		}
+
+		stats[strings.ToLower(dev.DeviceID)] = s
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,69 @@
+//go:build !nobaseline
+// +build !nobaseline
+
+package collector
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const baselineCollectorSubsystem = "baseline"
+
+var errNoBaseline = errors.New("idle power baseline must be positive")
+
+// CLI opts.
+var baselineIdlePower = CEEMSExporterApp.Flag(
+	"collector.baseline.idle-power-watts",
+	"Static idle power baseline of the node in Watts.",
+).Default("0").Float64()
+
+type baselineCollector struct {
+	logger    *slog.Logger
+	hostname  string
+	idlePower float64
+	idleDesc  *prometheus.Desc
+}
+
+func init() {
+	RegisterCollector(baselineCollectorSubsystem, defaultDisabled, NewBaselineCollector)
+}
+
+// NewBaselineCollector returns a new Collector exposing the static idle power
+// baseline of the node. Recording rules can subtract it from the node power
+// before splitting it among compute units.
+func NewBaselineCollector(logger *slog.Logger) (Collector, error) {
+	if *baselineIdlePower <= 0 {
+		logger.Error("Invalid idle power baseline", "watts", *baselineIdlePower)
+
+		return nil, errNoBaseline
+	}
+
+	return &baselineCollector{
+		logger:    logger,
+		hostname:  hostname,
+		idlePower: *baselineIdlePower,
+		idleDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, baselineCollectorSubsystem, "idle_power_watts"),
+			"Static idle power baseline of the node in Watts",
+			[]string{"hostname"}, nil,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes idle power baseline.
+func (c *baselineCollector) Update(ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(c.idleDesc, prometheus.GaugeValue, c.idlePower, c.hostname)
+
+	return nil
+}
+
+// Stop releases system resources used by the collector.
+func (c *baselineCollector) Stop(_ context.Context) error {
+	c.logger.Debug("Stopping", "collector", baselineCollectorSubsystem)
+
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build !nobaseline
+// +build !nobaseline
+
+package collector
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineCollector(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--collector.baseline.idle-power-watts", "85.5",
+		},
+	)
+	require.NoError(t, err)
+
+	collector, err := NewBaselineCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	metrics := make(chan prometheus.Metric, 1)
+
+	err = collector.Update(metrics)
+	require.NoError(t, err)
+
+	m := &dto.Metric{}
+	require.NoError(t, (<-metrics).Write(m))
+	assert.InDelta(t, 85.5, m.GetGauge().GetValue(), 0)
+
+	err = collector.Stop(context.Background())
+	require.NoError(t, err)
+}
+
+func TestBaselineCollectorInvalid(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse([]string{})
+	require.NoError(t, err)
+
+	_, err = NewBaselineCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.ErrorIs(t, err, errNoBaseline)
+}
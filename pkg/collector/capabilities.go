@@ -0,0 +1,108 @@
+package collector
+
+import (
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+// capabilities contains the runtime capabilities detected on the host.
+type capabilities struct {
+	CgroupMode string   `yaml:"cgroup_mode"`
+	BPF        bool     `yaml:"bpf"`
+	GPUDrivers []string `yaml:"gpu_drivers"`
+	IPMIDevice string   `yaml:"ipmi_device"`
+	// Reachability of BMC keyed by the interface used to reach it
+	BMCReachable map[string]bool `yaml:"bmc_reachable"`
+}
+
+// bmcProbes contains functions that check if BMC of the host is reachable keyed
+// by the interface used to reach it. They are registered by the collectors that
+// talk to BMC. A probe returns false for ok when the interface is not configured
+// on the host.
+var bmcProbes = make(map[string]func(logger *slog.Logger) (reachable bool, ok bool))
+
+// effectiveConfig contains the resolved CLI flags, enabled collectors and
+// detected capabilities of the exporter.
+type effectiveConfig struct {
+	Flags        map[string]string `yaml:"flags"`
+	Collectors   []string          `yaml:"collectors"`
+	Capabilities capabilities      `yaml:"capabilities"`
+}
+
+// newEffectiveConfig returns the resolved config of the app and the capabilities
+// detected on the current host.
+func newEffectiveConfig(app *kingpin.Application, logger *slog.Logger) effectiveConfig {
+	// Get values of all flags including defaults
+	flags := make(map[string]string)
+
+	for _, f := range app.Model().Flags {
+		if f.Hidden || f.Name == "help" || f.Name == "version" {
+			continue
+		}
+
+		flags[f.Name] = f.Value.String()
+	}
+
+	// Get enabled collectors
+	var collectors []string
+
+	for name, enabled := range collectorState {
+		if *enabled {
+			collectors = append(collectors, name)
+		}
+	}
+
+	slices.Sort(collectors)
+
+	return effectiveConfig{
+		Flags:        flags,
+		Collectors:   collectors,
+		Capabilities: detectCapabilities(logger),
+	}
+}
+
+// detectCapabilities detects cgroup mode, BPF support, GPU drivers, IPMI
+// device and BMC reachability on the host.
+func detectCapabilities(logger *slog.Logger) capabilities {
+	var caps capabilities
+
+	// cgroups mode
+	caps.CgroupMode = cgroupModeName(cgroupMode(*cgroupfsPath))
+
+	// eBPF collector needs BTF enabled kernel
+	if _, err := os.Stat(sysFilePath("kernel/btf/vmlinux")); err == nil {
+		caps.BPF = true
+	}
+
+	// GPU drivers
+	if _, err := os.Stat(procFilePath("driver/nvidia/version")); err == nil {
+		caps.GPUDrivers = append(caps.GPUDrivers, "nvidia")
+	}
+
+	if _, err := os.Stat(sysFilePath("module/amdgpu")); err == nil {
+		caps.GPUDrivers = append(caps.GPUDrivers, "amd")
+	}
+
+	// IPMI device using OpenIPMI driver
+	for _, dev := range []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"} {
+		if _, err := os.Stat(dev); err == nil {
+			caps.IPMIDevice = dev
+
+			break
+		}
+	}
+
+	// BMC reachability
+	caps.BMCReachable = make(map[string]bool)
+
+	for name, probe := range bmcProbes {
+		if reachable, ok := probe(logger); ok {
+			caps.BMCReachable[name] = reachable
+		}
+	}
+
+	return caps
+}
@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEffectiveConfig(t *testing.T) {
+	app := kingpin.New("test", "test app")
+	app.Flag("web.listen-address", "").Default(":9010").String()
+	app.Flag("hidden.flag", "").Hidden().Default("foo").String()
+
+	_, err := app.Parse([]string{})
+	require.NoError(t, err)
+
+	config := newEffectiveConfig(app, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	assert.Equal(t, map[string]string{"web.listen-address": ":9010"}, config.Flags)
+	assert.NotEmpty(t, config.Capabilities.CgroupMode)
+	assert.NotNil(t, config.Capabilities.BMCReachable)
+}
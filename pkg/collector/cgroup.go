@@ -22,20 +22,23 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/procfs"
 	"github.com/prometheus/procfs/blockdevice"
+	"golang.org/x/sys/unix"
 )
 
 const (
 	// Max cgroup subsystems count that is used from BPF side
 	// to define a max index for the default controllers on tasks.
 	// For further documentation check BPF part.
-	cgroupSubSysCount = 15
-	genericSubsystem  = "compute"
+	cgroupSubSysCount        = 15
+	genericSubsystem         = "compute"
+	cgroupCollectorSubsystem = "cgroup"
 )
 
 // Resource Managers.
 const (
-	slurm   = "slurm"
-	libvirt = "libvirt"
+	slurm     = "slurm"
+	libvirt   = "libvirt"
+	userslice = "userslice"
 )
 
 // Block IO Op names.
@@ -71,6 +74,18 @@ var (
 	libvirtCgroupPathRegex = regexp.MustCompile("^.*/(?:.+?)-qemu-(?:[0-9]+)-(instance-[0-9a-f]+)(?:.*$)")
 )
 
+// Regular expressions of cgroup paths for user slices on login nodes.
+/*
+	For v1 possibilities are /cpuacct/user.slice/user-1000.slice
+							 /memory/user.slice/user-1000.slice/session-1.scope
+
+	For v2 possibilities are /user.slice/user-1000.slice
+							 /user.slice/user-1000.slice/user@1000.service
+*/
+var (
+	userSliceCgroupPathRegex = regexp.MustCompile(`^.*/user\.slice/user-([0-9]+)\.slice(?:.*$)`)
+)
+
 // CLI options.
 var (
 	activeController = CEEMSExporterApp.Flag(
@@ -81,7 +96,7 @@ var (
 	// Hidden opts for e2e and unit tests.
 	forceCgroupsVersion = CEEMSExporterApp.Flag(
 		"collector.cgroups.force-version",
-		"Set cgroups version manually. Used only for testing.",
+		"Set cgroups version manually. Ignored when it does not match with mounted cgroups hierarchy. Used only for testing.",
 	).Hidden().Enum("v1", "v2")
 )
 
@@ -118,6 +133,7 @@ type cgroupManager struct {
 	logger           *slog.Logger
 	fs               procfs.FS
 	mode             cgroups.CGMode    // cgroups mode: unified, legacy, hybrid
+	bootID           string            // Boot ID of the boot in which cgroups mode is detected
 	root             string            // cgroups root
 	slice            string            // Slice under which cgroups are managed eg system.slice, machine.slice
 	scope            string            // Scope under which cgroups are managed eg slurmstepd.scope, machine-qemu\x2d1\x2dvm1.scope
@@ -132,8 +148,8 @@ type cgroupManager struct {
 // String implements stringer interface of the struct.
 func (c *cgroupManager) String() string {
 	return fmt.Sprintf(
-		"mode: %d root: %s slice: %s scope: %s mount: %s manager: %s",
-		c.mode,
+		"mode: %s root: %s slice: %s scope: %s mount: %s manager: %s",
+		cgroupModeName(c.mode),
 		c.root,
 		c.slice,
 		c.scope,
@@ -157,7 +173,7 @@ func (c *cgroupManager) setMountPoint() {
 			// For cgroups v1 we need to shift root to /sys/fs/cgroup/cpuacct
 			c.root = filepath.Join(c.root, c.activeController)
 		}
-	case libvirt:
+	case libvirt, userslice:
 		switch c.mode { //nolint:exhaustive
 		case cgroups.Unified:
 			// /sys/fs/cgroup/machine.slice
@@ -275,6 +291,113 @@ func (c *cgroupManager) discover() ([]cgroup, error) {
 	return cgroups, nil
 }
 
+// cgroupMode returns the cgroups mode of the cgroupfs mounted at root. Unlike
+// cgroups.Mode(), it works when the host's cgroupfs is mounted at a non-standard
+// path, for instance, when exporter is running inside a container. If root is
+// not a cgroupfs mount, mode of /sys/fs/cgroup is returned.
+func cgroupMode(root string) cgroups.CGMode {
+	var st unix.Statfs_t
+	if err := unix.Statfs(root, &st); err != nil {
+		return cgroups.Unavailable
+	}
+
+	switch st.Type {
+	case unix.CGROUP2_SUPER_MAGIC:
+		return cgroups.Unified
+	case unix.TMPFS_MAGIC:
+		// cgroups v1 and hybrid hierarchies are mounted on a tmpfs
+		if err := unix.Statfs(filepath.Join(root, "unified"), &st); err == nil && st.Type == unix.CGROUP2_SUPER_MAGIC {
+			return cgroups.Hybrid
+		}
+
+		return cgroups.Legacy
+	default:
+		return cgroups.Mode()
+	}
+}
+
+// cgroupModeName returns the name of cgroups mode.
+func cgroupModeName(mode cgroups.CGMode) string {
+	switch mode {
+	case cgroups.Unified:
+		return "unified"
+	case cgroups.Legacy:
+		return "legacy"
+	case cgroups.Hybrid:
+		return "hybrid"
+	default:
+		return "unavailable"
+	}
+}
+
+// isCgroupfsMount returns true if root is a mount point of cgroups v2 hierarchy
+// or if active controller of cgroups v1 is mounted under root.
+func isCgroupfsMount(root string) bool {
+	var st unix.Statfs_t
+	if err := unix.Statfs(root, &st); err == nil && st.Type == unix.CGROUP2_SUPER_MAGIC {
+		return true
+	}
+
+	if err := unix.Statfs(filepath.Join(root, *activeController), &st); err == nil && st.Type == unix.CGROUP_SUPER_MAGIC {
+		return true
+	}
+
+	return false
+}
+
+// detectCgroupMode returns the cgroups mode of the current boot. Mode is always
+// detected from the hierarchy mounted at cgroupfs path so that nodes that are
+// migrated from cgroups v1 to v2 during OS upgrades keep working without any
+// changes to exporter's config. Forced cgroups version is honoured only when
+// cgroupfs path is not a real cgroupfs mount, which is the case in tests.
+func detectCgroupMode(logger *slog.Logger) cgroups.CGMode {
+	mode := cgroupMode(*cgroupfsPath)
+
+	if *forceCgroupsVersion != "" {
+		forcedMode := cgroups.Unified
+		if *forceCgroupsVersion == "v1" {
+			forcedMode = cgroups.Legacy
+		}
+
+		if !isCgroupfsMount(*cgroupfsPath) {
+			return forcedMode
+		}
+
+		if forcedMode != mode && (forcedMode == cgroups.Unified || mode == cgroups.Unified) {
+			logger.Warn(
+				"Ignoring forced cgroups version as it does not match with mounted cgroups hierarchy",
+				"forced", *forceCgroupsVersion, "detected", cgroupModeName(mode),
+			)
+		}
+	}
+
+	switch mode {
+	case cgroups.Unavailable:
+		logger.Error("No cgroups hierarchy found", "path", *cgroupfsPath)
+	case cgroups.Legacy, cgroups.Hybrid:
+		// In hybrid mode, only systemd uses unified hierarchy mounted at <cgroupfs>/unified
+		// without any controllers. Resource accounting is done by controllers in
+		// v1 hierarchy and so they are treated same as legacy mode.
+		if _, err := os.Stat(filepath.Join(*cgroupfsPath, *activeController)); err != nil {
+			logger.Error(
+				"Active cgroup subsystem not found in cgroups v1 hierarchy",
+				"mode", cgroupModeName(mode), "subsystem", *activeController, "err", err,
+			)
+		}
+	}
+
+	return mode
+}
+
+// bootID returns the boot ID of current boot.
+func bootID() string {
+	if id, err := os.ReadFile(procFilePath("sys/kernel/random/boot_id")); err == nil {
+		return strings.TrimSpace(string(id))
+	}
+
+	return ""
+}
+
 // NewCgroupManager returns an instance of cgroupManager based on resource manager.
 func NewCgroupManager(name string, logger *slog.Logger) (*cgroupManager, error) {
 	// Instantiate a new Proc FS
@@ -285,35 +408,25 @@ func NewCgroupManager(name string, logger *slog.Logger) (*cgroupManager, error)
 		return nil, err
 	}
 
-	var manager *cgroupManager
+	// Detect cgroups mode of current boot
+	mode := detectCgroupMode(logger)
+
+	manager := &cgroupManager{
+		logger: logger,
+		fs:     fs,
+		mode:   mode,
+		bootID: bootID(),
+		root:   *cgroupfsPath,
+	}
 
 	switch name {
 	case slurm:
-		if (*forceCgroupsVersion == "" && cgroups.Mode() == cgroups.Unified) || *forceCgroupsVersion == "v2" {
-			manager = &cgroupManager{
-				logger: logger,
-				fs:     fs,
-				mode:   cgroups.Unified,
-				root:   *cgroupfsPath,
-				slice:  "system.slice",
-				scope:  "slurmstepd.scope",
-			}
+		if mode == cgroups.Unified {
+			manager.slice = "system.slice"
+			manager.scope = "slurmstepd.scope"
 		} else {
-			var mode cgroups.CGMode
-			if *forceCgroupsVersion == "v1" {
-				mode = cgroups.Legacy
-			} else {
-				mode = cgroups.Mode()
-			}
-
-			manager = &cgroupManager{
-				logger:           logger,
-				fs:               fs,
-				mode:             mode,
-				root:             *cgroupfsPath,
-				activeController: *activeController,
-				slice:            slurm,
-			}
+			manager.activeController = *activeController
+			manager.slice = slurm
 		}
 
 		// Add manager field
@@ -330,36 +443,10 @@ func NewCgroupManager(name string, logger *slog.Logger) (*cgroupManager, error)
 			return slurmIgnoreProcsRegex.MatchString(p)
 		}
 
-		// Set mountpoint
-		manager.setMountPoint()
-
-		return manager, nil
-
 	case libvirt:
-		if (*forceCgroupsVersion == "" && cgroups.Mode() == cgroups.Unified) || *forceCgroupsVersion == "v2" {
-			manager = &cgroupManager{
-				logger: logger,
-				fs:     fs,
-				mode:   cgroups.Unified,
-				root:   *cgroupfsPath,
-				slice:  "machine.slice",
-			}
-		} else {
-			var mode cgroups.CGMode
-			if *forceCgroupsVersion == "v1" {
-				mode = cgroups.Legacy
-			} else {
-				mode = cgroups.Mode()
-			}
-
-			manager = &cgroupManager{
-				logger:           logger,
-				fs:               fs,
-				mode:             mode,
-				root:             *cgroupfsPath,
-				activeController: *activeController,
-				slice:            "machine.slice",
-			}
+		manager.slice = "machine.slice"
+		if mode != cgroups.Unified {
+			manager.activeController = *activeController
 		}
 
 		// Add manager field
@@ -378,14 +465,35 @@ func NewCgroupManager(name string, logger *slog.Logger) (*cgroupManager, error)
 			return false
 		}
 
-		// Set mountpoint
-		manager.setMountPoint()
+	case userslice:
+		manager.slice = "user.slice"
+		if mode != cgroups.Unified {
+			manager.activeController = *activeController
+		}
 
-		return manager, nil
+		// Add manager field
+		manager.manager = userslice
+
+		// Add path regex
+		manager.idRegex = userSliceCgroupPathRegex
+
+		// Identify child cgroup
+		// User sessions and user manager are children of user slice
+		manager.isChild = func(p string) bool {
+			return strings.Contains(p, "/session-") || strings.Contains(p, "/user@")
+		}
+		manager.ignoreProc = func(p string) bool {
+			return false
+		}
 
 	default:
 		return nil, errors.New("unknown resource manager")
 	}
+
+	// Set mountpoint
+	manager.setMountPoint()
+
+	return manager, nil
 }
 
 // cgMetric contains metrics returned by cgroup.
@@ -424,7 +532,9 @@ type cgroupCollector struct {
 	hostname          string
 	hostMemInfo       map[string]float64
 	blockDevices      map[string]string
+	cgroupMode        *prometheus.Desc
 	numCgs            *prometheus.Desc
+	numActiveCgs      *prometheus.Desc
 	cgCPUUser         *prometheus.Desc
 	cgCPUSystem       *prometheus.Desc
 	cgCPUs            *prometheus.Desc
@@ -493,12 +603,24 @@ func NewCgroupCollector(logger *slog.Logger, cgManager *cgroupManager, opts cgro
 		hostMemInfo:   hostMemInfo,
 		hostname:      hostname,
 		blockDevices:  blockDevices,
+		cgroupMode: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, genericSubsystem, "cgroup_mode_info"),
+			"Detected cgroups mode (unified, legacy or hybrid) of the current boot",
+			[]string{"manager", "hostname", "mode", "boot_id"},
+			nil,
+		),
 		numCgs: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, genericSubsystem, "units"),
 			"Total number of jobs",
 			[]string{"manager", "hostname"},
 			nil,
 		),
+		numActiveCgs: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, genericSubsystem, "unit_active_cgroups"),
+			"Total number of active cgroups of jobs including their child cgroups",
+			[]string{"manager", "hostname"},
+			nil,
+		),
 		cgCPUUser: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, genericSubsystem, "unit_cpu_user_seconds_total"),
 			"Total job CPU user seconds",
@@ -633,6 +755,12 @@ func (c *cgroupCollector) Update(ch chan<- prometheus.Metric, metrics []cgMetric
 	// Fetch metrics
 	metrics = c.doUpdate(metrics)
 
+	// Send detected cgroups mode
+	ch <- prometheus.MustNewConstMetric(
+		c.cgroupMode, prometheus.GaugeValue, 1,
+		c.cgroupManager.manager, c.hostname, cgroupModeName(c.cgroupManager.mode), c.cgroupManager.bootID,
+	)
+
 	// First send num jobs on the current host
 	ch <- prometheus.MustNewConstMetric(c.numCgs, prometheus.GaugeValue, float64(len(metrics)), c.cgroupManager.manager, c.hostname)
 
@@ -705,6 +833,18 @@ func (c *cgroupCollector) Update(ch chan<- prometheus.Metric, metrics []cgMetric
 	return nil
 }
 
+// updateActiveCgroups sends the total number of active cgroups including the child
+// cgroups. Cost of collectors like ebpf and perf scales with number of cgroups rather
+// than number of jobs.
+func (c *cgroupCollector) updateActiveCgroups(ch chan<- prometheus.Metric, cgroups []cgroup) {
+	var numCgroups int
+	for _, cgrp := range cgroups {
+		numCgroups += 1 + len(cgrp.children)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.numActiveCgs, prometheus.GaugeValue, float64(numCgroups), c.cgroupManager.manager, c.hostname)
+}
+
 // Stop releases any system resources held by collector.
 func (c *cgroupCollector) Stop(_ context.Context) error {
 	return nil
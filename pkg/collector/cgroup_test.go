@@ -7,10 +7,12 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"path/filepath"
 	"testing"
 
 	"github.com/containerd/cgroups/v3"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -240,6 +242,42 @@ func TestNewCgroupManagerV1(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCgroupMode(t *testing.T) {
+	// Non existent path
+	assert.Equal(t, cgroups.Unavailable, cgroupMode(filepath.Join(t.TempDir(), "non-existent")))
+
+	// Path that is not a cgroupfs mount must return mode of host
+	assert.Equal(t, cgroups.Mode(), cgroupMode("testdata"))
+}
+
+func TestDetectCgroupMode(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--path.procfs", "testdata/proc",
+			"--path.cgroupfs", "testdata/sys/fs/cgroup",
+			"--collector.cgroups.force-version", "v1",
+		},
+	)
+	require.NoError(t, err)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Forced version must be honoured when cgroupfs path is not a real mount
+	assert.False(t, isCgroupfsMount(*cgroupfsPath))
+	assert.Equal(t, cgroups.Legacy, detectCgroupMode(logger))
+
+	// Detected mode and boot ID must be set on manager
+	manager, err := NewCgroupManager("slurm", logger)
+	require.NoError(t, err)
+	assert.Equal(t, cgroups.Legacy, manager.mode)
+	assert.Equal(t, "5d2cd1a6-0f64-4c4e-9c6b-8f2a4b1d7e31", manager.bootID)
+
+	// Mode names
+	assert.Equal(t, "unified", cgroupModeName(cgroups.Unified))
+	assert.Equal(t, "hybrid", cgroupModeName(cgroups.Hybrid))
+	assert.Equal(t, "unavailable", cgroupModeName(cgroups.Unavailable))
+}
+
 func TestParseCgroupSubSysIds(t *testing.T) {
 	_, err := CEEMSExporterApp.Parse(
 		[]string{
@@ -334,3 +372,23 @@ func TestParseCgroupSubSysIds(t *testing.T) {
 
 	assert.ElementsMatch(t, expectedControllers, controllers)
 }
+
+func TestCgroupCollectorActiveCgroups(t *testing.T) {
+	c := cgroupCollector{
+		cgroupManager: &cgroupManager{manager: slurm},
+		hostname:      "host",
+		numActiveCgs:  prometheus.NewDesc("active_cgroups", "", []string{"manager", "hostname"}, nil),
+	}
+
+	cgrps := []cgroup{
+		{id: "1", children: []cgroupPath{{rel: "1/step_0"}, {rel: "1/step_1"}}},
+		{id: "2"},
+	}
+
+	ch := make(chan prometheus.Metric, 1)
+	c.updateActiveCgroups(ch, cgrps)
+
+	m := &dto.Metric{}
+	require.NoError(t, (<-ch).Write(m))
+	assert.InDelta(t, 4, m.GetGauge().GetValue(), 0)
+}
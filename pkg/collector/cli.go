@@ -12,12 +12,14 @@ import (
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/mahendrapaipuri/ceems/internal/common"
 	internal_runtime "github.com/mahendrapaipuri/ceems/internal/runtime"
 	"github.com/mahendrapaipuri/ceems/internal/security"
 	"github.com/prometheus/common/promslog"
 	"github.com/prometheus/common/promslog/flag"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/exporter-toolkit/web"
+	"gopkg.in/yaml.v3"
 )
 
 // CEEMSExporter represents the `ceems_exporter` cli.
@@ -92,6 +94,19 @@ func (b *CEEMSExporter) Main() error {
 			"web.debug-server",
 			"Enable debug server (default: disabled).",
 		).Default("false").Bool()
+		enableUnitsAPI = b.App.Flag(
+			"web.units-api",
+			"Enable JSON API at /api/v1/units listing current compute units and their metrics. Accessible only from localhost (default: disabled).",
+		).Default("false").Bool()
+
+		printConfig = b.App.Flag(
+			"print-config",
+			"Print effective configuration and detected capabilities in YAML and exit.",
+		).Default("false").Bool()
+		helpConfig = b.App.Flag(
+			"help-config",
+			"Print all available flags and configuration file options with their types and defaults in YAML and exit.",
+		).Default("false").Bool()
 
 		// test CLI flags hidden
 		dropPrivs = b.App.Flag(
@@ -109,13 +124,21 @@ func (b *CEEMSExporter) Main() error {
 		).Bool()
 	}
 
+	// Exporter is run when no command is given
+	b.App.Command("run", "Run exporter (default).").Default()
+
+	debugCmd := b.App.Command("debug", "Troubleshooting utilities.")
+	debugBPFCmd := debugCmd.Command(
+		"bpf", "Check kernel version, BTF, program and map types and cgroups layout needed by eBPF collectors on the current node.",
+	)
+
 	promslogConfig := &promslog.Config{}
 	flag.AddFlags(&b.App, promslogConfig)
 	b.App.Version(version.Print(b.appName))
 	b.App.UsageWriter(os.Stdout)
 	b.App.HelpFlag.Short('h')
 
-	_, err := b.App.Parse(os.Args[1:])
+	command, err := b.App.Parse(os.Args[1:])
 	if err != nil {
 		return fmt.Errorf("failed to parse CLI flags: %w", err)
 	}
@@ -136,6 +159,33 @@ func (b *CEEMSExporter) Main() error {
 		DisableDefaultCollectors()
 	}
 
+	// Print skeleton of all available options and exit
+	if *helpConfig {
+		redfish, err := common.ConfigSkeleton("Configuration file of Redfish collector", redfishConfig{})
+		if err != nil {
+			return fmt.Errorf("failed to generate config skeleton: %w", err)
+		}
+
+		return common.WriteSkeletons(os.Stdout, common.FlagsSkeleton(&b.App), redfish)
+	}
+
+	// Print matrix of eBPF support checks and exit
+	if command == debugBPFCmd.FullCommand() {
+		return writeBPFChecks(os.Stdout, bpfChecks())
+	}
+
+	// Print effective config and exit
+	if *printConfig {
+		out, err := yaml.Marshal(newEffectiveConfig(&b.App, logger))
+		if err != nil {
+			return fmt.Errorf("failed to marshal config: %w", err)
+		}
+
+		fmt.Fprint(os.Stdout, string(out))
+
+		return nil
+	}
+
 	logger.Info("Starting "+b.appName, "version", version.Info())
 	logger.Info(
 		"Operational information", "build_context", version.BuildContext(),
@@ -207,6 +257,7 @@ func (b *CEEMSExporter) Main() error {
 			MaxRequests:            *maxRequests,
 			IncludeExporterMetrics: !*disableExporterMetrics,
 			EnableDebugServer:      *enableDebugServer,
+			EnableUnitsAPI:         *enableUnitsAPI,
 			LandingConfig: &web.LandingConfig{
 				Name:        b.App.Name,
 				Description: b.App.Help,
@@ -33,6 +33,18 @@ var (
 		[]string{"collector"},
 		nil,
 	)
+	subCollectorDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "scrape", "subcollector_duration_seconds"),
+		CEEMSExporterAppName+": Duration of a sub collector scrape of resource manager collector.",
+		[]string{"collector", "subcollector"},
+		nil,
+	)
+	subCollectorSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "scrape", "subcollector_success"),
+		CEEMSExporterAppName+": Whether a sub collector of resource manager collector succeeded.",
+		[]string{"collector", "subcollector"},
+		nil,
+	)
 )
 
 const (
@@ -184,6 +196,8 @@ func NewCEEMSCollector(logger *slog.Logger) (*CEEMSCollector, error) {
 func (n CEEMSCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- scrapeDurationDesc
 	ch <- scrapeSuccessDesc
+	ch <- subCollectorDurationDesc
+	ch <- subCollectorSuccessDesc
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -238,3 +252,23 @@ func execute(name string, c Collector, ch chan<- prometheus.Metric, logger *slog
 	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
 	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
 }
+
+// executeSub runs update of a sub collector, like cgroup, perf or ebpf, of a resource
+// manager collector and exports its duration and status. Resource manager collectors
+// update their sub collectors concurrently and hence, duration of resource manager
+// collector does not reveal the slow sub collector.
+func executeSub(name string, subName string, ch chan<- prometheus.Metric, update func() error) error {
+	begin := time.Now()
+	err := update()
+	duration := time.Since(begin)
+
+	var success float64
+	if err == nil {
+		success = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(subCollectorDurationDesc, prometheus.GaugeValue, duration.Seconds(), name, subName)
+	ch <- prometheus.MustNewConstMetric(subCollectorSuccessDesc, prometheus.GaugeValue, success, name, subName)
+
+	return err
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteSub(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		err     error
+		success float64
+	}{
+		{name: "success", success: 1},
+		{name: "failure", err: errors.New("failed"), success: 0},
+	} {
+		ch := make(chan prometheus.Metric, 2)
+
+		err := executeSub(slurmCollectorSubsystem, ebpfCollectorSubsystem, ch, func() error {
+			return test.err
+		})
+		require.ErrorIs(t, err, test.err, test.name)
+
+		duration := &dto.Metric{}
+		require.NoError(t, (<-ch).Write(duration))
+		assert.GreaterOrEqual(t, duration.GetGauge().GetValue(), float64(0), test.name)
+
+		success := &dto.Metric{}
+		require.NoError(t, (<-ch).Write(success))
+		assert.InDelta(t, test.success, success.GetGauge().GetValue(), 0, test.name)
+
+		labels := make(map[string]string)
+		for _, l := range success.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+
+		assert.Equal(t, map[string]string{"collector": "slurm", "subcollector": "ebpf"}, labels, test.name)
+	}
+}
@@ -0,0 +1,292 @@
+//go:build !nodcgm
+// +build !nodcgm
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const dcgmCollectorSubsystem = "dcgm"
+
+// CLI opts.
+var (
+	dcgmStatsEnabled = CEEMSExporterApp.Flag(
+		"collector.dcgm.stats",
+		"Enables collection of per unit NVIDIA GPU stats from dcgm-exporter (default: disabled)",
+	).Default("false").Bool()
+	dcgmExporterURL = CEEMSExporterApp.Flag(
+		"collector.dcgm.exporter-url",
+		"URL of metrics endpoint of dcgm-exporter running on the host",
+	).Default("http://localhost:9400/metrics").String()
+	dcgmExporterTimeout = CEEMSExporterApp.Flag(
+		"collector.dcgm.timeout",
+		"Timeout of requests to dcgm-exporter",
+	).Default("5s").Duration()
+)
+
+// DCGM fields exported by dcgm-exporter that are used by the collector.
+const (
+	dcgmFieldGPUUtil        = "DCGM_FI_DEV_GPU_UTIL"          // Percent. Not available for MIG instances
+	dcgmFieldGrEngineActive = "DCGM_FI_PROF_GR_ENGINE_ACTIVE" // Ratio
+	dcgmFieldFBUsed         = "DCGM_FI_DEV_FB_USED"           // MiB
+	dcgmFieldSMOccupancy    = "DCGM_FI_PROF_SM_OCCUPANCY"     // Ratio
+	dcgmFieldPowerUsage     = "DCGM_FI_DEV_POWER_USAGE"       // Watts
+)
+
+// Labels of dcgm-exporter metrics that identify GPUs and MIG instances.
+const (
+	dcgmUUIDLabel   = "UUID"
+	dcgmGPUIIDLabel = "GPU_I_ID"
+)
+
+// dcgmStats contains the current stats of a GPU or a MIG instance. Nil values
+// indicate that the field is not exported by dcgm-exporter.
+type dcgmStats struct {
+	utilization *float64
+	memoryUsed  *float64
+	smOccupancy *float64
+	power       *float64
+}
+
+type dcgmCollector struct {
+	logger        *slog.Logger
+	cgroupManager *cgroupManager
+	hostname      string
+	url           string
+	client        *http.Client
+	timeout       time.Duration
+	gpuDevs       []Device
+	metricDescs   map[string]*prometheus.Desc
+}
+
+// NewDCGMCollector returns a new Collector exposing NVIDIA GPU metrics of
+// compute units using dcgm-exporter.
+func NewDCGMCollector(logger *slog.Logger, cgManager *cgroupManager, gpuDevs []Device) (*dcgmCollector, error) {
+	if len(gpuDevs) == 0 {
+		logger.Error("No GPU devices found. DCGM collector wont return any data")
+	}
+
+	labels := []string{"manager", "hostname", "uuid", "index", "hindex", "gpuuuid"}
+
+	metricDescs := map[string]*prometheus.Desc{
+		"utilization": prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dcgmCollectorSubsystem, "unit_gpu_utilization_ratio"),
+			"Current utilization of GPU used by compute unit (0-1)",
+			labels, nil,
+		),
+		"memory_used": prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dcgmCollectorSubsystem, "unit_gpu_memory_used_bytes"),
+			"Current frame buffer memory used on GPU by compute unit in bytes",
+			labels, nil,
+		),
+		"sm_occupancy": prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dcgmCollectorSubsystem, "unit_gpu_sm_occupancy_ratio"),
+			"Current ratio of warps resident on SMs of GPU used by compute unit to the maximum supported warps (0-1)",
+			labels, nil,
+		),
+		"power": prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dcgmCollectorSubsystem, "unit_gpu_power_watts"),
+			"Current power consumption of GPU used by compute unit in watts",
+			labels, nil,
+		),
+	}
+
+	return &dcgmCollector{
+		logger:        logger,
+		cgroupManager: cgManager,
+		hostname:      hostname,
+		url:           *dcgmExporterURL,
+		client:        &http.Client{},
+		timeout:       *dcgmExporterTimeout,
+		gpuDevs:       gpuDevs,
+		metricDescs:   metricDescs,
+	}, nil
+}
+
+// Update implements Collector and exposes GPU metrics of compute units. gpuOrdinals
+// contains the GPU ordinals bound to each compute unit keyed by unit's uuid.
+func (c *dcgmCollector) Update(ch chan<- prometheus.Metric, gpuOrdinals map[string][]string) error {
+	if len(c.gpuDevs) == 0 {
+		return ErrNoData
+	}
+
+	// Nothing to do when no unit is using GPUs
+	if len(gpuOrdinals) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := c.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch metrics from dcgm-exporter: %w", err)
+	}
+
+	for uuid, ordinals := range gpuOrdinals {
+		for _, ordinal := range ordinals {
+			gpuuuid, miggid, smFraction := gpuByOrdinal(c.gpuDevs, ordinal)
+			if gpuuuid == "" {
+				continue
+			}
+
+			s, ok := stats[gpuStatsKey(gpuuuid, miggid)]
+			if !ok {
+				continue
+			}
+
+			// Power is only reported for physical GPUs. Power of the physical GPU
+			// is split between its MIG instances based on their fraction of SMs so
+			// that each unit is attributed the power of its own MIG instance
+			if miggid != "" && s.power == nil {
+				if p := stats[gpuStatsKey(gpuuuid, "")].power; p != nil {
+					power := *p * smFraction
+					s.power = &power
+				}
+			}
+
+			labels := []string{
+				c.cgroupManager.manager,
+				c.hostname,
+				uuid,
+				ordinal,
+				fmt.Sprintf("%s/gpu-%s", c.hostname, ordinal),
+				fmt.Sprintf("%s/%s", gpuuuid, miggid),
+			}
+
+			for name, value := range map[string]*float64{
+				"utilization":  s.utilization,
+				"memory_used":  s.memoryUsed,
+				"sm_occupancy": s.smOccupancy,
+				"power":        s.power,
+			} {
+				if value != nil {
+					ch <- prometheus.MustNewConstMetric(c.metricDescs[name], prometheus.GaugeValue, *value, labels...)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Stop releases system resources used by the collector.
+func (c *dcgmCollector) Stop(_ context.Context) error {
+	c.logger.Debug("Stopping", "collector", dcgmCollectorSubsystem)
+
+	c.client.CloseIdleConnections()
+
+	return nil
+}
+
+// fetch scrapes dcgm-exporter and returns stats of GPUs and MIG instances.
+func (c *dcgmCollector) fetch(ctx context.Context) (map[string]dcgmStats, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return parseDCGMMetrics(resp.Body)
+}
+
+// gpuStatsKey returns the key of stats of GPU with UUID gpuuuid and MIG
+// instance with GPU instance ID miggid. miggid is empty for full GPUs.
+func gpuStatsKey(gpuuuid, miggid string) string {
+	return gpuuuid + "/" + miggid
+}
+
+// parseDCGMMetrics parses metrics in Prometheus text format exported by
+// dcgm-exporter and returns stats keyed by GPU UUID and GPU instance ID.
+func parseDCGMMetrics(r io.Reader) (map[string]dcgmStats, error) {
+	var parser expfmt.TextParser
+
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]dcgmStats)
+
+	for _, field := range []string{dcgmFieldGPUUtil, dcgmFieldGrEngineActive, dcgmFieldFBUsed, dcgmFieldSMOccupancy, dcgmFieldPowerUsage} {
+		family, ok := families[field]
+		if !ok {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			var gpuuuid, miggid string
+
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case dcgmUUIDLabel:
+					gpuuuid = l.GetValue()
+				case dcgmGPUIIDLabel:
+					miggid = l.GetValue()
+				}
+			}
+
+			if gpuuuid == "" {
+				continue
+			}
+
+			var value float64
+
+			switch {
+			case m.GetGauge() != nil:
+				value = m.GetGauge().GetValue()
+			case m.GetCounter() != nil:
+				value = m.GetCounter().GetValue()
+			default:
+				value = m.GetUntyped().GetValue()
+			}
+
+			key := gpuStatsKey(gpuuuid, miggid)
+			s := stats[key]
+
+			switch field {
+			case dcgmFieldGPUUtil:
+				value /= 100
+				s.utilization = &value
+			case dcgmFieldGrEngineActive:
+				// Graphics engine activity is parsed after GPU utilization and
+				// it is preferred as it is available for MIG instances as well
+				s.utilization = &value
+			case dcgmFieldFBUsed:
+				value *= 1024 * 1024
+				s.memoryUsed = &value
+			case dcgmFieldSMOccupancy:
+				s.smOccupancy = &value
+			case dcgmFieldPowerUsage:
+				s.power = &value
+			}
+
+			stats[key] = s
+		}
+	}
+
+	return stats, nil
+}
+
+// dcgmCollectorEnabled returns true if DCGM stats are enabled.
+func dcgmCollectorEnabled() bool {
+	return *dcgmStatsEnabled
+}
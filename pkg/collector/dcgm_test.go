@@ -0,0 +1,131 @@
+//go:build !nodcgm
+// +build !nodcgm
+
+package collector
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mockDCGMGPUDevices() []Device {
+	return []Device{
+		{globalIndex: "0", uuid: "GPU-0"},
+		{globalIndex: "1", uuid: "GPU-1"},
+		{
+			uuid:       "GPU-2",
+			migEnabled: true,
+			migInstances: []MIGInstance{
+				{globalIndex: "2", gpuInstID: 1, smFraction: 0.5},
+			},
+		},
+	}
+}
+
+func TestParseDCGMMetrics(t *testing.T) {
+	f, err := os.Open("testdata/dcgm-exporter")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	stats, err := parseDCGMMetrics(f)
+	require.NoError(t, err)
+	require.Len(t, stats, 4)
+
+	// Graphics engine activity must be preferred over GPU utilization
+	assert.InEpsilon(t, 0.5, *stats["GPU-0/"].utilization, 0)
+	assert.InEpsilon(t, 1024*1024*1024, *stats["GPU-0/"].memoryUsed, 0)
+	assert.InEpsilon(t, 0.3, *stats["GPU-0/"].smOccupancy, 0)
+	assert.InEpsilon(t, 150.5, *stats["GPU-0/"].power, 0)
+
+	assert.InEpsilon(t, 1, *stats["GPU-1/"].utilization, 0)
+	assert.Nil(t, stats["GPU-1/"].smOccupancy)
+
+	// MIG instances do not report power
+	assert.InEpsilon(t, 0.25, *stats["GPU-2/1"].utilization, 0)
+	assert.Nil(t, stats["GPU-2/1"].power)
+
+	// Malformed metrics
+	_, err = parseDCGMMetrics(strings.NewReader("foo bar baz"))
+	require.Error(t, err)
+}
+
+func TestDCGMCollector(t *testing.T) {
+	out, err := os.ReadFile("testdata/dcgm-exporter")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Write(out)
+	}))
+	defer server.Close()
+
+	_, err = CEEMSExporterApp.Parse([]string{
+		"--collector.dcgm.stats",
+		"--collector.dcgm.exporter-url", server.URL + "/metrics",
+	})
+	require.NoError(t, err)
+
+	collector, err := NewDCGMCollector(
+		slog.New(slog.NewTextHandler(io.Discard, nil)), &cgroupManager{manager: "slurm"}, mockDCGMGPUDevices(),
+	)
+	require.NoError(t, err)
+
+	gpuOrdinals := map[string][]string{
+		"1009248": {"0", "2"},
+		"1009249": {"1"},
+		"1009250": {"4"},
+	}
+
+	metrics := make(chan prometheus.Metric, 20)
+	require.NoError(t, collector.Update(metrics, gpuOrdinals))
+	close(metrics)
+
+	// Four metrics of GPU 0, four metrics of MIG instance of GPU 2 and
+	// three metrics of GPU 1. Unknown GPU ordinals are ignored
+	var numMetrics int
+
+	var migPower float64
+
+	for metric := range metrics {
+		numMetrics++
+
+		if strings.Contains(metric.Desc().String(), "unit_gpu_power_watts") {
+			m := &dto.Metric{}
+			require.NoError(t, metric.Write(m))
+
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "gpuuuid" && l.GetValue() == "GPU-2/1" {
+					migPower = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, 11, numMetrics)
+
+	// Power of MIG instance must be the power of GPU split by fraction of SMs
+	assert.InEpsilon(t, 100, migPower, 0)
+
+	// Failed scrapes must return error
+	collector.url = server.URL + "/unknown"
+	require.Error(t, collector.Update(make(chan prometheus.Metric, 20), gpuOrdinals))
+
+	require.NoError(t, collector.Stop(context.Background()))
+}
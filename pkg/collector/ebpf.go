@@ -11,8 +11,10 @@ import (
 	"fmt"
 	"log/slog"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
@@ -150,6 +152,8 @@ type ebpfCollector struct {
 	cgroupIDUUIDCache  map[uint64]string
 	cgroupPathIDCache  map[string]uint64
 	activeCgroupInodes []uint64
+	cgroupUUIDInodes   map[string]uint64 // Inode of root cgroup of each unit. Changes when unit is restarted
+	created            *createdTracker
 	netColl            *ebpf.Collection
 	vfsColl            *ebpf.Collection
 	links              map[string]link.Link
@@ -386,6 +390,7 @@ func NewEbpfCollector(logger *slog.Logger, cgManager *cgroupManager) (*ebpfColle
 		opts:              opts,
 		cgroupIDUUIDCache: make(map[uint64]string),
 		cgroupPathIDCache: make(map[string]uint64),
+		created:           newCreatedTracker(time.Now()),
 		netColl:           netColl,
 		vfsColl:           vfsColl,
 		links:             links,
@@ -505,6 +510,8 @@ func NewEbpfCollector(logger *slog.Logger, cgManager *cgroupManager) (*ebpfColle
 // cgroupIDUUIDMap provides a map to cgroupID to compute unit UUID. If the map is empty, it means
 // cgroup ID and compute unit UUID is identical.
 func (c *ebpfCollector) Update(ch chan<- prometheus.Metric, cgroups []cgroup) error {
+	scrapeTime := time.Now()
+
 	// Update active cgroups
 	c.discoverCgroups(cgroups)
 
@@ -593,6 +600,9 @@ func (c *ebpfCollector) Update(ch chan<- prometheus.Metric, cgroups []cgroup) er
 	// Wait for all go routines
 	wg.Wait()
 
+	// Evict created timestamps of series of terminated units
+	c.created.done(scrapeTime)
+
 	return nil
 }
 
@@ -636,9 +646,11 @@ func (c *ebpfCollector) updateVFSWrite(ch chan<- prometheus.Metric, aggMetrics *
 
 	// Update metrics to the channel
 	for key, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.vfsWriteRequests, prometheus.CounterValue, float64(value.Calls), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
-		ch <- prometheus.MustNewConstMetric(c.vfsWriteBytes, prometheus.CounterValue, float64(value.Bytes), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
-		ch <- prometheus.MustNewConstMetric(c.vfsWriteErrors, prometheus.CounterValue, float64(value.Errors), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		created := c.createdAt(key.UUID, key.Mount)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsWriteRequests, prometheus.CounterValue, float64(value.Calls), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsWriteBytes, prometheus.CounterValue, float64(value.Bytes), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsWriteErrors, prometheus.CounterValue, float64(value.Errors), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
 	}
 
 	return nil
@@ -660,9 +672,11 @@ func (c *ebpfCollector) updateVFSRead(ch chan<- prometheus.Metric, aggMetrics *a
 
 	// Update metrics to the channel
 	for key, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.vfsReadRequests, prometheus.CounterValue, float64(value.Calls), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
-		ch <- prometheus.MustNewConstMetric(c.vfsReadBytes, prometheus.CounterValue, float64(value.Bytes), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
-		ch <- prometheus.MustNewConstMetric(c.vfsReadErrors, prometheus.CounterValue, float64(value.Errors), c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		created := c.createdAt(key.UUID, key.Mount)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsReadRequests, prometheus.CounterValue, float64(value.Calls), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsReadBytes, prometheus.CounterValue, float64(value.Bytes), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsReadErrors, prometheus.CounterValue, float64(value.Errors), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Mount)
 	}
 
 	return nil
@@ -684,8 +698,10 @@ func (c *ebpfCollector) updateVFSOpen(ch chan<- prometheus.Metric, aggMetrics *a
 
 	// Update metrics to the channel
 	for uuid, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.vfsOpenRequests, prometheus.CounterValue, float64(value.Calls), c.cgroupManager.manager, c.hostname, uuid)
-		ch <- prometheus.MustNewConstMetric(c.vfsOpenErrors, prometheus.CounterValue, float64(value.Errors), c.cgroupManager.manager, c.hostname, uuid)
+		created := c.createdAt(uuid)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsOpenRequests, prometheus.CounterValue, float64(value.Calls), created, c.cgroupManager.manager, c.hostname, uuid)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsOpenErrors, prometheus.CounterValue, float64(value.Errors), created, c.cgroupManager.manager, c.hostname, uuid)
 	}
 
 	return nil
@@ -707,8 +723,10 @@ func (c *ebpfCollector) updateVFSCreate(ch chan<- prometheus.Metric, aggMetrics
 
 	// Update metrics to the channel
 	for uuid, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.vfsCreateRequests, prometheus.CounterValue, float64(value.Calls), c.cgroupManager.manager, c.hostname, uuid)
-		ch <- prometheus.MustNewConstMetric(c.vfsCreateErrors, prometheus.CounterValue, float64(value.Errors), c.cgroupManager.manager, c.hostname, uuid)
+		created := c.createdAt(uuid)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsCreateRequests, prometheus.CounterValue, float64(value.Calls), created, c.cgroupManager.manager, c.hostname, uuid)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsCreateErrors, prometheus.CounterValue, float64(value.Errors), created, c.cgroupManager.manager, c.hostname, uuid)
 	}
 
 	return nil
@@ -730,8 +748,10 @@ func (c *ebpfCollector) updateVFSUnlink(ch chan<- prometheus.Metric, aggMetrics
 
 	// Update metrics to the channel
 	for uuid, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.vfsUnlinkRequests, prometheus.CounterValue, float64(value.Calls), c.cgroupManager.manager, c.hostname, uuid)
-		ch <- prometheus.MustNewConstMetric(c.vfsUnlinkErrors, prometheus.CounterValue, float64(value.Errors), c.cgroupManager.manager, c.hostname, uuid)
+		created := c.createdAt(uuid)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsUnlinkRequests, prometheus.CounterValue, float64(value.Calls), created, c.cgroupManager.manager, c.hostname, uuid)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.vfsUnlinkErrors, prometheus.CounterValue, float64(value.Errors), created, c.cgroupManager.manager, c.hostname, uuid)
 	}
 
 	return nil
@@ -753,8 +773,10 @@ func (c *ebpfCollector) updateNetIngress(ch chan<- prometheus.Metric, aggMetrics
 
 	// Update metrics to the channel
 	for key, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.netIngressPackets, prometheus.CounterValue, float64(value.Packets), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
-		ch <- prometheus.MustNewConstMetric(c.netIngressBytes, prometheus.CounterValue, float64(value.Bytes), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		created := c.createdAt(key.UUID, key.Proto, key.Family)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netIngressPackets, prometheus.CounterValue, float64(value.Packets), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netIngressBytes, prometheus.CounterValue, float64(value.Bytes), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
 	}
 
 	return nil
@@ -776,8 +798,10 @@ func (c *ebpfCollector) updateNetEgress(ch chan<- prometheus.Metric, aggMetrics
 
 	// Update metrics to the channel
 	for key, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.netEgressPackets, prometheus.CounterValue, float64(value.Packets), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
-		ch <- prometheus.MustNewConstMetric(c.netEgressBytes, prometheus.CounterValue, float64(value.Bytes), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		created := c.createdAt(key.UUID, key.Proto, key.Family)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netEgressPackets, prometheus.CounterValue, float64(value.Packets), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netEgressBytes, prometheus.CounterValue, float64(value.Bytes), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
 	}
 
 	return nil
@@ -799,13 +823,24 @@ func (c *ebpfCollector) updateNetRetrans(ch chan<- prometheus.Metric, aggMetrics
 
 	// Update metrics to the channel
 	for key, value := range aggMetric {
-		ch <- prometheus.MustNewConstMetric(c.netRetransPackets, prometheus.CounterValue, float64(value.Packets), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
-		ch <- prometheus.MustNewConstMetric(c.netRetransBytes, prometheus.CounterValue, float64(value.Bytes), c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		created := c.createdAt(key.UUID, key.Proto, key.Family)
+
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netRetransPackets, prometheus.CounterValue, float64(value.Packets), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
+		ch <- prometheus.MustNewConstMetricWithCreatedTimestamp(c.netRetransBytes, prometheus.CounterValue, float64(value.Bytes), created, c.cgroupManager.manager, c.hostname, key.UUID, key.Proto, key.Family)
 	}
 
 	return nil
 }
 
+// createdAt returns created timestamp of counters of unit with uuid and labels. BPF
+// maps are keyed by cgroup IDs and hence, counters restart from zero when
+// exporter is restarted or when cgroup of the unit is re-created.
+func (c *ebpfCollector) createdAt(uuid string, labels ...string) time.Time {
+	key := strings.Join(append([]string{uuid}, labels...), "|")
+
+	return c.created.created(key, strconv.FormatUint(c.cgroupUUIDInodes[uuid], 10))
+}
+
 // readMaps reads the BPF maps in a security context and returns aggregate metrics.
 func (c *ebpfCollector) readMaps() (*aggMetrics, error) {
 	dataPtr := &ebpfReadMapsCtxData{
@@ -838,18 +873,26 @@ func (c *ebpfCollector) discoverCgroups(cgroups []cgroup) {
 
 	// Reset activeCgroups from last scrape
 	c.activeCgroupInodes = make([]uint64, 0)
+	c.cgroupUUIDInodes = make(map[string]uint64, len(cgroups))
 
 	for _, cgrp := range cgroups {
 		uuid := cgrp.uuid
 
+		// Inode of root cgroup of unit identifies the current instance of unit
+		if id, ok := c.cgroupPathIDCache[cgrp.path.abs]; ok {
+			c.cgroupUUIDInodes[uuid] = id
+		} else if id, err := cgroupID(cgrp.path.abs); err == nil {
+			c.cgroupUUIDInodes[uuid] = id
+		}
+
 		for _, child := range cgrp.children {
 			path := child.abs
 
-			// Get inode of the cgroup path if not already present in the cache
+			// Get ID of the cgroup path if not already present in the cache
 			if _, ok := c.cgroupPathIDCache[path]; !ok {
-				if inode, err := inode(path); err == nil {
-					c.cgroupPathIDCache[path] = inode
-					c.cgroupIDUUIDCache[inode] = uuid
+				if id, err := cgroupID(path); err == nil {
+					c.cgroupPathIDCache[path] = id
+					c.cgroupIDUUIDCache[id] = uuid
 				}
 			}
 
@@ -0,0 +1,121 @@
+//go:build !noebpf
+// +build !noebpf
+
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+	"github.com/containerd/cgroups/v3"
+)
+
+// Minimum kernel version supported by ebpf collector.
+const minBPFKernelVersion = "5.8"
+
+// errBPFChecksFailed is returned when at least one of the checks of eBPF support fails.
+var errBPFChecksFailed = errors.New("eBPF support checks failed")
+
+// bpfCheck is the result of a single check of eBPF support on the current node.
+type bpfCheck struct {
+	name   string
+	detail string
+	err    error
+}
+
+// bpfChecks probes the current node for all the features that ebpf collector
+// relies on: kernel version, BTF, program and map types used by bpf programs,
+// kernel symbols and cgroups layout.
+func bpfChecks() []bpfCheck {
+	var checks []bpfCheck
+
+	// Kernel version and bpf objects for it
+	kernelVer, err := KernelVersion()
+	if err == nil && kernelVer < KernelStringToNumeric(minBPFKernelVersion) {
+		err = fmt.Errorf("kernel must be at least %s", minBPFKernelVersion)
+	}
+
+	checks = append(checks, bpfCheck{name: "kernel version", detail: kernelVersionString(kernelVer), err: err})
+
+	for _, obj := range []string{bpfVFSObjs(kernelVer), bpfNetObjs(kernelVer)} {
+		_, err := objsFS.ReadFile("bpf/objs/" + obj)
+		checks = append(checks, bpfCheck{name: "bpf object", detail: obj, err: err})
+	}
+
+	// CO-RE relocations of bpf programs need kernel BTF
+	_, err = os.Stat(sysFilePath("kernel/btf/vmlinux"))
+	checks = append(checks, bpfCheck{name: "kernel BTF", detail: sysFilePath("kernel/btf/vmlinux"), err: err})
+
+	// Program types of bpf programs
+	for _, pt := range []ebpf.ProgramType{ebpf.Kprobe, ebpf.Tracing} {
+		checks = append(checks, bpfCheck{name: "program type", detail: pt.String(), err: features.HaveProgramType(pt)})
+	}
+
+	// Map types of bpf programs
+	for _, mt := range []ebpf.MapType{ebpf.Array, ebpf.Hash, ebpf.LRUHash, ebpf.PerCPUArray} {
+		checks = append(checks, bpfCheck{name: "map type", detail: mt.String(), err: features.HaveMapType(mt)})
+	}
+
+	// Kernel symbols are needed to attach kprobes to arch specific functions
+	_, err = NewKsyms()
+	checks = append(checks, bpfCheck{name: "kernel symbols", detail: procFilePath("kallsyms"), err: err})
+
+	// cgroups layout. Subsystem indices of controllers are needed in cgroups v1
+	var detail string
+
+	err = nil
+
+	switch cgroupMode(*cgroupfsPath) {
+	case cgroups.Unified:
+		detail = "unified"
+	case cgroups.Legacy, cgroups.Hybrid:
+		detail = "legacy"
+		_, err = parseCgroupSubSysIds()
+	case cgroups.Unavailable:
+		detail = "unavailable"
+		err = fmt.Errorf("no cgroupfs found at %s", *cgroupfsPath)
+	}
+
+	checks = append(checks, bpfCheck{name: "cgroups mode", detail: detail, err: err})
+
+	return checks
+}
+
+// writeBPFChecks writes the pass/fail matrix of checks to w. An error is
+// returned when at least one of the checks failed.
+func writeBPFChecks(w io.Writer, checks []bpfCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tDETAIL\tRESULT\tERROR")
+
+	var failed bool
+
+	for _, c := range checks {
+		result, msg := "PASS", ""
+		if c.err != nil {
+			result, msg = "FAIL", c.err.Error()
+			failed = true
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.name, c.detail, result, msg)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if failed {
+		return errBPFChecksFailed
+	}
+
+	return nil
+}
+
+// kernelVersionString returns the kernel version string of numeric version.
+func kernelVersionString(ver int64) string {
+	return fmt.Sprintf("%d.%d.%d", ver>>16, (ver>>8)&0xff, ver&0xff)
+}
@@ -1,12 +1,15 @@
 package collector
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
 	"log/slog"
 	"os"
 	"os/user"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -378,3 +381,25 @@ func TestNetBPFObjects(t *testing.T) {
 		assert.Equal(t, test.obj, obj, test.name)
 	}
 }
+
+func TestWriteBPFChecks(t *testing.T) {
+	checks := []bpfCheck{
+		{name: "kernel version", detail: kernelVersionString(KernelStringToNumeric("6.1.12-foo"))},
+		{name: "program type", detail: "Tracing", err: errors.New("operation not permitted")},
+	}
+
+	var buf bytes.Buffer
+
+	err := writeBPFChecks(&buf, checks)
+	require.ErrorIs(t, err, errBPFChecksFailed)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"CHECK", "DETAIL", "RESULT", "ERROR"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"kernel", "version", "6.1.12", "PASS"}, strings.Fields(lines[1]))
+	assert.Contains(t, lines[2], "FAIL    operation not permitted")
+
+	// All checks passed
+	buf.Reset()
+	require.NoError(t, writeBPFChecks(&buf, checks[:1]))
+}
@@ -38,6 +38,7 @@ var (
 		"mdevUUID":  regexp.MustCompile(`^\s+MDEV UUID\s+: ([a-zA-Z0-9\-]+)`),
 		"gpuInstID": regexp.MustCompile(`^\s+GPU Instance ID\s+: ([0-9]+|N/A)`),
 	}
+	migProfileRegex = regexp.MustCompile(`^\|\s+([0-9]+)\s+MIG\s+\S+\s+[0-9]+\s+[0-9]+/[0-9]+\s+[0-9.]+\s+\S+\s+([0-9]+)\s`)
 )
 
 // BusID is a struct that contains PCI bus address of GPU device.
@@ -176,6 +177,26 @@ func (d *Device) CompareBusID(id string) bool {
 	return d.busID.Compare(busID)
 }
 
+// gpuByOrdinal returns the UUID of GPU and GPU instance ID of MIG instance that
+// have the global index ordinal along with the flag value of GPU. For MIG instances,
+// flag value is the fraction of SMs of GPU that are allocated to instance.
+func gpuByOrdinal(devs []Device, ordinal string) (string, string, float64) {
+	for _, dev := range devs {
+		// If the device has MIG enabled loop over them as well
+		for _, mig := range dev.migInstances {
+			if ordinal == mig.globalIndex {
+				return dev.uuid, strconv.FormatUint(mig.gpuInstID, 10), mig.smFraction
+			}
+		}
+
+		if ordinal == dev.globalIndex {
+			return dev.uuid, "", 1
+		}
+	}
+
+	return "", "", 1
+}
+
 // GetGPUDevices returns GPU devices.
 func GetGPUDevices(gpuType string, logger *slog.Logger) ([]Device, error) {
 	if gpuType == "nvidia" {
@@ -214,7 +235,17 @@ func GetNvidiaGPUDevices(logger *slog.Logger) ([]Device, error) {
 		return nil, err
 	}
 
-	return parseNvidiaSmiOutput(nvidiaSmiOutput, logger)
+	// Get total number of SMs of physical GPUs from MIG profiles. Command
+	// fails when there are no MIG enabled GPUs and it is not an error
+	var gpuSMs map[string]float64
+
+	if migProfilesOutput, err := osexec.Execute(nvidiaSmiCmd, []string{"mig", "--list-gpu-instance-profiles"}, nil); err != nil {
+		logger.Debug("Failed to list MIG GPU instance profiles", "err", err)
+	} else {
+		gpuSMs = parseNvidiaMIGProfiles(string(migProfilesOutput))
+	}
+
+	return parseNvidiaSmiOutput(nvidiaSmiOutput, gpuSMs, logger)
 }
 
 // GetAMDGPUDevices returns all GPU devices using rocm-smi command
@@ -280,8 +311,50 @@ func lookupRocmSmiCmd() (string, error) {
 	}
 }
 
+// parseNvidiaMIGProfiles parses nvidia-smi MIG GPU instance profiles and returns
+// a map of GPU index to total number of SMs of physical GPU.
+//
+// Example output:
+// +-----------------------------------------------------------------------------+
+// | GPU instance profiles:                                                      |
+// | GPU   Name             ID    Instances   Memory     P2P    SM    DEC   ENC  |
+// |                              Free/Total   GiB              CE    JPEG  OFA  |
+// |=============================================================================|
+// |   0  MIG 1g.5gb        19     7/7        4.75       No     14     0     0   |
+// |                                                             1     0     0   |
+// +-----------------------------------------------------------------------------+
+// |   0  MIG 7g.40gb        0     1/1        39.25      No     98     5     0   |
+// |                                                             7     1     1   |
+// +-----------------------------------------------------------------------------+
+//
+// The profile spanning all the slices has the largest number of SMs and it is
+// the total number of SMs of physical GPU that are available to MIG instances.
+func parseNvidiaMIGProfiles(cmdOutput string) map[string]float64 {
+	gpuSMs := make(map[string]float64)
+
+	for _, line := range strings.Split(cmdOutput, "\n") {
+		matches := migProfileRegex.FindStringSubmatch(line)
+		if len(matches) < 3 {
+			continue
+		}
+
+		smCount, err := strconv.ParseFloat(matches[2], 64)
+		if err != nil {
+			continue
+		}
+
+		if smCount > gpuSMs[matches[1]] {
+			gpuSMs[matches[1]] = smCount
+		}
+	}
+
+	return gpuSMs
+}
+
 // parseNvidiaSmiOutput parses nvidia-smi output and return GPU Devices map.
-func parseNvidiaSmiOutput(cmdOutput []byte, logger *slog.Logger) ([]Device, error) {
+// gpuSMs is a map of GPU index to total number of SMs of physical GPU which is
+// used to estimate fraction of SMs of each MIG instance.
+func parseNvidiaSmiOutput(cmdOutput []byte, gpuSMs map[string]float64, logger *slog.Logger) ([]Device, error) {
 	// Get all devices
 	var gpuDevices []Device
 
@@ -337,10 +410,26 @@ func parseNvidiaSmiOutput(cmdOutput []byte, logger *slog.Logger) ([]Device, erro
 			globalIndex++
 		}
 
+		// Fraction of each instance must be estimated against SMs of physical
+		// GPU so that unallocated slices are not attributed to instances. If
+		// it is not available, fallback to total SMs of existing instances.
+		if totalSMs > 0 {
+			if physicalSMs := gpuSMs[dev.localIndex]; physicalSMs >= totalSMs {
+				totalSMs = physicalSMs
+			} else {
+				logger.Debug("Total SMs of physical GPU not found. Using SMs of MIG instances", "gpu", dev.localIndex)
+			}
+		}
+
 		// Now we have total SMs get fraction for each instance.
 		// We will use it for splitting total power between instances.
+		// When SM counts are not reported, power is split equally
 		for imig, mig := range gpu.MIGDevices.Devices {
-			migDevs[imig].smFraction = float64(mig.DeviceAttrs.Shared.SMCount) / totalSMs
+			if totalSMs > 0 {
+				migDevs[imig].smFraction = float64(mig.DeviceAttrs.Shared.SMCount) / totalSMs
+			} else {
+				migDevs[imig].smFraction = 1 / float64(len(migDevs))
+			}
 		}
 
 		dev.migInstances = migDevs
@@ -38,9 +38,9 @@ func getExpectedNvidiaDevs() []Device {
 			uuid:       "GPU-956348bc-d43d-23ed-53d4-857749fa2b67",
 			busID:      BusID{domain: 0x0, bus: 0x21, device: 0x0, function: 0x0},
 			migInstances: []MIGInstance{
-				{localIndex: 0x0, globalIndex: "2", computeInstID: 0x0, gpuInstID: 0x1, smFraction: 0.6},
-				{localIndex: 0x1, globalIndex: "3", computeInstID: 0x0, gpuInstID: 0x5, smFraction: 0.2},
-				{localIndex: 0x2, globalIndex: "4", computeInstID: 0x0, gpuInstID: 0xd, smFraction: 0.2},
+				{localIndex: 0x0, globalIndex: "2", computeInstID: 0x0, gpuInstID: 0x1, smFraction: 0.42857142857142855},
+				{localIndex: 0x1, globalIndex: "3", computeInstID: 0x0, gpuInstID: 0x5, smFraction: 0.14285714285714285},
+				{localIndex: 0x2, globalIndex: "4", computeInstID: 0x0, gpuInstID: 0xd, smFraction: 0.14285714285714285},
 			},
 			migEnabled:  true,
 			vgpuEnabled: true,
@@ -151,6 +151,57 @@ func TestParseNvidiaSmiOutput(t *testing.T) {
 	assert.Equal(t, getExpectedNvidiaDevs(), gpuDevices)
 }
 
+func TestParseNvidiaMIGProfiles(t *testing.T) {
+	out, err := os.ReadFile("testdata/nvidia-smi")
+	require.NoError(t, err)
+
+	// MIG enabled GPUs must report SMs of the full 7g profile
+	gpuSMs := parseNvidiaMIGProfiles(string(out))
+	assert.Equal(t, map[string]float64{"2": 98, "3": 98}, gpuSMs)
+
+	// Without MIG profiles, fraction must fallback to SMs of existing instances
+	nvidiaSmiLog := `<?xml version="1.0" ?>
+<nvidia_smi_log>
+	<gpu id="00000000:21:00.0">
+		<mig_mode>
+				<current_mig>Enabled</current_mig>
+		</mig_mode>
+		<mig_devices>
+				<mig_device>
+					<index>0</index>
+					<gpu_instance_id>1</gpu_instance_id>
+					<compute_instance_id>0</compute_instance_id>
+					<device_attributes>
+						<shared>
+							<multiprocessor_count>42</multiprocessor_count>
+						</shared>
+					</device_attributes>
+				</mig_device>
+				<mig_device>
+					<index>1</index>
+					<gpu_instance_id>5</gpu_instance_id>
+					<compute_instance_id>0</compute_instance_id>
+					<device_attributes>
+						<shared>
+							<multiprocessor_count>14</multiprocessor_count>
+						</shared>
+					</device_attributes>
+				</mig_device>
+		</mig_devices>
+	</gpu>
+</nvidia_smi_log>`
+
+	gpuDevices, err := parseNvidiaSmiOutput([]byte(nvidiaSmiLog), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	assert.InEpsilon(t, 0.75, gpuDevices[0].migInstances[0].smFraction, 0)
+	assert.InEpsilon(t, 0.25, gpuDevices[0].migInstances[1].smFraction, 0)
+
+	gpuDevices, err = parseNvidiaSmiOutput([]byte(nvidiaSmiLog), map[string]float64{"0": 98}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	assert.InEpsilon(t, 42.0/98, gpuDevices[0].migInstances[0].smFraction, 0)
+	assert.InEpsilon(t, 14.0/98, gpuDevices[0].migInstances[1].smFraction, 0)
+}
+
 func TestNvidiaMIGAtLowerAddr(t *testing.T) {
 	nvidiaSmiLog := `<?xml version="1.0" ?>
 <!DOCTYPE nvidia_smi_log SYSTEM "nvsmi_device_v12.dtd">
@@ -212,6 +263,11 @@ echo """%s"""
 	// Check if globalIndex for GPU 0 is empty and GPU 1 is 3
 	assert.Empty(t, gpuDevices[0].globalIndex)
 	assert.Equal(t, "3", gpuDevices[1].globalIndex)
+
+	// Without SM counts, MIG instances must get equal fractions
+	for _, mig := range gpuDevices[0].migInstances {
+		assert.InEpsilon(t, 1.0/3, mig.smFraction, 0)
+	}
 }
 
 func TestNvidiaMIGAtHigherAddr(t *testing.T) {
@@ -559,9 +615,9 @@ func TestUpdateMdevs(t *testing.T) {
 			localIndex: "2", globalIndex: "", name: "NVIDIA A100-PCIE-40GB NVIDIA Ampere", uuid: "GPU-956348bc-d43d-23ed-53d4-857749fa2b67",
 			busID: BusID{domain: 0x0, bus: 0x21, device: 0x0, function: 0x0},
 			migInstances: []MIGInstance{
-				{localIndex: 0x0, globalIndex: "2", computeInstID: 0x0, gpuInstID: 0x1, smFraction: 0.6, mdevUUIDs: []string{"f0f4b97c-6580-48a6-ae1b-a807d6dfe08f"}},
-				{localIndex: 0x1, globalIndex: "3", computeInstID: 0x0, gpuInstID: 0x5, smFraction: 0.2, mdevUUIDs: []string{"3b356d38-854e-48be-b376-00c72c7d119c", "5bb3bad7-ce3b-4aa5-84d7-b5b33cf9d45e"}},
-				{localIndex: 0x2, globalIndex: "4", computeInstID: 0x0, gpuInstID: 0xd, smFraction: 0.2, mdevUUIDs: []string{}},
+				{localIndex: 0x0, globalIndex: "2", computeInstID: 0x0, gpuInstID: 0x1, smFraction: 0.42857142857142855, mdevUUIDs: []string{"f0f4b97c-6580-48a6-ae1b-a807d6dfe08f"}},
+				{localIndex: 0x1, globalIndex: "3", computeInstID: 0x0, gpuInstID: 0x5, smFraction: 0.14285714285714285, mdevUUIDs: []string{"3b356d38-854e-48be-b376-00c72c7d119c", "5bb3bad7-ce3b-4aa5-84d7-b5b33cf9d45e"}},
+				{localIndex: 0x2, globalIndex: "4", computeInstID: 0x0, gpuInstID: 0xd, smFraction: 0.14285714285714285, mdevUUIDs: []string{}},
 			},
 			migEnabled: true, vgpuEnabled: true,
 		},
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
@@ -8,9 +9,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/procfs"
+	"golang.org/x/sys/unix"
 )
 
 var (
@@ -135,6 +139,27 @@ func inode(path string) (uint64, error) {
 	return stat.Ino, nil
 }
 
+// cgroupID returns the kernel ID of the cgroup at a given path. The cgroup ID
+// is read from the file handle of the cgroup directory which is the same ID
+// that eBPF helpers like bpf_get_current_cgroup_id return. It works even when
+// the cgroupfs is bind mounted inside a container with its own cgroup namespace.
+// When the path is not on cgroupfs or file handles are not supported, inode
+// of the path is returned.
+func cgroupID(path string) (uint64, error) {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(path, &statfs); err != nil {
+		return 0, fmt.Errorf("error running statfs(%s): %w", path, err)
+	}
+
+	if statfs.Type == unix.CGROUP2_SUPER_MAGIC || statfs.Type == unix.CGROUP_SUPER_MAGIC {
+		if handle, _, err := unix.NameToHandleAt(unix.AT_FDCWD, path, 0); err == nil && handle.Size() == 8 {
+			return binary.NativeEndian.Uint64(handle.Bytes()), nil
+		}
+	}
+
+	return inode(path)
+}
+
 // unescapeString sanitizes the string by unescaping UTF-8 characters.
 func unescapeString(s string) (string, error) {
 	sanitized, err := strconv.Unquote("\"" + s + "\"")
@@ -155,6 +180,66 @@ func readUintFromFile(path string) (uint64, error) {
 	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
 }
 
+// createdSeries is a counter series tracked by createdTracker.
+type createdSeries struct {
+	instance string
+	created  time.Time
+}
+
+// createdTracker tracks created timestamps of counter series. A series is
+// considered created at the previous scrape before it has been seen for the
+// first time. When the instance backing a series changes, e.g., when cgroup of
+// a unit is re-created after a requeue, counter restarts from zero and the
+// series is considered created again.
+type createdTracker struct {
+	mu         sync.Mutex
+	lastScrape time.Time
+	series     map[string]createdSeries
+	seen       map[string]struct{}
+}
+
+// newCreatedTracker returns a new instance of createdTracker. Series seen in
+// the first scrape are considered created at start.
+func newCreatedTracker(start time.Time) *createdTracker {
+	return &createdTracker{
+		lastScrape: start,
+		series:     make(map[string]createdSeries),
+		seen:       make(map[string]struct{}),
+	}
+}
+
+// created returns created timestamp of series identified by key and backed by instance.
+func (t *createdTracker) created(key string, instance string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.seen[key] = struct{}{}
+
+	if s, ok := t.series[key]; ok && s.instance == instance {
+		return s.created
+	}
+
+	t.series[key] = createdSeries{instance: instance, created: t.lastScrape}
+
+	return t.lastScrape
+}
+
+// done must be called at the end of each scrape. Series that are not seen in
+// the scrape are evicted.
+func (t *createdTracker) done(scrapeTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key := range t.series {
+		if _, ok := t.seen[key]; !ok {
+			delete(t.series, key)
+		}
+	}
+
+	t.seen = make(map[string]struct{})
+	t.lastScrape = scrapeTime
+}
+
 // // lookupIPs returns all the IP addresses of the current host.
 // // Returns botth IPv4 and IPv6.
 // func lookupIPs() ([]string, error) {
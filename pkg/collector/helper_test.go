@@ -5,6 +5,7 @@ package collector
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,3 +45,44 @@ func TestInode(t *testing.T) {
 
 	assert.Positive(t, inodeValue)
 }
+
+func TestCgroupID(t *testing.T) {
+	absPath, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+
+	// When path is not on cgroupfs, inode must be returned
+	id, err := cgroupID(absPath)
+	require.NoError(t, err)
+
+	inodeValue, err := inode(absPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, inodeValue, id)
+
+	// Non existent path must return error
+	_, err = cgroupID(filepath.Join(absPath, "non-existent"))
+	require.Error(t, err)
+}
+
+func TestCreatedTracker(t *testing.T) {
+	start := time.Unix(1000, 0)
+	tracker := newCreatedTracker(start)
+
+	// Series seen in first scrape are created at start
+	assert.Equal(t, start, tracker.created("a", "1"))
+	assert.Equal(t, start, tracker.created("b", "1"))
+	tracker.done(time.Unix(1010, 0))
+
+	// Existing series keep their created timestamp and new ones get last scrape time
+	assert.Equal(t, start, tracker.created("a", "1"))
+	assert.Equal(t, time.Unix(1010, 0), tracker.created("c", "1"))
+
+	// Series backed by a new instance must be reset
+	assert.Equal(t, time.Unix(1010, 0), tracker.created("b", "2"))
+	tracker.done(time.Unix(1020, 0))
+
+	// Series not seen in previous scrape are evicted
+	tracker.created("a", "1")
+	tracker.done(time.Unix(1030, 0))
+	assert.Equal(t, time.Unix(1030, 0), tracker.created("c", "1"))
+}
@@ -0,0 +1,257 @@
+//go:build !noinventory
+// +build !noinventory
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+	"github.com/stmcginnis/gofish"
+)
+
+const inventoryCollectorSubsystem = "inventory"
+
+// CLI opts.
+var (
+	inventoryRefreshInterval = CEEMSExporterApp.Flag(
+		"collector.inventory.refresh-interval",
+		"Interval at which hardware inventory of the node is refreshed.",
+	).Default("24h").Duration()
+	inventoryRedfishConfigFile = CEEMSExporterApp.Flag(
+		"collector.inventory.redfish-web-config",
+		"Path to Redfish web configuration file to fetch BIOS and BMC firmware versions (default: none).",
+	).Default("").String()
+)
+
+// inventory is the hardware inventory of the node.
+type inventory struct {
+	cpuModel    string
+	cpuSockets  int
+	memoryBytes uint64
+	gpuModels   []string
+	biosVersion string
+	bmcVersion  string
+	bootTime    uint64
+}
+
+// labelValues returns label values of inventory info metric.
+func (i inventory) labelValues() []string {
+	return []string{
+		i.cpuModel,
+		strconv.Itoa(i.cpuSockets),
+		strconv.FormatUint(i.memoryBytes, 10),
+		strings.Join(i.gpuModels, ","),
+		i.biosVersion,
+		i.bmcVersion,
+	}
+}
+
+type inventoryCollector struct {
+	logger          *slog.Logger
+	fs              procfs.FS
+	hostname        string
+	redfishConfig   *gofish.ClientConfig
+	refreshInterval time.Duration
+	lastRefresh     time.Time
+	inventory       inventory
+	mu              sync.Mutex
+	infoDesc        *prometheus.Desc
+	bootTimeDesc    *prometheus.Desc
+}
+
+func init() {
+	RegisterCollector(inventoryCollectorSubsystem, defaultDisabled, NewInventoryCollector)
+}
+
+// NewInventoryCollector returns a new Collector exposing hardware inventory
+// and boot time of the node. Inventory is refreshed periodically as it
+// rarely changes.
+func NewInventoryCollector(logger *slog.Logger) (Collector, error) {
+	fs, err := procfs.NewFS(*procfsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open procfs: %w", err)
+	}
+
+	// BIOS and BMC firmware versions are fetched from Redfish only when
+	// a config file is provided
+	var redfishConfig *gofish.ClientConfig
+	if *inventoryRedfishConfigFile != "" {
+		if redfishConfig, err = newRedfishClientConfig(*inventoryRedfishConfigFile, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	return &inventoryCollector{
+		logger:          logger,
+		fs:              fs,
+		hostname:        hostname,
+		redfishConfig:   redfishConfig,
+		refreshInterval: *inventoryRefreshInterval,
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, inventoryCollectorSubsystem, "info"),
+			"Hardware inventory of the node",
+			[]string{
+				"hostname", "cpu_model", "cpu_sockets", "memory_bytes",
+				"gpu_models", "bios_version", "bmc_firmware_version",
+			}, nil,
+		),
+		bootTimeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, inventoryCollectorSubsystem, "boot_time_seconds"),
+			"Boot time of the node in seconds since epoch",
+			[]string{"hostname"}, nil,
+		),
+	}, nil
+}
+
+// Update implements Collector and exposes hardware inventory.
+func (c *inventoryCollector) Update(ch chan<- prometheus.Metric) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Refresh inventory when it is stale
+	if c.lastRefresh.IsZero() || time.Since(c.lastRefresh) > c.refreshInterval {
+		inv, err := c.discover()
+		if err != nil {
+			return err
+		}
+
+		c.inventory = inv
+		c.lastRefresh = time.Now()
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.infoDesc, prometheus.GaugeValue, 1, append([]string{c.hostname}, c.inventory.labelValues()...)...,
+	)
+	ch <- prometheus.MustNewConstMetric(c.bootTimeDesc, prometheus.GaugeValue, float64(c.inventory.bootTime), c.hostname)
+
+	return nil
+}
+
+// Stop releases system resources used by the collector.
+func (c *inventoryCollector) Stop(_ context.Context) error {
+	c.logger.Debug("Stopping", "collector", inventoryCollectorSubsystem)
+
+	return nil
+}
+
+// discover returns current hardware inventory of the node.
+func (c *inventoryCollector) discover() (inventory, error) {
+	var inv inventory
+
+	// Boot time
+	stat, err := c.fs.Stat()
+	if err != nil {
+		return inv, fmt.Errorf("failed to read stat: %w", err)
+	}
+
+	inv.bootTime = stat.BootTime
+
+	// CPU model and number of sockets
+	info, err := c.fs.CPUInfo()
+	if err != nil {
+		return inv, fmt.Errorf("failed to read cpuinfo: %w", err)
+	}
+
+	sockets := make(map[string]struct{})
+
+	for _, cpu := range info {
+		sockets[cpu.PhysicalID] = struct{}{}
+
+		if inv.cpuModel == "" {
+			inv.cpuModel = cpu.ModelName
+		}
+	}
+
+	inv.cpuSockets = len(sockets)
+
+	// Total memory
+	meminfo, err := c.fs.Meminfo()
+	if err != nil {
+		return inv, fmt.Errorf("failed to read meminfo: %w", err)
+	}
+
+	if meminfo.MemTotalBytes != nil {
+		inv.memoryBytes = *meminfo.MemTotalBytes
+	}
+
+	inv.gpuModels = c.gpuModels()
+
+	// BIOS version from DMI which can be overridden by Redfish
+	if content, err := os.ReadFile(sysFilePath("class/dmi/id/bios_version")); err == nil {
+		inv.biosVersion = strings.TrimSpace(string(content))
+	}
+
+	if c.redfishConfig != nil {
+		if err := c.updateFirmwareVersions(&inv); err != nil {
+			c.logger.Error("Failed to fetch firmware versions from Redfish", "err", err)
+		}
+	}
+
+	return inv, nil
+}
+
+// gpuModels returns the sorted unique models of GPUs on the node.
+func (c *inventoryCollector) gpuModels() []string {
+	gpuTypes := []string{"nvidia", "amd"}
+	if *gpuType != "" {
+		gpuTypes = []string{*gpuType}
+	}
+
+	var models []string
+
+	for _, gpuType := range gpuTypes {
+		devs, err := GetGPUDevices(gpuType, c.logger)
+		if err != nil {
+			c.logger.Debug("No GPU devices found", "type", gpuType, "err", err)
+
+			continue
+		}
+
+		for _, dev := range devs {
+			if !slices.Contains(models, dev.name) {
+				models = append(models, dev.name)
+			}
+		}
+	}
+
+	slices.Sort(models)
+
+	return models
+}
+
+// updateFirmwareVersions updates BIOS and BMC firmware versions of inventory
+// using Redfish API.
+func (c *inventoryCollector) updateFirmwareVersions(inv *inventory) error {
+	client, err := gofish.Connect(*c.redfishConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create a Redfish client: %w", err)
+	}
+	defer client.Logout()
+
+	if systems, err := client.Service.Systems(); err != nil {
+		c.logger.Debug("Failed to fetch systems from Redfish", "err", err)
+	} else if len(systems) > 0 && systems[0].BIOSVersion != "" {
+		inv.biosVersion = systems[0].BIOSVersion
+	}
+
+	managers, err := client.Service.Managers()
+	if err != nil {
+		return fmt.Errorf("failed to fetch managers from Redfish: %w", err)
+	}
+
+	if len(managers) > 0 {
+		inv.bmcVersion = managers[0].FirmwareVersion
+	}
+
+	return nil
+}
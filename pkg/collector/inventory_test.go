@@ -0,0 +1,146 @@
+//go:build !noinventory
+// +build !noinventory
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testInventoryRedfishServer() *httptest.Server {
+	responses := map[string]string{
+		"/redfish/v1/Managers":     `{"Members":[{"@odata.id":"/redfish/v1/Managers/BMC"}]}`,
+		"/redfish/v1/Managers/BMC": `{"@odata.id":"/redfish/v1/Managers/BMC","Id":"BMC","FirmwareVersion":"1.10.2"}`,
+		"/redfish/v1/Systems":      `{"Members":[{"@odata.id":"/redfish/v1/Systems/1"}]}`,
+		"/redfish/v1/Systems/1":    `{"@odata.id":"/redfish/v1/Systems/1","Id":"1","BiosVersion":"U46 v2.90"}`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redfish/v1/" {
+			if data, err := os.ReadFile("testdata/redfish/service_root.json"); err == nil {
+				w.Write(data)
+
+				return
+			}
+		}
+
+		if resp, ok := responses[r.URL.Path]; ok {
+			w.Write([]byte(resp))
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+// inventoryLabels returns labels of inventory info metric.
+func inventoryLabels(t *testing.T, collector Collector) map[string]string {
+	t.Helper()
+
+	metrics := make(chan prometheus.Metric, 2)
+	require.NoError(t, collector.Update(metrics))
+	close(metrics)
+
+	labels := make(map[string]string)
+
+	for metric := range metrics {
+		m := &dto.Metric{}
+		require.NoError(t, metric.Write(m))
+
+		if m.GetGauge().GetValue() != 1 {
+			continue
+		}
+
+		for _, l := range m.GetLabel() {
+			labels[l.GetName()] = l.GetValue()
+		}
+	}
+
+	return labels
+}
+
+func TestInventoryCollector(t *testing.T) {
+	// Make a sysfs with DMI info
+	sysDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sysDir, "class/dmi/id"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sysDir, "class/dmi/id/bios_version"), []byte("2.1.0\n"), 0o600))
+
+	_, err := CEEMSExporterApp.Parse(
+		[]string{
+			"--path.procfs", "testdata/proc",
+			"--path.sysfs", sysDir,
+			"--collector.gpu.type", "nvidia",
+			"--collector.gpu.nvidia-smi-path", "testdata/nvidia-smi",
+		},
+	)
+	require.NoError(t, err)
+
+	collector, err := NewInventoryCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	labels := inventoryLabels(t, collector)
+	assert.Equal(t, "Intel(R) Core(TM) i7-8650U CPU @ 1.90GHz", labels["cpu_model"])
+	assert.Equal(t, "1", labels["cpu_sockets"])
+	assert.Equal(t, "16042172416", labels["memory_bytes"])
+	assert.Equal(t, "NVIDIA A100-PCIE-40GB NVIDIA Ampere", labels["gpu_models"])
+	assert.Equal(t, "2.1.0", labels["bios_version"])
+	assert.Empty(t, labels["bmc_firmware_version"])
+
+	require.NoError(t, collector.Stop(context.Background()))
+}
+
+func TestInventoryCollectorWithRedfish(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Start a dummy Redfish server
+	server := testInventoryRedfishServer()
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	configFile := fmt.Sprintf(`
+---
+redfish_web_config:
+  protocol: http
+  hostname: %s
+  port: %s
+  username: admin
+  password: secret`, serverURL.Hostname(), serverURL.Port())
+
+	configPath := filepath.Join(tmpDir, "config.yml")
+	require.NoError(t, os.WriteFile(configPath, []byte(configFile), 0o600))
+
+	_, err = CEEMSExporterApp.Parse(
+		[]string{
+			"--path.procfs", "testdata/proc",
+			"--path.sysfs", tmpDir,
+			"--collector.gpu.type", "nvidia",
+			"--collector.gpu.nvidia-smi-path", "testdata/nvidia-smi",
+			"--collector.inventory.redfish-web-config", configPath,
+		},
+	)
+	require.NoError(t, err)
+
+	collector, err := NewInventoryCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	labels := inventoryLabels(t, collector)
+	assert.Equal(t, "U46 v2.90", labels["bios_version"])
+	assert.Equal(t, "1.10.2", labels["bmc_firmware_version"])
+}
@@ -131,10 +131,10 @@ type impiCollector struct {
 	}
 	----------------------------------------------------------------------
 
-	NOTE: If the native Go implementation is stable enough, we should remove
-	all other modes as it does not add any value to the collector. Currently native
-	implementation is only a fallback mode when no other IPMI utils are found on the
-	node.
+	NOTE: Native Go implementation using OpenIPMI driver is the preferred mode
+	and it is used whenever a power reading can be made using it. IPMI utils are
+	only used when native mode is not available on the node or when a command is
+	explicitly configured using CLI flag.
 */
 
 var (
@@ -205,6 +205,8 @@ type ipmiClientSecurityCtxData struct {
 
 func init() {
 	RegisterCollector(ipmiCollectorSubsystem, defaultDisabled, NewIPMICollector)
+
+	bmcProbes["ipmi"] = ipmiBMCReachable
 }
 
 // NewIPMICollector returns a new Collector exposing IMPI DCMI power metrics.
@@ -240,6 +242,8 @@ func NewIPMICollector(logger *slog.Logger) (Collector, error) {
 	// If no IPMI command is provided, try to find one
 	var cmdSlice []string
 
+	var client *ipmi.IPMIClient
+
 	var err error
 
 	// If native mode is forced set execMode and goto outside
@@ -250,13 +254,21 @@ func NewIPMICollector(logger *slog.Logger) (Collector, error) {
 	}
 
 	if *ipmiDcmiCmd == "" && *ipmiDcmiCmdDepr == "" {
-		if cmdSlice, err = findIPMICmd(); err != nil {
-			logger.Info("None of ipmitool,ipmiutil,ipmi-dcmi commands found. Using native implementation using OpenIPMI interface")
-
+		// Prefer native implementation as it neither needs third party tools nor
+		// sudo and it avoids forking a sub-process on every scrape
+		if client, err = newNativeIPMIClient(logger); err == nil {
 			execMode = nativeMode
 
 			goto outside
 		}
+
+		logger.Info("Native implementation using OpenIPMI interface not available. Falling back to IPMI commands", "err", err)
+
+		if cmdSlice, err = findIPMICmd(); err != nil {
+			logger.Error("None of ipmitool,ipmiutil,ipmi-dcmi commands found")
+
+			return nil, err
+		}
 	} else {
 		if *ipmiDcmiCmdDepr != "" {
 			cmdSlice = strings.Split(*ipmiDcmiCmdDepr, " ")
@@ -345,14 +357,18 @@ outside:
 		// Capability to be able to talk to /dev/ipmi0
 		caps := setupCollectorCaps(logger, ipmiCollectorSubsystem, []string{"cap_dac_override"})
 
-		// Setup IPMI client
-		collector.client, err = ipmi.NewIPMIClient(*ipmiDevNum, logger.With("subsystem", "ipmi_client"))
-		if err != nil {
-			logger.Error("Failed to create a IPMI client", "err", err)
+		// Setup IPMI client when it is not already done while detecting mode
+		if client == nil {
+			client, err = ipmi.NewIPMIClient(*ipmiDevNum, logger.With("subsystem", "ipmi_client"))
+			if err != nil {
+				logger.Error("Failed to create a IPMI client", "err", err)
 
-			return nil, err
+				return nil, err
+			}
 		}
 
+		collector.client = client
+
 		// Setup new security context(s)
 		collector.securityContexts[openIPMICtx], err = security.NewSecurityContext(openIPMICtx, caps, dcmiPowerReading, logger)
 		if err != nil {
@@ -596,6 +612,42 @@ func dcmiPowerReading(data interface{}) error {
 	return nil
 }
 
+// newNativeIPMIClient returns a new IPMI client using OpenIPMI driver after verifying
+// that DCMI power readings can be made using it. Collectors are initiated before
+// dropping privileges and hence, the device file can be opened here.
+func newNativeIPMIClient(logger *slog.Logger) (*ipmi.IPMIClient, error) {
+	client, err := ipmi.NewIPMIClient(*ipmiDevNum, logger.With("subsystem", "ipmi_client"))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := client.PowerReading(time.Second); err != nil {
+		client.Close()
+
+		return nil, fmt.Errorf("failed to get DCMI power reading: %w", err)
+	}
+
+	return client, nil
+}
+
+// ipmiBMCReachable returns true if BMC responds to DCMI power reading requests
+// made using OpenIPMI driver. ok is false when IPMI device cannot be opened.
+func ipmiBMCReachable(logger *slog.Logger) (bool, bool) {
+	client, err := ipmi.NewIPMIClient(*ipmiDevNum, logger.With("subsystem", "ipmi_client"))
+	if err != nil {
+		return false, false
+	}
+	defer client.Close()
+
+	if _, err := client.PowerReading(time.Second); err != nil {
+		logger.Debug("BMC is not reachable using OpenIPMI driver", "err", err)
+
+		return false, true
+	}
+
+	return true, true
+}
+
 // Find IPMI command from list of different IPMI implementations.
 func findIPMICmd() ([]string, error) {
 	for _, cmd := range ipmiDcmiCmds {
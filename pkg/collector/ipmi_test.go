@@ -216,3 +216,32 @@ func TestIpmiUtilFinder(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "ipmiutil", ipmiCmdSlice[0])
 }
+
+func TestIPMICollectorNativeFallback(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Set path
+	t.Setenv("PATH", fmt.Sprintf("%s:%s", tmpDir, os.Getenv("PATH")))
+
+	ipmiDcmiPath, err := filepath.Abs("testdata/ipmi/freeipmi/ipmi-dcmi")
+	require.NoError(t, err)
+
+	err = os.Link(ipmiDcmiPath, tmpDir+"/ipmi-dcmi")
+	require.NoError(t, err)
+
+	// Use a non existent device so that native mode is not available
+	_, err = CEEMSExporterApp.Parse([]string{
+		"--collector.ipmi_dcmi.dev-num", "99",
+		"--collector.ipmi_dcmi.test-mode",
+	})
+	require.NoError(t, err)
+
+	collector, err := NewIPMICollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Collector must fall back to IPMI command found on PATH
+	c, ok := collector.(*impiCollector)
+	require.True(t, ok)
+	assert.Equal(t, testMode, c.execMode)
+	assert.Equal(t, "ipmi-dcmi", c.ipmiCmd[0])
+}
@@ -289,7 +289,11 @@ func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) error {
 		defer wg.Done()
 
 		// Update cgroup metrics
-		if err := c.cgroupCollector.Update(ch, metrics.cgMetrics); err != nil {
+		if err := executeSub(libvirtCollectorSubsystem, cgroupCollectorSubsystem, ch, func() error {
+			c.cgroupCollector.updateActiveCgroups(ch, metrics.cgroups)
+
+			return c.cgroupCollector.Update(ch, metrics.cgMetrics)
+		}); err != nil {
 			c.logger.Error("Failed to update cgroup stats", "err", err)
 		}
 
@@ -306,7 +310,9 @@ func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update perf metrics
-			if err := c.perfCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(libvirtCollectorSubsystem, perfCollectorSubsystem, ch, func() error {
+				return c.perfCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update perf stats", "err", err)
 			}
 		}()
@@ -319,7 +325,9 @@ func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update ebpf metrics
-			if err := c.ebpfCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(libvirtCollectorSubsystem, ebpfCollectorSubsystem, ch, func() error {
+				return c.ebpfCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update IO and/or network stats", "err", err)
 			}
 		}()
@@ -332,7 +340,9 @@ func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update RDMA metrics
-			if err := c.rdmaCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(libvirtCollectorSubsystem, rdmaCollectorSubsystem, ch, func() error {
+				return c.rdmaCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update RDMA stats", "err", err)
 			}
 		}()
@@ -0,0 +1,445 @@
+//go:build !nonode
+// +build !nonode
+
+package collector
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	nodeCollectorSubsystem = "node"
+
+	// Metrics exported by node collector use the same namespace as node_exporter
+	// so that they can be used as a drop-in replacement.
+	nodeExporterNamespace = "node"
+)
+
+// Timeout for statfs calls on mount points. Mount points whose statfs calls
+// do not return within this timeout are ignored until they respond.
+const mountStatTimeout = 5 * time.Second
+
+// Default mount points and file system types that are excluded. These are
+// same as node_exporter's defaults.
+const (
+	defMountPointsExcluded = "^/(dev|proc|run/credentials/.+|sys|var/lib/docker/.+|var/lib/containers/storage/.+)($|/)"
+	defFSTypesExcluded     = "^(autofs|binfmt_misc|bpf|cgroup2?|configfs|debugfs|devpts|devtmpfs|fusectl|hugetlbfs|iso9660|mqueue|nsfs|overlay|proc|procfs|pstore|rootfs|rpc_pipefs|securityfs|selinuxfs|squashfs|sysfs|tracefs)$"
+)
+
+var (
+	nodeLoadavg = CEEMSExporterApp.Flag(
+		"collector.node.loadavg",
+		"Enables collecting load average metrics when node collector is enabled (default: enabled).",
+	).Default("true").Bool()
+	nodeMeminfo = CEEMSExporterApp.Flag(
+		"collector.node.meminfo",
+		"Enables collecting all memory metrics when node collector is enabled (default: enabled).",
+	).Default("true").Bool()
+	nodeFilesystem = CEEMSExporterApp.Flag(
+		"collector.node.filesystem",
+		"Enables collecting filesystem fill metrics when node collector is enabled (default: enabled).",
+	).Default("true").Bool()
+	nodeNetdev = CEEMSExporterApp.Flag(
+		"collector.node.netdev",
+		"Enables collecting network device totals when node collector is enabled (default: enabled).",
+	).Default("true").Bool()
+	nodeMountPointsExclude = CEEMSExporterApp.Flag(
+		"collector.node.filesystem.mount-points-exclude",
+		"Regexp of mount points to exclude for filesystem metrics.",
+	).Default(defMountPointsExcluded).String()
+	nodeFSTypesExclude = CEEMSExporterApp.Flag(
+		"collector.node.filesystem.fs-types-exclude",
+		"Regexp of filesystem types to exclude for filesystem metrics.",
+	).Default(defFSTypesExcluded).String()
+	nodeNetdevExclude = CEEMSExporterApp.Flag(
+		"collector.node.netdev.device-exclude",
+		"Regexp of network devices to exclude for network metrics (default: none).",
+	).Default("").String()
+)
+
+// mountPoint is a mount point read from mounts file.
+type mountPoint struct {
+	device     string
+	mountPoint string
+	fsType     string
+	options    string
+}
+
+type nodeCollector struct {
+	logger              *slog.Logger
+	fs                  procfs.FS
+	mountPointsExcluded *regexp.Regexp
+	fsTypesExcluded     *regexp.Regexp
+	netdevExcluded      *regexp.Regexp
+	stuckMounts         map[string]struct{}
+	stuckMountsMtx      sync.Mutex
+	load1               *prometheus.Desc
+	load5               *prometheus.Desc
+	load15              *prometheus.Desc
+	fsSize              *prometheus.Desc
+	fsFree              *prometheus.Desc
+	fsAvail             *prometheus.Desc
+	fsFiles             *prometheus.Desc
+	fsFilesFree         *prometheus.Desc
+	fsReadOnly          *prometheus.Desc
+	netdevDescs         map[string]*prometheus.Desc
+}
+
+func init() {
+	RegisterCollector(nodeCollectorSubsystem, defaultDisabled, NewNodeCollector)
+}
+
+// NewNodeCollector returns a new Collector exposing a subset of node_exporter's
+// metrics with compatible names.
+func NewNodeCollector(logger *slog.Logger) (Collector, error) {
+	fs, err := procfs.NewFS(*procfsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open procfs: %w", err)
+	}
+
+	mountPointsExcluded, err := regexp.Compile(*nodeMountPointsExclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex for mount points exclude: %w", err)
+	}
+
+	fsTypesExcluded, err := regexp.Compile(*nodeFSTypesExclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex for filesystem types exclude: %w", err)
+	}
+
+	var netdevExcluded *regexp.Regexp
+	if *nodeNetdevExclude != "" {
+		if netdevExcluded, err = regexp.Compile(*nodeNetdevExclude); err != nil {
+			return nil, fmt.Errorf("invalid regex for network devices exclude: %w", err)
+		}
+	}
+
+	fsLabels := []string{"device", "fstype", "mountpoint"}
+
+	netdevDescs := make(map[string]*prometheus.Desc)
+
+	for _, direction := range []string{"receive", "transmit"} {
+		for _, stat := range []string{"bytes", "packets", "errs", "drop"} {
+			name := fmt.Sprintf("%s_%s_total", direction, stat)
+			netdevDescs[name] = prometheus.NewDesc(
+				prometheus.BuildFQName(nodeExporterNamespace, "network", name),
+				fmt.Sprintf("Network device statistic %s_%s.", direction, stat),
+				[]string{"device"}, nil,
+			)
+		}
+	}
+
+	return &nodeCollector{
+		logger:              logger,
+		fs:                  fs,
+		mountPointsExcluded: mountPointsExcluded,
+		fsTypesExcluded:     fsTypesExcluded,
+		netdevExcluded:      netdevExcluded,
+		stuckMounts:         make(map[string]struct{}),
+		load1: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "", "load1"),
+			"1m load average.", nil, nil,
+		),
+		load5: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "", "load5"),
+			"5m load average.", nil, nil,
+		),
+		load15: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "", "load15"),
+			"15m load average.", nil, nil,
+		),
+		fsSize: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "size_bytes"),
+			"Filesystem size in bytes.", fsLabels, nil,
+		),
+		fsFree: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "free_bytes"),
+			"Filesystem free space in bytes.", fsLabels, nil,
+		),
+		fsAvail: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "avail_bytes"),
+			"Filesystem space available to non-root users in bytes.", fsLabels, nil,
+		),
+		fsFiles: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "files"),
+			"Filesystem total file nodes.", fsLabels, nil,
+		),
+		fsFilesFree: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "files_free"),
+			"Filesystem total free file nodes.", fsLabels, nil,
+		),
+		fsReadOnly: prometheus.NewDesc(
+			prometheus.BuildFQName(nodeExporterNamespace, "filesystem", "readonly"),
+			"Filesystem read-only status.", fsLabels, nil,
+		),
+		netdevDescs: netdevDescs,
+	}, nil
+}
+
+// Update implements Collector and exposes node metrics.
+func (c *nodeCollector) Update(ch chan<- prometheus.Metric) error {
+	var errs error
+
+	if *nodeLoadavg {
+		if err := c.updateLoadavg(ch); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("couldn't get load average: %w", err))
+		}
+	}
+
+	if *nodeMeminfo {
+		if err := c.updateMeminfo(ch); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("couldn't get meminfo: %w", err))
+		}
+	}
+
+	if *nodeFilesystem {
+		if err := c.updateFilesystem(ch); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("couldn't get filesystem stats: %w", err))
+		}
+	}
+
+	if *nodeNetdev {
+		if err := c.updateNetdev(ch); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("couldn't get network device stats: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// Stop releases system resources used by the collector.
+func (c *nodeCollector) Stop(_ context.Context) error {
+	c.logger.Debug("Stopping", "collector", nodeCollectorSubsystem)
+
+	return nil
+}
+
+// updateLoadavg exports load averages from /proc/loadavg.
+func (c *nodeCollector) updateLoadavg(ch chan<- prometheus.Metric) error {
+	loads, err := c.fs.LoadAvg()
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.load1, prometheus.GaugeValue, loads.Load1)
+	ch <- prometheus.MustNewConstMetric(c.load5, prometheus.GaugeValue, loads.Load5)
+	ch <- prometheus.MustNewConstMetric(c.load15, prometheus.GaugeValue, loads.Load15)
+
+	return nil
+}
+
+// updateMeminfo exports all fields from /proc/meminfo.
+func (c *nodeCollector) updateMeminfo(ch chan<- prometheus.Metric) error {
+	file, err := os.Open(procFilePath("meminfo"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	memInfo, err := parseMemInfo(file)
+	if err != nil {
+		return err
+	}
+
+	for k, v := range memInfo {
+		metricType := prometheus.GaugeValue
+		if strings.HasSuffix(k, "_total") {
+			metricType = prometheus.CounterValue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName(nodeExporterNamespace, "memory", k),
+				fmt.Sprintf("Memory information field %s.", k),
+				nil, nil,
+			),
+			metricType, v,
+		)
+	}
+
+	return nil
+}
+
+// updateFilesystem exports size and usage of mounted filesystems.
+func (c *nodeCollector) updateFilesystem(ch chan<- prometheus.Metric) error {
+	mounts, err := c.mountPoints()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(mounts))
+
+	for _, m := range mounts {
+		if c.mountPointsExcluded.MatchString(m.mountPoint) || c.fsTypesExcluded.MatchString(m.fsType) {
+			continue
+		}
+
+		// Same mount point can appear several times in mounts file
+		key := m.device + m.mountPoint
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		buf, ok := c.statfs(m.mountPoint)
+		if !ok {
+			continue
+		}
+
+		readOnly := 0.0
+		if strings.Contains(","+m.options+",", ",ro,") {
+			readOnly = 1
+		}
+
+		labels := []string{m.device, m.fsType, m.mountPoint}
+
+		ch <- prometheus.MustNewConstMetric(c.fsSize, prometheus.GaugeValue, float64(buf.Blocks)*float64(buf.Bsize), labels...)
+		ch <- prometheus.MustNewConstMetric(c.fsFree, prometheus.GaugeValue, float64(buf.Bfree)*float64(buf.Bsize), labels...)
+		ch <- prometheus.MustNewConstMetric(c.fsAvail, prometheus.GaugeValue, float64(buf.Bavail)*float64(buf.Bsize), labels...)
+		ch <- prometheus.MustNewConstMetric(c.fsFiles, prometheus.GaugeValue, float64(buf.Files), labels...)
+		ch <- prometheus.MustNewConstMetric(c.fsFilesFree, prometheus.GaugeValue, float64(buf.Ffree), labels...)
+		ch <- prometheus.MustNewConstMetric(c.fsReadOnly, prometheus.GaugeValue, readOnly, labels...)
+	}
+
+	return nil
+}
+
+// statfs returns filesystem stats of mount point. Stats of mount points that
+// do not respond within timeout, like unreachable network filesystems, are
+// skipped until the pending call returns.
+func (c *nodeCollector) statfs(mountPoint string) (unix.Statfs_t, bool) {
+	c.stuckMountsMtx.Lock()
+	if _, ok := c.stuckMounts[mountPoint]; ok {
+		c.stuckMountsMtx.Unlock()
+		c.logger.Debug("Mount point is in an unresponsive state", "mountpoint", mountPoint)
+
+		return unix.Statfs_t{}, false
+	}
+	c.stuckMountsMtx.Unlock()
+
+	type result struct {
+		buf unix.Statfs_t
+		err error
+	}
+
+	resultCh := make(chan result, 1)
+
+	go func() {
+		var buf unix.Statfs_t
+
+		err := unix.Statfs(rootfsFilePath(mountPoint), &buf)
+
+		// Remove from stuck mounts once the call returns
+		c.stuckMountsMtx.Lock()
+		delete(c.stuckMounts, mountPoint)
+		c.stuckMountsMtx.Unlock()
+
+		resultCh <- result{buf, err}
+	}()
+
+	select {
+	case r := <-resultCh:
+		if r.err != nil {
+			c.logger.Debug("Failed to get stats of mount point", "mountpoint", mountPoint, "err", r.err)
+
+			return unix.Statfs_t{}, false
+		}
+
+		return r.buf, true
+	case <-time.After(mountStatTimeout):
+		c.stuckMountsMtx.Lock()
+		c.stuckMounts[mountPoint] = struct{}{}
+		c.stuckMountsMtx.Unlock()
+
+		c.logger.Warn("Mount point timed out, it is being labeled as stuck and will not be monitored", "mountpoint", mountPoint)
+
+		return unix.Statfs_t{}, false
+	}
+}
+
+// mountPoints returns mount points of init process and falls back to mount
+// points of current process.
+func (c *nodeCollector) mountPoints() ([]mountPoint, error) {
+	file, err := os.Open(procFilePath("1/mounts"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(procFilePath("mounts"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseMounts(file)
+}
+
+// updateNetdev exports network device totals from /proc/net/dev.
+func (c *nodeCollector) updateNetdev(ch chan<- prometheus.Metric) error {
+	netDev, err := c.fs.NetDev()
+	if err != nil {
+		return err
+	}
+
+	for name, line := range netDev {
+		if c.netdevExcluded != nil && c.netdevExcluded.MatchString(name) {
+			continue
+		}
+
+		for stat, value := range map[string]uint64{
+			"receive_bytes_total":    line.RxBytes,
+			"receive_packets_total":  line.RxPackets,
+			"receive_errs_total":     line.RxErrors,
+			"receive_drop_total":     line.RxDropped,
+			"transmit_bytes_total":   line.TxBytes,
+			"transmit_packets_total": line.TxPackets,
+			"transmit_errs_total":    line.TxErrors,
+			"transmit_drop_total":    line.TxDropped,
+		} {
+			ch <- prometheus.MustNewConstMetric(c.netdevDescs[stat], prometheus.CounterValue, float64(value), name)
+		}
+	}
+
+	return nil
+}
+
+// parseMounts parses mounts file.
+func parseMounts(r io.Reader) ([]mountPoint, error) {
+	var mounts []mountPoint
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("malformed mount point information: %q", scanner.Text())
+		}
+
+		// Ensure we handle the translation of \040 and \011
+		// as per fstab(5).
+		parts[1] = strings.ReplaceAll(parts[1], "\\040", " ")
+		parts[1] = strings.ReplaceAll(parts[1], "\\011", "\t")
+
+		mounts = append(mounts, mountPoint{
+			device:     parts[0],
+			mountPoint: parts[1],
+			fsType:     parts[2],
+			options:    parts[3],
+		})
+	}
+
+	return mounts, scanner.Err()
+}
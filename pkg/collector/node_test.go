@@ -0,0 +1,105 @@
+//go:build !nonode
+// +build !nonode
+
+package collector
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeCollector(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse([]string{
+		"--path.procfs", "testdata/proc",
+		"--collector.node.netdev.device-exclude", "^lo$",
+	})
+	require.NoError(t, err)
+
+	collector, err := NewNodeCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// Setup background goroutine to capture metrics.
+	metrics := make(chan prometheus.Metric)
+	names := make(chan []string)
+
+	go func() {
+		var got []string
+		for m := range metrics {
+			got = append(got, m.Desc().String())
+		}
+		names <- got
+	}()
+
+	err = collector.Update(metrics)
+	require.NoError(t, err)
+	close(metrics)
+
+	got := strings.Join(<-names, "\n")
+
+	for _, name := range []string{
+		`"node_load1"`, `"node_load15"`, `"node_memory_MemTotal_bytes"`, `"node_memory_DirectMap2M_bytes"`,
+		`"node_filesystem_avail_bytes"`, `"node_network_receive_bytes_total"`, `"node_network_transmit_drop_total"`,
+	} {
+		assert.Contains(t, got, name)
+	}
+
+	err = collector.Stop(context.Background())
+	require.NoError(t, err)
+}
+
+func TestNodeCollectorDisabledSubsets(t *testing.T) {
+	_, err := CEEMSExporterApp.Parse([]string{
+		"--path.procfs", "testdata/proc",
+		"--no-collector.node.meminfo",
+		"--no-collector.node.filesystem",
+		"--no-collector.node.netdev",
+	})
+	require.NoError(t, err)
+
+	collector, err := NewNodeCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	metrics := make(chan prometheus.Metric, 100)
+
+	err = collector.Update(metrics)
+	require.NoError(t, err)
+	close(metrics)
+
+	// Only load averages must be exported
+	assert.Len(t, metrics, 3)
+}
+
+func TestParseMounts(t *testing.T) {
+	file, err := os.Open("testdata/proc/mounts")
+	require.NoError(t, err)
+	defer file.Close()
+
+	mounts, err := parseMounts(file)
+	require.NoError(t, err)
+	require.Len(t, mounts, 9)
+
+	assert.Equal(t, mountPoint{"/dev/sda3", "/data storage", "xfs", "rw,relatime"}, mounts[7])
+
+	c, err := NewNodeCollector(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	nc := c.(*nodeCollector)
+
+	var included []string
+
+	for _, m := range mounts {
+		if !nc.mountPointsExcluded.MatchString(m.mountPoint) && !nc.fsTypesExcluded.MatchString(m.fsType) {
+			included = append(included, m.mountPoint)
+		}
+	}
+
+	assert.Equal(t, []string{"/", "/run", "/home", "/data storage"}, included)
+}
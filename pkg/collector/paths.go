@@ -7,13 +7,18 @@ import (
 var (
 	// The path of the proc filesystem.
 	sysPath    = CEEMSExporterApp.Flag("path.sysfs", "sysfs mountpoint.").Hidden().Default("/sys").String()
-	procfsPath = CEEMSExporterApp.Flag("path.procfs", "procfs mountpoint.").
-			Hidden().
-			Default("/proc").
-			String()
-	cgroupfsPath = CEEMSExporterApp.Flag("path.cgroupfs", "cgroupfs mountpoint.").Hidden().
-			Default("/sys/fs/cgroup").
-			String()
+	procfsPath = CEEMSExporterApp.Flag(
+		"path.procfs",
+		"procfs mountpoint. Set it to host's procfs mountpoint when running exporter inside a container.",
+	).Default("/proc").String()
+	cgroupfsPath = CEEMSExporterApp.Flag(
+		"path.cgroupfs",
+		"cgroupfs mountpoint. Set it to host's cgroupfs mountpoint when running exporter inside a container.",
+	).Default("/sys/fs/cgroup").String()
+	rootfsPath = CEEMSExporterApp.Flag(
+		"path.rootfs",
+		"rootfs mountpoint. Set it to host's rootfs mountpoint when running exporter inside a container.",
+	).Default("/").String()
 )
 
 // sysFilePath returns the sub directory of sys fs.
@@ -30,3 +35,8 @@ func procFilePath(name string) string {
 func cgroupFilePath(name string) string {
 	return filepath.Join(*cgroupfsPath, name)
 }
+
+// rootfsFilePath returns the path relative to root fs.
+func rootfsFilePath(name string) string {
+	return filepath.Join(*rootfsPath, name)
+}
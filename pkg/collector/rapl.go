@@ -29,6 +29,15 @@ type raplCollector struct {
 	securityContexts map[string]*security.SecurityContext
 	joulesMetricDesc *prometheus.Desc
 	wattsMetricDesc  *prometheus.Desc
+	countersMu       sync.Mutex
+	counters         map[string]*raplCounter
+}
+
+// raplCounter keeps track of energy counter of a RAPL zone across scrapes
+// to account for counter wraparounds.
+type raplCounter struct {
+	last   uint64
+	offset uint64
 }
 
 // Security context names.
@@ -101,6 +110,7 @@ func NewRaplCollector(logger *slog.Logger) (Collector, error) {
 		securityContexts: securityContexts,
 		joulesMetricDesc: joulesMetricDesc,
 		wattsMetricDesc:  wattsMetricDesc,
+		counters:         make(map[string]*raplCounter),
 	}
 
 	return &collector, nil
@@ -208,8 +218,11 @@ func (c *raplCollector) updateEnergy(zones []sysfs.RaplZone, ch chan<- prometheu
 		return ErrNoData
 	}
 
+	c.countersMu.Lock()
+	defer c.countersMu.Unlock()
+
 	for rz, microJoules := range dataPtr.counters {
-		joules := float64(microJoules) / 1000000.0
+		joules := float64(c.monotonicCounter(rz, microJoules)) / 1000000.0
 
 		if *raplZoneLabel {
 			ch <- c.joulesMetricWithZoneLabel(rz, joules)
@@ -221,6 +234,33 @@ func (c *raplCollector) updateEnergy(zones []sysfs.RaplZone, ch chan<- prometheu
 	return nil
 }
 
+// monotonicCounter returns the energy counter of the zone corrected for
+// wraparounds. RAPL counters wrap to zero once they reach max_energy_range_uj
+// and exposing raw values would result in spikes in rate() queries.
+func (c *raplCollector) monotonicCounter(rz sysfs.RaplZone, microJoules uint64) uint64 {
+	counter, ok := c.counters[rz.Path]
+	if !ok {
+		c.counters[rz.Path] = &raplCounter{last: microJoules}
+
+		return microJoules
+	}
+
+	if microJoules < counter.last {
+		if rz.MaxMicrojoules > 0 {
+			counter.offset += rz.MaxMicrojoules
+		} else {
+			// When range is unknown, best we can do is to continue from last value
+			counter.offset += counter.last
+		}
+
+		c.logger.Debug("RAPL energy counter wraparound detected", "zone", rz.Name, "path", rz.Path)
+	}
+
+	counter.last = microJoules
+
+	return counter.offset + microJoules
+}
+
 func (c *raplCollector) wattsMetric(z sysfs.RaplZone, v float64) prometheus.Metric {
 	index := strconv.Itoa(z.Index)
 	descriptor := prometheus.NewDesc(
@@ -81,3 +81,18 @@ func TestRaplMetrics(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectedPowerLimits, powerLimits)
 }
+
+func TestRaplCounterWraparound(t *testing.T) {
+	c := raplCollector{
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		counters: make(map[string]*raplCounter),
+	}
+	rz := sysfs.RaplZone{Name: "package", Path: "intel-rapl:0", MaxMicrojoules: 1000}
+
+	assert.Equal(t, uint64(900), c.monotonicCounter(rz, 900))
+	assert.Equal(t, uint64(950), c.monotonicCounter(rz, 950))
+
+	// Counter wraps around
+	assert.Equal(t, uint64(1050), c.monotonicCounter(rz, 50))
+	assert.Equal(t, uint64(1100), c.monotonicCounter(rz, 100))
+}
@@ -7,43 +7,20 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net"
-	"net/url"
-	"path/filepath"
+	"net/http"
 	"strings"
 	"time"
 
-	"github.com/mahendrapaipuri/ceems/internal/common"
-	"github.com/mahendrapaipuri/ceems/pkg/ipmi"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/common/config"
 	"github.com/stmcginnis/gofish"
 	"github.com/stmcginnis/gofish/redfish"
 )
 
-const redfishCollectorSubsystem = "redfish"
-
-// Header names.
 const (
-	redfishURLHeaderName = "X-Redfish-Url"
+	redfishCollectorSubsystem = "redfish"
+	redfishProbeTimeout       = 5 * time.Second
 )
 
-const hostnamePlaceholder = "{hostname}"
-
-type redfishConfig struct {
-	Web struct {
-		Proto        string `yaml:"protocol"`
-		Hostname     string `yaml:"hostname"`
-		Port         int    `yaml:"port"`
-		URL          *url.URL
-		ExternalURL  string `yaml:"external_url"`
-		Username     string `yaml:"username"`
-		Password     string `yaml:"password"`
-		InSecure     bool   `yaml:"insecure_skip_verify"`
-		SessionToken bool   `yaml:"use_session_token"`
-	} `yaml:"redfish_web_config"`
-}
-
 type redfishCollector struct {
 	logger      *slog.Logger
 	hostname    string
@@ -61,6 +38,40 @@ var redfishConfigFile = CEEMSExporterApp.Flag(
 
 func init() {
 	RegisterCollector(redfishCollectorSubsystem, defaultDisabled, NewRedfishCollector)
+
+	bmcProbes["redfish"] = redfishBMCReachable
+}
+
+// redfishBMCReachable returns true if service root of Redfish API server is
+// reachable. ok is false when Redfish web config is not provided.
+func redfishBMCReachable(logger *slog.Logger) (bool, bool) {
+	if *redfishConfigFile == "" {
+		return false, false
+	}
+
+	config, err := newRedfishClientConfig(*redfishConfigFile, logger)
+	if err != nil {
+		return false, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redfishProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(config.Endpoint, "/")+"/redfish/v1/", nil)
+	if err != nil {
+		return false, true
+	}
+
+	resp, err := config.HTTPClient.Do(req)
+	if err != nil {
+		logger.Debug("Redfish API server is not reachable", "err", err)
+
+		return false, true
+	}
+
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK, true
 }
 
 // NewRedfishCollector returns a new Collector to fetch power usage from redfish API.
@@ -85,96 +96,16 @@ func NewRedfishCollector(logger *slog.Logger) (Collector, error) {
 		),
 	}
 
-	// Get absolute config file path
-	configFilePath, err := filepath.Abs(*redfishConfigFile)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path of the config file: %w", err)
-	}
-
-	// Make config from file
-	cfg, err := common.MakeConfig[redfishConfig](configFilePath)
-	if err != nil {
-		logger.Error("Failed to parse Redfish config file", "err", err)
-
-		return nil, fmt.Errorf("failed to parse Redfish config file: %w", err)
-	}
-
-	// If BMC Hostname is not provided, attempt to discover it using OpenIPMI interface
-	if cfg.Web.Hostname == "" {
-		// Make a new IPMI client
-		if client, err := ipmi.NewIPMIClient(0, logger.With("subsystem", "ipmi_client")); err == nil {
-			// Attempt to get new IP address
-			if bmcIP, err := client.LanIP(time.Second); err == nil {
-				// Attempt to get BMC hostname from IP
-				if hostname, err := net.LookupAddr(*bmcIP); err == nil {
-					cfg.Web.Hostname = hostname[0]
-				} else {
-					cfg.Web.Hostname = *bmcIP
-				}
-			}
-			defer client.Close()
-		}
-	}
-
-	// If cfg.Web.Hostname has {hostname} placeholder, replace it with current host name
-	cfg.Web.Hostname = strings.Replace(cfg.Web.Hostname, hostnamePlaceholder, hostname, -1)
-
-	// Build Redfish URL
-	cfg.Web.URL, err = url.Parse(fmt.Sprintf("%s://%s:%d", cfg.Web.Proto, cfg.Web.Hostname, cfg.Web.Port))
+	// Make Redfish client config from config file
+	config, err := newRedfishClientConfig(*redfishConfigFile, logger)
 	if err != nil {
-		logger.Error("Failed to build Redfish URL", "err", err)
-
-		return nil, fmt.Errorf("invalid redfish web config: %w", err)
-	}
-
-	logger.Debug("Redfish URL", "url", cfg.Web.URL.String())
-
-	// Make a new HTTP client config
-	clientConfig := config.HTTPClientConfig{
-		TLSConfig: config.TLSConfig{
-			InsecureSkipVerify: cfg.Web.InSecure,
-		},
-		HTTPHeaders: &config.Headers{
-			Headers: map[string]config.Header{
-				redfishURLHeaderName: {
-					Values: []string{cfg.Web.URL.String()},
-				},
-			},
-		},
-	}
-
-	// Get the URL that client will talk to
-	// If external URL is provided, always prefer it over the raw BMC hostname and port
-	var endpoint string
-	if cfg.Web.ExternalURL != "" {
-		endpoint = cfg.Web.ExternalURL
-	} else {
-		endpoint = cfg.Web.URL.String()
-	}
-
-	// Make a HTTP client from client config
-	httpClient, err := config.NewClientFromConfig(clientConfig, "redfish")
-	if err != nil {
-		logger.Error("Failed to create a HTTP client for Redfish", "err", err)
-
-		return nil, fmt.Errorf("failed to create a HTTP client for Redfish: %w", err)
-	}
-
-	// Create a redfish client
-	config := gofish.ClientConfig{
-		Endpoint:         endpoint,
-		Username:         cfg.Web.Username,
-		Password:         cfg.Web.Password,
-		Insecure:         cfg.Web.InSecure,
-		BasicAuth:        !cfg.Web.SessionToken,
-		HTTPClient:       httpClient,
-		ReuseConnections: true,
+		return nil, err
 	}
 
 	collector := redfishCollector{
 		logger:      logger,
 		hostname:    hostname,
-		config:      &config,
+		config:      config,
 		cachedPower: make(map[string]*redfish.Power),
 		metricDesc:  metricDesc,
 	}
@@ -246,7 +177,7 @@ func (c *redfishCollector) powerReadings() map[string]map[string]float64 {
 	for _, chass := range c.chassis {
 		chassisID := SanitizeMetricName(chass.ID)
 
-		power, err := chass.Power()
+		power, err := c.chassisPower(chass)
 		if err != nil {
 			c.logger.Error(
 				"Failed to get power statistics from Redfish. Using last cached values",
@@ -281,3 +212,60 @@ func (c *redfishCollector) powerReadings() map[string]map[string]float64 {
 
 	return values
 }
+
+// chassisPower returns the power stats of chassis. When chassis does not expose the
+// deprecated Power resource, power consumption is read from the EnvironmentMetrics
+// or, if unavailable, estimated as the total input power of power supplies of
+// PowerSubsystem. Only current power consumption is available in these cases.
+func (c *redfishCollector) chassisPower(chass *redfish.Chassis) (*redfish.Power, error) {
+	power, err := chass.Power()
+	if err != nil || (power != nil && len(power.PowerControl) > 0) {
+		return power, err
+	}
+
+	var watts float64
+
+	// EnvironmentMetrics reports the total power of chassis
+	metrics, err := chass.EnvironmentMetrics()
+	if err != nil {
+		return nil, err
+	}
+
+	if metrics != nil && metrics.PowerWatts.Reading > 0 {
+		watts = float64(metrics.PowerWatts.Reading)
+	} else {
+		subsystem, err := chass.PowerSubsystem()
+		if err != nil {
+			return nil, err
+		}
+
+		if subsystem == nil {
+			return nil, nil //nolint:nilnil
+		}
+
+		supplies, err := subsystem.PowerSupplies()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, supply := range supplies {
+			unit, err := redfish.GetPowerSupplyUnit(chass.GetClient(), supply.ODataID)
+			if err != nil {
+				return nil, err
+			}
+
+			unitMetrics, err := unit.Metrics()
+			if err != nil {
+				return nil, err
+			}
+
+			if unitMetrics != nil {
+				watts += float64(unitMetrics.InputPowerWatts.Reading)
+			}
+		}
+	}
+
+	return &redfish.Power{
+		PowerControl: []redfish.PowerControl{{PowerConsumedWatts: float32(watts)}},
+	}, nil
+}
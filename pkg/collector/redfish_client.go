@@ -0,0 +1,127 @@
+package collector
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/ipmi"
+	"github.com/prometheus/common/config"
+	"github.com/stmcginnis/gofish"
+)
+
+// Header names.
+const (
+	redfishURLHeaderName = "X-Redfish-Url"
+)
+
+const hostnamePlaceholder = "{hostname}"
+
+type redfishConfig struct {
+	Web struct {
+		Proto        string   `yaml:"protocol"`
+		Hostname     string   `yaml:"hostname"`
+		Port         int      `yaml:"port"`
+		URL          *url.URL `yaml:"-"`
+		ExternalURL  string   `yaml:"external_url"`
+		Username     string   `yaml:"username"`
+		Password     string   `yaml:"password"`
+		InSecure     bool     `yaml:"insecure_skip_verify"`
+		SessionToken bool     `yaml:"use_session_token"`
+	} `yaml:"redfish_web_config"`
+}
+
+// newRedfishClientConfig returns Redfish client config made from the config file.
+// It is shared by all the collectors that talk to Redfish API.
+func newRedfishClientConfig(configFile string, logger *slog.Logger) (*gofish.ClientConfig, error) {
+	// Get absolute config file path
+	configFilePath, err := filepath.Abs(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path of the config file: %w", err)
+	}
+
+	// Make config from file
+	cfg, err := common.MakeConfig[redfishConfig](configFilePath)
+	if err != nil {
+		logger.Error("Failed to parse Redfish config file", "err", err)
+
+		return nil, fmt.Errorf("failed to parse Redfish config file: %w", err)
+	}
+
+	// If BMC Hostname is not provided, attempt to discover it using OpenIPMI interface
+	if cfg.Web.Hostname == "" {
+		// Make a new IPMI client
+		if client, err := ipmi.NewIPMIClient(0, logger.With("subsystem", "ipmi_client")); err == nil {
+			// Attempt to get new IP address
+			if bmcIP, err := client.LanIP(time.Second); err == nil {
+				// Attempt to get BMC hostname from IP
+				if hostname, err := net.LookupAddr(*bmcIP); err == nil {
+					cfg.Web.Hostname = hostname[0]
+				} else {
+					cfg.Web.Hostname = *bmcIP
+				}
+			}
+			defer client.Close()
+		}
+	}
+
+	// If cfg.Web.Hostname has {hostname} placeholder, replace it with current host name
+	cfg.Web.Hostname = strings.Replace(cfg.Web.Hostname, hostnamePlaceholder, hostname, -1)
+
+	// Build Redfish URL
+	cfg.Web.URL, err = url.Parse(fmt.Sprintf("%s://%s:%d", cfg.Web.Proto, cfg.Web.Hostname, cfg.Web.Port))
+	if err != nil {
+		logger.Error("Failed to build Redfish URL", "err", err)
+
+		return nil, fmt.Errorf("invalid redfish web config: %w", err)
+	}
+
+	logger.Debug("Redfish URL", "url", cfg.Web.URL.String())
+
+	// Make a new HTTP client config
+	clientConfig := config.HTTPClientConfig{
+		TLSConfig: config.TLSConfig{
+			InsecureSkipVerify: cfg.Web.InSecure,
+		},
+		HTTPHeaders: &config.Headers{
+			Headers: map[string]config.Header{
+				redfishURLHeaderName: {
+					Values: []string{cfg.Web.URL.String()},
+				},
+			},
+		},
+	}
+
+	// Get the URL that client will talk to
+	// If external URL is provided, always prefer it over the raw BMC hostname and port
+	var endpoint string
+	if cfg.Web.ExternalURL != "" {
+		endpoint = cfg.Web.ExternalURL
+	} else {
+		endpoint = cfg.Web.URL.String()
+	}
+
+	// Make a HTTP client from client config
+	httpClient, err := config.NewClientFromConfig(clientConfig, "redfish")
+	if err != nil {
+		logger.Error("Failed to create a HTTP client for Redfish", "err", err)
+
+		return nil, fmt.Errorf("failed to create a HTTP client for Redfish: %w", err)
+	}
+
+	// Create a redfish client
+	return &gofish.ClientConfig{
+		Endpoint:         endpoint,
+		Username:         cfg.Web.Username,
+		Password:         cfg.Web.Password,
+		Insecure:         cfg.Web.InSecure,
+		BasicAuth:        !cfg.Web.SessionToken,
+		HTTPClient:       httpClient,
+		ReuseConnections: true,
+	}, nil
+}
@@ -307,3 +307,100 @@ func TestPowerReadings(t *testing.T) {
 	got = collector.powerReadings()
 	assert.EqualValues(t, expected, got)
 }
+
+func TestPowerReadingsPowerSubsystem(t *testing.T) {
+	prefix := "/redfish/v1/Chassis/Chassis-2"
+	files := map[string]string{
+		"/redfish/v1/":                           "service_root.json",
+		prefix:                                   "chassis_power_subsystem.json",
+		prefix + "/PowerSubsystem":               "power_subsystem.json",
+		prefix + "/PowerSubsystem/PowerSupplies": "power_supplies.json",
+		prefix + "/PowerSubsystem/PowerSupplies/0":         "power_supply_0.json",
+		prefix + "/PowerSubsystem/PowerSupplies/1":         "power_supply_1.json",
+		prefix + "/PowerSubsystem/PowerSupplies/0/Metrics": "power_supply_0_metrics.json",
+		prefix + "/PowerSubsystem/PowerSupplies/1/Metrics": "power_supply_1_metrics.json",
+		prefix + "/EnvironmentMetrics":                     "environment_metrics.json",
+	}
+
+	// Start a dummy Redfish server that does not expose EnvironmentMetrics at first
+	var withEnvMetrics bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix+"/EnvironmentMetrics" && !withEnvMetrics {
+			w.Write([]byte(`{"@odata.id": "` + r.URL.Path + `", "Id": "EnvironmentMetrics"}`))
+
+			return
+		}
+
+		if data, err := os.ReadFile(filepath.Join("testdata/redfish", files[r.URL.Path])); err == nil {
+			w.Write(data)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := gofish.ClientConfig{Endpoint: server.URL}
+	redfishClient, err := gofish.Connect(config)
+	require.NoError(t, err)
+
+	chass, err := redfish.GetChassis(redfishClient, prefix)
+	require.NoError(t, err)
+
+	collector := &redfishCollector{
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		config:      &config,
+		chassis:     []*redfish.Chassis{chass},
+		client:      redfishClient,
+		cachedPower: make(map[string]*redfish.Power),
+	}
+
+	// Power must be sum of input power of power supplies
+	got := collector.powerReadings()
+	assert.InEpsilon(t, 395, got["current"]["Chassis_2"], 0)
+	assert.Empty(t, got["avg"]["Chassis_2"])
+
+	// Power from EnvironmentMetrics must be preferred when available
+	withEnvMetrics = true
+
+	got = collector.powerReadings()
+	assert.InEpsilon(t, 374.5, got["current"]["Chassis_2"], 0)
+}
+
+func TestRedfishBMCReachable(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Start a dummy Redfish server
+	server := testRedfishServer()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	// Make config file
+	configFileTmpl := `
+---
+redfish_web_config:
+  protocol: http
+  hostname: %s
+  port: %s`
+
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	configFile := fmt.Sprintf(configFileTmpl, serverURL.Hostname(), serverURL.Port())
+	require.NoError(t, os.WriteFile(configPath, []byte(configFile), 0o600))
+
+	_, err = CEEMSExporterApp.Parse([]string{"--collector.redfish.web-config", configPath})
+	require.NoError(t, err)
+
+	reachable, ok := redfishBMCReachable(logger)
+	assert.True(t, ok)
+	assert.True(t, reachable)
+
+	// Unreachable server
+	server.Close()
+
+	reachable, ok = redfishBMCReachable(logger)
+	assert.True(t, ok)
+	assert.False(t, reachable)
+}
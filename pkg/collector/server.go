@@ -27,6 +27,7 @@ type WebConfig struct {
 	MaxRequests            int
 	IncludeExporterMetrics bool
 	EnableDebugServer      bool
+	EnableUnitsAPI         bool
 	LandingConfig          *web.LandingConfig
 }
 
@@ -137,6 +138,12 @@ func NewCEEMSExporterServer(c *Config) (*CEEMSExporterServer, error) {
 	// Handle targets path
 	router.Handle(c.Web.TargetsPath, server.newTargetsHandler())
 
+	// If EnableUnitsAPI is true add units API endpoint. Handler serves
+	// only requests from localhost
+	if c.Web.EnableUnitsAPI {
+		router.Handle("/api/v1/units", server.newUnitsHandler()).Methods(http.MethodGet)
+	}
+
 	// If EnableDebugServer is true add debug endpoints
 	if c.Web.EnableDebugServer {
 		// pprof debug end points. Expose them only on localhost
@@ -217,6 +224,17 @@ func (s *CEEMSExporterServer) newMetricsHandler() http.Handler {
 	return handler
 }
 
+// newUnitsHandler creates a new handler for listing compute units.
+func (s *CEEMSExporterServer) newUnitsHandler() http.Handler {
+	return UnitsHandlerFor(
+		s.metricsHandler.metricsRegistry,
+		promhttp.HandlerOpts{
+			ErrorLog:            slog.NewLogLogger(s.logger.Handler(), slog.LevelError),
+			MaxRequestsInFlight: s.metricsHandler.maxRequests,
+		},
+	)
+}
+
 // newTargetsHandler creates a new handler for exporting Grafana Alloy targets.
 func (s *CEEMSExporterServer) newTargetsHandler() http.Handler {
 	return TargetsHandlerFor(
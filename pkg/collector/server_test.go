@@ -105,6 +105,35 @@ func TestCEEMSExporterServer(t *testing.T) {
 					path:     "/debug/pprof/",
 					respCode: 200,
 				},
+				{
+					path:     "/api/v1/units",
+					respCode: 404,
+				},
+			},
+		},
+		{
+			name: "metrics with units API",
+			config: &Config{
+				Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+				Collector: &CEEMSCollector{},
+				Web: WebConfig{
+					MetricsPath:    "/metrics",
+					MaxRequests:    5,
+					EnableUnitsAPI: true,
+					LandingConfig: &web.LandingConfig{
+						Name: "CEEMS Exporter",
+					},
+				},
+			},
+			reqs: []req{
+				{
+					path:     "/metrics",
+					respCode: 200,
+				},
+				{
+					path:     "/api/v1/units",
+					respCode: 200,
+				},
 			},
 		},
 	}
@@ -15,7 +15,9 @@ import (
 
 	"github.com/mahendrapaipuri/ceems/internal/security"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/procfs"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
@@ -48,6 +50,17 @@ var (
 		`GPU order mapping between SLURM and NVIDIA SMI/ROCm SMI tools. 
 It should be of format <slurm_gpu_index>: <nvidia_or_rocm_smi_index>[.<mig_gpu_instance_id>] delimited by ",".`,
 	).Default("").PlaceHolder("0:1,1:0.3,2:0.4,3:0.5,4:0.6").String()
+
+	// Metadata opts.
+	slurmMetadataLabels = CEEMSExporterApp.Flag(
+		"collector.slurm.metadata-label",
+		`Attach job metadata as labels to all job metrics. Repeat the flag to add multiple labels (default: disabled).
+Supported labels:
+	- "user": Name of the user that owns the job (SLURM_JOB_USER)
+	- "project": Account of the job (SLURM_JOB_ACCOUNT)
+	- "exclusive": "true" when job is allocated all CPUs of the node (SLURM_CPUS_ON_NODE) and "false" otherwise
+WARNING: "user" and "project" labels increase cardinality of the metrics on TSDB. Use them only when CEEMS API server is not deployed.`,
+	).Enums("user", "project", "exclusive")
 )
 
 // Security context names.
@@ -58,15 +71,25 @@ const (
 // slurmReadProcSecurityCtxData contains the input/output data for
 // reading processes inside a security context.
 type slurmReadProcSecurityCtxData struct {
-	procs       []procfs.Proc
-	uuid        string
-	gpuOrdinals []string
+	procs         []procfs.Proc
+	uuid          string
+	metadata      bool
+	accelNodes    map[string]string
+	gpuOrdinals   []string
+	accelOrdinals []string
+	user          string
+	project       string
+	cpusOnNode    int
 }
 
 // jobProps contains SLURM job properties.
 type jobProps struct {
-	uuid        string   // This is SLURM's job ID
-	gpuOrdinals []string // GPU ordinals bound to job
+	uuid          string   // This is SLURM's job ID
+	gpuOrdinals   []string // GPU ordinals bound to job
+	accelOrdinals []string // Accelerator ordinals used by job
+	user          string   // Job user. Only populated when metadata labels are enabled
+	project       string   // Job account. Only populated when metadata labels are enabled
+	exclusive     string   // Whether job has exclusive node allocation. Only populated when metadata labels are enabled
 }
 
 // emptyGPUOrdinals returns true if gpuOrdinals is empty.
@@ -74,6 +97,59 @@ func (p *jobProps) emptyGPUOrdinals() bool {
 	return len(p.gpuOrdinals) == 0
 }
 
+// emptyAccelOrdinals returns true if accelOrdinals is empty.
+func (p *jobProps) emptyAccelOrdinals() bool {
+	return len(p.accelOrdinals) == 0
+}
+
+// emptyMetadata returns true if user, project and exclusive are empty.
+func (p *jobProps) emptyMetadata() bool {
+	return p.user == "" && p.project == "" && p.exclusive == ""
+}
+
+// metadataLabels returns label pairs of metadata labels for job.
+func (p *jobProps) metadataLabels(labels []string) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+
+	for _, label := range labels {
+		var value string
+
+		switch label {
+		case "user":
+			value = p.user
+		case "project":
+			value = p.project
+		case "exclusive":
+			value = p.exclusive
+		}
+
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(label), Value: proto.String(value)})
+	}
+
+	return pairs
+}
+
+// labelledMetric wraps a metric and adds extra label pairs when writing it.
+type labelledMetric struct {
+	prometheus.Metric
+	labels []*dto.LabelPair
+}
+
+// Write implements prometheus.Metric.
+func (m *labelledMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+
+	// Label pairs must be sorted by name
+	out.Label = append(out.Label, m.labels...)
+	slices.SortFunc(out.Label, func(a, b *dto.LabelPair) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+
+	return nil
+}
+
 type slurmMetrics struct {
 	cgMetrics []cgMetric
 	jobProps  []jobProps
@@ -87,12 +163,17 @@ type slurmCollector struct {
 	perfCollector    *perfCollector
 	ebpfCollector    *ebpfCollector
 	rdmaCollector    *rdmaCollector
+	dcgmCollector    *dcgmCollector
 	hostname         string
 	gpuDevs          []Device
+	accelDevs        []Accelerator
 	procFS           procfs.FS
+	nodeCPUs         int
 	jobGpuFlag       *prometheus.Desc
+	jobAccelFlag     *prometheus.Desc
 	collectError     *prometheus.Desc
 	jobPropsCache    map[string]jobProps
+	metadataLabels   []string
 	securityContexts map[string]*security.SecurityContext
 }
 
@@ -111,6 +192,15 @@ func NewSlurmCollector(logger *slog.Logger) (Collector, error) {
 		logger.Warn("flag --collector.slurm.swap.memory.metrics has been deprecated. Use --collector.slurm.swap-memory-metrics instead")
 	}
 
+	// Warn about cardinality when metadata labels are enabled
+	metadataLabels := slices.Compact(slices.Sorted(slices.Values(*slurmMetadataLabels)))
+	if len(metadataLabels) > 0 {
+		logger.Warn(
+			"Job metadata labels enabled. This increases cardinality of metrics on TSDB",
+			"labels", strings.Join(metadataLabels, ","),
+		)
+	}
+
 	// Get SLURM's cgroup details
 	cgroupManager, err := NewCgroupManager("slurm", logger)
 	if err != nil {
@@ -199,6 +289,27 @@ func NewSlurmCollector(logger *slog.Logger) (Collector, error) {
 		logger.Debug("GPUs reindexed")
 	}
 
+	// Start new instance of dcgmCollector
+	var dcgmCollector *dcgmCollector
+
+	if dcgmCollectorEnabled() {
+		dcgmCollector, err = NewDCGMCollector(logger.With("sub_collector", "dcgm"), cgroupManager, gpuDevs)
+		if err != nil {
+			logger.Info("Failed to create DCGM collector", "err", err)
+
+			return nil, err
+		}
+	}
+
+	// Attempt to get accelerator devices only when accelerator collector
+	// is enabled as there is no use of job to accelerator map without
+	// accelerator metrics
+	var accelDevs []Accelerator
+
+	if acceleratorCollectorEnabled() {
+		accelDevs = GetAcceleratorDevices(logger)
+	}
+
 	// Instantiate a new Proc FS
 	procFS, err := procfs.NewFS(*procfsPath)
 	if err != nil {
@@ -207,8 +318,20 @@ func NewSlurmCollector(logger *slog.Logger) (Collector, error) {
 		return nil, err
 	}
 
+	// Get number of CPUs of the node to identify jobs with exclusive node allocation
+	var nodeCPUs int
+
+	if slices.Contains(metadataLabels, "exclusive") {
+		if cpuInfo, err := procFS.CPUInfo(); err == nil {
+			nodeCPUs = len(cpuInfo)
+		} else {
+			logger.Warn("Failed to get number of CPUs on node. Exclusive label will be empty", "err", err)
+		}
+	}
+
 	// Setup necessary capabilities. These are the caps we need to read
-	// env vars in /proc file system to get SLURM job GPU indices
+	// env vars and file descriptors in /proc file system to get SLURM job
+	// GPU and accelerator indices
 	caps := setupCollectorCaps(logger, slurmCollectorSubsystem, []string{"cap_sys_ptrace", "cap_dac_read_search"})
 
 	// Setup new security context(s)
@@ -225,10 +348,14 @@ func NewSlurmCollector(logger *slog.Logger) (Collector, error) {
 		perfCollector:    perfCollector,
 		ebpfCollector:    ebpfCollector,
 		rdmaCollector:    rdmaCollector,
+		dcgmCollector:    dcgmCollector,
 		hostname:         hostname,
 		gpuDevs:          gpuDevs,
+		accelDevs:        accelDevs,
 		procFS:           procFS,
+		nodeCPUs:         nodeCPUs,
 		jobPropsCache:    make(map[string]jobProps),
+		metadataLabels:   metadataLabels,
 		securityContexts: map[string]*security.SecurityContext{slurmReadProcCtx: securityCtx},
 		jobGpuFlag: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, genericSubsystem, "unit_gpu_index_flag"),
@@ -243,6 +370,20 @@ func NewSlurmCollector(logger *slog.Logger) (Collector, error) {
 			},
 			nil,
 		),
+		jobAccelFlag: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, genericSubsystem, "unit_accelerator_index_flag"),
+			"A value > 0 indicates the job using current accelerator",
+			[]string{
+				"manager",
+				"hostname",
+				"uuid",
+				"vendor",
+				"index",
+				"hindex",
+				"accelid",
+			},
+			nil,
+		),
 		collectError: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, genericSubsystem, "collect_error"),
 			"Indicates collection error, 0=no error, 1=error",
@@ -261,6 +402,14 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 		return err
 	}
 
+	// Add metadata labels to metrics if enabled
+	if len(c.metadataLabels) > 0 {
+		var done func()
+
+		ch, done = c.withMetadataLabels(ch, metrics.jobProps)
+		defer done()
+	}
+
 	// Start a wait group
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -269,7 +418,11 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 		defer wg.Done()
 
 		// Update cgroup metrics
-		if err := c.cgroupCollector.Update(ch, metrics.cgMetrics); err != nil {
+		if err := executeSub(slurmCollectorSubsystem, cgroupCollectorSubsystem, ch, func() error {
+			c.cgroupCollector.updateActiveCgroups(ch, metrics.cgroups)
+
+			return c.cgroupCollector.Update(ch, metrics.cgMetrics)
+		}); err != nil {
 			c.logger.Error("Failed to update cgroup stats", "err", err)
 		}
 
@@ -277,6 +430,11 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 		if len(c.gpuDevs) > 0 {
 			c.updateGPUOrdinals(ch, metrics.jobProps)
 		}
+
+		// Update slurm job accelerator ordinals
+		if len(c.accelDevs) > 0 {
+			c.updateAccelOrdinals(ch, metrics.jobProps)
+		}
 	}()
 
 	if perfCollectorEnabled() {
@@ -286,7 +444,9 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update perf metrics
-			if err := c.perfCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(slurmCollectorSubsystem, perfCollectorSubsystem, ch, func() error {
+				return c.perfCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update perf stats", "err", err)
 			}
 		}()
@@ -299,7 +459,9 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update ebpf metrics
-			if err := c.ebpfCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(slurmCollectorSubsystem, ebpfCollectorSubsystem, ch, func() error {
+				return c.ebpfCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update IO and/or network stats", "err", err)
 			}
 		}()
@@ -312,12 +474,38 @@ func (c *slurmCollector) Update(ch chan<- prometheus.Metric) error {
 			defer wg.Done()
 
 			// Update RDMA metrics
-			if err := c.rdmaCollector.Update(ch, metrics.cgroups); err != nil {
+			if err := executeSub(slurmCollectorSubsystem, rdmaCollectorSubsystem, ch, func() error {
+				return c.rdmaCollector.Update(ch, metrics.cgroups)
+			}); err != nil {
 				c.logger.Error("Failed to update RDMA stats", "err", err)
 			}
 		}()
 	}
 
+	if dcgmCollectorEnabled() {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			// Make a map of GPU ordinals of jobs
+			gpuOrdinals := make(map[string][]string)
+
+			for _, p := range metrics.jobProps {
+				if !p.emptyGPUOrdinals() {
+					gpuOrdinals[p.uuid] = p.gpuOrdinals
+				}
+			}
+
+			// Update DCGM metrics
+			if err := executeSub(slurmCollectorSubsystem, dcgmCollectorSubsystem, ch, func() error {
+				return c.dcgmCollector.Update(ch, gpuOrdinals)
+			}); err != nil {
+				c.logger.Error("Failed to update DCGM stats", "err", err)
+			}
+		}()
+	}
+
 	// Wait for all go routines
 	wg.Wait()
 
@@ -355,41 +543,73 @@ func (c *slurmCollector) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Stop dcgmCollector
+	if dcgmCollectorEnabled() {
+		if err := c.dcgmCollector.Stop(ctx); err != nil {
+			c.logger.Error("Failed to stop DCGM collector", "err", err)
+		}
+	}
+
 	return nil
 }
 
-// updateGPUOrdinals updates the metrics channel with GPU ordinals for SLURM job.
-func (c *slurmCollector) updateGPUOrdinals(ch chan<- prometheus.Metric, jobProps []jobProps) {
-	// Update slurm job properties
+// withMetadataLabels returns a channel that forwards metrics to ch after adding
+// metadata labels to the metrics that have uuid label. The returned function
+// must be called once all the metrics are sent to the returned channel.
+func (c *slurmCollector) withMetadataLabels(ch chan<- prometheus.Metric, jobProps []jobProps) (chan<- prometheus.Metric, func()) {
+	// Make a map of metadata label pairs of each job
+	labels := make(map[string][]*dto.LabelPair, len(jobProps))
 	for _, p := range jobProps {
-		// GPU job mapping
-		for _, gpuOrdinal := range p.gpuOrdinals {
-			var gpuuuid, miggid string
+		labels[p.uuid] = p.metadataLabels(c.metadataLabels)
+	}
 
-			flagValue := float64(1)
-			// Check the int index of devices where gpuOrdinal == dev.index
-			for _, dev := range c.gpuDevs {
-				// If the device has MIG enabled loop over them as well
-				for _, mig := range dev.migInstances {
-					if gpuOrdinal == mig.globalIndex {
-						gpuuuid = dev.uuid
-						miggid = strconv.FormatUint(mig.gpuInstID, 10)
+	metricCh := make(chan prometheus.Metric)
+	doneCh := make(chan struct{})
 
-						// For MIG, we export SM fraction as flag value
-						flagValue = mig.smFraction
+	go func() {
+		defer close(doneCh)
 
-						goto update_chan
-					}
-				}
+		for metric := range metricCh {
+			ch <- c.labelMetric(metric, labels)
+		}
+	}()
 
-				if gpuOrdinal == dev.globalIndex {
-					gpuuuid = dev.uuid
+	return metricCh, func() {
+		close(metricCh)
+		<-doneCh
+	}
+}
 
-					goto update_chan
-				}
-			}
+// labelMetric adds metadata labels to metric based on its uuid label.
+func (c *slurmCollector) labelMetric(metric prometheus.Metric, labels map[string][]*dto.LabelPair) prometheus.Metric {
+	m := &dto.Metric{}
+	if err := metric.Write(m); err != nil {
+		return metric
+	}
+
+	for _, l := range m.GetLabel() {
+		if l.GetName() != "uuid" {
+			continue
+		}
+
+		if pairs, ok := labels[l.GetValue()]; ok {
+			return &labelledMetric{Metric: metric, labels: pairs}
+		}
+
+		break
+	}
+
+	return metric
+}
+
+// updateGPUOrdinals updates the metrics channel with GPU ordinals for SLURM job.
+func (c *slurmCollector) updateGPUOrdinals(ch chan<- prometheus.Metric, jobProps []jobProps) {
+	// Update slurm job properties
+	for _, p := range jobProps {
+		// GPU job mapping
+		for _, gpuOrdinal := range p.gpuOrdinals {
+			gpuuuid, miggid, flagValue := gpuByOrdinal(c.gpuDevs, gpuOrdinal)
 
-		update_chan:
 			// We set label of gpuuuid of format <gpu_uuid>/<mig_instance_id>
 			// On the DCGM side, we need to use relabel magic to merge UUID
 			// and GPU_I_ID labels and set them exactly as <uuid>/<gpu_i_id>
@@ -409,6 +629,32 @@ func (c *slurmCollector) updateGPUOrdinals(ch chan<- prometheus.Metric, jobProps
 	}
 }
 
+// updateAccelOrdinals updates the metrics channel with accelerator ordinals for SLURM job.
+func (c *slurmCollector) updateAccelOrdinals(ch chan<- prometheus.Metric, jobProps []jobProps) {
+	for _, p := range jobProps {
+		for _, accelOrdinal := range p.accelOrdinals {
+			for _, dev := range c.accelDevs {
+				if dev.index != accelOrdinal {
+					continue
+				}
+
+				ch <- prometheus.MustNewConstMetric(
+					c.jobAccelFlag,
+					prometheus.GaugeValue,
+					float64(1),
+					c.cgroupManager.manager,
+					c.hostname,
+					p.uuid,
+					dev.vendor,
+					dev.index,
+					fmt.Sprintf("%s/accel-%s", c.hostname, dev.index),
+					dev.busID,
+				)
+			}
+		}
+	}
+}
+
 // jobProperties finds job properties for each active cgroup and returns initialised metric structs.
 func (c *slurmCollector) jobProperties(cgroups []cgroup) slurmMetrics {
 	// Get currently active jobs and set them in activeJobs state variable
@@ -418,17 +664,14 @@ func (c *slurmCollector) jobProperties(cgroups []cgroup) slurmMetrics {
 
 	var cgMetrics []cgMetric
 
-	var gpuOrdinals []string
-
 	// Iterate over all active cgroups and get job properties
 	for _, cgrp := range cgroups {
 		jobuuid := cgrp.uuid
 
-		// Get GPU ordinals of the job
-		if len(c.gpuDevs) > 0 {
-			if jobPropsCached, ok := c.jobPropsCache[jobuuid]; !ok || (ok && jobPropsCached.emptyGPUOrdinals()) {
-				gpuOrdinals = c.gpuOrdinals(jobuuid, cgrp.procs)
-				c.jobPropsCache[jobuuid] = jobProps{uuid: jobuuid, gpuOrdinals: gpuOrdinals}
+		// Get GPU and accelerator ordinals and metadata of the job
+		if len(c.gpuDevs) > 0 || len(c.accelDevs) > 0 || len(c.metadataLabels) > 0 {
+			if jobPropsCached, ok := c.jobPropsCache[jobuuid]; !ok || c.incompleteJobProps(jobPropsCached) {
+				c.jobPropsCache[jobuuid] = c.readJobProps(jobuuid, cgrp.procs)
 				jProps = append(jProps, c.jobPropsCache[jobuuid])
 			} else {
 				jProps = append(jProps, c.jobPropsCache[jobuuid])
@@ -466,14 +709,35 @@ func (c *slurmCollector) jobMetrics() (slurmMetrics, error) {
 	return c.jobProperties(cgroups), nil
 }
 
-// gpuOrdinals returns GPU ordinals bound to current job.
-func (c *slurmCollector) gpuOrdinals(uuid string, procs []procfs.Proc) []string {
-	var gpuOrdinals []string
+// incompleteJobProps returns true if job properties must be read again.
+func (c *slurmCollector) incompleteJobProps(p jobProps) bool {
+	return (len(c.gpuDevs) > 0 && p.emptyGPUOrdinals()) ||
+		(len(c.accelDevs) > 0 && p.emptyAccelOrdinals()) ||
+		(len(c.metadataLabels) > 0 && p.emptyMetadata())
+}
+
+// readJobProps returns GPU ordinals bound to current job, accelerator ordinals
+// used by current job and job metadata when metadata labels are enabled.
+func (c *slurmCollector) readJobProps(uuid string, procs []procfs.Proc) jobProps {
+	props := jobProps{uuid: uuid}
 
 	// Read env vars in a security context that raises necessary capabilities
 	dataPtr := &slurmReadProcSecurityCtxData{
-		procs: procs,
-		uuid:  uuid,
+		procs:    procs,
+		uuid:     uuid,
+		metadata: len(c.metadataLabels) > 0,
+	}
+
+	// Accelerators are not exposed to jobs via env vars. So we look for the
+	// device nodes of accelerators in open file descriptors of job processes
+	if len(c.accelDevs) > 0 {
+		dataPtr.accelNodes = make(map[string]string)
+
+		for _, dev := range c.accelDevs {
+			for _, node := range dev.devNodes {
+				dataPtr.accelNodes[node] = dev.index
+			}
+		}
 	}
 
 	if securityCtx, ok := c.securityContexts[slurmReadProcCtx]; ok {
@@ -482,30 +746,50 @@ func (c *slurmCollector) gpuOrdinals(uuid string, procs []procfs.Proc) []string
 				"Failed to run inside security contxt", "jobid", uuid, "err", err,
 			)
 
-			return nil
+			return props
 		}
 	} else {
 		c.logger.Error(
 			"Security context not found", "name", slurmReadProcCtx, "jobid", uuid,
 		)
 
-		return nil
+		return props
 	}
 
 	// Emit warning when there are GPUs but no job to GPU map found
-	if len(dataPtr.gpuOrdinals) == 0 {
-		c.logger.Warn("Failed to get GPU ordinals for job", "jobid", uuid)
-	} else {
+	if len(c.gpuDevs) > 0 {
+		if len(dataPtr.gpuOrdinals) == 0 {
+			c.logger.Warn("Failed to get GPU ordinals for job", "jobid", uuid)
+		} else {
+			c.logger.Debug(
+				"GPU ordinals", "jobid", uuid, "ordinals", strings.Join(dataPtr.gpuOrdinals, ","),
+			)
+		}
+	}
+
+	if len(c.accelDevs) > 0 && len(dataPtr.accelOrdinals) > 0 {
 		c.logger.Debug(
-			"GPU ordinals", "jobid", uuid, "ordinals", strings.Join(gpuOrdinals, ","),
+			"Accelerator ordinals", "jobid", uuid, "ordinals", strings.Join(dataPtr.accelOrdinals, ","),
 		)
 	}
 
-	return dataPtr.gpuOrdinals
+	props.gpuOrdinals = dataPtr.gpuOrdinals
+	props.accelOrdinals = dataPtr.accelOrdinals
+	props.user = dataPtr.user
+	props.project = dataPtr.project
+
+	// A job has exclusive node allocation when it is allocated all the CPUs
+	// of the node. When SLURM_CPUS_ON_NODE is not found, leave it empty
+	if c.nodeCPUs > 0 && dataPtr.cpusOnNode > 0 {
+		props.exclusive = strconv.FormatBool(dataPtr.cpusOnNode >= c.nodeCPUs)
+	}
+
+	return props
 }
 
-// readProcEnvirons reads the environment variables of processes and returns
-// GPU ordinals of job. This function will be executed in a security context.
+// readProcEnvirons reads the environment variables and file descriptors of processes
+// and returns GPU ordinals, accelerator ordinals and metadata of job. This function
+// will be executed in a security context.
 func readProcEnvirons(data interface{}) error {
 	// Assert data is of slurmSecurityCtxData
 	var d *slurmReadProcSecurityCtxData
@@ -528,11 +812,28 @@ func readProcEnvirons(data interface{}) error {
 	// have capabilities to read environment variables. So, we just do
 	// old school loop on procs and attempt to find target env variables.
 	for _, proc := range d.procs {
-		// If SLURM_JOB_GPUS env var is found, exit loop
-		if len(jobGPUs) > 0 {
+		// If SLURM_JOB_GPUS env var and metadata (when requested) are found, exit loop.
+		// When accelerators are present, we need to inspect all processes as
+		// any of them can open accelerator devices.
+		if len(jobGPUs) > 0 && (!d.metadata || (d.user != "" && d.project != "" && d.cpusOnNode > 0)) && len(d.accelNodes) == 0 {
 			break
 		}
 
+		// Look for accelerator device nodes in open file descriptors.
+		// All processes in job cgroup belong to the job and hence, there is
+		// no need to check for SLURM_JOB_ID env var.
+		// NOTE: This needs CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH caps
+		// on the current process
+		if len(d.accelNodes) > 0 {
+			if targets, err := proc.FileDescriptorTargets(); err == nil {
+				for _, target := range targets {
+					if ordinal, ok := d.accelNodes[target]; ok && !slices.Contains(d.accelOrdinals, ordinal) {
+						d.accelOrdinals = append(d.accelOrdinals, ordinal)
+					}
+				}
+			}
+		}
+
 		// Read process environment variables
 		// NOTE: This needs CAP_SYS_PTRACE and CAP_DAC_READ_SEARCH caps
 		// on the current process
@@ -544,6 +845,18 @@ func readProcEnvirons(data interface{}) error {
 
 		// When env var entry found, get all necessary env vars
 		for _, env := range environments {
+			if d.metadata && d.user == "" && strings.HasPrefix(env, "SLURM_JOB_USER=") {
+				d.user = strings.TrimPrefix(env, "SLURM_JOB_USER=")
+			}
+
+			if d.metadata && d.project == "" && strings.HasPrefix(env, "SLURM_JOB_ACCOUNT=") {
+				d.project = strings.TrimPrefix(env, "SLURM_JOB_ACCOUNT=")
+			}
+
+			if d.metadata && d.cpusOnNode == 0 && strings.HasPrefix(env, "SLURM_CPUS_ON_NODE=") {
+				d.cpusOnNode, _ = strconv.Atoi(strings.TrimPrefix(env, "SLURM_CPUS_ON_NODE="))
+			}
+
 			if strings.Contains(env, "SLURM_STEP_GPUS") {
 				stepGPUs = strings.Split(strings.Split(env, "=")[1], ",")
 			}
@@ -563,6 +876,8 @@ func readProcEnvirons(data interface{}) error {
 	// the case eversince we migrated to SLURM 23.11 on JZ. Maybe it is a
 	// side effect of Atos' patches?
 	// Relevant SLURM src: https://github.com/SchedMD/slurm/blob/d3e78848f72745ceb80e2a6bebdbcf3cfd7462b1/src/plugins/gres/common/gres_common.c#L262-L265
+	slices.Sort(d.accelOrdinals)
+
 	if len(jobGPUs) > 0 {
 		d.gpuOrdinals = jobGPUs
 	} else if len(stepGPUs) > 0 {
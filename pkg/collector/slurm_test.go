@@ -16,6 +16,7 @@ import (
 	"github.com/containerd/cgroups/v3"
 	"github.com/mahendrapaipuri/ceems/internal/security"
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/procfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -183,6 +184,171 @@ func TestSlurmJobPropsWithProcsFS(t *testing.T) {
 	assert.Equal(t, expectedProps, metrics.jobProps)
 }
 
+func TestSlurmJobAccelOrdinals(t *testing.T) {
+	procFS, err := procfs.NewFS("testdata/proc")
+	require.NoError(t, err)
+
+	proc, err := procFS.Proc(3346567)
+	require.NoError(t, err)
+
+	// Use fd targets of test procs as accelerator device nodes
+	dataPtr := &slurmReadProcSecurityCtxData{
+		procs: []procfs.Proc{proc},
+		uuid:  "1009248",
+		accelNodes: map[string]string{
+			"../../symlinktargets/xyz": "1",
+			"../../symlinktargets/uvw": "0",
+			"../../symlinktargets/foo": "2",
+		},
+	}
+
+	err = readProcEnvirons(dataPtr)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"0", "1"}, dataPtr.accelOrdinals)
+	assert.Equal(t, []string{"2", "3"}, dataPtr.gpuOrdinals)
+
+	c := slurmCollector{
+		cgroupManager: &cgroupManager{manager: "slurm"},
+		hostname:      "host",
+		accelDevs: []Accelerator{
+			{vendor: "xilinx", index: "0", busID: "0000:3b:00.1"},
+			{vendor: "xilinx", index: "1", busID: "0000:d8:00.1"},
+		},
+		jobAccelFlag: prometheus.NewDesc("accel_flag", "", []string{"manager", "hostname", "uuid", "vendor", "index", "hindex", "accelid"}, nil),
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	c.updateAccelOrdinals(ch, []jobProps{{uuid: "1009248", accelOrdinals: dataPtr.accelOrdinals}})
+	close(ch)
+
+	var accelIDs []string
+
+	for metric := range ch {
+		m := &dto.Metric{}
+		require.NoError(t, metric.Write(m))
+
+		for _, l := range m.GetLabel() {
+			if l.GetName() == "accelid" {
+				accelIDs = append(accelIDs, l.GetValue())
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"0000:3b:00.1", "0000:d8:00.1"}, accelIDs)
+}
+
+func TestSlurmJobMetadataLabels(t *testing.T) {
+	path := t.TempDir()
+
+	cgroupsPath := path + "/cgroups"
+	err := os.Mkdir(cgroupsPath, 0o750)
+	require.NoError(t, err)
+
+	procFS := path + "/proc"
+	err = os.Mkdir(procFS, 0o750)
+	require.NoError(t, err)
+
+	fs, err := procfs.NewFS(procFS)
+	require.NoError(t, err)
+
+	// cgroup Manager
+	cgManager := &cgroupManager{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		fs:         fs,
+		mode:       cgroups.Legacy,
+		root:       cgroupsPath,
+		idRegex:    slurmCgroupPathRegex,
+		mountPoint: cgroupsPath + "/cpuacct/slurm",
+		manager:    "slurm",
+		isChild: func(p string) bool {
+			return false
+		},
+	}
+
+	c := slurmCollector{
+		cgroupManager:    cgManager,
+		logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		hostname:         "host",
+		jobPropsCache:    make(map[string]jobProps),
+		metadataLabels:   []string{"exclusive", "project", "user"},
+		nodeCPUs:         4,
+		securityContexts: make(map[string]*security.SecurityContext),
+	}
+
+	// Add dummy security context
+	c.securityContexts[slurmReadProcCtx], err = security.NewSecurityContext(
+		slurmReadProcCtx,
+		nil,
+		readProcEnvirons,
+		c.logger,
+	)
+	require.NoError(t, err)
+
+	// Add cgroups and procs with job metadata in environment
+	for i := range 2 {
+		dir := fmt.Sprintf("%s/cpuacct/slurm/job_%d", cgroupsPath, i)
+
+		err = os.MkdirAll(dir, 0o750)
+		require.NoError(t, err)
+
+		err = os.WriteFile(dir+"/cgroup.procs", []byte(fmt.Sprintf("%d\n", i)), 0o600)
+		require.NoError(t, err)
+
+		dir = fmt.Sprintf("%s/%d", procFS, i)
+
+		err = os.MkdirAll(dir, 0o750)
+		require.NoError(t, err)
+
+		envs := []string{
+			fmt.Sprintf("SLURM_JOB_ID=%d", i),
+			fmt.Sprintf("SLURM_JOB_USER=usr%d", i),
+			fmt.Sprintf("SLURM_JOB_ACCOUNT=acc%d", i),
+			fmt.Sprintf("SLURM_CPUS_ON_NODE=%d", 4/(i+1)),
+		}
+		err = os.WriteFile(dir+"/environ", []byte(strings.Join(envs, "\000")+"\000"), 0o600)
+		require.NoError(t, err)
+	}
+
+	metrics, err := c.jobMetrics()
+	require.NoError(t, err)
+
+	expectedProps := []jobProps{
+		{uuid: "0", user: "usr0", project: "acc0", exclusive: "true"},
+		{uuid: "1", user: "usr1", project: "acc1", exclusive: "false"},
+	}
+	assert.ElementsMatch(t, expectedProps, metrics.jobProps)
+
+	// Check metadata labels are added to metrics with uuid label
+	desc := prometheus.NewDesc("test_metric", "Test metric", []string{"manager", "hostname", "uuid"}, nil)
+	ch := make(chan prometheus.Metric, 2)
+	metadataCh, done := c.withMetadataLabels(ch, metrics.jobProps)
+	metadataCh <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 0, "slurm", "host", "1")
+	metadataCh <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 0, "slurm", "host", "2")
+	done()
+	close(ch)
+
+	var labels []map[string]string
+
+	for metric := range ch {
+		m := &dto.Metric{}
+		require.NoError(t, metric.Write(m))
+
+		l := make(map[string]string)
+		for _, pair := range m.GetLabel() {
+			l[pair.GetName()] = pair.GetValue()
+		}
+
+		labels = append(labels, l)
+	}
+
+	expectedLabels := []map[string]string{
+		{"manager": "slurm", "hostname": "host", "uuid": "1", "user": "usr1", "project": "acc1", "exclusive": "false"},
+		{"manager": "slurm", "hostname": "host", "uuid": "2"},
+	}
+	assert.Equal(t, expectedLabels, labels)
+}
+
 func TestJobPropsCaching(t *testing.T) {
 	path := t.TempDir()
 
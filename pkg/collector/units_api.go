@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Unit contains the current metrics of a compute unit tracked by exporter.
+type Unit struct {
+	UUID    string             `json:"uuid"`
+	Manager string             `json:"manager"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// UnitsHandlerFor returns http.Handler that lists all the compute units tracked
+// by the collectors registered in gatherer along with their current metrics.
+// Only requests originating from loopback addresses are served.
+func UnitsHandlerFor(gatherer prometheus.Gatherer, opts promhttp.HandlerOpts) http.Handler {
+	var inFlightSem chan struct{}
+
+	if opts.MaxRequestsInFlight > 0 {
+		inFlightSem = make(chan struct{}, opts.MaxRequestsInFlight)
+	}
+
+	return http.HandlerFunc(func(rsp http.ResponseWriter, req *http.Request) {
+		// Serve only local requests
+		if !isLoopback(req.RemoteAddr) {
+			http.Error(rsp, "Units API is only accessible from localhost", http.StatusForbidden)
+
+			return
+		}
+
+		if inFlightSem != nil {
+			select {
+			case inFlightSem <- struct{}{}: // All good, carry on.
+				defer func() { <-inFlightSem }()
+			default:
+				http.Error(rsp, fmt.Sprintf(
+					"Limit of concurrent requests reached (%d), try again later.", opts.MaxRequestsInFlight,
+				), http.StatusServiceUnavailable)
+
+				return
+			}
+		}
+
+		mfs, err := gatherer.Gather()
+		if err != nil {
+			if opts.ErrorLog != nil {
+				opts.ErrorLog.Println("error gathering metrics:", err)
+			}
+
+			// Still report the error if no metrics have been gathered.
+			if len(mfs) == 0 {
+				httpError(rsp, err)
+
+				return
+			}
+		}
+
+		rsp.Header().Set(contentTypeHeader, contentType)
+
+		if err := json.NewEncoder(rsp).Encode(unitsFromMetrics(mfs)); err != nil {
+			http.Error(rsp, "Failed to encode JSON: "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// unitsFromMetrics groups the metrics that have uuid label by compute unit.
+func unitsFromMetrics(mfs []*dto.MetricFamily) []Unit {
+	units := make(map[string]*Unit)
+
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			var uuid, manager string
+
+			var extraLabels []string
+
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "uuid":
+					uuid = l.GetValue()
+				case "manager":
+					manager = l.GetValue()
+				case "hostname":
+					continue
+				default:
+					extraLabels = append(extraLabels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+				}
+			}
+
+			// Ignore metrics that are not related to compute units
+			if uuid == "" {
+				continue
+			}
+
+			key := manager + "/" + uuid
+			if _, ok := units[key]; !ok {
+				units[key] = &Unit{UUID: uuid, Manager: manager, Metrics: make(map[string]float64)}
+			}
+
+			name := mf.GetName()
+			if len(extraLabels) > 0 {
+				name = fmt.Sprintf("%s{%s}", name, strings.Join(extraLabels, ","))
+			}
+
+			units[key].Metrics[name] = metricValue(m)
+		}
+	}
+
+	// Return units in a stable order
+	keys := make([]string, 0, len(units))
+	for key := range units {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	unitList := make([]Unit, len(keys))
+	for i, key := range keys {
+		unitList[i] = *units[key]
+	}
+
+	return unitList
+}
+
+// metricValue returns the value of gauge, counter or untyped metric.
+func metricValue(m *dto.Metric) float64 {
+	switch {
+	case m.GetGauge() != nil:
+		return m.GetGauge().GetValue()
+	case m.GetCounter() != nil:
+		return m.GetCounter().GetValue()
+	default:
+		return m.GetUntyped().GetValue()
+	}
+}
+
+// isLoopback returns true if the remote address is a loopback address.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockUnitsCollector struct {
+	cpuDesc   *prometheus.Desc
+	blkioDesc *prometheus.Desc
+	numDesc   *prometheus.Desc
+}
+
+func (c *mockUnitsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cpuDesc
+	ch <- c.blkioDesc
+	ch <- c.numDesc
+}
+
+func (c *mockUnitsCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.numDesc, prometheus.GaugeValue, 2, "slurm", "host")
+	ch <- prometheus.MustNewConstMetric(c.cpuDesc, prometheus.CounterValue, 10, "slurm", "host", "1")
+	ch <- prometheus.MustNewConstMetric(c.cpuDesc, prometheus.CounterValue, 20, "slurm", "host", "2")
+	ch <- prometheus.MustNewConstMetric(c.blkioDesc, prometheus.GaugeValue, 100, "slurm", "host", "2", "sda")
+}
+
+func TestUnitsHandler(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(&mockUnitsCollector{
+		numDesc:   prometheus.NewDesc("units", "", []string{"manager", "hostname"}, nil),
+		cpuDesc:   prometheus.NewDesc("cpu_total", "", []string{"manager", "hostname", "uuid"}, nil),
+		blkioDesc: prometheus.NewDesc("blkio_bytes", "", []string{"manager", "hostname", "uuid", "device"}, nil),
+	})
+
+	handler := UnitsHandlerFor(reg, promhttp.HandlerOpts{})
+
+	expected := []Unit{
+		{UUID: "1", Manager: "slurm", Metrics: map[string]float64{"cpu_total": 10}},
+		{UUID: "2", Manager: "slurm", Metrics: map[string]float64{"cpu_total": 20, `blkio_bytes{device="sda"}`: 100}},
+	}
+
+	// Request from localhost
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.RemoteAddr = "127.0.0.1:42000"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var units []Unit
+	err := json.NewDecoder(w.Body).Decode(&units)
+	require.NoError(t, err)
+	assert.Equal(t, expected, units)
+
+	// Request from remote host must be forbidden
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/units", nil)
+	req.RemoteAddr = "192.168.1.1:42000"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
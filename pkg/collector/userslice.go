@@ -0,0 +1,209 @@
+//go:build !nouserslice
+// +build !nouserslice
+
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	userSliceCollectorSubsystem = "userslice"
+)
+
+// CLI opts.
+var (
+	// cgroup opts.
+	userSliceCollectSwapMemoryStats = CEEMSExporterApp.Flag(
+		"collector.userslice.swap-memory-metrics",
+		"Enables collection of swap memory metrics (default: disabled)",
+	).Default("false").Bool()
+	userSliceCollectBlkIOStats = CEEMSExporterApp.Flag(
+		"collector.userslice.blkio-metrics",
+		"Enables collection of block IO metrics (default: disabled)",
+	).Default("false").Bool()
+	userSliceCollectPSIStats = CEEMSExporterApp.Flag(
+		"collector.userslice.psi-metrics",
+		"Enables collection of PSI metrics (default: disabled)",
+	).Default("false").Bool()
+
+	// testing flags.
+	userSliceRunUserDir = CEEMSExporterApp.Flag(
+		"collector.userslice.run-user-dir",
+		"Directory containing runtime directories of logged in users",
+	).Default("/run/user").Hidden().String()
+)
+
+type userSliceMetrics struct {
+	cgMetrics []cgMetric
+	cgroups   []cgroup
+}
+
+type userSliceCollector struct {
+	logger          *slog.Logger
+	cgroupManager   *cgroupManager
+	cgroupCollector *cgroupCollector
+	hostname        string
+	userNames       map[string]string
+	userNamesMtx    sync.Mutex
+	userSessionFlag *prometheus.Desc
+}
+
+func init() {
+	RegisterCollector(userSliceCollectorSubsystem, defaultDisabled, NewUserSliceCollector)
+}
+
+// NewUserSliceCollector returns a new user slice collector exposing a summary of
+// cgroups of users on login/interactive nodes.
+func NewUserSliceCollector(logger *slog.Logger) (Collector, error) {
+	// Get user slice's cgroup details
+	cgroupManager, err := NewCgroupManager(userslice, logger)
+	if err != nil {
+		logger.Info("Failed to create cgroup manager", "err", err)
+
+		return nil, err
+	}
+
+	logger.Info("cgroup: " + cgroupManager.String())
+
+	// Set cgroup options
+	opts := cgroupOpts{
+		collectSwapMemStats: *userSliceCollectSwapMemoryStats,
+		collectBlockIOStats: *userSliceCollectBlkIOStats,
+		collectPSIStats:     *userSliceCollectPSIStats,
+	}
+
+	// Start new instance of cgroupCollector
+	cgCollector, err := NewCgroupCollector(logger.With("sub_collector", "cgroup"), cgroupManager, opts)
+	if err != nil {
+		logger.Info("Failed to create cgroup collector", "err", err)
+
+		return nil, err
+	}
+
+	return &userSliceCollector{
+		cgroupManager:   cgroupManager,
+		cgroupCollector: cgCollector,
+		hostname:        hostname,
+		userNames:       make(map[string]string),
+		userSessionFlag: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, genericSubsystem, "unit_user_session_flag"),
+			"A value > 0 indicates that user has an active login session",
+			[]string{"manager", "hostname", "uuid", "uid"},
+			nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+// Update implements Collector and update user slice metrics.
+func (c *userSliceCollector) Update(ch chan<- prometheus.Metric) error {
+	metrics, err := c.userMetrics()
+	if err != nil {
+		return err
+	}
+
+	// Update cgroup metrics
+	c.cgroupCollector.updateActiveCgroups(ch, metrics.cgroups)
+
+	if err := c.cgroupCollector.Update(ch, metrics.cgMetrics); err != nil {
+		c.logger.Error("Failed to update cgroup stats", "err", err)
+	}
+
+	// Update user session flags
+	for _, cgrp := range metrics.cgroups {
+		var flag float64
+		if _, err := os.Stat(filepath.Join(*userSliceRunUserDir, cgrp.id)); err == nil {
+			flag = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.userSessionFlag, prometheus.GaugeValue, flag, c.cgroupManager.manager, c.hostname, cgrp.uuid, cgrp.id,
+		)
+	}
+
+	return nil
+}
+
+// Stop releases system resources used by the collector.
+func (c *userSliceCollector) Stop(ctx context.Context) error {
+	c.logger.Debug("Stopping", "collector", userSliceCollectorSubsystem)
+
+	// Stop cgroupCollector
+	if err := c.cgroupCollector.Stop(ctx); err != nil {
+		c.logger.Error("Failed to stop cgroup collector", "err", err)
+	}
+
+	return nil
+}
+
+// userMetrics returns initialised user slice metrics structs.
+func (c *userSliceCollector) userMetrics() (userSliceMetrics, error) {
+	// Get active cgroups
+	cgroups, err := c.cgroupManager.discover()
+	if err != nil {
+		return userSliceMetrics{}, fmt.Errorf("failed to discover cgroups: %w", err)
+	}
+
+	var activeUIDs []string
+
+	cgMetrics := make([]cgMetric, len(cgroups))
+
+	// Collector can be scraped concurrently
+	c.userNamesMtx.Lock()
+	defer c.userNamesMtx.Unlock()
+
+	for icgrp := range cgroups {
+		uid := cgroups[icgrp].id
+
+		// Resolve user name from UID and cache it
+		if _, ok := c.userNames[uid]; !ok {
+			c.userNames[uid] = c.resolveUser(uid)
+		}
+
+		cgroups[icgrp].uuid = c.userNames[uid]
+		activeUIDs = append(activeUIDs, uid)
+
+		cgMetrics[icgrp] = cgMetric{uuid: cgroups[icgrp].uuid, path: "/" + cgroups[icgrp].path.rel}
+	}
+
+	// Remove users that logged out from cache
+	for uid := range c.userNames {
+		if !slices.Contains(activeUIDs, uid) {
+			delete(c.userNames, uid)
+		}
+	}
+
+	return userSliceMetrics{cgMetrics: cgMetrics, cgroups: cgroups}, nil
+}
+
+// resolveUser returns the user name of the given UID. It uses the owner
+// of runtime directory /run/user/<uid> of the user when it exists and falls
+// back to the UID in the cgroup path. If user name cannot be resolved,
+// UID is returned.
+func (c *userSliceCollector) resolveUser(uid string) string {
+	if info, err := os.Stat(filepath.Join(*userSliceRunUserDir, uid)); err == nil {
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid = strconv.FormatUint(uint64(stat.Uid), 10)
+		}
+	}
+
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username
+	}
+
+	c.logger.Debug("Failed to resolve user name", "uid", uid)
+
+	return uid
+}
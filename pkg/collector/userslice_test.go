@@ -0,0 +1,100 @@
+//go:build !nouserslice
+// +build !nouserslice
+
+package collector
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containerd/cgroups/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSliceMetrics(t *testing.T) {
+	path := t.TempDir()
+
+	// Make user slices
+	cgroupsPath := filepath.Join(path, "cgroups")
+	for _, p := range []string{"user.slice/user-0.slice/session-1.scope", "user.slice/user-99999.slice/user@99999.service"} {
+		err := os.MkdirAll(filepath.Join(cgroupsPath, p), 0o750)
+		require.NoError(t, err)
+	}
+
+	// Make runtime dirs
+	runUserPath := filepath.Join(path, "run-user")
+	err := os.MkdirAll(filepath.Join(runUserPath, "0"), 0o750)
+	require.NoError(t, err)
+
+	_, err = CEEMSExporterApp.Parse(
+		[]string{
+			"--collector.userslice.run-user-dir", runUserPath,
+		},
+	)
+	require.NoError(t, err)
+
+	// cgroup Manager
+	cgManager := &cgroupManager{
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mode:       cgroups.Unified,
+		root:       cgroupsPath,
+		mountPoint: filepath.Join(cgroupsPath, "user.slice"),
+		idRegex:    userSliceCgroupPathRegex,
+		isChild: func(p string) bool {
+			return strings.Contains(p, "/session-") || strings.Contains(p, "/user@")
+		},
+	}
+
+	c := userSliceCollector{
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		cgroupManager: cgManager,
+		userNames:     make(map[string]string),
+	}
+
+	metrics, err := c.userMetrics()
+	require.NoError(t, err)
+
+	expectedMetrics := []cgMetric{
+		{uuid: "root", path: "/user.slice/user-0.slice"},
+		{uuid: "99999", path: "/user.slice/user-99999.slice"},
+	}
+	assert.ElementsMatch(t, expectedMetrics, metrics.cgMetrics)
+	assert.Len(t, c.userNames, 2)
+
+	// Remove a user slice and check if cache is cleaned up
+	err = os.RemoveAll(filepath.Join(cgroupsPath, "user.slice/user-99999.slice"))
+	require.NoError(t, err)
+
+	_, err = c.userMetrics()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0": "root"}, c.userNames)
+
+	// Concurrent scrapes must be safe
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.userMetrics()
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestUserSliceCgroupPathRegex(t *testing.T) {
+	assert.Equal(t, []string{"/user.slice/user-1000.slice/session-1.scope", "1000"}, userSliceCgroupPathRegex.FindStringSubmatch("/user.slice/user-1000.slice/session-1.scope"))
+
+	// Dots must be matched literally
+	assert.Nil(t, userSliceCgroupPathRegex.FindStringSubmatch("/userXslice/user-1000Xslice"))
+}
@@ -0,0 +1,296 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Custom errors.
+var (
+	ErrNotFound        = errors.New("Grafana resource not found")
+	ErrUserNotFound    = errors.New("Grafana user not found")
+	ErrInvalidResponse = errors.New("unexpected response from Grafana API")
+)
+
+// Folder permissions that can be granted to Grafana teams.
+const (
+	FolderPermissionView  = "View"
+	FolderPermissionEdit  = "Edit"
+	FolderPermissionAdmin = "Admin"
+)
+
+// Team is the Grafana team returned by teams API.
+type Team struct {
+	ID          int    `json:"id"`
+	UID         string `json:"uid"`
+	OrgID       int    `json:"orgId"`
+	Name        string `json:"name"`
+	MemberCount int    `json:"memberCount"`
+}
+
+// Folder is the Grafana folder returned by search API.
+type Folder struct {
+	ID    int    `json:"id"`
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+}
+
+// TeamSyncResult contains the changes made to a Grafana team during sync.
+type TeamSyncResult struct {
+	TeamID  int
+	Added   []string
+	Removed []string
+	Missing []string // Users that do not exist in Grafana yet
+}
+
+// teamsSearchResponse is the API response of teams search endpoint.
+type teamsSearchResponse struct {
+	TotalCount int    `json:"totalCount"`
+	Teams      []Team `json:"teams"`
+}
+
+// SyncTeam ensures that a Grafana team with given name exists and its members
+// match the given list of logins. Members of the team that are not in the logins
+// are removed only when removeStale is true. Logins that do not have a Grafana
+// account yet are reported in the result and will be added in a future sync.
+func (g *Grafana) SyncTeam(ctx context.Context, name string, logins []string, removeStale bool) (*TeamSyncResult, error) {
+	team, err := g.TeamByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create team if it does not exist
+	if team == nil {
+		if team, err = g.CreateTeam(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	// Get current members of the team
+	members, err := g.teamMembersData(ctx, strconv.Itoa(team.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]int, len(members))
+	for _, member := range members {
+		if member.Login != "" {
+			current[member.Login] = member.UserID
+		}
+	}
+
+	result := &TeamSyncResult{TeamID: team.ID}
+	expected := make(map[string]struct{}, len(logins))
+
+	for _, login := range logins {
+		expected[login] = struct{}{}
+
+		if _, ok := current[login]; ok {
+			continue
+		}
+
+		userID, err := g.UserIDByLogin(ctx, login)
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				result.Missing = append(result.Missing, login)
+
+				continue
+			}
+
+			return result, err
+		}
+
+		if err := g.AddTeamMember(ctx, team.ID, userID); err != nil {
+			return result, err
+		}
+
+		result.Added = append(result.Added, login)
+	}
+
+	if !removeStale {
+		return result, nil
+	}
+
+	for login, userID := range current {
+		if _, ok := expected[login]; ok {
+			continue
+		}
+
+		if err := g.RemoveTeamMember(ctx, team.ID, userID); err != nil {
+			return result, err
+		}
+
+		result.Removed = append(result.Removed, login)
+	}
+
+	return result, nil
+}
+
+// TeamByName returns the Grafana team with given name. If the team does not
+// exist, nil is returned.
+func (g *Grafana) TeamByName(ctx context.Context, name string) (*Team, error) {
+	endpoint := g.URL.JoinPath("/api/teams/search")
+	endpoint.RawQuery = url.Values{"name": []string{name}}.Encode()
+
+	var data teamsSearchResponse
+	if err := g.do(ctx, http.MethodGet, endpoint.String(), nil, &data); err != nil {
+		return nil, err
+	}
+
+	for _, team := range data.Teams {
+		if team.Name == name {
+			return &team, nil
+		}
+	}
+
+	return nil, nil //nolint:nilnil
+}
+
+// CreateTeam creates a new Grafana team with given name.
+func (g *Grafana) CreateTeam(ctx context.Context, name string) (*Team, error) {
+	var data struct {
+		TeamID int `json:"teamId"`
+	}
+
+	if err := g.do(ctx, http.MethodPost, g.URL.JoinPath("/api/teams").String(), map[string]string{"name": name}, &data); err != nil {
+		return nil, err
+	}
+
+	return &Team{ID: data.TeamID, Name: name}, nil
+}
+
+// UserIDByLogin returns the ID of Grafana user with given login.
+func (g *Grafana) UserIDByLogin(ctx context.Context, login string) (int, error) {
+	endpoint := g.URL.JoinPath("/api/users/lookup")
+	endpoint.RawQuery = url.Values{"loginOrEmail": []string{login}}.Encode()
+
+	var data struct {
+		ID int `json:"id"`
+	}
+
+	if err := g.do(ctx, http.MethodGet, endpoint.String(), nil, &data); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return 0, ErrUserNotFound
+		}
+
+		return 0, err
+	}
+
+	return data.ID, nil
+}
+
+// AddTeamMember adds user to Grafana team.
+func (g *Grafana) AddTeamMember(ctx context.Context, teamID, userID int) error {
+	endpoint := g.URL.JoinPath(fmt.Sprintf("/api/teams/%d/members", teamID)).String()
+
+	return g.do(ctx, http.MethodPost, endpoint, map[string]int{"userId": userID}, nil)
+}
+
+// RemoveTeamMember removes user from Grafana team.
+func (g *Grafana) RemoveTeamMember(ctx context.Context, teamID, userID int) error {
+	endpoint := g.URL.JoinPath(fmt.Sprintf("/api/teams/%d/members/%d", teamID, userID)).String()
+
+	return g.do(ctx, http.MethodDelete, endpoint, nil, nil)
+}
+
+// EnsureFolder returns the Grafana folder with given title and creates it if
+// it does not exist.
+func (g *Grafana) EnsureFolder(ctx context.Context, title string) (*Folder, error) {
+	endpoint := g.URL.JoinPath("/api/search")
+	endpoint.RawQuery = url.Values{"type": []string{"dash-folder"}, "query": []string{title}}.Encode()
+
+	var folders []Folder
+	if err := g.do(ctx, http.MethodGet, endpoint.String(), nil, &folders); err != nil {
+		return nil, err
+	}
+
+	for _, folder := range folders {
+		if folder.Title == title {
+			return &folder, nil
+		}
+	}
+
+	var folder Folder
+	if err := g.do(ctx, http.MethodPost, g.URL.JoinPath("/api/folders").String(), map[string]string{"title": title}, &folder); err != nil {
+		return nil, err
+	}
+
+	return &folder, nil
+}
+
+// SetFolderTeamPermission grants permission on Grafana folder to the team.
+// Permissions of other teams and users on the folder are not modified.
+func (g *Grafana) SetFolderTeamPermission(ctx context.Context, folderUID string, teamID int, permission string) error {
+	endpoint := g.URL.JoinPath(fmt.Sprintf("/api/access-control/folders/%s/teams/%d", url.PathEscape(folderUID), teamID)).String()
+
+	return g.do(ctx, http.MethodPost, endpoint, map[string]string{"permission": permission}, nil)
+}
+
+// teamMembersData fetches team members data from a given Grafana team.
+func (g *Grafana) teamMembersData(ctx context.Context, teamsID string) ([]GrafanaTeamsReponse, error) {
+	var data []GrafanaTeamsReponse
+	if err := g.do(ctx, http.MethodGet, g.teamMembersEndpoint(teamsID), nil, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// do makes a request to Grafana API with an optional JSON payload and decodes
+// the response into out when it is not nil.
+func (g *Grafana) do(ctx context.Context, method, endpoint string, payload any, out any) error {
+	var body io.Reader
+
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload for Grafana API: %w", err)
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create a new HTTP request for Grafana API: %w", err)
+	}
+
+	// Add necessary headers
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request for Grafana API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read HTTP response body for Grafana API: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s %s", ErrNotFound, method, req.URL.Path)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("%w: %s %s returned %d: %s", ErrInvalidResponse, method, req.URL.Path, resp.StatusCode, string(respBody))
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal HTTP response body for Grafana API: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockGrafana is a minimal in-memory implementation of Grafana teams, users
+// and folders API.
+type mockGrafana struct {
+	mu          sync.Mutex
+	users       map[string]int
+	teams       map[string]int
+	members     map[int]map[int]string
+	folders     map[string]string
+	permissions map[string]string
+}
+
+func newMockGrafana(users map[string]int) *mockGrafana {
+	return &mockGrafana{
+		users:       users,
+		teams:       make(map[string]int),
+		members:     make(map[int]map[int]string),
+		folders:     make(map[string]string),
+		permissions: make(map[string]string),
+	}
+}
+
+func (m *mockGrafana) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/teams/search":
+		resp := teamsSearchResponse{}
+		if id, ok := m.teams[r.URL.Query().Get("name")]; ok {
+			resp.Teams = append(resp.Teams, Team{ID: id, Name: r.URL.Query().Get("name")})
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/teams":
+		var req map[string]string
+
+		json.NewDecoder(r.Body).Decode(&req)
+
+		id := len(m.teams) + 1
+		m.teams[req["name"]] = id
+		m.members[id] = make(map[int]string)
+
+		json.NewEncoder(w).Encode(map[string]int{"teamId": id})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/users/lookup":
+		id, ok := m.users[r.URL.Query().Get("loginOrEmail")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	case len(parts) >= 4 && parts[1] == "teams" && parts[3] == "members":
+		teamID, _ := strconv.Atoi(parts[2])
+
+		switch r.Method {
+		case http.MethodGet:
+			var resp []GrafanaTeamsReponse
+			for id, login := range m.members[teamID] {
+				resp = append(resp, GrafanaTeamsReponse{UserID: id, Login: login})
+			}
+
+			json.NewEncoder(w).Encode(resp)
+		case http.MethodPost:
+			var req map[string]int
+
+			json.NewDecoder(r.Body).Decode(&req)
+
+			for login, id := range m.users {
+				if id == req["userId"] {
+					m.members[teamID][id] = login
+				}
+			}
+		case http.MethodDelete:
+			userID, _ := strconv.Atoi(parts[4])
+			delete(m.members[teamID], userID)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/api/search":
+		var resp []Folder
+		if uid, ok := m.folders[r.URL.Query().Get("query")]; ok {
+			resp = append(resp, Folder{UID: uid, Title: r.URL.Query().Get("query")})
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/folders":
+		var req map[string]string
+
+		json.NewDecoder(r.Body).Decode(&req)
+
+		uid := "uid-" + req["title"]
+		m.folders[req["title"]] = uid
+
+		json.NewEncoder(w).Encode(Folder{UID: uid, Title: req["title"]})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/access-control/folders/"):
+		var req map[string]string
+
+		json.NewDecoder(r.Body).Decode(&req)
+
+		m.permissions[parts[3]+"/"+parts[5]] = req["permission"]
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGrafanaSyncTeam(t *testing.T) {
+	mock := newMockGrafana(map[string]int{"usr1": 1, "usr2": 2, "usr3": 3})
+
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	grafana, err := New(server.URL, config_util.HTTPClientConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// First sync must create team and add existing users
+	result, err := grafana.SyncTeam(ctx, "prj1", []string{"usr1", "usr2", "usr4"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TeamID)
+	assert.ElementsMatch(t, []string{"usr1", "usr2"}, result.Added)
+	assert.Equal(t, []string{"usr4"}, result.Missing)
+	assert.Empty(t, result.Removed)
+	assert.Equal(t, map[int]string{1: "usr1", 2: "usr2"}, mock.members[1])
+
+	// Second sync must reuse team, add new users and remove stale ones
+	result, err = grafana.SyncTeam(ctx, "prj1", []string{"usr2", "usr3"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.TeamID)
+	assert.Equal(t, []string{"usr3"}, result.Added)
+	assert.Equal(t, []string{"usr1"}, result.Removed)
+	assert.Equal(t, map[int]string{2: "usr2", 3: "usr3"}, mock.members[1])
+	assert.Len(t, mock.teams, 1)
+
+	// Stale members must be kept when removal is disabled
+	result, err = grafana.SyncTeam(ctx, "prj1", []string{"usr1"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"usr1"}, result.Added)
+	assert.Empty(t, result.Removed)
+	assert.Len(t, mock.members[1], 3)
+}
+
+func TestGrafanaFolderTeamPermission(t *testing.T) {
+	mock := newMockGrafana(nil)
+
+	server := httptest.NewServer(mock)
+	defer server.Close()
+
+	grafana, err := New(server.URL, config_util.HTTPClientConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	folder, err := grafana.EnsureFolder(ctx, "prj1")
+	require.NoError(t, err)
+	assert.Equal(t, "uid-prj1", folder.UID)
+
+	// Existing folder must be reused
+	folder, err = grafana.EnsureFolder(ctx, "prj1")
+	require.NoError(t, err)
+	assert.Equal(t, "uid-prj1", folder.UID)
+	assert.Len(t, mock.folders, 1)
+
+	err = grafana.SetFolderTeamPermission(ctx, folder.UID, 2, FolderPermissionView)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"uid-prj1/2": "View"}, mock.permissions)
+}
+
+func TestGrafanaAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	grafana, err := New(server.URL, config_util.HTTPClientConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	_, err = grafana.SyncTeam(context.Background(), "prj1", []string{"usr1"}, true)
+	require.ErrorIs(t, err, ErrInvalidResponse)
+}
@@ -1,9 +1,7 @@
 package ipmi
 
 import (
-	"bytes"
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"time"
 	"unsafe"
@@ -48,10 +46,9 @@ func (i *IPMIClient) PowerReading(timeout time.Duration) (*PowerReading, error)
 		return nil, fmt.Errorf("failed to make IPMI request: %w", err)
 	}
 
-	// Check completion code
-	var completionCode uint16
-	if err := binary.Read(bytes.NewReader(resp.Data[0:1]), binary.BigEndian, &completionCode); err == nil && completionCode != 0 {
-		return nil, errors.New("received non zero completion code for IPMI power readings response")
+	// Check completion code which is the first byte of response data
+	if completionCode := resp.Data[0]; completionCode != 0 {
+		return nil, fmt.Errorf("received non zero completion code 0x%x for IPMI power readings response", completionCode)
 	}
 
 	// Get readings
@@ -1,9 +1,6 @@
 package ipmi
 
 import (
-	"bytes"
-	"encoding/binary"
-	"errors"
 	"fmt"
 	"time"
 	"unsafe"
@@ -42,10 +39,9 @@ func (i *IPMIClient) LanIP(timeout time.Duration) (*string, error) {
 		return nil, fmt.Errorf("failed to make IPMI request: %w", err)
 	}
 
-	// Check completion code
-	var completionCode uint16
-	if err := binary.Read(bytes.NewReader(resp.Data[0:1]), binary.BigEndian, &completionCode); err == nil && completionCode != 0 {
-		return nil, errors.New("received non zero completion code for IPMI LAN IP response")
+	// Check completion code which is the first byte of response data
+	if completionCode := resp.Data[0]; completionCode != 0 {
+		return nil, fmt.Errorf("received non zero completion code 0x%x for IPMI LAN IP response", completionCode)
 	}
 
 	// Get LAN IP
@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http/httputil"
 	"net/url"
 	"os"
@@ -18,7 +19,6 @@ import (
 	"strings"
 	"sync"
 	"syscall"
-	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/mahendrapaipuri/ceems/internal/common"
@@ -66,6 +66,11 @@ func (c *CEEMSLBAppConfig) Validate() error {
 		}
 	}
 
+	// Validate connections config
+	if err := c.LB.Connections.Validate(); err != nil {
+		return err
+	}
+
 	// Preflight checks for backends
 	for _, backend := range c.LB.Backends {
 		if backend.ID == "" {
@@ -126,8 +131,10 @@ func (c *CEEMSLBAppConfig) UnmarshalYAML(unmarshal func(interface{}) error) erro
 
 // CEEMSLBConfig contains the CEEMS load balancer config.
 type CEEMSLBConfig struct {
-	Backends []base.Backend `yaml:"backends"`
-	Strategy string         `yaml:"strategy"`
+	Backends      []base.Backend           `yaml:"backends"`
+	Strategy      string                   `yaml:"strategy"`
+	Connections   common.ConnectionsConfig `yaml:"connections"`
+	RequestsLimit int                      `yaml:"requests_limit"`
 }
 
 // CEEMSLoadBalancer represents the `ceems_lb` cli.
@@ -161,6 +168,10 @@ func (lb *CEEMSLoadBalancer) Main() error {
 			"config.file",
 			"Configuration file path.",
 		).Envar("CEEMS_LB_CONFIG_FILE").Default("").String()
+		drainTimeout = lb.App.Flag(
+			"web.drain-timeout",
+			"Maximum duration to wait for in-flight requests to finish during shutdown.",
+		).Default("30s").Duration()
 		maxProcs = lb.App.Flag(
 			"runtime.gomaxprocs", "The target number of CPUs Go will run on (GOMAXPROCS)",
 		).Envar("GOMAXPROCS").Default("1").Int()
@@ -286,6 +297,8 @@ func (lb *CEEMSLoadBalancer) Main() error {
 			WebSystemdSocket: *systemdSocket,
 			WebConfigFile:    webConfigFilePath,
 			APIServer:        config.Server,
+			Connections:      config.LB.Connections,
+			RequestsLimit:    config.LB.RequestsLimit,
 			Manager:          managers[lbType],
 		}
 
@@ -298,31 +311,7 @@ func (lb *CEEMSLoadBalancer) Main() error {
 		}
 
 		// Add backend servers to serverPool
-		for _, backend := range config.LB.Backends {
-			for _, backendURL := range backendURLs(lbType, backend) {
-				webURL, err := url.Parse(backendURL)
-				if err != nil {
-					// If we dont unwrap original error, the URL string will be printed to log which
-					// might contain sensitive passwords
-					logger.Error("Could not parse backend server URL", "backend_type", lbType, "err", errors.Unwrap(err))
-
-					continue
-				}
-
-				rp := httputil.NewSingleHostReverseProxy(webURL)
-
-				backendServer, err := lb_backend.New(lbType, webURL, rp, logger.With("backend_type", lbType))
-				if err != nil {
-					logger.Error("Could not set up backend server", "backend_type", lbType, "err", errors.Unwrap(err))
-
-					continue
-				}
-
-				rp.ErrorHandler = frontend.ErrorHandler(webURL, backendServer, lbs[lbType], logger.With("backend_type", lbType))
-
-				managers[lbType].Add(backend.ID, backendServer)
-			}
-		}
+		addBackends(managers[lbType], lbType, config.LB, lbs[lbType], logger.With("backend_type", lbType))
 
 		// Validate configured cluster IDs against the ones in CEEMS DB
 		if err := lbs[lbType].ValidateClusterIDs(ctx); err != nil {
@@ -332,17 +321,27 @@ func (lb *CEEMSLoadBalancer) Main() error {
 		}
 	}
 
-	// Declare wait group and tickers
+	// Declare wait group and cancel functions of health checkers
 	var wg sync.WaitGroup
 
-	for _, lbType := range lbTypes {
-		// Spawn a go routine to do health checks of backend TSDB servers
+	monitorCancels := make(map[base.LBType]context.CancelFunc, 2)
+
+	// startMonitor spawns a go routine to do health checks of backend servers
+	// of a given LB type
+	startMonitor := func(lbType base.LBType) {
+		monitorCtx, cancel := context.WithCancel(ctx)
+		monitorCancels[lbType] = cancel
+
 		wg.Add(1)
 
 		go func() {
 			defer wg.Done()
-			frontend.Monitor(ctx, managers[lbType], logger.With("backend_type", lbType))
+			frontend.Monitor(monitorCtx, managers[lbType], logger.With("backend_type", lbType))
 		}()
+	}
+
+	for _, lbType := range lbTypes {
+		startMonitor(lbType)
 
 		// Initializing the server in a goroutine so that
 		// it won't block the graceful shutdown handling below
@@ -353,19 +352,50 @@ func (lb *CEEMSLoadBalancer) Main() error {
 		}()
 	}
 
-	// Listen for the interrupt signal.
-	<-ctx.Done()
+	// Reload backends config on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	defer signal.Stop(hup)
+
+	// Listen for the interrupt and hangup signals.
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-hup:
+			logger.Info("Received SIGHUP. Reloading backends config")
+
+			newManagers, err := reloadBackends(ctx, configFilePath, lbTypes, lbs, logger)
+			if err != nil {
+				logger.Error("Failed to reload backends config. Continuing with current config", "err", err)
+
+				continue
+			}
+
+			// Restart health checkers with new managers
+			for _, lbType := range lbTypes {
+				monitorCancels[lbType]()
+
+				managers[lbType] = newManagers[lbType]
+				startMonitor(lbType)
+			}
+
+			logger.Info("Backends config reloaded")
+		}
+	}
 
 	// Wait for all DB go routines to finish
 	wg.Wait()
 
 	// Restore default behavior on the interrupt signal and notify user of shutdown.
 	stop()
-	logger.Info("Shutting down gracefully, press Ctrl+C again to force")
+	logger.Info("Shutting down gracefully, press Ctrl+C again to force", "drain_timeout", *drainTimeout)
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	shutDownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// The context is used to inform the server it has drain timeout to finish
+	// the in-flight requests it is currently handling
+	shutDownCtx, cancel := context.WithTimeout(context.Background(), *drainTimeout)
 	defer cancel()
 
 	for _, lbType := range lbTypes {
@@ -380,6 +410,94 @@ func (lb *CEEMSLoadBalancer) Main() error {
 	return nil
 }
 
+// reloadBackends reads the config file again and replaces the server pool managers
+// of load balancers with new ones. Load balancer types that are not running
+// currently cannot be added on reload. If reloading fails for any of the load
+// balancers, none of them will be updated.
+func reloadBackends(
+	ctx context.Context,
+	configFilePath string,
+	lbTypes []base.LBType,
+	lbs map[base.LBType]frontend.LoadBalancer,
+	logger *slog.Logger,
+) (map[base.LBType]serverpool.Manager, error) {
+	config, err := common.MakeConfig[CEEMSLBAppConfig](configFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	for _, t := range backendTypes(config) {
+		if !slices.Contains(lbTypes, t) {
+			logger.Warn("New load balancer types cannot be added on reload. Restart is needed", "backend_type", t)
+		}
+	}
+
+	// Make new managers and populate them with backends
+	managers := make(map[base.LBType]serverpool.Manager, len(lbTypes))
+
+	for _, lbType := range lbTypes {
+		managers[lbType], err = serverpool.New(config.LB.Strategy, logger.With("backend_type", lbType))
+		if err != nil {
+			return nil, err
+		}
+
+		addBackends(managers[lbType], lbType, config.LB, lbs[lbType], logger.With("backend_type", lbType))
+	}
+
+	// Validate all managers before swapping any of them so that either all
+	// load balancers use new config or none of them
+	for _, lbType := range lbTypes {
+		if err := lbs[lbType].ValidateManager(ctx, managers[lbType]); err != nil {
+			return nil, fmt.Errorf("failed to reload %s load balancer: %w", lbType, err)
+		}
+	}
+
+	for _, lbType := range lbTypes {
+		lbs[lbType].Swap(managers[lbType])
+	}
+
+	return managers, nil
+}
+
+// addBackends adds backend servers of type lbType to the server pool manager.
+func addBackends(
+	manager serverpool.Manager,
+	lbType base.LBType,
+	config CEEMSLBConfig,
+	lb frontend.LoadBalancer,
+	logger *slog.Logger,
+) {
+	// All backends share the same transport so that connections are reused
+	transport := common.NewHTTPTransport(config.Connections)
+
+	for _, backend := range config.Backends {
+		for _, backendURL := range backendURLs(lbType, backend) {
+			webURL, err := url.Parse(backendURL)
+			if err != nil {
+				// If we dont unwrap original error, the URL string will be printed to log which
+				// might contain sensitive passwords
+				logger.Error("Could not parse backend server URL", "err", errors.Unwrap(err))
+
+				continue
+			}
+
+			rp := httputil.NewSingleHostReverseProxy(webURL)
+			rp.Transport = transport
+
+			backendServer, err := lb_backend.New(lbType, webURL, rp, logger)
+			if err != nil {
+				logger.Error("Could not set up backend server", "err", errors.Unwrap(err))
+
+				continue
+			}
+
+			rp.ErrorHandler = frontend.ErrorHandler(webURL, backendServer, lb, logger)
+
+			manager.Add(backend.ID, backendServer)
+		}
+	}
+}
+
 // backendTypes returns LB backend types in the current config.
 func backendTypes(config *CEEMSLBAppConfig) []base.LBType {
 	var types []base.LBType
@@ -4,8 +4,11 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -15,6 +18,10 @@ import (
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
+	"github.com/mahendrapaipuri/ceems/pkg/lb/frontend"
+	"github.com/mahendrapaipuri/ceems/pkg/lb/serverpool"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -219,3 +226,60 @@ clusters:
 	_, err := common.MakeConfig[CEEMSLBAppConfig](configFilePath)
 	require.Error(t, err)
 }
+
+type mockLB struct {
+	frontend.LoadBalancer
+	validateErr error
+	manager     serverpool.Manager
+}
+
+func (lb *mockLB) ValidateManager(_ context.Context, _ serverpool.Manager) error {
+	return lb.validateErr
+}
+
+func (lb *mockLB) Swap(m serverpool.Manager) {
+	lb.manager = m
+}
+
+func TestReloadBackendsAllOrNothing(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Make config file
+	configFile := `
+---
+ceems_lb:
+  strategy: "round-robin"
+  backends:
+    - id: "default"
+      tsdb_urls:
+        - http://localhost:9090
+      pyroscope_urls:
+        - http://localhost:4040`
+
+	configFilePath := makeConfigFile(configFile, tmpDir)
+
+	lbTypes := []base.LBType{base.PromLB, base.PyroLB}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Second LB type fails validation
+	promLB := &mockLB{}
+	pyroLB := &mockLB{validateErr: errors.New("unknown cluster ID")}
+	lbs := map[base.LBType]frontend.LoadBalancer{base.PromLB: promLB, base.PyroLB: pyroLB}
+
+	_, err := reloadBackends(context.Background(), configFilePath, lbTypes, lbs, logger)
+	require.Error(t, err)
+
+	// None of the load balancers must be updated
+	assert.Nil(t, promLB.manager)
+	assert.Nil(t, pyroLB.manager)
+
+	// When all of them pass, all must be updated
+	pyroLB.validateErr = nil
+
+	managers, err := reloadBackends(context.Background(), configFilePath, lbTypes, lbs, logger)
+	require.NoError(t, err)
+	assert.Equal(t, managers[base.PromLB], promLB.manager)
+	assert.Equal(t, managers[base.PyroLB], pyroLB.manager)
+	assert.Len(t, promLB.manager.Backends()["default"], 1)
+	assert.Len(t, pyroLB.manager.Backends()["default"], 1)
+}
@@ -0,0 +1,187 @@
+//go:build cgo
+// +build cgo
+
+package frontend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
+)
+
+// Interval at which footprints of users are reported to CEEMS API server.
+const footprintReportInterval = time.Minute
+
+// footprintReporter records the number of requests and response bytes served
+// to each user and reports them periodically to CEEMS API server so that users
+// can see their footprint on monitoring infrastructure.
+type footprintReporter struct {
+	logger     *slog.Logger
+	source     string
+	endpoint   *url.URL
+	client     *http.Client
+	secret     string
+	interval   time.Duration
+	mu         sync.Mutex
+	footprints map[string]*ceems_api.Footprint // Footprints since last report keyed by user
+	done       chan struct{}
+}
+
+// newFootprintReporter returns a new footprintReporter. A nil reporter is returned
+// when URL of CEEMS API server is not configured as there is nowhere to report.
+func newFootprintReporter(c *Config, amw *authenticationMiddleware) *footprintReporter {
+	if amw.ceems.footprintEndpoint() == nil {
+		return nil
+	}
+
+	return &footprintReporter{
+		logger:     c.Logger,
+		source:     "lb_" + c.LBType.String(),
+		endpoint:   amw.ceems.footprintEndpoint(),
+		client:     amw.ceems.client,
+		secret:     amw.ceems.secret,
+		interval:   footprintReportInterval,
+		footprints: make(map[string]*ceems_api.Footprint),
+		done:       make(chan struct{}),
+	}
+}
+
+// Middleware records response bytes served to the logged user.
+func (fr *footprintReporter) Middleware(next http.Handler) http.Handler {
+	if fr == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Logged user header is only set by auth middleware on restricted paths
+		user := r.Header.Get(loggedUserHeader)
+		if user == "" {
+			user = r.Header.Get(grafanaUserHeader)
+		}
+
+		if user == "" {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		recorder := &middleware.ByteRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		fr.add(ceems_api.Footprint{Source: fr.source, User: user, Requests: 1, Bytes: recorder.Bytes})
+	})
+}
+
+// add adds f to footprints since last report.
+func (fr *footprintReporter) add(f ceems_api.Footprint) {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+
+	if _, ok := fr.footprints[f.User]; !ok {
+		fr.footprints[f.User] = &ceems_api.Footprint{Source: f.Source, User: f.User}
+	}
+
+	fr.footprints[f.User].Requests += f.Requests
+	fr.footprints[f.User].Bytes += f.Bytes
+}
+
+// report sends footprints since last report to CEEMS API server. When report
+// fails, footprints are retained so that they are sent in the next report.
+func (fr *footprintReporter) report(ctx context.Context) error {
+	fr.mu.Lock()
+	footprints := make([]ceems_api.Footprint, 0, len(fr.footprints))
+
+	for _, f := range fr.footprints {
+		footprints = append(footprints, *f)
+	}
+
+	fr.footprints = make(map[string]*ceems_api.Footprint)
+	fr.mu.Unlock()
+
+	if len(footprints) == 0 {
+		return nil
+	}
+
+	if err := fr.send(ctx, footprints); err != nil {
+		for _, f := range footprints {
+			fr.add(f)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// send makes the request to CEEMS API server with footprints.
+func (fr *footprintReporter) send(ctx context.Context, footprints []ceems_api.Footprint) error {
+	body, err := json.Marshal(footprints)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fr.endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// Add necessary headers
+	middleware.SetCEEMSUser(req, fr.secret)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := fr.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Start reports footprints periodically until reporter is stopped.
+func (fr *footprintReporter) Start() {
+	if fr == nil {
+		return
+	}
+
+	ticker := time.NewTicker(fr.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := fr.report(context.Background()); err != nil {
+				fr.logger.Error("Failed to report footprints to CEEMS API server", "err", err)
+			}
+		case <-fr.done:
+			return
+		}
+	}
+}
+
+// Stop stops periodic reports and makes a final report of remaining footprints.
+func (fr *footprintReporter) Stop(ctx context.Context) {
+	if fr == nil {
+		return
+	}
+
+	close(fr.done)
+
+	if err := fr.report(ctx); err != nil {
+		fr.logger.Error("Failed to report footprints to CEEMS API server", "err", err)
+	}
+}
@@ -0,0 +1,77 @@
+//go:build cgo
+// +build cgo
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
+	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
+	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFootprintReporter(t *testing.T) {
+	var fail atomic.Bool
+
+	var reported []ceems_api.Footprint
+
+	ceemsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Header[ceemsUserHeader]; !ok || r.Header.Get(middleware.CEEMSTokenHeader) != "internal" || fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		json.NewDecoder(r.Body).Decode(&reported)
+	}))
+	defer ceemsServer.Close()
+
+	webURL, err := url.Parse(ceemsServer.URL)
+	require.NoError(t, err)
+
+	c := &Config{Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), LBType: base.PyroLB}
+
+	// Reporter must be disabled without CEEMS API server
+	assert.Nil(t, newFootprintReporter(c, &authenticationMiddleware{}))
+
+	reporter := newFootprintReporter(c, &authenticationMiddleware{ceems: ceems{webURL: webURL, client: http.DefaultClient, secret: "internal"}})
+	require.NotNil(t, reporter)
+
+	handler := reporter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("01234"))
+	}))
+
+	for _, user := range []string{"usr1", "usr1", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/query", nil)
+		if user != "" {
+			req.Header.Set(grafanaUserHeader, user)
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Footprints must be retained when report fails
+	fail.Store(true)
+	require.Error(t, reporter.report(context.Background()))
+
+	fail.Store(false)
+	require.NoError(t, reporter.report(context.Background()))
+	assert.Equal(t, []ceems_api.Footprint{{Source: "lb_pyroscope", User: "usr1", Requests: 2, Bytes: 10}}, reported)
+
+	// Nothing must be reported after a successful report
+	reported = nil
+
+	reporter.Stop(context.Background())
+	assert.Nil(t, reported)
+}
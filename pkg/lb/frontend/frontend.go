@@ -14,21 +14,30 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
-	ceems_api_base "github.com/mahendrapaipuri/ceems/pkg/api/base"
+	"github.com/mahendrapaipuri/ceems/internal/common"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
 	ceems_api_cli "github.com/mahendrapaipuri/ceems/pkg/api/cli"
 	ceems_api_http "github.com/mahendrapaipuri/ceems/pkg/api/http"
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/serverpool"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/exporter-toolkit/web"
 )
 
+// metricsPath is the path at which metrics of load balancer are served.
+const metricsPath = "/metrics"
+
 // Custom errors.
 var (
-	ErrUnknownClusterID = errors.New("unknown cluster ID")
+	ErrUnknownClusterID    = errors.New("unknown cluster ID")
+	errUnexpectedStatus    = errors.New("unexpected status code")
+	errIncompleteOwnership = errors.New("ownership of some units missing in response")
 )
 
 // RetryContextKey is the key used to set context value for retry.
@@ -51,6 +60,9 @@ type LoadBalancer interface {
 	Start() error
 	Shutdown(ctx context.Context) error
 	ValidateClusterIDs(ctx context.Context) error
+	ValidateManager(ctx context.Context, m serverpool.Manager) error
+	Swap(m serverpool.Manager)
+	Reload(ctx context.Context, m serverpool.Manager) error
 }
 
 // Config makes a server config from CLI args.
@@ -61,6 +73,8 @@ type Config struct {
 	WebSystemdSocket bool
 	WebConfigFile    string
 	APIServer        ceems_api_cli.CEEMSAPIServerConfig
+	Connections      common.ConnectionsConfig
+	RequestsLimit    int
 	Manager          serverpool.Manager
 }
 
@@ -69,9 +83,14 @@ type loadBalancer struct {
 	logger    *slog.Logger
 	lbType    base.LBType
 	manager   serverpool.Manager
+	mu        sync.RWMutex
 	server    *http.Server
 	webConfig *web.FlagConfig
+	conns     common.ConnectionsConfig
 	amw       *authenticationMiddleware
+	reporter  *footprintReporter
+	metrics   *middleware.Metrics
+	reqsLimit int
 }
 
 // New returns a new instance of load balancer.
@@ -82,6 +101,15 @@ func New(c *Config) (LoadBalancer, error) {
 		return nil, fmt.Errorf("failed to setup auth middleware: %w", err)
 	}
 
+	// HTTP request metrics are labelled by the type of load balancer as all
+	// requests are proxied to backends
+	metrics, err := middleware.NewMetrics(
+		"ceems_lb", prometheus.DefaultRegisterer, func(_ *http.Request) string { return c.LBType.String() },
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup metrics middleware: %w", err)
+	}
+
 	return &loadBalancer{
 		logger: c.Logger,
 		lbType: c.LBType,
@@ -94,18 +122,69 @@ func New(c *Config) (LoadBalancer, error) {
 			WebSystemdSocket:   &c.WebSystemdSocket,
 			WebConfigFile:      &c.WebConfigFile,
 		},
-		manager: c.Manager,
-		amw:     amw,
+		manager:   c.Manager,
+		conns:     c.Connections,
+		amw:       amw,
+		reporter:  newFootprintReporter(c, amw),
+		metrics:   metrics,
+		reqsLimit: c.RequestsLimit,
 	}, nil
 }
 
 // ValidateClusterIDs validates the cluster IDs by checking them against DB.
 func (lb *loadBalancer) ValidateClusterIDs(ctx context.Context) error {
-	// Fetch all cluster IDs set in config file
-	for id := range lb.manager.Backends() {
-		lb.amw.clusterIDs = append(lb.amw.clusterIDs, id)
+	return lb.Reload(ctx, lb.currentManager())
+}
+
+// Reload validates the cluster IDs of backends in manager m and replaces the
+// current manager of the load balancer with m. If validation fails, current
+// manager is left untouched.
+func (lb *loadBalancer) Reload(ctx context.Context, m serverpool.Manager) error {
+	if err := lb.ValidateManager(ctx, m); err != nil {
+		return err
 	}
 
+	lb.Swap(m)
+
+	return nil
+}
+
+// ValidateManager checks if cluster IDs of backends in server pool manager
+// exist in CEEMS DB.
+func (lb *loadBalancer) ValidateManager(ctx context.Context, m serverpool.Manager) error {
+	return lb.validateClusterIDs(ctx, managerClusterIDs(m))
+}
+
+// Swap replaces the current server pool manager and cluster IDs. In-flight
+// requests continue to use the backends of the old manager.
+func (lb *loadBalancer) Swap(m serverpool.Manager) {
+	lb.mu.Lock()
+	lb.manager = m
+	lb.mu.Unlock()
+
+	lb.amw.setClusterIDs(managerClusterIDs(m))
+}
+
+// currentManager returns the current server pool manager.
+func (lb *loadBalancer) currentManager() serverpool.Manager {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+
+	return lb.manager
+}
+
+// managerClusterIDs returns cluster IDs of backends in server pool manager.
+func managerClusterIDs(m serverpool.Manager) []string {
+	clusterIDs := make([]string, 0, len(m.Backends()))
+	for id := range m.Backends() {
+		clusterIDs = append(clusterIDs, id)
+	}
+
+	return clusterIDs
+}
+
+// validateClusterIDs checks if clusterIDs exist in CEEMS DB.
+func (lb *loadBalancer) validateClusterIDs(ctx context.Context, clusterIDs []string) error {
 	// If neither CEEMD DB or API server is configured, return
 	// This means LB is used without any access control configured
 	if lb.amw.ceems.db == nil && lb.amw.ceems.clustersEndpoint() == nil {
@@ -116,9 +195,15 @@ func (lb *loadBalancer) ValidateClusterIDs(ctx context.Context) error {
 	var clusters []models.Cluster
 
 	if lb.amw.ceems.db != nil {
+		// Include monthly partitions of units table
+		table, err := ceems_api_http.UnitsSource(ctx, lb.amw.ceems.db, time.Time{}, time.Time{})
+		if err != nil {
+			return err
+		}
+
 		//nolint:gosec
 		rows, err := lb.amw.ceems.db.QueryContext(
-			ctx, "SELECT DISTINCT cluster_id, resource_manager FROM "+ceems_api_base.UnitsDBTableName,
+			ctx, "SELECT DISTINCT cluster_id, resource_manager FROM "+table,
 		)
 		if err != nil {
 			return err
@@ -150,8 +235,8 @@ func (lb *loadBalancer) ValidateClusterIDs(ctx context.Context) error {
 			return err
 		}
 
-		// Add necessary headers. Value of header is not important only its presence
-		req.Header.Add(ceemsUserHeader, "admin")
+		// Add necessary headers
+		middleware.SetCEEMSUser(req, lb.amw.ceems.secret)
 
 		// Make request
 		// If request failed, forbid the query. It can happen when CEEMS API server
@@ -201,7 +286,7 @@ validate:
 	}
 
 	// Check if ID is in actualClusterIDs
-	for _, id := range lb.amw.clusterIDs {
+	for _, id := range clusterIDs {
 		if !slices.Contains(actualClusterIDs, id) {
 			return fmt.Errorf(
 				"%w: %s. Cluster IDs in CEEMS DB are %s",
@@ -213,14 +298,44 @@ validate:
 	return nil
 }
 
+// handler returns the handler of load balancer server with middlewares applied.
+func (lb *loadBalancer) handler() http.Handler {
+	// Apply middleware
+	handler := middleware.Chain(
+		http.HandlerFunc(lb.Serve),
+		middleware.Logging(lb.logger),
+		lb.metrics.Middleware,
+		middleware.SecurityHeaders(),
+		middleware.RateLimit(lb.reqsLimit),
+		lb.amw.Middleware,
+		lb.reporter.Middleware,
+	)
+
+	// Metrics of load balancer itself are served at metrics path and rest of
+	// the requests are proxied to backends
+	router := http.NewServeMux()
+	router.Handle(metricsPath, promhttp.Handler())
+	router.Handle("/", handler)
+
+	return router
+}
+
 // Start server.
 func (lb *loadBalancer) Start() error {
-	// Apply middleware
-	lb.server.Handler = lb.amw.Middleware(http.HandlerFunc(lb.Serve))
+	lb.server.Handler = lb.handler()
+
+	// Report footprints of users to CEEMS API server periodically
+	go lb.reporter.Start()
+
+	// Configure HTTP/2 and keep-alives of connections
+	if err := common.ConfigureHTTPServer(lb.server, lb.conns); err != nil {
+		return err
+	}
+
 	lb.logger.Info("Starting "+base.CEEMSLoadBalancerAppName, "listening", lb.server.Addr)
 
 	// Listen for requests
-	if err := web.ListenAndServe(lb.server, lb.webConfig, lb.logger); err != nil &&
+	if err := common.ListenAndServe(lb.server, lb.webConfig, lb.conns, lb.logger); err != nil &&
 		!errors.Is(err, http.ErrServerClosed) {
 		lb.logger.Error("Failed to Listen and Serve HTTP server", "err", err)
 
@@ -241,6 +356,14 @@ func (lb *loadBalancer) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop ownership cache
+	if lb.amw.ownership != nil {
+		lb.amw.ownership.Stop()
+	}
+
+	// Report remaining footprints before shutting down
+	lb.reporter.Stop(ctx)
+
 	// Shutdown the server
 	if err := lb.server.Shutdown(ctx); err != nil {
 		lb.logger.Error("Failed to shutdown HTTP server", "err", err)
@@ -278,7 +401,7 @@ func (lb *loadBalancer) Serve(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Choose target based on query Period
-	if target := lb.manager.Target(id, queryPeriod); target != nil {
+	if target := lb.currentManager().Target(id, queryPeriod); target != nil {
 		target.Serve(w, r)
 
 		return
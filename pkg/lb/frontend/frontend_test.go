@@ -48,6 +48,12 @@ INSERT INTO units VALUES(1, 'slurm-0', 'slurm');
 INSERT INTO units VALUES(2, 'os-0', 'openstack');
 INSERT INTO units VALUES(3, 'os-1', 'openstack');
 INSERT INTO units VALUES(4, 'slurm-1', 'slurm');
+CREATE TABLE units_2024_01 (
+	"id" integer not null primary key,
+	"cluster_id" text,
+	"resource_manager" text
+);
+INSERT INTO units_2024_01 VALUES(5, 'os-2', 'openstack');
 COMMIT;`
 
 	_, err = db.Exec(stmts)
@@ -317,6 +323,7 @@ func TestValidateClusterIDsWithDBPass(t *testing.T) {
 
 	manager.Add("slurm-0", backend)
 	manager.Add("os-1", backend)
+	manager.Add("os-2", backend) // Units of cluster are only in monthly partition
 
 	// make minimal config
 	config := &Config{
@@ -371,6 +378,61 @@ func TestValidateClusterIDsWithDBFail(t *testing.T) {
 	require.Error(t, lb.ValidateClusterIDs(context.Background()))
 }
 
+func TestReload(t *testing.T) {
+	tmpDir := t.TempDir()
+	err := setupClusterIDsDB(tmpDir)
+	require.NoError(t, err, "failed to setup test DB")
+
+	dummyServer := dummyTSDBServer("slurm-0")
+	defer dummyServer.Close()
+	backendURL, err := url.Parse(dummyServer.URL)
+	require.NoError(t, err)
+
+	rp := httputil.NewSingleHostReverseProxy(backendURL)
+	backend := backend.NewTSDB(backendURL, rp, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	// Start manager
+	manager, err := serverpool.New("round-robin", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	manager.Add("slurm-0", backend)
+
+	// make minimal config
+	config := &Config{
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Manager: manager,
+		Address: "localhost:9030", // dummy address
+	}
+	config.APIServer.Data.Path = tmpDir
+
+	// New load balancer
+	lb, err := New(config)
+	require.NoError(t, err)
+	require.NoError(t, lb.ValidateClusterIDs(context.Background()))
+
+	// Reload with a manager with unknown cluster ID must fail and keep current manager
+	badManager, err := serverpool.New("least-connection", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	badManager.Add("unknown", backend)
+	require.Error(t, lb.Reload(context.Background(), badManager))
+
+	l, ok := lb.(*loadBalancer)
+	require.True(t, ok)
+	assert.Equal(t, manager, l.currentManager())
+	assert.True(t, l.amw.isValidClusterID("slurm-0"))
+
+	// Reload with a new strategy and backends
+	newManager, err := serverpool.New("least-connection", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	newManager.Add("os-1", backend)
+	require.NoError(t, lb.Reload(context.Background(), newManager))
+	assert.Equal(t, newManager, l.currentManager())
+	assert.True(t, l.amw.isValidClusterID("os-1"))
+	assert.False(t, l.amw.isValidClusterID("slurm-0"))
+}
+
 func TestValidateClusterIDsWithAPIPass(t *testing.T) {
 	// Test CEEMS API server
 	expected := ceems_api_http.Response[models.Cluster]{
@@ -468,3 +530,40 @@ func TestValidateClusterIDsWithAPIFail(t *testing.T) {
 	// Validate cluster IDs
 	require.Error(t, lb.ValidateClusterIDs(context.Background()))
 }
+
+func TestLoadBalancerMetricsAndRateLimit(t *testing.T) {
+	// Start manager
+	manager, err := serverpool.New("round-robin", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	// make minimal config
+	config := &Config{
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Manager:       manager,
+		Address:       "localhost:9030", // dummy address
+		RequestsLimit: 1,
+	}
+
+	// New load balancer
+	lb, err := New(config)
+	require.NoError(t, err)
+
+	handler := lb.(*loadBalancer).handler()
+
+	// First request must pass rate limiter and fail at cluster ID validation
+	responseRecorder := httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusBadRequest, responseRecorder.Code)
+
+	// Second request must be rate limited
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/test", nil))
+	assert.Equal(t, http.StatusTooManyRequests, responseRecorder.Code)
+
+	// Metrics must be served and not be rate limited
+	responseRecorder = httptest.NewRecorder()
+	handler.ServeHTTP(responseRecorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, responseRecorder.Code)
+	assert.Contains(t, responseRecorder.Body.String(), `ceems_lb_http_requests_total{code="400",handler="tsdb",method="GET"}`)
+	assert.Contains(t, responseRecorder.Body.String(), `ceems_lb_http_requests_total{code="429",handler="tsdb",method="GET"}`)
+}
@@ -10,33 +10,28 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"slices"
-	"strconv"
 	"strings"
 	"time"
 
 	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/mahendrapaipuri/ceems/internal/common"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/backend"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/serverpool"
 	"google.golang.org/protobuf/proto"
 )
 
-// These functions are nicked from https://github.com/prometheus/prometheus/blob/main/web/api/v1/api.go
 var (
 	// MinTime is the default timestamp used for the begin of optional time ranges.
 	// Exposed to let downstream projects to reference it.
-	MinTime = time.Unix(math.MinInt64/1000+62135596801, 0).UTC()
+	MinTime = common.MinTime
 
 	// MaxTime is the default timestamp used for the end of optional time ranges.
 	// Exposed to let downstream projects to reference it.
-	MaxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999).UTC()
-
-	minTimeFormatted = MinTime.Format(time.RFC3339Nano)
-	maxTimeFormatted = MaxTime.Format(time.RFC3339Nano)
+	MaxTime = common.MaxTime
 )
 
 // AllowRetry checks if a failed request can be retried.
@@ -239,7 +234,7 @@ func parseTimeParam(r *http.Request, paramName string, defaultValue time.Time) (
 		return defaultValue, nil
 	}
 
-	result, err := parseTime(val)
+	result, err := common.ParseTime(val)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("invalid time value for '%s': %w", paramName, err)
 	}
@@ -247,33 +242,6 @@ func parseTimeParam(r *http.Request, paramName string, defaultValue time.Time) (
 	return result, nil
 }
 
-// Convert time parameter string into time.Time.
-func parseTime(s string) (time.Time, error) {
-	if t, err := strconv.ParseFloat(s, 64); err == nil {
-		s, ns := math.Modf(t)
-		ns = math.Round(ns*1000) / 1000
-
-		return time.Unix(int64(s), int64(ns*float64(time.Second))).UTC(), nil
-	}
-
-	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
-		return t, nil
-	}
-
-	// Stdlib's time parser can only handle 4 digit years. As a workaround until
-	// that is fixed we want to at least support our own boundary times.
-	// Context: https://github.com/prometheus/client_golang/issues/614
-	// Upstream issue: https://github.com/golang/go/issues/20555
-	switch s {
-	case minTimeFormatted:
-		return MinTime, nil
-	case maxTimeFormatted:
-		return MaxTime, nil
-	}
-
-	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
-}
-
 // healthCheck monitors the status of all backend servers.
 func healthCheck(ctx context.Context, manager serverpool.Manager, logger *slog.Logger) {
 	aliveChannel := make(chan bool, 1)
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	querierv1 "github.com/grafana/pyroscope/api/gen/proto/go/querier/v1"
+	"github.com/mahendrapaipuri/ceems/internal/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
@@ -24,7 +25,7 @@ func TestParseTimeParam(t *testing.T) {
 		asError func() error
 	}
 
-	ts, err := parseTime("1582468023986")
+	ts, err := common.ParseTime("1582468023986")
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -58,7 +59,7 @@ func TestParseTimeParam(t *testing.T) {
 			result: resultType{
 				asTime: time.Time{},
 				asError: func() error {
-					_, err := parseTime("baz")
+					_, err := common.ParseTime("baz")
 
 					return fmt.Errorf("invalid time value for '%s': %w", "foo", err)
 				},
@@ -83,74 +84,6 @@ func TestParseTimeParam(t *testing.T) {
 	}
 }
 
-func TestParseTime(t *testing.T) {
-	ts, err := time.Parse(time.RFC3339Nano, "2015-06-03T13:21:58.555Z")
-	require.NoError(t, err)
-
-	tests := []struct {
-		input  string
-		fail   bool
-		result time.Time
-	}{
-		{
-			input: "",
-			fail:  true,
-		},
-		{
-			input: "abc",
-			fail:  true,
-		},
-		{
-			input: "30s",
-			fail:  true,
-		},
-		{
-			input:  "123",
-			result: time.Unix(123, 0),
-		},
-		{
-			input:  "123.123",
-			result: time.Unix(123, 123000000),
-		},
-		{
-			input:  "2015-06-03T13:21:58.555Z",
-			result: ts,
-		},
-		{
-			input:  "2015-06-03T14:21:58.555+01:00",
-			result: ts,
-		},
-		{
-			// Test float rounding.
-			input:  "1543578564.705",
-			result: time.Unix(1543578564, 705*1e6),
-		},
-		{
-			input:  MinTime.Format(time.RFC3339Nano),
-			result: MinTime,
-		},
-		{
-			input:  MaxTime.Format(time.RFC3339Nano),
-			result: MaxTime,
-		},
-	}
-
-	for _, test := range tests {
-		ts, err := parseTime(test.input)
-		if !test.fail {
-			require.NoError(t, err)
-			// assert.Equal(t, test.result, ts)
-			if !ts.Equal(test.result) {
-				t.Errorf("%s: expected %s, got %s", test.input, test.result, ts)
-			}
-
-			continue
-		}
-
-		assert.Error(t, err)
-	}
-}
-
 func TestParseTSDBQueryParams(t *testing.T) {
 	tests := []struct {
 		path   string
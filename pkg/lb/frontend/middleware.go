@@ -4,6 +4,7 @@
 package frontend
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -18,20 +19,25 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/mahendrapaipuri/ceems/internal/middleware"
 	ceems_api_base "github.com/mahendrapaipuri/ceems/pkg/api/base"
 	ceems_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
 	"github.com/mahendrapaipuri/ceems/pkg/lb/base"
+	"github.com/mahendrapaipuri/ceems/pkg/tsdb"
 	"github.com/prometheus/common/config"
 )
 
 // Headers.
 const (
-	grafanaUserHeader    = "X-Grafana-User"
-	dashboardUserHeader  = "X-Dashboard-User"
-	loggedUserHeader     = "X-Logged-User"
-	adminUserHeader      = "X-Admin-User"
-	ceemsUserHeader      = "X-Ceems-User"
+	grafanaUserHeader    = middleware.GrafanaUserHeader
+	dashboardUserHeader  = middleware.DashboardUserHeader
+	loggedUserHeader     = middleware.LoggedUserHeader
+	adminUserHeader      = middleware.AdminUserHeader
+	ceemsUserHeader      = middleware.CEEMSUserHeader
 	ceemsClusterIDHeader = "X-Ceems-Cluster-Id"
 )
 
@@ -82,6 +88,7 @@ type ceems struct {
 	db     *sql.DB
 	webURL *url.URL
 	client *http.Client
+	secret string // Shared internal secret to authenticate requests to CEEMS API server
 }
 
 func (c *ceems) verifyEndpoint() *url.URL {
@@ -100,11 +107,27 @@ func (c *ceems) clustersEndpoint() *url.URL {
 	return nil
 }
 
+func (c *ceems) footprintEndpoint() *url.URL {
+	if c.webURL != nil {
+		return c.webURL.JoinPath("/api/v1/stats/monitoring-usage/admin")
+	}
+
+	return nil
+}
+
+// Maximum number of units whose ownership is cached.
+const maxCachedOwnership = 100000
+
+// Regex that will match max-age directive of Cache-Control header.
+var regexpMaxAge = regexp.MustCompile(`max-age=(\d+)`)
+
 // authenticationMiddleware implements the auth middleware for LB.
 type authenticationMiddleware struct {
 	logger        *slog.Logger
 	ceems         ceems
+	ownership     *ttlcache.Cache[string, struct{}] // Units verified to be owned by users using CEEMS API server
 	clusterIDs    []string
+	mu            sync.RWMutex
 	pathsACLRegex *regexp.Regexp
 	parseRequest  func(*ReqParams, *http.Request) error
 }
@@ -160,9 +183,20 @@ func newAuthMiddleware(c *Config) (*authenticationMiddleware, error) {
 			db:     db,
 			webURL: ceemsWebURL,
 			client: ceemsClient,
+			secret: string(c.APIServer.Web.InternalSecret),
 		},
 	}
 
+	// Cache ownership of units verified by CEEMS API server to avoid making
+	// requests for the same units on every query
+	if db == nil && ceemsWebURL != nil {
+		amw.ownership = ttlcache.New(
+			ttlcache.WithCapacity[string, struct{}](maxCachedOwnership),
+			ttlcache.WithDisableTouchOnHit[string, struct{}](),
+		)
+		go amw.ownership.Start()
+	}
+
 	// Setup parsing functions based on LB type
 	switch c.LBType {
 	case base.PromLB:
@@ -176,6 +210,22 @@ func newAuthMiddleware(c *Config) (*authenticationMiddleware, error) {
 	return amw, nil
 }
 
+// setClusterIDs replaces the valid cluster IDs of the middleware.
+func (amw *authenticationMiddleware) setClusterIDs(ids []string) {
+	amw.mu.Lock()
+	defer amw.mu.Unlock()
+
+	amw.clusterIDs = ids
+}
+
+// isValidClusterID returns true if id is one of the valid cluster IDs.
+func (amw *authenticationMiddleware) isValidClusterID(id string) bool {
+	amw.mu.RLock()
+	defer amw.mu.RUnlock()
+
+	return slices.Contains(amw.clusterIDs, id)
+}
+
 // Check UUIDs in query belong to user or not.
 func (amw *authenticationMiddleware) isUserUnit(
 	ctx context.Context,
@@ -187,49 +237,122 @@ func (amw *authenticationMiddleware) isUserUnit(
 	// Always prefer checking with DB connection directly if it is available
 	// As DB query is way more faster than HTTP API request
 	if amw.ceems.db != nil {
-		return ceems_api.VerifyOwnership(ctx, user, clusterIDs, uuids, starts, amw.ceems.db, amw.logger)
+		if ceems_api.VerifyOwnership(ctx, user, false, clusterIDs, uuids, starts, amw.ceems.db, amw.logger) {
+			return true
+		}
+
+		// Only admin users in DB are known here. Admin users from other sources
+		// like LDAP group are resolved by CEEMS API server and hence, verify
+		// with API when it is available
+		if amw.ceems.webURL == nil {
+			return false
+		}
+	}
+
+	// Only verify the units whose ownership is not cached
+	key := func(uuid string) string {
+		return strings.Join([]string{user, strings.Join(clusterIDs, ","), uuid}, "/")
+	}
+
+	var unverified []string
+
+	for _, uuid := range uuids {
+		if amw.ownership == nil || !amw.ownership.Has(key(uuid)) {
+			unverified = append(unverified, uuid)
+		}
+	}
+
+	if len(uuids) > 0 && len(unverified) == 0 {
+		return true
 	}
 
 	// If CEEMS URL is available make a API request
 	// Any errors in making HTTP request will fail the query. This can happen due
 	// to deployment issues and by failing queries we make operators to look into
 	// what is happening
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, amw.ceems.verifyEndpoint().String(), nil)
+	ownership, maxAge, err := amw.verifyWithAPI(ctx, user, ceems_api.VerifyRequest{
+		UUIDs:      unverified,
+		ClusterIDs: clusterIDs,
+		Starts:     starts,
+	})
 	if err != nil {
-		amw.logger.Debug("Failed to create new request for unit ownership verification",
+		amw.logger.Debug("Failed to verify unit ownership",
 			"user", user, "queried_uuids", strings.Join(uuids, ","), "err", err)
 
 		return false
 	}
 
-	// Add uuids to request
-	urlVals := url.Values{"uuid": uuids, "cluster_id": clusterIDs}
-	for _, s := range starts {
-		urlVals.Add("time", strconv.FormatInt(s, 10))
+	for _, o := range ownership {
+		if !o.Owned {
+			amw.logger.Debug("Unauthorised query", "user", user, "queried_uuids", strings.Join(uuids, ","))
+
+			return false
+		}
+	}
+
+	// Cache ownership of units only when all the units are owned by user
+	if amw.ownership != nil && maxAge > 0 {
+		for _, o := range ownership {
+			amw.ownership.Set(key(o.UUID), struct{}{}, maxAge)
+		}
+	}
+
+	return true
+}
+
+// verifyWithAPI returns ownership of units in request using CEEMS API server
+// along with the duration for which ownership can be cached.
+func (amw *authenticationMiddleware) verifyWithAPI(
+	ctx context.Context,
+	user string,
+	verifyReq ceems_api.VerifyRequest,
+) ([]ceems_api.UnitOwnership, time.Duration, error) {
+	body, err := json.Marshal(verifyReq)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	req.URL.RawQuery = urlVals.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, amw.ceems.verifyEndpoint().String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// Add necessary headers
 	req.Header.Add(grafanaUserHeader, user)
+	req.Header.Set("Content-Type", "application/json")
 
 	// Make request
 	// If request failed, forbid the query. It can happen when CEEMS API server
 	// goes offline and we should wait for it to come back online
-	if resp, err := amw.ceems.client.Do(req); err != nil {
-		amw.logger.Debug("Failed to make request for unit ownership verification",
-			"user", user, "queried_uuids", strings.Join(uuids, ","), "err", err)
+	resp, err := amw.ceems.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
 
-		return false
-	} else if resp.StatusCode != http.StatusOK {
-		defer resp.Body.Close()
-		amw.logger.Debug("Unauthorised query", "user", user,
-			"queried_uuids", strings.Join(uuids, ","), "status_code", resp.StatusCode)
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("%w: %d", errUnexpectedStatus, resp.StatusCode)
+	}
 
-		return false
+	var response ceems_api.Response[ceems_api.UnitOwnership]
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, 0, err
 	}
 
-	return true
+	// Every queried unit must be present in the response
+	if len(response.Data) != len(verifyReq.UUIDs) {
+		return nil, 0, errIncompleteOwnership
+	}
+
+	// Get max age from cache hints
+	var maxAge time.Duration
+	if matches := regexpMaxAge.FindStringSubmatch(resp.Header.Get("Cache-Control")); len(matches) == 2 {
+		if seconds, err := strconv.Atoi(matches[1]); err == nil {
+			maxAge = time.Duration(seconds) * time.Second
+		}
+	}
+
+	return response.Data, maxAge, nil
 }
 
 // Middleware function, which will be called for each request.
@@ -241,17 +364,27 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 
 		var err error
 
-		// Get cluster id from X-Ceems-Cluster-Id header
-		// This is most important and request parameter that we need
-		// to proxy request. Rest of them are optional
+		// Get cluster id from X-Ceems-Cluster-Id header. This is usually set
+		// per datasource in Grafana so that queries without ceems_id label can
+		// still be routed to the correct cluster
 		reqParams.clusterID = r.Header.Get(ceemsClusterIDHeader)
 
-		// Verify clusterID is in list of valid cluster IDs
-		if !slices.Contains(amw.clusterIDs, reqParams.clusterID) {
+		// For restricted endpoints, clone request and parse query params. If
+		// query has a ceems_id label, it takes precedence over the header
+		if amw.pathsACLRegex.MatchString(r.URL.Path) {
+			if err = amw.parseRequest(reqParams, r); err != nil {
+				amw.logger.Error("Failed to parse query in the request", "err", err)
+			}
+		}
+
+		// Verify clusterID is in list of valid cluster IDs. Cluster ID is the
+		// most important request parameter that we need to proxy request.
+		// Rest of them are optional
+		if !amw.isValidClusterID(reqParams.clusterID) {
 			// Write an error and stop the handler chain
 			w.WriteHeader(http.StatusBadRequest)
 
-			response := ceems_api.Response[any]{
+			response := tsdb.Response{
 				Status:    "error",
 				ErrorType: "bad_request",
 				Error:     "invalid cluster ID",
@@ -275,26 +408,15 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 			goto end
 		}
 
-		// Clone request, parse query params and set them in request context
-		// This will ensure we set query params in request's context always
-		err = amw.parseRequest(reqParams, r)
-		if err != nil {
-			amw.logger.Error("Failed to parse query in the request", "err", err)
-		}
-
-		// Remove any X-Admin-User header or X-Logged-User if passed
-		r.Header.Del(adminUserHeader)
-		r.Header.Del(loggedUserHeader)
-
-		// Check if username header is available
-		loggedUser = r.Header.Get(grafanaUserHeader)
+		// Check if username header is available and set logged user header
+		loggedUser = middleware.LoggedUser(r)
 		if loggedUser == "" {
 			amw.logger.Error("Grafana user Header not found. Denying authentication")
 
 			// Write an error and stop the handler chain
 			w.WriteHeader(http.StatusUnauthorized)
 
-			response := ceems_api.Response[any]{
+			response := tsdb.Response{
 				Status:    "error",
 				ErrorType: "unauthorized",
 				Error:     "no user header found",
@@ -309,9 +431,6 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 
 		amw.logger.Debug("middleware", "logged_user", loggedUser, "url", r.URL)
 
-		// Set logged user header
-		r.Header.Set(loggedUserHeader, loggedUser)
-
 		// Check if user is querying for his/her own compute units by looking to DB
 		if !amw.isUserUnit(
 			r.Context(),
@@ -323,7 +442,7 @@ func (amw *authenticationMiddleware) Middleware(next http.Handler) http.Handler
 			// Write an error and stop the handler chain
 			w.WriteHeader(http.StatusForbidden)
 
-			response := ceems_api.Response[any]{
+			response := tsdb.Response{
 				Status:    "error",
 				ErrorType: "forbidden",
 				Error:     "user do not have permissions to view unit metrics",
@@ -6,6 +6,7 @@ package frontend
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
@@ -13,10 +14,11 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"path/filepath"
-	"strconv"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/jellydator/ttlcache/v3"
 	http_api "github.com/mahendrapaipuri/ceems/pkg/api/http"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,13 +91,18 @@ INSERT INTO users VALUES(4, 'rm-1', 'usr1', '["prj1"]');
 INSERT INTO users VALUES(5, 'rm-1', 'usr2', '["prj1"]');
 INSERT INTO users VALUES(6, 'rm-1', 'usr4', '["prj4"]');
 INSERT INTO users VALUES(7, 'rm-1', 'usr5', '["prj5"]');
-CREATE TABLE admin_users (
+CREATE TABLE roles (
 	"id" integer not null primary key,
-	"source" text,
-	"users" text
+	"username" text,
+	"role" text,
+	"source" text
 );
-INSERT INTO admin_users VALUES(1, 'ceems', '["adm1","adm2","adm3"]');
-INSERT INTO admin_users VALUES(2, 'grafana', '["adm4","adm5","adm6"]');
+INSERT INTO roles VALUES(1, 'adm1', 'admin', 'ceems');
+INSERT INTO roles VALUES(2, 'adm2', 'admin', 'ceems');
+INSERT INTO roles VALUES(3, 'adm3', 'admin', 'ceems');
+INSERT INTO roles VALUES(4, 'adm4', 'admin', 'grafana');
+INSERT INTO roles VALUES(5, 'adm5', 'admin', 'grafana');
+INSERT INTO roles VALUES(6, 'adm6', 'admin', 'grafana');
 COMMIT;`
 
 	_, err = db.Exec(stmts)
@@ -156,6 +163,9 @@ func setupMiddlewareWithAPI(tmpDir string) (http.Handler, error) {
 	return amw.Middleware(nextHandler), nil
 }
 
+// Admin users in LDAP group of mock CEEMS API server.
+var mockLDAPAdmins = []string{"ldapadm"}
+
 func setupCEEMSAPI(db *sql.DB) *httptest.Server {
 	// We copy the logic from CEEMS API server here for testing
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -166,29 +176,31 @@ func setupCEEMSAPI(db *sql.DB) *httptest.Server {
 		// Get current logged user and dashboard user from headers
 		user := r.Header.Get(grafanaUserHeader)
 
-		// Get list of queried uuids and cluster IDs
-		uuids := r.URL.Query()["uuid"]
-		rmIDs := r.URL.Query()["cluster_id"]
+		// Get list of queried uuids, cluster IDs and start times
+		var req http_api.VerifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.UUIDs) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
 
-		var starts []int64
-
-		for _, s := range r.URL.Query()["start"] {
-			if is, err := strconv.ParseInt(s, 10, 64); err == nil {
-				starts = append(starts, is)
-			}
+			return
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		// Check if user is owner of the queries uuids
-		if http_api.VerifyOwnership(ctx, user, rmIDs, uuids, starts, db, slog.New(slog.NewTextHandler(io.Discard, nil))) { //nolint:contextcheck
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("success"))
-		} else {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte("fail"))
+		// Admin users in LDAP group are only resolved by CEEMS API server
+		isAdmin := slices.Contains(mockLDAPAdmins, user)
+
+		// Check ownership of the queried uuids
+		ownership, err := http_api.UnitsOwnership(ctx, user, isAdmin, req.ClusterIDs, req.UUIDs, req.Starts, db, slog.New(slog.NewTextHandler(io.Discard, nil))) //nolint:contextcheck
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
 		}
+
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(&http_api.Response[http_api.UnitOwnership]{Status: "success", Data: ownership})
 	}))
 
 	return server
@@ -278,6 +290,29 @@ func TestMiddlewareWithDB(t *testing.T) {
 			header: true,
 			code:   200,
 		},
+		{
+			name:   "pass due to cluster ID from ceems_id label without cluster header",
+			req:    "/query?query=foo{uuid=\"1479763\",ceems_id=\"rm-1\"}&time=1735045414",
+			user:   "usr1",
+			header: true,
+			code:   200,
+		},
+		{
+			name:   "forbid due to invalid cluster ID in ceems_id label",
+			req:    "/query?query=foo{uuid=\"1479763\",ceems_id=\"rm-2\"}&time=1735045414",
+			id:     "rm-0",
+			user:   "usr1",
+			header: true,
+			code:   400,
+		},
+		{
+			name:   "forbid due to ceems_id label overriding cluster header",
+			req:    "/query?query=foo{uuid=\"1479765\",ceems_id=\"rm-1\"}&time=1735045414",
+			id:     "rm-0",
+			user:   "usr2",
+			header: true,
+			code:   403,
+		},
 		{
 			name:   "forbid due to no uuid",
 			req:    "/query_range?query=foo{uuid=\"\"}",
@@ -325,3 +360,74 @@ func TestMiddlewareWithDB(t *testing.T) {
 		assert.Equal(t, test.code, resAPI.StatusCode, "%s with API", test.name)
 	}
 }
+
+func TestIsUserUnitOwnershipCache(t *testing.T) {
+	db, err := setupTestDB(t.TempDir())
+	require.NoError(t, err, "failed to setup test DB")
+
+	// Count the number of requests made to CEEMS API server
+	var numRequests int
+
+	ceemsServer := setupCEEMSAPI(db)
+	defer ceemsServer.Close()
+
+	counter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests++
+
+		ceemsServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer counter.Close()
+
+	ceemsURL, err := url.Parse(counter.URL)
+	require.NoError(t, err)
+
+	amw := authenticationMiddleware{
+		logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ceems:     ceems{webURL: ceemsURL, client: http.DefaultClient},
+		ownership: ttlcache.New[string, struct{}](),
+	}
+
+	// Owned units must be cached and only uncached units must be verified
+	assert.True(t, amw.isUserUnit(context.Background(), "usr1", []string{"rm-0"}, []string{"1479763"}, nil))
+	assert.True(t, amw.isUserUnit(context.Background(), "usr1", []string{"rm-0"}, []string{"1479763"}, nil))
+	assert.Equal(t, 1, numRequests)
+
+	// Units that are not owned must not be cached
+	assert.False(t, amw.isUserUnit(context.Background(), "usr1", []string{"rm-0"}, []string{"1479763", "1479765"}, nil))
+	assert.False(t, amw.isUserUnit(context.Background(), "usr1", []string{"rm-0"}, []string{"1479765"}, nil))
+	assert.Equal(t, 3, numRequests)
+
+	// Cache must be per user
+	assert.False(t, amw.isUserUnit(context.Background(), "usr3", []string{"rm-0"}, []string{"1479763"}, nil))
+	assert.Equal(t, 4, numRequests)
+}
+
+func TestIsUserUnitAdminFromAPI(t *testing.T) {
+	db, err := setupTestDB(t.TempDir())
+	require.NoError(t, err, "failed to setup test DB")
+
+	ceemsServer := setupCEEMSAPI(db)
+	defer ceemsServer.Close()
+
+	ceemsURL, err := url.Parse(ceemsServer.URL)
+	require.NoError(t, err)
+
+	// Admin users in LDAP group are unknown to DB and must be denied without API
+	amw := authenticationMiddleware{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ceems:  ceems{db: db},
+	}
+	assert.False(t, amw.isUserUnit(context.Background(), "ldapadm", []string{"rm-0"}, []string{"1479765"}, nil))
+
+	// When API is available, admin users resolved by CEEMS API server must pass
+	amw.ceems.webURL = ceemsURL
+	amw.ceems.client = http.DefaultClient
+	assert.True(t, amw.isUserUnit(context.Background(), "ldapadm", []string{"rm-0"}, []string{"1479765"}, nil))
+
+	// Units of other users must still be denied for non admin users
+	assert.False(t, amw.isUserUnit(context.Background(), "usr3", []string{"rm-0"}, []string{"1479765"}, nil))
+
+	// Admin users in DB must not need API requests
+	ceemsServer.Close()
+	assert.True(t, amw.isUserUnit(context.Background(), "adm1", []string{"rm-0"}, []string{"1479765"}, nil))
+}
@@ -0,0 +1,314 @@
+// Package ldap implements LDAP client to resolve group membership of users
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+// Default settings.
+const (
+	defaultUserFilter        = "(uid=%s)"
+	defaultUsernameAttribute = "uid"
+	defaultCacheTTL          = 5 * time.Minute
+	defaultTimeout           = 10 * time.Second
+	membersPageSize          = 500
+)
+
+// Custom errors.
+var (
+	ErrMissingConfig = errors.New("url, base_dn and group_dn must be set")
+	errUserFilter    = errors.New("user_filter must contain exactly one %s placeholder")
+)
+
+// Config contains the configuration of LDAP client.
+type Config struct {
+	URL               string                `yaml:"url"`
+	StartTLS          bool                  `yaml:"start_tls"`
+	BindDN            string                `yaml:"bind_dn"`
+	BindPassword      config_util.Secret    `yaml:"bind_password"`
+	BindPasswordFile  string                `yaml:"bind_password_file"`
+	BaseDN            string                `yaml:"base_dn"`
+	UserFilter        string                `yaml:"user_filter"`
+	UsernameAttribute string                `yaml:"username_attribute"`
+	GroupDN           string                `yaml:"group_dn"`
+	SyncMembers       bool                  `yaml:"sync_members"`
+	CacheTTL          model.Duration        `yaml:"cache_ttl"`
+	TLSConfig         config_util.TLSConfig `yaml:"tls_config"`
+}
+
+// Enabled returns true when LDAP is configured.
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// SetDirectory joins any relative file paths with dir.
+func (c *Config) SetDirectory(dir string) {
+	c.BindPasswordFile = config_util.JoinDir(dir, c.BindPasswordFile)
+	c.TLSConfig.SetDirectory(dir)
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if c.BaseDN == "" || c.GroupDN == "" {
+		return ErrMissingConfig
+	}
+
+	if c.UserFilter != "" && strings.Count(c.UserFilter, "%s") != 1 {
+		return errUserFilter
+	}
+
+	if c.BindPassword != "" && c.BindPasswordFile != "" {
+		return errors.New("at most one of bind_password and bind_password_file must be set")
+	}
+
+	return c.TLSConfig.Validate()
+}
+
+// membership is the cached group membership of a user.
+type membership struct {
+	member  bool
+	expires time.Time
+}
+
+// Client resolves the membership of users in a LDAP group. Memberships are cached
+// for a configurable duration to avoid querying LDAP server for every request.
+type Client struct {
+	logger   *slog.Logger
+	config   Config
+	tls      *tls.Config
+	cacheTTL time.Duration
+	mu       sync.RWMutex
+	cache    map[string]membership
+
+	// search returns true when user is member of the group and list returns
+	// usernames of all members of the group. They are fields to be able to mock
+	// them in tests.
+	search func(ctx context.Context, user string) (bool, error)
+	list   func(ctx context.Context) ([]string, error)
+}
+
+// New returns a new instance of LDAP client.
+func New(c Config, logger *slog.Logger) (*Client, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !c.Enabled() {
+		return nil, ErrMissingConfig
+	}
+
+	tlsConfig, err := config_util.NewTLSConfig(&c.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LDAP TLS config: %w", err)
+	}
+
+	if c.UserFilter == "" {
+		c.UserFilter = defaultUserFilter
+	}
+
+	if c.UsernameAttribute == "" {
+		c.UsernameAttribute = defaultUsernameAttribute
+	}
+
+	cacheTTL := time.Duration(c.CacheTTL)
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+
+	client := &Client{
+		logger:   logger,
+		config:   c,
+		tls:      tlsConfig,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]membership),
+	}
+	client.search = client.searchMembership
+	client.list = client.searchMembers
+
+	return client, nil
+}
+
+// Members returns usernames of all members of the configured group. Unlike
+// IsMember, members are never cached.
+func (c *Client) Members(ctx context.Context) ([]string, error) {
+	return c.list(ctx)
+}
+
+// IsMember returns true if user is member of the configured group. Errors are
+// logged and user is considered as non member. Failed lookups are not cached.
+func (c *Client) IsMember(ctx context.Context, user string) bool {
+	if user == "" {
+		return false
+	}
+
+	c.mu.RLock()
+	m, ok := c.cache[user]
+	c.mu.RUnlock()
+
+	if ok && time.Now().Before(m.expires) {
+		return m.member
+	}
+
+	member, err := c.search(ctx, user)
+	if err != nil {
+		c.logger.Error("Failed to resolve LDAP group membership", "user", user, "group", c.config.GroupDN, "err", err)
+
+		return false
+	}
+
+	c.mu.Lock()
+	// Purge expired memberships to keep the cache bounded
+	for k, v := range c.cache {
+		if time.Now().After(v.expires) {
+			delete(c.cache, k)
+		}
+	}
+
+	c.cache[user] = membership{member: member, expires: time.Now().Add(c.cacheTTL)}
+	c.mu.Unlock()
+
+	return member
+}
+
+// filter returns the LDAP search filter that matches user only when it is
+// member of the group.
+func (c *Client) filter(user string) string {
+	return fmt.Sprintf(
+		"(&%s(memberOf=%s))",
+		fmt.Sprintf(c.config.UserFilter, ldap.EscapeFilter(user)),
+		ldap.EscapeFilter(c.config.GroupDN),
+	)
+}
+
+// membersFilter returns the LDAP search filter that matches all members of
+// the group.
+func (c *Client) membersFilter() string {
+	return fmt.Sprintf(
+		"(&%s(memberOf=%s))",
+		fmt.Sprintf(c.config.UserFilter, "*"),
+		ldap.EscapeFilter(c.config.GroupDN),
+	)
+}
+
+// connect returns a connection to LDAP server that is bound with configured
+// credentials along with the timeout of requests.
+func (c *Client) connect(ctx context.Context) (*ldap.Conn, time.Duration, error) {
+	timeout := defaultTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+
+	conn, err := ldap.DialURL(c.config.URL, ldap.DialWithTLSConfig(c.tls))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to connect to LDAP server: %w", err)
+	}
+
+	conn.SetTimeout(timeout)
+
+	if c.config.StartTLS {
+		if err := conn.StartTLS(c.tls); err != nil {
+			conn.Close()
+
+			return nil, 0, fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+
+	if c.config.BindDN != "" {
+		password, err := c.bindPassword()
+		if err != nil {
+			conn.Close()
+
+			return nil, 0, err
+		}
+
+		if err := conn.Bind(c.config.BindDN, password); err != nil {
+			conn.Close()
+
+			return nil, 0, fmt.Errorf("failed to bind to LDAP server: %w", err)
+		}
+	}
+
+	return conn, timeout, nil
+}
+
+// searchMembership searches LDAP server for user in the group.
+func (c *Client) searchMembership(ctx context.Context, user string) (bool, error) {
+	conn, timeout, err := c.connect(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	req := ldap.NewSearchRequest(
+		c.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		1, int(timeout.Seconds()), false, c.filter(user), []string{"dn"}, nil,
+	)
+
+	res, err := conn.Search(req)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return false, fmt.Errorf("failed to search LDAP server: %w", err)
+	}
+
+	return res != nil && len(res.Entries) > 0, nil
+}
+
+// searchMembers searches LDAP server for all members of the group. Results are
+// paged so that large groups are not truncated by size limits of server.
+func (c *Client) searchMembers(ctx context.Context) ([]string, error) {
+	conn, timeout, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	req := ldap.NewSearchRequest(
+		c.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(timeout.Seconds()), false, c.membersFilter(), []string{c.config.UsernameAttribute}, nil,
+	)
+
+	res, err := conn.SearchWithPaging(req, membersPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search LDAP server: %w", err)
+	}
+
+	members := make([]string, 0, len(res.Entries))
+
+	for _, entry := range res.Entries {
+		if user := entry.GetAttributeValue(c.config.UsernameAttribute); user != "" {
+			members = append(members, user)
+		}
+	}
+
+	return members, nil
+}
+
+// bindPassword returns the bind password. Password file is read for every bind
+// so that rotated passwords are taken into account without restarts.
+func (c *Client) bindPassword() (string, error) {
+	if c.config.BindPasswordFile == "" {
+		return string(c.config.BindPassword), nil
+	}
+
+	password, err := os.ReadFile(c.config.BindPasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read LDAP bind password file: %w", err)
+	}
+
+	return strings.TrimSpace(string(password)), nil
+}
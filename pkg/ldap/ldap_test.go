@@ -0,0 +1,146 @@
+package ldap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var noOpLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		valid  bool
+	}{
+		{name: "disabled", config: Config{}, valid: true},
+		{
+			name:   "valid",
+			config: Config{URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com", GroupDN: "cn=admins,dc=example,dc=com"},
+			valid:  true,
+		},
+		{
+			name:   "missing group",
+			config: Config{URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com"},
+		},
+		{
+			name: "invalid user filter",
+			config: Config{
+				URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com",
+				GroupDN: "cn=admins,dc=example,dc=com", UserFilter: "(uid=foo)",
+			},
+		},
+		{
+			name: "multiple passwords",
+			config: Config{
+				URL: "ldaps://ldap.example.com", BaseDN: "dc=example,dc=com", GroupDN: "cn=admins,dc=example,dc=com",
+				BindPassword: "secret", BindPasswordFile: "/etc/ceems/ldap",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if test.valid {
+			require.NoError(t, err, test.name)
+		} else {
+			require.Error(t, err, test.name)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	c, err := New(Config{
+		URL:        "ldaps://ldap.example.com",
+		BaseDN:     "dc=example,dc=com",
+		GroupDN:    "cn=hpc-admins,ou=groups,dc=example,dc=com",
+		UserFilter: "(sAMAccountName=%s)",
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		"(&(sAMAccountName=foo)(memberOf=cn=hpc-admins,ou=groups,dc=example,dc=com))",
+		c.filter("foo"),
+	)
+
+	// Special characters in user names must be escaped
+	assert.Equal(
+		t,
+		`(&(sAMAccountName=\2a\29\28uid=\2a)(memberOf=cn=hpc-admins,ou=groups,dc=example,dc=com))`,
+		c.filter("*)(uid=*"),
+	)
+}
+
+func TestMembersFilter(t *testing.T) {
+	c, err := New(Config{
+		URL:         "ldaps://ldap.example.com",
+		BaseDN:      "dc=example,dc=com",
+		GroupDN:     "cn=hpc-admins,ou=groups,dc=example,dc=com",
+		UserFilter:  "(sAMAccountName=%s)",
+		SyncMembers: true,
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	assert.Equal(t, "(&(sAMAccountName=*)(memberOf=cn=hpc-admins,ou=groups,dc=example,dc=com))", c.membersFilter())
+	assert.Equal(t, defaultUsernameAttribute, c.config.UsernameAttribute)
+}
+
+func TestIsMember(t *testing.T) {
+	c, err := New(Config{
+		URL:      "ldaps://ldap.example.com",
+		BaseDN:   "dc=example,dc=com",
+		GroupDN:  "cn=hpc-admins,ou=groups,dc=example,dc=com",
+		CacheTTL: model.Duration(time.Hour),
+	}, noOpLogger)
+	require.NoError(t, err)
+
+	// Mock LDAP search
+	searches := make(map[string]int)
+	members := map[string]bool{"foo": true, "bar": false}
+	failing := true
+
+	c.search = func(_ context.Context, user string) (bool, error) {
+		searches[user]++
+
+		if user == "baz" && failing {
+			return false, errors.New("ldap server unavailable")
+		}
+
+		return members[user], nil
+	}
+
+	assert.True(t, c.IsMember(context.Background(), "foo"))
+	assert.False(t, c.IsMember(context.Background(), "bar"))
+	assert.False(t, c.IsMember(context.Background(), ""))
+
+	// Memberships must be cached
+	assert.True(t, c.IsMember(context.Background(), "foo"))
+	assert.False(t, c.IsMember(context.Background(), "bar"))
+	assert.Equal(t, map[string]int{"foo": 1, "bar": 1}, searches)
+
+	// Failed lookups must not be cached
+	members["baz"] = true
+
+	assert.False(t, c.IsMember(context.Background(), "baz"))
+
+	failing = false
+
+	assert.True(t, c.IsMember(context.Background(), "baz"))
+	assert.Equal(t, 2, searches["baz"])
+
+	// Expired memberships must be searched again
+	c.cache["foo"] = membership{member: true, expires: time.Now().Add(-time.Second)}
+	members["foo"] = false
+
+	assert.False(t, c.IsMember(context.Background(), "foo"))
+	assert.Equal(t, 2, searches["foo"])
+}
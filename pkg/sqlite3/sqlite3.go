@@ -20,6 +20,9 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/mahendrapaipuri/ceems/pkg/api/models"
@@ -57,6 +60,10 @@ const (
 	DriverName = "ceems_sqlite3"
 )
 
+// mmapSizeParam is the DSN parameter to set the size of memory mapped I/O of
+// connections as it is not supported by the upstream driver.
+const mmapSizeParam = "_mmap_size"
+
 var (
 	seq   uint64
 	mu    sync.Mutex
@@ -79,6 +86,11 @@ func (d *Driver) Open(dsn string) (driver.Conn, error) {
 
 	var err error
 
+	dsn, mmapSize, err := parseMmapSize(dsn)
+	if err != nil {
+		return nil, err
+	}
+
 	if inner, err = d.SQLiteDriver.Open(dsn); err != nil {
 		return nil, err
 	}
@@ -92,6 +104,14 @@ func (d *Driver) Open(dsn string) (driver.Conn, error) {
 		return nil, fmt.Errorf("unknown connection type %T", inner)
 	}
 
+	if mmapSize > 0 {
+		if _, err := sconn.Exec(fmt.Sprintf("PRAGMA mmap_size = %d", mmapSize), nil); err != nil {
+			sconn.Close()
+
+			return nil, fmt.Errorf("failed to set mmap_size: %w", err)
+		}
+	}
+
 	mu.Lock()
 	seq++
 	conn := &Conn{cid: seq, SQLiteConn: sconn}
@@ -101,6 +121,33 @@ func (d *Driver) Open(dsn string) (driver.Conn, error) {
 	return conn, nil
 }
 
+// parseMmapSize returns DSN without mmap size parameter along with its value.
+func parseMmapSize(dsn string) (string, int64, error) {
+	pos := strings.IndexRune(dsn, '?')
+	if pos < 0 {
+		return dsn, 0, nil
+	}
+
+	params, err := url.ParseQuery(dsn[pos+1:])
+	if err != nil {
+		return "", 0, err
+	}
+
+	val := params.Get(mmapSizeParam)
+	if val == "" {
+		return dsn, 0, nil
+	}
+
+	mmapSize, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid %s: %w", mmapSizeParam, err)
+	}
+
+	params.Del(mmapSizeParam)
+
+	return dsn[:pos] + "?" + params.Encode(), mmapSize, nil
+}
+
 // Conn wraps a sqlite3.SQLiteConn and maintains an ID so that the connection can be
 // closed.
 type Conn struct {
@@ -37,6 +37,22 @@ func TestDriver(t *testing.T) {
 	require.Equal(t, 0, NumConns())
 }
 
+func TestParseMmapSize(t *testing.T) {
+	dsn, mmapSize, err := parseMmapSize("file:test.db?_busy_timeout=5000&_mmap_size=1048576")
+	require.NoError(t, err)
+	assert.Equal(t, "file:test.db?_busy_timeout=5000", dsn)
+	assert.Equal(t, int64(1048576), mmapSize)
+
+	// DSN without parameter is returned as it is
+	dsn, mmapSize, err = parseMmapSize("file:test.db")
+	require.NoError(t, err)
+	assert.Equal(t, "file:test.db", dsn)
+	assert.Zero(t, mmapSize)
+
+	_, _, err = parseMmapSize("file:test.db?_mmap_size=foo")
+	require.Error(t, err)
+}
+
 func TestOpenMany(t *testing.T) {
 	tmpdir := t.TempDir()
 	expectedConnections := 12
@@ -0,0 +1,325 @@
+// Package redfishtest provides a mock Redfish BMC server with vendor flavoured
+// payloads for testing CEEMS exporter and its configurations offline.
+package redfishtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Vendor is the vendor of the mocked BMC.
+type Vendor string
+
+// Supported vendors.
+const (
+	Generic    Vendor = "generic"
+	Dell       Vendor = "dell"
+	HPE        Vendor = "hpe"
+	Lenovo     Vendor = "lenovo"
+	Supermicro Vendor = "supermicro"
+)
+
+// vendorProps contains the properties that differ between vendors.
+type vendorProps struct {
+	manufacturer string
+	chassisID    string
+	oemKey       string
+}
+
+var vendors = map[Vendor]vendorProps{
+	Generic:    {manufacturer: "Redfish Computers", chassisID: "Chassis-1"},
+	Dell:       {manufacturer: "Dell Inc.", chassisID: "System.Embedded.1", oemKey: "Dell"},
+	HPE:        {manufacturer: "HPE", chassisID: "1", oemKey: "Hpe"},
+	Lenovo:     {manufacturer: "Lenovo", chassisID: "1", oemKey: "Lenovo"},
+	Supermicro: {manufacturer: "Supermicro", chassisID: "1", oemKey: "Supermicro"},
+}
+
+// Options are the options of mock server.
+type Options struct {
+	// Vendor of the BMC. Defaults to Generic.
+	Vendor Vendor
+
+	// Username and Password that are accepted to create sessions and in basic
+	// auth. When empty, any credentials are accepted.
+	Username string
+	Password string
+
+	// PowerWatts are the readings of power controls of the chassis.
+	// Defaults to a single power control with 300 W reading.
+	PowerWatts []float64
+
+	// TLS starts the server with TLS when true.
+	TLS bool
+}
+
+// Server is a mock Redfish BMC server.
+type Server struct {
+	*httptest.Server
+
+	vendor   Vendor
+	props    vendorProps
+	username string
+	password string
+
+	mu       sync.Mutex
+	power    []float64
+	sessions map[string]string // Token to session ID
+}
+
+// NewServer starts and returns a new mock Redfish server. Caller must call
+// Close when done.
+func NewServer(opts Options) *Server {
+	if opts.Vendor == "" {
+		opts.Vendor = Generic
+	}
+
+	props, ok := vendors[opts.Vendor]
+	if !ok {
+		props = vendors[Generic]
+	}
+
+	if len(opts.PowerWatts) == 0 {
+		opts.PowerWatts = []float64{300}
+	}
+
+	s := &Server{
+		vendor:   opts.Vendor,
+		props:    props,
+		username: opts.Username,
+		password: opts.Password,
+		power:    opts.PowerWatts,
+		sessions: make(map[string]string),
+	}
+
+	if opts.TLS {
+		s.Server = httptest.NewTLSServer(http.HandlerFunc(s.handle))
+	} else {
+		s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	}
+
+	return s
+}
+
+// ChassisID returns the ID of the chassis of the mocked BMC.
+func (s *Server) ChassisID() string {
+	return s.props.chassisID
+}
+
+// SetPower sets the readings of power controls of the chassis.
+func (s *Server) SetPower(watts ...float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.power = watts
+}
+
+// Sessions returns the number of active sessions.
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sessions)
+}
+
+// handle serves all the requests.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	chassisPath := "/redfish/v1/Chassis/" + s.props.chassisID
+	sessionsPath := "/redfish/v1/SessionService/Sessions"
+
+	switch {
+	case path == "/redfish/v1" || path == "/redfish":
+		s.write(w, s.serviceRoot())
+	case (path == sessionsPath || path == "/redfish/v1/Sessions") && r.Method == http.MethodPost:
+		s.createSession(w, r)
+	case strings.HasPrefix(path, sessionsPath+"/") && r.Method == http.MethodDelete:
+		s.deleteSession(w, r, strings.TrimPrefix(path, sessionsPath+"/"))
+	case !s.authorized(r):
+		w.WriteHeader(http.StatusUnauthorized)
+	case path == "/redfish/v1/Chassis":
+		s.write(w, map[string]interface{}{
+			"@odata.id":           "/redfish/v1/Chassis",
+			"@odata.type":         "#ChassisCollection.ChassisCollection",
+			"Name":                "Chassis Collection",
+			"Members@odata.count": 1,
+			"Members":             []interface{}{map[string]string{"@odata.id": chassisPath}},
+		})
+	case path == chassisPath:
+		s.write(w, s.chassis(chassisPath))
+	case path == chassisPath+"/Power":
+		s.write(w, s.powerPayload(chassisPath))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// authorized returns true if request has a valid session token or basic auth.
+func (s *Server) authorized(r *http.Request) bool {
+	if token := r.Header.Get("X-Auth-Token"); token != "" {
+		s.mu.Lock()
+		_, ok := s.sessions[token]
+		s.mu.Unlock()
+
+		return ok
+	}
+
+	if user, pass, ok := r.BasicAuth(); ok {
+		return s.validCredentials(user, pass)
+	}
+
+	return false
+}
+
+// validCredentials returns true if credentials are accepted.
+func (s *Server) validCredentials(user, pass string) bool {
+	return s.username == "" || (user == s.username && pass == s.password)
+}
+
+// createSession creates a new session and returns its token.
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	var creds struct {
+		UserName string `json:"UserName"`
+		Password string `json:"Password"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil || !s.validCredentials(creds.UserName, creds.Password) {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	token := hex.EncodeToString(b)
+	id := token[:8]
+
+	s.mu.Lock()
+	s.sessions[token] = id
+	s.mu.Unlock()
+
+	location := "/redfish/v1/SessionService/Sessions/" + id
+
+	w.Header().Set("X-Auth-Token", token)
+	w.Header().Set("Location", location)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(map[string]string{"@odata.id": location, "Id": id, "UserName": creds.UserName}); err != nil {
+		w.Write([]byte("KO"))
+	}
+}
+
+// deleteSession deletes the session with id.
+func (s *Server) deleteSession(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for token, sid := range s.sessions {
+		if sid == id && token == r.Header.Get("X-Auth-Token") {
+			delete(s.sessions, token)
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// serviceRoot returns the service root payload.
+func (s *Server) serviceRoot() map[string]interface{} {
+	return map[string]interface{}{
+		"@odata.id":      "/redfish/v1",
+		"@odata.type":    "#ServiceRoot.v1_5_0.ServiceRoot",
+		"Id":             "RootService",
+		"Name":           "Root Service",
+		"RedfishVersion": "1.6.0",
+		"Vendor":         s.props.manufacturer,
+		"Chassis":        map[string]string{"@odata.id": "/redfish/v1/Chassis"},
+		"SessionService": map[string]string{"@odata.id": "/redfish/v1/SessionService"},
+		"Links": map[string]interface{}{
+			"Sessions": map[string]string{"@odata.id": "/redfish/v1/SessionService/Sessions"},
+		},
+		"Oem": s.oem(),
+	}
+}
+
+// chassis returns the chassis payload.
+func (s *Server) chassis(path string) map[string]interface{} {
+	return map[string]interface{}{
+		"@odata.id":    path,
+		"@odata.type":  "#Chassis.v1_10_0.Chassis",
+		"Id":           s.props.chassisID,
+		"Name":         "Computer System Chassis",
+		"ChassisType":  "RackMount",
+		"Manufacturer": s.props.manufacturer,
+		"Status":       map[string]string{"State": "Enabled", "Health": "OK"},
+		"Power":        map[string]string{"@odata.id": path + "/Power"},
+		"Oem":          s.oem(),
+	}
+}
+
+// powerPayload returns the power payload of chassis.
+func (s *Server) powerPayload(path string) map[string]interface{} {
+	s.mu.Lock()
+	power := append([]float64(nil), s.power...)
+	s.mu.Unlock()
+
+	controls := make([]interface{}, 0, len(power))
+
+	for i, watts := range power {
+		controls = append(controls, map[string]interface{}{
+			"@odata.id":          fmt.Sprintf("%s/Power#/PowerControl/%d", path, i),
+			"MemberId":           fmt.Sprint(i),
+			"Name":               "System Power Control",
+			"PowerConsumedWatts": watts,
+			"PowerMetrics": map[string]interface{}{
+				"IntervalInMin":        1,
+				"MinConsumedWatts":     watts,
+				"MaxConsumedWatts":     watts,
+				"AverageConsumedWatts": watts,
+			},
+			"Status": map[string]string{"State": "Enabled", "Health": "OK"},
+		})
+	}
+
+	return map[string]interface{}{
+		"@odata.id":    path + "/Power",
+		"@odata.type":  "#Power.v1_5_0.Power",
+		"Id":           "Power",
+		"Name":         "Power",
+		"PowerControl": controls,
+		"Oem":          s.oem(),
+	}
+}
+
+// oem returns the vendor specific OEM section.
+func (s *Server) oem() map[string]interface{} {
+	if s.props.oemKey == "" {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		s.props.oemKey: map[string]string{"@odata.type": fmt.Sprintf("#%s.v1_0_0.%s", s.props.oemKey, s.props.oemKey)},
+	}
+}
+
+// write writes payload as JSON.
+func (s *Server) write(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		w.Write([]byte("KO"))
+	}
+}
@@ -0,0 +1,53 @@
+package redfishtest
+
+import (
+	"testing"
+
+	"github.com/stmcginnis/gofish"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	for _, vendor := range []Vendor{Generic, Dell, HPE, Lenovo, Supermicro} {
+		server := NewServer(Options{Vendor: vendor, Username: "admin", Password: "secret", PowerWatts: []float64{250, 50}})
+
+		client, err := gofish.Connect(gofish.ClientConfig{Endpoint: server.URL, Username: "admin", Password: "secret"})
+		require.NoError(t, err, vendor)
+		assert.Equal(t, 1, server.Sessions(), vendor)
+
+		chassis, err := client.Service.Chassis()
+		require.NoError(t, err, vendor)
+		require.Len(t, chassis, 1, vendor)
+		assert.Equal(t, server.ChassisID(), chassis[0].ID, vendor)
+		assert.Equal(t, vendors[vendor].manufacturer, chassis[0].Manufacturer, vendor)
+
+		power, err := chassis[0].Power()
+		require.NoError(t, err, vendor)
+		require.Len(t, power.PowerControl, 2, vendor)
+		assert.InEpsilon(t, 250, power.PowerControl[0].PowerConsumedWatts, 0, vendor)
+
+		// Updated readings must be returned
+		server.SetPower(400)
+
+		power, err = chassis[0].Power()
+		require.NoError(t, err, vendor)
+		require.Len(t, power.PowerControl, 1, vendor)
+		assert.InEpsilon(t, 400, power.PowerControl[0].PowerConsumedWatts, 0, vendor)
+
+		// Logout must delete the session
+		client.Logout()
+		assert.Equal(t, 0, server.Sessions(), vendor)
+
+		server.Close()
+	}
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	server := NewServer(Options{Username: "admin", Password: "secret"})
+	defer server.Close()
+
+	_, err := gofish.Connect(gofish.ClientConfig{Endpoint: server.URL, Username: "admin", Password: "wrong"})
+	require.Error(t, err)
+	assert.Equal(t, 0, server.Sessions())
+}
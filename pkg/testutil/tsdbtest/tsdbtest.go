@@ -0,0 +1,317 @@
+// Package tsdbtest provides a mock Prometheus compatible TSDB server for testing
+// CEEMS components and their configurations offline.
+package tsdbtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default settings returned by the status end points.
+const (
+	DefaultScrapeInterval     = 15 * time.Second
+	DefaultEvaluationInterval = 15 * time.Second
+)
+
+// Sample is an instant vector sample.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Point is a value of range vector at a given time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a range vector series.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// response is the API response of TSDB.
+type response struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// result is the data of query response.
+type result struct {
+	ResultType string        `json:"resultType"`
+	Result     []interface{} `json:"result"`
+}
+
+// rule is a registered query response.
+type rule struct {
+	expr    *regexp.Regexp
+	isRange bool
+	vector  []Sample
+	matrix  []Series
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Path  string
+	Query string
+}
+
+// Server is a mock TSDB server. Responses of queries are registered using
+// SetVector and SetMatrix. Queries that do not match any registered expression
+// return an empty result.
+type Server struct {
+	*httptest.Server
+
+	mu                 sync.Mutex
+	rules              []rule
+	requests           []Request
+	deleted            [][]string
+	scrapeInterval     time.Duration
+	evaluationInterval time.Duration
+	flags              map[string]interface{}
+}
+
+// NewServer starts and returns a new mock TSDB server. Caller must call Close
+// when done.
+func NewServer() *Server {
+	s := &Server{
+		scrapeInterval:     DefaultScrapeInterval,
+		evaluationInterval: DefaultEvaluationInterval,
+		flags:              make(map[string]interface{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", s.query)
+	mux.HandleFunc("/api/v1/query_range", s.queryRange)
+	mux.HandleFunc("/api/v1/status/config", s.config)
+	mux.HandleFunc("/api/v1/status/flags", s.flagsHandler)
+	mux.HandleFunc("/api/v1/admin/tsdb/delete_series", s.deleteSeries)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// SetVector registers samples returned by instant queries that match the
+// regular expression expr. Expressions are evaluated in the order they are
+// registered and the first match wins.
+func (s *Server) SetVector(expr string, samples ...Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules = append(s.rules, rule{expr: regexp.MustCompile(expr), vector: samples})
+}
+
+// SetMatrix registers series returned by range queries that match the
+// regular expression expr. Expressions are evaluated in the order they are
+// registered and the first match wins.
+func (s *Server) SetMatrix(expr string, series ...Series) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rules = append(s.rules, rule{expr: regexp.MustCompile(expr), isRange: true, matrix: series})
+}
+
+// SetIntervals sets the global scrape and evaluation intervals returned by
+// config end point.
+func (s *Server) SetIntervals(scrape, evaluation time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scrapeInterval = scrape
+	s.evaluationInterval = evaluation
+}
+
+// SetFlag sets the CLI flag returned by flags end point.
+func (s *Server) SetFlag(name string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flags[name] = value
+}
+
+// Requests returns the query requests received by the server.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Deleted returns the matchers of delete series requests received by the server.
+func (s *Server) Deleted() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]string(nil), s.deleted...)
+}
+
+// match returns the first rule that matches query.
+func (s *Server) match(path, query string, isRange bool) (rule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Path: path, Query: query})
+
+	for _, r := range s.rules {
+		if r.isRange == isRange && r.expr.MatchString(query) {
+			return r, true
+		}
+	}
+
+	return rule{}, false
+}
+
+// query handles instant queries.
+func (s *Server) query(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	ts := time.Now()
+	if t := r.Form.Get("time"); t != "" {
+		var err error
+		if ts, err = parseTime(t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+
+			return
+		}
+	}
+
+	res := result{ResultType: "vector", Result: []interface{}{}}
+
+	if rule, ok := s.match(r.URL.Path, r.Form.Get("query"), false); ok {
+		for _, sample := range rule.vector {
+			res.Result = append(res.Result, map[string]interface{}{
+				"metric": labels(sample.Labels),
+				"value":  []interface{}{float64(ts.UnixMilli()) / 1000, formatValue(sample.Value)},
+			})
+		}
+	}
+
+	writeData(w, res)
+}
+
+// queryRange handles range queries.
+func (s *Server) queryRange(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	res := result{ResultType: "matrix", Result: []interface{}{}}
+
+	if rule, ok := s.match(r.URL.Path, r.Form.Get("query"), true); ok {
+		for _, series := range rule.matrix {
+			values := make([]interface{}, 0, len(series.Points))
+			for _, p := range series.Points {
+				values = append(values, []interface{}{float64(p.Time.UnixMilli()) / 1000, formatValue(p.Value)})
+			}
+
+			res.Result = append(res.Result, map[string]interface{}{
+				"metric": labels(series.Labels),
+				"values": values,
+			})
+		}
+	}
+
+	writeData(w, res)
+}
+
+// config handles config end point.
+func (s *Server) config(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	yaml := fmt.Sprintf(
+		"global:\n  scrape_interval: %s\n  evaluation_interval: %s\n",
+		s.scrapeInterval, s.evaluationInterval,
+	)
+	s.mu.Unlock()
+
+	writeData(w, map[string]string{"yaml": yaml})
+}
+
+// flagsHandler handles flags end point.
+func (s *Server) flagsHandler(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	flags := make(map[string]interface{}, len(s.flags))
+	for k, v := range s.flags {
+		flags[k] = v
+	}
+	s.mu.Unlock()
+
+	writeData(w, flags)
+}
+
+// deleteSeries handles delete series end point.
+func (s *Server) deleteSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.deleted = append(s.deleted, r.Form["match[]"])
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// labels returns a copy of labels that is never nil.
+func labels(l map[string]string) map[string]string {
+	m := make(map[string]string, len(l))
+	for k, v := range l {
+		m[k] = v
+	}
+
+	return m
+}
+
+// formatValue formats sample value the same way as Prometheus.
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// parseTime parses time stamps in either RFC3339 or Unix format.
+func parseTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.UnixMilli(int64(t * 1000)), nil
+	}
+
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// writeData writes a successful response.
+func writeData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response{Status: "success", Data: data}); err != nil {
+		w.Write([]byte("KO"))
+	}
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(response{Status: "error", ErrorType: "bad_data", Error: err.Error()}); err != nil {
+		w.Write([]byte("KO"))
+	}
+}
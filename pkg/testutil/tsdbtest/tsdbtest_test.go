@@ -0,0 +1,78 @@
+package tsdbtest_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mahendrapaipuri/ceems/pkg/testutil/tsdbtest"
+	"github.com/mahendrapaipuri/ceems/pkg/tsdb"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	server := tsdbtest.NewServer()
+	defer server.Close()
+
+	server.SetVector(`^unit:cpu_usage`,
+		tsdbtest.Sample{Labels: map[string]string{"uuid": "1"}, Value: 1.5},
+		tsdbtest.Sample{Labels: map[string]string{"uuid": "2"}, Value: 2},
+	)
+
+	now := time.Now()
+	server.SetMatrix(`^unit:mem_usage`,
+		tsdbtest.Series{
+			Labels: map[string]string{"uuid": "1"},
+			Points: []tsdbtest.Point{{Time: now.Add(-time.Minute), Value: 10}, {Time: now, Value: 20}},
+		},
+	)
+	server.SetIntervals(30*time.Second, time.Minute)
+	server.SetFlag("query.max-samples", 1000)
+
+	client, err := tsdb.New(server.URL, config_util.HTTPClientConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Instant queries
+	metric, err := client.QueryByLabel(ctx, "unit:cpu_usage{uuid=~\"1|2\"}", now, "uuid")
+	require.NoError(t, err)
+	assert.Equal(t, tsdb.Metric{"1": 1.5, "2": 2}, metric)
+
+	// Unregistered queries return empty results
+	metric, err = client.QueryByLabel(ctx, "unit:gpu_usage", now, "uuid")
+	require.NoError(t, err)
+	assert.Empty(t, metric)
+
+	// Range queries
+	rangeMetric, err := client.RangeQueryByLabel(ctx, "unit:mem_usage", now.Add(-time.Minute), now, "1m", "uuid")
+	require.NoError(t, err)
+	assert.Len(t, rangeMetric["1"], 2)
+
+	// Instant queries must not match range rules
+	metric, err = client.QueryByLabel(ctx, "unit:mem_usage", now, "uuid")
+	require.NoError(t, err)
+	assert.Empty(t, metric)
+
+	// Settings
+	settings := client.Settings(ctx)
+	require.NotNil(t, settings)
+	assert.Equal(t, 30*time.Second, settings.ScrapeInterval)
+	assert.Equal(t, time.Minute, settings.EvaluationInterval)
+	assert.Equal(t, uint64(1000), settings.QueryMaxSamples)
+
+	// Delete series
+	require.NoError(t, client.Delete(ctx, now.Add(-time.Hour), now, []string{`{uuid="1"}`}))
+	assert.Equal(t, [][]string{{`{uuid="1"}`}}, server.Deleted())
+
+	// Received requests
+	requests := server.Requests()
+	require.Len(t, requests, 4)
+	assert.Equal(t, "/api/v1/query_range", requests[2].Path)
+	assert.Equal(t, "unit:mem_usage", requests[2].Query)
+}
@@ -313,8 +313,14 @@ func (t *TSDB) Flags(ctx context.Context) (map[string]interface{}, error) {
 	return flagsData, nil
 }
 
-// Query makes a TSDB query.
+// Query makes a TSDB query and returns the values keyed by uuid label.
 func (t *TSDB) Query(ctx context.Context, query string, queryTime time.Time) (Metric, error) {
+	return t.QueryByLabel(ctx, query, queryTime, "uuid")
+}
+
+// QueryByLabel makes a TSDB query and returns the values keyed by the value
+// of label.
+func (t *TSDB) QueryByLabel(ctx context.Context, query string, queryTime time.Time, label string) (Metric, error) {
 	// Add form data to request
 	// TSDB expects time stamps in UTC zone
 	values := url.Values{
@@ -402,7 +408,7 @@ func (t *TSDB) Query(ctx context.Context, query string, queryTime time.Time) (Me
 					continue
 				}
 
-				if id, exists := metric["uuid"]; exists {
+				if id, exists := metric[label]; exists {
 					if v, ok := id.(string); ok {
 						uuid = v
 					}
@@ -439,6 +445,19 @@ func (t *TSDB) RangeQuery(
 	startTime time.Time,
 	endTime time.Time,
 	step string,
+) (RangeMetric, error) {
+	return t.RangeQueryByLabel(ctx, query, startTime, endTime, step, "__name__")
+}
+
+// RangeQueryByLabel makes a TSDB range query and returns the values keyed by
+// the value of label.
+func (t *TSDB) RangeQueryByLabel(
+	ctx context.Context,
+	query string,
+	startTime time.Time,
+	endTime time.Time,
+	step string,
+	label string,
 ) (RangeMetric, error) {
 	// Add form data to request
 	// TSDB expects time stamps in UTC zone
@@ -530,7 +549,7 @@ func (t *TSDB) RangeQuery(
 					continue
 				}
 
-				if name, ok = metric["__name__"]; !ok {
+				if name, ok = metric[label]; !ok {
 					continue
 				}
 
@@ -240,7 +240,8 @@ func TestTSDBQuerySuccess(t *testing.T) {
 			"result": []interface{}{
 				map[string]interface{}{
 					"metric": map[string]string{
-						"uuid": "1",
+						"uuid":     "1",
+						"hostname": "compute-0",
 					},
 					"value": []interface{}{
 						12345, "1.1",
@@ -248,7 +249,8 @@ func TestTSDBQuerySuccess(t *testing.T) {
 				},
 				map[string]interface{}{
 					"metric": map[string]string{
-						"uuid": "2",
+						"uuid":     "2",
+						"hostname": "compute-1",
 					},
 					"value": []interface{}{
 						12345, "2.2",
@@ -272,6 +274,10 @@ func TestTSDBQuerySuccess(t *testing.T) {
 	m, err := tsdb.Query(context.Background(), "", time.Now())
 	require.NoError(t, err)
 	assert.Equal(t, Metric{"1": 1.1, "2": 2.2}, m)
+
+	m, err = tsdb.QueryByLabel(context.Background(), "", time.Now(), "hostname")
+	require.NoError(t, err)
+	assert.Equal(t, Metric{"compute-0": 1.1, "compute-1": 2.2}, m)
 }
 
 func TestTSDBQueryFail(t *testing.T) {
@@ -336,6 +342,11 @@ func TestTSDBQueryRangeSuccess(t *testing.T) {
 		},
 		m,
 	)
+
+	// Values keyed by instance label
+	m, err = tsdb.RangeQueryByLabel(context.Background(), "", time.Now(), time.Now(), "300", "instance")
+	require.NoError(t, err)
+	assert.Contains(t, m, "localhost:9090")
 }
 
 func TestTSDBQueryRangeFail(t *testing.T) {
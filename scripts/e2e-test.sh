@@ -7,7 +7,7 @@ cd "$(dirname $0)/.."
 port="$((10000 + (RANDOM % 10000)))"
 tmpdir=$(mktemp -d /tmp/ceems_e2e_test.XXXXXX)
 
-skip_re="^(go_|ceems_exporter_build_info|ceems_scrape_collector_duration_seconds|process_|ceems_textfile_mtime_seconds|ceems_time_(zone|seconds)|ceems_network_(receive|transmit)_(bytes|packets)_total)"
+skip_re="^(go_|ceems_exporter_build_info|ceems_scrape_(sub)?collector_duration_seconds|process_|ceems_textfile_mtime_seconds|ceems_time_(zone|seconds)|ceems_network_(receive|transmit)_(bytes|packets)_total)"
 
 arch="$(uname -m)"
 